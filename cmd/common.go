@@ -19,6 +19,15 @@ func Init() {
 	bootstrap.InitIndex()
 }
 
+// InitClient inits the commands managing the instance, it doesn't open the
+// search index that is locked by the running server
+func InitClient() {
+	bootstrap.InitConfig()
+	bootstrap.Log()
+	bootstrap.InitDB()
+	data.InitData()
+}
+
 var pid = -1
 var pidFile string
 
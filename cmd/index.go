@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the search index of the running server",
+}
+
+func init() {
+	var rebuild = &cobra.Command{
+		Use:   "rebuild",
+		Short: "Clear and build the search index again",
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			if err := adminRequest("POST", "/api/admin/index/build", nil, nil, nil); err != nil {
+				utils.Log.Errorf("failed to rebuild index: %+v", err)
+			} else {
+				utils.Log.Infof("Index is rebuilding in the server")
+			}
+		},
+	}
+	RootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(rebuild)
+}
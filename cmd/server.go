@@ -12,9 +12,16 @@ import (
 	"github.com/alist-org/alist/v3/cmd/flags"
 	_ "github.com/alist-org/alist/v3/drivers"
 	"github.com/alist-org/alist/v3/internal/bootstrap"
+	"github.com/alist-org/alist/v3/internal/cluster"
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/plugin"
+	"github.com/alist-org/alist/v3/internal/syncjob"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server"
+	"github.com/alist-org/alist/v3/server/ftp"
+	"github.com/alist-org/alist/v3/server/sftp"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -28,9 +35,18 @@ var ServerCmd = &cobra.Command{
 the address is defined in config file`,
 	Run: func(cmd *cobra.Command, args []string) {
 		Init()
+		bootstrap.InitTracing()
 		bootstrap.InitAria2()
 		bootstrap.InitQbittorrent()
+		bootstrap.InitEvents()
+		bootstrap.InitAudit()
+		bootstrap.InitCache()
+		bootstrap.InitCluster()
+		bootstrap.InitPlugins()
 		bootstrap.LoadStorages()
+		bootstrap.InitStorageHealth()
+		bootstrap.InitTrash()
+		bootstrap.InitTus()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
@@ -52,6 +68,80 @@ the address is defined in config file`,
 				utils.Log.Fatalf("failed to start: %s", err.Error())
 			}
 		}()
+		var s3Srv *http.Server
+		if conf.Conf.S3.Enable {
+			s3r := gin.New()
+			s3r.Use(gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
+			server.InitS3(s3r)
+			s3Base := fmt.Sprintf("%s:%d", conf.Conf.Address, conf.Conf.S3.Port)
+			utils.Log.Infof("start s3 server @ %s", s3Base)
+			s3Srv = &http.Server{Addr: s3Base, Handler: s3r}
+			go func() {
+				var err error
+				if conf.Conf.S3.SSL {
+					err = s3Srv.ListenAndServeTLS(conf.Conf.Scheme.CertFile, conf.Conf.Scheme.KeyFile)
+				} else {
+					err = s3Srv.ListenAndServe()
+				}
+				if err != nil && err != http.ErrServerClosed {
+					utils.Log.Fatalf("failed to start s3 server: %s", err.Error())
+				}
+			}()
+		}
+		var ftpSrv *ftp.Server
+		if conf.Conf.FTP.Enable {
+			var err error
+			ftpSrv, err = server.NewFTP()
+			if err != nil {
+				utils.Log.Fatalf("failed to init ftp server: %+v", err)
+			}
+			utils.Log.Infof("start ftp server @ %s", ftpSrv.Addr)
+			go func() {
+				err := ftpSrv.ListenAndServe()
+				if err != nil && err != ftp.ErrServerClosed {
+					utils.Log.Fatalf("failed to start ftp server: %s", err.Error())
+				}
+			}()
+		}
+		var sftpSrv *sftp.Server
+		if conf.Conf.SFTP.Enable {
+			var err error
+			sftpSrv, err = server.NewSFTP()
+			if err != nil {
+				utils.Log.Fatalf("failed to init sftp server: %+v", err)
+			}
+			utils.Log.Infof("start sftp server @ %s", sftpSrv.Addr)
+			go func() {
+				err := sftpSrv.ListenAndServe()
+				if err != nil && err != sftp.ErrServerClosed {
+					utils.Log.Fatalf("failed to start sftp server: %s", err.Error())
+				}
+			}()
+		}
+		var sockSrv *http.Server
+		if conf.Conf.AdminSocket != "" {
+			listener, err := listenAdminSocket(conf.Conf.AdminSocket)
+			if err != nil {
+				utils.Log.Errorf("failed to listen admin socket: %+v", err)
+			} else {
+				utils.Log.Infof("start admin socket @ %s", conf.Conf.AdminSocket)
+				sockSrv = &http.Server{Handler: r}
+				go func() {
+					err := sockSrv.Serve(listener)
+					if err != nil && err != http.ErrServerClosed {
+						utils.Log.Errorf("failed to serve admin socket: %s", err.Error())
+					}
+				}()
+			}
+		}
+		// reload the settings and the storages on SIGHUP
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				reload()
+			}
+		}()
 		// Wait for interrupt signal to gracefully shutdown the server with
 		// a timeout of 5 seconds.
 		quit := make(chan os.Signal)
@@ -67,6 +157,37 @@ the address is defined in config file`,
 		if err := srv.Shutdown(ctx); err != nil {
 			utils.Log.Fatal("Server Shutdown:", err)
 		}
+		if sockSrv != nil {
+			if err := sockSrv.Shutdown(ctx); err != nil {
+				utils.Log.Fatal("Admin Socket Shutdown:", err)
+			}
+		}
+		if s3Srv != nil {
+			if err := s3Srv.Shutdown(ctx); err != nil {
+				utils.Log.Fatal("S3 Server Shutdown:", err)
+			}
+		}
+		if ftpSrv != nil {
+			if err := ftpSrv.Close(); err != nil {
+				utils.Log.Fatal("FTP Server Shutdown:", err)
+			}
+		}
+		if sftpSrv != nil {
+			if err := sftpSrv.Close(); err != nil {
+				utils.Log.Fatal("SFTP Server Shutdown:", err)
+			}
+		}
+		syncjob.Stop()
+		if err := cluster.Close(); err != nil {
+			utils.Log.Errorf("failed to leave the cluster: %+v", err)
+		}
+		if err := op.CloseListCache(); err != nil {
+			utils.Log.Errorf("failed to close listing cache: %+v", err)
+		}
+		plugin.Close()
+		if err := tracing.Shutdown(ctx); err != nil {
+			utils.Log.Errorf("failed to export spans left: %+v", err)
+		}
 		// catching ctx.Done(). timeout of 3 seconds.
 		select {
 		case <-ctx.Done():
@@ -90,6 +211,23 @@ func init() {
 	// serverCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
+// reload applies the settings and the storages changed in the database, the
+// untouched storages are kept
+func reload() {
+	utils.Log.Infof("reloading settings and storages")
+	if err := op.ReloadSettings(); err != nil {
+		utils.Log.Errorf("failed to reload settings: %+v", err)
+	}
+	res, err := op.ReloadStorages(context.Background())
+	if err != nil {
+		utils.Log.Errorf("failed to reload storages: %+v", err)
+	}
+	if res != nil {
+		utils.Log.Infof("storages reloaded, added: %v, updated: %v, removed: %v, unchanged: %d",
+			res.Added, res.Updated, res.Removed, res.Unchanged)
+	}
+}
+
 // OutAlistInit 暴露用于外部启动server的函数
 func OutAlistInit() {
 	var (
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// errNoServer means that no server is listening on the admin socket, the
+// commands that can work without the server change the database directly
+var errNoServer = errors.New("the server is not running")
+
+// listenAdminSocket listens on the unix socket of the admin cli, a socket
+// left by a crashed server is removed, but a running one is kept
+func listenAdminSocket(path string) (net.Listener, error) {
+	if utils.Exists(path) {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, errors.Errorf("another server is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, errors.Wrap(err, "failed remove the stale socket")
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// only the owner of the server can reach the api with the socket
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// adminRequest calls the api of the running server through the admin
+// socket with the admin token, and decodes the data of the response to
+// data if it's not nil. It returns errNoServer if the server is not
+// running. InitClient must be called first to read the token.
+func adminRequest(method, api string, query url.Values, body, data any) error {
+	path := conf.Conf.AdminSocket
+	if path == "" || !utils.Exists(path) {
+		return errNoServer
+	}
+	client := &http.Client{
+		Timeout: time.Minute,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	var reader io.Reader
+	if body != nil {
+		b, err := utils.Json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	u := "http://alist" + api
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", setting.GetStr(conf.Token))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return errNoServer
+		}
+		return err
+	}
+	defer res.Body.Close()
+	var resp common.Resp[json.RawMessage]
+	if err := utils.Json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return errors.Wrapf(err, "failed decode the response: %s", res.Status)
+	}
+	if resp.Code != 200 {
+		return fmt.Errorf("%s (code %d)", resp.Message, resp.Code)
+	}
+	if data != nil && len(resp.Data) > 0 {
+		return utils.Json.Unmarshal(resp.Data, data)
+	}
+	return nil
+}
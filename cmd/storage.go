@@ -4,8 +4,19 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -21,24 +32,66 @@ func init() {
 		Use:   "disable",
 		Short: "Disable a storage",
 		Run: func(cmd *cobra.Command, args []string) {
-			Init()
-			storage, err := db.GetStorageByMountPath(mountPath)
-			if err != nil {
-				utils.Log.Errorf("failed to query storage: %+v", err)
+			InitClient()
+			if err := setStorageDisabled(mountPath, true); err != nil {
+				utils.Log.Errorf("failed to disable storage: %+v", err)
 			} else {
-				storage.Disabled = true
-				err = db.UpdateStorage(storage)
-				if err != nil {
-					utils.Log.Errorf("failed to update storage: %+v", err)
-				} else {
-					utils.Log.Infof("Storage with mount path [%s] have been disabled", mountPath)
-				}
+				utils.Log.Infof("Storage with mount path [%s] have been disabled", mountPath)
 			}
 		},
 	}
 	disable.Flags().StringVarP(&mountPath, "mount-path", "m", "", "The mountPath of storage")
+	var enable = &cobra.Command{
+		Use:   "enable",
+		Short: "Enable a storage",
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			if err := setStorageDisabled(mountPath, false); err != nil {
+				utils.Log.Errorf("failed to enable storage: %+v", err)
+			} else {
+				utils.Log.Infof("Storage with mount path [%s] have been enabled", mountPath)
+			}
+		},
+	}
+	enable.Flags().StringVarP(&mountPath, "mount-path", "m", "", "The mountPath of storage")
+	var file string
+	var add = &cobra.Command{
+		Use:   "add",
+		Short: "Add a storage from a json file",
+		Long: `Add a storage from a json file, or from stdin if the file is "-".
+The json is the same as the one of the storage api, such as
+{"mount_path":"/local","driver":"Local","addition":"{\"root_folder_path\":\"/data\"}"}`,
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			id, err := addStorage(file)
+			if err != nil {
+				utils.Log.Errorf("failed to add storage: %+v", err)
+			} else {
+				utils.Log.Infof("Storage have been added with id %d", id)
+			}
+		},
+	}
+	add.Flags().StringVarP(&file, "file", "f", "-", "The json file of storage")
+	var list = &cobra.Command{
+		Use:   "list",
+		Short: "List all storages",
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			storages, _, err := db.GetStorages(1, -1)
+			if err != nil {
+				utils.Log.Errorf("failed to list storages: %+v", err)
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tMOUNT PATH\tDRIVER\tSTATUS\tDISABLED")
+			for _, storage := range storages {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\n", storage.ID, storage.MountPath, storage.Driver, storageStatus(storage), storage.Disabled)
+			}
+			_ = w.Flush()
+		},
+	}
 	RootCmd.AddCommand(storageCmd)
-	storageCmd.AddCommand(disable)
+	storageCmd.AddCommand(disable, enable, add, list)
 
 	// Here you will define your flags and configuration settings.
 
@@ -50,3 +103,73 @@ func init() {
 	// is called directly, e.g.:
 	// storageCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
+
+// setStorageDisabled enables or disables the storage in the running server,
+// or in the database if the server is not running
+func setStorageDisabled(mountPath string, disabled bool) error {
+	storage, err := db.GetStorageByMountPath(utils.FixAndCleanPath(mountPath))
+	if err != nil {
+		return errors.WithMessage(err, "failed to query storage")
+	}
+	api := "/api/admin/storage/enable"
+	if disabled {
+		api = "/api/admin/storage/disable"
+	}
+	query := url.Values{"id": {strconv.Itoa(int(storage.ID))}}
+	err = adminRequest("POST", api, query, nil, nil)
+	if !errors.Is(err, errNoServer) {
+		return err
+	}
+	storage.Disabled = disabled
+	return db.UpdateStorage(storage)
+}
+
+// addStorage creates the storage in the running server, so that it's loaded
+// at once, or in the database if the server is not running
+func addStorage(file string) (uint, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to read storage")
+	}
+	var storage model.Storage
+	if err = utils.Json.Unmarshal(data, &storage); err != nil {
+		return 0, errors.Wrap(err, "failed to parse storage")
+	}
+	if storage.MountPath == "" || storage.Driver == "" {
+		return 0, errors.New("mount_path and driver are required")
+	}
+	if _, err = op.GetDriverNew(storage.Driver); err != nil {
+		return 0, err
+	}
+	var resp struct {
+		ID uint `json:"id"`
+	}
+	err = adminRequest("POST", "/api/admin/storage/create", nil, storage, &resp)
+	if !errors.Is(err, errNoServer) {
+		return resp.ID, err
+	}
+	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+	storage.Modified = time.Now()
+	if err = db.CreateStorage(&storage); err != nil {
+		return 0, err
+	}
+	return storage.ID, nil
+}
+
+// storageStatus is the status saved when the server loaded the storage the
+// last time
+func storageStatus(storage model.Storage) string {
+	if storage.Disabled {
+		return "disabled"
+	}
+	if storage.Status == "" {
+		return "-"
+	}
+	return storage.Status
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/handles"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// taskTypes are the task managers of the task api
+var taskTypes = []string{"aria2_down", "aria2_transfer", "upload", "copy", "batch", "sync", "qbit_down", "qbit_transfer"}
+
+// taskCmd represents the task command
+var taskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "Manage the tasks of the running server",
+}
+
+func checkTaskType(typ string) error {
+	if !utils.SliceContains(taskTypes, typ) {
+		return errors.Errorf("unknown task type %s, should be one of %s", typ, strings.Join(taskTypes, ", "))
+	}
+	return nil
+}
+
+func init() {
+	var typ, tid string
+	var done bool
+	var list = &cobra.Command{
+		Use:   "list",
+		Short: "List the undone or done tasks",
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			if err := checkTaskType(typ); err != nil {
+				utils.Log.Errorf("%+v", err)
+				return
+			}
+			api := "/api/admin/task/" + typ + "/undone"
+			if done {
+				api = "/api/admin/task/" + typ + "/done"
+			}
+			var tasks []handles.TaskInfo
+			if err := adminRequest("GET", api, nil, nil, &tasks); err != nil {
+				utils.Log.Errorf("failed to list tasks: %+v", err)
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tSTATE\tPROGRESS\tERROR")
+			for _, t := range tasks {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d%%\t%s\n", t.ID, t.Name, t.State, t.Progress, t.Error)
+			}
+			_ = w.Flush()
+		},
+	}
+	list.Flags().StringVarP(&typ, "type", "t", "copy", "The type of tasks, one of "+strings.Join(taskTypes, ", "))
+	list.Flags().BoolVar(&done, "done", false, "List the done tasks instead")
+	var cancel = &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel a task",
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			if err := checkTaskType(typ); err != nil {
+				utils.Log.Errorf("%+v", err)
+				return
+			}
+			query := url.Values{"tid": {tid}}
+			if err := adminRequest("POST", "/api/admin/task/"+typ+"/cancel", query, nil, nil); err != nil {
+				utils.Log.Errorf("failed to cancel task: %+v", err)
+			} else {
+				utils.Log.Infof("Task [%s] have been canceled", tid)
+			}
+		},
+	}
+	cancel.Flags().StringVarP(&typ, "type", "t", "copy", "The type of task, one of "+strings.Join(taskTypes, ", "))
+	cancel.Flags().StringVar(&tid, "tid", "", "The id of task")
+	RootCmd.AddCommand(taskCmd)
+	taskCmd.AddCommand(list, cancel)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// userCmd represents the user command
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage user",
+}
+
+func init() {
+	var username, password string
+	var passwordCmd = &cobra.Command{
+		Use:   "password",
+		Short: "Reset the password of a user",
+		Long: `Reset the password of a user,
+a random password is generated if it's not specified`,
+		Run: func(cmd *cobra.Command, args []string) {
+			InitClient()
+			if password == "" {
+				password = random.String(8)
+			}
+			if err := resetPassword(username, password); err != nil {
+				utils.Log.Errorf("failed to reset password: %+v", err)
+			} else {
+				utils.Log.Infof("the password of user [%s] is reset to: %s", username, password)
+			}
+		},
+	}
+	passwordCmd.Flags().StringVarP(&username, "username", "u", "", "The username of user")
+	passwordCmd.Flags().StringVarP(&password, "password", "p", "", "The new password")
+	RootCmd.AddCommand(userCmd)
+	userCmd.AddCommand(passwordCmd)
+}
+
+// resetPassword updates the user in the running server, so that the cached
+// user is refreshed, or in the database if the server is not running
+func resetPassword(username, password string) error {
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return errors.WithMessage(err, "failed to query user")
+	}
+	user.Password = password
+	err = adminRequest("POST", "/api/admin/user/update", nil, user, nil)
+	if !errors.Is(err, errNoServer) {
+		return err
+	}
+	return op.UpdateUser(user)
+}
@@ -6,6 +6,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
 )
 
 // 居然有四种返回方式
@@ -145,6 +147,12 @@ func (c *Cloud189File) IsDir() bool     { return false }
 func (c *Cloud189File) GetID() string   { return fmt.Sprint(c.ID) }
 func (c *Cloud189File) GetPath() string { return "" }
 func (c *Cloud189File) Thumb() string   { return c.Icon.SmallUrl }
+func (c *Cloud189File) GetHashes() map[string]string {
+	if c.Md5 == "" {
+		return nil
+	}
+	return map[string]string{model.HashMD5: strings.ToLower(c.Md5)}
+}
 
 // 文件夹
 type Cloud189Folder struct {
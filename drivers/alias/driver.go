@@ -3,10 +3,12 @@ package alias
 import (
 	"context"
 	"errors"
+	stdpath "path"
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/utils"
 )
@@ -111,4 +113,124 @@ func (d *Alias) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (
 	return nil, errs.ObjectNotFound
 }
 
+func (d *Alias) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	dst, err := d.chooseDir(ctx, parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return fs.MakeDir(ctx, stdpath.Join(dst, dirName))
+}
+
+func (d *Alias) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	srcs, err := d.getDsts(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dstRoot, dstSub := d.getRootAndPath(dstDir.GetPath())
+	dsts, ok := d.pathMap[dstRoot]
+	if !ok {
+		return errs.ObjectNotFound
+	}
+	// move in the same storage if possible, otherwise move to the chosen one
+	for _, src := range srcs {
+		target := ""
+		for _, dst := range dsts {
+			p := stdpath.Join(dst, dstSub)
+			if s1, err := fs.GetStorage(src, &fs.GetStoragesArgs{}); err == nil {
+				if s2, err := fs.GetStorage(p, &fs.GetStoragesArgs{}); err == nil && s1 == s2 {
+					target = p
+					break
+				}
+			}
+		}
+		if target == "" {
+			if target, err = d.chooseDir(ctx, dstDir.GetPath()); err != nil {
+				return err
+			}
+		}
+		if err = fs.Move(ctx, src, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Alias) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	srcs, err := d.getDsts(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	for _, src := range srcs {
+		if err = fs.Rename(ctx, src, newName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Alias) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	srcs, err := d.getDsts(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dst, err := d.chooseDir(ctx, dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	_, err = fs.Copy(ctx, srcs[0], dst)
+	return err
+}
+
+func (d *Alias) Remove(ctx context.Context, obj model.Obj) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	srcs, err := d.getDsts(ctx, obj.GetPath())
+	if err != nil {
+		return err
+	}
+	for _, src := range srcs {
+		if err = fs.Remove(ctx, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Alias) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	if !d.Writable {
+		return errs.PermissionDenied
+	}
+	dst, err := d.chooseDir(ctx, dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return fs.PutDirectly(ctx, dst, &model.FileStream{
+		Obj:        stream,
+		ReadCloser: stream,
+		Mimetype:   stream.GetMimetype(),
+	})
+}
+
+func (d *Alias) Capabilities(caps *driver.Capabilities) {
+	caps.MakeDir = d.Writable
+	caps.Move = d.Writable
+	caps.Rename = d.Writable
+	caps.Copy = d.Writable
+	caps.Remove = d.Writable
+	caps.Upload = d.Writable
+}
+
 var _ driver.Driver = (*Alias)(nil)
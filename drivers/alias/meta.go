@@ -9,14 +9,15 @@ type Addition struct {
 	// Usually one of two
 	// driver.RootPath
 	// define other
-	Paths string `json:"paths" required:"true" type:"text"`
+	Paths       string `json:"paths" required:"true" type:"text"`
+	Writable    bool   `json:"writable" type:"bool" default:"false"`
+	WritePolicy string `json:"write_policy" type:"select" options:"first_found,most_free_space" default:"first_found" help:"How to choose the path to create new files and folders when there are several paths with the same name"`
 }
 
 var config = driver.Config{
 	Name:        "Alias",
 	LocalSort:   true,
 	NoCache:     true,
-	NoUpload:    true,
 	DefaultRoot: "/",
 }
 
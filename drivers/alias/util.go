@@ -6,11 +6,14 @@ import (
 	stdpath "path"
 	"strings"
 
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 func (d *Alias) listRoot() []model.Obj {
@@ -101,3 +104,50 @@ func (d *Alias) link(ctx context.Context, dst, sub string, args model.LinkArgs)
 	link, _, err := fs.Link(ctx, reqPath, args)
 	return link, err
 }
+
+// getDsts get the real paths of the path in all the storages it exists
+func (d *Alias) getDsts(ctx context.Context, path string) ([]string, error) {
+	root, sub := d.getRootAndPath(path)
+	dsts, ok := d.pathMap[root]
+	if !ok {
+		return nil, errs.ObjectNotFound
+	}
+	var res []string
+	for _, dst := range dsts {
+		p := stdpath.Join(dst, sub)
+		if _, err := fs.Get(ctx, p, &fs.GetArgs{NoLog: true}); err == nil {
+			res = append(res, p)
+		}
+	}
+	if len(res) == 0 {
+		return nil, errs.ObjectNotFound
+	}
+	return res, nil
+}
+
+// chooseDir choose one of the real paths of the dir to create new objects by the write policy
+func (d *Alias) chooseDir(ctx context.Context, dir string) (string, error) {
+	dsts, err := d.getDsts(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if d.WritePolicy != "most_free_space" || len(dsts) == 1 {
+		return dsts[0], nil
+	}
+	chosen, max := dsts[0], int64(-1)
+	for _, dst := range dsts {
+		storage, err := fs.GetStorage(dst, &fs.GetStoragesArgs{})
+		if err != nil {
+			continue
+		}
+		space, err := op.GetSpace(ctx, storage)
+		if err != nil {
+			log.Debugf("[alias] can't get space of %s: %+v", dst, err)
+			continue
+		}
+		if space.Free > max {
+			chosen, max = dst, space.Free
+		}
+	}
+	return chosen, nil
+}
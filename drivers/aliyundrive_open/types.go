@@ -1,6 +1,7 @@
 package aliyundrive_open
 
 import (
+	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
@@ -33,7 +34,7 @@ type File struct {
 }
 
 func fileToObj(f File) *model.ObjThumb {
-	return &model.ObjThumb{
+	obj := &model.ObjThumb{
 		Object: model.Object{
 			ID:       f.FileId,
 			Name:     f.Name,
@@ -43,6 +44,10 @@ func fileToObj(f File) *model.ObjThumb {
 		},
 		Thumbnail: model.Thumbnail{Thumbnail: f.Thumbnail},
 	}
+	if f.ContentHash != "" {
+		obj.Hashes = map[string]string{model.HashSHA1: strings.ToLower(f.ContentHash)}
+	}
+	return obj
 }
 
 type PartInfo struct {
@@ -12,11 +12,15 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/aliyundrive"
 	_ "github.com/alist-org/alist/v3/drivers/aliyundrive_open"
 	_ "github.com/alist-org/alist/v3/drivers/aliyundrive_share"
+	_ "github.com/alist-org/alist/v3/drivers/azure_blob"
+	_ "github.com/alist-org/alist/v3/drivers/backblaze_b2"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_netdisk"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_photo"
 	_ "github.com/alist-org/alist/v3/drivers/baidu_share"
 	_ "github.com/alist-org/alist/v3/drivers/cloudreve"
+	_ "github.com/alist-org/alist/v3/drivers/crypt"
 	_ "github.com/alist-org/alist/v3/drivers/ftp"
+	_ "github.com/alist-org/alist/v3/drivers/google_cloud_storage"
 	_ "github.com/alist-org/alist/v3/drivers/google_drive"
 	_ "github.com/alist-org/alist/v3/drivers/google_photo"
 	_ "github.com/alist-org/alist/v3/drivers/lanzou"
@@ -32,6 +36,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/seafile"
 	_ "github.com/alist-org/alist/v3/drivers/sftp"
 	_ "github.com/alist-org/alist/v3/drivers/smb"
+	_ "github.com/alist-org/alist/v3/drivers/storj"
 	_ "github.com/alist-org/alist/v3/drivers/teambition"
 	_ "github.com/alist-org/alist/v3/drivers/terabox"
 	_ "github.com/alist-org/alist/v3/drivers/thunder"
@@ -39,6 +44,7 @@ import (
 	_ "github.com/alist-org/alist/v3/drivers/url_tree"
 	_ "github.com/alist-org/alist/v3/drivers/uss"
 	_ "github.com/alist-org/alist/v3/drivers/virtual"
+	_ "github.com/alist-org/alist/v3/drivers/web3_storage"
 	_ "github.com/alist-org/alist/v3/drivers/webdav"
 	_ "github.com/alist-org/alist/v3/drivers/yandex_disk"
 )
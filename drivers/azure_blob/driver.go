@@ -0,0 +1,161 @@
+package azure_blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+type AzureBlob struct {
+	model.Storage
+	Addition
+	endpoint         string
+	dfsEndpoint      string
+	sasQuery         string
+	accessToken      string
+	tokenExpire      time.Time
+	delegationKey    *UserDelegationKey
+	delegationExpire time.Time
+}
+
+func (d *AzureBlob) Config() driver.Config {
+	if d.AuthType == "sas_token" {
+		// a sas can't sign a narrower one, so the configured sas,
+		// which may cover the whole container, stays on the server
+		c := config
+		c.OnlyProxy = true
+		return c
+	}
+	return config
+}
+
+func (d *AzureBlob) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *AzureBlob) Init(ctx context.Context) error {
+	d.endpoint = strings.TrimSuffix(d.Endpoint, "/")
+	if d.endpoint == "" {
+		d.endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", d.AccountName)
+	}
+	d.dfsEndpoint = strings.Replace(d.endpoint, ".blob.", ".dfs.", 1)
+	d.sasQuery = strings.TrimPrefix(d.SasToken, "?")
+	switch d.AuthType {
+	case "sas_token":
+		if d.sasQuery == "" {
+			return errors.New("sas token is required")
+		}
+	case "client_credentials":
+		if d.TenantID == "" || d.ClientID == "" || d.ClientSecret == "" {
+			return errors.New("tenant id, client id and client secret are required")
+		}
+	default:
+		if d.AccountKey == "" {
+			return errors.New("account key is required")
+		}
+	}
+	d.accessToken, d.delegationKey = "", nil
+	// check the container and the credentials
+	_, _, err := d.list(ctx, getKey(d.GetRootPath(), true), "/")
+	return err
+}
+
+func (d *AzureBlob) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *AzureBlob) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	prefix := getKey(dir.GetPath(), true)
+	blobs, prefixes, err := d.list(ctx, prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]model.Obj, 0, len(blobs)+len(prefixes))
+	dirs := make(map[string]struct{}, len(prefixes))
+	for _, p := range prefixes {
+		name := strings.TrimSuffix(strings.TrimPrefix(p.Name, prefix), "/")
+		dirs[name] = struct{}{}
+		objs = append(objs, &model.Object{
+			Name:     name,
+			Path:     stdpath.Join(dir.GetPath(), name),
+			Modified: d.Modified,
+			IsFolder: true,
+		})
+	}
+	for _, b := range blobs {
+		name := strings.TrimPrefix(b.Name, prefix)
+		if name == placeholderName {
+			continue
+		}
+		// directories of hierarchical namespace are also listed as blobs
+		if _, ok := dirs[name]; ok || b.isFolder() {
+			if !ok {
+				objs = append(objs, &model.Object{
+					Name:     name,
+					Path:     stdpath.Join(dir.GetPath(), name),
+					Modified: d.Modified,
+					IsFolder: true,
+				})
+			}
+			continue
+		}
+		obj := blobToObj(b, name)
+		obj.Path = stdpath.Join(dir.GetPath(), name)
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func (d *AzureBlob) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	u, expire, err := d.signUrl(ctx, getKey(file.GetPath(), false))
+	if err != nil {
+		return nil, err
+	}
+	link := &model.Link{URL: u}
+	if expire > 0 {
+		exp := expire - time.Minute
+		link.Expiration = &exp
+	}
+	return link, nil
+}
+
+func (d *AzureBlob) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return d.mkdir(ctx, getKey(stdpath.Join(parentDir.GetPath(), dirName), false))
+}
+
+func (d *AzureBlob) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.rename(ctx, srcObj.GetPath(), stdpath.Join(dstDir.GetPath(), srcObj.GetName()), srcObj.IsDir())
+}
+
+func (d *AzureBlob) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.rename(ctx, srcObj.GetPath(), stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName), srcObj.IsDir())
+}
+
+func (d *AzureBlob) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dst := stdpath.Join(dstDir.GetPath(), srcObj.GetName())
+	if srcObj.IsDir() {
+		return d.copyDir(ctx, srcObj.GetPath(), dst)
+	}
+	return d.copyBlob(ctx, getKey(srcObj.GetPath(), false), getKey(dst, false))
+}
+
+func (d *AzureBlob) Remove(ctx context.Context, obj model.Obj) error {
+	return d.remove(ctx, obj.GetPath(), obj.IsDir())
+}
+
+func (d *AzureBlob) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	if stream.GetSize() <= int64(d.ChunkSize)*1024*1024 {
+		return d.putBlob(ctx, key, io.TeeReader(stream, driver.NewProgress(stream.GetSize(), up)), stream.GetSize())
+	}
+	return d.putBlocks(ctx, key, stream, stream.GetSize(), up)
+}
+
+var _ driver.Driver = (*AzureBlob)(nil)
@@ -0,0 +1,35 @@
+package azure_blob
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	AccountName           string `json:"account_name" required:"true"`
+	Container             string `json:"container" required:"true"`
+	Endpoint              string `json:"endpoint" help:"Leave empty to use https://<account_name>.blob.core.windows.net"`
+	AuthType              string `json:"auth_type" type:"select" options:"account_key,sas_token,client_credentials" default:"account_key"`
+	AccountKey            string `json:"account_key"`
+	SasToken              string `json:"sas_token" help:"Account or container SAS, with read, write, delete and list permissions, the files are proxied with it"`
+	TenantID              string `json:"tenant_id"`
+	ClientID              string `json:"client_id"`
+	ClientSecret          string `json:"client_secret"`
+	HierarchicalNamespace bool   `json:"hierarchical_namespace" help:"Enable if the account is ADLS Gen2, directories are then created and renamed natively"`
+	SignURLExpire         int    `json:"sign_url_expire" type:"number" default:"4"`
+	ChunkSize             int    `json:"chunk_size" type:"number" default:"8" help:"Block size in MB"`
+}
+
+var config = driver.Config{
+	Name:        "AzureBlob",
+	DefaultRoot: "/",
+	LocalSort:   true,
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &AzureBlob{}
+	})
+}
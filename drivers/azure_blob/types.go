@@ -0,0 +1,83 @@
+package azure_blob
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type ErrResp struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+type Blob struct {
+	Name       string `xml:"Name"`
+	Properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		ContentLength int64  `xml:"Content-Length"`
+		ResourceType  string `xml:"ResourceType"`
+	} `xml:"Properties"`
+	Metadata struct {
+		HdiIsFolder string `xml:"hdi_isfolder"`
+	} `xml:"Metadata"`
+}
+
+type BlobPrefix struct {
+	Name string `xml:"Name"`
+}
+
+type ListResp struct {
+	XMLName xml.Name `xml:"EnumerationResults"`
+	Blobs   struct {
+		Blob       []Blob       `xml:"Blob"`
+		BlobPrefix []BlobPrefix `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+type BlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+type KeyInfo struct {
+	XMLName xml.Name `xml:"KeyInfo"`
+	Start   string   `xml:"Start"`
+	Expiry  string   `xml:"Expiry"`
+}
+
+type UserDelegationKey struct {
+	XMLName       xml.Name `xml:"UserDelegationKey"`
+	SignedOid     string   `xml:"SignedOid"`
+	SignedTid     string   `xml:"SignedTid"`
+	SignedStart   string   `xml:"SignedStart"`
+	SignedExpiry  string   `xml:"SignedExpiry"`
+	SignedService string   `xml:"SignedService"`
+	SignedVersion string   `xml:"SignedVersion"`
+	Value         string   `xml:"Value"`
+}
+
+type TokenResp struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (b Blob) isFolder() bool {
+	return b.Properties.ResourceType == "directory" || strings.EqualFold(b.Metadata.HdiIsFolder, "true")
+}
+
+func blobToObj(b Blob, name string) *model.Object {
+	modified, _ := time.Parse(http.TimeFormat, b.Properties.LastModified)
+	return &model.Object{
+		Name:     name,
+		Size:     b.Properties.ContentLength,
+		Modified: modified,
+	}
+}
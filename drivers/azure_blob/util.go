@@ -0,0 +1,499 @@
+package azure_blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// do others that not defined in Driver interface
+
+const (
+	apiVersion      = "2020-12-06"
+	placeholderName = ".alist"
+)
+
+func getKey(path string, dir bool) string {
+	path = strings.TrimPrefix(path, "/")
+	if path != "" && dir {
+		path += "/"
+	}
+	return path
+}
+
+func escapeKey(key string) string {
+	seg := strings.Split(key, "/")
+	for i := range seg {
+		seg[i] = url.PathEscape(seg[i])
+	}
+	return strings.Join(seg, "/")
+}
+
+func (d *AzureBlob) blobUrl(key string) string {
+	return fmt.Sprintf("%s/%s/%s", d.endpoint, d.Container, escapeKey(key))
+}
+
+func (d *AzureBlob) dfsUrl(key string) string {
+	return fmt.Sprintf("%s/%s/%s", d.dfsEndpoint, d.Container, escapeKey(key))
+}
+
+// signSharedKey sign the request with the account key,
+// see https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+func (d *AzureBlob) signSharedKey(req *http.Request) error {
+	key, err := base64.StdEncoding.DecodeString(d.AccountKey)
+	if err != nil {
+		return errors.WithMessage(err, "invalid account key")
+	}
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	headers := make([]string, 0)
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-ms-") {
+			headers = append(headers, k+":"+strings.Join(v, ","))
+		}
+	}
+	sort.Strings(headers)
+	resource := "/" + d.AccountName + req.URL.EscapedPath()
+	query := req.URL.Query()
+	names := make([]string, 0, len(query))
+	for k := range query {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		values := query[k]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(values, ",")
+	}
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		strings.Join(headers, "\n"),
+		resource,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", d.AccountName, hmacSign(key, stringToSign)))
+	return nil
+}
+
+func hmacSign(key []byte, s string) string {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (d *AzureBlob) getAccessToken(ctx context.Context) (string, error) {
+	if d.accessToken != "" && time.Now().Before(d.tokenExpire) {
+		return d.accessToken, nil
+	}
+	var resp TokenResp
+	_, err := base.RestyClient.R().
+		SetContext(ctx).
+		SetFormData(map[string]string{
+			"grant_type":    "client_credentials",
+			"client_id":     d.ClientID,
+			"client_secret": d.ClientSecret,
+			"scope":         "https://storage.azure.com/.default",
+		}).
+		SetResult(&resp).
+		SetError(&resp).
+		Post(fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", d.TenantID))
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.Errorf("%s: %s", resp.Error, resp.ErrorDescription)
+	}
+	d.accessToken = resp.AccessToken
+	d.tokenExpire = time.Now().Add(time.Duration(resp.ExpiresIn-60) * time.Second)
+	return d.accessToken, nil
+}
+
+func (d *AzureBlob) authorize(ctx context.Context, req *http.Request) error {
+	req.Header.Set("x-ms-version", apiVersion)
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	switch d.AuthType {
+	case "sas_token":
+		if req.URL.RawQuery == "" {
+			req.URL.RawQuery = d.sasQuery
+		} else {
+			req.URL.RawQuery += "&" + d.sasQuery
+		}
+		return nil
+	case "client_credentials":
+		token, err := d.getAccessToken(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	default:
+		return d.signSharedKey(req)
+	}
+}
+
+// request send a signed request, the body will be closed by caller if resp is nil
+func (d *AzureBlob) request(ctx context.Context, method, u string, query url.Values, header map[string]string, body io.Reader, size int64) (*http.Response, error) {
+	if query != nil {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		// azure requires the Content-Length even if there is no body
+		req.Body = http.NoBody
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	if err = d.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return nil, errs.ObjectNotFound
+		}
+		data, _ := io.ReadAll(res.Body)
+		var e ErrResp
+		if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+			return nil, errors.Errorf("%s: %s", e.Code, strings.SplitN(e.Message, "\n", 2)[0])
+		}
+		return nil, errors.Errorf("azure responded with status %d: %s", res.StatusCode, string(data))
+	}
+	return res, nil
+}
+
+func (d *AzureBlob) do(ctx context.Context, method, u string, query url.Values, header map[string]string, body io.Reader, size int64, out interface{}) error {
+	res, err := d.request(ctx, method, u, query, header, body, size)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil
+	}
+	return xml.NewDecoder(res.Body).Decode(out)
+}
+
+// list blobs under the prefix, if delimiter is empty all the blobs will be listed recursively
+func (d *AzureBlob) list(ctx context.Context, prefix, delimiter string) ([]Blob, []BlobPrefix, error) {
+	blobs := make([]Blob, 0)
+	prefixes := make([]BlobPrefix, 0)
+	marker := ""
+	for {
+		query := url.Values{
+			"restype": {"container"},
+			"comp":    {"list"},
+			"include": {"metadata"},
+			"prefix":  {prefix},
+		}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		var resp ListResp
+		err := d.do(ctx, http.MethodGet, d.endpoint+"/"+d.Container, query, nil, nil, 0, &resp)
+		if err != nil {
+			return nil, nil, err
+		}
+		blobs = append(blobs, resp.Blobs.Blob...)
+		prefixes = append(prefixes, resp.Blobs.BlobPrefix...)
+		if resp.NextMarker == "" {
+			break
+		}
+		marker = resp.NextMarker
+	}
+	return blobs, prefixes, nil
+}
+
+func (d *AzureBlob) getUserDelegationKey(ctx context.Context) (*UserDelegationKey, error) {
+	if d.delegationKey != nil && time.Now().Add(time.Duration(d.SignURLExpire)*time.Hour).Before(d.delegationExpire) {
+		return d.delegationKey, nil
+	}
+	now := time.Now().UTC()
+	expire := now.Add(7 * 24 * time.Hour)
+	body, err := xml.Marshal(KeyInfo{
+		Start:  now.Add(-5 * time.Minute).Format(time.RFC3339),
+		Expiry: expire.Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp UserDelegationKey
+	err = d.do(ctx, http.MethodPost, d.endpoint+"/", url.Values{
+		"restype": {"service"},
+		"comp":    {"userdelegationkey"},
+	}, map[string]string{"Content-Type": "application/xml"}, bytes.NewReader(body), int64(len(body)), &resp)
+	if err != nil {
+		return nil, err
+	}
+	d.delegationKey, d.delegationExpire = &resp, expire
+	return d.delegationKey, nil
+}
+
+// signUrl create a read only service sas, or user delegation sas if use client credentials,
+// the url with the configured sas is only used by the proxy
+// see https://learn.microsoft.com/rest/api/storageservices/create-service-sas
+func (d *AzureBlob) signUrl(ctx context.Context, key string) (string, time.Duration, error) {
+	expire := time.Duration(d.SignURLExpire) * time.Hour
+	u := d.blobUrl(key)
+	if d.AuthType == "sas_token" {
+		return u + "?" + d.sasQuery, 0, nil
+	}
+	se := time.Now().UTC().Add(expire).Format(time.RFC3339)
+	resource := fmt.Sprintf("/blob/%s/%s/%s", d.AccountName, d.Container, key)
+	query := url.Values{
+		"sv":  {apiVersion},
+		"sr":  {"b"},
+		"sp":  {"r"},
+		"se":  {se},
+		"spr": {"https"},
+	}
+	var stringToSign string
+	var signKey []byte
+	if d.AuthType == "client_credentials" {
+		k, err := d.getUserDelegationKey(ctx)
+		if err != nil {
+			return "", 0, err
+		}
+		if signKey, err = base64.StdEncoding.DecodeString(k.Value); err != nil {
+			return "", 0, err
+		}
+		stringToSign = strings.Join([]string{
+			"r", "", se, resource,
+			k.SignedOid, k.SignedTid, k.SignedStart, k.SignedExpiry, k.SignedService, k.SignedVersion,
+			"", "", "", // saoid, suoid, scid
+			"", "https", apiVersion, "b",
+			"", "", // snapshot, encryption scope
+			"", "", "", "", "", // response headers
+		}, "\n")
+		query.Set("skoid", k.SignedOid)
+		query.Set("sktid", k.SignedTid)
+		query.Set("skt", k.SignedStart)
+		query.Set("ske", k.SignedExpiry)
+		query.Set("sks", k.SignedService)
+		query.Set("skv", k.SignedVersion)
+	} else {
+		var err error
+		if signKey, err = base64.StdEncoding.DecodeString(d.AccountKey); err != nil {
+			return "", 0, errors.WithMessage(err, "invalid account key")
+		}
+		stringToSign = strings.Join([]string{
+			"r", "", se, resource,
+			"", "", "https", apiVersion, "b",
+			"", "", // snapshot, encryption scope
+			"", "", "", "", "", // response headers
+		}, "\n")
+	}
+	query.Set("sig", hmacSign(signKey, stringToSign))
+	return u + "?" + query.Encode(), expire, nil
+}
+
+func (d *AzureBlob) putBlob(ctx context.Context, key string, r io.Reader, size int64) error {
+	return d.do(ctx, http.MethodPut, d.blobUrl(key), nil, map[string]string{
+		"x-ms-blob-type": "BlockBlob",
+	}, r, size, nil)
+}
+
+// putBlocks upload the stream as blocks then commit the block list
+func (d *AzureBlob) putBlocks(ctx context.Context, key string, r io.Reader, size int64, up func(int)) error {
+	chunkSize := int64(d.ChunkSize) * 1024 * 1024
+	if chunkSize <= 0 {
+		chunkSize = 8 * 1024 * 1024
+	}
+	ids := make([]string, 0, size/chunkSize+1)
+	for done, i := int64(0), 0; done < size; i++ {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		n := chunkSize
+		if size-done < n {
+			n = size - done
+		}
+		// block ids must be the same length in a blob
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", i)))
+		err := d.do(ctx, http.MethodPut, d.blobUrl(key), url.Values{
+			"comp":    {"block"},
+			"blockid": {id},
+		}, nil, io.LimitReader(r, n), n, nil)
+		if err != nil {
+			return errors.WithMessagef(err, "failed put block %d", i)
+		}
+		ids = append(ids, id)
+		done += n
+		up(int(done * 100 / size))
+	}
+	body, err := xml.Marshal(BlockList{Latest: ids})
+	if err != nil {
+		return err
+	}
+	body = append([]byte(xml.Header), body...)
+	return d.do(ctx, http.MethodPut, d.blobUrl(key), url.Values{"comp": {"blocklist"}},
+		map[string]string{"Content-Type": "application/xml"}, bytes.NewReader(body), int64(len(body)), nil)
+}
+
+func (d *AzureBlob) deleteBlob(ctx context.Context, key string) error {
+	err := d.do(ctx, http.MethodDelete, d.blobUrl(key), nil, nil, nil, 0, nil)
+	if errors.Is(err, errs.ObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+// copyBlob start a server side copy and wait until it finished
+func (d *AzureBlob) copyBlob(ctx context.Context, src, dst string) error {
+	source := d.blobUrl(src)
+	if d.AuthType == "sas_token" {
+		source += "?" + d.sasQuery
+	}
+	res, err := d.request(ctx, http.MethodPut, d.blobUrl(dst), nil, map[string]string{
+		"x-ms-copy-source": source,
+	}, nil, 0)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+	status := res.Header.Get("x-ms-copy-status")
+	for status == "pending" {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+		res, err = d.request(ctx, http.MethodHead, d.blobUrl(dst), nil, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		_ = res.Body.Close()
+		status = res.Header.Get("x-ms-copy-status")
+	}
+	if status != "" && status != "success" {
+		return errors.Errorf("copy %s: %s", status, res.Header.Get("x-ms-copy-status-description"))
+	}
+	return nil
+}
+
+func (d *AzureBlob) copyDir(ctx context.Context, src, dst string) error {
+	src, dst = getKey(src, true), getKey(dst, true)
+	if d.HierarchicalNamespace {
+		if err := d.mkdir(ctx, dst); err != nil {
+			return err
+		}
+	}
+	blobs, _, err := d.list(ctx, src, "")
+	if err != nil {
+		return err
+	}
+	for _, b := range blobs {
+		target := dst + strings.TrimPrefix(b.Name, src)
+		if b.isFolder() {
+			err = d.mkdir(ctx, target)
+		} else {
+			err = d.copyBlob(ctx, b.Name, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *AzureBlob) mkdir(ctx context.Context, key string) error {
+	key = strings.TrimSuffix(key, "/")
+	if !d.HierarchicalNamespace {
+		return d.putBlob(ctx, key+"/"+placeholderName, bytes.NewReader([]byte{}), 0)
+	}
+	return d.do(ctx, http.MethodPut, d.dfsUrl(key), url.Values{"resource": {"directory"}}, nil, nil, 0, nil)
+}
+
+// rename move the path natively on hierarchical namespace, otherwise copy then delete
+func (d *AzureBlob) rename(ctx context.Context, src, dst string, dir bool) error {
+	if d.HierarchicalNamespace {
+		return d.do(ctx, http.MethodPut, d.dfsUrl(getKey(dst, false)), nil, map[string]string{
+			"x-ms-rename-source": "/" + d.Container + "/" + escapeKey(getKey(src, false)),
+		}, nil, 0, nil)
+	}
+	var err error
+	if dir {
+		err = d.copyDir(ctx, src, dst)
+	} else {
+		err = d.copyBlob(ctx, getKey(src, false), getKey(dst, false))
+	}
+	if err != nil {
+		return err
+	}
+	return d.remove(ctx, src, dir)
+}
+
+func (d *AzureBlob) remove(ctx context.Context, path string, dir bool) error {
+	if !dir {
+		return d.deleteBlob(ctx, getKey(path, false))
+	}
+	if d.HierarchicalNamespace {
+		continuation := ""
+		for {
+			query := url.Values{"recursive": {"true"}}
+			if continuation != "" {
+				query.Set("continuation", continuation)
+			}
+			res, err := d.request(ctx, http.MethodDelete, d.dfsUrl(getKey(path, false)), query, nil, nil, 0)
+			if err != nil {
+				return err
+			}
+			_ = res.Body.Close()
+			continuation = res.Header.Get("x-ms-continuation")
+			if continuation == "" {
+				return nil
+			}
+		}
+	}
+	blobs, _, err := d.list(ctx, getKey(path, true), "")
+	if err != nil {
+		return err
+	}
+	for _, b := range blobs {
+		if err = d.deleteBlob(ctx, b.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package backblaze_b2
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+type BackblazeB2 struct {
+	model.Storage
+	Addition
+	auth     AuthResp
+	bucketID string
+	partSize int64
+}
+
+func (d *BackblazeB2) Config() driver.Config {
+	return config
+}
+
+func (d *BackblazeB2) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *BackblazeB2) Init(ctx context.Context) error {
+	if err := d.authorize(); err != nil {
+		return err
+	}
+	if err := d.getBucket(); err != nil {
+		return err
+	}
+	d.partSize = int64(d.ChunkSize) * 1024 * 1024
+	if d.partSize <= 0 {
+		d.partSize = d.auth.RecommendedPartSize
+	}
+	if d.partSize < d.auth.AbsoluteMinimumPartSize {
+		d.partSize = d.auth.AbsoluteMinimumPartSize
+	}
+	return nil
+}
+
+func (d *BackblazeB2) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *BackblazeB2) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	files, err := d.listFiles(ctx, dir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(files, func(src File) (model.Obj, error) {
+		obj := fileToObj(src)
+		if obj.IsFolder {
+			obj.Modified = d.Modified
+		}
+		return obj, nil
+	})
+}
+
+func (d *BackblazeB2) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	key := getKey(file.GetPath(), false)
+	u := fmt.Sprintf("%s/file/%s/%s", d.auth.DownloadUrl, d.Bucket, utils.EncodePath(key, true))
+	if d.LinkExpire <= 0 {
+		return &model.Link{URL: u}, nil
+	}
+	expire := time.Hour * time.Duration(d.LinkExpire)
+	var resp DownloadAuthResp
+	err := d.request(ctx, "b2_get_download_authorization", base.Json{
+		"bucketId":               d.bucketID,
+		"fileNamePrefix":         key,
+		"validDurationInSeconds": int(expire.Seconds()),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	// refresh link a little earlier than expiration
+	exp := expire - time.Minute
+	return &model.Link{
+		URL:        u + "?Authorization=" + resp.AuthorizationToken,
+		Expiration: &exp,
+	}, nil
+}
+
+func (d *BackblazeB2) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	key := getKey(stdpath.Join(parentDir.GetPath(), dirName), true) + placeholderName
+	return d.uploadFile(ctx, key, bytes.NewReader([]byte{}), 0)
+}
+
+func (d *BackblazeB2) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	err := d.Copy(ctx, srcObj, dstDir)
+	if err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *BackblazeB2) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	dst := stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName)
+	var err error
+	if srcObj.IsDir() {
+		err = d.copyDir(ctx, srcObj.GetPath(), dst)
+	} else {
+		err = d.copyFile(ctx, objToFile(srcObj), getKey(dst, false))
+	}
+	if err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *BackblazeB2) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dst := stdpath.Join(dstDir.GetPath(), srcObj.GetName())
+	if srcObj.IsDir() {
+		return d.copyDir(ctx, srcObj.GetPath(), dst)
+	}
+	return d.copyFile(ctx, objToFile(srcObj), getKey(dst, false))
+}
+
+func (d *BackblazeB2) Remove(ctx context.Context, obj model.Obj) error {
+	if obj.IsDir() {
+		return d.removeDir(ctx, obj.GetPath())
+	}
+	return d.deleteFile(ctx, getKey(obj.GetPath(), false))
+}
+
+func (d *BackblazeB2) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	// the retries read the parts again
+	tempFile, err := utils.CreateTempFile(stream.GetReadCloser())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tempFile.Close()
+		_ = os.Remove(tempFile.Name())
+	}()
+	if stream.GetSize() <= d.partSize {
+		return d.uploadFile(ctx, key, tempFile, stream.GetSize())
+	}
+	return d.uploadLargeFile(ctx, key, tempFile, stream.GetSize(), up)
+}
+
+func objToFile(obj model.Obj) File {
+	return File{
+		FileID:        obj.GetID(),
+		FileName:      getKey(obj.GetPath(), false),
+		ContentLength: obj.GetSize(),
+	}
+}
+
+func (d *BackblazeB2) Capabilities(caps *driver.Capabilities) {
+	caps.Checksum = true
+}
+
+var _ driver.Driver = (*BackblazeB2)(nil)
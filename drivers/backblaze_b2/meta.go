@@ -0,0 +1,29 @@
+package backblaze_b2
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	KeyID          string `json:"key_id" required:"true"`
+	ApplicationKey string `json:"application_key" required:"true"`
+	Bucket         string `json:"bucket" required:"true"`
+	LinkExpire     int    `json:"link_expire" type:"number" default:"4" help:"Hours the download authorization stays valid, 0 for public bucket."`
+	ChunkSize      int    `json:"chunk_size" type:"number" default:"100" help:"Part size of large file upload in MB, 0 to use recommended size."`
+	MaxRetry       int    `json:"max_retry" type:"number" default:"5" help:"Max retries when B2 responds 429 or 503, or an upload url fails."`
+}
+
+var config = driver.Config{
+	Name:        "BackblazeB2",
+	DefaultRoot: "/",
+	LocalSort:   true,
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &BackblazeB2{}
+	})
+}
@@ -0,0 +1,90 @@
+package backblaze_b2
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type ErrResp struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type AuthResp struct {
+	AccountID               string `json:"accountId"`
+	AuthorizationToken      string `json:"authorizationToken"`
+	ApiUrl                  string `json:"apiUrl"`
+	DownloadUrl             string `json:"downloadUrl"`
+	RecommendedPartSize     int64  `json:"recommendedPartSize"`
+	AbsoluteMinimumPartSize int64  `json:"absoluteMinimumPartSize"`
+	Allowed                 struct {
+		BucketID   string `json:"bucketId"`
+		BucketName string `json:"bucketName"`
+	} `json:"allowed"`
+}
+
+type Bucket struct {
+	BucketID   string `json:"bucketId"`
+	BucketName string `json:"bucketName"`
+	BucketType string `json:"bucketType"`
+}
+
+type BucketsResp struct {
+	Buckets []Bucket `json:"buckets"`
+}
+
+type File struct {
+	FileID          string `json:"fileId"`
+	FileName        string `json:"fileName"`
+	Action          string `json:"action"`
+	ContentLength   int64  `json:"contentLength"`
+	ContentSha1     string `json:"contentSha1"`
+	UploadTimestamp int64  `json:"uploadTimestamp"`
+	FileInfo        struct {
+		LastModified string `json:"src_last_modified_millis"`
+	} `json:"fileInfo"`
+}
+
+type FilesResp struct {
+	Files        []File  `json:"files"`
+	NextFileName *string `json:"nextFileName"`
+	NextFileID   *string `json:"nextFileId"`
+}
+
+type UploadUrlResp struct {
+	UploadUrl          string `json:"uploadUrl"`
+	AuthorizationToken string `json:"authorizationToken"`
+}
+
+type DownloadAuthResp struct {
+	AuthorizationToken string `json:"authorizationToken"`
+}
+
+func fileToObj(f File) *model.Object {
+	if f.Action == "folder" {
+		return &model.Object{
+			ID:       f.FileID,
+			Path:     "/" + strings.TrimSuffix(f.FileName, "/"),
+			Name:     path.Base(strings.TrimSuffix(f.FileName, "/")),
+			IsFolder: true,
+		}
+	}
+	obj := &model.Object{
+		ID:       f.FileID,
+		Path:     "/" + f.FileName,
+		Name:     path.Base(f.FileName),
+		Size:     f.ContentLength,
+		Modified: time.UnixMilli(f.UploadTimestamp),
+	}
+	// large files have no sha1 unless it's given on upload, which is
+	// prefixed with unverified
+	sha := strings.TrimPrefix(f.ContentSha1, "unverified:")
+	if len(sha) == 40 {
+		obj.Hashes = map[string]string{model.HashSHA1: strings.ToLower(sha)}
+	}
+	return obj
+}
@@ -0,0 +1,481 @@
+package backblaze_b2
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// do others that not defined in Driver interface
+
+const (
+	authUrl         = "https://api.backblazeb2.com/b2api/v2/b2_authorize_account"
+	placeholderName = ".bzEmpty"
+	maxCopySize     = 5 * 1024 * 1024 * 1024
+)
+
+func (d *BackblazeB2) authorize() error {
+	var resp AuthResp
+	var e ErrResp
+	res, err := base.RestyClient.R().
+		SetBasicAuth(d.KeyID, d.ApplicationKey).
+		SetResult(&resp).
+		SetError(&e).
+		Get(authUrl)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return toError(res.StatusCode(), e)
+	}
+	d.auth = resp
+	return nil
+}
+
+func (d *BackblazeB2) getBucket() error {
+	if d.auth.Allowed.BucketID != "" && d.auth.Allowed.BucketName == d.Bucket {
+		d.bucketID = d.auth.Allowed.BucketID
+		return nil
+	}
+	var resp BucketsResp
+	err := d.request(context.Background(), "b2_list_buckets", base.Json{
+		"accountId":  d.auth.AccountID,
+		"bucketName": d.Bucket,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	for _, b := range resp.Buckets {
+		if b.BucketName == d.Bucket {
+			d.bucketID = b.BucketID
+			return nil
+		}
+	}
+	return errors.Errorf("bucket [%s] not found or not allowed by this key", d.Bucket)
+}
+
+// toError translate b2 error code to a readable error
+func toError(status int, e ErrResp) error {
+	switch {
+	case e.Code == "cap_exceeded":
+		return errors.New("B2 cap exceeded, raise the caps of this account in the B2 console")
+	case status == http.StatusTooManyRequests:
+		return errors.New("B2 rate limit reached, try again later")
+	case status == http.StatusServiceUnavailable:
+		return errors.New("B2 service is busy, try again later")
+	case e.Message != "":
+		return errors.Errorf("%s: %s", e.Code, e.Message)
+	}
+	return errors.Errorf("B2 responded with status %d", status)
+}
+
+// retryAfter get the wait duration from Retry-After header, or back off exponentially
+func retryAfter(header http.Header, retry int) time.Duration {
+	if wait := retryAfterHeader(header); wait > 0 {
+		return wait
+	}
+	if retry > 6 {
+		retry = 6
+	}
+	return time.Second << retry
+}
+
+// retryAfterHeader get the wait duration from Retry-After header, 0 if it's not set
+func retryAfterHeader(header http.Header) time.Duration {
+	if s, err := strconv.Atoi(header.Get("Retry-After")); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return 0
+}
+
+func (d *BackblazeB2) request(ctx context.Context, api string, body interface{}, resp interface{}) error {
+	reAuthed := false
+	for retry := 0; ; retry++ {
+		var e ErrResp
+		req := base.RestyClient.R().
+			SetContext(ctx).
+			SetHeader("Authorization", d.auth.AuthorizationToken).
+			SetBody(body).
+			SetError(&e)
+		if resp != nil {
+			req.SetResult(resp)
+		}
+		res, err := req.Post(d.auth.ApiUrl + "/b2api/v2/" + api)
+		if err != nil {
+			return err
+		}
+		if !res.IsError() {
+			return nil
+		}
+		switch {
+		case res.StatusCode() == http.StatusUnauthorized && !reAuthed:
+			reAuthed = true
+			if err = d.authorize(); err != nil {
+				return err
+			}
+			continue
+		case (res.StatusCode() == http.StatusTooManyRequests || res.StatusCode() == http.StatusServiceUnavailable) && retry < d.MaxRetry:
+			wait := retryAfter(res.Header(), retry)
+			log.Debugf("[b2] %s responded %d, retry after %s", api, res.StatusCode(), wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		return toError(res.StatusCode(), e)
+	}
+}
+
+func getKey(path string, dir bool) string {
+	path = strings.TrimPrefix(path, "/")
+	if path != "" && dir {
+		path += "/"
+	}
+	return path
+}
+
+func (d *BackblazeB2) listFiles(ctx context.Context, prefix string) ([]File, error) {
+	prefix = getKey(prefix, true)
+	files := make([]File, 0)
+	var startFileName *string
+	for {
+		var resp FilesResp
+		err := d.request(ctx, "b2_list_file_names", base.Json{
+			"bucketId":      d.bucketID,
+			"prefix":        prefix,
+			"delimiter":     "/",
+			"startFileName": startFileName,
+			"maxFileCount":  1000,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range resp.Files {
+			if f.Action != "folder" && strings.HasSuffix(f.FileName, "/"+placeholderName) {
+				continue
+			}
+			files = append(files, f)
+		}
+		if resp.NextFileName == nil {
+			break
+		}
+		startFileName = resp.NextFileName
+	}
+	return files, nil
+}
+
+func (d *BackblazeB2) deleteFile(ctx context.Context, key string) error {
+	var startFileID *string
+	startFileName := &key
+	for {
+		var resp FilesResp
+		err := d.request(ctx, "b2_list_file_versions", base.Json{
+			"bucketId":      d.bucketID,
+			"prefix":        key,
+			"startFileName": startFileName,
+			"startFileId":   startFileID,
+			"maxFileCount":  100,
+		}, &resp)
+		if err != nil {
+			return err
+		}
+		for _, f := range resp.Files {
+			if f.FileName != key {
+				continue
+			}
+			err = d.request(ctx, "b2_delete_file_version", base.Json{
+				"fileName": f.FileName,
+				"fileId":   f.FileID,
+			}, nil)
+			if err != nil {
+				return err
+			}
+		}
+		if resp.NextFileName == nil || *resp.NextFileName != key {
+			break
+		}
+		startFileName, startFileID = resp.NextFileName, resp.NextFileID
+	}
+	return nil
+}
+
+func (d *BackblazeB2) removeDir(ctx context.Context, prefix string) error {
+	files, err := d.listFiles(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.Action == "folder" {
+			err = d.removeDir(ctx, f.FileName)
+		} else {
+			err = d.deleteFile(ctx, f.FileName)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return d.deleteFile(ctx, getKey(prefix, true)+placeholderName)
+}
+
+func (d *BackblazeB2) copyFile(ctx context.Context, f File, dstKey string) error {
+	if f.ContentLength <= maxCopySize {
+		return d.request(ctx, "b2_copy_file", base.Json{
+			"sourceFileId": f.FileID,
+			"fileName":     dstKey,
+		}, nil)
+	}
+	// b2_copy_file only support files smaller than 5GB, copy by parts instead
+	fileID, err := d.startLargeFile(ctx, dstKey)
+	if err != nil {
+		return err
+	}
+	sha1s := make([]string, 0)
+	for start, part := int64(0), 1; start < f.ContentLength; part++ {
+		end := start + d.partSize
+		if end > f.ContentLength {
+			end = f.ContentLength
+		}
+		var resp struct {
+			ContentSha1 string `json:"contentSha1"`
+		}
+		err = d.request(ctx, "b2_copy_part", base.Json{
+			"sourceFileId": f.FileID,
+			"largeFileId":  fileID,
+			"partNumber":   part,
+			"range":        fmt.Sprintf("bytes=%d-%d", start, end-1),
+		}, &resp)
+		if err != nil {
+			d.cancelLargeFile(fileID)
+			return err
+		}
+		sha1s = append(sha1s, resp.ContentSha1)
+		start = end
+	}
+	return d.finishLargeFile(ctx, fileID, sha1s)
+}
+
+func (d *BackblazeB2) copyDir(ctx context.Context, src, dst string) error {
+	files, err := d.listFiles(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := strings.TrimPrefix(f.FileName, getKey(src, true))
+		if f.Action == "folder" {
+			err = d.copyDir(ctx, f.FileName, getKey(dst, true)+name)
+		} else {
+			err = d.copyFile(ctx, f, getKey(dst, true)+name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sha1Suffix hash the data read through it, and append the hex digest after EOF,
+// so that b2 can verify the content without buffering it ("hex_digits_at_end")
+type sha1Suffix struct {
+	r      io.Reader
+	h      hash.Hash
+	suffix io.Reader
+}
+
+func newSha1Suffix(r io.Reader) *sha1Suffix {
+	h := sha1.New()
+	return &sha1Suffix{r: io.TeeReader(r, h), h: h}
+}
+
+func (s *sha1Suffix) Read(p []byte) (int, error) {
+	if s.suffix == nil {
+		n, err := s.r.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+		s.suffix = strings.NewReader(s.Sum())
+		if n > 0 {
+			return n, nil
+		}
+	}
+	return s.suffix.Read(p)
+}
+
+func (s *sha1Suffix) Sum() string {
+	return hex.EncodeToString(s.h.Sum(nil))
+}
+
+// uploadError is the failure of a request to an upload url, after which B2
+// asks for a new url if it's retryable
+type uploadError struct {
+	err       error
+	retryable bool
+	wait      time.Duration
+}
+
+func (e *uploadError) Error() string {
+	return e.err.Error()
+}
+
+func (d *BackblazeB2) upload(ctx context.Context, uploadUrl, token string, body *sha1Suffix, size int64, header map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadUrl, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size + sha1.Size*2
+	req.Header.Set("Authorization", token)
+	req.Header.Set("X-Bz-Content-Sha1", "hex_digits_at_end")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		// the pod of the url may be gone, as with the timeouts
+		return &uploadError{err: err, retryable: ctx.Err() == nil}
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		var e ErrResp
+		data, _ := io.ReadAll(res.Body)
+		_ = json.Unmarshal(data, &e)
+		switch res.StatusCode {
+		case http.StatusUnauthorized, http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return &uploadError{err: toError(res.StatusCode, e), retryable: true, wait: retryAfterHeader(res.Header)}
+		}
+		return toError(res.StatusCode, e)
+	}
+	return nil
+}
+
+// uploadRetry uploads the n bytes of r from off, with the upload url in
+// cur or a new one from getUrl. The url is dropped and the upload retried
+// with backoff on the errors that B2 asks for a new url after, and cur
+// keeps the url in use for the next parts. It returns the sha1 of the data.
+func (d *BackblazeB2) uploadRetry(ctx context.Context, cur *UploadUrlResp, getUrl func() (UploadUrlResp, error), r io.ReaderAt, off, n int64, header map[string]string) (string, error) {
+	for retry := 0; ; retry++ {
+		if cur.UploadUrl == "" {
+			u, err := getUrl()
+			if err != nil {
+				return "", err
+			}
+			*cur = u
+		}
+		body := newSha1Suffix(io.NewSectionReader(r, off, n))
+		err := d.upload(ctx, cur.UploadUrl, cur.AuthorizationToken, body, n, header)
+		if err == nil {
+			return body.Sum(), nil
+		}
+		var ue *uploadError
+		if !errors.As(err, &ue) || !ue.retryable || retry >= d.MaxRetry {
+			return "", err
+		}
+		*cur = UploadUrlResp{}
+		wait := ue.wait
+		if wait == 0 {
+			wait = retryAfter(nil, retry)
+		}
+		log.Debugf("[b2] upload failed, retry with a new url after %s: %+v", wait, err)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (d *BackblazeB2) uploadFile(ctx context.Context, key string, r io.ReaderAt, size int64) error {
+	var cur UploadUrlResp
+	_, err := d.uploadRetry(ctx, &cur, func() (UploadUrlResp, error) {
+		var resp UploadUrlResp
+		err := d.request(ctx, "b2_get_upload_url", base.Json{
+			"bucketId": d.bucketID,
+		}, &resp)
+		return resp, err
+	}, r, 0, size, map[string]string{
+		"X-Bz-File-Name": encodeName(key),
+		"Content-Type":   "b2/x-auto",
+	})
+	return err
+}
+
+func (d *BackblazeB2) startLargeFile(ctx context.Context, key string) (string, error) {
+	var resp File
+	err := d.request(ctx, "b2_start_large_file", base.Json{
+		"bucketId":    d.bucketID,
+		"fileName":    key,
+		"contentType": "b2/x-auto",
+	}, &resp)
+	return resp.FileID, err
+}
+
+func (d *BackblazeB2) finishLargeFile(ctx context.Context, fileID string, sha1s []string) error {
+	err := d.request(ctx, "b2_finish_large_file", base.Json{
+		"fileId":        fileID,
+		"partSha1Array": sha1s,
+	}, nil)
+	if err != nil {
+		d.cancelLargeFile(fileID)
+	}
+	return err
+}
+
+func (d *BackblazeB2) cancelLargeFile(fileID string) {
+	err := d.request(context.Background(), "b2_cancel_large_file", base.Json{
+		"fileId": fileID,
+	}, nil)
+	if err != nil {
+		log.Errorf("[b2] failed cancel large file %s: %+v", fileID, err)
+	}
+}
+
+func (d *BackblazeB2) uploadLargeFile(ctx context.Context, key string, r io.ReaderAt, size int64, up func(int)) error {
+	fileID, err := d.startLargeFile(ctx, key)
+	if err != nil {
+		return err
+	}
+	getUrl := func() (UploadUrlResp, error) {
+		var resp UploadUrlResp
+		err := d.request(ctx, "b2_get_upload_part_url", base.Json{
+			"fileId": fileID,
+		}, &resp)
+		return resp, err
+	}
+	var cur UploadUrlResp
+	sha1s := make([]string, 0, size/d.partSize+1)
+	for done, part := int64(0), 1; done < size; part++ {
+		n := d.partSize
+		if size-done < n {
+			n = size - done
+		}
+		sum, err := d.uploadRetry(ctx, &cur, getUrl, r, done, n, map[string]string{
+			"X-Bz-Part-Number": strconv.Itoa(part),
+		})
+		if err != nil {
+			d.cancelLargeFile(fileID)
+			return errors.WithMessagef(err, "failed upload part %d", part)
+		}
+		sha1s = append(sha1s, sum)
+		done += n
+		up(int(done * 100 / size))
+	}
+	return d.finishLargeFile(ctx, fileID, sha1s)
+}
+
+// encodeName encode file name for X-Bz-File-Name header, keep the slashes
+func encodeName(name string) string {
+	return strings.ReplaceAll(url.PathEscape(name), "%2F", "/")
+}
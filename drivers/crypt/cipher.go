@@ -0,0 +1,512 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// the file format and the name encryption are the same as rclone crypt,
+// see https://rclone.org/crypt/#file-formats
+
+const (
+	fileMagic       = "RCLONE\x00\x00"
+	fileNonceSize   = 24
+	fileHeaderSize  = len(fileMagic) + fileNonceSize
+	blockHeaderSize = secretbox.Overhead
+	blockDataSize   = 64 * 1024
+	blockSize       = blockHeaderSize + blockDataSize
+	nameBlockSize   = aes.BlockSize
+	obfuscQuoteRune = '!'
+	offSuffix       = ".bin"
+)
+
+var defaultSalt = []byte{0xA8, 0x0D, 0xF4, 0x3A, 0x8F, 0xBD, 0x03, 0x08, 0xA7, 0xCA, 0xB8, 0x3E, 0x58, 0x1F, 0x86, 0xB1}
+
+var (
+	ErrNotEncrypted   = errors.New("not an encrypted file")
+	ErrBadBlock       = errors.New("failed to authenticate decrypted block, bad password?")
+	ErrBadPadding     = errors.New("bad padding of encrypted name")
+	ErrBadNameLength  = errors.New("bad length of encrypted name")
+	ErrTooShortHeader = errors.New("file is too short to be encrypted")
+)
+
+type Cipher struct {
+	dataKey   [32]byte
+	nameKey   [32]byte
+	nameTweak [nameBlockSize]byte
+	block     cipher.Block
+	mode      string
+	dirName   bool
+	encoding  string
+	rand      io.Reader
+}
+
+func NewCipher(password, salt, mode string, dirName bool, encoding string) (*Cipher, error) {
+	c := &Cipher{mode: mode, dirName: dirName, encoding: encoding, rand: rand.Reader}
+	saltBytes := defaultSalt
+	if salt != "" {
+		saltBytes = []byte(salt)
+	}
+	keySize := len(c.dataKey) + len(c.nameKey) + len(c.nameTweak)
+	key := make([]byte, keySize)
+	if password != "" {
+		var err error
+		key, err = scrypt.Key([]byte(password), saltBytes, 16384, 8, 1, keySize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	copy(c.dataKey[:], key)
+	copy(c.nameKey[:], key[len(c.dataKey):])
+	copy(c.nameTweak[:], key[len(c.dataKey)+len(c.nameKey):])
+	var err error
+	c.block, err = aes.NewCipher(c.nameKey[:])
+	return c, err
+}
+
+func (c *Cipher) encode(src []byte) string {
+	if c.encoding == "base64" {
+		return base64.RawURLEncoding.EncodeToString(src)
+	}
+	return strings.ToLower(strings.TrimRight(base32.HexEncoding.EncodeToString(src), "="))
+}
+
+func (c *Cipher) decode(s string) ([]byte, error) {
+	if c.encoding == "base64" {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	if strings.HasSuffix(s, "=") {
+		return nil, ErrNotEncrypted
+	}
+	if n := len(s) % 8; n != 0 {
+		s += strings.Repeat("=", 8-n)
+	}
+	return base32.HexEncoding.DecodeString(strings.ToUpper(s))
+}
+
+func pad(b []byte) []byte {
+	n := nameBlockSize - len(b)%nameBlockSize
+	return append(b, bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(b []byte) ([]byte, error) {
+	if len(b) == 0 || len(b)%nameBlockSize != 0 {
+		return nil, ErrBadPadding
+	}
+	n := int(b[len(b)-1])
+	if n == 0 || n > nameBlockSize {
+		return nil, ErrBadPadding
+	}
+	for _, p := range b[len(b)-n:] {
+		if int(p) != n {
+			return nil, ErrBadPadding
+		}
+	}
+	return b[:len(b)-n], nil
+}
+
+func (c *Cipher) encryptSegment(plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+	return c.encode(emeTransform(c.block, c.nameTweak[:], pad([]byte(plaintext)), true))
+}
+
+func (c *Cipher) decryptSegment(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	raw, err := c.decode(ciphertext)
+	if err != nil {
+		return "", ErrNotEncrypted
+	}
+	if len(raw) == 0 || len(raw)%nameBlockSize != 0 || len(raw) > 16*8*nameBlockSize {
+		return "", ErrBadNameLength
+	}
+	plain, err := unpad(emeTransform(c.block, c.nameTweak[:], raw, false))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (c *Cipher) obfuscateSegment(plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+	if !utf8.ValidString(plaintext) {
+		return "!." + plaintext
+	}
+	// a simple rotation based on the name and the name key
+	var dir int
+	for _, r := range plaintext {
+		dir += int(r)
+	}
+	dir = dir % 256
+	var result bytes.Buffer
+	result.WriteString(strconv.Itoa(dir) + ".")
+	for i := 0; i < len(c.nameKey); i++ {
+		dir += int(c.nameKey[i])
+	}
+	for _, r := range plaintext {
+		switch {
+		case r == obfuscQuoteRune:
+			result.WriteRune(obfuscQuoteRune)
+			result.WriteRune(obfuscQuoteRune)
+		case r >= '0' && r <= '9':
+			thisDir := (dir % 9) + 1
+			result.WriteRune(rune('0' + (int(r)-'0'+thisDir)%10))
+		case (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z'):
+			thisDir := dir%25 + 1
+			pos := int(r - 'A')
+			if pos >= 26 {
+				pos -= 6
+			}
+			pos = (pos + thisDir) % 52
+			if pos >= 26 {
+				pos += 6
+			}
+			result.WriteRune(rune('A' + pos))
+		case r >= 0xA0 && r <= 0xFF:
+			thisDir := (dir % 95) + 1
+			result.WriteRune(rune(0xA0 + (int(r)-0xA0+thisDir)%96))
+		case r >= 0x100:
+			thisDir := (dir % 127) + 1
+			base := int(r - r%256)
+			newRune := rune(base + (int(r)-base+thisDir)%256)
+			if !utf8.ValidRune(newRune) {
+				result.WriteRune(obfuscQuoteRune)
+				result.WriteRune(r)
+			} else {
+				result.WriteRune(newRune)
+			}
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String()
+}
+
+func (c *Cipher) deobfuscateSegment(ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	pos := strings.Index(ciphertext, ".")
+	if pos == -1 {
+		return "", ErrNotEncrypted
+	}
+	num := ciphertext[:pos]
+	if num == "!" {
+		return ciphertext[pos+1:], nil
+	}
+	dir, err := strconv.Atoi(num)
+	if err != nil {
+		return "", ErrNotEncrypted
+	}
+	for i := 0; i < len(c.nameKey); i++ {
+		dir += int(c.nameKey[i])
+	}
+	var result bytes.Buffer
+	inQuote := false
+	for _, r := range ciphertext[pos+1:] {
+		switch {
+		case inQuote:
+			result.WriteRune(r)
+			inQuote = false
+		case r == obfuscQuoteRune:
+			inQuote = true
+		case r >= '0' && r <= '9':
+			thisDir := (dir % 9) + 1
+			newRune := '0' + int(r) - '0' - thisDir
+			if newRune < '0' {
+				newRune += 10
+			}
+			result.WriteRune(rune(newRune))
+		case (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z'):
+			thisDir := dir%25 + 1
+			pos := int(r - 'A')
+			if pos >= 26 {
+				pos -= 6
+			}
+			pos = pos - thisDir
+			if pos < 0 {
+				pos += 52
+			}
+			if pos >= 26 {
+				pos += 6
+			}
+			result.WriteRune(rune('A' + pos))
+		case r >= 0xA0 && r <= 0xFF:
+			thisDir := (dir % 95) + 1
+			newRune := 0xA0 + int(r) - 0xA0 - thisDir
+			if newRune < 0xA0 {
+				newRune += 96
+			}
+			result.WriteRune(rune(newRune))
+		case r >= 0x100:
+			thisDir := (dir % 127) + 1
+			base := int(r - r%256)
+			newRune := rune(int(r) - thisDir)
+			if int(newRune) < base {
+				newRune += 256
+			}
+			result.WriteRune(newRune)
+		default:
+			result.WriteRune(r)
+		}
+	}
+	return result.String(), nil
+}
+
+func (c *Cipher) encryptName(name string) string {
+	switch c.mode {
+	case "off":
+		return name
+	case "obfuscate":
+		return c.obfuscateSegment(name)
+	default:
+		return c.encryptSegment(name)
+	}
+}
+
+func (c *Cipher) decryptName(name string) (string, error) {
+	switch c.mode {
+	case "off":
+		return name, nil
+	case "obfuscate":
+		return c.deobfuscateSegment(name)
+	default:
+		return c.decryptSegment(name)
+	}
+}
+
+// EncryptFileName encrypt the name of a file
+func (c *Cipher) EncryptFileName(name string) string {
+	if c.mode == "off" {
+		return name + offSuffix
+	}
+	return c.encryptName(name)
+}
+
+// DecryptFileName decrypt the name of a file
+func (c *Cipher) DecryptFileName(name string) (string, error) {
+	if c.mode == "off" {
+		if !strings.HasSuffix(name, offSuffix) || len(name) == len(offSuffix) {
+			return "", ErrNotEncrypted
+		}
+		return strings.TrimSuffix(name, offSuffix), nil
+	}
+	return c.decryptName(name)
+}
+
+// EncryptDirName encrypt the name of a directory
+func (c *Cipher) EncryptDirName(name string) string {
+	if !c.dirName {
+		return name
+	}
+	return c.encryptName(name)
+}
+
+// DecryptDirName decrypt the name of a directory
+func (c *Cipher) DecryptDirName(name string) (string, error) {
+	if !c.dirName {
+		return name, nil
+	}
+	return c.decryptName(name)
+}
+
+// EncryptedSize is the size of the encrypted file with the plain size
+func EncryptedSize(size int64) int64 {
+	blocks, residue := size/blockDataSize, size%blockDataSize
+	encrypted := int64(fileHeaderSize) + blocks*blockSize
+	if residue != 0 {
+		encrypted += blockHeaderSize + residue
+	}
+	return encrypted
+}
+
+// DecryptedSize is the size of the plain file with the encrypted size
+func DecryptedSize(size int64) (int64, error) {
+	size -= int64(fileHeaderSize)
+	if size < 0 {
+		return 0, ErrTooShortHeader
+	}
+	blocks, residue := size/blockSize, size%blockSize
+	decrypted := blocks * blockDataSize
+	if residue != 0 {
+		residue -= blockHeaderSize
+		if residue <= 0 {
+			return 0, ErrBadBlock
+		}
+	}
+	return decrypted + residue, nil
+}
+
+type nonce [fileNonceSize]byte
+
+// increment the nonce as a little endian number
+func (n *nonce) increment() {
+	for i := 0; i < len(*n); i++ {
+		digit := (*n)[i]
+		(*n)[i] = digit + 1
+		if (*n)[i] >= digit {
+			return
+		}
+	}
+}
+
+// add x to the nonce as a little endian number
+func (n *nonce) add(x uint64) {
+	carry := uint16(0)
+	for i := 0; i < 8; i++ {
+		digit := (*n)[i]
+		xDigit := byte(x)
+		x >>= 8
+		carry += uint16(digit) + uint16(xDigit)
+		(*n)[i] = byte(carry)
+		carry >>= 8
+	}
+	if carry != 0 {
+		for i := 8; i < len(*n); i++ {
+			digit := (*n)[i]
+			(*n)[i] = digit + 1
+			if (*n)[i] >= digit {
+				break
+			}
+		}
+	}
+}
+
+type encrypter struct {
+	c     *Cipher
+	in    io.Reader
+	nonce nonce
+	buf   []byte
+	out   []byte
+	err   error
+}
+
+// EncryptReader return a reader of the encrypted data of in
+func (c *Cipher) EncryptReader(in io.Reader) (io.Reader, error) {
+	e := &encrypter{c: c, in: in, buf: make([]byte, blockDataSize)}
+	if _, err := io.ReadFull(e.c.rand, e.nonce[:]); err != nil {
+		return nil, err
+	}
+	e.out = append([]byte(fileMagic), e.nonce[:]...)
+	return e, nil
+}
+
+func (e *encrypter) Read(p []byte) (int, error) {
+	if len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		n, err := io.ReadFull(e.in, e.buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if n > 0 {
+			e.out = secretbox.Seal(e.out[:0], e.buf[:n], (*[24]byte)(&e.nonce), &e.c.dataKey)
+			e.nonce.increment()
+		}
+		e.err = err
+		if err != nil && n == 0 {
+			return 0, err
+		}
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+type decrypter struct {
+	c       *Cipher
+	in      io.ReadCloser
+	nonce   nonce
+	buf     []byte
+	out     []byte
+	discard int
+	limit   int64
+	err     error
+}
+
+// DecryptReader return a reader of the plain data, in must start at the block of offset,
+// which is BlockOffset(offset), and the header of the file is needed for the nonce
+func (c *Cipher) DecryptReader(header []byte, in io.ReadCloser, offset, limit int64) (io.ReadCloser, error) {
+	if len(header) < fileHeaderSize {
+		return nil, ErrTooShortHeader
+	}
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, ErrNotEncrypted
+	}
+	d := &decrypter{c: c, in: in, buf: make([]byte, blockSize), limit: limit}
+	copy(d.nonce[:], header[len(fileMagic):fileHeaderSize])
+	d.nonce.add(uint64(offset / blockDataSize))
+	d.discard = int(offset % blockDataSize)
+	return d, nil
+}
+
+// BlockOffset return the offset in the encrypted file of the block contains offset
+func BlockOffset(offset int64) int64 {
+	return int64(fileHeaderSize) + offset/blockDataSize*blockSize
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	if d.limit <= 0 {
+		return 0, io.EOF
+	}
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		n, err := io.ReadFull(d.in, d.buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if n > 0 {
+			if n <= blockHeaderSize {
+				return 0, ErrBadBlock
+			}
+			out, ok := secretbox.Open(d.out[:0], d.buf[:n], (*[24]byte)(&d.nonce), &d.c.dataKey)
+			if !ok {
+				return 0, ErrBadBlock
+			}
+			d.nonce.increment()
+			if d.discard > 0 {
+				if d.discard > len(out) {
+					d.discard = len(out)
+				}
+				out = out[d.discard:]
+				d.discard = 0
+			}
+			d.out = out
+		}
+		d.err = err
+		if err != nil && n == 0 {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > d.limit {
+		p = p[:d.limit]
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	d.limit -= int64(n)
+	return n, nil
+}
+
+func (d *decrypter) Close() error {
+	return d.in.Close()
+}
@@ -0,0 +1,137 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// the expected values are from the tests of rclone crypt
+
+func TestKey(t *testing.T) {
+	c, err := NewCipher("potato", "", "standard", true, "base32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey := [32]byte{0x74, 0x55, 0xC7, 0x1A, 0xB1, 0x7C, 0x86, 0x5B, 0x84, 0x71, 0xF4, 0x7B, 0x79, 0xAC, 0xB0, 0x7E, 0xB3, 0x1D, 0x56, 0x78, 0xB8, 0x0C, 0x7E, 0x2E, 0xAF, 0x4F, 0xC8, 0x06, 0x6A, 0x9E, 0xE4, 0x68}
+	if c.dataKey != dataKey {
+		t.Errorf("data key: got %x", c.dataKey)
+	}
+	nameTweak := [16]byte{0xC1, 0x8D, 0x59, 0x32, 0xF5, 0x5B, 0x28, 0x28, 0xC5, 0xE1, 0xE8, 0x72, 0x15, 0x52, 0x03, 0x10}
+	if c.nameTweak != nameTweak {
+		t.Errorf("name tweak: got %x", c.nameTweak)
+	}
+}
+
+func TestEncryptFileName(t *testing.T) {
+	tests := []struct {
+		mode, encoding, in, out string
+	}{
+		{"standard", "base32", "1", "p0e52nreeaj0a5ea7s64m4j72s"},
+		{"standard", "base32", "123", "qgm4avr35m5loi1th53ato71v0"},
+		{"standard", "base32", "1234567890123456", "mijbj0frqf6ms7frcr6bd9h0env53jv96pjaaoirk7forcgpt70g"},
+		{"standard", "base64", "12", "qQUDHOGN_jVdLIMQzYrhvA"},
+		{"standard", "base64", "1234567890123456", "tKa5gfvTzW4d-2bMtqYgdf5Rz-k2ZqViW6HfjbIZ6cE"},
+		{"obfuscate", "base32", "!hello", "53.!!lipps"},
+		{"obfuscate", "base32", "¡", "161.ä"},
+		{"obfuscate", "base32", "Π", "160.ς"},
+		{"off", "base32", "123", "123.bin"},
+	}
+	for _, test := range tests {
+		c, err := NewCipher("", "", test.mode, true, test.encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.EncryptFileName(test.in); got != test.out {
+			t.Errorf("encrypt %s %q: got %q, want %q", test.mode, test.in, got, test.out)
+		}
+		if got, err := c.DecryptFileName(test.out); err != nil || got != test.in {
+			t.Errorf("decrypt %s %q: got %q, %v", test.mode, test.out, got, err)
+		}
+	}
+}
+
+// sequenceReader return 1, 2, 3... as the nonce like rclone tests
+type sequenceReader struct{ i byte }
+
+func (r *sequenceReader) Read(p []byte) (int, error) {
+	for i := range p {
+		r.i++
+		p[i] = r.i
+	}
+	return len(p), nil
+}
+
+func TestEncryptData(t *testing.T) {
+	header := []byte{
+		0x52, 0x43, 0x4c, 0x4f, 0x4e, 0x45, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18,
+	}
+	tests := []struct {
+		in, out []byte
+	}{
+		{[]byte{}, header},
+		{[]byte{1}, append(append([]byte{}, header...),
+			0x09, 0x5b, 0x44, 0x6c, 0xd6, 0x23, 0x7b, 0xbc, 0xb0, 0x8d, 0x09, 0xfb, 0x52, 0x4c, 0xe5, 0x65, 0xAA)},
+		{[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, append(append([]byte{}, header...),
+			0xb9, 0xc4, 0x55, 0x2a, 0x27, 0x10, 0x06, 0x29, 0x18, 0x96, 0x0a, 0x3e, 0x60, 0x8c, 0x29, 0xb9,
+			0xaa, 0x8a, 0x5e, 0x1e, 0x16, 0x5b, 0x6d, 0x07, 0x5d, 0xe4, 0xe9, 0xbb, 0x36, 0x7f, 0xd6, 0xd4)},
+	}
+	for _, test := range tests {
+		c, err := NewCipher("", "", "standard", true, "base32")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.rand = &sequenceReader{}
+		r, err := c.EncryptReader(bytes.NewReader(test.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, test.out) {
+			t.Errorf("encrypt %x: got %x", test.in, out)
+		}
+		if size := EncryptedSize(int64(len(test.in))); size != int64(len(out)) {
+			t.Errorf("encrypted size of %d: got %d", len(test.in), size)
+		}
+	}
+}
+
+func TestDecryptRange(t *testing.T) {
+	c, err := NewCipher("potato", "sausage", "standard", true, "base32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, 3*blockDataSize+100)
+	for i := range plain {
+		plain[i] = byte(i * 7)
+	}
+	r, err := c.EncryptReader(bytes.NewReader(plain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size, err := DecryptedSize(int64(len(encrypted))); err != nil || size != int64(len(plain)) {
+		t.Fatalf("decrypted size: got %d, %v", size, err)
+	}
+	for _, rng := range [][2]int64{{0, int64(len(plain))}, {10, 100}, {blockDataSize - 5, 10}, {2*blockDataSize + 1, blockDataSize + 99}} {
+		in := io.NopCloser(bytes.NewReader(encrypted[BlockOffset(rng[0]):]))
+		d, err := c.DecryptReader(encrypted[:fileHeaderSize], in, rng[0], rng[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := io.ReadAll(d)
+		if err != nil {
+			t.Fatalf("decrypt range %v: %v", rng, err)
+		}
+		if !bytes.Equal(out, plain[rng[0]:rng[0]+rng[1]]) {
+			t.Errorf("decrypt range %v: got wrong data", rng)
+		}
+	}
+}
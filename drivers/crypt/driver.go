@@ -0,0 +1,126 @@
+package crypt
+
+import (
+	"context"
+	"net/http"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Crypt struct {
+	model.Storage
+	Addition
+	cipher *Cipher
+}
+
+func (d *Crypt) Config() driver.Config {
+	return config
+}
+
+func (d *Crypt) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Crypt) Init(ctx context.Context) error {
+	d.RemotePath = utils.FixAndCleanPath(d.RemotePath)
+	if d.RemotePath == "/" || strings.HasPrefix(d.MountPath+"/", d.RemotePath+"/") ||
+		strings.HasPrefix(d.RemotePath+"/", d.MountPath+"/") {
+		return errors.New("remote path can't be the root or contain this storage")
+	}
+	var err error
+	d.cipher, err = NewCipher(d.Password, d.Salt, d.FilenameEncryption, d.DirectoryNameEncryption, d.FilenameEncoding)
+	return err
+}
+
+func (d *Crypt) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Crypt) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	objs, err := fs.List(ctx, d.remoteDir(dir.GetPath()), &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if o, ok := d.convertObj(obj); ok {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+func (d *Crypt) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	size := file.GetSize()
+	start, length := int64(0), size
+	link := &model.Link{}
+	if args.Header.Get("Range") != "" {
+		r, err := http_range.ParseRange(args.Header.Get("Range"), size)
+		if err == nil && len(r) > 0 {
+			start, length = r[0].Start, r[0].Length
+			link.Status = http.StatusPartialContent
+			link.Header = http.Header{
+				"Content-Range":  []string{r[0].ContentRange(size)},
+				"Content-Length": []string{strconv.FormatInt(length, 10)},
+			}
+		}
+	}
+	data, err := d.open(ctx, d.encryptPath(file.GetPath(), false), start, length)
+	if err != nil {
+		return nil, err
+	}
+	link.Data = data
+	return link, nil
+}
+
+func (d *Crypt) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return fs.MakeDir(ctx, d.encryptPath(stdpath.Join(parentDir.GetPath(), dirName), true))
+}
+
+func (d *Crypt) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return fs.Move(ctx, d.encryptPath(srcObj.GetPath(), srcObj.IsDir()), d.remoteDir(dstDir.GetPath()))
+}
+
+func (d *Crypt) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if srcObj.IsDir() {
+		newName = d.cipher.EncryptDirName(newName)
+	} else {
+		newName = d.cipher.EncryptFileName(newName)
+	}
+	return fs.Rename(ctx, d.encryptPath(srcObj.GetPath(), srcObj.IsDir()), newName)
+}
+
+func (d *Crypt) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	_, err := fs.Copy(ctx, d.encryptPath(srcObj.GetPath(), srcObj.IsDir()), d.remoteDir(dstDir.GetPath()))
+	return err
+}
+
+func (d *Crypt) Remove(ctx context.Context, obj model.Obj) error {
+	return fs.Remove(ctx, d.encryptPath(obj.GetPath(), obj.IsDir()))
+}
+
+func (d *Crypt) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	r, err := d.cipher.EncryptReader(stream)
+	if err != nil {
+		return err
+	}
+	return fs.PutDirectly(ctx, d.remoteDir(dstDir.GetPath()), &model.FileStream{
+		Obj: &model.Object{
+			Name:     d.cipher.EncryptFileName(stream.GetName()),
+			Size:     EncryptedSize(stream.GetSize()),
+			Modified: stream.ModTime(),
+		},
+		ReadCloser: &utils.ReadCloser{Reader: r, Closer: stream},
+		Mimetype:   "application/octet-stream",
+	})
+}
+
+var _ driver.Driver = (*Crypt)(nil)
@@ -0,0 +1,74 @@
+package crypt
+
+import "crypto/cipher"
+
+// emeTransform is the EME (ECB-Mix-ECB) wide block mode used by rclone to encrypt names,
+// see https://eprint.iacr.org/2003/147.pdf
+func emeTransform(bc cipher.Block, tweak []byte, in []byte, encrypt bool) []byte {
+	const bs = 16
+	m := len(in) / bs
+	transform := bc.Decrypt
+	if encrypt {
+		transform = bc.Encrypt
+	}
+	out := make([]byte, len(in))
+	// L_j = 2^j * AES(K, 0)
+	lTable := make([][]byte, m)
+	li := make([]byte, bs)
+	bc.Encrypt(li, make([]byte, bs))
+	for j := 0; j < m; j++ {
+		multByTwo(li, li)
+		lTable[j] = append([]byte(nil), li...)
+	}
+	ppj := make([]byte, bs)
+	for j := 0; j < m; j++ {
+		xorBlocks(ppj, in[j*bs:(j+1)*bs], lTable[j])
+		transform(out[j*bs:(j+1)*bs], ppj)
+	}
+	mp := make([]byte, bs)
+	xorBlocks(mp, out[0:bs], tweak)
+	for j := 1; j < m; j++ {
+		xorBlocks(mp, mp, out[j*bs:(j+1)*bs])
+	}
+	mc := make([]byte, bs)
+	transform(mc, mp)
+	mm := make([]byte, bs)
+	xorBlocks(mm, mp, mc)
+	for j := 1; j < m; j++ {
+		multByTwo(mm, mm)
+		xorBlocks(out[j*bs:(j+1)*bs], out[j*bs:(j+1)*bs], mm)
+	}
+	ccc1 := make([]byte, bs)
+	xorBlocks(ccc1, mc, tweak)
+	for j := 1; j < m; j++ {
+		xorBlocks(ccc1, ccc1, out[j*bs:(j+1)*bs])
+	}
+	copy(out[0:bs], ccc1)
+	for j := 0; j < m; j++ {
+		transform(out[j*bs:(j+1)*bs], out[j*bs:(j+1)*bs])
+		xorBlocks(out[j*bs:(j+1)*bs], out[j*bs:(j+1)*bs], lTable[j])
+	}
+	return out
+}
+
+// multByTwo multiply the block by 2 in GF(2^128)
+func multByTwo(out, in []byte) {
+	tmp := make([]byte, 16)
+	tmp[0] = 2 * in[0]
+	if in[15] >= 128 {
+		tmp[0] ^= 135
+	}
+	for j := 1; j < 16; j++ {
+		tmp[j] = 2 * in[j]
+		if in[j-1] >= 128 {
+			tmp[j] += 1
+		}
+	}
+	copy(out, tmp)
+}
+
+func xorBlocks(out, in1, in2 []byte) {
+	for i := range in1 {
+		out[i] = in1[i] ^ in2[i]
+	}
+}
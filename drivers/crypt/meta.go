@@ -0,0 +1,30 @@
+package crypt
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	RemotePath              string `json:"remote_path" required:"true" help:"The mount path of the storage to encrypt, like /local/encrypted"`
+	Password                string `json:"password" required:"true" help:"Same as the password of rclone crypt, not the obscured one in rclone.conf"`
+	Salt                    string `json:"salt" help:"Same as password2 of rclone crypt, leave empty to use the default salt"`
+	FilenameEncryption      string `json:"filename_encryption" type:"select" options:"standard,obfuscate,off" default:"standard"`
+	DirectoryNameEncryption bool   `json:"directory_name_encryption" type:"bool" default:"true"`
+	FilenameEncoding        string `json:"filename_encoding" type:"select" options:"base32,base64" default:"base32"`
+	ShowNotEncrypted        bool   `json:"show_not_encrypted" type:"bool" default:"false" help:"Show the files that can't be decrypted with their raw names"`
+}
+
+var config = driver.Config{
+	Name:        "Crypt",
+	LocalSort:   true,
+	OnlyProxy:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Crypt{}
+	})
+}
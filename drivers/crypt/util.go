@@ -0,0 +1,161 @@
+package crypt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// do others that not defined in Driver interface
+
+// encryptPath convert the path in this storage to the path in the remote storage
+func (d *Crypt) encryptPath(path string, isDir bool) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return d.RemotePath
+	}
+	seg := strings.Split(path, "/")
+	for i := range seg {
+		if i == len(seg)-1 && !isDir {
+			seg[i] = d.cipher.EncryptFileName(seg[i])
+		} else {
+			seg[i] = d.cipher.EncryptDirName(seg[i])
+		}
+	}
+	return stdpath.Join(d.RemotePath, stdpath.Join(seg...))
+}
+
+func (d *Crypt) convertObj(obj model.Obj) (model.Obj, bool) {
+	var name string
+	var err error
+	size := obj.GetSize()
+	if obj.IsDir() {
+		name, err = d.cipher.DecryptDirName(obj.GetName())
+	} else {
+		name, err = d.cipher.DecryptFileName(obj.GetName())
+		if err == nil {
+			size, err = DecryptedSize(size)
+		}
+	}
+	if err != nil {
+		if !d.ShowNotEncrypted {
+			return nil, false
+		}
+		name, size = obj.GetName(), obj.GetSize()
+	}
+	return &model.Object{
+		Name:     name,
+		Size:     size,
+		Modified: obj.ModTime(),
+		IsFolder: obj.IsDir(),
+	}, true
+}
+
+// openRemote open the remote file at offset, it takes care of the different kinds of link
+func openRemote(ctx context.Context, link *model.Link, offset int64) (io.ReadCloser, error) {
+	if link.FilePath != nil && *link.FilePath != "" {
+		f, err := os.Open(*link.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+	if link.Data != nil {
+		// the data is already at offset if the range is handled
+		if link.Status == http.StatusPartialContent {
+			return link.Data, nil
+		}
+		if s, ok := link.Data.(io.Seeker); ok {
+			if _, err := s.Seek(offset, io.SeekStart); err != nil {
+				_ = link.Data.Close()
+				return nil, err
+			}
+			return link.Data, nil
+		}
+		if _, err := io.CopyN(io.Discard, link.Data, offset); err != nil {
+			_ = link.Data.Close()
+			return nil, err
+		}
+		return link.Data, nil
+	}
+	if link.URL == "" {
+		return nil, errors.New("the remote storage doesn't provide a readable link")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		return res.Body, nil
+	case http.StatusOK:
+		if _, err = io.CopyN(io.Discard, res.Body, offset); err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+		return res.Body, nil
+	}
+	_ = res.Body.Close()
+	return nil, errors.Errorf("failed read remote file: %s", res.Status)
+}
+
+// open the remote file and return the plain data from offset
+func (d *Crypt) open(ctx context.Context, remotePath string, offset, length int64) (io.ReadCloser, error) {
+	link, _, err := fs.Link(ctx, remotePath, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	r, err := openRemote(ctx, link, 0)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, fileHeaderSize)
+	if _, err = io.ReadFull(r, header); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	blockOffset := BlockOffset(offset)
+	if blockOffset > int64(fileHeaderSize) {
+		// read the blocks from the one contains offset
+		_ = r.Close()
+		link, _, err = fs.Link(ctx, remotePath, model.LinkArgs{
+			Header: http.Header{"Range": []string{fmt.Sprintf("bytes=%d-", blockOffset)}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if r, err = openRemote(ctx, link, blockOffset); err != nil {
+			return nil, err
+		}
+	}
+	return d.cipher.DecryptReader(header, r, offset, length)
+}
+
+func (d *Crypt) remoteDir(path string) string {
+	if utils.PathEqual(path, "/") {
+		return d.RemotePath
+	}
+	return d.encryptPath(path, true)
+}
@@ -0,0 +1,181 @@
+package google_cloud_storage
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+type GoogleCloudStorage struct {
+	model.Storage
+	Addition
+	account     ServiceAccount
+	privateKey  *rsa.PrivateKey
+	email       string
+	accessToken string
+	tokenExpire time.Time
+	keySha256   string
+}
+
+func (d *GoogleCloudStorage) Config() driver.Config {
+	if d.EncryptionKey != "" {
+		// the links carry the token and the key in headers
+		c := config
+		c.OnlyProxy = true
+		return c
+	}
+	return config
+}
+
+func (d *GoogleCloudStorage) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *GoogleCloudStorage) Init(ctx context.Context) error {
+	d.privateKey, d.tokenExpire = nil, time.Time{}
+	if d.AuthType == "workload_identity" {
+		if err := d.getEmail(ctx); err != nil {
+			return errors.WithMessage(err, "failed get service account from metadata server")
+		}
+	} else if err := d.parseServiceAccount(); err != nil {
+		return err
+	}
+	if d.EncryptionKey != "" {
+		key, err := base64.StdEncoding.DecodeString(d.EncryptionKey)
+		if err != nil || len(key) != 32 {
+			return errors.New("encryption key must be a base64 encoded 256 bit key")
+		}
+		h := sha256.Sum256(key)
+		d.keySha256 = base64.StdEncoding.EncodeToString(h[:])
+	}
+	if err := d.refreshToken(ctx); err != nil {
+		return err
+	}
+	// check the bucket
+	_, err := d.request(ctx, "GET", apiUrl+"/b/"+d.Bucket, nil, nil)
+	return err
+}
+
+func (d *GoogleCloudStorage) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *GoogleCloudStorage) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	prefix := getKey(dir.GetPath(), true)
+	objects, prefixes, err := d.list(ctx, prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]model.Obj, 0, len(objects)+len(prefixes))
+	for _, p := range prefixes {
+		name := strings.TrimSuffix(strings.TrimPrefix(p, prefix), "/")
+		objs = append(objs, &model.Object{
+			Name:     name,
+			Path:     stdpath.Join(dir.GetPath(), name),
+			Modified: d.Modified,
+			IsFolder: true,
+		})
+	}
+	for _, o := range objects {
+		// the directory placeholder created by console
+		if o.Name == prefix {
+			continue
+		}
+		name := strings.TrimPrefix(o.Name, prefix)
+		obj := objectToObj(o, name)
+		obj.Path = stdpath.Join(dir.GetPath(), name)
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+func (d *GoogleCloudStorage) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	key := getKey(file.GetPath(), false)
+	if d.EncryptionKey != "" {
+		// the key must be sent in headers, so it's always proxied
+		if time.Now().After(d.tokenExpire) {
+			if err := d.refreshToken(ctx); err != nil {
+				return nil, err
+			}
+		}
+		header := map[string][]string{
+			"Authorization": {"Bearer " + d.accessToken},
+		}
+		for k, v := range d.encryptionHeaders("x-goog-") {
+			header[k] = []string{v}
+		}
+		return &model.Link{
+			URL:    d.objectUrl(key) + "?alt=media",
+			Header: header,
+		}, nil
+	}
+	expire := time.Duration(d.SignURLExpire) * time.Hour
+	u, err := d.signUrl(ctx, key, expire)
+	if err != nil {
+		return nil, err
+	}
+	exp := expire - time.Minute
+	return &model.Link{
+		URL:        u,
+		Expiration: &exp,
+	}, nil
+}
+
+func (d *GoogleCloudStorage) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return d.putObject(ctx, getKey(stdpath.Join(parentDir.GetPath(), dirName), true), emptyReader())
+}
+
+func (d *GoogleCloudStorage) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if err := d.Copy(ctx, srcObj, dstDir); err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *GoogleCloudStorage) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	dst := stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName)
+	var err error
+	if srcObj.IsDir() {
+		err = d.copyDir(ctx, srcObj.GetPath(), dst)
+	} else {
+		err = d.copyObject(ctx, getKey(srcObj.GetPath(), false), getKey(dst, false))
+	}
+	if err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *GoogleCloudStorage) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dst := stdpath.Join(dstDir.GetPath(), srcObj.GetName())
+	if srcObj.IsDir() {
+		return d.copyDir(ctx, srcObj.GetPath(), dst)
+	}
+	return d.copyObject(ctx, getKey(srcObj.GetPath(), false), getKey(dst, false))
+}
+
+func (d *GoogleCloudStorage) Remove(ctx context.Context, obj model.Obj) error {
+	if obj.IsDir() {
+		return d.removeDir(ctx, obj.GetPath())
+	}
+	return d.removeObject(ctx, getKey(obj.GetPath(), false))
+}
+
+func (d *GoogleCloudStorage) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	if stream.GetSize() <= int64(d.ChunkSize)*1024*1024 {
+		return d.putObject(ctx, key, io.TeeReader(stream, driver.NewProgress(stream.GetSize(), up)))
+	}
+	return d.resumableUpload(ctx, key, stream, stream.GetSize(), up)
+}
+
+var _ driver.Driver = (*GoogleCloudStorage)(nil)
@@ -0,0 +1,29 @@
+package google_cloud_storage
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	Bucket             string `json:"bucket" required:"true"`
+	AuthType           string `json:"auth_type" type:"select" options:"service_account,workload_identity" default:"service_account" help:"workload_identity uses the metadata server of GCE/GKE/Cloud Run"`
+	ServiceAccountJSON string `json:"service_account_json" type:"text" help:"Content of the JSON key file of the service account"`
+	EncryptionKey      string `json:"encryption_key" help:"Base64 encoded AES-256 customer-supplied encryption key, the files are proxied since signed urls can not carry the key"`
+	SignURLExpire      int    `json:"sign_url_expire" type:"number" default:"4"`
+	ChunkSize          int    `json:"chunk_size" type:"number" default:"8" help:"Chunk size of resumable upload in MB"`
+}
+
+var config = driver.Config{
+	Name:        "GoogleCloudStorage",
+	DefaultRoot: "/",
+	LocalSort:   true,
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &GoogleCloudStorage{}
+	})
+}
@@ -0,0 +1,69 @@
+package google_cloud_storage
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenUri     string `json:"token_uri"`
+}
+
+type TokenResp struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type ErrResp struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type Object struct {
+	Name    string    `json:"name"`
+	Size    string    `json:"size"`
+	Updated time.Time `json:"updated"`
+	Md5Hash string    `json:"md5Hash"`
+}
+
+type ObjectsResp struct {
+	Items         []Object `json:"items"`
+	Prefixes      []string `json:"prefixes"`
+	NextPageToken string   `json:"nextPageToken"`
+}
+
+type RewriteResp struct {
+	Done         bool   `json:"done"`
+	RewriteToken string `json:"rewriteToken"`
+}
+
+type SignBlobResp struct {
+	SignedBlob string `json:"signedBlob"`
+}
+
+func objectToObj(o Object, name string) *model.Object {
+	size, _ := strconv.ParseInt(o.Size, 10, 64)
+	obj := &model.Object{
+		Name:     name,
+		Size:     size,
+		Modified: o.Updated,
+	}
+	// the md5 is in base64, and missing for composite objects
+	if sum, err := base64.StdEncoding.DecodeString(o.Md5Hash); err == nil && len(sum) > 0 {
+		obj.Hashes = map[string]string{model.HashMD5: hex.EncodeToString(sum)}
+	}
+	return obj
+}
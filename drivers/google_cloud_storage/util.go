@@ -0,0 +1,453 @@
+package google_cloud_storage
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/go-resty/resty/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+// do others that not defined in Driver interface
+
+const (
+	apiUrl      = "https://storage.googleapis.com/storage/v1"
+	uploadUrl   = "https://storage.googleapis.com/upload/storage/v1"
+	host        = "storage.googleapis.com"
+	metadataUrl = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default"
+	scope       = "https://www.googleapis.com/auth/devstorage.read_write"
+)
+
+func getKey(path string, dir bool) string {
+	path = strings.TrimPrefix(path, "/")
+	if path != "" && dir {
+		path += "/"
+	}
+	return path
+}
+
+func (d *GoogleCloudStorage) parseServiceAccount() error {
+	if err := utils.Json.UnmarshalFromString(d.ServiceAccountJSON, &d.account); err != nil {
+		return errors.WithMessage(err, "invalid service account json")
+	}
+	block, _ := pem.Decode([]byte(d.account.PrivateKey))
+	if block == nil {
+		return errors.New("invalid private key of service account")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return errors.WithMessage(err, "invalid private key of service account")
+		}
+	}
+	var ok bool
+	if d.privateKey, ok = key.(*rsa.PrivateKey); !ok {
+		return errors.New("private key of service account is not a rsa key")
+	}
+	if d.account.TokenUri == "" {
+		d.account.TokenUri = "https://oauth2.googleapis.com/token"
+	}
+	d.email = d.account.ClientEmail
+	return nil
+}
+
+func (d *GoogleCloudStorage) refreshToken(ctx context.Context) error {
+	var resp TokenResp
+	var err error
+	if d.AuthType == "workload_identity" {
+		_, err = base.RestyClient.R().SetContext(ctx).
+			SetHeader("Metadata-Flavor", "Google").
+			SetResult(&resp).
+			Get(metadataUrl + "/token")
+	} else {
+		now := time.Now()
+		assertion, e := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   d.account.ClientEmail,
+			"scope": scope,
+			"aud":   d.account.TokenUri,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		}).SignedString(d.privateKey)
+		if e != nil {
+			return e
+		}
+		_, err = base.RestyClient.R().SetContext(ctx).
+			SetFormData(map[string]string{
+				"grant_type": "urn:ietf:params:oauth:grant-type:jwt-bearer",
+				"assertion":  assertion,
+			}).
+			SetResult(&resp).
+			SetError(&resp).
+			Post(d.account.TokenUri)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Error != "" || resp.AccessToken == "" {
+		return errors.Errorf("failed get access token: %s %s", resp.Error, resp.ErrorDescription)
+	}
+	d.accessToken = resp.AccessToken
+	d.tokenExpire = time.Now().Add(time.Duration(resp.ExpiresIn-60) * time.Second)
+	return nil
+}
+
+func (d *GoogleCloudStorage) getEmail(ctx context.Context) error {
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetHeader("Metadata-Flavor", "Google").
+		Get(metadataUrl + "/email")
+	if err != nil {
+		return err
+	}
+	d.email = strings.TrimSpace(res.String())
+	return nil
+}
+
+// encryptionHeaders return the headers of customer-supplied encryption key,
+// prefix is "x-goog-" for the object itself and "x-goog-copy-source-" for the source of rewrite
+func (d *GoogleCloudStorage) encryptionHeaders(prefix string) map[string]string {
+	if d.EncryptionKey == "" {
+		return nil
+	}
+	return map[string]string{
+		prefix + "encryption-algorithm":  "AES256",
+		prefix + "encryption-key":        d.EncryptionKey,
+		prefix + "encryption-key-sha256": d.keySha256,
+	}
+}
+
+func (d *GoogleCloudStorage) request(ctx context.Context, method, u string, callback base.ReqCallback, resp interface{}) (*resty.Response, error) {
+	if time.Now().After(d.tokenExpire) {
+		if err := d.refreshToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var e ErrResp
+	req := base.RestyClient.R().
+		SetContext(ctx).
+		SetAuthToken(d.accessToken).
+		SetError(&e)
+	if callback != nil {
+		callback(req)
+	}
+	if resp != nil {
+		req.SetResult(resp)
+	}
+	res, err := req.Execute(method, u)
+	if err != nil {
+		return nil, err
+	}
+	if res.IsError() {
+		switch res.StatusCode() {
+		case http.StatusUnauthorized:
+			d.tokenExpire = time.Time{}
+		case http.StatusNotFound:
+			return nil, errs.ObjectNotFound
+		}
+		if e.Error.Message != "" {
+			return nil, errors.New(e.Error.Message)
+		}
+		return nil, errors.Errorf("gcs responded with status %d", res.StatusCode())
+	}
+	return res, nil
+}
+
+func (d *GoogleCloudStorage) objectUrl(key string) string {
+	return fmt.Sprintf("%s/b/%s/o/%s", apiUrl, url.PathEscape(d.Bucket), url.PathEscape(key))
+}
+
+func (d *GoogleCloudStorage) list(ctx context.Context, prefix, delimiter string) ([]Object, []string, error) {
+	objects := make([]Object, 0)
+	prefixes := make([]string, 0)
+	pageToken := ""
+	for {
+		var resp ObjectsResp
+		_, err := d.request(ctx, http.MethodGet, fmt.Sprintf("%s/b/%s/o", apiUrl, url.PathEscape(d.Bucket)), func(req *resty.Request) {
+			req.SetQueryParams(map[string]string{
+				"prefix":    prefix,
+				"delimiter": delimiter,
+				"pageToken": pageToken,
+				"fields":    "items(name,size,updated,md5Hash),prefixes,nextPageToken",
+			})
+		}, &resp)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, resp.Items...)
+		prefixes = append(prefixes, resp.Prefixes...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	return objects, prefixes, nil
+}
+
+func (d *GoogleCloudStorage) putObject(ctx context.Context, key string, body io.Reader) error {
+	_, err := d.request(ctx, http.MethodPost, fmt.Sprintf("%s/b/%s/o", uploadUrl, url.PathEscape(d.Bucket)), func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"uploadType": "media",
+			"name":       key,
+		}).SetHeaders(d.encryptionHeaders("x-goog-")).SetBody(body)
+	}, nil)
+	return err
+}
+
+// resumableUpload upload the stream in chunks to a resumable session,
+// a failed chunk is retried after asking the server how many bytes it has persisted
+func (d *GoogleCloudStorage) resumableUpload(ctx context.Context, key string, stream io.Reader, size int64, up func(int)) error {
+	res, err := d.request(ctx, http.MethodPost, fmt.Sprintf("%s/b/%s/o", uploadUrl, url.PathEscape(d.Bucket)), func(req *resty.Request) {
+		req.SetQueryParams(map[string]string{
+			"uploadType": "resumable",
+			"name":       key,
+		}).SetHeaders(d.encryptionHeaders("x-goog-")).
+			SetHeader("X-Upload-Content-Length", strconv.FormatInt(size, 10)).
+			SetBody(base.Json{"name": key})
+	}, nil)
+	if err != nil {
+		return err
+	}
+	session := res.Header().Get("Location")
+	if session == "" {
+		return errors.New("failed create resumable upload session")
+	}
+	// chunk size must be a multiple of 256 KiB
+	chunkSize := int64(d.ChunkSize) * 1024 * 1024
+	if chunkSize <= 0 {
+		chunkSize = 8 * 1024 * 1024
+	}
+	buf := make([]byte, chunkSize)
+	for done := int64(0); done < size; {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		n := chunkSize
+		if size-done < n {
+			n = size - done
+		}
+		_, err := io.ReadFull(stream, buf[:n])
+		if err != nil {
+			return err
+		}
+		// resend the buffered bytes from what the server committed
+		// until the whole chunk is committed
+		chunkStart, chunkEnd := done, done+n
+		for offset, retry := chunkStart, 0; offset < chunkEnd; retry++ {
+			if retry > 3 {
+				return errors.New("failed upload chunk: too many retries")
+			}
+			persisted, err := d.putChunk(ctx, session, buf[offset-chunkStart:n], offset, size)
+			if err != nil {
+				if retry >= 3 {
+					return err
+				}
+				if persisted, err = d.queryUpload(ctx, session, size); err != nil {
+					return err
+				}
+			}
+			if persisted < chunkStart || persisted > chunkEnd {
+				return errors.Errorf("unexpected committed offset %d of chunk %d-%d", persisted, chunkStart, chunkEnd)
+			}
+			offset = persisted
+		}
+		done = chunkEnd
+		up(int(done * 100 / size))
+	}
+	return nil
+}
+
+func (d *GoogleCloudStorage) putChunk(ctx context.Context, session string, chunk []byte, start, size int64) (int64, error) {
+	end := start + int64(len(chunk)) - 1
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetHeaders(d.encryptionHeaders("x-goog-")).
+		SetHeader("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size)).
+		SetBody(chunk).
+		Put(session)
+	if err != nil {
+		return 0, err
+	}
+	return committedOffset(res, size)
+}
+
+// queryUpload gets the committed offset of the upload session
+func (d *GoogleCloudStorage) queryUpload(ctx context.Context, session string, size int64) (int64, error) {
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetHeaders(d.encryptionHeaders("x-goog-")).
+		SetHeader("Content-Range", fmt.Sprintf("bytes */%d", size)).
+		Put(session)
+	if err != nil {
+		return 0, err
+	}
+	return committedOffset(res, size)
+}
+
+// committedOffset reads the offset the server committed from the response
+// of a chunk or a status query, the server may commit part of the chunk
+func committedOffset(res *resty.Response, size int64) (int64, error) {
+	switch res.StatusCode() {
+	case http.StatusOK, http.StatusCreated:
+		return size, nil
+	case http.StatusPermanentRedirect:
+	default:
+		return 0, errors.Errorf("failed upload chunk: %s", res.String())
+	}
+	// Range: bytes=0-42
+	r := res.Header().Get("Range")
+	if r == "" {
+		return 0, nil
+	}
+	i := strings.LastIndex(r, "-")
+	last, err := strconv.ParseInt(r[i+1:], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return last + 1, nil
+}
+
+func (d *GoogleCloudStorage) copyObject(ctx context.Context, src, dst string) error {
+	token := ""
+	for {
+		var resp RewriteResp
+		_, err := d.request(ctx, http.MethodPost, d.objectUrl(src)+"/rewriteTo/b/"+url.PathEscape(d.Bucket)+"/o/"+url.PathEscape(dst), func(req *resty.Request) {
+			req.SetHeaders(d.encryptionHeaders("x-goog-")).
+				SetHeaders(d.encryptionHeaders("x-goog-copy-source-")).
+				SetQueryParam("rewriteToken", token)
+		}, &resp)
+		if err != nil {
+			return err
+		}
+		if resp.Done {
+			return nil
+		}
+		token = resp.RewriteToken
+	}
+}
+
+func (d *GoogleCloudStorage) copyDir(ctx context.Context, src, dst string) error {
+	src, dst = getKey(src, true), getKey(dst, true)
+	objects, _, err := d.list(ctx, src, "")
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err = d.copyObject(ctx, o.Name, dst+strings.TrimPrefix(o.Name, src)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *GoogleCloudStorage) removeObject(ctx context.Context, key string) error {
+	_, err := d.request(ctx, http.MethodDelete, d.objectUrl(key), nil, nil)
+	if errors.Is(err, errs.ObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (d *GoogleCloudStorage) removeDir(ctx context.Context, prefix string) error {
+	objects, _, err := d.list(ctx, getKey(prefix, true), "")
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err = d.removeObject(ctx, o.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escape encode the string as RFC 3986, which is required by v4 signature
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func (d *GoogleCloudStorage) signBytes(ctx context.Context, data []byte) ([]byte, error) {
+	if d.privateKey != nil {
+		h := sha256.Sum256(data)
+		return rsa.SignPKCS1v15(rand.Reader, d.privateKey, crypto.SHA256, h[:])
+	}
+	// use the iam api to sign with the attached service account
+	var resp SignBlobResp
+	_, err := d.request(ctx, http.MethodPost, fmt.Sprintf("https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:signBlob", d.email), func(req *resty.Request) {
+		req.SetBody(base.Json{"payload": base64.StdEncoding.EncodeToString(data)})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.SignedBlob)
+}
+
+// signUrl generate a v4 signed url of the object,
+// see https://cloud.google.com/storage/docs/access-control/signing-urls-manually
+func (d *GoogleCloudStorage) signUrl(ctx context.Context, key string, expire time.Duration) (string, error) {
+	now := time.Now().UTC()
+	datetime := now.Format("20060102T150405Z")
+	credentialScope := now.Format("20060102") + "/auto/storage/goog4_request"
+	seg := strings.Split(key, "/")
+	for i := range seg {
+		seg[i] = escape(seg[i])
+	}
+	path := "/" + escape(d.Bucket) + "/" + strings.Join(seg, "/")
+	query := map[string]string{
+		"X-Goog-Algorithm":     "GOOG4-RSA-SHA256",
+		"X-Goog-Credential":    d.email + "/" + credentialScope,
+		"X-Goog-Date":          datetime,
+		"X-Goog-Expires":       strconv.Itoa(int(expire.Seconds())),
+		"X-Goog-SignedHeaders": "host",
+	}
+	names := make([]string, 0, len(query))
+	for k := range query {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	params := make([]string, 0, len(names))
+	for _, k := range names {
+		params = append(params, escape(k)+"="+escape(query[k]))
+	}
+	canonicalQuery := strings.Join(params, "&")
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet, path, canonicalQuery, "host:" + host, "", "host", "UNSIGNED-PAYLOAD",
+	}, "\n")
+	h := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"GOOG4-RSA-SHA256", datetime, credentialScope, hex.EncodeToString(h[:]),
+	}, "\n")
+	sig, err := d.signBytes(ctx, []byte(stringToSign))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s%s?%s&X-Goog-Signature=%s", host, path, canonicalQuery, hex.EncodeToString(sig)), nil
+}
+
+func emptyReader() io.Reader {
+	return bytes.NewReader([]byte{})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/alist-org/alist/v3/drivers/base"
@@ -18,6 +19,7 @@ type GoogleDrive struct {
 	model.Storage
 	Addition
 	AccessToken string
+	exports     map[string]string
 }
 
 func (d *GoogleDrive) Config() driver.Config {
@@ -32,6 +34,14 @@ func (d *GoogleDrive) Init(ctx context.Context) error {
 	if d.ChunkSize == 0 {
 		d.ChunkSize = 5
 	}
+	exports, err := parseExportFormats(d.ExportFormats)
+	if err != nil {
+		return err
+	}
+	d.exports = exports
+	if d.DriveID != "" && (d.RootFolderID == "" || d.RootFolderID == "root") {
+		d.RootFolderID = d.DriveID
+	}
 	return d.refreshToken()
 }
 
@@ -40,16 +50,45 @@ func (d *GoogleDrive) Drop(ctx context.Context) error {
 }
 
 func (d *GoogleDrive) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	if dir.GetID() == sharedDrivesID {
+		drives, err := d.getDrives()
+		if err != nil {
+			return nil, err
+		}
+		return utils.SliceConvert(drives, func(src Drive) (model.Obj, error) {
+			return driveToObj(src), nil
+		})
+	}
 	files, err := d.getFiles(dir.GetID())
 	if err != nil {
 		return nil, err
 	}
-	return utils.SliceConvert(files, func(src File) (model.Obj, error) {
-		return fileToObj(src), nil
+	d.resolveShortcuts(files)
+	objs, err := utils.SliceConvert(files, func(src File) (model.Obj, error) {
+		return fileToObj(src, d.exports), nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if d.SharedDrives && d.DriveID == "" && dir.GetID() == d.RootFolderID {
+		objs = append(objs, &model.Object{
+			ID:       sharedDrivesID,
+			Name:     "Shared drives",
+			IsFolder: true,
+		})
+	}
+	return objs, nil
 }
 
 func (d *GoogleDrive) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	if o, ok := file.(*Object); ok && o.ExportMime != "" {
+		return &model.Link{
+			URL: fmt.Sprintf("https://www.googleapis.com/drive/v3/files/%s/export?mimeType=%s", file.GetID(), url.QueryEscape(o.ExportMime)),
+			Header: http.Header{
+				"Authorization": []string{"Bearer " + d.AccessToken},
+			},
+		}, nil
+	}
 	url := fmt.Sprintf("https://www.googleapis.com/drive/v3/files/%s?includeItemsFromAllDrives=true&supportsAllDrives=true", file.GetID())
 	_, err := d.request(url, http.MethodGet, nil, nil)
 	if err != nil {
@@ -165,3 +204,4 @@ func (d *GoogleDrive) Put(ctx context.Context, dstDir model.Obj, stream model.Fi
 }
 
 var _ driver.Driver = (*GoogleDrive)(nil)
+var _ driver.Versioner = (*GoogleDrive)(nil)
@@ -13,6 +13,9 @@ type Addition struct {
 	ClientID       string `json:"client_id" required:"true" default:"202264815644.apps.googleusercontent.com"`
 	ClientSecret   string `json:"client_secret" required:"true" default:"X4Z3ca8xfWDb1Voo-F9a7ZxJ"`
 	ChunkSize      int64  `json:"chunk_size" type:"number" default:"5" help:"chunk size while uploading (unit: MB)"`
+	DriveID        string `json:"drive_id" help:"id of the shared drive to use, leave empty for My Drive"`
+	SharedDrives   bool   `json:"shared_drives" help:"show all shared drives in a virtual folder under the root"`
+	ExportFormats  string `json:"export_formats" default:"document:docx,spreadsheet:xlsx,presentation:pptx,drawing:svg" help:"export Google-native files as these formats, such as document:docx,spreadsheet:xlsx"`
 }
 
 var config = driver.Config{
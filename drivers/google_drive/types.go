@@ -31,26 +31,62 @@ type File struct {
 	} `json:"shortcutDetails"`
 }
 
-func fileToObj(f File) *model.ObjThumb {
+type Drives struct {
+	NextPageToken string  `json:"nextPageToken"`
+	Drives        []Drive `json:"drives"`
+}
+
+type Drive struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Object struct {
+	model.ObjThumb
+	// ExportMime is the mime type a Google-native document is exported as
+	ExportMime string
+}
+
+func fileToObj(f File, exports map[string]string) *Object {
 	log.Debugf("google file: %+v", f)
 	size, _ := strconv.ParseInt(f.Size, 10, 64)
-	obj := &model.ObjThumb{
-		Object: model.Object{
-			ID:       f.Id,
-			Name:     f.Name,
-			Size:     size,
-			Modified: f.ModifiedTime,
-			IsFolder: f.MimeType == "application/vnd.google-apps.folder",
+	obj := &Object{
+		ObjThumb: model.ObjThumb{
+			Object: model.Object{
+				ID:       f.Id,
+				Name:     f.Name,
+				Size:     size,
+				Modified: f.ModifiedTime,
+				IsFolder: f.MimeType == folderMimeType,
+			},
+			Thumbnail: model.Thumbnail{},
 		},
-		Thumbnail: model.Thumbnail{},
 	}
-	if f.MimeType == "application/vnd.google-apps.shortcut" {
+	mimeType := f.MimeType
+	if f.MimeType == shortcutMimeType {
 		obj.ID = f.ShortcutDetails.TargetId
-		obj.IsFolder = f.ShortcutDetails.TargetMimeType == "application/vnd.google-apps.folder"
+		obj.IsFolder = f.ShortcutDetails.TargetMimeType == folderMimeType
+		mimeType = f.ShortcutDetails.TargetMimeType
+	}
+	if ext, ok := exports[mimeType]; ok {
+		obj.Name += "." + ext
+		obj.ExportMime = exportMimeTypes[ext]
 	}
 	return obj
 }
 
+func driveToObj(d Drive) *Object {
+	return &Object{
+		ObjThumb: model.ObjThumb{
+			Object: model.Object{
+				ID:       d.Id,
+				Name:     d.Name,
+				IsFolder: true,
+			},
+		},
+	}
+}
+
 type Error struct {
 	Error struct {
 		Errors []struct {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/model"
@@ -16,6 +17,56 @@ import (
 
 // do others that not defined in Driver interface
 
+const (
+	folderMimeType   = "application/vnd.google-apps.folder"
+	shortcutMimeType = "application/vnd.google-apps.shortcut"
+	googleAppsPrefix = "application/vnd.google-apps."
+	// sharedDrivesID is the id of the virtual folder holding all shared drives
+	sharedDrivesID = "shared_drives"
+)
+
+var exportMimeTypes = map[string]string{
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"odt":  "application/vnd.oasis.opendocument.text",
+	"ods":  "application/x-vnd.oasis.opendocument.spreadsheet",
+	"odp":  "application/vnd.oasis.opendocument.presentation",
+	"rtf":  "application/rtf",
+	"pdf":  "application/pdf",
+	"txt":  "text/plain",
+	"csv":  "text/csv",
+	"tsv":  "text/tab-separated-values",
+	"html": "application/zip",
+	"epub": "application/epub+zip",
+	"svg":  "image/svg+xml",
+	"png":  "image/png",
+	"jpg":  "image/jpeg",
+	"json": "application/vnd.google-apps.script+json",
+}
+
+// parseExportFormats parses export formats like document:docx,spreadsheet:xlsx
+// into a map from Google-native mime type to file extension.
+func parseExportFormats(formats string) (map[string]string, error) {
+	res := make(map[string]string)
+	for _, item := range strings.Split(formats, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kind, ext, ok := strings.Cut(item, ":")
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if !ok || ext == "" {
+			return nil, fmt.Errorf("invalid export format: %s", item)
+		}
+		if _, ok := exportMimeTypes[ext]; !ok {
+			return nil, fmt.Errorf("unsupported export extension: %s", ext)
+		}
+		res[googleAppsPrefix+strings.TrimSpace(kind)] = ext
+	}
+	return res, nil
+}
+
 func (d *GoogleDrive) refreshToken() error {
 	url := "https://www.googleapis.com/oauth2/v4/token"
 	var resp base.TokenResp
@@ -89,6 +140,12 @@ func (d *GoogleDrive) getFiles(id string) ([]File, error) {
 			//"supportsAllDrives":         "true",
 			"pageToken": pageToken,
 		}
+		if d.DriveID != "" {
+			query["corpora"] = "drive"
+			query["driveId"] = d.DriveID
+		} else if d.SharedDrives {
+			query["corpora"] = "allDrives"
+		}
 		_, err := d.request("https://www.googleapis.com/drive/v3/files", http.MethodGet, func(req *resty.Request) {
 			req.SetQueryParams(query)
 		}, &resp)
@@ -101,6 +158,51 @@ func (d *GoogleDrive) getFiles(id string) ([]File, error) {
 	return res, nil
 }
 
+func (d *GoogleDrive) getDrives() ([]Drive, error) {
+	pageToken := "first"
+	res := make([]Drive, 0)
+	for pageToken != "" {
+		if pageToken == "first" {
+			pageToken = ""
+		}
+		var resp Drives
+		query := map[string]string{
+			"pageSize":  "100",
+			"pageToken": pageToken,
+		}
+		_, err := d.request("https://www.googleapis.com/drive/v3/drives", http.MethodGet, func(req *resty.Request) {
+			req.SetQueryParams(query)
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		pageToken = resp.NextPageToken
+		res = append(res, resp.Drives...)
+	}
+	return res, nil
+}
+
+// resolveShortcuts fills shortcuts to files with the size and modified time
+// of their targets, shortcuts to folders are listed by target id directly.
+func (d *GoogleDrive) resolveShortcuts(files []File) {
+	for i := range files {
+		f := &files[i]
+		if f.MimeType != shortcutMimeType || f.ShortcutDetails.TargetMimeType == folderMimeType {
+			continue
+		}
+		var target File
+		_, err := d.request("https://www.googleapis.com/drive/v3/files/"+f.ShortcutDetails.TargetId, http.MethodGet, func(req *resty.Request) {
+			req.SetQueryParam("fields", "id,mimeType,size,modifiedTime")
+		}, &target)
+		if err != nil {
+			log.Warnf("[google_drive] failed to resolve shortcut %s: %+v", f.Name, err)
+			continue
+		}
+		f.Size = target.Size
+		f.ModifiedTime = target.ModifiedTime
+	}
+}
+
 func (d *GoogleDrive) chunkUpload(ctx context.Context, stream model.FileStreamer, url string) error {
 	var defaultChunkSize = d.ChunkSize * 1024 * 1024
 	var finish int64 = 0
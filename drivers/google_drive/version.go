@@ -0,0 +1,85 @@
+package google_drive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Revision struct {
+	Id           string    `json:"id"`
+	Size         string    `json:"size"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+}
+
+type Revisions struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+func revisionUrl(file model.Obj, version string) string {
+	return fmt.Sprintf("https://www.googleapis.com/drive/v3/files/%s/revisions/%s?alt=media&acknowledgeAbuse=true", file.GetID(), version)
+}
+
+// ListVersions lists the previous revisions of the file, google drive lists
+// them the oldest first and the last one is the current content
+func (d *GoogleDrive) ListVersions(ctx context.Context, file model.Obj) ([]model.FileVersion, error) {
+	var resp Revisions
+	url := fmt.Sprintf("https://www.googleapis.com/drive/v3/files/%s/revisions?fields=revisions(id,size,modifiedTime)&pageSize=200", file.GetID())
+	_, err := d.request(url, http.MethodGet, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]model.FileVersion, 0, len(resp.Revisions))
+	for i := len(resp.Revisions) - 2; i >= 0; i-- {
+		r := resp.Revisions[i]
+		size, _ := strconv.ParseInt(r.Size, 10, 64)
+		versions = append(versions, model.FileVersion{ID: r.Id, Size: size, Modified: r.ModifiedTime})
+	}
+	return versions, nil
+}
+
+func (d *GoogleDrive) LinkVersion(ctx context.Context, file model.Obj, version string, args model.LinkArgs) (*model.Link, error) {
+	return &model.Link{
+		URL: revisionUrl(file, version),
+		Header: http.Header{
+			"Authorization": []string{"Bearer " + d.AccessToken},
+		},
+	}, nil
+}
+
+// RestoreVersion uploads the content of the revision as a new revision of
+// the file, since google drive can't restore revisions in place
+func (d *GoogleDrive) RestoreVersion(ctx context.Context, file model.Obj, version string) error {
+	res, err := base.RestyClient.R().SetContext(ctx).SetDoNotParseResponse(true).
+		SetHeader("Authorization", "Bearer "+d.AccessToken).
+		Get(revisionUrl(file, version))
+	if err != nil {
+		return err
+	}
+	body := res.RawBody()
+	defer body.Close()
+	if res.StatusCode() != http.StatusOK {
+		return errors.Errorf("failed get revision %s: %s", version, res.Status())
+	}
+	size, err := strconv.ParseInt(res.Header().Get("Content-Length"), 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "failed get the size of the revision")
+	}
+	return d.Put(ctx, nil, &model.FileStream{
+		Obj: &model.Object{
+			Name:     file.GetName(),
+			Size:     size,
+			Modified: time.Now(),
+		},
+		ReadCloser: body,
+		Mimetype:   utils.GetMimeType(file.GetName()),
+		Old:        file,
+	}, func(int) {})
+}
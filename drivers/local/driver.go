@@ -12,12 +12,14 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/cron"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/disintegration/imaging"
@@ -27,7 +29,9 @@ import (
 type Local struct {
 	model.Storage
 	Addition
-	mkdirPerm int32
+	mkdirPerm  int32
+	recycleBin string
+	cron       *cron.Cron
 }
 
 func (d *Local) Config() driver.Config {
@@ -54,10 +58,22 @@ func (d *Local) Init(ctx context.Context) error {
 		}
 		d.Addition.RootFolderPath = abs
 	}
+	if err := d.initRecycleBin(); err != nil {
+		return err
+	}
+	if d.recycleEnabled() && d.RecycleBinDays > 0 {
+		d.purgeTrash()
+		d.cron = cron.NewCron(time.Hour)
+		d.cron.Do(d.purgeTrash)
+	}
 	return nil
 }
 
 func (d *Local) Drop(ctx context.Context) error {
+	if d.cron != nil {
+		d.cron.Stop()
+		d.cron = nil
+	}
 	return nil
 }
 
@@ -76,6 +92,9 @@ func (d *Local) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([
 		if !d.ShowHidden && strings.HasPrefix(f.Name(), ".") {
 			continue
 		}
+		if d.inRecycleBin(filepath.Join(fullPath, f.Name())) {
+			continue
+		}
 		thumb := ""
 		if d.Thumbnail {
 			typeName := utils.GetFileType(f.Name())
@@ -223,6 +242,9 @@ func (d *Local) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
 }
 
 func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
+	if d.recycleEnabled() && !d.inRecycleBin(obj.GetPath()) {
+		return d.moveToRecycleBin(obj.GetPath())
+	}
 	var err error
 	if obj.IsDir() {
 		err = os.RemoveAll(obj.GetPath())
@@ -254,4 +276,17 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+func (d *Local) GetSpace(ctx context.Context) (*model.StorageSpace, error) {
+	total, free, err := getDiskSpace(d.GetRootPath())
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageSpace{Total: total, Free: free}, nil
+}
+
+func (d *Local) Capabilities(caps *driver.Capabilities) {
+	caps.Trash = caps.Trash || d.recycleEnabled()
+}
+
 var _ driver.Driver = (*Local)(nil)
+var _ driver.Trasher = (*Local)(nil)
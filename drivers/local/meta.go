@@ -7,9 +7,11 @@ import (
 
 type Addition struct {
 	driver.RootPath
-	Thumbnail  bool   `json:"thumbnail" required:"true" help:"enable thumbnail"`
-	ShowHidden bool   `json:"show_hidden" default:"true" required:"false" help:"show hidden directories and files"`
-	MkdirPerm  string `json:"mkdir_perm" default:"777"`
+	Thumbnail      bool   `json:"thumbnail" required:"true" help:"enable thumbnail"`
+	ShowHidden     bool   `json:"show_hidden" default:"true" required:"false" help:"show hidden directories and files"`
+	MkdirPerm      string `json:"mkdir_perm" default:"777"`
+	RecycleBinPath string `json:"recycle_bin_path" help:"move removed files here instead of deleting them; relative paths are resolved against the root folder"`
+	RecycleBinDays int    `json:"recycle_bin_days" type:"number" default:"30" help:"purge items from the recycle bin after this many days, 0 to keep forever"`
 }
 
 var config = driver.Config{
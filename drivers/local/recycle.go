@@ -0,0 +1,274 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// The recycle bin follows the freedesktop.org trash layout: removed objects
+// live in <bin>/files and each one has a json record in <bin>/info that
+// remembers where it came from.
+const (
+	recycleFilesDir = "files"
+	recycleInfoDir  = "info"
+	recycleInfoExt  = ".json"
+	recycleTimeFmt  = "20060102150405"
+)
+
+// trashRecord is the record of a removed object, the name is the name of the
+// object in the recycle bin, which is the id of the item
+type trashRecord struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	IsDir     bool      `json:"is_dir"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (d *Local) recycleEnabled() bool {
+	return d.recycleBin != ""
+}
+
+func (d *Local) initRecycleBin() error {
+	if d.RecycleBinPath == "" {
+		d.recycleBin = ""
+		return nil
+	}
+	bin := d.RecycleBinPath
+	if !filepath.IsAbs(bin) {
+		bin = filepath.Join(d.GetRootPath(), bin)
+	}
+	d.recycleBin = filepath.Clean(bin)
+	for _, dir := range []string{recycleFilesDir, recycleInfoDir} {
+		err := os.MkdirAll(filepath.Join(d.recycleBin, dir), os.FileMode(d.mkdirPerm))
+		if err != nil {
+			return errors.WithMessage(err, "failed to create recycle bin")
+		}
+	}
+	return nil
+}
+
+// inRecycleBin reports whether path is the recycle bin itself or inside it.
+func (d *Local) inRecycleBin(path string) bool {
+	if !d.recycleEnabled() {
+		return false
+	}
+	rel, err := filepath.Rel(d.recycleBin, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func (d *Local) moveToRecycleBin(path string) error {
+	rel, err := filepath.Rel(d.GetRootPath(), path)
+	if err != nil {
+		return err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	base := filepath.Base(path) + "." + now.Format(recycleTimeFmt)
+	name := base
+	for i := 1; utils.Exists(filepath.Join(d.recycleBin, recycleFilesDir, name)) ||
+		utils.Exists(d.trashInfoPath(name)); i++ {
+		name = fmt.Sprintf("%s.%d", base, i)
+	}
+	item := trashRecord{
+		Name:      name,
+		Path:      utils.FixAndCleanPath(filepath.ToSlash(rel)),
+		IsDir:     stat.IsDir(),
+		DeletedAt: now,
+	}
+	if !item.IsDir {
+		item.Size = stat.Size()
+	}
+	data, err := utils.Json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	// write the record first so an interrupted removal never leaves an
+	// orphan without its original location
+	if err = os.WriteFile(d.trashInfoPath(name), data, 0644); err != nil {
+		return err
+	}
+	if err = moveAll(path, filepath.Join(d.recycleBin, recycleFilesDir, name), item.IsDir); err != nil {
+		_ = os.Remove(d.trashInfoPath(name))
+		return err
+	}
+	return nil
+}
+
+// moveAll renames src to dst, falling back to copy and delete when they
+// are on different devices.
+func moveAll(src, dst string, isDir bool) error {
+	err := os.Rename(src, dst)
+	var linkErr *os.LinkError
+	if err == nil || !errors.As(err, &linkErr) || !errors.Is(linkErr.Err, syscall.EXDEV) {
+		return err
+	}
+	if isDir {
+		err = utils.CopyDir(src, dst)
+	} else {
+		err = utils.CopyFile(src, dst)
+	}
+	if err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func (d *Local) trashInfoPath(name string) string {
+	return filepath.Join(d.recycleBin, recycleInfoDir, name+recycleInfoExt)
+}
+
+func (d *Local) getTrashRecord(name string) (*trashRecord, error) {
+	if name == "" || name != filepath.Base(name) {
+		return nil, fmt.Errorf("invalid trash item name: %s", name)
+	}
+	data, err := os.ReadFile(d.trashInfoPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errs.ObjectNotFound
+		}
+		return nil, err
+	}
+	var item trashRecord
+	if err = utils.Json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+	item.Name = name
+	return &item, nil
+}
+
+func (r trashRecord) toItem() model.TrashItem {
+	return model.TrashItem{
+		ID:        r.Name,
+		Name:      stdpath.Base(r.Path),
+		Path:      r.Path,
+		Size:      r.Size,
+		IsDir:     r.IsDir,
+		DeletedAt: r.DeletedAt,
+	}
+}
+
+func (d *Local) listTrash() ([]trashRecord, error) {
+	entries, err := os.ReadDir(filepath.Join(d.recycleBin, recycleInfoDir))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]trashRecord, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), recycleInfoExt) {
+			continue
+		}
+		item, err := d.getTrashRecord(strings.TrimSuffix(e.Name(), recycleInfoExt))
+		if err != nil {
+			log.Warnf("[local] skip broken trash record %s: %+v", e.Name(), err)
+			continue
+		}
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+func (d *Local) ListTrash(ctx context.Context) ([]model.TrashItem, error) {
+	if !d.recycleEnabled() {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	records, err := d.listTrash()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]model.TrashItem, 0, len(records))
+	for _, r := range records {
+		items = append(items, r.toItem())
+	}
+	return items, nil
+}
+
+// GetTrashItem gets the item by the name in the recycle bin as the id, and
+// the original name
+func (d *Local) GetTrashItem(ctx context.Context, id, name string) (*model.TrashItem, error) {
+	if !d.recycleEnabled() {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	r, err := d.getTrashRecord(id)
+	if err != nil {
+		return nil, err
+	}
+	item := r.toItem()
+	if item.Name != name {
+		return nil, errors.WithStack(errs.ObjectNotFound)
+	}
+	return &item, nil
+}
+
+func (d *Local) RestoreTrash(ctx context.Context, id, name string) error {
+	item, err := d.GetTrashItem(ctx, id, name)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(d.GetRootPath(), filepath.FromSlash(item.Path))
+	if utils.Exists(dst) {
+		return fmt.Errorf("%s already exists", item.Path)
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), os.FileMode(d.mkdirPerm)); err != nil {
+		return err
+	}
+	if err = moveAll(filepath.Join(d.recycleBin, recycleFilesDir, id), dst, item.IsDir); err != nil {
+		return err
+	}
+	return os.Remove(d.trashInfoPath(id))
+}
+
+func (d *Local) DeleteTrash(ctx context.Context, id, name string) error {
+	if _, err := d.GetTrashItem(ctx, id, name); err != nil {
+		return err
+	}
+	return d.deleteTrash(id)
+}
+
+func (d *Local) deleteTrash(name string) error {
+	if err := os.RemoveAll(filepath.Join(d.recycleBin, recycleFilesDir, name)); err != nil {
+		return err
+	}
+	return os.Remove(d.trashInfoPath(name))
+}
+
+// purgeTrash permanently deletes items removed more than RecycleBinDays ago.
+func (d *Local) purgeTrash() {
+	if d.RecycleBinDays <= 0 {
+		return
+	}
+	items, err := d.listTrash()
+	if err != nil {
+		log.Errorf("[local] failed to list recycle bin: %+v", err)
+		return
+	}
+	deadline := time.Now().AddDate(0, 0, -d.RecycleBinDays)
+	for _, item := range items {
+		if item.DeletedAt.After(deadline) {
+			continue
+		}
+		if err := d.deleteTrash(item.Name); err != nil {
+			log.Errorf("[local] failed to purge %s from recycle bin: %+v", item.Name, err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package local
+
+import "github.com/alist-org/alist/v3/internal/errs"
+
+func getDiskSpace(path string) (total, free int64, err error) {
+	return 0, 0, errs.NotImplement
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package local
+
+import "golang.org/x/sys/windows"
+
+func getDiskSpace(path string) (total, free int64, err error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var freeBytes, totalBytes, totalFree uint64
+	if err = windows.GetDiskFreeSpaceEx(p, &freeBytes, &totalBytes, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return int64(totalBytes), int64(freeBytes), nil
+}
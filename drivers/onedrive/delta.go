@@ -0,0 +1,142 @@
+package onedrive
+
+import (
+	"errors"
+	"net/http"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+var errResyncRequired = errors.New("delta token expired, resync required")
+
+// The delta api reports changed items by id, so we remember the id of every
+// folder we have listed and clear the list cache of exactly those folders
+// whose children changed.
+
+func (d *Onedrive) initDelta() error {
+	root, err := d.GetFile(d.GetRootPath())
+	if err != nil {
+		return err
+	}
+	d.deltaMu.Lock()
+	d.dirIDs = map[string]string{root.Id: utils.FixAndCleanPath(d.GetRootPath())}
+	d.deltaMu.Unlock()
+	return d.resetDelta()
+}
+
+// resetDelta starts tracking from the current state of the drive.
+func (d *Onedrive) resetDelta() error {
+	var resp DeltaResp
+	_, err := d.Request(d.GetMetaUrl(false, "/")+"/delta?token=latest", http.MethodGet, nil, &resp)
+	if err != nil {
+		return err
+	}
+	d.deltaMu.Lock()
+	d.deltaLink = resp.DeltaLink
+	d.deltaMu.Unlock()
+	return nil
+}
+
+func (d *Onedrive) rememberDirs(dir model.Obj, files []File) {
+	if d.DeltaInterval <= 0 {
+		return
+	}
+	dirPath := utils.FixAndCleanPath(dir.GetPath())
+	d.deltaMu.Lock()
+	defer d.deltaMu.Unlock()
+	if d.dirIDs == nil {
+		return
+	}
+	if dir.GetID() != "" {
+		d.dirIDs[dir.GetID()] = dirPath
+	}
+	for _, f := range files {
+		if f.File == nil {
+			d.dirIDs[f.Id] = stdpath.Join(dirPath, f.Name)
+		}
+	}
+}
+
+func (d *Onedrive) syncDelta() error {
+	d.deltaMu.Lock()
+	link := d.deltaLink
+	d.deltaMu.Unlock()
+	if link == "" {
+		return d.resetDelta()
+	}
+	var changed []File
+	for {
+		var resp DeltaResp
+		_, err := d.Request(link, http.MethodGet, nil, &resp)
+		if errors.Is(err, errResyncRequired) {
+			log.Warnf("[onedrive] %s, clear all cached folders", err)
+			d.clearAll()
+			return d.resetDelta()
+		}
+		if err != nil {
+			return err
+		}
+		changed = append(changed, resp.Value...)
+		if resp.NextLink == "" {
+			link = resp.DeltaLink
+			break
+		}
+		link = resp.NextLink
+	}
+	d.deltaMu.Lock()
+	defer d.deltaMu.Unlock()
+	dirty := make(map[string]struct{})
+	for _, f := range changed {
+		if p, ok := d.dirIDs[f.ParentReference.Id]; ok {
+			dirty[p] = struct{}{}
+		}
+		old, ok := d.dirIDs[f.Id]
+		if !ok {
+			continue
+		}
+		// the folder itself was deleted, renamed or moved, so everything
+		// cached below its old path is stale
+		dirty[old] = struct{}{}
+		for id, p := range d.dirIDs {
+			if utils.IsSubPath(old, p) {
+				dirty[p] = struct{}{}
+				delete(d.dirIDs, id)
+			}
+		}
+		delete(d.dirIDs, f.Id)
+		if f.Deleted == nil && f.File == nil {
+			if parent, ok := d.dirIDs[f.ParentReference.Id]; ok {
+				d.dirIDs[f.Id] = stdpath.Join(parent, f.Name)
+			}
+		}
+	}
+	for p := range dirty {
+		d.clearCache(p)
+	}
+	d.deltaLink = link
+	return nil
+}
+
+func (d *Onedrive) clearAll() {
+	d.deltaMu.Lock()
+	defer d.deltaMu.Unlock()
+	for _, p := range d.dirIDs {
+		d.clearCache(p)
+	}
+}
+
+// clearCache clears the list cache of the folder at the drive path p.
+func (d *Onedrive) clearCache(p string) {
+	root := utils.FixAndCleanPath(d.GetRootPath())
+	if !utils.IsSubPath(root, p) {
+		return
+	}
+	rel := utils.FixAndCleanPath(strings.TrimPrefix(p, root))
+	log.Debugf("[onedrive] delta: clear cache of %s", rel)
+	op.ClearCache(d, rel)
+}
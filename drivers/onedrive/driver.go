@@ -5,19 +5,28 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"sync"
+	"time"
 
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/cron"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/go-resty/resty/v2"
+	log "github.com/sirupsen/logrus"
 )
 
 type Onedrive struct {
 	model.Storage
 	Addition
 	AccessToken string
+
+	cron      *cron.Cron
+	deltaMu   sync.Mutex
+	deltaLink string
+	dirIDs    map[string]string
 }
 
 func (d *Onedrive) Config() driver.Config {
@@ -32,10 +41,28 @@ func (d *Onedrive) Init(ctx context.Context) error {
 	if d.ChunkSize < 1 {
 		d.ChunkSize = 5
 	}
-	return d.refreshToken()
+	if err := d.refreshToken(); err != nil {
+		return err
+	}
+	if d.DeltaInterval > 0 {
+		if err := d.initDelta(); err != nil {
+			return err
+		}
+		d.cron = cron.NewCron(time.Second * time.Duration(d.DeltaInterval))
+		d.cron.Do(func() {
+			if err := d.syncDelta(); err != nil {
+				log.Errorf("[onedrive] failed to sync delta: %+v", err)
+			}
+		})
+	}
+	return nil
 }
 
 func (d *Onedrive) Drop(ctx context.Context) error {
+	if d.cron != nil {
+		d.cron.Stop()
+		d.cron = nil
+	}
 	return nil
 }
 
@@ -44,6 +71,7 @@ func (d *Onedrive) List(ctx context.Context, dir model.Obj, args model.ListArgs)
 	if err != nil {
 		return nil, err
 	}
+	d.rememberDirs(dir, files)
 	return utils.SliceConvert(files, func(src File) (model.Obj, error) {
 		return fileToObj(src, dir.GetID()), nil
 	})
@@ -157,4 +185,19 @@ func (d *Onedrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileS
 	return err
 }
 
+func (d *Onedrive) GetSpace(ctx context.Context) (*model.StorageSpace, error) {
+	drive, err := d.getDrive()
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageSpace{Total: drive.Quota.Total, Free: drive.Quota.Remaining}, nil
+}
+
+// CheckHealth gets the drive, which refreshes the token if it's expired
+func (d *Onedrive) CheckHealth(ctx context.Context) error {
+	_, err := d.getDrive()
+	return err
+}
+
 var _ driver.Driver = (*Onedrive)(nil)
+var _ driver.Versioner = (*Onedrive)(nil)
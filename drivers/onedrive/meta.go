@@ -7,14 +7,15 @@ import (
 
 type Addition struct {
 	driver.RootPath
-	Region       string `json:"region" type:"select" required:"true" options:"global,cn,us,de" default:"global"`
-	IsSharepoint bool   `json:"is_sharepoint"`
-	ClientID     string `json:"client_id" required:"true"`
-	ClientSecret string `json:"client_secret" required:"true"`
-	RedirectUri  string `json:"redirect_uri" required:"true" default:"https://alist.nn.ci/tool/onedrive/callback"`
-	RefreshToken string `json:"refresh_token" required:"true"`
-	SiteId       string `json:"site_id"`
-	ChunkSize    int64  `json:"chunk_size" type:"number" default:"5"`
+	Region        string `json:"region" type:"select" required:"true" options:"global,cn,us,de" default:"global"`
+	IsSharepoint  bool   `json:"is_sharepoint"`
+	ClientID      string `json:"client_id" required:"true"`
+	ClientSecret  string `json:"client_secret" required:"true"`
+	RedirectUri   string `json:"redirect_uri" required:"true" default:"https://alist.nn.ci/tool/onedrive/callback"`
+	RefreshToken  string `json:"refresh_token" required:"true"`
+	SiteId        string `json:"site_id"`
+	ChunkSize     int64  `json:"chunk_size" type:"number" default:"5"`
+	DeltaInterval int    `json:"delta_interval" type:"number" default:"0" help:"poll the delta api every N seconds and only clear the cache of changed folders, 0 to disable"`
 }
 
 var config = driver.Config{
@@ -23,6 +23,13 @@ type RespErr struct {
 	} `json:"error"`
 }
 
+type Drive struct {
+	Quota struct {
+		Total     int64 `json:"total"`
+		Remaining int64 `json:"remaining"`
+	} `json:"quota"`
+}
+
 type File struct {
 	Id                   string    `json:"id"`
 	Name                 string    `json:"name"`
@@ -38,8 +45,12 @@ type File struct {
 		} `json:"medium"`
 	} `json:"thumbnails"`
 	ParentReference struct {
+		Id      string `json:"id"`
 		DriveId string `json:"driveId"`
 	} `json:"parentReference"`
+	Deleted *struct {
+		State string `json:"state"`
+	} `json:"deleted"`
 }
 
 type Object struct {
@@ -72,3 +83,9 @@ type Files struct {
 	Value    []File `json:"value"`
 	NextLink string `json:"@odata.nextLink"`
 }
+
+type DeltaResp struct {
+	Value     []File `json:"value"`
+	NextLink  string `json:"@odata.nextLink"`
+	DeltaLink string `json:"@odata.deltaLink"`
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	stdpath "path"
 	"strconv"
+	"strings"
 
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/driver"
@@ -120,6 +121,9 @@ func (d *Onedrive) Request(url string, method string, callback base.ReqCallback,
 			}
 			return d.Request(url, method, callback, resp)
 		}
+		if e.Error.Code == "resyncRequired" {
+			return nil, errResyncRequired
+		}
 		return nil, errors.New(e.Error.Message)
 	}
 	return res.Body(), nil
@@ -147,6 +151,14 @@ func (d *Onedrive) GetFile(path string) (*File, error) {
 	return &file, err
 }
 
+func (d *Onedrive) getDrive() (*Drive, error) {
+	// the meta url of root is like .../drive/root
+	u := strings.TrimSuffix(d.GetMetaUrl(false, "/"), "/root")
+	var drive Drive
+	_, err := d.Request(u, http.MethodGet, nil, &drive)
+	return &drive, err
+}
+
 func (d *Onedrive) upSmall(ctx context.Context, dstDir model.Obj, stream model.FileStreamer) error {
 	url := d.GetMetaUrl(false, stdpath.Join(dstDir.GetPath(), stream.GetName())) + "/content"
 	data, err := io.ReadAll(stream)
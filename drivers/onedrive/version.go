@@ -0,0 +1,56 @@
+package onedrive
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type Version struct {
+	Id                   string    `json:"id"`
+	Size                 int64     `json:"size"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+}
+
+type Versions struct {
+	Value []Version `json:"value"`
+}
+
+func (d *Onedrive) versionUrl(file model.Obj, version string) string {
+	return d.GetMetaUrl(false, file.GetPath()) + "/versions/" + url.PathEscape(version)
+}
+
+// ListVersions lists the previous versions of the file, the first version
+// returned by onedrive is the current one
+func (d *Onedrive) ListVersions(ctx context.Context, file model.Obj) ([]model.FileVersion, error) {
+	var resp Versions
+	_, err := d.Request(d.GetMetaUrl(false, file.GetPath())+"/versions", http.MethodGet, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]model.FileVersion, 0, len(resp.Value))
+	for i, v := range resp.Value {
+		if i == 0 {
+			continue
+		}
+		versions = append(versions, model.FileVersion{ID: v.Id, Size: v.Size, Modified: v.LastModifiedDateTime})
+	}
+	return versions, nil
+}
+
+func (d *Onedrive) LinkVersion(ctx context.Context, file model.Obj, version string, args model.LinkArgs) (*model.Link, error) {
+	return &model.Link{
+		URL: d.versionUrl(file, version) + "/content",
+		Header: http.Header{
+			"Authorization": []string{"Bearer " + d.AccessToken},
+		},
+	}, nil
+}
+
+func (d *Onedrive) RestoreVersion(ctx context.Context, file model.Obj, version string) error {
+	_, err := d.Request(d.versionUrl(file, version)+"/restoreVersion", http.MethodPost, nil, nil)
+	return err
+}
@@ -59,12 +59,19 @@ func (d *S3) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]mo
 }
 
 func (d *S3) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return d.link(file, nil)
+}
+
+// link signs the url of the file, or of the version of it if versionId is
+// not nil
+func (d *S3) link(file model.Obj, versionId *string) (*model.Link, error) {
 	path := getKey(file.GetPath(), false)
 	filename := stdpath.Base(path)
 	disposition := fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename))
 	input := &s3.GetObjectInput{
-		Bucket: &d.Bucket,
-		Key:    &path,
+		Bucket:    &d.Bucket,
+		Key:       &path,
+		VersionId: versionId,
 		//ResponseContentDisposition: &disposition,
 	}
 	if d.CustomHost == "" {
@@ -131,12 +138,12 @@ func (d *S3) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *S3) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	uploader := s3manager.NewUploader(d.Session)
-	if stream.GetSize() > s3manager.MaxUploadParts*s3manager.DefaultUploadPartSize {
-		uploader.PartSize = stream.GetSize() / (s3manager.MaxUploadParts - 1)
-	}
 	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
 	log.Debugln("key:", key)
+	if stream.GetSize() > int64(d.UploadPartSize)*1024*1024 && stream.GetSize() > s3manager.MinUploadPartSize {
+		return d.multipartUpload(ctx, key, stream, stream.GetSize(), up)
+	}
+	uploader := s3manager.NewUploader(d.Session)
 	input := &s3manager.UploadInput{
 		Bucket: &d.Bucket,
 		Key:    &key,
@@ -146,4 +153,9 @@ func (d *S3) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreame
 	return err
 }
 
+func (d *S3) Capabilities(caps *driver.Capabilities) {
+	caps.UploadResume = true
+}
+
 var _ driver.Driver = (*S3)(nil)
+var _ driver.Versioner = (*S3)(nil)
@@ -18,6 +18,8 @@ type Addition struct {
 	ForcePathStyle    bool   `json:"force_path_style"`
 	ListObjectVersion string `json:"list_object_version" type:"select" options:"v1,v2" default:"v1"`
 	RemoveBucket      bool   `json:"remove_bucket" help:"Remove bucket name from path when using custom host."`
+	UploadPartSize    int    `json:"upload_part_size" type:"number" default:"16" help:"Part size of multipart upload in MB, at least 5"`
+	UploadConcurrency int    `json:"upload_concurrency" type:"number" default:"4" help:"Number of parts uploaded in parallel"`
 }
 
 var config = driver.Config{
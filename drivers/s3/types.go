@@ -1 +1,13 @@
 package s3
+
+import "time"
+
+// multipartRecord is persisted while a multipart upload is in progress,
+// so that an interrupted upload of the same file can be resumed
+type multipartRecord struct {
+	UploadID string    `json:"upload_id"`
+	Key      string    `json:"key"`
+	Size     int64     `json:"size"`
+	PartSize int64     `json:"part_size"`
+	Created  time.Time `json:"created"`
+}
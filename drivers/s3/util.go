@@ -1,12 +1,24 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -15,6 +27,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -247,3 +260,211 @@ func (d *S3) removeFile(src string) error {
 	_, err := d.client.DeleteObject(input)
 	return err
 }
+
+// records older than this are aborted instead of resumed, most lifecycle rules clean them up anyway
+const multipartRecordExpire = 7 * 24 * time.Hour
+
+func (d *S3) recordPath(key string, size int64) string {
+	name := utils.GetMD5Encode(fmt.Sprintf("%s/%s/%s/%d", d.Endpoint, d.Bucket, key, size))
+	return filepath.Join(flags.DataDir, "s3_multipart", name+".json")
+}
+
+func (d *S3) loadRecord(key string, size int64) *multipartRecord {
+	data, err := os.ReadFile(d.recordPath(key, size))
+	if err != nil {
+		return nil
+	}
+	var rec multipartRecord
+	if err = utils.Json.Unmarshal(data, &rec); err != nil || rec.Key != key || rec.Size != size {
+		return nil
+	}
+	return &rec
+}
+
+func (d *S3) saveRecord(rec *multipartRecord) error {
+	p := d.recordPath(rec.Key, rec.Size)
+	if err := os.MkdirAll(filepath.Dir(p), 0o777); err != nil {
+		return err
+	}
+	data, err := utils.Json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o666)
+}
+
+func (d *S3) removeRecord(key string, size int64) {
+	_ = os.Remove(d.recordPath(key, size))
+}
+
+// listUploadedParts get the parts that already uploaded to the multipart upload
+func (d *S3) listUploadedParts(ctx context.Context, rec *multipartRecord) (map[int64]*s3.Part, error) {
+	parts := make(map[int64]*s3.Part)
+	err := d.client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   &d.Bucket,
+		Key:      &rec.Key,
+		UploadId: &rec.UploadID,
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		for _, p := range page.Parts {
+			parts[*p.PartNumber] = p
+		}
+		return true
+	})
+	return parts, err
+}
+
+func (d *S3) startMultipart(ctx context.Context, key string, size int64) (*multipartRecord, map[int64]*s3.Part, error) {
+	partSize := int64(d.UploadPartSize) * 1024 * 1024
+	if partSize < s3manager.MinUploadPartSize {
+		partSize = s3manager.MinUploadPartSize
+	}
+	if size/partSize >= s3manager.MaxUploadParts {
+		partSize = size/(s3manager.MaxUploadParts-1) + 1
+	}
+	if rec := d.loadRecord(key, size); rec != nil {
+		if time.Since(rec.Created) < multipartRecordExpire {
+			parts, err := d.listUploadedParts(ctx, rec)
+			if err == nil {
+				log.Infof("[s3] resume multipart upload of %s with %d parts uploaded", key, len(parts))
+				return rec, parts, nil
+			}
+			log.Warnf("[s3] failed list parts of %s, start a new upload: %+v", key, err)
+		}
+		_, _ = d.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   &d.Bucket,
+			Key:      &rec.Key,
+			UploadId: &rec.UploadID,
+		})
+		d.removeRecord(key, size)
+	}
+	out, err := d.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: &d.Bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	rec := &multipartRecord{
+		UploadID: *out.UploadId,
+		Key:      key,
+		Size:     size,
+		PartSize: partSize,
+		Created:  time.Now(),
+	}
+	if err = d.saveRecord(rec); err != nil {
+		log.Warnf("[s3] failed save multipart record, upload can't be resumed: %+v", err)
+	}
+	return rec, map[int64]*s3.Part{}, nil
+}
+
+// samePart checks the uploaded part has the content of buf with its ETag,
+// which is the MD5 of the part unless it's encrypted with SSE-KMS or SSE-C,
+// then the part is just uploaded again
+func samePart(p *s3.Part, buf []byte) bool {
+	if aws.Int64Value(p.Size) != int64(len(buf)) {
+		return false
+	}
+	sum := md5.Sum(buf)
+	return strings.EqualFold(strings.Trim(aws.StringValue(p.ETag), `"`), hex.EncodeToString(sum[:]))
+}
+
+// multipartUpload upload the stream with parts in parallel,
+// the upload id is kept until completed so a failed upload can continue from the uploaded parts
+// whose content is unchanged
+func (d *S3) multipartUpload(ctx context.Context, key string, stream io.Reader, size int64, up driver.UpdateProgress) error {
+	rec, uploaded, err := d.startMultipart(ctx, key, size)
+	if err != nil {
+		return err
+	}
+	concurrency := d.UploadConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int64
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+	report := func(n int64) {
+		up(int(atomic.AddInt64(&done, n) * 100 / size))
+	}
+	sem := make(chan struct{}, concurrency)
+	partCount := (size + rec.PartSize - 1) / rec.PartSize
+	parts := make([]*s3.CompletedPart, partCount)
+	for i := int64(0); i < partCount && ctx.Err() == nil; i++ {
+		partNumber := i + 1
+		n := rec.PartSize
+		if size-i*rec.PartSize < n {
+			n = size - i*rec.PartSize
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		buf := make([]byte, n)
+		if _, err = io.ReadFull(stream, buf); err != nil {
+			<-sem
+			setErr(err)
+			break
+		}
+		if p, ok := uploaded[partNumber]; ok && samePart(p, buf) {
+			// the uploaded part has the same content, skip it
+			<-sem
+			parts[i] = &s3.CompletedPart{ETag: p.ETag, PartNumber: p.PartNumber}
+			report(n)
+			continue
+		}
+		wg.Add(1)
+		go func(i int64, buf []byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			out, err := d.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+				Bucket:     &d.Bucket,
+				Key:        &rec.Key,
+				UploadId:   &rec.UploadID,
+				PartNumber: aws.Int64(i + 1),
+				Body:       bytes.NewReader(buf),
+			})
+			if err != nil {
+				setErr(fmt.Errorf("failed upload part %d: %w", i+1, err))
+				return
+			}
+			parts[i] = &s3.CompletedPart{ETag: out.ETag, PartNumber: aws.Int64(i + 1)}
+			report(int64(len(buf)))
+		}(i, buf)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	_, err = d.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &d.Bucket,
+		Key:             &rec.Key,
+		UploadId:        &rec.UploadID,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return err
+	}
+	d.removeRecord(key, size)
+	return nil
+}
@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// ListVersions lists the noncurrent versions of the object, which are kept
+// only if the versioning of the bucket is enabled
+func (d *S3) ListVersions(ctx context.Context, file model.Obj) ([]model.FileVersion, error) {
+	key := getKey(file.GetPath(), false)
+	input := &s3.ListObjectVersionsInput{
+		Bucket: &d.Bucket,
+		Prefix: &key,
+	}
+	versions := make([]model.FileVersion, 0)
+	err := d.client.ListObjectVersionsPagesWithContext(ctx, input, func(page *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, v := range page.Versions {
+			if aws.StringValue(v.Key) != key || aws.BoolValue(v.IsLatest) {
+				continue
+			}
+			versions = append(versions, model.FileVersion{
+				ID:       aws.StringValue(v.VersionId),
+				Size:     aws.Int64Value(v.Size),
+				Modified: aws.TimeValue(v.LastModified),
+			})
+		}
+		return true
+	})
+	return versions, errors.WithStack(err)
+}
+
+func (d *S3) LinkVersion(ctx context.Context, file model.Obj, version string, args model.LinkArgs) (*model.Link, error) {
+	return d.link(file, &version)
+}
+
+// RestoreVersion copies the version over the object, so that it becomes the
+// latest version and the current one is kept as a noncurrent version
+func (d *S3) RestoreVersion(ctx context.Context, file model.Obj, version string) error {
+	key := getKey(file.GetPath(), false)
+	source := "/" + d.Bucket + "/" + key + "?versionId=" + url.QueryEscape(version)
+	_, err := d.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     &d.Bucket,
+		CopySource: aws.String(source),
+		Key:        &key,
+	})
+	return errors.WithStack(err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path"
+	"sync"
 
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/driver"
@@ -17,6 +18,7 @@ type SFTP struct {
 	model.Storage
 	Addition
 	client *sftp.Client
+	mu     sync.Mutex
 }
 
 func (d *SFTP) Config() driver.Config {
@@ -32,14 +34,21 @@ func (d *SFTP) Init(ctx context.Context) error {
 }
 
 func (d *SFTP) Drop(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if d.client != nil {
 		_ = d.client.Close()
+		d.client = nil
 	}
 	return nil
 }
 
 func (d *SFTP) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
-	files, err := d.client.ReadDir(dir.GetPath())
+	var files []os.FileInfo
+	err := d.retry(func(client *sftp.Client) (err error) {
+		files, err = client.ReadDir(dir.GetPath())
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -49,7 +58,11 @@ func (d *SFTP) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]
 }
 
 func (d *SFTP) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
-	remoteFile, err := d.client.Open(file.GetPath())
+	var remoteFile *sftp.File
+	err := d.retry(func(client *sftp.Client) (err error) {
+		remoteFile, err = client.Open(file.GetPath())
+		return
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -61,15 +74,21 @@ func (d *SFTP) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*
 }
 
 func (d *SFTP) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
-	return d.client.MkdirAll(path.Join(parentDir.GetPath(), dirName))
+	return d.retry(func(client *sftp.Client) error {
+		return client.MkdirAll(path.Join(parentDir.GetPath(), dirName))
+	})
 }
 
 func (d *SFTP) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
-	return d.client.Rename(srcObj.GetPath(), path.Join(dstDir.GetPath(), srcObj.GetName()))
+	return d.retry(func(client *sftp.Client) error {
+		return client.Rename(srcObj.GetPath(), path.Join(dstDir.GetPath(), srcObj.GetName()))
+	})
 }
 
 func (d *SFTP) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
-	return d.client.Rename(srcObj.GetPath(), path.Join(path.Dir(srcObj.GetPath()), newName))
+	return d.retry(func(client *sftp.Client) error {
+		return client.Rename(srcObj.GetPath(), path.Join(path.Dir(srcObj.GetPath()), newName))
+	})
 }
 
 func (d *SFTP) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
@@ -77,11 +96,17 @@ func (d *SFTP) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
 }
 
 func (d *SFTP) Remove(ctx context.Context, obj model.Obj) error {
-	return d.remove(obj.GetPath())
+	return d.retry(func(client *sftp.Client) error {
+		return d.remove(client, obj.GetPath())
+	})
 }
 
 func (d *SFTP) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	dstFile, err := d.client.Create(path.Join(dstDir.GetPath(), stream.GetName()))
+	var dstFile *sftp.File
+	err := d.retry(func(client *sftp.Client) (err error) {
+		dstFile, err = client.Create(path.Join(dstDir.GetPath(), stream.GetName()))
+		return
+	})
 	if err != nil {
 		return err
 	}
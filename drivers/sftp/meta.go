@@ -8,8 +8,10 @@ import (
 type Addition struct {
 	Address    string `json:"address" required:"true"`
 	Username   string `json:"username" required:"true"`
-	PrivateKey string `json:"private_key" type:"text"`
+	PrivateKey string `json:"private_key" type:"text" help:"PEM or OpenSSH format"`
+	Passphrase string `json:"passphrase" help:"Passphrase of the private key, leave empty if not encrypted"`
 	Password   string `json:"password"`
+	HostKey    string `json:"host_key" help:"Pin the host key, SHA256 fingerprint like SHA256:xxx or public key like ssh-ed25519 AAAA..., leave empty to skip verification"`
 	driver.RootPath
 }
 
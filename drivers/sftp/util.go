@@ -1,72 +1,158 @@
 package sftp
 
 import (
+	"errors"
+	"io"
+	"net"
 	"path"
+	"strings"
 
 	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 )
 
 // do others that not defined in Driver interface
 
 func (d *SFTP) initClient() error {
-	var auth ssh.AuthMethod
+	auth := []ssh.AuthMethod{ssh.Password(d.Password)}
 	if d.PrivateKey != "" {
-		signer, err := ssh.ParsePrivateKey([]byte(d.PrivateKey))
+		var signer ssh.Signer
+		var err error
+		if d.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(d.PrivateKey), []byte(d.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(d.PrivateKey))
+		}
 		if err != nil {
 			return err
 		}
-		auth = ssh.PublicKeys(signer)
-	} else {
-		auth = ssh.Password(d.Password)
+		auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+		if d.Password != "" {
+			auth = append(auth, ssh.Password(d.Password))
+		}
+	}
+	hostKeyCallback, err := d.hostKeyCallback()
+	if err != nil {
+		return err
 	}
 	config := &ssh.ClientConfig{
 		User:            d.Username,
-		Auth:            []ssh.AuthMethod{auth},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
 	}
 	conn, err := ssh.Dial("tcp", d.Address, config)
 	if err != nil {
 		return err
 	}
-	d.client, err = sftp.NewClient(conn)
+	d.client, err = sftp.NewClient(conn, sftp.UseConcurrentReads(true), sftp.UseConcurrentWrites(true))
+	if err != nil {
+		_ = conn.Close()
+	}
 	return err
 }
 
-func (d *SFTP) remove(remotePath string) error {
-	f, err := d.client.Stat(remotePath)
+// hostKeyCallback verify the host key with the pinned fingerprint or public key
+func (d *SFTP) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	pinned := strings.TrimSpace(d.HostKey)
+	if pinned == "" {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if strings.HasPrefix(pinned, "SHA256:") {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if fp := ssh.FingerprintSHA256(key); fp != pinned {
+				return errors.New("host key mismatch, got " + fp)
+			}
+			return nil
+		}, nil
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pinned))
+	if err != nil {
+		return nil, errors.New("invalid host key: " + err.Error())
+	}
+	return ssh.FixedHostKey(pub), nil
+}
+
+// isConnLost check whether the error is caused by a broken connection
+func isConnLost(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) || errors.Is(err, io.EOF) ||
+		errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retry run the operation with the current client, and reconnect once if the connection is lost,
+// so that the connection is reused between operations instead of dialing every time
+func (d *SFTP) retry(f func(client *sftp.Client) error) error {
+	d.mu.Lock()
+	client := d.client
+	d.mu.Unlock()
+	if client == nil {
+		if err := d.reconnect(nil); err != nil {
+			return err
+		}
+		return d.retry(f)
+	}
+	err := f(client)
+	if err == nil || !isConnLost(err) {
+		return err
+	}
+	log.Warnf("[sftp] connection to %s lost, reconnecting: %v", d.Address, err)
+	if err = d.reconnect(client); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	client = d.client
+	d.mu.Unlock()
+	return f(client)
+}
+
+// reconnect replace the broken client, do nothing if it has been replaced by others
+func (d *SFTP) reconnect(broken *sftp.Client) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.client != broken {
+		return nil
+	}
+	if d.client != nil {
+		_ = d.client.Close()
+		d.client = nil
+	}
+	return d.initClient()
+}
+
+func (d *SFTP) remove(client *sftp.Client, remotePath string) error {
+	f, err := client.Stat(remotePath)
 	if err != nil {
 		return nil
 	}
 	if f.IsDir() {
-		return d.removeDirectory(remotePath)
+		return d.removeDirectory(client, remotePath)
 	} else {
-		return d.removeFile(remotePath)
+		return d.removeFile(client, remotePath)
 	}
 }
 
-func (d *SFTP) removeDirectory(remotePath string) error {
-	remoteFiles, err := d.client.ReadDir(remotePath)
+func (d *SFTP) removeDirectory(client *sftp.Client, remotePath string) error {
+	remoteFiles, err := client.ReadDir(remotePath)
 	if err != nil {
 		return err
 	}
 	for _, backupDir := range remoteFiles {
 		remoteFilePath := path.Join(remotePath, backupDir.Name())
 		if backupDir.IsDir() {
-			err := d.removeDirectory(remoteFilePath)
+			err := d.removeDirectory(client, remoteFilePath)
 			if err != nil {
 				return err
 			}
 		} else {
-			err := d.removeFile(remoteFilePath)
+			err := d.removeFile(client, remoteFilePath)
 			if err != nil {
 				return err
 			}
 		}
 	}
-	return d.client.RemoveDirectory(remotePath)
+	return client.RemoveDirectory(remotePath)
 }
 
-func (d *SFTP) removeFile(remotePath string) error {
-	return d.client.Remove(path.Join(remotePath))
+func (d *SFTP) removeFile(client *sftp.Client, remotePath string) error {
+	return client.Remove(path.Join(remotePath))
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/alist-org/alist/v3/drivers/base"
 	"github.com/alist-org/alist/v3/internal/driver"
@@ -18,7 +19,10 @@ type SMB struct {
 	lastConnTime int64
 	model.Storage
 	Addition
-	fs *smb2.Share
+	// mu guards the connection, which is replaced after idle
+	mu      sync.Mutex
+	session *smb2.Session
+	fs      *smb2.Share
 }
 
 func (d *SMB) Config() driver.Config {
@@ -33,22 +37,25 @@ func (d *SMB) Init(ctx context.Context) error {
 	if strings.Index(d.Addition.Address, ":") < 0 {
 		d.Addition.Address = d.Addition.Address + ":445"
 	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	return d.initFS()
 }
 
 func (d *SMB) Drop(ctx context.Context) error {
-	if d.fs != nil {
-		_ = d.fs.Umount()
-	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closeFS()
 	return nil
 }
 
 func (d *SMB) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return nil, err
 	}
 	fullPath := dir.GetPath()
-	rawFiles, err := d.fs.ReadDir(fullPath)
+	rawFiles, err := share.ReadDir(fullPath)
 	if err != nil {
 		d.cleanLastConnTime()
 		return nil, err
@@ -70,11 +77,12 @@ func (d *SMB) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]m
 }
 
 func (d *SMB) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return nil, err
 	}
 	fullPath := file.GetPath()
-	remoteFile, err := d.fs.Open(fullPath)
+	remoteFile, err := share.Open(fullPath)
 	if err != nil {
 		d.cleanLastConnTime()
 		return nil, err
@@ -88,11 +96,12 @@ func (d *SMB) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*m
 }
 
 func (d *SMB) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return err
 	}
 	fullPath := filepath.Join(parentDir.GetPath(), dirName)
-	err := d.fs.MkdirAll(fullPath, 0700)
+	err = share.MkdirAll(fullPath, 0700)
 	if err != nil {
 		d.cleanLastConnTime()
 		return err
@@ -102,12 +111,13 @@ func (d *SMB) MakeDir(ctx context.Context, parentDir model.Obj, dirName string)
 }
 
 func (d *SMB) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return err
 	}
 	srcPath := srcObj.GetPath()
 	dstPath := filepath.Join(dstDir.GetPath(), srcObj.GetName())
-	err := d.fs.Rename(srcPath, dstPath)
+	err = share.Rename(srcPath, dstPath)
 	if err != nil {
 		d.cleanLastConnTime()
 		return err
@@ -117,12 +127,13 @@ func (d *SMB) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
 }
 
 func (d *SMB) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return err
 	}
 	srcPath := srcObj.GetPath()
 	dstPath := filepath.Join(filepath.Dir(srcPath), newName)
-	err := d.fs.Rename(srcPath, dstPath)
+	err = share.Rename(srcPath, dstPath)
 	if err != nil {
 		d.cleanLastConnTime()
 		return err
@@ -132,16 +143,16 @@ func (d *SMB) Rename(ctx context.Context, srcObj model.Obj, newName string) erro
 }
 
 func (d *SMB) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return err
 	}
 	srcPath := srcObj.GetPath()
 	dstPath := filepath.Join(dstDir.GetPath(), srcObj.GetName())
-	var err error
 	if srcObj.IsDir() {
-		err = d.CopyDir(srcPath, dstPath)
+		err = d.CopyDir(share, srcPath, dstPath)
 	} else {
-		err = d.CopyFile(srcPath, dstPath)
+		err = d.CopyFile(share, srcPath, dstPath)
 	}
 	if err != nil {
 		d.cleanLastConnTime()
@@ -152,15 +163,15 @@ func (d *SMB) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
 }
 
 func (d *SMB) Remove(ctx context.Context, obj model.Obj) error {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return err
 	}
-	var err error
 	fullPath := obj.GetPath()
 	if obj.IsDir() {
-		err = d.fs.RemoveAll(fullPath)
+		err = share.RemoveAll(fullPath)
 	} else {
-		err = d.fs.Remove(fullPath)
+		err = share.Remove(fullPath)
 	}
 	if err != nil {
 		d.cleanLastConnTime()
@@ -171,11 +182,12 @@ func (d *SMB) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *SMB) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
-	if err := d.checkConn(); err != nil {
+	share, err := d.checkConn()
+	if err != nil {
 		return err
 	}
 	fullPath := filepath.Join(dstDir.GetPath(), stream.GetName())
-	out, err := d.fs.Create(fullPath)
+	out, err := share.Create(fullPath)
 	if err != nil {
 		d.cleanLastConnTime()
 		return err
@@ -184,7 +196,7 @@ func (d *SMB) Put(ctx context.Context, dstDir model.Obj, stream model.FileStream
 	defer func() {
 		_ = out.Close()
 		if errors.Is(err, context.Canceled) {
-			_ = d.fs.Remove(fullPath)
+			_ = share.Remove(fullPath)
 		}
 	}()
 	err = utils.CopyWithCtx(ctx, out, stream, stream.GetSize(), up)
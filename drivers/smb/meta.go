@@ -8,6 +8,7 @@ import (
 type Addition struct {
 	driver.RootPath
 	Address   string `json:"address" required:"true"`
+	Domain    string `json:"domain" help:"Windows domain or workgroup of the user, leave empty if not needed"`
 	Username  string `json:"username" required:"true"`
 	Password  string `json:"password"`
 	ShareName string `json:"share_name" required:"true"`
@@ -24,6 +24,9 @@ func (d *SMB) getLastConnTime() time.Time {
 	return time.Unix(atomic.LoadInt64(&d.lastConnTime), 0)
 }
 
+// initFS connects to the share, the old connection is closed only after
+// the new one is made, so that it's kept if the new one fails. d.mu must be
+// held.
 func (d *SMB) initFS() error {
 	conn, err := net.Dial("tcp", d.Address)
 	if err != nil {
@@ -33,43 +36,64 @@ func (d *SMB) initFS() error {
 		Initiator: &smb2.NTLMInitiator{
 			User:     d.Username,
 			Password: d.Password,
+			Domain:   d.Domain,
 		},
 	}
 	s, err := dialer.Dial(conn)
 	if err != nil {
+		_ = conn.Close()
 		return err
 	}
-	d.fs, err = s.Mount(d.ShareName)
+	share, err := s.Mount(d.ShareName)
 	if err != nil {
+		_ = s.Logoff()
 		return err
 	}
+	d.closeFS()
+	d.session, d.fs = s, share
 	d.updateLastConnTime()
-	return err
+	return nil
 }
 
-func (d *SMB) checkConn() error {
-	if time.Since(d.getLastConnTime()) < 5*time.Minute {
-		return nil
+// checkConn reconnects if the connection has been idle for long or failed,
+// and returns the share to use
+func (d *SMB) checkConn() (*smb2.Share, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.fs == nil || time.Since(d.getLastConnTime()) >= 5*time.Minute {
+		if err := d.initFS(); err != nil {
+			return nil, err
+		}
 	}
+	return d.fs, nil
+}
+
+// closeFS unmount the share and log off the session, which also closes the
+// tcp connection. d.mu must be held.
+func (d *SMB) closeFS() {
 	if d.fs != nil {
 		_ = d.fs.Umount()
+		d.fs = nil
+	}
+	if d.session != nil {
+		_ = d.session.Logoff()
+		d.session = nil
 	}
-	return d.initFS()
 }
 
 // CopyFile File copies a single file from src to dst
-func (d *SMB) CopyFile(src, dst string) error {
+func (d *SMB) CopyFile(share *smb2.Share, src, dst string) error {
 	var err error
 	var srcfd *smb2.File
 	var dstfd *smb2.File
 	var srcinfo fs.FileInfo
 
-	if srcfd, err = d.fs.Open(src); err != nil {
+	if srcfd, err = share.Open(src); err != nil {
 		return err
 	}
 	defer srcfd.Close()
 
-	if dstfd, err = d.CreateNestedFile(dst); err != nil {
+	if dstfd, err = d.CreateNestedFile(share, dst); err != nil {
 		return err
 	}
 	defer dstfd.Close()
@@ -77,25 +101,25 @@ func (d *SMB) CopyFile(src, dst string) error {
 	if _, err = io.Copy(dstfd, srcfd); err != nil {
 		return err
 	}
-	if srcinfo, err = d.fs.Stat(src); err != nil {
+	if srcinfo, err = share.Stat(src); err != nil {
 		return err
 	}
-	return d.fs.Chmod(dst, srcinfo.Mode())
+	return share.Chmod(dst, srcinfo.Mode())
 }
 
 // CopyDir Dir copies a whole directory recursively
-func (d *SMB) CopyDir(src string, dst string) error {
+func (d *SMB) CopyDir(share *smb2.Share, src string, dst string) error {
 	var err error
 	var fds []fs.FileInfo
 	var srcinfo fs.FileInfo
 
-	if srcinfo, err = d.fs.Stat(src); err != nil {
+	if srcinfo, err = share.Stat(src); err != nil {
 		return err
 	}
-	if err = d.fs.MkdirAll(dst, srcinfo.Mode()); err != nil {
+	if err = share.MkdirAll(dst, srcinfo.Mode()); err != nil {
 		return err
 	}
-	if fds, err = d.fs.ReadDir(src); err != nil {
+	if fds, err = share.ReadDir(src); err != nil {
 		return err
 	}
 	for _, fd := range fds {
@@ -103,11 +127,11 @@ func (d *SMB) CopyDir(src string, dst string) error {
 		dstfp := filepath.Join(dst, fd.Name())
 
 		if fd.IsDir() {
-			if err = d.CopyDir(srcfp, dstfp); err != nil {
+			if err = d.CopyDir(share, srcfp, dstfp); err != nil {
 				return err
 			}
 		} else {
-			if err = d.CopyFile(srcfp, dstfp); err != nil {
+			if err = d.CopyFile(share, srcfp, dstfp); err != nil {
 				return err
 			}
 		}
@@ -116,8 +140,8 @@ func (d *SMB) CopyDir(src string, dst string) error {
 }
 
 // Exists determine whether the file exists
-func (d *SMB) Exists(name string) bool {
-	if _, err := d.fs.Stat(name); err != nil {
+func (d *SMB) Exists(share *smb2.Share, name string) bool {
+	if _, err := share.Stat(name); err != nil {
 		if os.IsNotExist(err) {
 			return false
 		}
@@ -126,13 +150,13 @@ func (d *SMB) Exists(name string) bool {
 }
 
 // CreateNestedFile create nested file
-func (d *SMB) CreateNestedFile(path string) (*smb2.File, error) {
+func (d *SMB) CreateNestedFile(share *smb2.Share, path string) (*smb2.File, error) {
 	basePath := filepath.Dir(path)
-	if !d.Exists(basePath) {
-		err := d.fs.MkdirAll(basePath, 0700)
+	if !d.Exists(share, basePath) {
+		err := share.MkdirAll(basePath, 0700)
 		if err != nil {
 			return nil, err
 		}
 	}
-	return d.fs.Create(path)
+	return share.Create(path)
 }
@@ -0,0 +1,168 @@
+package storj
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	stdpath "path"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/pkg/errors"
+	"storj.io/uplink"
+)
+
+type Storj struct {
+	model.Storage
+	Addition
+	session *session.Session
+	client  *s3.S3
+	// project is set with access grant, the files are read and written
+	// with the satellite and the storage nodes directly
+	project *uplink.Project
+}
+
+func (d *Storj) Config() driver.Config {
+	if d.AccessType == "access_grant" {
+		// there is no url to redirect to without gateway-mt
+		c := config
+		c.OnlyProxy = true
+		return c
+	}
+	return config
+}
+
+func (d *Storj) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Storj) Init(ctx context.Context) error {
+	if d.UploadConcurrency < 1 {
+		d.UploadConcurrency = 4
+	}
+	if d.UploadPartSize < 5 {
+		d.UploadPartSize = 64
+	}
+	if d.DownloadConcurrency < 1 {
+		d.DownloadConcurrency = 4
+	}
+	if d.DownloadPartSize < 1 {
+		d.DownloadPartSize = 16
+	}
+	if d.AccessType == "access_grant" {
+		if d.AccessGrant == "" {
+			return errors.New("access grant is required")
+		}
+		return d.openProject(ctx)
+	}
+	if d.AccessKeyID == "" || d.SecretAccessKey == "" {
+		return errors.New("access key id and secret access key are required")
+	}
+	if d.Endpoint == "" {
+		d.Endpoint = "https://gateway.storjshare.io"
+	}
+	return d.initSession()
+}
+
+func (d *Storj) Drop(ctx context.Context) error {
+	if d.project != nil {
+		err := d.project.Close()
+		d.project = nil
+		return err
+	}
+	return nil
+}
+
+func (d *Storj) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	return d.list(ctx, dir.GetPath())
+}
+
+func (d *Storj) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	key := getKey(file.GetPath(), false)
+	if d.project != nil {
+		return &model.Link{
+			Handle: d.serveObject(key, file.GetSize()),
+		}, nil
+	}
+	filename := stdpath.Base(key)
+	disposition := fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename))
+	req, _ := d.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket:                     &d.Bucket,
+		Key:                        &key,
+		ResponseContentDisposition: &disposition,
+	})
+	expire := time.Hour * time.Duration(d.SignURLExpire)
+	link, err := req.Presign(expire)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Link{
+		URL:        link,
+		Expiration: &expire,
+	}, nil
+}
+
+func (d *Storj) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return d.Put(ctx, &model.Object{
+		Path: stdpath.Join(parentDir.GetPath(), dirName),
+	}, &model.FileStream{
+		Obj: &model.Object{
+			Name:     placeholderName,
+			Modified: time.Now(),
+		},
+		ReadCloser: io.NopCloser(bytes.NewReader([]byte{})),
+		Mimetype:   "application/octet-stream",
+	}, func(int) {})
+}
+
+func (d *Storj) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.move(ctx, srcObj, stdpath.Join(dstDir.GetPath(), srcObj.GetName()))
+}
+
+func (d *Storj) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.move(ctx, srcObj, stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName))
+}
+
+func (d *Storj) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dst := stdpath.Join(dstDir.GetPath(), srcObj.GetName())
+	if srcObj.IsDir() {
+		return d.copyDir(ctx, srcObj.GetPath(), dst)
+	}
+	return d.copyFile(ctx, srcObj.GetPath(), dst)
+}
+
+func (d *Storj) Remove(ctx context.Context, obj model.Obj) error {
+	if obj.IsDir() {
+		return d.removeDir(ctx, obj.GetPath())
+	}
+	return d.removeFile(ctx, obj.GetPath())
+}
+
+func (d *Storj) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	key := getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()), false)
+	body := io.TeeReader(stream, driver.NewProgress(stream.GetSize(), up))
+	if d.project != nil {
+		return d.uploadObject(ctx, key, body, stream.GetSize())
+	}
+	uploader := s3manager.NewUploader(d.session, func(u *s3manager.Uploader) {
+		u.Concurrency = d.UploadConcurrency
+		u.PartSize = int64(d.UploadPartSize) * 1024 * 1024
+	})
+	if stream.GetSize() > s3manager.MaxUploadParts*uploader.PartSize {
+		uploader.PartSize = stream.GetSize() / (s3manager.MaxUploadParts - 1)
+	}
+	_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: &d.Bucket,
+		Key:    &key,
+		Body:   body,
+	})
+	return err
+}
+
+var _ driver.Driver = (*Storj)(nil)
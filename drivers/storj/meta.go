@@ -0,0 +1,34 @@
+package storj
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	AccessType          string `json:"access_type" type:"select" options:"s3_credentials,access_grant" default:"s3_credentials" help:"s3_credentials goes through gateway-mt, access_grant talks to the satellite and the storage nodes directly."`
+	Bucket              string `json:"bucket" required:"true"`
+	Endpoint            string `json:"endpoint" default:"https://gateway.storjshare.io"`
+	AccessKeyID         string `json:"access_key_id"`
+	SecretAccessKey     string `json:"secret_access_key"`
+	SignURLExpire       int    `json:"sign_url_expire" type:"number" default:"4"`
+	AccessGrant         string `json:"access_grant" type:"text" help:"Serialized access grant, the files are proxied through alist with it."`
+	UploadConcurrency   int    `json:"upload_concurrency" type:"number" default:"4" help:"Number of segments uploaded in parallel."`
+	UploadPartSize      int    `json:"upload_part_size" type:"number" default:"64" help:"Segment size in MB, Storj uses 64MB segments."`
+	DownloadConcurrency int    `json:"download_concurrency" type:"number" default:"4" help:"Number of ranges downloaded in parallel with access grant."`
+	DownloadPartSize    int    `json:"download_part_size" type:"number" default:"16" help:"Size in MB of the ranges downloaded in parallel with access grant."`
+}
+
+var config = driver.Config{
+	Name:        "Storj",
+	DefaultRoot: "/",
+	LocalSort:   true,
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Storj{}
+	})
+}
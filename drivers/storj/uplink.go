@@ -0,0 +1,279 @@
+package storj
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	"storj.io/uplink"
+)
+
+// openProject opens the project of the access grant and checks the bucket
+func (d *Storj) openProject(ctx context.Context) error {
+	access, err := uplink.ParseAccess(d.AccessGrant)
+	if err != nil {
+		return errors.WithMessage(err, "failed parse access grant")
+	}
+	project, err := uplink.OpenProject(ctx, access)
+	if err != nil {
+		return err
+	}
+	if _, err = project.StatBucket(ctx, d.Bucket); err != nil {
+		_ = project.Close()
+		return err
+	}
+	d.project = project
+	return nil
+}
+
+func (d *Storj) listObjects(ctx context.Context, prefix string) ([]model.Obj, error) {
+	files := make([]model.Obj, 0)
+	it := d.project.ListObjects(ctx, d.Bucket, &uplink.ListObjectsOptions{
+		Prefix: prefix,
+		System: true,
+	})
+	for it.Next() {
+		object := it.Item()
+		if object.IsPrefix {
+			files = append(files, &model.Object{
+				Name:     path.Base(strings.Trim(object.Key, "/")),
+				Modified: d.Modified,
+				IsFolder: true,
+			})
+			continue
+		}
+		if strings.HasSuffix(object.Key, "/") {
+			continue
+		}
+		name := path.Base(object.Key)
+		if name == placeholderName {
+			continue
+		}
+		files = append(files, &model.Object{
+			Name:     name,
+			Size:     object.System.ContentLength,
+			Modified: object.System.Created,
+		})
+	}
+	return files, it.Err()
+}
+
+// uploadObject uploads the object as a multipart upload, whose parts are
+// the segments of the object, so that UploadConcurrency segments are
+// erasure coded and sent to the storage nodes at once
+func (d *Storj) uploadObject(ctx context.Context, key string, r io.Reader, size int64) error {
+	partSize := int64(d.UploadPartSize) * 1024 * 1024
+	if size <= partSize || d.UploadConcurrency <= 1 {
+		upload, err := d.project.UploadObject(ctx, d.Bucket, key, nil)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(upload, r); err != nil {
+			_ = upload.Abort()
+			return err
+		}
+		return upload.Commit()
+	}
+	info, err := d.project.BeginUpload(ctx, d.Bucket, key, nil)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	sem := make(chan struct{}, d.UploadConcurrency)
+	for partNumber := uint32(1); ; partNumber++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		buf := make([]byte, partSize)
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			<-sem
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			<-sem
+			fail(err)
+			break
+		}
+		wg.Add(1)
+		go func(partNumber uint32, data []byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := d.uploadPart(ctx, key, info.UploadID, partNumber, data); err != nil {
+				fail(err)
+			}
+		}(partNumber, buf[:n])
+		if int64(n) < partSize {
+			break
+		}
+	}
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		_ = d.project.AbortUpload(context.Background(), d.Bucket, key, info.UploadID)
+		return firstErr
+	}
+	_, err = d.project.CommitUpload(ctx, d.Bucket, key, info.UploadID, nil)
+	return err
+}
+
+func (d *Storj) uploadPart(ctx context.Context, key, uploadID string, partNumber uint32, data []byte) error {
+	part, err := d.project.UploadPart(ctx, d.Bucket, key, uploadID, partNumber)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(part, bytes.NewReader(data)); err != nil {
+		_ = part.Abort()
+		return err
+	}
+	return part.Commit()
+}
+
+// serveObject serves the object with ranges, which are downloaded
+// DownloadConcurrency parts at once and written in order
+func (d *Storj) serveObject(key string, size int64) func(w http.ResponseWriter, r *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		rg := http_range.Range{Start: 0, Length: size}
+		ranges, err := http_range.ParseRange(r.Header.Get("Range"), size)
+		if err != nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return nil
+		}
+		// multiple ranges are served as the whole object
+		if len(ranges) == 1 {
+			rg = ranges[0]
+		}
+		filename := path.Base(key)
+		w.Header().Set("Content-Type", utils.GetMimeType(filename))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(filename)))
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.FormatInt(rg.Length, 10))
+		if len(ranges) == 1 {
+			w.Header().Set("Content-Range", rg.ContentRange(size))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		if r.Method == http.MethodHead || rg.Length == 0 {
+			return nil
+		}
+		return d.download(r.Context(), w, key, rg.Start, rg.Length)
+	}
+}
+
+type partResult struct {
+	data []byte
+	err  error
+}
+
+func (d *Storj) download(ctx context.Context, w io.Writer, key string, start, length int64) error {
+	partSize := int64(d.DownloadPartSize) * 1024 * 1024
+	if length <= partSize || d.DownloadConcurrency <= 1 {
+		download, err := d.project.DownloadObject(ctx, d.Bucket, key, &uplink.DownloadOptions{
+			Offset: start,
+			Length: length,
+		})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = download.Close()
+		}()
+		_, err = io.Copy(w, download)
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	n := (length + partSize - 1) / partSize
+	results := make([]chan partResult, n)
+	for i := range results {
+		results[i] = make(chan partResult, 1)
+	}
+	// a part holds the semaphore until it's written, so that at most
+	// DownloadConcurrency parts are in memory
+	sem := make(chan struct{}, d.DownloadConcurrency)
+	go func() {
+		for i := int64(0); i < n; i++ {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			partStart := start + i*partSize
+			partLength := partSize
+			if end := start + length; partStart+partLength > end {
+				partLength = end - partStart
+			}
+			go func(ch chan partResult, start, length int64) {
+				data, err := d.downloadPart(ctx, key, start, length)
+				ch <- partResult{data: data, err: err}
+			}(results[i], partStart, partLength)
+		}
+	}()
+	for i := int64(0); i < n; i++ {
+		var res partResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if res.err != nil {
+			return res.err
+		}
+		if _, err := w.Write(res.data); err != nil {
+			return err
+		}
+		<-sem
+	}
+	return nil
+}
+
+func (d *Storj) downloadPart(ctx context.Context, key string, start, length int64) ([]byte, error) {
+	download, err := d.project.DownloadObject(ctx, d.Bucket, key, &uplink.DownloadOptions{
+		Offset: start,
+		Length: length,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = download.Close()
+	}()
+	data := make([]byte, length)
+	if _, err = io.ReadFull(download, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
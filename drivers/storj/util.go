@@ -0,0 +1,177 @@
+package storj
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// do others that not defined in Driver interface
+
+func (d *Storj) initSession() error {
+	cfg := &aws.Config{
+		Credentials:      credentials.NewStaticCredentials(d.AccessKeyID, d.SecretAccessKey, ""),
+		Region:           aws.String("us-1"),
+		Endpoint:         &d.Endpoint,
+		S3ForcePathStyle: aws.Bool(true),
+	}
+	var err error
+	d.session, err = session.NewSession(cfg)
+	if err != nil {
+		return err
+	}
+	d.client = s3.New(d.session)
+	return nil
+}
+
+func getKey(path string, dir bool) string {
+	path = strings.TrimPrefix(path, "/")
+	if path != "" && dir {
+		path += "/"
+	}
+	return path
+}
+
+const placeholderName = ".alist"
+
+func (d *Storj) list(ctx context.Context, prefix string) ([]model.Obj, error) {
+	prefix = getKey(prefix, true)
+	if d.project != nil {
+		return d.listObjects(ctx, prefix)
+	}
+	files := make([]model.Obj, 0)
+	var continuationToken *string
+	for {
+		input := &s3.ListObjectsV2Input{
+			Bucket:            &d.Bucket,
+			ContinuationToken: continuationToken,
+			Prefix:            &prefix,
+			Delimiter:         aws.String("/"),
+		}
+		res, err := d.client.ListObjectsV2WithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range res.CommonPrefixes {
+			files = append(files, &model.Object{
+				Name:     path.Base(strings.Trim(*object.Prefix, "/")),
+				Modified: d.Modified,
+				IsFolder: true,
+			})
+		}
+		for _, object := range res.Contents {
+			if strings.HasSuffix(*object.Key, "/") {
+				continue
+			}
+			name := path.Base(*object.Key)
+			if name == placeholderName {
+				continue
+			}
+			files = append(files, &model.Object{
+				Name:     name,
+				Size:     *object.Size,
+				Modified: *object.LastModified,
+			})
+		}
+		if !aws.BoolValue(res.IsTruncated) || res.NextContinuationToken == nil {
+			break
+		}
+		continuationToken = res.NextContinuationToken
+	}
+	return files, nil
+}
+
+func (d *Storj) copyFile(ctx context.Context, src string, dst string) error {
+	srcKey := getKey(src, false)
+	dstKey := getKey(dst, false)
+	if d.project != nil {
+		_, err := d.project.CopyObject(ctx, d.Bucket, srcKey, d.Bucket, dstKey, nil)
+		return err
+	}
+	copySource := &url.URL{Path: "/" + d.Bucket + "/" + srcKey}
+	_, err := d.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     &d.Bucket,
+		CopySource: aws.String(copySource.EscapedPath()),
+		Key:        &dstKey,
+	})
+	return err
+}
+
+func (d *Storj) move(ctx context.Context, srcObj model.Obj, dst string) error {
+	if d.project != nil && !srcObj.IsDir() {
+		return d.project.MoveObject(ctx, d.Bucket, getKey(srcObj.GetPath(), false), d.Bucket, getKey(dst, false), nil)
+	}
+	var err error
+	if srcObj.IsDir() {
+		err = d.copyDir(ctx, srcObj.GetPath(), dst)
+	} else {
+		err = d.copyFile(ctx, srcObj.GetPath(), dst)
+	}
+	if err != nil {
+		return err
+	}
+	if srcObj.IsDir() {
+		return d.removeDir(ctx, srcObj.GetPath())
+	}
+	return d.removeFile(ctx, srcObj.GetPath())
+}
+
+func (d *Storj) copyDir(ctx context.Context, src string, dst string) error {
+	objs, err := d.list(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		cSrc := path.Join(src, obj.GetName())
+		cDst := path.Join(dst, obj.GetName())
+		if obj.IsDir() {
+			err = d.copyDir(ctx, cSrc, cDst)
+		} else {
+			err = d.copyFile(ctx, cSrc, cDst)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Storj) removeDir(ctx context.Context, src string) error {
+	objs, err := d.list(ctx, src)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		cSrc := path.Join(src, obj.GetName())
+		if obj.IsDir() {
+			err = d.removeDir(ctx, cSrc)
+		} else {
+			err = d.removeFile(ctx, cSrc)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_ = d.removeFile(ctx, path.Join(src, placeholderName))
+	return nil
+}
+
+func (d *Storj) removeFile(ctx context.Context, src string) error {
+	key := getKey(src, false)
+	if d.project != nil {
+		_, err := d.project.DeleteObject(ctx, d.Bucket, key)
+		return err
+	}
+	_, err := d.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: &d.Bucket,
+		Key:    &key,
+	})
+	return err
+}
@@ -0,0 +1,153 @@
+package web3_storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+type Web3Storage struct {
+	model.Storage
+	Addition
+}
+
+func (d *Web3Storage) Config() driver.Config {
+	return config
+}
+
+func (d *Web3Storage) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Web3Storage) Init(ctx context.Context) error {
+	d.Gateway = strings.TrimSuffix(d.Gateway, "/")
+	// check the token
+	_, err := d.getUploads(ctx)
+	return err
+}
+
+func (d *Web3Storage) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Web3Storage) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	uploads, err := d.getUploads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objs := d.listDir(uploads, dir.GetPath())
+	for _, obj := range objs {
+		obj.(*model.Object).Path = stdpath.Join(dir.GetPath(), obj.GetName())
+	}
+	return objs, nil
+}
+
+func (d *Web3Storage) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	return &model.Link{
+		URL: d.Gateway + "/ipfs/" + file.GetID() + "?filename=" + url.QueryEscape(file.GetName()),
+	}, nil
+}
+
+func (d *Web3Storage) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	name := d.getKey(stdpath.Join(parentDir.GetPath(), dirName, placeholderName))
+	return d.request(ctx, "POST", "/upload", func(req *resty.Request) {
+		req.SetHeader("X-Name", url.PathEscape(name)).SetBody(bytes.NewReader([]byte{}))
+	}, nil)
+}
+
+func (d *Web3Storage) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	dst := stdpath.Join(dstDir.GetPath(), srcObj.GetName())
+	if srcObj.IsDir() {
+		return d.renamePrefix(ctx, srcObj.GetPath(), dst)
+	}
+	return d.rename(ctx, srcObj.GetID(), d.getKey(dst))
+}
+
+func (d *Web3Storage) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	dst := stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName)
+	if srcObj.IsDir() {
+		return d.renamePrefix(ctx, srcObj.GetPath(), dst)
+	}
+	return d.rename(ctx, srcObj.GetID(), d.getKey(dst))
+}
+
+func (d *Web3Storage) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return errs.NotSupport
+}
+
+func (d *Web3Storage) Remove(ctx context.Context, obj model.Obj) error {
+	if !obj.IsDir() {
+		return d.remove(ctx, obj.GetID())
+	}
+	uploads, err := d.getUploads(ctx)
+	if err != nil {
+		return err
+	}
+	prefix := d.getKey(obj.GetPath()) + "/"
+	for _, u := range uploads {
+		if !strings.HasPrefix(u.Name, prefix) {
+			continue
+		}
+		if err = d.remove(ctx, u.Cid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Web3Storage) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	name := d.getKey(stdpath.Join(dstDir.GetPath(), stream.GetName()))
+	// a CAR file is imported as the DAG it contains, other files are packed by the server
+	api, contentType := "/upload", "application/octet-stream"
+	if strings.HasSuffix(strings.ToLower(stream.GetName()), ".car") {
+		api, contentType = "/car", "application/car"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiUrl+api, io.TeeReader(stream, driver.NewProgress(stream.GetSize(), up)))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stream.GetSize()
+	req.Header.Set("Authorization", "Bearer "+d.Token)
+	req.Header.Set("X-Name", url.PathEscape(name))
+	req.Header.Set("Content-Type", contentType)
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		var e ErrResp
+		data, _ := io.ReadAll(res.Body)
+		_ = utils.Json.Unmarshal(data, &e)
+		return errors.Errorf("failed upload: %s %s", e.Name, e.Message)
+	}
+	return nil
+}
+
+func (d *Web3Storage) Other(ctx context.Context, args model.OtherArgs) (interface{}, error) {
+	switch args.Method {
+	case "status":
+		var resp StatusResp
+		err := d.request(ctx, "GET", "/status/"+url.PathEscape(args.Obj.GetID()), nil, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp, nil
+	default:
+		return nil, errs.NotSupport
+	}
+}
+
+var _ driver.Driver = (*Web3Storage)(nil)
@@ -0,0 +1,26 @@
+package web3_storage
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	Token   string `json:"token" required:"true" help:"API token created in the web3.storage account page"`
+	Gateway string `json:"gateway" required:"true" default:"https://w3s.link"`
+}
+
+var config = driver.Config{
+	Name:              "Web3Storage",
+	LocalSort:         true,
+	DefaultRoot:       "/",
+	NoOverwriteUpload: true,
+	Alert:             "warning|Uploads are pinned to IPFS and stored on Filecoin, deleting a file only removes it from the list.",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Web3Storage{}
+	})
+}
@@ -0,0 +1,61 @@
+package web3_storage
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type ErrResp struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+type Upload struct {
+	Cid     string    `json:"cid"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	DagSize int64     `json:"dagSize"`
+}
+
+type UploadResp struct {
+	Cid string `json:"cid"`
+}
+
+type Pin struct {
+	PeerID   string    `json:"peerId"`
+	PeerName string    `json:"peerName"`
+	Region   string    `json:"region"`
+	Status   string    `json:"status"`
+	Updated  time.Time `json:"updated"`
+}
+
+type Deal struct {
+	DealID            int64     `json:"dealId"`
+	StorageProvider   string    `json:"storageProvider"`
+	Status            string    `json:"status"`
+	PieceCid          string    `json:"pieceCid"`
+	DataCid           string    `json:"dataCid"`
+	DataModelSelector string    `json:"dataModelSelector"`
+	Activation        time.Time `json:"activation"`
+	Created           time.Time `json:"created"`
+	Updated           time.Time `json:"updated"`
+}
+
+type StatusResp struct {
+	Cid     string    `json:"cid"`
+	DagSize int64     `json:"dagSize"`
+	Created time.Time `json:"created"`
+	Pins    []Pin     `json:"pins"`
+	Deals   []Deal    `json:"deals"`
+}
+
+func uploadToObj(u Upload, name string) *model.Object {
+	return &model.Object{
+		ID:       u.Cid,
+		Name:     name,
+		Size:     u.DagSize,
+		Modified: u.Created,
+	}
+}
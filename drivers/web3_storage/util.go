@@ -0,0 +1,144 @@
+package web3_storage
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/drivers/base"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+)
+
+// do others that not defined in Driver interface
+
+const (
+	apiUrl          = "https://api.web3.storage"
+	placeholderName = ".keep"
+	pageSize        = 100
+)
+
+func (d *Web3Storage) request(ctx context.Context, method, pathname string, callback base.ReqCallback, resp interface{}) error {
+	var e ErrResp
+	req := base.RestyClient.R().
+		SetContext(ctx).
+		SetAuthToken(d.Token).
+		SetError(&e)
+	if callback != nil {
+		callback(req)
+	}
+	if resp != nil {
+		req.SetResult(resp)
+	}
+	res, err := req.Execute(method, apiUrl+pathname)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		if e.Message != "" {
+			return errors.Errorf("%s: %s", e.Name, e.Message)
+		}
+		return errors.Errorf("web3.storage responded with status %d", res.StatusCode())
+	}
+	return nil
+}
+
+// getUploads list all uploads of the account, newest first
+func (d *Web3Storage) getUploads(ctx context.Context) ([]Upload, error) {
+	res := make([]Upload, 0)
+	before := time.Now().UTC()
+	for {
+		var resp []Upload
+		err := d.request(ctx, "GET", "/user/uploads", func(req *resty.Request) {
+			req.SetQueryParams(map[string]string{
+				"size":   fmt.Sprint(pageSize),
+				"before": before.Format(time.RFC3339Nano),
+			})
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp...)
+		if len(resp) < pageSize {
+			break
+		}
+		before = resp[len(resp)-1].Created
+	}
+	return res, nil
+}
+
+// getKey convert the path to the upload name, which is relative to root without the leading slash
+func (d *Web3Storage) getKey(path string) string {
+	return strings.TrimPrefix(path, "/")
+}
+
+// listDir build the virtual directory from the upload names,
+// only the newest upload is kept if there are several uploads with the same name
+func (d *Web3Storage) listDir(uploads []Upload, dir string) []model.Obj {
+	prefix := d.getKey(dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+	res := make([]model.Obj, 0)
+	seen := make(map[string]struct{})
+	for _, u := range uploads {
+		if !strings.HasPrefix(u.Name, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(u.Name, prefix)
+		if name == "" {
+			continue
+		}
+		isFolder := false
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, isFolder = name[:i], true
+		}
+		if name == placeholderName {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		if isFolder {
+			res = append(res, &model.Object{
+				Name:     name,
+				Modified: u.Created,
+				IsFolder: true,
+			})
+			continue
+		}
+		res = append(res, uploadToObj(u, name))
+	}
+	return res
+}
+
+func (d *Web3Storage) rename(ctx context.Context, cid, name string) error {
+	return d.request(ctx, "POST", "/user/uploads/"+url.PathEscape(cid)+"/rename", func(req *resty.Request) {
+		req.SetBody(base.Json{"name": name})
+	}, nil)
+}
+
+func (d *Web3Storage) renamePrefix(ctx context.Context, src, dst string) error {
+	uploads, err := d.getUploads(ctx)
+	if err != nil {
+		return err
+	}
+	src, dst = d.getKey(src)+"/", d.getKey(dst)+"/"
+	for _, u := range uploads {
+		if !strings.HasPrefix(u.Name, src) {
+			continue
+		}
+		if err = d.rename(ctx, u.Cid, dst+strings.TrimPrefix(u.Name, src)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Web3Storage) remove(ctx context.Context, cid string) error {
+	return d.request(ctx, "DELETE", "/user/uploads/"+url.PathEscape(cid), nil, nil)
+}
@@ -0,0 +1,117 @@
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/gowebdav"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// nextcloudRoots returns the upload and files collections of the user for
+// chunked upload, see
+// https://docs.nextcloud.com/server/latest/developer_manual/client_apis/WebDAV/chunking.html
+func (d *WebDav) nextcloudRoots() (string, string, error) {
+	address := strings.TrimSuffix(d.Address, "/")
+	i := strings.Index(address, "/remote.php/")
+	if i < 0 {
+		return "", "", fmt.Errorf("chunked upload requires an address like https://host/remote.php/dav/files/<user>")
+	}
+	dav := address[:i] + "/remote.php/dav"
+	files := dav + "/files/" + d.Username
+	if strings.HasPrefix(address[i:], "/remote.php/dav/files/") {
+		files = address
+	}
+	return dav + "/uploads/" + d.Username, files, nil
+}
+
+func (d *WebDav) chunkedUpload(ctx context.Context, filePath string, stream model.FileStreamer, up driver.UpdateProgress) error {
+	uploads, files, err := d.nextcloudRoots()
+	if err != nil {
+		return err
+	}
+	if err = d.client.MkdirAll(stdpath.Dir(filePath), 0644); err != nil {
+		return err
+	}
+	c := d.newClient(uploads)
+	dest := gowebdav.PathEscape(gowebdav.Join(files, filePath))
+	total := strconv.FormatInt(stream.GetSize(), 10)
+	setHeaders := func(r *http.Request) {
+		r.Header.Set("Destination", dest)
+		r.Header.Set("OC-Total-Length", total)
+	}
+	id := "alist-" + uuid.NewString()
+	if err = expectStatus(c.Do("MKCOL", id, nil, setHeaders)); err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if e := expectStatus(c.Do(http.MethodDelete, id, nil, nil)); e != nil {
+				log.Warnf("[webdav] failed to clean upload session %s: %+v", id, e)
+			}
+		}
+	}()
+
+	chunkSize := d.ChunkSize * 1024 * 1024
+	buf := make([]byte, chunkSize)
+	var finish int64
+	for n := 1; finish < stream.GetSize(); n++ {
+		if utils.IsCanceled(ctx) {
+			err = ctx.Err()
+			return err
+		}
+		size := stream.GetSize() - finish
+		if size > chunkSize {
+			size = chunkSize
+		}
+		if _, err = io.ReadFull(stream, buf[:size]); err != nil {
+			return err
+		}
+		chunk := stdpath.Join(id, strconv.Itoa(n))
+		for retry := 0; retry < 3; retry++ {
+			err = expectStatus(c.Do(http.MethodPut, chunk, bytes.NewReader(buf[:size]), func(r *http.Request) {
+				setHeaders(r)
+				r.ContentLength = size
+			}))
+			if err == nil {
+				break
+			}
+			log.Debugf("[webdav] failed to upload chunk %d of %s, retry: %+v", n, filePath, err)
+		}
+		if err != nil {
+			return err
+		}
+		finish += size
+		up(int(finish * 100 / stream.GetSize()))
+	}
+	err = expectStatus(c.Do("MOVE", stdpath.Join(id, ".file"), nil, func(r *http.Request) {
+		setHeaders(r)
+		r.Header.Set("Overwrite", "T")
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to assemble chunks: %w", err)
+	}
+	return nil
+}
+
+func expectStatus(res *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("unexpected status %s: %s", res.Status, strings.TrimSpace(string(body)))
+}
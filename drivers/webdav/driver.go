@@ -94,6 +94,9 @@ func (d *WebDav) Remove(ctx context.Context, obj model.Obj) error {
 }
 
 func (d *WebDav) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	if d.isNextcloud() && d.ChunkSize > 0 && stream.GetSize() > d.ChunkSize*1024*1024 {
+		return d.chunkedUpload(ctx, path.Join(dstDir.GetPath(), stream.GetName()), stream, up)
+	}
 	callback := func(r *http.Request) {
 		r.Header.Set("Content-Type", stream.GetMimetype())
 		r.ContentLength = stream.GetSize()
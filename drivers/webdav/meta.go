@@ -6,10 +6,12 @@ import (
 )
 
 type Addition struct {
-	Vendor   string `json:"vendor" type:"select" options:"sharepoint,other" default:"other"`
-	Address  string `json:"address" required:"true"`
-	Username string `json:"username" required:"true"`
-	Password string `json:"password" required:"true"`
+	Vendor    string `json:"vendor" type:"select" options:"sharepoint,nextcloud,other" default:"other" help:"nextcloud enables chunked upload for nextcloud and owncloud"`
+	Address   string `json:"address" required:"true"`
+	Username  string `json:"username" required:"true"`
+	Password  string `json:"password"`
+	Token     string `json:"token" help:"bearer or oauth access token, used instead of the password when set"`
+	ChunkSize int64  `json:"chunk_size" type:"number" default:"10" help:"chunk size of nextcloud chunked upload (unit: MB), 0 to disable"`
 	driver.RootPath
 }
 
@@ -13,8 +13,19 @@ func (d *WebDav) isSharepoint() bool {
 	return d.Vendor == "sharepoint"
 }
 
+func (d *WebDav) isNextcloud() bool {
+	return d.Vendor == "nextcloud"
+}
+
+func (d *WebDav) newClient(uri string) *gowebdav.Client {
+	if d.Token != "" {
+		return gowebdav.NewTokenClient(uri, d.Token)
+	}
+	return gowebdav.NewClient(uri, d.Username, d.Password)
+}
+
 func (d *WebDav) setClient() error {
-	c := gowebdav.NewClient(d.Address, d.Username, d.Password)
+	c := d.newClient(d.Address)
 	if d.isSharepoint() {
 		cookie, err := odrvcookie.GetCookie(d.Username, d.Password, d.Address)
 		if err == nil {
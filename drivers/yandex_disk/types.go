@@ -36,19 +36,26 @@ type File struct {
 	//MediaType  string    `json:"media_type"`
 	Preview string `json:"preview"`
 	Path    string `json:"path"`
-	//Sha256     string    `json:"sha256"`
-	Type string `json:"type"`
-	//Md5        string    `json:"md5"`
+	Sha256  string `json:"sha256"`
+	Type    string `json:"type"`
+	Md5     string `json:"md5"`
 	//Revision   int64     `json:"revision"`
 }
 
 func fileToObj(f File) model.Obj {
-	return &model.Object{
+	obj := &model.Object{
 		Name:     f.Name,
 		Size:     f.Size,
 		Modified: f.Modified,
 		IsFolder: f.Type == "dir",
 	}
+	if f.Md5 != "" {
+		obj.Hashes = map[string]string{model.HashMD5: f.Md5}
+		if f.Sha256 != "" {
+			obj.Hashes[model.HashSHA256] = f.Sha256
+		}
+	}
+	return obj
 }
 
 type FilesResp struct {
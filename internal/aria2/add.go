@@ -45,8 +45,9 @@ func AddURI(ctx context.Context, uri string, dstDirPath string) error {
 		return errors.Wrapf(err, "failed to add uri %s", uri)
 	}
 	DownTaskManager.Submit(task.WithCancelCtx(&task.Task[string]{
-		ID:   gid,
-		Name: fmt.Sprintf("download %s to [%s](%s)", uri, storage.GetStorage().MountPath, dstDirActualPath),
+		ID:      gid,
+		Creator: op.UserIDFromCtx(ctx),
+		Name:    fmt.Sprintf("download %s to [%s](%s)", uri, storage.GetStorage().MountPath, dstDirActualPath),
 		Func: func(tsk *task.Task[string]) error {
 			m := &Monitor{
 				tsk:        tsk,
@@ -27,24 +27,29 @@ type Monitor struct {
 	finish     chan struct{}
 }
 
-func (m *Monitor) Loop() error {
+func (m *Monitor) Loop() (err error) {
 	defer func() {
 		notify.Signals.Delete(m.tsk.ID)
-		// clear temp dir, should do while complete
-		//_ = os.RemoveAll(m.tempDir)
+		// the temp dir is cleared after transferring when completed,
+		// clear it here if download failed or canceled
+		if err != nil {
+			if e := os.RemoveAll(m.tempDir); e != nil {
+				log.Errorf("failed to remove aria2 temp dir: %+v", e)
+			}
+		}
 	}()
 	m.c = make(chan int)
 	m.finish = make(chan struct{})
 	notify.Signals.Store(m.tsk.ID, m.c)
-	var (
-		err error
-		ok  bool
-	)
+	var ok bool
 outer:
 	for {
 		select {
 		case <-m.tsk.Ctx.Done():
-			_, err := client.Remove(m.tsk.ID)
+			_, err = client.Remove(m.tsk.ID)
+			if err == nil {
+				err = m.tsk.Ctx.Err()
+			}
 			return err
 		case <-m.c:
 			ok, err = m.Update()
@@ -61,7 +66,6 @@ outer:
 	if err != nil {
 		return err
 	}
-	m.tsk.SetStatus("aria2 download completed, transferring")
 	<-m.finish
 	m.tsk.SetStatus("completed")
 	return nil
@@ -133,14 +137,30 @@ func (m *Monitor) Complete() error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	user, err := op.GetTaskUser(m.tsk.Creator)
+	if err != nil {
+		return errors.WithMessage(err, "failed get the user of the task")
+	}
 	// get files
 	files, err := client.GetFiles(m.tsk.ID)
 	log.Debugf("files len: %d", len(files))
 	if err != nil {
 		return errors.Wrapf(err, "failed to get files of %s", m.tsk.ID)
 	}
+	// files not selected in a torrent are not downloaded
+	selected := files[:0]
+	for _, file := range files {
+		if file.Selected != "false" {
+			selected = append(selected, file)
+		}
+	}
+	files = selected
 	// upload files
-	var wg sync.WaitGroup
+	var (
+		wg          sync.WaitGroup
+		transferred atomic.Int32
+	)
+	m.tsk.SetStatus(fmt.Sprintf("transferring 0/%d files", len(files)))
 	wg.Add(len(files))
 	go func() {
 		wg.Wait()
@@ -153,9 +173,13 @@ func (m *Monitor) Complete() error {
 	for i, _ := range files {
 		file := files[i]
 		TransferTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-			Name: fmt.Sprintf("transfer %s to [%s](%s)", file.Path, storage.GetStorage().MountPath, dstDirActualPath),
+			Name:    fmt.Sprintf("transfer %s to [%s](%s)", file.Path, storage.GetStorage().MountPath, dstDirActualPath),
+			Creator: m.tsk.Creator,
 			Func: func(tsk *task.Task[uint64]) error {
-				defer wg.Done()
+				defer func() {
+					m.tsk.SetStatus(fmt.Sprintf("transferring %d/%d files", transferred.Add(1), len(files)))
+					wg.Done()
+				}()
 				size, _ := strconv.ParseInt(file.Length, 10, 64)
 				mimetype := utils.GetMimeType(file.Path)
 				f, err := os.Open(file.Path)
@@ -177,7 +201,7 @@ func (m *Monitor) Complete() error {
 					log.Errorf("find relation directory error: %v", err)
 				}
 				newDistDir := filepath.Join(dstDirActualPath, relDir)
-				return op.Put(tsk.Ctx, storage, newDistDir, stream, tsk.SetProgress)
+				return op.PutWithQuota(tsk.Ctx, user, storage, newDistDir, stream, tsk.SetProgress)
 			},
 		}))
 	}
@@ -0,0 +1,27 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+// InitAudit cleans the audit logs older than the retention days daily, by
+// the leader of the cluster
+func InitAudit() {
+	clean := func() {
+		if !cluster.IsLeader() {
+			return
+		}
+		days := setting.GetInt(conf.AuditRetentionDays, 90)
+		if days > 0 {
+			op.CleanAuditLogs(time.Duration(days) * 24 * time.Hour)
+		}
+	}
+	go clean()
+	cron.NewCron(24 * time.Hour).Do(clean)
+}
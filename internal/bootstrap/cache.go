@@ -0,0 +1,34 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/listcache"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func InitCache() {
+	var (
+		c   listcache.Cache
+		err error
+	)
+	cacheConf := conf.Conf.Cache
+	switch strings.ToLower(cacheConf.Type) {
+	case "", "memory":
+		return
+	case "redis":
+		c, err = listcache.NewRedis(cacheConf.RedisAddress, cacheConf.RedisPassword, cacheConf.RedisDB, cacheConf.Prefix)
+	case "bbolt", "bolt":
+		c, err = listcache.NewBolt(cacheConf.BoltFile)
+	default:
+		utils.Log.Errorf("not supported cache type: %s, fallback to memory", cacheConf.Type)
+		return
+	}
+	if err != nil {
+		utils.Log.Errorf("failed to init %s cache, fallback to memory: %+v", cacheConf.Type, err)
+		return
+	}
+	op.SetListCache(c)
+}
@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/pkg/cron"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// InitCluster joins the cluster if it's enabled, it should be called before
+// the storages are loaded. The leader takes over the tasks of the gone
+// instances every minute once the storages are loaded.
+func InitCluster() {
+	if !conf.Conf.Cluster.Enable {
+		return
+	}
+	if err := cluster.Init(conf.Conf.Cluster); err != nil {
+		utils.Log.Fatalf("failed to join the cluster: %+v", err)
+	}
+	cron.NewCron(time.Minute).Do(func() {
+		if cluster.IsLeader() && conf.StoragesLoaded {
+			fs.TakeOverTasks()
+		}
+	})
+}
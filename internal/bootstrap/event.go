@@ -0,0 +1,67 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/aria2"
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/progress"
+	"github.com/alist-org/alist/v3/internal/qbittorrent"
+	"github.com/alist-org/alist/v3/internal/uploadrule"
+	"github.com/alist-org/alist/v3/internal/webhook"
+	"github.com/alist-org/alist/v3/pkg/task"
+)
+
+// InitEvents publishes the failures of the tasks, pushes the changes of the
+// tasks to the progress subscribers, starts posting the events to the
+// webhooks and running the actions of the upload rules, it should be called
+// before storages are loaded
+// so that the storages failed to init are reported
+func InitEvents() {
+	fs.UploadTaskManager.OnErrored(taskFailed[uint64]("upload"))
+	fs.CopyTaskManager.OnErrored(taskFailed[uint64]("copy"))
+	fs.BatchTaskManager.OnErrored(taskFailed[uint64]("batch"))
+	fs.SyncTaskManager.OnErrored(taskFailed[uint64]("sync"))
+	aria2.DownTaskManager.OnErrored(taskFailed[string]("aria2_down"))
+	aria2.TransferTaskManager.OnErrored(taskFailed[uint64]("aria2_transfer"))
+	qbittorrent.DownTaskManager.OnErrored(taskFailed[string]("qbittorrent_down"))
+	qbittorrent.TransferTaskManager.OnErrored(taskFailed[uint64]("qbittorrent_transfer"))
+	fs.UploadTaskManager.OnChange(taskChanged[uint64]("upload"))
+	fs.CopyTaskManager.OnChange(taskChanged[uint64]("copy"))
+	fs.BatchTaskManager.OnChange(taskChanged[uint64]("batch"))
+	fs.SyncTaskManager.OnChange(taskChanged[uint64]("sync"))
+	aria2.DownTaskManager.OnChange(taskChanged[string]("aria2_down"))
+	aria2.TransferTaskManager.OnChange(taskChanged[uint64]("aria2_transfer"))
+	qbittorrent.DownTaskManager.OnChange(taskChanged[string]("qbit_down"))
+	qbittorrent.TransferTaskManager.OnChange(taskChanged[uint64]("qbit_transfer"))
+	webhook.Init()
+	uploadrule.Init()
+}
+
+func taskFailed[K comparable](typ string) task.Callback[K] {
+	return func(t *task.Task[K]) {
+		event.Publish(event.TaskFailed, map[string]any{
+			"type":  typ,
+			"id":    fmt.Sprint(t.ID),
+			"name":  t.Name,
+			"error": t.GetErrMsg(),
+		})
+	}
+}
+
+// taskChanged publishes the changes of the tasks by the type of the task api,
+// so that the clients can manage them with it
+func taskChanged[K comparable](typ string) task.Callback[K] {
+	return func(t *task.Task[K]) {
+		progress.Publish(t.Creator, progress.Event{
+			Type:     typ,
+			ID:       fmt.Sprint(t.ID),
+			Name:     t.Name,
+			State:    t.GetState(),
+			Status:   t.GetStatus(),
+			Progress: t.GetProgress(),
+			Error:    t.GetErrMsg(),
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+// InitStorageHealth checks the health of the storages in the interval of
+// the setting, which is read every minute so that it applies without a
+// restart
+func InitStorageHealth() {
+	cron.NewCron(time.Minute).Do(func() {
+		interval := setting.GetInt(conf.StorageHealthInterval, 5)
+		if interval <= 0 {
+			return
+		}
+		op.CheckStoragesHealth(context.Background(), time.Duration(interval)*time.Minute, setting.GetInt(conf.StorageHealthFailures, 3))
+	})
+}
@@ -0,0 +1,15 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/plugin"
+)
+
+// InitPlugins registers the drivers of the plugins, it should be called
+// before the storages are loaded
+func InitPlugins() {
+	if conf.Conf.PluginDir == "" {
+		return
+	}
+	plugin.Load(conf.Conf.PluginDir)
+}
@@ -5,8 +5,10 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/syncjob"
 	"github.com/alist-org/alist/v3/pkg/utils"
 )
 
@@ -26,5 +28,7 @@ func LoadStorages() {
 			}
 		}
 		conf.StoragesLoaded = true
+		fs.RestoreTasks()
+		syncjob.Init()
 	}(storages)
 }
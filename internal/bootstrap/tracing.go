@@ -0,0 +1,19 @@
+package bootstrap
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/tracing"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// InitTracing starts exporting the spans if the tracing is enabled, it
+// should be called before the storages are loaded
+func InitTracing() {
+	if !conf.Conf.Tracing.Enable {
+		return
+	}
+	if err := tracing.Init(conf.Conf.Tracing); err != nil {
+		utils.Log.Fatalf("failed to init tracing: %+v", err)
+	}
+	utils.Log.Infof("export spans to %s", conf.Conf.Tracing.Endpoint)
+}
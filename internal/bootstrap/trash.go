@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/cron"
+)
+
+// InitTrash purges the expired items from the recycle bins of the storages
+// hourly, by the leader of the cluster
+func InitTrash() {
+	purge := func() {
+		if !cluster.IsLeader() {
+			return
+		}
+		op.PurgeTrash(context.Background())
+	}
+	cron.NewCron(time.Hour).Do(purge)
+}
@@ -0,0 +1,8 @@
+package bootstrap
+
+import "github.com/alist-org/alist/v3/internal/tus"
+
+// InitTus loads the tus uploads of the last run, so that they are resumed
+func InitTus() {
+	tus.Init()
+}
@@ -0,0 +1,293 @@
+// Package bundle exports the configuration of the instance as a single json,
+// and imports it on another instance for migrations and disaster recovery.
+package bundle
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Version of the format of the bundle, the bundles of newer versions can't
+// be imported
+const Version = 1
+
+// secretSettings are the settings encrypted in the bundle
+var secretSettings = []string{
+	conf.Token, conf.Aria2Secret, conf.SSOClientSecret, conf.PreviewConverterSecret, conf.MetricsToken,
+}
+
+// skippedSettings are the states of the instance rather than the settings
+var skippedSettings = []string{conf.IndexProgress}
+
+// Bundle is the configuration of the instance, the secrets are encrypted
+// with the password of the bundle: the additions of the storages, the
+// passwords and the 2FA of the users, the passwords of the metas and the
+// secret settings.
+type Bundle struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Salt of the key derived from the password
+	Salt string `json:"salt"`
+	// Check is the encrypted checkText
+	Check    string              `json:"check"`
+	Settings []model.SettingItem `json:"settings"`
+	Users    []User              `json:"users"`
+	Metas    []model.Meta        `json:"metas"`
+	Storages []model.Storage     `json:"storages"`
+}
+
+// User keeps the fields of the user that are hidden from the api
+type User struct {
+	model.User
+	OtpSecret     string `json:"otp_secret"`
+	RecoveryCodes string `json:"recovery_codes"`
+}
+
+// ImportResult is the number of the imported items of each kind, and the
+// errors of the items failed to import
+type ImportResult struct {
+	Settings int      `json:"settings"`
+	Users    int      `json:"users"`
+	Metas    int      `json:"metas"`
+	Storages int      `json:"storages"`
+	Errors   []string `json:"errors"`
+}
+
+// Export exports the configuration, the secrets are encrypted with the
+// password
+func Export(password string) (*Bundle, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	s, err := newSealer(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Version:   Version,
+		CreatedAt: time.Now(),
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+	}
+	if b.Check, err = s.seal(checkText); err != nil {
+		return nil, err
+	}
+	settings, err := db.GetSettingItems()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get settings")
+	}
+	for _, item := range settings {
+		if item.IsDeprecated() || utils.SliceContains(skippedSettings, item.Key) {
+			continue
+		}
+		if utils.SliceContains(secretSettings, item.Key) {
+			if item.Value, err = s.seal(item.Value); err != nil {
+				return nil, err
+			}
+		}
+		b.Settings = append(b.Settings, item)
+	}
+	users, _, err := db.GetUsers(1, -1)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get users")
+	}
+	for _, user := range users {
+		u := User{User: user, RecoveryCodes: user.RecoveryCodes}
+		if u.Password, err = s.seal(user.Password); err != nil {
+			return nil, err
+		}
+		if u.OtpSecret, err = s.seal(user.OtpSecret); err != nil {
+			return nil, err
+		}
+		b.Users = append(b.Users, u)
+	}
+	if b.Metas, _, err = db.GetMetas(1, -1); err != nil {
+		return nil, errors.WithMessage(err, "failed get metas")
+	}
+	for i := range b.Metas {
+		if b.Metas[i].Password, err = s.seal(b.Metas[i].Password); err != nil {
+			return nil, err
+		}
+	}
+	if b.Storages, _, err = db.GetStorages(1, -1); err != nil {
+		return nil, errors.WithMessage(err, "failed get storages")
+	}
+	for i := range b.Storages {
+		b.Storages[i].Status = ""
+		if b.Storages[i].Addition, err = s.seal(b.Storages[i].Addition); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// decrypt decrypts the secrets of the bundle in place, so that nothing is
+// imported if the password is wrong
+func decrypt(b *Bundle, password string) error {
+	if b.Version > Version {
+		return errors.Errorf("the version %d of the bundle is not supported, upgrade alist first", b.Version)
+	}
+	salt, err := base64.StdEncoding.DecodeString(b.Salt)
+	if err != nil {
+		return errors.Wrap(err, "invalid salt")
+	}
+	s, err := newSealer(password, salt)
+	if err != nil {
+		return err
+	}
+	if check, err := s.open(b.Check); err != nil || check != checkText {
+		return errors.New("the password of the bundle is wrong")
+	}
+	for i := range b.Settings {
+		if utils.SliceContains(secretSettings, b.Settings[i].Key) {
+			if b.Settings[i].Value, err = s.open(b.Settings[i].Value); err != nil {
+				return errors.WithMessagef(err, "setting [%s]", b.Settings[i].Key)
+			}
+		}
+	}
+	for i := range b.Users {
+		u := &b.Users[i]
+		if u.Password, err = s.open(u.Password); err != nil {
+			return errors.WithMessagef(err, "user [%s]", u.Username)
+		}
+		if u.User.OtpSecret, err = s.open(u.OtpSecret); err != nil {
+			return errors.WithMessagef(err, "user [%s]", u.Username)
+		}
+		u.User.RecoveryCodes = u.RecoveryCodes
+	}
+	for i := range b.Metas {
+		if b.Metas[i].Password, err = s.open(b.Metas[i].Password); err != nil {
+			return errors.WithMessagef(err, "meta [%s]", b.Metas[i].Path)
+		}
+	}
+	for i := range b.Storages {
+		if b.Storages[i].Addition, err = s.open(b.Storages[i].Addition); err != nil {
+			return errors.WithMessagef(err, "storage [%s]", b.Storages[i].MountPath)
+		}
+	}
+	return nil
+}
+
+// Import imports the bundle exported with the password. The items are
+// matched by the key of the setting, the username, the path of the meta and
+// the mount path of the storage, the matched ones are updated and the others
+// are created. The admin and the guest are matched by the role.
+func Import(ctx context.Context, b *Bundle, password string) (*ImportResult, error) {
+	if err := decrypt(b, password); err != nil {
+		return nil, err
+	}
+	res := &ImportResult{}
+	fail := func(err error, format string, args ...any) {
+		res.Errors = append(res.Errors, errors.WithMessagef(err, format, args...).Error())
+	}
+	importSettings(b.Settings, res, fail)
+	for _, u := range b.Users {
+		if err := importUser(u.User); err != nil {
+			fail(err, "failed import user [%s]", u.Username)
+		} else {
+			res.Users++
+		}
+	}
+	for _, meta := range b.Metas {
+		if err := importMeta(meta); err != nil {
+			fail(err, "failed import meta [%s]", meta.Path)
+		} else {
+			res.Metas++
+		}
+	}
+	for _, storage := range b.Storages {
+		if err := importStorage(ctx, storage); err != nil {
+			fail(err, "failed import storage [%s]", storage.MountPath)
+		}
+		// the storage is saved even if it fails to init
+		if _, err := db.GetStorageByMountPath(utils.FixAndCleanPath(storage.MountPath)); err == nil {
+			res.Storages++
+		}
+	}
+	return res, nil
+}
+
+// importSettings imports the values of the settings known by this version,
+// the other fields are kept from this version
+func importSettings(settings []model.SettingItem, res *ImportResult, fail func(error, string, ...any)) {
+	current, err := db.GetSettingItems()
+	if err != nil {
+		fail(err, "failed get settings")
+		return
+	}
+	values := make(map[string]string, len(settings))
+	for _, item := range settings {
+		if !utils.SliceContains(skippedSettings, item.Key) {
+			values[item.Key] = item.Value
+		}
+	}
+	var items []model.SettingItem
+	for _, item := range current {
+		if v, ok := values[item.Key]; ok && !item.IsDeprecated() {
+			item.Value = v
+			items = append(items, item)
+		}
+	}
+	if err := op.SaveSettingItems(items); err != nil {
+		fail(err, "failed save settings")
+		return
+	}
+	res.Settings = len(items)
+}
+
+func importUser(u model.User) error {
+	var old *model.User
+	var err error
+	if u.IsAdmin() || u.IsGuest() {
+		old, err = db.GetUserByRole(u.Role)
+	} else {
+		old, err = db.GetUserByName(u.Username)
+	}
+	if err != nil {
+		u.ID = 0
+		return op.CreateUser(&u)
+	}
+	if old.Role != u.Role {
+		return fmt.Errorf("the user exists with another role")
+	}
+	u.ID = old.ID
+	return op.UpdateUser(&u)
+}
+
+func importMeta(meta model.Meta) error {
+	old, err := db.GetMetaByPath(utils.FixAndCleanPath(meta.Path))
+	if err != nil {
+		meta.ID = 0
+		return op.CreateMeta(&meta)
+	}
+	meta.ID = old.ID
+	return op.UpdateMeta(&meta)
+}
+
+func importStorage(ctx context.Context, storage model.Storage) error {
+	if _, err := op.GetDriverNew(storage.Driver); err != nil {
+		return err
+	}
+	old, err := db.GetStorageByMountPath(utils.FixAndCleanPath(storage.MountPath))
+	if err == nil {
+		storage.ID = old.ID
+		return op.UpdateStorage(ctx, storage)
+	}
+	storage.ID = 0
+	if storage.Disabled {
+		storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+		return db.CreateStorage(&storage)
+	}
+	_, err = op.CreateStorage(ctx, storage)
+	return err
+}
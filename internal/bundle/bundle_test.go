@@ -0,0 +1,102 @@
+package bundle_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+func TestExportImport(t *testing.T) {
+	if err := db.SaveSettingItems([]model.SettingItem{
+		{Key: conf.Token, Value: "secret-token", Group: model.SINGLE, Flag: model.PRIVATE},
+		{Key: conf.SiteTitle, Value: "my alist", Group: model.SITE},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.CreateUser(&model.User{Username: "bob", Password: "bob-password", OtpSecret: "bob-otp", Permission: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.CreateMeta(&model.Meta{Path: "/private", Password: "meta-password"}); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	addition := fmt.Sprintf(`{"root_folder_path":%q}`, root)
+	if _, err := op.CreateStorage(context.Background(), model.Storage{Driver: "Local", MountPath: "/local", Addition: addition}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := bundle.Export("passphrase")
+	if err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	data, _ := utils.Json.MarshalToString(b)
+	for _, secret := range []string{"secret-token", "bob-password", "bob-otp", "meta-password", "root_folder_path"} {
+		if strings.Contains(data, secret) {
+			t.Errorf("the secret %s is not encrypted", secret)
+		}
+	}
+
+	// lose everything
+	user, _ := op.GetUserByName("bob")
+	if err := op.DeleteUserById(user.ID); err != nil {
+		t.Fatal(err)
+	}
+	meta, _ := db.GetMetaByPath("/private")
+	if err := op.DeleteMetaById(meta.ID); err != nil {
+		t.Fatal(err)
+	}
+	storage, _ := db.GetStorageByMountPath("/local")
+	if err := op.DeleteStorageById(context.Background(), storage.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveSettingItem(&model.SettingItem{Key: conf.Token, Value: "other", Group: model.SINGLE, Flag: model.PRIVATE}); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded bundle.Bundle
+	if err := utils.Json.UnmarshalFromString(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bundle.Import(context.Background(), &decoded, "wrong"); err == nil {
+		t.Fatalf("expect the wrong password rejected")
+	}
+	res, err := bundle.Import(context.Background(), &decoded, "passphrase")
+	if err != nil {
+		t.Fatalf("failed import: %+v", err)
+	}
+	if len(res.Errors) > 0 || res.Users != 1 || res.Metas != 1 || res.Storages != 1 || res.Settings != 2 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if user, err := db.GetUserByName("bob"); err != nil || user.Password != "bob-password" || user.OtpSecret != "bob-otp" {
+		t.Errorf("the user is not restored: %+v %+v", user, err)
+	}
+	if meta, err := db.GetMetaByPath("/private"); err != nil || meta.Password != "meta-password" {
+		t.Errorf("the meta is not restored: %+v %+v", meta, err)
+	}
+	if s, err := op.GetStorageByMountPath("/local"); err != nil || !strings.Contains(s.GetStorage().Addition, root) {
+		t.Errorf("the storage is not restored: %+v", err)
+	}
+	if item, err := db.GetSettingItemByKey(conf.Token); err != nil || item.Value != "secret-token" {
+		t.Errorf("the token is not restored: %+v %+v", item, err)
+	}
+}
@@ -0,0 +1,71 @@
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// checkText is encrypted in the bundle to find a wrong password before
+// importing anything
+const checkText = "alist"
+
+// sealer encrypts the secrets of the bundle with aes-gcm, the key is derived
+// from the password with scrypt
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(password string, salt []byte) (*sealer, error) {
+	if password == "" {
+		return nil, errors.New("the password of the bundle is required")
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+func (s *sealer) seal(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+func (s *sealer) open(sealed string) (string, error) {
+	if sealed == "" {
+		return "", nil
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid encrypted value")
+	}
+	size := s.aead.NonceSize()
+	if len(data) < size {
+		return "", errors.New("invalid encrypted value")
+	}
+	plain, err := s.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return "", errors.New("failed decrypt, the password may be wrong")
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,149 @@
+// Package cluster lets several instances run behind a load balancer with
+// the shared database and redis. The states that must be seen by all the
+// instances are kept in the redis (Store and the slots), the caches kept in
+// the memory are cleared on the other instances after any change (Publish),
+// and the scheduled jobs are run by the leader only (IsLeader).
+//
+// Without Init the package works for a single instance, so the callers
+// don't have to check whether the cluster is enabled.
+package cluster
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	redisTimeout = 3 * time.Second
+	// lease is how long the node and the leader are kept without renewing,
+	// the node is seen as gone after it
+	lease = 15 * time.Second
+	// renew is the interval to renew the lease
+	renew = 5 * time.Second
+)
+
+var (
+	client *redis.Client
+	prefix string
+	node   string
+	leader atomic.Bool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+)
+
+// Init connects to the redis and joins the cluster as the node
+func Init(c conf.Cluster) error {
+	node = c.Node
+	if node == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return errors.Wrap(err, "failed get hostname as the node name")
+		}
+		node = hostname
+	}
+	cli := redis.NewClient(&redis.Options{Addr: c.RedisAddress, Password: c.RedisPassword, DB: c.RedisDB})
+	ctx, cancelPing := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancelPing()
+	if err := cli.Ping(ctx).Err(); err != nil {
+		_ = cli.Close()
+		return errors.Wrap(err, "failed connect to redis")
+	}
+	client, prefix = cli, c.Prefix
+	resetSlots(ctx)
+	var runCtx context.Context
+	runCtx, cancel = context.WithCancel(context.Background())
+	heartbeat(runCtx)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(renew)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				heartbeat(runCtx)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		subscribe(runCtx)
+	}()
+	log.Infof("joined the cluster as node %s", node)
+	return nil
+}
+
+// Enabled reports whether the instance is in a cluster
+func Enabled() bool {
+	return client != nil
+}
+
+// Node returns the name of the instance, it's empty without the cluster
+func Node() string {
+	return node
+}
+
+// Close leaves the cluster, the leadership is released so that another
+// node takes over the scheduled jobs at once
+func Close() error {
+	if !Enabled() {
+		return nil
+	}
+	cancel()
+	wg.Wait()
+	ctx, cancelDel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancelDel()
+	if leader.Load() {
+		releaseScript.Run(ctx, client, []string{leaderKey()}, node)
+		leader.Store(false)
+	}
+	client.Del(ctx, nodeKey(node))
+	err := client.Close()
+	client = nil
+	return err
+}
+
+func nodeKey(n string) string {
+	return prefix + "node:" + n
+}
+
+// Alive reports whether the node is still in the cluster
+func Alive(n string) bool {
+	if !Enabled() {
+		return n == node
+	}
+	if n == node {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	count, err := client.Exists(ctx, nodeKey(n)).Result()
+	if err != nil {
+		// the node is taken as alive, so that its work isn't taken over
+		// by mistake
+		log.Warnf("failed check node %s: %+v", n, err)
+		return true
+	}
+	return count > 0
+}
+
+// heartbeat renews the lease of the node and the leader, or tries to be
+// the leader if there is none
+func heartbeat(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := client.Set(ctx, nodeKey(node), time.Now().Unix(), lease).Err(); err != nil {
+		log.Warnf("failed renew node %s: %+v", node, err)
+	}
+	elect(ctx)
+}
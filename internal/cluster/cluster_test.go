@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestCluster(t *testing.T) {
+	s := miniredis.RunT(t)
+	// another node is the leader already
+	s.Set("test:leader", "other")
+	s.Set("test:node:other", "1")
+	if err := Init(conf.Cluster{Node: "a", RedisAddress: s.Addr(), Prefix: "test:"}); err != nil {
+		t.Fatalf("failed init: %+v", err)
+	}
+	defer Close()
+	if IsLeader() {
+		t.Errorf("expect the node isn't the leader while the other one is")
+	}
+	if !Alive("other") || Alive("gone") {
+		t.Errorf("expect the alive nodes are told by the leases")
+	}
+	s.Del("test:leader")
+	elect(context.Background())
+	if !IsLeader() {
+		t.Errorf("expect the node takes over the leadership")
+	}
+
+	store := NewStore[int]("count")
+	store.Set("ip", 3, 0)
+	if v, ok := store.Get("ip"); !ok || v != 3 {
+		t.Errorf("expect the value is stored in redis, got %d, %v", v, ok)
+	}
+	store.Expire("ip", time.Minute)
+	if ttl := s.TTL("test:store:count:ip"); ttl != time.Minute {
+		t.Errorf("expect the value expires, got ttl %s", ttl)
+	}
+	store.Del("ip")
+	if _, ok := store.Get("ip"); ok {
+		t.Errorf("expect the value is deleted")
+	}
+
+	// a slot taken by the gone node is freed
+	s.HSet("test:slot:user:1", "gone", "1")
+	s.HSet("test:slot:user:1", "other", "1")
+	if ok, err := AcquireSlot("user:1", 2); err != nil || !ok {
+		t.Fatalf("expect the slot of the gone node is taken, got %v: %+v", ok, err)
+	}
+	if ok, _ := AcquireSlot("user:1", 2); ok {
+		t.Errorf("expect no slot is left")
+	}
+	ReleaseSlot("user:1")
+	if ok, _ := AcquireSlot("user:1", 2); !ok {
+		t.Errorf("expect the released slot is taken again")
+	}
+
+	got := make(chan string, 2)
+	Subscribe("ping", func(data string) { got <- data })
+	// the subscription may not be ready yet
+	deadline := time.After(3 * time.Second)
+	for _, n := range []string{"a", "other"} {
+		msg, _ := utils.Json.MarshalToString(message{Node: n, Topic: "ping", Data: n})
+		for s.Publish("test:events", msg) == 0 {
+			select {
+			case <-deadline:
+				t.Fatalf("no subscriber")
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+	select {
+	case data := <-got:
+		if data != "other" {
+			t.Errorf("expect the messages of the node itself are skipped, got %s", data)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expect the message of the other node is handled")
+	}
+
+	if err := Close(); err != nil {
+		t.Fatalf("failed close: %+v", err)
+	}
+	if s.Exists("test:leader") || s.Exists("test:node:a") {
+		t.Errorf("expect the leadership and the lease are released on close")
+	}
+}
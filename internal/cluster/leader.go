@@ -0,0 +1,57 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// renewScript renews the lease of the leader if the node is still the leader
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript gives up the leadership if the node is the leader
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+func leaderKey() string {
+	return prefix + "leader"
+}
+
+// IsLeader reports whether the instance runs the scheduled jobs, a single
+// instance is always the leader
+func IsLeader() bool {
+	return !Enabled() || leader.Load()
+}
+
+// elect renews the leadership or takes it over if the leader is gone, the
+// leadership of the node before a restart is renewed too
+func elect(ctx context.Context) {
+	was := leader.Load()
+	n, err := renewScript.Run(ctx, client, []string{leaderKey()}, node, lease.Milliseconds()).Int()
+	is := err == nil && n == 1
+	if err == nil && !is {
+		is, err = client.SetNX(ctx, leaderKey(), node, lease).Result()
+	}
+	if err != nil {
+		// the lease may expire before the redis is back, it's safer to
+		// stop the jobs than to run them on two nodes
+		log.Warnf("failed elect leader: %+v", err)
+		is = false
+	}
+	leader.Store(is)
+	if is != was {
+		if is {
+			log.Infof("node %s is the leader of the cluster now", node)
+		} else {
+			log.Infof("node %s is not the leader of the cluster anymore", node)
+		}
+	}
+}
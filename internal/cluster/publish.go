@@ -0,0 +1,95 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type message struct {
+	Node  string `json:"node"`
+	Topic string `json:"topic"`
+	Data  string `json:"data"`
+}
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string][]func(data string))
+)
+
+func channel() string {
+	return prefix + "events"
+}
+
+// Subscribe calls f with the data published to the topic by the other
+// nodes, it can be called before Init
+func Subscribe(topic string, f func(data string)) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[topic] = append(handlers[topic], f)
+}
+
+// Publish tells the other nodes about a change here, nothing is done
+// without the cluster. The messages are lost if a node is disconnected
+// from the redis, so the caches cleared by them must expire as well.
+func Publish(topic, data string) {
+	if !Enabled() {
+		return
+	}
+	payload, err := utils.Json.MarshalToString(message{Node: node, Topic: topic, Data: data})
+	if err != nil {
+		log.Errorf("failed encode message of %s: %+v", topic, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Publish(ctx, channel(), payload).Err(); err != nil {
+		log.Warnf("failed publish %s to the cluster: %+v", topic, err)
+	}
+}
+
+// subscribe receives the messages until ctx is done, the handlers of a
+// topic are called in order
+func subscribe(ctx context.Context) {
+	sub := client.Subscribe(ctx, channel())
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			var m message
+			if err := utils.Json.UnmarshalFromString(msg.Payload, &m); err != nil {
+				log.Warnf("failed decode message from the cluster: %+v", err)
+				continue
+			}
+			if m.Node == node {
+				continue
+			}
+			handlersMu.RLock()
+			hs := handlers[m.Topic]
+			handlersMu.RUnlock()
+			for _, h := range hs {
+				handle(m, h)
+			}
+		}
+	}
+}
+
+func handle(m message, h func(string)) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("panic handling %s from node %s: %v", m.Topic, m.Node, err)
+		}
+	}()
+	start := time.Now()
+	h(m.Data)
+	log.Debugf("handled %s from node %s in %s", m.Topic, m.Node, time.Since(start))
+}
@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// The slots of a key are counted by the nodes in a hash, so that the slots
+// taken by a gone node are freed with its lease.
+//
+// acquireScript takes a slot if the slots taken by the alive nodes are less
+// than the max, the counts of the gone nodes are dropped on the way. The
+// keys of the leases of the other nodes are passed after the hash, with
+// their names from ARGV[3], as the keys of a script must all be in KEYS to
+// be routed in a redis cluster. The nodes counted after the keys are read
+// are taken as alive.
+var acquireScript = redis.NewScript(`
+local gone = {}
+for i = 2, #KEYS do
+	if redis.call('EXISTS', KEYS[i]) == 0 then
+		gone[ARGV[i + 1]] = true
+	end
+end
+local counts = redis.call('HGETALL', KEYS[1])
+local total = 0
+for i = 1, #counts, 2 do
+	if counts[i] == ARGV[1] or not gone[counts[i]] then
+		total = total + tonumber(counts[i + 1])
+	else
+		redis.call('HDEL', KEYS[1], counts[i])
+	end
+end
+if total >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+return 1`)
+
+// releaseSlotScript frees a slot of the node
+var releaseSlotScript = redis.NewScript(`
+local n = redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+if n <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return n`)
+
+func slotKey(key string) string {
+	return prefix + "slot:" + key
+}
+
+// AcquireSlot takes one of the max slots of key across the cluster, it
+// reports false if all of them are taken. The slots can't be counted
+// without the cluster, the caller counts them in the memory instead.
+//
+// In a redis cluster the prefix should have a hash tag, such as
+// "{alist}:", so that the keys of the script are in the same hash slot.
+func AcquireSlot(key string, max int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	nodes, err := client.HKeys(ctx, slotKey(key)).Result()
+	if err != nil {
+		return false, err
+	}
+	keys := []string{slotKey(key)}
+	args := []any{node, max}
+	for _, n := range nodes {
+		if n != node {
+			keys = append(keys, nodeKey(n))
+			args = append(args, n)
+		}
+	}
+	n, err := acquireScript.Run(ctx, client, keys, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseSlot frees the slot of key taken by AcquireSlot
+func ReleaseSlot(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := releaseSlotScript.Run(ctx, client, []string{slotKey(key)}, node).Err(); err != nil {
+		log.Warnf("failed release slot of %s: %+v", key, err)
+	}
+}
+
+// resetSlots frees the slots left by the node before a restart
+func resetSlots(ctx context.Context) {
+	iter := client.Scan(ctx, 0, utils.EscapeGlob(prefix+"slot:")+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		client.HDel(ctx, iter.Val(), node)
+	}
+	if err := iter.Err(); err != nil {
+		log.Warnf("failed reset slots of node %s: %+v", node, err)
+	}
+}
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// Store keeps the short-lived states such as the login attempts and the
+// sessions of the logins, which may be done through any node. The values
+// are json encoded in the redis with the cluster, or kept in the memory.
+type Store[T any] struct {
+	name string
+	mem  cache.ICache[T]
+}
+
+// NewStore returns the store of name, the names of the stores must be
+// unique
+func NewStore[T any](name string) *Store[T] {
+	return &Store[T]{name: name, mem: cache.NewMemCache[T]()}
+}
+
+func (s *Store[T]) key(key string) string {
+	return prefix + "store:" + s.name + ":" + key
+}
+
+// Get returns the value of key
+func (s *Store[T]) Get(key string) (T, bool) {
+	var v T
+	if !Enabled() {
+		return s.mem.Get(key)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := client.Get(ctx, s.key(key)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Warnf("failed get %s of %s from redis: %+v", key, s.name, err)
+		}
+		return v, false
+	}
+	if err := utils.Json.Unmarshal(data, &v); err != nil {
+		log.Warnf("failed decode %s of %s: %+v", key, s.name, err)
+		return v, false
+	}
+	return v, true
+}
+
+// Set sets the value of key, it never expires if ttl is 0
+func (s *Store[T]) Set(key string, v T, ttl time.Duration) {
+	if !Enabled() {
+		if ttl > 0 {
+			s.mem.Set(key, v, cache.WithEx[T](ttl))
+		} else {
+			s.mem.Set(key, v)
+		}
+		return
+	}
+	data, err := utils.Json.Marshal(v)
+	if err != nil {
+		log.Errorf("failed encode %s of %s: %+v", key, s.name, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Set(ctx, s.key(key), data, ttl).Err(); err != nil {
+		log.Warnf("failed set %s of %s to redis: %+v", key, s.name, err)
+	}
+}
+
+// Expire makes key expire after d
+func (s *Store[T]) Expire(key string, d time.Duration) {
+	if !Enabled() {
+		s.mem.Expire(key, d)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Expire(ctx, s.key(key), d).Err(); err != nil {
+		log.Warnf("failed expire %s of %s in redis: %+v", key, s.name, err)
+	}
+}
+
+// Del deletes key
+func (s *Store[T]) Del(key string) {
+	if !Enabled() {
+		s.mem.Del(key)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Del(ctx, s.key(key)).Err(); err != nil {
+		log.Warnf("failed delete %s of %s from redis: %+v", key, s.name, err)
+	}
+}
@@ -34,6 +34,72 @@ type LogConfig struct {
 	Compress   bool   `json:"compress" env:"COMPRESS"`
 }
 
+type S3 struct {
+	Enable bool `json:"enable" env:"S3_ENABLE"`
+	Port   int  `json:"port" env:"S3_PORT"`
+	SSL    bool `json:"ssl" env:"S3_SSL"`
+}
+
+type FTP struct {
+	Enable bool `json:"enable" env:"FTP_ENABLE"`
+	Port   int  `json:"port" env:"FTP_PORT"`
+	// PassivePortRange is like "30000-30100", random ports are used if empty
+	PassivePortRange string `json:"passive_port_range" env:"FTP_PASSIVE_PORT_RANGE"`
+	// PublicHost is the ip told to clients in passive mode, the local ip of
+	// the control connection is used if empty
+	PublicHost string `json:"public_host" env:"FTP_PUBLIC_HOST"`
+	// TLS allows clients to upgrade with AUTH TLS, ImplicitTLS accepts
+	// tls connections only, both use the cert of scheme
+	TLS         bool `json:"tls" env:"FTP_TLS"`
+	ImplicitTLS bool `json:"implicit_tls" env:"FTP_IMPLICIT_TLS"`
+}
+
+type SFTP struct {
+	Enable bool `json:"enable" env:"SFTP_ENABLE"`
+	Port   int  `json:"port" env:"SFTP_PORT"`
+}
+
+// Cache is the backend of the listing cache, one of memory, redis and bbolt.
+// The listings in redis are shared between instances, the ones in bbolt
+// survive restarts
+type Cache struct {
+	Type          string `json:"type" env:"CACHE_TYPE"`
+	RedisAddress  string `json:"redis_address" env:"CACHE_REDIS_ADDRESS"`
+	RedisPassword string `json:"redis_password" env:"CACHE_REDIS_PASSWORD"`
+	RedisDB       int    `json:"redis_db" env:"CACHE_REDIS_DB"`
+	// Prefix is prepended to the keys in redis
+	Prefix   string `json:"prefix" env:"CACHE_PREFIX"`
+	BoltFile string `json:"bolt_file" env:"CACHE_BOLT_FILE"`
+}
+
+// Cluster runs several instances behind a load balancer, the instances must
+// share the database and the jwt secret. The login states, the transfer
+// slots and the changes of the caches are shared through the redis, and the
+// scheduled jobs are run by the leader only
+type Cluster struct {
+	Enable bool `json:"enable" env:"CLUSTER_ENABLE"`
+	// Node is the unique name of the instance, the hostname by default
+	Node          string `json:"node" env:"CLUSTER_NODE"`
+	RedisAddress  string `json:"redis_address" env:"CLUSTER_REDIS_ADDRESS"`
+	RedisPassword string `json:"redis_password" env:"CLUSTER_REDIS_PASSWORD"`
+	RedisDB       int    `json:"redis_db" env:"CLUSTER_REDIS_DB"`
+	// Prefix is prepended to the keys and the channel in redis, it should
+	// have a hash tag such as "{alist}:" in a redis cluster
+	Prefix string `json:"prefix" env:"CLUSTER_PREFIX"`
+}
+
+// Tracing exports the spans of the requests, the fs operations, the calls to
+// the drivers and the proxied streams to an otlp collector over http
+type Tracing struct {
+	Enable bool `json:"enable" env:"TRACING_ENABLE"`
+	// Endpoint is the host and the port of the collector
+	Endpoint string `json:"endpoint" env:"TRACING_ENDPOINT"`
+	Insecure bool   `json:"insecure" env:"TRACING_INSECURE"`
+	// SampleRatio is the ratio of the traces sampled, from 0 to 1
+	SampleRatio float64 `json:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	ServiceName string  `json:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
 type Config struct {
 	Force                 bool      `json:"force" env:"FORCE"`
 	Address               string    `json:"address" env:"ADDR"`
@@ -49,6 +115,17 @@ type Config struct {
 	Log                   LogConfig `json:"log"`
 	MaxConnections        int       `json:"max_connections" env:"MAX_CONNECTIONS"`
 	TlsInsecureSkipVerify bool      `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	S3                    S3        `json:"s3"`
+	FTP                   FTP       `json:"ftp"`
+	SFTP                  SFTP      `json:"sftp"`
+	Cache                 Cache     `json:"cache"`
+	Cluster               Cluster   `json:"cluster"`
+	Tracing               Tracing   `json:"tracing"`
+	// AdminSocket is the unix socket serving the api to the admin cli on
+	// the same host, it's disabled if empty
+	AdminSocket string `json:"admin_socket" env:"ADMIN_SOCKET"`
+	// PluginDir holds the executables of the driver plugins
+	PluginDir string `json:"plugin_dir" env:"PLUGIN_DIR"`
 }
 
 func DefaultConfig() *Config {
@@ -56,6 +133,9 @@ func DefaultConfig() *Config {
 	indexDir := filepath.Join(flags.DataDir, "bleve")
 	logPath := filepath.Join(flags.DataDir, "log/log.log")
 	dbPath := filepath.Join(flags.DataDir, "data.db")
+	cachePath := filepath.Join(flags.DataDir, "cache.db")
+	socketPath := filepath.Join(flags.DataDir, "admin.sock")
+	pluginDir := filepath.Join(flags.DataDir, "plugins")
 	return &Config{
 		Address:        "0.0.0.0",
 		Port:           5244,
@@ -78,5 +158,35 @@ func DefaultConfig() *Config {
 		},
 		MaxConnections:        0,
 		TlsInsecureSkipVerify: true,
+		S3: S3{
+			Enable: false,
+			Port:   5246,
+		},
+		FTP: FTP{
+			Enable: false,
+			Port:   5221,
+		},
+		SFTP: SFTP{
+			Enable: false,
+			Port:   5222,
+		},
+		Cache: Cache{
+			Type:         "memory",
+			RedisAddress: "localhost:6379",
+			Prefix:       "alist:",
+			BoltFile:     cachePath,
+		},
+		Cluster: Cluster{
+			RedisAddress: "localhost:6379",
+			Prefix:       "alist:cluster:",
+		},
+		Tracing: Tracing{
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			SampleRatio: 1,
+			ServiceName: "alist",
+		},
+		AdminSocket: socketPath,
+		PluginDir:   pluginDir,
 	}
 }
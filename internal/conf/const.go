@@ -29,16 +29,48 @@ const (
 	AudioAutoplay      = "audio_autoplay"
 	VideoAutoplay      = "video_autoplay"
 
+	// thumbnail
+	ThumbnailGenerate      = "thumbnail_generate"
+	ThumbnailCachePath     = "thumbnail_cache_path"
+	ThumbnailMaxSourceSize = "thumbnail_max_source_size"
+
+	// media info
+	MediaInfoExtract = "media_info_extract"
+
+	// hls transcoding
+	HlsTranscode      = "hls_transcode"
+	HlsTranscodeTypes = "hls_transcode_types"
+	HlsMaxTranscodes  = "hls_max_transcodes"
+	HlsCacheSize      = "hls_cache_size"
+
+	// document preview
+	PreviewMaxSourceSize   = "preview_max_source_size"
+	PreviewCacheSize       = "preview_cache_size"
+	PreviewOfficeTypes     = "preview_office_types"
+	PreviewOfficeConverter = "preview_office_converter"
+	PreviewConverterUrl    = "preview_converter_url"
+	PreviewConverterSecret = "preview_converter_secret"
+
 	// global
 	HideFiles               = "hide_files"
 	CustomizeHead           = "customize_head"
 	CustomizeBody           = "customize_body"
 	LinkExpiration          = "link_expiration"
 	SignAll                 = "sign_all"
+	TempLinkMaxExpiration   = "temp_link_max_expiration"
 	PrivacyRegs             = "privacy_regs"
 	OcrApi                  = "ocr_api"
 	FilenameCharMapping     = "filename_char_mapping"
 	ForwardDirectLinkParams = "forward_direct_link_params"
+	TaskMaxRetry            = "task_max_retry"
+	CopyVerify              = "copy_verify"
+	ProxyConcurrency        = "proxy_concurrency"
+	ProxyPartSize           = "proxy_part_size"
+	StorageHealthInterval   = "storage_health_interval"
+	StorageHealthFailures   = "storage_health_failures"
+	MetricsEnabled          = "metrics_enabled"
+	MetricsToken            = "metrics_token"
+	SlowOperationThreshold  = "slow_operation_threshold"
 
 	// index
 	SearchIndex     = "search_index"
@@ -59,10 +91,30 @@ const (
 	SSOClientSecret  = "sso_client_secret"
 	SSOLoginEnabled  = "sso_login_enabled"
 	SSOLoginplatform = "sso_login_platform"
+	// OIDC and generic OAuth2
+	SSOIssuer            = "sso_issuer"
+	SSOAuthURL           = "sso_auth_url"
+	SSOTokenURL          = "sso_token_url"
+	SSOUserInfoURL       = "sso_userinfo_url"
+	SSOScopes            = "sso_scopes"
+	SSOIDClaim           = "sso_id_claim"
+	SSOUsernameClaim     = "sso_username_claim"
+	SSOGroupsClaim       = "sso_groups_claim"
+	SSOAutoRegister      = "sso_auto_register"
+	SSODefaultDir        = "sso_default_dir"
+	SSODefaultPermission = "sso_default_permission"
+
+	// audit
+	AuditEnabled       = "audit_enabled"
+	AuditDownloads     = "audit_downloads"
+	AuditRetentionDays = "audit_retention_days"
 
 	// qbittorrent
 	QbittorrentUrl      = "qbittorrent_url"
 	QbittorrentSeedtime = "qbittorrent_seedtime"
+
+	// s3
+	S3Buckets = "s3_buckets"
 )
 
 const (
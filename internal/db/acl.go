@@ -0,0 +1,53 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetACLRuleById(id uint) (*model.ACLRule, error) {
+	var r model.ACLRule
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl rule")
+	}
+	return &r, nil
+}
+
+func CreateACLRule(r *model.ACLRule) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateACLRule(r *model.ACLRule) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func GetACLRules(pageIndex, pageSize int) (rules []model.ACLRule, count int64, err error) {
+	ruleDB := db.Model(&model.ACLRule{})
+	if err = ruleDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get acl rules count")
+	}
+	if err = ruleDB.Order("path").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&rules).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find acl rules")
+	}
+	return rules, count, nil
+}
+
+func GetAllACLRules() ([]model.ACLRule, error) {
+	var rules []model.ACLRule
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl rules")
+	}
+	return rules, nil
+}
+
+func DeleteACLRuleById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ACLRule{}, id).Error)
+}
+
+func DeleteACLRulesByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.ACLRule{UserID: userID}).Delete(&model.ACLRule{}).Error)
+}
+
+func DeleteACLRulesByGroupID(groupID uint) error {
+	return errors.WithStack(db.Where(model.ACLRule{GroupID: groupID}).Delete(&model.ACLRule{}).Error)
+}
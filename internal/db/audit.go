@@ -0,0 +1,59 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLogs(logs []model.AuditLog) error {
+	return errors.WithStack(db.CreateInBatches(logs, 100).Error)
+}
+
+func filterAuditLogs(f model.AuditFilter) *gorm.DB {
+	q := db.Model(&model.AuditLog{})
+	if f.Username != "" {
+		q = q.Where("username = ?", f.Username)
+	}
+	if f.Action != "" {
+		q = q.Where("action = ?", f.Action)
+	}
+	if f.Target != "" {
+		q = q.Where("target LIKE ? ESCAPE '!'", escapeLike(f.Target)+"%")
+	}
+	if !f.Since.IsZero() {
+		q = q.Where(columnName("time")+" >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		q = q.Where(columnName("time")+" < ?", f.Until)
+	}
+	return q
+}
+
+// GetAuditLogs returns the filtered audit logs, the latest first
+func GetAuditLogs(f model.AuditFilter, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	if err := filterAuditLogs(f).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err := filterAuditLogs(f).Order("id DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// IterAuditLogs calls fn with the filtered audit logs in batches, the
+// oldest first, so that exporting a lot of logs doesn't load all of them
+func IterAuditLogs(f model.AuditFilter, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	err := filterAuditLogs(f).Order("id").FindInBatches(&logs, 500, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	}).Error
+	return errors.WithStack(err)
+}
+
+func DeleteAuditLogsBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.AuditLog{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.S3Key), new(model.SSHKey), new(model.Group), new(model.UserGroup), new(model.ACLRule), new(model.Share), new(model.Webhook), new(model.AppPassword), new(model.WebAuthnCredential), new(model.AuditLog), new(model.QuotaUsage), new(model.SyncJob), new(model.MediaInfo), new(model.Symlink), new(model.UploadRule))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func GetGroupByName(name string) (*model.Group, error) {
+	g := model.Group{Name: name}
+	if err := db.Where(g).First(&g).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	return &g, nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Create(g).Error)
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Save(g).Error)
+}
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err = groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err = groupDB.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	return groups, count, nil
+}
+
+func DeleteGroupById(id uint) error {
+	if err := db.Where(model.UserGroup{GroupID: id}).Delete(&model.UserGroup{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.Group{}, id).Error)
+}
+
+func AddUserToGroup(userID, groupID uint) error {
+	return errors.WithStack(db.FirstOrCreate(&model.UserGroup{UserID: userID, GroupID: groupID}).Error)
+}
+
+func RemoveUserFromGroup(userID, groupID uint) error {
+	return errors.WithStack(db.Delete(&model.UserGroup{UserID: userID, GroupID: groupID}).Error)
+}
+
+func GetUserGroups() ([]model.UserGroup, error) {
+	var ugs []model.UserGroup
+	if err := db.Find(&ugs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user groups")
+	}
+	return ugs, nil
+}
+
+func GetUsersByGroupID(groupID uint) ([]model.User, error) {
+	var users []model.User
+	err := db.Where("id IN (?)", db.Model(&model.UserGroup{}).Select("user_id").Where(model.UserGroup{GroupID: groupID})).
+		Find(&users).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get users of group")
+	}
+	return users, nil
+}
+
+func GetGroupIDsByUserID(userID uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&model.UserGroup{}).Where(model.UserGroup{UserID: userID}).Pluck("group_id", &ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get groups of user")
+	}
+	return ids, nil
+}
+
+func DeleteUserGroupsByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.UserGroup{UserID: userID}).Delete(&model.UserGroup{}).Error)
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+// the max hashes in a query, sqlite limits the variables of a statement
+const mediaInfoBatch = 500
+
+// GetMediaInfos gets the cached infos of the hashes, the missing ones are
+// skipped
+func GetMediaInfos(hashes []string) ([]model.MediaInfo, error) {
+	var infos []model.MediaInfo
+	for start := 0; start < len(hashes); start += mediaInfoBatch {
+		end := start + mediaInfoBatch
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		var batch []model.MediaInfo
+		if err := db.Where("hash IN ?", hashes[start:end]).Find(&batch).Error; err != nil {
+			return nil, errors.Wrapf(err, "failed get media infos")
+		}
+		infos = append(infos, batch...)
+	}
+	return infos, nil
+}
+
+func SaveMediaInfo(info *model.MediaInfo) error {
+	return errors.WithStack(db.Save(info).Error)
+}
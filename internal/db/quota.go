@@ -0,0 +1,33 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddQuotaUsage adds n bytes to the usage of the user on the storage
+func AddQuotaUsage(userID, storageID uint, n int64) error {
+	u := model.QuotaUsage{UserID: userID, StorageID: storageID, Used: n}
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "storage_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"used": gorm.Expr("? + ?", clause.Column{Table: clause.CurrentTable, Name: "used"}, n)}),
+	}).Create(&u).Error)
+}
+
+func GetQuotaUsagesByUserID(userID uint) ([]model.QuotaUsage, error) {
+	var usages []model.QuotaUsage
+	if err := db.Where(model.QuotaUsage{UserID: userID}).Find(&usages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get quota usages")
+	}
+	return usages, nil
+}
+
+func DeleteQuotaUsagesByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.QuotaUsage{UserID: userID}).Delete(&model.QuotaUsage{}).Error)
+}
+
+func DeleteQuotaUsagesByStorageID(storageID uint) error {
+	return errors.WithStack(db.Where(model.QuotaUsage{StorageID: storageID}).Delete(&model.QuotaUsage{}).Error)
+}
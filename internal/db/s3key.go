@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateS3Key(k *model.S3Key) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func GetS3KeyByAccessKeyID(accessKeyID string) (*model.S3Key, error) {
+	k := model.S3Key{AccessKeyID: accessKeyID}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3KeysByUserID(userID uint) ([]model.S3Key, error) {
+	var keys []model.S3Key
+	if err := db.Where(model.S3Key{UserID: userID}).Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 keys")
+	}
+	return keys, nil
+}
+
+func DeleteS3KeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Key{}, id).Error)
+}
+
+func DeleteS3KeysByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.S3Key{UserID: userID}).Delete(&model.S3Key{}).Error)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateShare(s *model.Share) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func GetShareByToken(token string) (*model.Share, error) {
+	s := model.Share{Token: token}
+	if err := db.Where(s).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find share")
+	}
+	return &s, nil
+}
+
+func GetShareById(id uint) (*model.Share, error) {
+	var s model.Share
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share")
+	}
+	return &s, nil
+}
+
+// GetShares returns the shares of the user, or of all users if userID is 0
+func GetShares(userID uint, pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	shareDB := db.Model(&model.Share{}).Where(model.Share{UserID: userID})
+	if err = shareDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get shares count")
+	}
+	if err = shareDB.Order("id desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find shares")
+	}
+	return shares, count, nil
+}
+
+// IncreaseShareDownloads counts a download of the share, it returns false
+// if the download limit is reached
+func IncreaseShareDownloads(id uint) (bool, error) {
+	res := db.Model(&model.Share{}).
+		Where("id = ? AND (max_downloads = 0 OR downloads < max_downloads)", id).
+		Update("downloads", gorm.Expr("downloads + 1"))
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func DeleteShareById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Share{}, id).Error)
+}
+
+func DeleteSharesByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.Share{UserID: userID}).Delete(&model.Share{}).Error)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSSHKey(k *model.SSHKey) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+func GetSSHKeyByFingerprint(fingerprint string) (*model.SSHKey, error) {
+	k := model.SSHKey{Fingerprint: fingerprint}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find ssh key")
+	}
+	return &k, nil
+}
+
+func GetSSHKeysByUserID(userID uint) ([]model.SSHKey, error) {
+	var keys []model.SSHKey
+	if err := db.Where(model.SSHKey{UserID: userID}).Find(&keys).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ssh keys")
+	}
+	return keys, nil
+}
+
+func DeleteSSHKeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SSHKey{}, id).Error)
+}
+
+func DeleteSSHKeysByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.SSHKey{UserID: userID}).Delete(&model.SSHKey{}).Error)
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSymlinkById(id uint) (*model.Symlink, error) {
+	var s model.Symlink
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get symlink")
+	}
+	return &s, nil
+}
+
+func CreateSymlink(s *model.Symlink) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateSymlink(s *model.Symlink) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func GetSymlinks(pageIndex, pageSize int) (symlinks []model.Symlink, count int64, err error) {
+	symlinkDB := db.Model(&model.Symlink{})
+	if err = symlinkDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get symlinks count")
+	}
+	if err = symlinkDB.Order("path").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&symlinks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find symlinks")
+	}
+	return symlinks, count, nil
+}
+
+func GetAllSymlinks() ([]model.Symlink, error) {
+	var symlinks []model.Symlink
+	if err := db.Find(&symlinks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get symlinks")
+	}
+	return symlinks, nil
+}
+
+func DeleteSymlinkById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Symlink{}, id).Error)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &j, nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+// UpdateSyncJobResult saves the result of the last run only, so that the
+// changes made to the job meanwhile are kept
+func UpdateSyncJobResult(id uint, lastRun time.Time, result string) error {
+	return errors.WithStack(db.Model(&model.SyncJob{ID: id}).
+		Updates(map[string]interface{}{"last_run": lastRun, "last_result": result}).Error)
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err = jobDB.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledSyncJobs() ([]model.SyncJob, error) {
+	var jobs []model.SyncJob
+	if err := db.Where("disabled = ?", false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get enabled sync jobs")
+	}
+	return jobs, nil
+}
+
+func DeleteSyncJobById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTaskItem(t *model.TaskItem) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func GetTaskItemsByType(typ string) ([]model.TaskItem, error) {
+	var items []model.TaskItem
+	if err := db.Where(model.TaskItem{Type: typ}).Order("id").Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get task items")
+	}
+	return items, nil
+}
+
+func DeleteTaskItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TaskItem{}, id).Error)
+}
+
+// ClaimTaskItem moves the task of the node to another one, it reports false
+// if the task has been claimed already
+func ClaimTaskItem(id uint, from, to string) (bool, error) {
+	res := db.Model(&model.TaskItem{}).Where("id = ? AND node = ?", id, from).Update("node", to)
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed claim task item")
+	}
+	return res.RowsAffected == 1, nil
+}
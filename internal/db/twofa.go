@@ -0,0 +1,50 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateAppPassword(p *model.AppPassword) error {
+	return errors.WithStack(db.Create(p).Error)
+}
+
+func GetAppPasswordsByUserID(userID uint) ([]model.AppPassword, error) {
+	var passwords []model.AppPassword
+	if err := db.Where(model.AppPassword{UserID: userID}).Find(&passwords).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get app passwords")
+	}
+	return passwords, nil
+}
+
+func DeleteAppPassword(userID, id uint) error {
+	return errors.WithStack(db.Where(model.AppPassword{UserID: userID}).Delete(&model.AppPassword{}, id).Error)
+}
+
+func DeleteAppPasswordsByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.AppPassword{UserID: userID}).Delete(&model.AppPassword{}).Error)
+}
+
+func CreateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	return errors.WithStack(db.Create(c).Error)
+}
+
+func UpdateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	return errors.WithStack(db.Save(c).Error)
+}
+
+func GetWebAuthnCredentialsByUserID(userID uint) ([]model.WebAuthnCredential, error) {
+	var credentials []model.WebAuthnCredential
+	if err := db.Where(model.WebAuthnCredential{UserID: userID}).Find(&credentials).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webauthn credentials")
+	}
+	return credentials, nil
+}
+
+func DeleteWebAuthnCredential(userID, id uint) error {
+	return errors.WithStack(db.Where(model.WebAuthnCredential{UserID: userID}).Delete(&model.WebAuthnCredential{}, id).Error)
+}
+
+func DeleteWebAuthnCredentialsByUserID(userID uint) error {
+	return errors.WithStack(db.Where(model.WebAuthnCredential{UserID: userID}).Delete(&model.WebAuthnCredential{}).Error)
+}
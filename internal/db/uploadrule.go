@@ -0,0 +1,46 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetUploadRuleById(id uint) (*model.UploadRule, error) {
+	var r model.UploadRule
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get upload rule")
+	}
+	return &r, nil
+}
+
+func CreateUploadRule(r *model.UploadRule) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateUploadRule(r *model.UploadRule) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func GetUploadRules(pageIndex, pageSize int) (rules []model.UploadRule, count int64, err error) {
+	ruleDB := db.Model(&model.UploadRule{})
+	if err = ruleDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get upload rules count")
+	}
+	if err = ruleDB.Order("priority, id").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&rules).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find upload rules")
+	}
+	return rules, count, nil
+}
+
+// GetEnabledUploadRules returns the enabled rules by the priority
+func GetEnabledUploadRules() ([]model.UploadRule, error) {
+	var rules []model.UploadRule
+	if err := db.Where("disabled = ?", false).Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get enabled upload rules")
+	}
+	return rules, nil
+}
+
+func DeleteUploadRuleById(id uint) error {
+	return errors.WithStack(db.Delete(&model.UploadRule{}, id).Error)
+}
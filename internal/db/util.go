@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 )
@@ -12,3 +13,9 @@ func columnName(name string) string {
 	}
 	return fmt.Sprintf("`%s`", name)
 }
+
+// escapeLike escapes the pattern of LIKE with "!", which works the same in
+// all the databases, the query must end with ESCAPE '!'
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
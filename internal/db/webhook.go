@@ -0,0 +1,45 @@
+package db
+
+import (
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var w model.Webhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &w, nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func GetWebhooks(pageIndex, pageSize int) (webhooks []model.Webhook, count int64, err error) {
+	webhookDB := db.Model(&model.Webhook{})
+	if err = webhookDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhooks count")
+	}
+	if err = webhookDB.Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&webhooks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhooks")
+	}
+	return webhooks, count, nil
+}
+
+func GetEnabledWebhooks() ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := db.Where("disabled = ?", false).Find(&webhooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get enabled webhooks")
+	}
+	return webhooks, nil
+}
+
+func DeleteWebhookById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
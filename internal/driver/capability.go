@@ -0,0 +1,26 @@
+package driver
+
+// Capabilities describes what a storage can do, so that callers can pick
+// the best strategy up front instead of trying and handling errors.
+type Capabilities struct {
+	List         bool `json:"list"`
+	MakeDir      bool `json:"make_dir"`
+	Move         bool `json:"move"`
+	Rename       bool `json:"rename"`
+	Copy         bool `json:"copy"` // server-side copy inside the storage
+	Remove       bool `json:"remove"`
+	Upload       bool `json:"upload"`
+	RangeRead    bool `json:"range_read"`
+	DirectLink   bool `json:"direct_link"` // links can be used by the client without proxy
+	Checksum     bool `json:"checksum"`    // objects carry hashes that can be used for verification
+	UploadResume bool `json:"upload_resume"`
+	Space        bool `json:"space"`
+	Versions     bool `json:"versions"`
+	Trash        bool `json:"trash"` // removed objects are kept in the recycle bin
+}
+
+type Capabler interface {
+	// Capabilities adjusts the capabilities derived from the implemented
+	// interfaces, for the ones that can't be detected such as checksums
+	Capabilities(caps *Capabilities)
+}
@@ -47,6 +47,38 @@ type Getter interface {
 	Get(ctx context.Context, path string) (model.Obj, error)
 }
 
+// Versioner is implemented by the storages that keep the previous versions
+// of files, the versions are identified by the ids given by the storage
+type Versioner interface {
+	// ListVersions lists the previous versions of the file, the latest first
+	ListVersions(ctx context.Context, file model.Obj) ([]model.FileVersion, error)
+	LinkVersion(ctx context.Context, file model.Obj, version string, args model.LinkArgs) (*model.Link, error)
+	// RestoreVersion makes the version the current content of the file
+	RestoreVersion(ctx context.Context, file model.Obj, version string) error
+}
+
+// Trasher is implemented by the storages that keep the removed objects in a
+// recycle bin of their own, which is then served by the recycle bin api
+// instead of op.TrashDir. The paths of the items are the original paths in
+// the storage.
+type Trasher interface {
+	ListTrash(ctx context.Context) ([]model.TrashItem, error)
+	GetTrashItem(ctx context.Context, id, name string) (*model.TrashItem, error)
+	RestoreTrash(ctx context.Context, id, name string) error
+	DeleteTrash(ctx context.Context, id, name string) error
+}
+
+// HealthChecker is implemented by the storages that can check their health
+// cheaply, such as the token is still valid, instead of listing the root
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+type GetSpacer interface {
+	// GetSpace get the total and free space of the storage
+	GetSpace(ctx context.Context) (*model.StorageSpace, error)
+}
+
 //type Writer interface {
 //	Mkdir
 //	Move
@@ -3,5 +3,6 @@ package errs
 import "errors"
 
 var (
-	EmptyToken = errors.New("empty token")
+	EmptyToken     = errors.New("empty token")
+	StorageOffline = errors.New("storage is offline")
 )
@@ -10,6 +10,9 @@ var (
 	ObjectNotFound = errors.New("object not found")
 	NotFolder      = errors.New("not a folder")
 	NotFile        = errors.New("not a file")
+	IsSymlink      = errors.New("it's a symlink, manage it in the symlinks instead")
+
+	ChecksumMismatch = errors.New("checksum mismatch")
 )
 
 func IsObjectNotFound(err error) bool {
@@ -4,4 +4,6 @@ import "errors"
 
 var (
 	PermissionDenied = errors.New("permission denied")
+	TooManyTransfers = errors.New("too many concurrent transfers")
+	QuotaExceeded    = errors.New("upload quota exceeded")
 )
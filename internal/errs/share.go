@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	ShareExpired       = errors.New("share is expired")
+	ShareLimitReached  = errors.New("download limit of the share is reached")
+	WrongSharePassword = errors.New("password of the share is incorrect")
+)
@@ -7,4 +7,5 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	TwoFARequired      = errors.New("2FA is enabled, use an app password instead")
 )
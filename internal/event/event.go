@@ -0,0 +1,56 @@
+// Package event is a tiny bus of the things happened in alist, such as a
+// file is uploaded or a task failed, so that the notifiers (such as
+// webhooks) don't need to be known by the places emitting the events.
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	FileUpload     = "file.upload"
+	FileDelete     = "file.delete"
+	FileMove       = "file.move"
+	FileRename     = "file.rename"
+	TaskFailed     = "task.failed"
+	StorageOffline = "storage.offline"
+	StorageOnline  = "storage.online"
+)
+
+// Types are all the types of events
+var Types = []string{FileUpload, FileDelete, FileMove, FileRename, TaskFailed, StorageOffline, StorageOnline}
+
+type Event struct {
+	Type string         `json:"event"`
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data"`
+}
+
+// Handler handles the published events, it's called synchronously by
+// Publish so it must not block
+type Handler func(e Event)
+
+var (
+	mu       sync.RWMutex
+	handlers []Handler
+)
+
+func Subscribe(h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers = append(handlers, h)
+}
+
+func Publish(typ string, data map[string]any) {
+	mu.RLock()
+	hs := handlers
+	mu.RUnlock()
+	if len(hs) == 0 {
+		return
+	}
+	e := Event{Type: typ, Time: time.Now(), Data: data}
+	for _, h := range hs {
+		h(e)
+	}
+}
@@ -0,0 +1,59 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// checkPerm fails if an acl rule denies the user of ctx the perm on path,
+// or on the target of path if it's in a symlink. Permissions that no rule
+// decides are checked by the frontends with the permissions of the user,
+// and calls without a user in ctx are internal.
+func checkPerm(ctx context.Context, path string, perm int32) error {
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok {
+		return nil
+	}
+	if allowed, decided := op.CheckPermission(user, path, perm); decided && !allowed {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	if resolved := op.ResolveSymlink(path); resolved != utils.FixAndCleanPath(path) {
+		if allowed, decided := op.CheckPermission(user, resolved, perm); decided && !allowed {
+			return errors.WithStack(errs.PermissionDenied)
+		}
+	}
+	return nil
+}
+
+// checkTreePerm is checkPerm for the operations on path and everything
+// under it, like moving a dir. It also fails if a rule under path denies
+// any of perms, since moving the objs out of the path or removing them
+// would get around the rule.
+func checkTreePerm(ctx context.Context, path string, perm, perms int32) error {
+	if err := checkPerm(ctx, path, perm); err != nil {
+		return err
+	}
+	user, ok := ctx.Value("user").(*model.User)
+	if ok && op.DeniedBelow(user, path, perms) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	return nil
+}
+
+// filterReadable removes the objs in dir that the user can't read
+func filterReadable(user *model.User, dir string, objs []model.Obj) []model.Obj {
+	res := objs[:0]
+	for _, obj := range objs {
+		allowed, decided := op.CheckPermission(user, stdpath.Join(dir, obj.GetName()), model.PermRead)
+		if !decided || allowed {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
@@ -0,0 +1,243 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	BatchCopy   = "copy"
+	BatchMove   = "move"
+	BatchRemove = "remove"
+)
+
+// maxBatchErrors is the max errors kept in the progress of a batch
+const maxBatchErrors = 100
+
+var BatchTaskManager = task.NewTaskManager(3, func(tid *uint64) {
+	atomic.AddUint64(tid, 1)
+})
+
+var batches generic_sync.MapOf[uint64, *Batch]
+
+type BatchError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+type BatchProgress struct {
+	Total  int `json:"total"`
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+	// Bytes is the bytes copied between storages
+	Bytes int64 `json:"bytes"`
+	// Verified is the files copied between storages and verified by checksums
+	Verified int          `json:"verified"`
+	Errors   []BatchError `json:"errors"`
+}
+
+// Batch copies, moves or removes many objects in one task
+type Batch struct {
+	ID     uint64
+	UserID uint
+	Op     string
+	Paths  []string
+	DstDir string
+
+	mu       sync.Mutex
+	progress BatchProgress
+	// curBytes is the bytes copied of the current file
+	curBytes int64
+	task     *task.Task[uint64]
+}
+
+func (b *Batch) Task() *task.Task[uint64] {
+	return b.task
+}
+
+func (b *Batch) Progress() BatchProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := b.progress
+	p.Bytes += b.curBytes
+	p.Errors = append([]BatchError(nil), b.progress.Errors...)
+	return p
+}
+
+func (b *Batch) finish(path string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.progress.Failed++
+		if len(b.progress.Errors) < maxBatchErrors {
+			b.progress.Errors = append(b.progress.Errors, BatchError{Path: path, Error: err.Error()})
+		}
+	} else {
+		b.progress.Done++
+	}
+	ended := b.progress.Done + b.progress.Failed
+	b.task.SetProgress(ended * 100 / b.progress.Total)
+	b.task.SetStatus(fmt.Sprintf("%d/%d done, %d failed", ended, b.progress.Total, b.progress.Failed))
+}
+
+func (b *Batch) setCurBytes(n int64) {
+	b.mu.Lock()
+	b.curBytes = n
+	b.mu.Unlock()
+}
+
+func (b *Batch) addBytes(n int64, verified bool) {
+	b.mu.Lock()
+	b.progress.Bytes += n
+	if verified {
+		b.progress.Verified++
+	}
+	b.curBytes = 0
+	b.mu.Unlock()
+}
+
+// GetBatch returns the batch of the id, it's gone once the task is removed
+func GetBatch(id uint64) (*Batch, bool) {
+	b, ok := batches.Load(id)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := BatchTaskManager.Get(id); !ok {
+		batches.Delete(id)
+		return nil, false
+	}
+	return b, true
+}
+
+// SubmitBatch submits a batch of the user of ctx, the paths are mount paths
+// and dstDir is ignored by remove. The permissions should be checked before.
+func SubmitBatch(ctx context.Context, batchOp string, paths []string, dstDir string) (*Batch, error) {
+	if batchOp != BatchCopy && batchOp != BatchMove && batchOp != BatchRemove {
+		return nil, errors.Errorf("unknown batch op: %s", batchOp)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("empty paths")
+	}
+	user, _ := ctx.Value("user").(*model.User)
+	b := &Batch{Op: batchOp, Paths: paths, DstDir: dstDir}
+	b.progress.Total = len(paths)
+	if user != nil {
+		b.UserID = user.ID
+	}
+	name := fmt.Sprintf("batch %s %d objects", batchOp, len(paths))
+	if batchOp != BatchRemove {
+		name += fmt.Sprintf(" to %s", dstDir)
+	}
+	b.task = task.WithCancelCtx(&task.Task[uint64]{
+		Name:    name,
+		Creator: b.UserID,
+		Func: func(t *task.Task[uint64]) error {
+			// the task runs after the request ends, so keep only the user of ctx
+			return b.run(context.WithValue(t.Ctx, "user", user))
+		},
+	})
+	b.ID = BatchTaskManager.Submit(b.task)
+	batches.Store(b.ID, b)
+	return b, nil
+}
+
+func (b *Batch) run(ctx context.Context) error {
+	// start over if the task is retried
+	b.mu.Lock()
+	b.progress = BatchProgress{Total: len(b.Paths)}
+	b.curBytes = 0
+	b.mu.Unlock()
+	for i, p := range b.Paths {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		// refresh the cache of the dst dir only after the last one
+		lazyCache := i < len(b.Paths)-1
+		var err error
+		switch b.Op {
+		case BatchCopy:
+			err = b.copy(ctx, p, lazyCache)
+		case BatchMove:
+			err = Move(ctx, p, b.DstDir, lazyCache)
+		case BatchRemove:
+			err = Remove(ctx, p)
+		}
+		b.finish(p, err)
+	}
+	if b.progress.Failed > 0 {
+		return errors.Errorf("%d of %d objects failed", b.progress.Failed, b.progress.Total)
+	}
+	return nil
+}
+
+// copy copies within the storage if it's supported, or copies the files
+// one by one in the batch instead of adding copy tasks, so that the bytes
+// are counted
+func (b *Batch) copy(ctx context.Context, srcPath string, lazyCache bool) error {
+	if err := checkTreePerm(ctx, srcPath, model.PermRead, model.PermRead); err != nil {
+		return err
+	}
+	if err := checkPerm(ctx, b.DstDir, model.PermWrite); err != nil {
+		return err
+	}
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(b.DstDir)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	if srcStorage.GetStorage() == dstStorage.GetStorage() && op.GetCapabilities(srcStorage).Copy {
+		return op.Copy(ctx, srcStorage, srcActualPath, dstDirActualPath, lazyCache)
+	}
+	return b.copyBetween2Storages(ctx, srcStorage, dstStorage, srcActualPath, dstDirActualPath)
+}
+
+func (b *Batch) copyBetween2Storages(ctx context.Context, srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string) error {
+	srcObj, err := op.Get(ctx, srcStorage, srcObjPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get src [%s] file", srcObjPath)
+	}
+	if !srcObj.IsDir() {
+		size := srcObj.GetSize()
+		v, err := copyFile(ctx, srcStorage, dstStorage, srcObjPath, dstDirPath, func(p int) {
+			b.setCurBytes(size * int64(p) / 100)
+		})
+		if err != nil {
+			b.setCurBytes(0)
+			return err
+		}
+		b.addBytes(size, v.Verified())
+		return nil
+	}
+	dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
+	if err := op.MakeDir(ctx, dstStorage, dstObjPath); err != nil {
+		return errors.WithMessagef(err, "failed make dir [%s]", dstObjPath)
+	}
+	objs, err := op.List(ctx, srcStorage, srcObjPath, model.ListArgs{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed list src [%s] objs", srcObjPath)
+	}
+	for _, obj := range objs {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		err := b.copyBetween2Storages(ctx, srcStorage, dstStorage, stdpath.Join(srcObjPath, obj.GetName()), dstObjPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,12 +3,19 @@ package fs
 import (
 	"context"
 	"fmt"
+	"os"
 	stdpath "path"
 	"sync/atomic"
+	"time"
 
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
@@ -22,6 +29,12 @@ var CopyTaskManager = task.NewTaskManager(3, func(tid *uint64) {
 // Copy if in the same storage, call move method
 // if not, add copy task
 func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (bool, error) {
+	if err := checkTreePerm(ctx, srcObjPath, model.PermRead, model.PermRead); err != nil {
+		return false, err
+	}
+	if err := checkPerm(ctx, dstDirPath, model.PermWrite); err != nil {
+		return false, err
+	}
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
 	if err != nil {
 		return false, errors.WithMessage(err, "failed get src storage")
@@ -30,18 +43,143 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 	if err != nil {
 		return false, errors.WithMessage(err, "failed get dst storage")
 	}
-	// copy if in the same storage, just call driver.Copy
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	// copy if in the same storage, just call driver.Copy,
+	// otherwise copy through a task as if they are different storages
+	if srcStorage.GetStorage() == dstStorage.GetStorage() && op.GetCapabilities(srcStorage).Copy {
 		return false, op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
 	}
 	// not in the same storage
+	addCopyTask(srcStorage, dstStorage, srcObjActualPath, dstDirActualPath, false, op.UserIDFromCtx(ctx))
+	return true, nil
+}
+
+const copyTaskType = "copy"
+
+// copyTaskData is persisted so that copy tasks can be resumed after restart
+type copyTaskData struct {
+	SrcStorage string `json:"src_storage"`
+	SrcObjPath string `json:"src_obj_path"`
+	DstStorage string `json:"dst_storage"`
+	DstDirPath string `json:"dst_dir_path"`
+	// File means the src object is known as a file, no need to list it
+	File bool `json:"file"`
+	// Creator is the user who copies, the tasks of the objs in the dir
+	// belong to the user too
+	Creator uint `json:"creator"`
+}
+
+func (d copyTaskData) name() string {
+	return fmt.Sprintf("copy [%s](%s) to [%s](%s)", d.SrcStorage, d.SrcObjPath, d.DstStorage, d.DstDirPath)
+}
+
+func addCopyTask(srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string, file bool, creator uint) {
+	data := copyTaskData{
+		SrcStorage: srcStorage.GetStorage().MountPath,
+		SrcObjPath: srcObjPath,
+		DstStorage: dstStorage.GetStorage().MountPath,
+		DstDirPath: dstDirPath,
+		File:       file,
+		Creator:    creator,
+	}
+	item := &model.TaskItem{Type: copyTaskType, Name: data.name(), Node: cluster.Node()}
+	var err error
+	item.Data, err = utils.Json.MarshalToString(data)
+	if err == nil {
+		err = db.CreateTaskItem(item)
+	}
+	if err != nil {
+		log.Warnf("failed to persist task %s, it won't be resumed after restart: %+v", item.Name, err)
+		item = nil
+	}
+	submitCopyTask(srcStorage, dstStorage, data, item)
+}
+
+func submitCopyTask(srcStorage, dstStorage driver.Driver, data copyTaskData, item *model.TaskItem) {
 	CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-		Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjActualPath, dstStorage.GetStorage().MountPath, dstDirActualPath),
-		Func: func(task *task.Task[uint64]) error {
-			return copyBetween2Storages(task, srcStorage, dstStorage, srcObjActualPath, dstDirActualPath)
+		Name:         data.name(),
+		Creator:      data.Creator,
+		MaxRetry:     setting.GetInt(conf.TaskMaxRetry, 3),
+		RetryBackoff: time.Second * 5,
+		Func: func(t *task.Task[uint64]) error {
+			if data.File {
+				err := copyFileBetween2Storages(t, srcStorage, dstStorage, data.SrcObjPath, data.DstDirPath)
+				log.Debugf("copy file between storages: %+v", err)
+				return err
+			}
+			return copyBetween2Storages(t, srcStorage, dstStorage, data.SrcObjPath, data.DstDirPath)
+		},
+		Finally: func(t *task.Task[uint64]) {
+			if item == nil {
+				return
+			}
+			if err := db.DeleteTaskItemById(item.ID); err != nil {
+				log.Errorf("failed to delete persisted task %s: %+v", item.Name, err)
+			}
 		},
 	}))
-	return true, nil
+}
+
+// RestoreTasks resubmits the copy and upload tasks interrupted by the last
+// shutdown, it should be called after storages are loaded.
+// Drivers that support resumable upload (such as s3 multipart) will
+// continue from where they stopped.
+func RestoreTasks() {
+	restoreTasks(true)
+	restoreUploadTasks()
+}
+
+// TakeOverTasks resubmits the copy tasks of the instances gone from the
+// cluster, it should be called by the leader only
+func TakeOverTasks() {
+	restoreTasks(false)
+}
+
+// restoreTasks resubmits the tasks of the gone instances, and the ones of
+// this instance if own is true. The tasks of the alive instances are left
+// to them.
+func restoreTasks(own bool) {
+	items, err := db.GetTaskItemsByType(copyTaskType)
+	if err != nil {
+		log.Errorf("failed to get persisted tasks: %+v", err)
+		return
+	}
+	for i := range items {
+		item := &items[i]
+		if item.Node == cluster.Node() {
+			if !own {
+				continue
+			}
+		} else {
+			if cluster.Alive(item.Node) {
+				continue
+			}
+			ok, err := db.ClaimTaskItem(item.ID, item.Node, cluster.Node())
+			if err != nil {
+				log.Errorf("failed to take over task %s: %+v", item.Name, err)
+			}
+			if !ok {
+				continue
+			}
+			log.Infof("take over task %s from node %s", item.Name, item.Node)
+			item.Node = cluster.Node()
+		}
+		var data copyTaskData
+		err := utils.Json.UnmarshalFromString(item.Data, &data)
+		var srcStorage, dstStorage driver.Driver
+		if err == nil {
+			srcStorage, err = op.GetStorageByMountPath(data.SrcStorage)
+		}
+		if err == nil {
+			dstStorage, err = op.GetStorageByMountPath(data.DstStorage)
+		}
+		if err != nil {
+			log.Warnf("failed to restore task %s, drop it: %+v", item.Name, err)
+			_ = db.DeleteTaskItemById(item.ID)
+			continue
+		}
+		log.Infof("restore task %s", item.Name)
+		submitCopyTask(srcStorage, dstStorage, data, item)
+	}
 }
 
 func copyBetween2Storages(t *task.Task[uint64], srcStorage, dstStorage driver.Driver, srcObjPath, dstDirPath string) error {
@@ -62,38 +200,76 @@ func copyBetween2Storages(t *task.Task[uint64], srcStorage, dstStorage driver.Dr
 			}
 			srcObjPath := stdpath.Join(srcObjPath, obj.GetName())
 			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
-			CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-				Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjPath, dstStorage.GetStorage().MountPath, dstObjPath),
-				Func: func(t *task.Task[uint64]) error {
-					return copyBetween2Storages(t, srcStorage, dstStorage, srcObjPath, dstObjPath)
-				},
-			}))
+			addCopyTask(srcStorage, dstStorage, srcObjPath, dstObjPath, false, t.Creator)
 		}
 	} else {
-		CopyTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-			Name: fmt.Sprintf("copy [%s](%s) to [%s](%s)", srcStorage.GetStorage().MountPath, srcObjPath, dstStorage.GetStorage().MountPath, dstDirPath),
-			Func: func(t *task.Task[uint64]) error {
-				err := copyFileBetween2Storages(t, srcStorage, dstStorage, srcObjPath, dstDirPath)
-				log.Debugf("copy file between storages: %+v", err)
-				return err
-			},
-		}))
+		addCopyTask(srcStorage, dstStorage, srcObjPath, dstDirPath, true, t.Creator)
 	}
 	return nil
 }
 
 func copyFileBetween2Storages(tsk *task.Task[uint64], srcStorage, dstStorage driver.Driver, srcFilePath, dstDirPath string) error {
-	srcFile, err := op.Get(tsk.Ctx, srcStorage, srcFilePath)
+	// the copied file counts against the quota of the user who copies
+	user, err := op.GetTaskUser(tsk.Creator)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
+		return errors.WithMessage(err, "failed get the user of the task")
 	}
-	link, _, err := op.Link(tsk.Ctx, srcStorage, srcFilePath, model.LinkArgs{})
+	ctx := context.WithValue(tsk.Ctx, "user", user)
+	v, err := copyFile(ctx, srcStorage, dstStorage, srcFilePath, dstDirPath, tsk.SetProgress)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
+		return err
+	}
+	tsk.SetStatus(v.String())
+	return nil
+}
+
+// copyFile copies the file and verifies the copied file by checksums
+// according to the copy verify setting, a mismatched copy is removed.
+func copyFile(ctx context.Context, srcStorage, dstStorage driver.Driver, srcFilePath, dstDirPath string, up driver.UpdateProgress) (verification, error) {
+	mode := setting.GetStr(conf.CopyVerify, verifyReported)
+	v := verification{mode: mode}
+	srcFile, err := op.Get(ctx, srcStorage, srcFilePath)
+	if err != nil {
+		return v, errors.WithMessagef(err, "failed get src [%s] file", srcFilePath)
+	}
+	quotaUser := getQuotaUser(ctx)
+	if err := op.CheckQuota(quotaUser, dstStorage.GetStorage(), srcFile.GetSize()); err != nil {
+		return v, err
+	}
+	link, _, err := op.Link(ctx, srcStorage, srcFilePath, model.LinkArgs{})
+	if err != nil {
+		return v, errors.WithMessagef(err, "failed get [%s] link", srcFilePath)
 	}
 	stream, err := getFileStreamFromLink(srcFile, link)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
+		return v, errors.WithMessagef(err, "failed get [%s] stream", srcFilePath)
+	}
+	if mode == verifyOff {
+		if err = op.Put(ctx, dstStorage, dstDirPath, stream, up, true); err == nil {
+			recordUpload(quotaUser, dstStorage, stream)
+		}
+		return v, err
+	}
+	// op.Put won't remove the temp file once the reader is wrapped
+	if f, ok := stream.GetReadCloser().(*os.File); ok {
+		defer func() {
+			if err := os.RemoveAll(f.Name()); err != nil {
+				log.Errorf("failed to remove file [%s]", f.Name())
+			}
+		}()
+	}
+	hr := newHashReader(stream.GetReadCloser(), model.GetHashes(srcFile))
+	stream.SetReadCloser(hr)
+	if err = op.Put(ctx, dstStorage, dstDirPath, stream, up, true); err != nil {
+		return v, err
+	}
+	recordUpload(quotaUser, dstStorage, stream)
+	dstFilePath := stdpath.Join(dstDirPath, srcFile.GetName())
+	v, err = verifyCopy(ctx, hr, srcFile, dstStorage, dstFilePath, mode)
+	if errors.Is(err, errs.ChecksumMismatch) {
+		if e := op.Remove(ctx, dstStorage, dstFilePath); e != nil {
+			log.Errorf("failed to remove mismatched copy [%s]: %+v", dstFilePath, e)
+		}
 	}
-	return op.Put(tsk.Ctx, dstStorage, dstDirPath, stream, tsk.SetProgress, true)
+	return v, err
 }
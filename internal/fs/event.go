@@ -0,0 +1,31 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// publish publishes the event with the user of ctx if there is one
+func publish(ctx context.Context, typ string, data map[string]any) {
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		data["user"] = user.Username
+	}
+	event.Publish(typ, data)
+}
+
+// publishUpload publishes the upload with the id of the upload rule applied,
+// whose actions are run by the subscriber of the rules
+func publishUpload(ctx context.Context, dstDirPath string, file *model.FileStream, rule *model.UploadRule) {
+	data := map[string]any{
+		"path": stdpath.Join(utils.FixAndCleanPath(dstDirPath), file.GetName()),
+		"size": file.GetSize(),
+	}
+	if rule != nil {
+		data["rule"] = rule.ID
+	}
+	publish(ctx, event.FileUpload, data)
+}
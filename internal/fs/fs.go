@@ -2,11 +2,16 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/tracing"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // the param named path of functions in this package is a mount path
@@ -19,7 +24,9 @@ type ListArgs struct {
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
+	ctx, span := tracing.Start(ctx, "fs.List", tracing.Path(path))
 	res, err := list(ctx, path, args)
+	tracing.End(span, err)
 	if err != nil {
 		if !args.NoLog {
 			log.Errorf("failed list %s: %+v", path, err)
@@ -34,7 +41,9 @@ type GetArgs struct {
 }
 
 func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
+	ctx, span := tracing.Start(ctx, "fs.Get", tracing.Path(path))
 	res, err := get(ctx, path)
+	tracing.End(span, err)
 	if err != nil {
 		if !args.NoLog {
 			log.Errorf("failed get %s: %+v", path, err)
@@ -45,7 +54,9 @@ func Get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 }
 
 func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	ctx, span := tracing.Start(ctx, "fs.Link", tracing.Path(path))
 	res, file, err := link(ctx, path, args)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed link %s: %+v", path, err)
 		return nil, nil, err
@@ -54,7 +65,9 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 }
 
 func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.MakeDir", tracing.Path(path))
 	err := makeDir(ctx, path, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
@@ -62,15 +75,21 @@ func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
 }
 
 func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.Move", tracing.Path(srcPath), attribute.String("alist.dst_dir", dstDirPath))
 	err := move(ctx, srcPath, dstDirPath, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
+	} else {
+		publish(ctx, event.FileMove, map[string]any{"src_path": utils.FixAndCleanPath(srcPath), "dst_dir": utils.FixAndCleanPath(dstDirPath)})
 	}
 	return err
 }
 
 func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool) (bool, error) {
+	ctx, span := tracing.Start(ctx, "fs.Copy", tracing.Path(srcObjPath), attribute.String("alist.dst_dir", dstDirPath))
 	res, err := _copy(ctx, srcObjPath, dstDirPath, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
@@ -78,31 +97,51 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool)
 }
 
 func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
+	ctx, span := tracing.Start(ctx, "fs.Rename", tracing.Path(srcPath))
 	err := rename(ctx, srcPath, dstName, lazyCache...)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
+	} else {
+		publish(ctx, event.FileRename, map[string]any{"src_path": utils.FixAndCleanPath(srcPath), "name": dstName})
 	}
 	return err
 }
 
 func Remove(ctx context.Context, path string) error {
+	ctx, span := tracing.Start(ctx, "fs.Remove", tracing.Path(path))
 	err := remove(ctx, path)
+	tracing.End(span, err)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
+	} else {
+		publish(ctx, event.FileDelete, map[string]any{"path": utils.FixAndCleanPath(path)})
 	}
 	return err
 }
 
 func PutDirectly(ctx context.Context, dstDirPath string, file *model.FileStream, lazyCache ...bool) error {
-	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
+	ctx, span := tracing.Start(ctx, "fs.Put", tracing.Path(stdpath.Join(dstDirPath, file.GetName())))
+	// the permission is of the dir uploaded to, rather than the one routed
+	// to by the rules
+	err := checkPerm(ctx, stdpath.Join(dstDirPath, file.GetName()), model.PermWrite)
+	var rule *model.UploadRule
+	if err == nil {
+		dstDirPath, rule = routeUpload(ctx, dstDirPath, file)
+		err = putDirectly(ctx, dstDirPath, file, lazyCache...)
+	}
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
+	} else {
+		publishUpload(ctx, dstDirPath, file, rule)
 	}
+	tracing.End(span, err)
 	return err
 }
 
-func PutAsTask(dstDirPath string, file *model.FileStream) error {
-	err := putAsTask(dstDirPath, file)
+func PutAsTask(ctx context.Context, dstDirPath string, file *model.FileStream) error {
+	dstDirPath, rule := routeUpload(ctx, dstDirPath, file)
+	err := putAsTask(ctx, dstDirPath, file, rule)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
@@ -120,6 +159,15 @@ func GetStorage(path string, args *GetStoragesArgs) (driver.Driver, error) {
 	return storageDriver, nil
 }
 
+func GetCapabilities(path string) (*driver.Capabilities, error) {
+	storageDriver, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, err
+	}
+	caps := op.GetCapabilities(storageDriver)
+	return &caps, nil
+}
+
 func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 	res, err := other(ctx, args)
 	if err != nil {
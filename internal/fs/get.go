@@ -13,19 +13,38 @@ import (
 
 func get(ctx context.Context, path string) (model.Obj, error) {
 	path = utils.FixAndCleanPath(path)
+	if err := checkPerm(ctx, path, model.PermRead); err != nil {
+		return nil, err
+	}
+	resolved := op.ResolveSymlink(path)
+	if _, err := checkTarget(ctx, path, resolved); err != nil {
+		return nil, err
+	}
+	obj, err := getResolved(ctx, path, resolved)
+	if err != nil {
+		return nil, err
+	}
+	// the symlink keeps its own name
+	if obj.GetName() != stdpath.Base(path) && path != "/" {
+		return &model.ObjWrapName{Name: stdpath.Base(path), Obj: obj}, nil
+	}
+	return obj, nil
+}
+
+func getResolved(ctx context.Context, path, resolved string) (model.Obj, error) {
 	// maybe a virtual file
-	if path != "/" {
-		virtualFiles := op.GetStorageVirtualFilesByPath(stdpath.Dir(path))
+	if resolved != "/" {
+		virtualFiles := op.GetStorageVirtualFilesByPath(stdpath.Dir(resolved))
 		for _, f := range virtualFiles {
-			if f.GetName() == stdpath.Base(path) {
+			if f.GetName() == stdpath.Base(resolved) {
 				return f, nil
 			}
 		}
 	}
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := op.GetStorageAndActualPath(resolved)
 	if err != nil {
 		// if there are no storage prefix with path, maybe root folder
-		if path == "/" {
+		if resolved == "/" {
 			return &model.Object{
 				Name:     "root",
 				Size:     0,
@@ -6,12 +6,19 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 )
 
 func link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	if err := checkPerm(ctx, path, model.PermRead); err != nil {
+		return nil, nil, err
+	}
+	if _, err := checkTarget(ctx, utils.FixAndCleanPath(path), op.ResolveSymlink(path)); err != nil {
+		return nil, nil, err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
@@ -21,7 +28,7 @@ func link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 		return nil, nil, errors.WithMessage(err, "failed link")
 	}
 	if l.URL != "" && !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
-		if c, ok := ctx.(*gin.Context); ok {
+		if c, ok := ctx.Value(gin.ContextKey).(*gin.Context); ok {
 			l.URL = common.GetApiUrl(c.Request) + l.URL
 		}
 	}
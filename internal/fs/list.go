@@ -2,7 +2,9 @@ package fs
 
 import (
 	"context"
+	"strings"
 
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -14,9 +16,18 @@ import (
 func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
 	meta := ctx.Value("meta").(*model.Meta)
 	user := ctx.Value("user").(*model.User)
-	virtualFiles := op.GetStorageVirtualFilesByPath(path)
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
-	if err != nil && len(virtualFiles) == 0 {
+	if err := checkPerm(ctx, path, model.PermRead); err != nil {
+		return nil, err
+	}
+	resolved := op.ResolveSymlink(path)
+	targetMeta, err := checkTarget(ctx, path, resolved)
+	if err != nil {
+		return nil, err
+	}
+	virtualFiles := op.GetStorageVirtualFilesByPath(resolved)
+	linkObjs := getSymlinkObjs(ctx, path)
+	storage, actualPath, err := op.GetStorageAndActualPath(resolved)
+	if err != nil && len(virtualFiles) == 0 && len(linkObjs) == 0 {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
 
@@ -29,18 +40,45 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 			if !args.NoLog {
 				log.Errorf("fs/list: %+v", err)
 			}
-			if len(virtualFiles) == 0 {
+			if len(virtualFiles) == 0 && len(linkObjs) == 0 {
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
 	}
 
 	om := model.NewObjMerge()
+	var hides []string
 	if whetherHide(user, meta, path) {
-		om.InitHideReg(meta.Hide)
+		hides = append(hides, meta.Hide)
+	}
+	// the objs hidden in the target of a symlink are hidden through it too
+	if targetMeta != nil && whetherHide(user, targetMeta, resolved) {
+		hides = append(hides, targetMeta.Hide)
+	}
+	if len(hides) > 0 {
+		om.InitHideReg(strings.Join(hides, "\n"))
+	}
+	if storage != nil {
+		_objs = hideInternalDirs(storage, actualPath, _objs)
+	}
+	// the symlinks take the place of the objects with the same names
+	objs := om.Merge(append(linkObjs, _objs...), virtualFiles...)
+	return filterReadable(user, path, objs), nil
+}
+
+// getSymlinkObjs gets the targets of the symlinks in the dir, the broken
+// symlinks are skipped
+func getSymlinkObjs(ctx context.Context, dir string) []model.Obj {
+	var objs []model.Obj
+	for _, s := range op.GetSymlinksIn(dir) {
+		obj, err := get(ctx, s.Path)
+		if err != nil {
+			log.Debugf("skip broken symlink %s: %+v", s.Path, err)
+			continue
+		}
+		objs = append(objs, obj)
 	}
-	objs := om.Merge(_objs, virtualFiles...)
-	return objs, nil
+	return objs
 }
 
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
@@ -63,3 +101,24 @@ func whetherHide(user *model.User, meta *model.Meta, path string) bool {
 	// if is guest, hide
 	return true
 }
+
+// hideInternalDirs hides the versions dirs and the recycle bin in the root
+func hideInternalDirs(storage driver.Driver, path string, objs []model.Obj) []model.Obj {
+	var hidden []string
+	if op.KeepsVersions(storage) {
+		hidden = append(hidden, op.VersionsDir)
+	}
+	if op.UsesTrash(storage) && utils.PathEqual(path, "/") {
+		hidden = append(hidden, op.TrashDir)
+	}
+	if len(hidden) == 0 {
+		return objs
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if !obj.IsDir() || !utils.SliceContains(hidden, obj.GetName()) {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
@@ -10,6 +10,9 @@ import (
 )
 
 func makeDir(ctx context.Context, path string, lazyCache ...bool) error {
+	if err := checkPerm(ctx, path, model.PermWrite); err != nil {
+		return err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -18,6 +21,16 @@ func makeDir(ctx context.Context, path string, lazyCache ...bool) error {
 }
 
 func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) error {
+	// the objs under srcPath move with it, out of the rules on them
+	if err := checkTreePerm(ctx, srcPath, model.PermRename, model.PermRead|model.PermRename); err != nil {
+		return err
+	}
+	if op.IsSymlink(srcPath) {
+		return errors.WithStack(errs.IsSymlink)
+	}
+	if err := checkPerm(ctx, dstDirPath, model.PermWrite); err != nil {
+		return err
+	}
 	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get src storage")
@@ -33,6 +46,13 @@ func move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) er
 }
 
 func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) error {
+	// the objs under srcPath move with it, out of the rules on them
+	if err := checkTreePerm(ctx, srcPath, model.PermRename, model.PermRead|model.PermRename); err != nil {
+		return err
+	}
+	if op.IsSymlink(srcPath) {
+		return errors.WithStack(errs.IsSymlink)
+	}
 	storage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -41,10 +61,19 @@ func rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 }
 
 func remove(ctx context.Context, path string) error {
+	if err := checkTreePerm(ctx, path, model.PermDelete, model.PermDelete); err != nil {
+		return err
+	}
+	if op.IsSymlink(path) {
+		return errors.WithStack(errs.IsSymlink)
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if op.UsesTrash(storage) && !op.InTrash(actualPath) {
+		return op.MoveToTrash(ctx, storage, actualPath)
+	}
 	return op.Remove(ctx, storage, actualPath)
 }
 
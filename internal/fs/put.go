@@ -3,14 +3,24 @@ package fs
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sync/atomic"
+	"time"
 
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/limit"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 var UploadTaskManager = task.NewTaskManager(3, func(tid *uint64) {
@@ -18,7 +28,7 @@ var UploadTaskManager = task.NewTaskManager(3, func(tid *uint64) {
 })
 
 // putAsTask add as a put task and return immediately
-func putAsTask(dstDirPath string, file *model.FileStream) error {
+func putAsTask(ctx context.Context, dstDirPath string, file *model.FileStream, rule *model.UploadRule) error {
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -26,20 +36,211 @@ func putAsTask(dstDirPath string, file *model.FileStream) error {
 	if storage.Config().NoUpload {
 		return errors.WithStack(errs.UploadNotSupported)
 	}
-	if file.NeedStore() {
-		tempFile, err := utils.CreateTempFile(file)
-		if err != nil {
-			return errors.Wrapf(err, "failed to create temp file")
-		}
-		file.SetReadCloser(tempFile)
+	quotaUser := getQuotaUser(ctx)
+	if err := op.CheckQuota(quotaUser, storage.GetStorage(), file.GetSize()); err != nil {
+		return err
+	}
+	// the task runs after the request ends, so keep only the user of ctx
+	user, _ := ctx.Value("user").(*model.User)
+	ctx = context.WithValue(context.Background(), "user", user)
+	transfer, err := startUpload(ctx, storage, file)
+	if err != nil {
+		return err
+	}
+	if !file.NeedStore() {
+		submitUploadTask(ctx, storage, dstDirPath, dstDirActualPath, file, transfer, quotaUser, rule, nil)
+		return nil
+	}
+	// the limits apply to receiving the file, then the task uploads it
+	// from the stored file
+	f, err := storeUploadFile(file.GetReadCloser())
+	transfer.Done()
+	if err != nil {
+		return errors.Wrapf(err, "failed to store file")
+	}
+	file.SetReadCloser(f)
+	data := uploadTaskData{
+		Storage:    storage.GetStorage().MountPath,
+		DstDirPath: dstDirActualPath,
+		Path:       dstDirPath,
+		Name:       file.GetName(),
+		Size:       file.GetSize(),
+		Modified:   file.ModTime(),
+		Mimetype:   file.GetMimetype(),
+		File:       f.Name(),
+		Creator:    op.UserIDFromCtx(ctx),
+	}
+	if rule != nil {
+		data.Rule = rule.ID
 	}
+	item := &model.TaskItem{Type: uploadTaskType, Name: data.name(), Node: cluster.Node()}
+	item.Data, err = utils.Json.MarshalToString(data)
+	if err == nil {
+		err = db.CreateTaskItem(item)
+	}
+	if err != nil {
+		log.Warnf("failed to persist task %s, it won't be resumed after restart: %+v", item.Name, err)
+		item = nil
+	}
+	submitUploadTask(ctx, storage, dstDirPath, dstDirActualPath, file, nil, quotaUser, rule, item)
+	return nil
+}
+
+func submitUploadTask(ctx context.Context, storage driver.Driver, dstDirPath, dstDirActualPath string, file *model.FileStream,
+	transfer *limit.Transfer, quotaUser *model.User, rule *model.UploadRule, item *model.TaskItem) {
 	UploadTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-		Name: fmt.Sprintf("upload %s to [%s](%s)", file.GetName(), storage.GetStorage().MountPath, dstDirActualPath),
-		Func: func(task *task.Task[uint64]) error {
-			return op.Put(task.Ctx, storage, dstDirActualPath, file, nil, true)
+		Name:    fmt.Sprintf("upload %s to [%s](%s)", file.GetName(), storage.GetStorage().MountPath, dstDirActualPath),
+		Creator: op.UserIDFromCtx(ctx),
+		Func: func(t *task.Task[uint64]) error {
+			if transfer != nil {
+				defer transfer.Done()
+			}
+			err := op.Put(t.Ctx, storage, dstDirActualPath, file, nil, true)
+			if err == nil {
+				recordUpload(quotaUser, storage, file)
+				publishUpload(ctx, dstDirPath, file, rule)
+			}
+			return err
+		},
+		Finally: func(t *task.Task[uint64]) {
+			if item == nil {
+				return
+			}
+			if err := db.DeleteTaskItemById(item.ID); err != nil {
+				log.Errorf("failed to delete persisted task %s: %+v", item.Name, err)
+			}
 		},
 	}))
-	return nil
+}
+
+const uploadTaskType = "upload"
+
+// uploadTaskData is persisted so that upload tasks can be resumed after
+// restart, the received file is kept in uploadTaskDir until it's uploaded
+type uploadTaskData struct {
+	Storage    string `json:"storage"`
+	DstDirPath string `json:"dst_dir_path"`
+	// Path is the full path of the dir, which the events of the upload have
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Mimetype string    `json:"mimetype"`
+	File     string    `json:"file"`
+	// Rule is the id of the upload rule applied, 0 if none
+	Rule    uint `json:"rule"`
+	Creator uint `json:"creator"`
+}
+
+func (d uploadTaskData) name() string {
+	return fmt.Sprintf("upload %s to [%s](%s)", d.Name, d.Storage, d.DstDirPath)
+}
+
+// uploadTaskDir keeps the files of the upload tasks, unlike the temp dir
+// it's not cleared on startup
+func uploadTaskDir() string {
+	return filepath.Join(flags.DataDir, "upload_tasks")
+}
+
+func storeUploadFile(r io.ReadCloser) (*os.File, error) {
+	defer func() {
+		_ = r.Close()
+	}()
+	if err := os.MkdirAll(uploadTaskDir(), 0o777); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(uploadTaskDir(), "file-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.Copy(f, r); err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// restoreUploadTasks resubmits the upload tasks of this instance interrupted
+// by the last shutdown, the files of other instances are not reachable so
+// they are left to them. The files no task refers to are removed.
+func restoreUploadTasks() {
+	items, err := db.GetTaskItemsByType(uploadTaskType)
+	if err != nil {
+		log.Errorf("failed to get persisted tasks: %+v", err)
+		return
+	}
+	files := make(map[string]struct{})
+	for i := range items {
+		item := &items[i]
+		if item.Node != cluster.Node() {
+			continue
+		}
+		var data uploadTaskData
+		err := utils.Json.UnmarshalFromString(item.Data, &data)
+		var storage driver.Driver
+		if err == nil {
+			storage, err = op.GetStorageByMountPath(data.Storage)
+		}
+		var f *os.File
+		if err == nil {
+			f, err = os.Open(data.File)
+		}
+		if err != nil {
+			log.Warnf("failed to restore task %s, drop it: %+v", item.Name, err)
+			_ = db.DeleteTaskItemById(item.ID)
+			_ = os.Remove(data.File)
+			continue
+		}
+		files[filepath.Clean(data.File)] = struct{}{}
+		var user *model.User
+		if data.Creator != 0 {
+			if user, err = op.GetUserById(data.Creator); err != nil {
+				log.Warnf("failed to get the user of task %s: %+v", item.Name, err)
+			}
+		}
+		var rule *model.UploadRule
+		if data.Rule != 0 {
+			if rule, err = op.GetUploadRuleById(data.Rule); err != nil {
+				log.Warnf("failed to get the upload rule of task %s: %+v", item.Name, err)
+			}
+		}
+		file := &model.FileStream{
+			Obj: &model.Object{
+				Name:     data.Name,
+				Size:     data.Size,
+				Modified: data.Modified,
+			},
+			ReadCloser:   f,
+			Mimetype:     data.Mimetype,
+			WebPutAsTask: true,
+		}
+		log.Infof("restore task %s", item.Name)
+		ctx := context.WithValue(context.Background(), "user", user)
+		submitUploadTask(ctx, storage, data.Path, data.DstDirPath, file, nil, user, rule, item)
+	}
+	entries, _ := os.ReadDir(uploadTaskDir())
+	for _, e := range entries {
+		name := filepath.Join(uploadTaskDir(), e.Name())
+		if _, ok := files[filepath.Clean(name)]; !ok {
+			_ = os.Remove(name)
+		}
+	}
+}
+
+// startUpload starts an upload transfer of the user of ctx, the file is
+// throttled by the upload limits of the user and the storage
+func startUpload(ctx context.Context, storage driver.Driver, file *model.FileStream) (*limit.Transfer, error) {
+	user, _ := ctx.Value("user").(*model.User)
+	transfer, err := limit.Start(ctx, user, storage.GetStorage(), limit.Upload, false)
+	if err != nil {
+		return nil, err
+	}
+	file.SetReadCloser(transfer.ReadCloser(ctx, file.GetReadCloser()))
+	return transfer, nil
 }
 
 // putDirect put the file and return after finish
@@ -51,5 +252,69 @@ func putDirectly(ctx context.Context, dstDirPath string, file *model.FileStream,
 	if storage.Config().NoUpload {
 		return errors.WithStack(errs.UploadNotSupported)
 	}
-	return op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...)
+	quotaUser := getQuotaUser(ctx)
+	if err := op.CheckQuota(quotaUser, storage.GetStorage(), file.GetSize()); err != nil {
+		return err
+	}
+	transfer, err := startUpload(ctx, storage, file)
+	if err != nil {
+		return err
+	}
+	defer transfer.Done()
+	ctx = context.WithValue(transfer.WithContext(ctx), quotaCtxKey{}, true)
+	err = op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...)
+	if err == nil {
+		recordUpload(quotaUser, storage, file)
+	}
+	return err
+}
+
+type quotaCtxKey struct{}
+
+type noRulesCtxKey struct{}
+
+// SkipUploadRules makes the uploads with ctx not routed by the upload rules,
+// such as the files written by alist itself
+func SkipUploadRules(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRulesCtxKey{}, true)
+}
+
+// routeUpload returns the dir the file uploaded to dstDirPath is stored to
+// by the upload rules, and the rule applied. The nested uploads (such as to
+// the storage of an alias) are routed by the outer upload already.
+func routeUpload(ctx context.Context, dstDirPath string, file *model.FileStream) (string, *model.UploadRule) {
+	if ctx.Value(quotaCtxKey{}) != nil || ctx.Value(noRulesCtxKey{}) != nil {
+		return dstDirPath, nil
+	}
+	var username string
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		username = user.Username
+	}
+	dir := utils.FixAndCleanPath(dstDirPath)
+	rule, err := op.MatchUploadRule(dir, file.GetName(), file.GetSize(), username)
+	if err != nil {
+		log.Errorf("failed match upload rules of %s: %+v", file.GetName(), err)
+		return dstDirPath, nil
+	}
+	if rule == nil {
+		return dstDirPath, nil
+	}
+	return rule.Target(dir, file.GetName(), username, time.Now()), rule
+}
+
+// getQuotaUser returns the user of ctx whose quota the upload counts
+// against, which is nil for the nested uploads (such as to the storage of
+// an alias) since the outer upload is counted already
+func getQuotaUser(ctx context.Context) *model.User {
+	if ctx.Value(quotaCtxKey{}) != nil {
+		return nil
+	}
+	user, _ := ctx.Value("user").(*model.User)
+	return user
+}
+
+func recordUpload(user *model.User, storage driver.Driver, file *model.FileStream) {
+	if err := op.RecordUpload(user, storage.GetStorage(), file.GetSize()); err != nil {
+		log.Errorf("failed record upload of %s: %+v", file.GetName(), err)
+	}
 }
@@ -0,0 +1,73 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+func TestRestoreUploadTasks(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/uploaded",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	dataDir := flags.DataDir
+	flags.DataDir = t.TempDir()
+	defer func() {
+		flags.DataDir = dataDir
+	}()
+	dir := filepath.Join(flags.DataDir, "upload_tasks")
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "file-1")
+	if err := os.WriteFile(file, []byte("a"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	orphan := filepath.Join(dir, "file-2")
+	if err := os.WriteFile(orphan, []byte("b"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := utils.Json.MarshalToString(map[string]any{
+		"storage":      "/uploaded",
+		"dst_dir_path": "/",
+		"path":         "/uploaded",
+		"name":         "a.txt",
+		"size":         1,
+		"file":         file,
+	})
+	if err := db.CreateTaskItem(&model.TaskItem{Type: "upload", Name: "upload a.txt", Data: data}); err != nil {
+		t.Fatal(err)
+	}
+	fs.RestoreTasks()
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("expect the file of no task is removed, got %+v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if items, _ := db.GetTaskItemsByType("upload"); len(items) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if content, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(content) != "a" {
+		t.Errorf("expect the file is uploaded, got %q: %+v", content, err)
+	}
+	if items, _ := db.GetTaskItemsByType("upload"); len(items) != 0 {
+		t.Errorf("expect the finished task is not persisted, got %+v", items)
+	}
+}
@@ -0,0 +1,42 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// checkTarget checks the meta of the target that path resolves to, since
+// the frontends only check the meta of path itself. The target can only be
+// opened with the password of path, so a symlink into a protected folder
+// needs a meta of the same password. It returns the meta of the target, nil
+// if path is not in a symlink.
+func checkTarget(ctx context.Context, path, resolved string) (*model.Meta, error) {
+	if path == resolved {
+		return nil, nil
+	}
+	user, ok := ctx.Value("user").(*model.User)
+	if !ok {
+		return nil, nil
+	}
+	meta, err := op.GetNearestMeta(resolved)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	// the password of path has been checked by the frontends if it applies
+	var password string
+	if m, ok := ctx.Value("meta").(*model.Meta); ok && m != nil && m.Password != "" && common.IsApply(m.Path, path, m.PSub) {
+		password = m.Password
+	}
+	if !common.CanAccess(user, meta, resolved, password) {
+		return nil, errors.WithStack(errs.PermissionDenied)
+	}
+	return meta, nil
+}
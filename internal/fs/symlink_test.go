@@ -0,0 +1,174 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestSymlink(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/linked",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "deep", "er"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	putString(t, "/linked/deep/er", "a.txt", "a")
+	for _, s := range []model.Symlink{
+		{Path: "/linked/short", Target: "/linked/deep/er"},
+		{Path: "/linked/shorter", Target: "/linked/short/"},
+		{Path: "/linked/broken", Target: "/linked/missing"},
+	} {
+		if err := op.CreateSymlink(&s); err != nil {
+			t.Fatalf("failed create symlink: %+v", err)
+		}
+	}
+	if got := op.ResolveSymlink("/linked/shorter/a.txt"); got != "/linked/deep/er/a.txt" {
+		t.Errorf("expect the symlinks are resolved in turn, got %s", got)
+	}
+
+	ctx := context.WithValue(context.Background(), "meta", (*model.Meta)(nil))
+	ctx = context.WithValue(ctx, "user", &model.User{Role: model.ADMIN})
+	objs, err := fs.List(ctx, "/linked", &fs.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed list: %+v", err)
+	}
+	names := map[string]bool{}
+	for _, obj := range objs {
+		names[obj.GetName()] = obj.IsDir()
+	}
+	if !names["short"] || !names["shorter"] || !names["deep"] {
+		t.Errorf("expect the symlinks are listed as folders, got %v", names)
+	}
+	if _, ok := names["broken"]; ok {
+		t.Errorf("expect the broken symlink is skipped")
+	}
+
+	objs, err = fs.List(ctx, "/linked/short", &fs.ListArgs{})
+	if err != nil || len(objs) != 1 || objs[0].GetName() != "a.txt" {
+		t.Fatalf("expect the target is listed, got %+v: %+v", objs, err)
+	}
+	obj, err := fs.Get(ctx, "/linked/shorter/a.txt", &fs.GetArgs{})
+	if err != nil || obj.GetSize() != 1 {
+		t.Fatalf("expect the file is got through the symlinks, got %+v: %+v", obj, err)
+	}
+	putString(t, "/linked/short", "b.txt", "b")
+	if _, err := os.Stat(filepath.Join(root, "deep", "er", "b.txt")); err != nil {
+		t.Errorf("expect the file is put into the target: %+v", err)
+	}
+
+	if err := fs.Remove(ctx, "/linked/short"); !errors.Is(errors.Cause(err), errs.IsSymlink) {
+		t.Errorf("expect the symlink can't be removed as an object, got %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "deep", "er")); err != nil {
+		t.Errorf("expect the target is kept: %+v", err)
+	}
+
+	// the meta and the acl rules of the target apply through the symlink
+	protected := &model.Meta{Path: "/linked/deep", Password: "pw", PSub: true, Hide: "^hidden", HSub: true}
+	if err := op.CreateMeta(protected); err != nil {
+		t.Fatal(err)
+	}
+	defer op.DeleteMetaById(protected.ID)
+	putString(t, "/linked/deep/er", "hidden.txt", "h")
+	guest := context.WithValue(context.Background(), "user", &model.User{ID: 1000})
+	if _, err := fs.Get(context.WithValue(guest, "meta", (*model.Meta)(nil)), "/linked/short/a.txt", &fs.GetArgs{}); !errors.Is(errors.Cause(err), errs.PermissionDenied) {
+		t.Errorf("expect the password of the target is required, got %+v", err)
+	}
+	guest = context.WithValue(guest, "meta", &model.Meta{Path: "/linked/short", Password: "pw", PSub: true})
+	if _, err := fs.Get(guest, "/linked/short/a.txt", &fs.GetArgs{}); err != nil {
+		t.Errorf("expect the target is opened with the same password: %+v", err)
+	}
+	objs, err = fs.List(guest, "/linked/short", &fs.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed list: %+v", err)
+	}
+	for _, obj := range objs {
+		if obj.GetName() == "hidden.txt" {
+			t.Errorf("expect the hidden objs of the target are hidden")
+		}
+	}
+	rule := &model.ACLRule{Path: "/linked/deep/er", Deny: model.PermRead}
+	if err := op.CreateACLRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	defer op.DeleteACLRuleById(rule.ID)
+	if _, err := fs.Get(guest, "/linked/short/a.txt", &fs.GetArgs{}); !errors.Is(errors.Cause(err), errs.PermissionDenied) {
+		t.Errorf("expect the acl rules of the target apply, got %+v", err)
+	}
+}
+
+func TestSymlinkLoop(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/looped",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	for _, dir := range []string{"x", "y"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	putString(t, "/looped/y", "a.txt", "a")
+	xl := &model.Symlink{Path: "/looped/x/l", Target: "/looped/y"}
+	if err := op.CheckSymlinkLoop(xl); err != nil {
+		t.Fatalf("expect no loop, got %+v", err)
+	}
+	if err := op.CreateSymlink(xl); err != nil {
+		t.Fatalf("failed create symlink: %+v", err)
+	}
+	defer op.DeleteSymlinkById(xl.ID)
+	for _, s := range []model.Symlink{
+		{Path: "/looped/x/up", Target: "/looped"},
+		{Path: "/looped/y/l", Target: "/looped/x"},
+		{Path: "/looped/y", Target: "/looped/x"},
+		{ID: xl.ID, Path: "/looped/x/l", Target: "/looped/x"},
+	} {
+		if err := op.CheckSymlinkLoop(&s); err == nil {
+			t.Errorf("expect %s -> %s makes a loop", s.Path, s.Target)
+		}
+	}
+	if err := op.CheckSymlinkLoop(&model.Symlink{ID: xl.ID, Path: "/looped/x/l", Target: "/looped/y/a.txt"}); err != nil {
+		t.Errorf("expect the updated symlink makes no loop, got %+v", err)
+	}
+
+	// the walkers stop at the loops made before the check
+	yl := &model.Symlink{Path: "/looped/y/l", Target: "/looped/x"}
+	if err := op.CreateSymlink(yl); err != nil {
+		t.Fatalf("failed create symlink: %+v", err)
+	}
+	defer op.DeleteSymlinkById(yl.ID)
+	ctx := context.WithValue(context.Background(), "user", &model.User{Role: model.ADMIN})
+	obj, err := fs.Get(context.WithValue(ctx, "meta", (*model.Meta)(nil)), "/looped", &fs.GetArgs{})
+	if err != nil {
+		t.Fatalf("failed get: %+v", err)
+	}
+	var files int
+	err = fs.WalkFS(ctx, -1, "/looped", obj, func(reqPath string, info model.Obj) error {
+		if !info.IsDir() {
+			files++
+		}
+		return nil
+	})
+	if err != nil || files != 1 {
+		t.Errorf("expect the file is walked once, got %d: %+v", files, err)
+	}
+}
@@ -0,0 +1,322 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var SyncTaskManager = task.NewTaskManager(1, func(tid *uint64) {
+	atomic.AddUint64(tid, 1)
+})
+
+// the operations of the sync actions
+const (
+	SyncCopy   = "copy"
+	SyncMkdir  = "mkdir"
+	SyncDelete = "delete"
+	SyncList   = "list"
+)
+
+// SyncAction is a change made by a sync, or to be made by a dry run. Src
+// and Dst are the paths in alist.
+type SyncAction struct {
+	Op    string `json:"op"`
+	Src   string `json:"src,omitempty"`
+	Dst   string `json:"dst"`
+	Error string `json:"error,omitempty"`
+}
+
+type SyncReport struct {
+	DryRun    bool         `json:"dry_run"`
+	Actions   []SyncAction `json:"actions"`
+	Unchanged int          `json:"unchanged"`
+	Failed    int          `json:"failed"`
+}
+
+func (r *SyncReport) String() string {
+	counts := make(map[string]int)
+	for _, a := range r.Actions {
+		if a.Error == "" {
+			counts[a.Op]++
+		}
+	}
+	s := fmt.Sprintf("copied %d, created %d dirs, deleted %d, unchanged %d, failed %d",
+		counts[SyncCopy], counts[SyncMkdir], counts[SyncDelete], r.Unchanged, r.Failed)
+	if r.DryRun {
+		s = "[dry run] " + s
+	}
+	return s
+}
+
+type syncSide struct {
+	storage driver.Driver
+	// path is the path in alist, actual is the one in the storage
+	path   string
+	actual string
+}
+
+func newSyncSide(path string) (*syncSide, error) {
+	path = utils.FixAndCleanPath(path)
+	storage, actual, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get storage of %s", path)
+	}
+	return &syncSide{storage: storage, path: path, actual: actual}, nil
+}
+
+func (s *syncSide) pathOf(rel string) string {
+	return stdpath.Join(s.path, rel)
+}
+
+func (s *syncSide) actualOf(rel string) string {
+	return stdpath.Join(s.actual, rel)
+}
+
+type syncer struct {
+	ctx      context.Context
+	job      *model.SyncJob
+	src, dst *syncSide
+	include  []string
+	exclude  []string
+	dryRun   bool
+	status   func(string)
+	report   *SyncReport
+}
+
+// Sync syncs the paths of the job, the files are not touched but the
+// actions are reported only if dryRun is true. The errors of the actions
+// are in the report, so that one failed file doesn't stop the others.
+func Sync(ctx context.Context, job *model.SyncJob, dryRun bool, status func(string)) (*SyncReport, error) {
+	if job.Mode != "" && job.Mode != model.SyncOneWay && job.Mode != model.SyncTwoWay {
+		return nil, errors.Errorf("unknown sync mode: %s", job.Mode)
+	}
+	src, err := newSyncSide(job.Src)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := newSyncSide(job.Dst)
+	if err != nil {
+		return nil, err
+	}
+	// the folders are walked in the storages, so only the symlinks in the
+	// paths of the sides may make them contain each other
+	srcResolved, dstResolved := op.ResolveSymlink(src.path), op.ResolveSymlink(dst.path)
+	if utils.IsSubPath(srcResolved, dstResolved) || utils.IsSubPath(dstResolved, srcResolved) {
+		return nil, errors.New("the source and the destination can't contain each other")
+	}
+	if status == nil {
+		status = func(string) {}
+	}
+	s := &syncer{
+		ctx:     ctx,
+		job:     job,
+		src:     src,
+		dst:     dst,
+		include: splitPatterns(job.Include),
+		exclude: splitPatterns(job.Exclude),
+		dryRun:  dryRun,
+		status:  status,
+		report:  &SyncReport{DryRun: dryRun, Actions: []SyncAction{}},
+	}
+	srcObj, err := op.Get(ctx, src.storage, src.actual)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get source %s", src.path)
+	}
+	if !srcObj.IsDir() {
+		return nil, errors.Errorf("the source %s is not a folder", src.path)
+	}
+	_, err = op.Get(ctx, dst.storage, dst.actual)
+	dstExists := err == nil
+	if !dstExists && !s.mkdir(dst, "") {
+		return s.report, nil
+	}
+	if err := s.syncDir("", true, dstExists); err != nil {
+		return s.report, err
+	}
+	return s.report, nil
+}
+
+func splitPatterns(s string) []string {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+func matchPatterns(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := stdpath.Match(p, stdpath.Base(rel)); ok {
+			return true
+		}
+		if ok, _ := stdpath.Match(strings.TrimPrefix(p, "/"), rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filtered reports whether the obj of rel is skipped, the include patterns
+// only apply to files so that the files in the folders can be matched
+func (s *syncer) filtered(rel string, isDir bool) bool {
+	if matchPatterns(s.exclude, rel) {
+		return true
+	}
+	return !isDir && len(s.include) > 0 && !matchPatterns(s.include, rel)
+}
+
+// do runs the action unless it's a dry run, and reports it
+func (s *syncer) do(opName, src, dst string, f func() error) bool {
+	a := SyncAction{Op: opName, Src: src, Dst: dst}
+	if !s.dryRun {
+		if err := f(); err != nil {
+			a.Error = err.Error()
+			s.report.Failed++
+		}
+	}
+	s.report.Actions = append(s.report.Actions, a)
+	return a.Error == ""
+}
+
+func (s *syncer) list(side *syncSide, rel string) (map[string]model.Obj, []model.Obj, bool) {
+	objs, err := op.List(s.ctx, side.storage, side.actualOf(rel), model.ListArgs{}, true)
+	if err != nil {
+		s.report.Actions = append(s.report.Actions, SyncAction{Op: SyncList, Dst: side.pathOf(rel), Error: err.Error()})
+		s.report.Failed++
+		return nil, nil, false
+	}
+	m := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		m[obj.GetName()] = obj
+	}
+	return m, objs, true
+}
+
+// syncDir syncs the folder of rel, the folder is not listed on the side it
+// doesn't exist or is just created
+func (s *syncer) syncDir(rel string, srcExists, dstExists bool) error {
+	if utils.IsCanceled(s.ctx) {
+		return s.ctx.Err()
+	}
+	s.status("syncing " + s.src.pathOf(rel))
+	var srcMap, dstMap map[string]model.Obj
+	var srcObjs, dstObjs []model.Obj
+	var ok bool
+	if srcExists {
+		if srcMap, srcObjs, ok = s.list(s.src, rel); !ok {
+			return nil
+		}
+	}
+	if dstExists {
+		if dstMap, dstObjs, ok = s.list(s.dst, rel); !ok {
+			return nil
+		}
+	}
+	twoWay := s.job.Mode == model.SyncTwoWay
+	for _, obj := range srcObjs {
+		name := obj.GetName()
+		objRel := stdpath.Join(rel, name)
+		if s.filtered(objRel, obj.IsDir()) {
+			continue
+		}
+		d := dstMap[name]
+		if d != nil && d.IsDir() != obj.IsDir() {
+			s.conflict(objRel)
+			continue
+		}
+		if obj.IsDir() {
+			// the new folder is empty, no need to list it
+			if d == nil && !s.mkdir(s.dst, objRel) {
+				continue
+			}
+			if err := s.syncDir(objRel, true, d != nil); err != nil {
+				return err
+			}
+			continue
+		}
+		switch {
+		case d == nil:
+			s.copy(s.src, s.dst, objRel)
+		case !s.differs(obj, d, twoWay):
+			s.report.Unchanged++
+		case twoWay && d.ModTime().After(obj.ModTime()):
+			s.copy(s.dst, s.src, objRel)
+		default:
+			s.copy(s.src, s.dst, objRel)
+		}
+	}
+	for _, obj := range dstObjs {
+		name := obj.GetName()
+		objRel := stdpath.Join(rel, name)
+		if srcMap[name] != nil || s.filtered(objRel, obj.IsDir()) {
+			continue
+		}
+		switch {
+		case twoWay && obj.IsDir():
+			if !s.mkdir(s.src, objRel) {
+				continue
+			}
+			if err := s.syncDir(objRel, false, true); err != nil {
+				return err
+			}
+		case twoWay:
+			s.copy(s.dst, s.src, objRel)
+		case s.job.Delete:
+			s.do(SyncDelete, "", s.dst.pathOf(objRel), func() error {
+				return op.Remove(s.ctx, s.dst.storage, s.dst.actualOf(objRel))
+			})
+		}
+	}
+	return nil
+}
+
+// differs reports whether the file is changed. The newer source is taken
+// as changed in one way sync, but not in two way sync, otherwise the files
+// would be copied back and forth since the copies are always newer.
+func (s *syncer) differs(src, dst model.Obj, twoWay bool) bool {
+	if src.GetSize() != dst.GetSize() {
+		return true
+	}
+	dstHashes := model.GetHashes(dst)
+	for typ, h := range model.GetHashes(src) {
+		if dh, ok := dstHashes[typ]; ok && h != "" && dh != "" {
+			return !strings.EqualFold(h, dh)
+		}
+	}
+	return !twoWay && src.ModTime().After(dst.ModTime())
+}
+
+func (s *syncer) conflict(rel string) {
+	s.report.Actions = append(s.report.Actions, SyncAction{
+		Op:    SyncCopy,
+		Src:   s.src.pathOf(rel),
+		Dst:   s.dst.pathOf(rel),
+		Error: "a file and a folder have the same name",
+	})
+	s.report.Failed++
+}
+
+func (s *syncer) mkdir(side *syncSide, rel string) bool {
+	return s.do(SyncMkdir, "", side.pathOf(rel), func() error {
+		return op.MakeDir(s.ctx, side.storage, side.actualOf(rel))
+	})
+}
+
+func (s *syncer) copy(from, to *syncSide, rel string) {
+	s.do(SyncCopy, from.pathOf(rel), to.pathOf(rel), func() error {
+		_, err := copyFile(s.ctx, from.storage, to.storage, from.actualOf(rel), stdpath.Dir(to.actualOf(rel)), nil)
+		return err
+	})
+}
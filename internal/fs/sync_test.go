@@ -0,0 +1,107 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/alist-org/alist/v3/drivers/local"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig()
+	db.Init(dB)
+}
+
+// setupSyncStorages mounts two local storages at /src and /dst
+func setupSyncStorages(t *testing.T) (string, string) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	for i, mount := range []string{"/src", "/dst"} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mount,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, dirs[i]),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+	}
+	return dirs[0], dirs[1]
+}
+
+func writeFile(t *testing.T, name, content string) {
+	if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	src, dst := setupSyncStorages(t)
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	writeFile(t, filepath.Join(src, "sub", "b.txt"), "bb")
+	writeFile(t, filepath.Join(src, "skip.log"), "log")
+	writeFile(t, filepath.Join(dst, "old.txt"), "old")
+	job := &model.SyncJob{Src: "/src", Dst: "/dst", Mode: model.SyncOneWay, Delete: true, Exclude: "*.log"}
+
+	report, err := fs.Sync(context.Background(), job, true, nil)
+	if err != nil {
+		t.Fatalf("failed dry run: %+v", err)
+	}
+	if len(report.Actions) != 4 {
+		t.Errorf("expect copy a.txt, mkdir sub, copy sub/b.txt and delete old.txt, got %+v", report.Actions)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect nothing is copied by the dry run")
+	}
+
+	if report, err = fs.Sync(context.Background(), job, false, nil); err != nil || report.Failed > 0 {
+		t.Fatalf("failed sync: %+v %+v", err, report)
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); err != nil || string(data) != "bb" {
+		t.Errorf("expect sub/b.txt is copied, got %q: %+v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "skip.log")); !os.IsNotExist(err) {
+		t.Errorf("expect the excluded file is not copied")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect the extra file is deleted")
+	}
+
+	report, err = fs.Sync(context.Background(), job, true, nil)
+	if err != nil || len(report.Actions) != 0 || report.Unchanged != 2 {
+		t.Errorf("expect nothing to do after sync, got %+v: %+v", report, err)
+	}
+
+	// two way sync copies the newer file back
+	job.Mode = model.SyncTwoWay
+	writeFile(t, filepath.Join(dst, "a.txt"), "changed")
+	future := time.Now().Add(time.Hour)
+	_ = os.Chtimes(filepath.Join(dst, "a.txt"), future, future)
+	writeFile(t, filepath.Join(dst, "new", "c.txt"), "c")
+	if report, err = fs.Sync(context.Background(), job, false, nil); err != nil || report.Failed > 0 {
+		t.Fatalf("failed two way sync: %+v %+v", err, report)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "a.txt")); string(data) != "changed" {
+		t.Errorf("expect the newer a.txt is copied back, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "new", "c.txt")); string(data) != "c" {
+		t.Errorf("expect new/c.txt is copied back, got %q", data)
+	}
+}
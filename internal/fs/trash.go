@@ -0,0 +1,91 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListTrash lists the items in the recycle bin of the storage of the path
+// that the user can read, the paths of the items are the full paths
+func ListTrash(ctx context.Context, path string) ([]model.TrashItem, error) {
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	items, err := op.ListTrash(ctx, storage)
+	if err != nil {
+		return nil, err
+	}
+	res := items[:0]
+	for _, item := range items {
+		item.Path = stdpath.Join(storage.GetStorage().MountPath, item.Path)
+		if checkPerm(ctx, item.Path, model.PermRead) == nil {
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+// GetTrashItem gets the item in the recycle bin of the storage of the path,
+// the path of the item is the full path
+func GetTrashItem(ctx context.Context, path, id, name string) (*model.TrashItem, error) {
+	storage, _, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	item, err := op.GetTrashItem(ctx, storage, id, name)
+	if err != nil {
+		return nil, err
+	}
+	item.Path = stdpath.Join(storage.GetStorage().MountPath, item.Path)
+	return item, nil
+}
+
+// RestoreTrash moves the item back to its original path, which needs the
+// write permission
+func RestoreTrash(ctx context.Context, path, id, name string) error {
+	item, err := GetTrashItem(ctx, path, id, name)
+	if err != nil {
+		return err
+	}
+	if err := checkPerm(ctx, item.Path, model.PermWrite); err != nil {
+		return err
+	}
+	storage, _, err := op.GetStorageAndActualPath(item.Path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err := op.RestoreTrash(ctx, storage, id, name); err != nil {
+		log.Errorf("failed restore %s from recycle bin: %+v", item.Path, err)
+		return err
+	}
+	publish(ctx, event.FileUpload, map[string]any{"path": item.Path, "trash": id})
+	return nil
+}
+
+// DeleteTrash deletes the item in the recycle bin permanently, which needs
+// the delete permission
+func DeleteTrash(ctx context.Context, path, id, name string) error {
+	item, err := GetTrashItem(ctx, path, id, name)
+	if err != nil {
+		return err
+	}
+	if err := checkPerm(ctx, item.Path, model.PermDelete); err != nil {
+		return err
+	}
+	storage, _, err := op.GetStorageAndActualPath(item.Path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err := op.DeleteTrash(ctx, storage, id, name); err != nil {
+		log.Errorf("failed delete %s from recycle bin: %+v", item.Path, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestTrash(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/trashed",
+		Trash:     true,
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	ctx := context.Background()
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	putString(t, "/trashed/dir", "a.txt", "a")
+	if err := fs.Remove(ctx, "/trashed/dir/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "dir", "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expect the file is removed, got %+v", err)
+	}
+	items, err := fs.ListTrash(ctx, "/trashed")
+	if err != nil {
+		t.Fatalf("failed list trash: %+v", err)
+	}
+	if len(items) != 1 || items[0].Path != "/trashed/dir/a.txt" || items[0].Size != 1 {
+		t.Fatalf("expect the removed file in the trash, got %+v", items)
+	}
+
+	listCtx := context.WithValue(ctx, "meta", (*model.Meta)(nil))
+	listCtx = context.WithValue(listCtx, "user", &model.User{Role: model.ADMIN})
+	objs, err := fs.List(listCtx, "/trashed", &fs.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed list: %+v", err)
+	}
+	for _, obj := range objs {
+		if obj.GetName() == op.TrashDir {
+			t.Errorf("expect the trash dir is hidden")
+		}
+	}
+
+	// the folder is made again on restoring
+	if err := os.Remove(filepath.Join(root, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.RestoreTrash(ctx, "/trashed", items[0].ID, items[0].Name); err != nil {
+		t.Fatalf("failed restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "dir", "a.txt")); err != nil || string(data) != "a" {
+		t.Errorf("expect the file is restored, got %q: %+v", data, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, op.TrashDir)); len(entries) != 0 {
+		t.Errorf("expect the empty trash dir is removed, got %d entries", len(entries))
+	}
+
+	if err := fs.Remove(ctx, "/trashed/dir"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	items, err = fs.ListTrash(ctx, "/trashed")
+	if err != nil || len(items) != 1 || !items[0].IsDir {
+		t.Fatalf("expect the removed dir in the trash, got %+v: %+v", items, err)
+	}
+	if err := fs.DeleteTrash(ctx, "/trashed", items[0].ID, "../dir"); err == nil {
+		t.Errorf("expect the invalid name is rejected")
+	}
+	if err := fs.DeleteTrash(ctx, "/trashed", items[0].ID, items[0].Name); err != nil {
+		t.Fatalf("failed delete from trash: %+v", err)
+	}
+	if items, err = fs.ListTrash(ctx, "/trashed"); err != nil || len(items) != 0 {
+		t.Errorf("expect the trash is empty, got %+v: %+v", items, err)
+	}
+}
+
+func TestDriverTrash(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/binned",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q,"recycle_bin_path":".bin"}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	ctx := context.Background()
+	putString(t, "/binned", "a.txt", "a")
+	if err := fs.Remove(ctx, "/binned/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	items, err := fs.ListTrash(ctx, "/binned")
+	if err != nil {
+		t.Fatalf("failed list trash: %+v", err)
+	}
+	if len(items) != 1 || items[0].Path != "/binned/a.txt" || items[0].Name != "a.txt" {
+		t.Fatalf("expect the removed file in the recycle bin of the driver, got %+v", items)
+	}
+	if err := fs.RestoreTrash(ctx, "/binned", items[0].ID, "b.txt"); err == nil {
+		t.Errorf("expect the item is not found by another name")
+	}
+	if err := fs.RestoreTrash(ctx, "/binned", items[0].ID, items[0].Name); err != nil {
+		t.Fatalf("failed restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "a" {
+		t.Errorf("expect the file is restored, got %q: %+v", data, err)
+	}
+}
@@ -0,0 +1,43 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestUploadRules(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/routed",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	rule := model.UploadRule{
+		Name:       "images",
+		Extensions: "jpg,png",
+		SrcPath:    "/routed/in",
+		DstPath:    "/routed/images/{ext}",
+	}
+	if err := op.CreateUploadRule(&rule); err != nil {
+		t.Fatalf("failed create rule: %+v", err)
+	}
+	defer op.DeleteUploadRuleById(rule.ID)
+
+	putString(t, "/routed/in", "a.PNG", "a")
+	putString(t, "/routed/in", "b.txt", "b")
+	putString(t, "/routed/other", "c.jpg", "c")
+	for _, name := range []string{"images/png/a.PNG", "in/b.txt", "other/c.jpg"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("expect %s: %v", name, err)
+		}
+	}
+}
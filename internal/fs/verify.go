@@ -0,0 +1,197 @@
+package fs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+// modes of the copy verify setting
+const (
+	verifyOff       = "off"
+	verifyReported  = "reported"
+	verifyRecompute = "recompute"
+)
+
+// hashReader computes the checksums of the data while it is uploaded
+type hashReader struct {
+	rc     io.ReadCloser
+	w      io.Writer
+	hashes map[string]hash.Hash
+	n      int64
+}
+
+// newHashReader computes md5 and the checksums reported by the source
+func newHashReader(rc io.ReadCloser, reported map[string]string) *hashReader {
+	r := &hashReader{rc: rc, hashes: map[string]hash.Hash{model.HashMD5: md5.New()}}
+	for typ := range reported {
+		if n, ok := model.HashNews[typ]; ok && r.hashes[typ] == nil {
+			r.hashes[typ] = n()
+		}
+	}
+	ws := make([]io.Writer, 0, len(r.hashes))
+	for _, h := range r.hashes {
+		ws = append(ws, h)
+	}
+	r.w = io.MultiWriter(ws...)
+	return r
+}
+
+func (r *hashReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if n > 0 {
+		_, _ = r.w.Write(p[:n])
+		r.n += int64(n)
+	}
+	return n, err
+}
+
+func (r *hashReader) Close() error {
+	return r.rc.Close()
+}
+
+func (r *hashReader) sums() map[string]string {
+	sums := make(map[string]string, len(r.hashes))
+	for typ, h := range r.hashes {
+		sums[typ] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums
+}
+
+// verification is the result of verifying a copied file
+type verification struct {
+	// checked are the checksums that matched
+	checked []string
+	mode    string
+}
+
+func (v verification) Verified() bool {
+	return len(v.checked) > 0
+}
+
+func (v verification) String() string {
+	if v.mode == verifyOff {
+		return "copied, verification is off"
+	}
+	if !v.Verified() {
+		return "copied, not verified: no checksum to compare"
+	}
+	return "copied, verified " + strings.Join(v.checked, ", ")
+}
+
+func mismatch(name, of, want, got string) error {
+	return errors.Wrapf(errs.ChecksumMismatch, "%s of %s is %s, expect %s", name, of, got, want)
+}
+
+// verifyCopy compares the data uploaded with the checksums reported by the
+// source, then the checksums reported by the destination with both of
+// them. If nothing can be compared and recompute is set, the copied file
+// is downloaded again to compute its md5.
+func verifyCopy(ctx context.Context, r *hashReader, srcFile model.Obj, dstStorage driver.Driver, dstPath, mode string) (verification, error) {
+	v := verification{mode: mode}
+	// the stream may not be fully read, such as the rapid upload of some
+	// drivers, then only the reported checksums are comparable
+	computed := map[string]string{}
+	if r.n == srcFile.GetSize() {
+		computed = r.sums()
+	} else if r.n > srcFile.GetSize() {
+		return v, mismatch("size", "source", strconv.FormatInt(srcFile.GetSize(), 10), strconv.FormatInt(r.n, 10))
+	}
+	srcHashes := model.GetHashes(srcFile)
+	for typ, want := range srcHashes {
+		got, ok := computed[typ]
+		if !ok || want == "" {
+			continue
+		}
+		if !strings.EqualFold(got, want) {
+			return v, mismatch(typ, "source", want, got)
+		}
+		v.checked = append(v.checked, typ+" with source")
+	}
+	dstObj, err := getCopied(ctx, dstStorage, dstPath)
+	if err != nil {
+		return v, errors.WithMessage(err, "failed get the copied file")
+	}
+	if dstObj.GetSize() != srcFile.GetSize() {
+		return v, mismatch("size", "destination", strconv.FormatInt(srcFile.GetSize(), 10), strconv.FormatInt(dstObj.GetSize(), 10))
+	}
+	for typ, got := range model.GetHashes(dstObj) {
+		want, ok := computed[typ]
+		if !ok {
+			want = srcHashes[typ]
+		}
+		if want == "" || got == "" {
+			continue
+		}
+		if !strings.EqualFold(got, want) {
+			return v, mismatch(typ, "destination", want, got)
+		}
+		v.checked = append(v.checked, typ+" with destination")
+	}
+	if v.Verified() || mode != verifyRecompute {
+		return v, nil
+	}
+	want, ok := computed[model.HashMD5]
+	if !ok {
+		want = srcHashes[model.HashMD5]
+	}
+	if want == "" {
+		return v, nil
+	}
+	got, err := hashCopied(ctx, dstStorage, dstPath, dstObj)
+	if err != nil {
+		return v, errors.WithMessage(err, "failed recompute md5 of the copied file")
+	}
+	if !strings.EqualFold(got, want) {
+		return v, mismatch(model.HashMD5, "destination", want, got)
+	}
+	v.checked = append(v.checked, "md5 recomputed from destination")
+	return v, nil
+}
+
+// getCopied gets the copied file, the list cache of the dir may not
+// contain it since the file is put with lazy cache
+func getCopied(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	obj, err := op.Get(ctx, storage, path)
+	if errs.IsObjectNotFound(err) {
+		op.ClearCache(storage, stdpath.Dir(path))
+		obj, err = op.Get(ctx, storage, path)
+	}
+	return obj, err
+}
+
+// hashCopied downloads the file from the storage and returns its md5
+func hashCopied(ctx context.Context, storage driver.Driver, path string, obj model.Obj) (string, error) {
+	link, _, err := op.Link(ctx, storage, path, model.LinkArgs{})
+	if err != nil {
+		return "", err
+	}
+	stream, err := getFileStreamFromLink(obj, link)
+	if err != nil {
+		return "", err
+	}
+	rc := stream.GetReadCloser()
+	defer func() {
+		_ = rc.Close()
+		if f, ok := rc.(*os.File); ok {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	h := md5.New()
+	if _, err = io.Copy(h, rc); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,64 @@
+package fs
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ListVersions lists the previous versions of the file, the latest first
+func ListVersions(ctx context.Context, path string) ([]model.FileVersion, error) {
+	if err := checkPerm(ctx, path, model.PermRead); err != nil {
+		return nil, err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	return op.ListVersions(ctx, storage, actualPath)
+}
+
+func LinkVersion(ctx context.Context, path, version string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	if err := checkPerm(ctx, path, model.PermRead); err != nil {
+		return nil, nil, err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed get storage")
+	}
+	l, obj, err := op.LinkVersion(ctx, storage, actualPath, version, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l.URL != "" && !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
+		if c, ok := ctx.Value(gin.ContextKey).(*gin.Context); ok {
+			l.URL = common.GetApiUrl(c.Request) + l.URL
+		}
+	}
+	return l, obj, nil
+}
+
+// RestoreVersion makes the version the current content of the file, which
+// needs the write permission
+func RestoreVersion(ctx context.Context, path, version string) error {
+	if err := checkPerm(ctx, path, model.PermWrite); err != nil {
+		return err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err := op.RestoreVersion(ctx, storage, actualPath, version); err != nil {
+		log.Errorf("failed restore version %s of %s: %+v", version, path, err)
+		return err
+	}
+	publish(ctx, event.FileUpload, map[string]any{"path": path, "version": version})
+	return nil
+}
@@ -0,0 +1,87 @@
+package fs_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func putString(t *testing.T, dir, name, content string) {
+	err := fs.PutDirectly(context.Background(), dir, &model.FileStream{
+		Obj:        &model.Object{Name: name, Size: int64(len(content)), Modified: time.Now()},
+		ReadCloser: io.NopCloser(strings.NewReader(content)),
+	})
+	if err != nil {
+		t.Fatalf("failed put %s: %+v", name, err)
+	}
+	// the versions are named by the time in milliseconds
+	time.Sleep(time.Millisecond * 5)
+}
+
+func TestEmulatedVersions(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:       "Local",
+		MountPath:    "/versioned",
+		KeepVersions: 2,
+		Addition:     fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	for _, content := range []string{"v1", "v2", "v3", "v4"} {
+		putString(t, "/versioned", "a.txt", content)
+	}
+	ctx := context.Background()
+	versions, err := fs.ListVersions(ctx, "/versioned/a.txt")
+	if err != nil {
+		t.Fatalf("failed list versions: %+v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expect the 2 latest versions are kept, got %+v", versions)
+	}
+	data, err := os.ReadFile(filepath.Join(root, op.VersionsDir, "a.txt", versions[0].ID))
+	if err != nil || string(data) != "v3" {
+		t.Errorf("expect the latest version is v3, got %q: %+v", data, err)
+	}
+
+	listCtx := context.WithValue(ctx, "meta", (*model.Meta)(nil))
+	listCtx = context.WithValue(listCtx, "user", &model.User{Role: model.ADMIN})
+	objs, err := fs.List(listCtx, "/versioned", &fs.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed list: %+v", err)
+	}
+	for _, obj := range objs {
+		if obj.GetName() == op.VersionsDir {
+			t.Errorf("expect the versions dir is hidden")
+		}
+	}
+
+	if err := fs.RestoreVersion(ctx, "/versioned/a.txt", versions[1].ID); err != nil {
+		t.Fatalf("failed restore version: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "v2" {
+		t.Errorf("expect v2 is restored, got %q: %+v", data, err)
+	}
+	versions, err = fs.ListVersions(ctx, "/versioned/a.txt")
+	if err != nil {
+		t.Fatalf("failed list versions: %+v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(root, op.VersionsDir, "a.txt", versions[0].ID))
+	if len(versions) != 2 || err != nil || string(data) != "v4" {
+		t.Errorf("expect the replaced v4 is kept as the latest version, got %+v %q: %+v", versions, data, err)
+	}
+
+	if err := fs.RestoreVersion(ctx, "/versioned/a.txt", "../a.txt"); err == nil {
+		t.Errorf("expect the invalid version is rejected")
+	}
+}
@@ -14,7 +14,12 @@ import (
 // WalkFS will stop when current depth > `depth`. For each visited node,
 // WalkFS calls walkFn. If a visited file system node is a directory and
 // walkFn returns path.SkipDir, walkFS will skip traversal of this node.
+// The folders reached again through symlinks are visited but not traversed.
 func WalkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn func(reqPath string, info model.Obj) error) error {
+	return walkFS(ctx, depth, name, info, walkFn, op.WalkedDirs{})
+}
+
+func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn func(reqPath string, info model.Obj) error, walked op.WalkedDirs) error {
 	// This implementation is based on Walk's code in the standard path/path package.
 	walkFnErr := walkFn(name, info)
 	if walkFnErr != nil {
@@ -23,7 +28,7 @@ func WalkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 		}
 		return walkFnErr
 	}
-	if !info.IsDir() || depth == 0 {
+	if !info.IsDir() || depth == 0 || !walked.Visit(name) {
 		return nil
 	}
 	meta, _ := op.GetNearestMeta(name)
@@ -34,7 +39,7 @@ func WalkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 	}
 	for _, fileInfo := range objs {
 		filename := path.Join(name, fileInfo.GetName())
-		if err := WalkFS(ctx, depth-1, filename, fileInfo, walkFn); err != nil {
+		if err := walkFS(ctx, depth-1, filename, fileInfo, walkFn, walked); err != nil {
 			if err == filepath.SkipDir {
 				break
 			}
@@ -0,0 +1,142 @@
+package hls
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var errTooManyTranscodes = errors.New("too many concurrent transcodes")
+
+var (
+	runningMu sync.Mutex
+	running   = make(map[uint]int)
+	cleaning  atomic.Bool
+)
+
+func cacheDir() string {
+	return filepath.Join(flags.DataDir, "hls")
+}
+
+// acquire takes a transcode slot of the user, waiting for a free one if
+// wait is true
+func acquire(ctx context.Context, userID uint, wait bool) error {
+	for {
+		runningMu.Lock()
+		max := setting.GetInt(conf.HlsMaxTranscodes, 2)
+		if max <= 0 || running[userID] < max {
+			running[userID]++
+			runningMu.Unlock()
+			return nil
+		}
+		runningMu.Unlock()
+		if !wait {
+			return errTooManyTranscodes
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func release(userID uint) {
+	runningMu.Lock()
+	defer runningMu.Unlock()
+	if running[userID] <= 1 {
+		delete(running, userID)
+	} else {
+		running[userID]--
+	}
+}
+
+// openInput returns the input of ffmpeg for the video and the headers to
+// request it with, ffmpeg seeks so the streams of the links are not
+// supported
+func openInput(ctx context.Context, path string) (string, string, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return "", "", err
+	}
+	if link.Data != nil {
+		_ = link.Data.Close()
+		return "", "", errors.New("the storage of the video can't be transcoded")
+	}
+	if link.FilePath != nil {
+		return *link.FilePath, "", nil
+	}
+	var headers strings.Builder
+	for k, vals := range link.Header {
+		for _, v := range vals {
+			headers.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+	return link.URL, headers.String(), nil
+}
+
+type cachedVideo struct {
+	dir    string
+	size   int64
+	usedAt time.Time
+}
+
+// clean drops the least recently played videos until the cache fits in
+// the size of the setting, the latest one is kept as it's being played
+func clean() {
+	if !cleaning.CompareAndSwap(false, true) {
+		return
+	}
+	defer cleaning.Store(false)
+	limit := int64(setting.GetInt(conf.HlsCacheSize, 2048)) * 1024 * 1024
+	entries, err := os.ReadDir(cacheDir())
+	if err != nil {
+		return
+	}
+	var videos []cachedVideo
+	var total int64
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		v := cachedVideo{dir: filepath.Join(cacheDir(), e.Name()), usedAt: info.ModTime()}
+		segments, _ := os.ReadDir(v.dir)
+		for _, s := range segments {
+			if si, err := s.Info(); err == nil {
+				v.size += si.Size()
+			}
+		}
+		total += v.size
+		videos = append(videos, v)
+	}
+	sort.Slice(videos, func(i, j int) bool {
+		return videos[i].usedAt.Before(videos[j].usedAt)
+	})
+	for i, v := range videos {
+		if total <= limit || i == len(videos)-1 {
+			break
+		}
+		if err := os.RemoveAll(v.dir); err != nil {
+			log.Warnf("failed remove cached hls segments %s: %+v", v.dir, err)
+			continue
+		}
+		total -= v.size
+	}
+}
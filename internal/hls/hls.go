@@ -0,0 +1,213 @@
+// Package hls transcodes the videos that browsers can't play to HLS with
+// ffmpeg on the fly.
+//
+// The playlist is made from the duration of the video, and each segment is
+// transcoded from its start time on the first request, so that seeking only
+// transcodes the segments played. The segments are cached in the data dir
+// until the cache is full, the least recently played videos are dropped
+// first.
+package hls
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/media"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SegmentDuration is the seconds of a segment
+const SegmentDuration = 6
+
+var (
+	segmentG singleflight.Group[string]
+
+	ffmpegOnce sync.Once
+	ffmpegPath string
+)
+
+func hasFFmpeg() bool {
+	ffmpegOnce.Do(func() {
+		ffmpegPath, _ = exec.LookPath("ffmpeg")
+	})
+	return ffmpegPath != ""
+}
+
+// Enabled reports whether the transcoding is enabled and ffmpeg is
+// installed
+func Enabled() bool {
+	return setting.GetBool(conf.HlsTranscode) && hasFFmpeg()
+}
+
+// Transcodable reports whether the file of name is transcoded, by the types
+// of the setting
+func Transcodable(name string) bool {
+	ext := strings.ToLower(utils.Ext(name))
+	for _, t := range strings.Split(setting.GetStr(conf.HlsTranscodeTypes), ",") {
+		if ext != "" && strings.TrimSpace(t) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// Video is a video to be played through the transcoding
+type Video struct {
+	Path string
+	// Key changes whenever the video changes
+	Key      string
+	Duration float64
+}
+
+// Segments returns the number of the segments
+func (v *Video) Segments() int {
+	return int(math.Ceil(v.Duration / SegmentDuration))
+}
+
+// span returns the start and the duration of the segment i
+func (v *Video) span(i int) (float64, float64) {
+	start := float64(i * SegmentDuration)
+	return start, math.Min(SegmentDuration, v.Duration-start)
+}
+
+// Open reads the duration of the video at path, which is cached with the
+// media infos
+func Open(ctx context.Context, path string) (*Video, error) {
+	obj, err := fs.Get(ctx, path, &fs.GetArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if obj.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	if !Transcodable(obj.GetName()) {
+		return nil, errors.Errorf("%s isn't transcoded", obj.GetName())
+	}
+	info, err := media.Get(ctx, path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed read duration")
+	}
+	if info.Duration <= 0 {
+		return nil, errors.Errorf("the duration of %s is unknown", obj.GetName())
+	}
+	return &Video{
+		Path:     path,
+		Key:      utils.GetMD5Encode(fmt.Sprintf("%s-%d-%d", path, obj.GetSize(), obj.ModTime().Unix())),
+		Duration: info.Duration,
+	}, nil
+}
+
+// Playlist returns the m3u8 of the video, segmentURL returns the url of the
+// segment i
+func (v *Video) Playlist(segmentURL func(i int) string) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", SegmentDuration)
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+	for i := 0; i < v.Segments(); i++ {
+		_, d := v.span(i)
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%s\n", d, segmentURL(i))
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+// Segment returns the local path of the transcoded segment i of the video,
+// the transcodes of the user wait for each other over the limit. The next
+// segment is transcoded ahead if the user has a free slot.
+func Segment(ctx context.Context, userID uint, v *Video, i int) (string, error) {
+	if i < 0 || i >= v.Segments() {
+		return "", errors.Errorf("segment %d is out of range [0, %d)", i, v.Segments())
+	}
+	name, err := segment(ctx, userID, v, i, true)
+	if err != nil {
+		return "", err
+	}
+	if i+1 < v.Segments() {
+		// the request may end before the next segment is done
+		ctx := context.WithValue(context.Background(), "user", ctx.Value("user"))
+		go func() {
+			if _, err := segment(ctx, userID, v, i+1, false); err != nil && !errors.Is(err, errTooManyTranscodes) {
+				log.Warnf("failed transcode segment %d of %s ahead: %+v", i+1, v.Path, err)
+			}
+		}()
+	}
+	return name, nil
+}
+
+func segment(ctx context.Context, userID uint, v *Video, i int, wait bool) (string, error) {
+	dir := filepath.Join(cacheDir(), v.Key)
+	name := filepath.Join(dir, fmt.Sprintf("%d.ts", i))
+	if _, err := os.Stat(name); err == nil {
+		touch(dir)
+		return name, nil
+	}
+	name, err, _ := segmentG.Do(name, func() (string, error) {
+		if err := acquire(ctx, userID, wait); err != nil {
+			return "", err
+		}
+		defer release(userID)
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return "", err
+		}
+		start, d := v.span(i)
+		if err := transcode(ctx, v.Path, start, d, name); err != nil {
+			return "", err
+		}
+		touch(dir)
+		go clean()
+		return name, nil
+	})
+	return name, err
+}
+
+// transcode transcodes the d seconds from start of the video to the
+// mpeg-ts of dst, the timestamps are kept so that the segments play on
+func transcode(ctx context.Context, path string, start, d float64, dst string) error {
+	input, headers, err := openInput(ctx, path)
+	if err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	args := []string{"-hide_banner", "-loglevel", "error", "-ss", formatSeconds(start)}
+	if headers != "" {
+		args = append(args, "-headers", headers)
+	}
+	args = append(args, "-i", input, "-t", formatSeconds(d),
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-c:v", "libx264", "-preset", "veryfast", "-pix_fmt", "yuv420p",
+		"-c:a", "aac", "-ac", "2", "-b:a", "128k",
+		"-output_ts_offset", formatSeconds(start), "-muxdelay", "0",
+		"-f", "mpegts", "-y", tmp)
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		_ = os.Remove(tmp)
+		return errors.Wrapf(err, "failed transcode with ffmpeg: %s", stderr.String())
+	}
+	return os.Rename(tmp, dst)
+}
+
+func formatSeconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+func touch(dir string) {
+	now := time.Now()
+	_ = os.Chtimes(dir, now, now)
+}
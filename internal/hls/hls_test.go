@@ -0,0 +1,23 @@
+package hls
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPlaylist(t *testing.T) {
+	v := &Video{Path: "/a.mkv", Duration: 14.5}
+	if v.Segments() != 3 {
+		t.Fatalf("expect 3 segments, got %d", v.Segments())
+	}
+	if start, d := v.span(2); start != 12 || d != 2.5 {
+		t.Errorf("expect the last segment is shorter, got %v, %v", start, d)
+	}
+	m3u8 := v.Playlist(func(i int) string { return fmt.Sprintf("/s%d.ts", i) })
+	for _, s := range []string{"#EXT-X-TARGETDURATION:6", "#EXTINF:6.000,\n/s0.ts", "#EXTINF:2.500,\n/s2.ts", "#EXT-X-ENDLIST"} {
+		if !strings.Contains(m3u8, s) {
+			t.Errorf("expect %q in the playlist:\n%s", s, m3u8)
+		}
+	}
+}
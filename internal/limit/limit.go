@@ -0,0 +1,285 @@
+// Package limit throttles the proxied downloads and the uploads by the
+// bandwidth limits of the user and the storage, the transfers of the same
+// user or storage share one token bucket so that the limit is the total.
+package limit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+type Direction int
+
+const (
+	Download Direction = iota
+	Upload
+)
+
+// minBurst keeps the reads and writes of small limits from being split
+// into too many pieces
+const minBurst = 32 * 1024
+
+type bucket struct {
+	limiter *rate.Limiter
+	active  int
+}
+
+var (
+	mu      sync.Mutex
+	buckets = make(map[string]*bucket)
+)
+
+type subject struct {
+	key   string
+	limit model.Limit
+}
+
+// Transfer is a download or an upload throttled by the limits, Done must be
+// called after it ends
+type Transfer struct {
+	limiters []*rate.Limiter
+	slots    []string
+	keys     map[string]bool
+	once     sync.Once
+}
+
+type ctxKey struct{}
+
+// Start starts a transfer of the user and the storage, either of them can
+// be nil. It fails with errs.TooManyTransfers if the concurrent transfers
+// of any of them reach the max, or waits for a free slot if wait is true.
+// The limits already applied by a transfer in ctx are skipped, so that the
+// nested transfers (such as uploading to the storage of an alias) are not
+// throttled twice.
+func Start(ctx context.Context, user *model.User, storage *model.Storage, dir Direction, wait bool) (*Transfer, error) {
+	var subjects []subject
+	if user != nil {
+		subjects = append(subjects, subject{key: fmt.Sprintf("user:%d", user.ID), limit: user.Limit})
+	}
+	if storage != nil {
+		subjects = append(subjects, subject{key: fmt.Sprintf("storage:%d", storage.ID), limit: storage.Limit})
+	}
+	parent, _ := ctx.Value(ctxKey{}).(*Transfer)
+	t := &Transfer{keys: make(map[string]bool)}
+	if parent != nil {
+		for k := range parent.keys {
+			t.keys[k] = true
+		}
+	}
+	for {
+		ok, err := t.acquire(subjects, dir)
+		if err != nil || ok {
+			return t, err
+		}
+		if !wait {
+			return nil, errors.WithStack(errs.TooManyTransfers)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// acquire takes the slots of all subjects or none of them
+func (t *Transfer) acquire(subjects []subject, dir Direction) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	var todo []subject
+	var taken []string
+	for _, s := range subjects {
+		if t.keys[s.key] {
+			continue
+		}
+		if s.limit.MaxTransfers > 0 {
+			ok, err := takeSlot(s.key, s.limit.MaxTransfers)
+			if err != nil || !ok {
+				for _, key := range taken {
+					releaseSlot(key)
+				}
+				return false, err
+			}
+			taken = append(taken, s.key)
+		}
+		todo = append(todo, s)
+	}
+	t.slots = append(t.slots, taken...)
+	for _, s := range todo {
+		t.keys[s.key] = true
+		bps := s.limit.DownloadLimit
+		if dir == Upload {
+			bps = s.limit.UploadLimit
+		}
+		if bps > 0 {
+			t.limiters = append(t.limiters, getLimiter(fmt.Sprintf("%s:%d", s.key, dir), bps))
+		}
+	}
+	return true, nil
+}
+
+// takeSlot takes one of the max concurrent transfers of key, which are
+// counted across the instances of the cluster. The bandwidth limits are
+// still applied by each instance.
+func takeSlot(key string, max int) (bool, error) {
+	if cluster.Enabled() {
+		return cluster.AcquireSlot(key, max)
+	}
+	b := getBucket(key)
+	if b.active >= max {
+		return false, nil
+	}
+	b.active++
+	return true, nil
+}
+
+func releaseSlot(key string) {
+	if cluster.Enabled() {
+		cluster.ReleaseSlot(key)
+		return
+	}
+	if b := buckets[key]; b != nil && b.active > 0 {
+		b.active--
+	}
+}
+
+func getBucket(key string) *bucket {
+	b := buckets[key]
+	if b == nil {
+		b = &bucket{}
+		buckets[key] = b
+	}
+	return b
+}
+
+// getLimiter returns the shared limiter of key, updating it if the limit
+// is changed
+func getLimiter(key string, bps int64) *rate.Limiter {
+	b := getBucket(key)
+	burst := int(bps)
+	if burst < minBurst {
+		burst = minBurst
+	}
+	if b.limiter == nil {
+		b.limiter = rate.NewLimiter(rate.Limit(bps), burst)
+	} else if b.limiter.Limit() != rate.Limit(bps) {
+		b.limiter.SetLimit(rate.Limit(bps))
+		b.limiter.SetBurst(burst)
+	}
+	return b.limiter
+}
+
+// Limited reports whether the transfer is throttled at all
+func (t *Transfer) Limited() bool {
+	return len(t.limiters) > 0
+}
+
+// WithContext returns ctx with the transfer, see Start
+func (t *Transfer) WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKey{}, t)
+}
+
+// Done releases the slots of the transfer, it can be called more than once
+func (t *Transfer) Done() {
+	t.once.Do(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, key := range t.slots {
+			releaseSlot(key)
+		}
+	})
+}
+
+// wait waits until n bytes can be transferred by all limiters
+func (t *Transfer) wait(ctx context.Context, n int) error {
+	for _, l := range t.limiters {
+		// WaitN fails if n exceeds the burst
+		for left := n; left > 0; {
+			m := left
+			if b := l.Burst(); m > b {
+				m = b
+			}
+			if err := l.WaitN(ctx, m); err != nil {
+				return err
+			}
+			left -= m
+		}
+	}
+	return nil
+}
+
+// maxChunk returns the max bytes to transfer at a time, which is the
+// smallest burst so that a single read doesn't stall too long
+func (t *Transfer) maxChunk(n int) int {
+	for _, l := range t.limiters {
+		if b := l.Burst(); b < n {
+			n = b
+		}
+	}
+	return n
+}
+
+type reader struct {
+	ctx context.Context
+	t   *Transfer
+	r   io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	p = p[:r.t.maxChunk(len(p))]
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.t.wait(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Reader returns r throttled by the transfer
+func (t *Transfer) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if !t.Limited() {
+		return r
+	}
+	return &reader{ctx: ctx, t: t, r: r}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ReadCloser returns rc throttled by the transfer
+func (t *Transfer) ReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	if !t.Limited() {
+		return rc
+	}
+	return readCloser{Reader: t.Reader(ctx, rc), Closer: rc}
+}
+
+// Write writes p to w throttled by the transfer
+func (t *Transfer) Write(ctx context.Context, w io.Writer, p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		m := t.maxChunk(len(p))
+		if err := t.wait(ctx, m); err != nil {
+			return written, err
+		}
+		n, err := w.Write(p[:m])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[m:]
+	}
+	return written, nil
+}
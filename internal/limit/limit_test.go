@@ -0,0 +1,58 @@
+package limit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+)
+
+func TestStart_MaxTransfers(t *testing.T) {
+	user := &model.User{ID: 1, Limit: model.Limit{MaxTransfers: 1}}
+	storage := &model.Storage{ID: 1}
+	first, err := Start(context.Background(), user, storage, Download, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Start(context.Background(), user, storage, Upload, false); !errors.Is(err, errs.TooManyTransfers) {
+		t.Errorf("expect too many transfers, got %+v", err)
+	}
+	// the nested transfer doesn't take the slot again
+	nested, err := Start(first.WithContext(context.Background()), user, storage, Upload, false)
+	if err != nil {
+		t.Fatalf("failed to start nested transfer: %+v", err)
+	}
+	nested.Done()
+	first.Done()
+	first.Done()
+	second, err := Start(context.Background(), user, storage, Download, false)
+	if err != nil {
+		t.Fatalf("failed to start after done: %+v", err)
+	}
+	second.Done()
+}
+
+func TestTransfer_Reader(t *testing.T) {
+	const bps = 256 * 1024
+	storage := &model.Storage{ID: 2, Limit: model.Limit{DownloadLimit: bps}}
+	transfer, err := Start(context.Background(), nil, storage, Download, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transfer.Done()
+	data := make([]byte, 2*bps)
+	start := time.Now()
+	n, err := io.Copy(io.Discard, transfer.Reader(context.Background(), bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("failed to read: %d, %+v", n, err)
+	}
+	// the burst is one second, so the rest takes about another second
+	if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+		t.Errorf("read %d bytes too fast: %s", n, elapsed)
+	}
+}
@@ -0,0 +1,150 @@
+package listcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("list")
+
+// boltStore keeps the listings in a local file, so that they survive
+// restarts. Each value is prefixed with the expire time in unix nano, zero
+// if it never expires.
+type boltStore struct {
+	db   *bolt.DB
+	done chan struct{}
+}
+
+// NewBolt opens the bbolt file and returns the cache backed by it
+func NewBolt(file string) (Cache, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	s := &boltStore{db: db, done: make(chan struct{})}
+	go s.purge()
+	return newPersistent(s), nil
+}
+
+func expiredAt(v []byte, now time.Time) bool {
+	if len(v) < 8 {
+		return true
+	}
+	expire := int64(binary.BigEndian.Uint64(v[:8]))
+	return expire != 0 && now.UnixNano() > expire
+}
+
+func (b *boltStore) get(key string) ([]byte, bool) {
+	var data []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltBucket).Get([]byte(key))
+		if v == nil || expiredAt(v, time.Now()) {
+			return nil
+		}
+		// the value is only valid in the transaction
+		data = append([]byte(nil), v[8:]...)
+		return nil
+	})
+	if err != nil {
+		log.Warnf("failed to get listing cache %s from bbolt: %+v", key, err)
+	}
+	return data, data != nil
+}
+
+func (b *boltStore) set(key string, data []byte, ttl time.Duration) {
+	v := make([]byte, 8+len(data))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(v, uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	copy(v[8:], data)
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), v)
+	})
+	if err != nil {
+		log.Warnf("failed to set listing cache %s to bbolt: %+v", key, err)
+	}
+}
+
+func (b *boltStore) del(key string) {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		log.Warnf("failed to delete listing cache %s from bbolt: %+v", key, err)
+	}
+}
+
+func (b *boltStore) delTree(key string) {
+	prefix := []byte(subKeyPrefix(key))
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		if err := bucket.Delete([]byte(key)); err != nil {
+			return err
+		}
+		// the keys are sorted, so the keys below key are next to each other
+		var keys [][]byte
+		c := bucket.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return deleteKeys(bucket, keys)
+	})
+	if err != nil {
+		log.Warnf("failed to delete listing cache below %s from bbolt: %+v", key, err)
+	}
+}
+
+// deleteKeys deletes the keys collected by a cursor, since deleting while
+// iterating skips keys
+func deleteKeys(bucket *bolt.Bucket, keys [][]byte) error {
+	for _, k := range keys {
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *boltStore) close() error {
+	close(b.done)
+	return b.db.Close()
+}
+
+// purge removes the expired listings periodically
+func (b *boltStore) purge() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case now := <-ticker.C:
+			err := b.db.Update(func(tx *bolt.Tx) error {
+				bucket := tx.Bucket(boltBucket)
+				var keys [][]byte
+				c := bucket.Cursor()
+				for k, v := c.First(); k != nil; k, v = c.Next() {
+					if expiredAt(v, now) {
+						keys = append(keys, append([]byte(nil), k...))
+					}
+				}
+				return deleteKeys(bucket, keys)
+			})
+			if err != nil {
+				log.Warnf("failed to purge listing cache of bbolt: %+v", err)
+			}
+		}
+	}
+}
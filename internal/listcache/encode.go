@@ -0,0 +1,98 @@
+package listcache
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// the types of objs that can be encoded
+const (
+	kindObject = iota
+	kindThumb
+	kindURL
+	kindThumbURL
+)
+
+type entry struct {
+	Kind int `json:"kind"`
+	// Wrap is the name of model.ObjWrapName if the obj is wrapped
+	Wrap   *string           `json:"wrap,omitempty"`
+	ID     string            `json:"id"`
+	Path   string            `json:"path"`
+	Name   string            `json:"name"`
+	Size   int64             `json:"size"`
+	Mod    time.Time         `json:"modified"`
+	Dir    bool              `json:"is_dir"`
+	Hashes map[string]string `json:"hashes,omitempty"`
+	Thumb  string            `json:"thumb,omitempty"`
+	URL    string            `json:"url,omitempty"`
+}
+
+// encode encodes the objs, it fails if any of them is of a type defined by
+// a driver
+func encode(objs []model.Obj) ([]byte, bool) {
+	entries := make([]entry, 0, len(objs))
+	for _, obj := range objs {
+		var e entry
+		if w, ok := obj.(*model.ObjWrapName); ok {
+			name := w.Name
+			e.Wrap = &name
+			obj = w.Obj
+		}
+		var o *model.Object
+		switch v := obj.(type) {
+		case *model.Object:
+			e.Kind, o = kindObject, v
+		case *model.ObjThumb:
+			e.Kind, o, e.Thumb = kindThumb, &v.Object, v.Thumbnail.Thumbnail
+		case *model.ObjectURL:
+			e.Kind, o, e.URL = kindURL, &v.Object, v.Url.Url
+		case *model.ObjThumbURL:
+			e.Kind, o, e.Thumb, e.URL = kindThumbURL, &v.Object, v.Thumbnail.Thumbnail, v.Url.Url
+		default:
+			return nil, false
+		}
+		e.ID, e.Path, e.Name, e.Size, e.Dir, e.Hashes = o.ID, o.Path, o.Name, o.Size, o.IsFolder, o.Hashes
+		e.Mod = o.Modified
+		entries = append(entries, e)
+	}
+	data, err := utils.Json.Marshal(entries)
+	return data, err == nil
+}
+
+func decode(data []byte) ([]model.Obj, error) {
+	var entries []entry
+	if err := utils.Json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	objs := make([]model.Obj, 0, len(entries))
+	for _, e := range entries {
+		o := model.Object{
+			ID:       e.ID,
+			Path:     e.Path,
+			Name:     e.Name,
+			Size:     e.Size,
+			Modified: e.Mod,
+			IsFolder: e.Dir,
+			Hashes:   e.Hashes,
+		}
+		var obj model.Obj
+		switch e.Kind {
+		case kindThumb:
+			obj = &model.ObjThumb{Object: o, Thumbnail: model.Thumbnail{Thumbnail: e.Thumb}}
+		case kindURL:
+			obj = &model.ObjectURL{Object: o, Url: model.Url{Url: e.URL}}
+		case kindThumbURL:
+			obj = &model.ObjThumbURL{Object: o, Thumbnail: model.Thumbnail{Thumbnail: e.Thumb}, Url: model.Url{Url: e.URL}}
+		default:
+			obj = &o
+		}
+		if e.Wrap != nil {
+			obj = &model.ObjWrapName{Name: *e.Wrap, Obj: obj}
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
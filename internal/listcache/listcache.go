@@ -0,0 +1,92 @@
+// Package listcache caches the listings of dirs, keyed by the mount path of
+// the storage joined with the path of the dir.
+package listcache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// Cache is the backend of the listing cache, a ttl not greater than zero
+// means the listing never expires
+type Cache interface {
+	Get(key string) ([]model.Obj, bool)
+	Set(key string, objs []model.Obj, ttl time.Duration)
+	Del(key string)
+	// DelTree deletes the listing of the dir and all dirs below it
+	DelTree(key string)
+	Close() error
+}
+
+// store is a key value store for the encoded listings
+type store interface {
+	get(key string) ([]byte, bool)
+	set(key string, data []byte, ttl time.Duration)
+	del(key string)
+	delTree(key string)
+	close() error
+}
+
+// subKeyPrefix is the prefix of the keys below key
+func subKeyPrefix(key string) string {
+	if strings.HasSuffix(key, "/") {
+		return key
+	}
+	return key + "/"
+}
+
+// persistent keeps the listings that can be encoded in the store, and the
+// others in memory, since the objs of some drivers carry fields that only
+// live in the process
+type persistent struct {
+	mem   *Memory
+	store store
+}
+
+func newPersistent(s store) *persistent {
+	return &persistent{mem: NewMemory(), store: s}
+}
+
+func (p *persistent) Get(key string) ([]model.Obj, bool) {
+	if objs, ok := p.mem.Get(key); ok {
+		return objs, true
+	}
+	data, ok := p.store.get(key)
+	if !ok {
+		return nil, false
+	}
+	objs, err := decode(data)
+	if err != nil {
+		p.store.del(key)
+		return nil, false
+	}
+	return objs, true
+}
+
+func (p *persistent) Set(key string, objs []model.Obj, ttl time.Duration) {
+	data, ok := encode(objs)
+	if !ok {
+		p.store.del(key)
+		p.mem.Set(key, objs, ttl)
+		return
+	}
+	p.mem.Del(key)
+	p.store.set(key, data, ttl)
+}
+
+func (p *persistent) Del(key string) {
+	p.mem.Del(key)
+	p.store.del(key)
+}
+
+func (p *persistent) DelTree(key string) {
+	p.mem.DelTree(key)
+	p.store.delTree(key)
+}
+
+func (p *persistent) Close() error {
+	_ = p.mem.Close()
+	return p.store.close()
+}
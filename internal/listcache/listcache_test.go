@@ -0,0 +1,98 @@
+package listcache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func testObjs() []model.Obj {
+	mod := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+	return []model.Obj{
+		&model.ObjWrapName{Name: "a", Obj: &model.Object{Name: "a", IsFolder: true, Modified: mod}},
+		&model.ObjThumb{Object: model.Object{Name: "b.jpg", Size: 10, Modified: mod}, Thumbnail: model.Thumbnail{Thumbnail: "thumb"}},
+		&model.ObjectURL{Object: model.Object{Name: "c", Size: 20, Hashes: map[string]string{model.HashMD5: "c"}}, Url: model.Url{Url: "url"}},
+	}
+}
+
+func testTree(t *testing.T, c Cache) {
+	for _, key := range []string{"/a", "/a/b", "/a/b/c", "/ab"} {
+		c.Set(key, testObjs(), time.Minute)
+	}
+	c.DelTree("/a")
+	for key, want := range map[string]bool{"/a": false, "/a/b": false, "/a/b/c": false, "/ab": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("get %s: expect %v, got %v", key, want, ok)
+		}
+	}
+	c.Set("/x", testObjs(), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("/x"); ok {
+		t.Errorf("expect /x to be expired")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	c := NewMemory()
+	defer c.Close()
+	testTree(t, c)
+}
+
+func TestBolt(t *testing.T) {
+	c, err := NewBolt(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	testTree(t, c)
+	c.Set("/y", testObjs(), 0)
+	objs, ok := c.Get("/y")
+	if !ok || len(objs) != 3 {
+		t.Fatalf("expect 3 objs of /y, got %v", objs)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	data, ok := encode(testObjs())
+	if !ok {
+		t.Fatal("failed to encode")
+	}
+	objs, err := decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, ok := objs[0].(*model.ObjWrapName)
+	if !ok || w.GetName() != "a" || !w.IsDir() {
+		t.Errorf("unexpected obj %+v", objs[0])
+	}
+	if th, ok := objs[1].(*model.ObjThumb); !ok || th.Thumb() != "thumb" || !th.ModTime().Equal(testObjs()[1].ModTime()) {
+		t.Errorf("unexpected obj %+v", objs[1])
+	}
+	if u, ok := objs[2].(*model.ObjectURL); !ok || u.URL() != "url" || u.Hashes[model.HashMD5] != "c" {
+		t.Errorf("unexpected obj %+v", objs[2])
+	}
+
+	type driverObj struct{ model.Object }
+	if _, ok := encode([]model.Obj{&driverObj{}}); ok {
+		t.Errorf("expect objs of drivers not to be encoded")
+	}
+}
+
+func TestPersistentKeepsDriverObjs(t *testing.T) {
+	c, err := NewBolt(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	type driverObj struct{ model.Object }
+	c.Set("/d", []model.Obj{&driverObj{}}, time.Minute)
+	objs, ok := c.Get("/d")
+	if !ok {
+		t.Fatal("expect /d to be cached")
+	}
+	if _, ok := objs[0].(*driverObj); !ok {
+		t.Errorf("expect the obj of the driver to be kept")
+	}
+}
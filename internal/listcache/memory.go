@@ -0,0 +1,97 @@
+package listcache
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type memItem struct {
+	objs   []model.Obj
+	expire time.Time
+}
+
+func (i memItem) expired(now time.Time) bool {
+	return !i.expire.IsZero() && now.After(i.expire)
+}
+
+// Memory keeps the listings in the process, they are lost on restart
+type Memory struct {
+	mu    sync.RWMutex
+	items map[string]memItem
+	done  chan struct{}
+	once  sync.Once
+}
+
+func NewMemory() *Memory {
+	m := &Memory{items: make(map[string]memItem), done: make(chan struct{})}
+	go m.purge()
+	return m
+}
+
+func (m *Memory) Get(key string) ([]model.Obj, bool) {
+	m.mu.RLock()
+	item, ok := m.items[key]
+	m.mu.RUnlock()
+	if !ok || item.expired(time.Now()) {
+		return nil, false
+	}
+	return item.objs, true
+}
+
+func (m *Memory) Set(key string, objs []model.Obj, ttl time.Duration) {
+	item := memItem{objs: objs}
+	if ttl > 0 {
+		item.expire = time.Now().Add(ttl)
+	}
+	m.mu.Lock()
+	m.items[key] = item
+	m.mu.Unlock()
+}
+
+func (m *Memory) Del(key string) {
+	m.mu.Lock()
+	delete(m.items, key)
+	m.mu.Unlock()
+}
+
+func (m *Memory) DelTree(key string) {
+	prefix := subKeyPrefix(key)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, key)
+	for k := range m.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.items, k)
+		}
+	}
+}
+
+func (m *Memory) Close() error {
+	m.once.Do(func() {
+		close(m.done)
+	})
+	return nil
+}
+
+// purge removes the expired listings periodically
+func (m *Memory) purge() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for k, item := range m.items {
+				if item.expired(now) {
+					delete(m.items, k)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
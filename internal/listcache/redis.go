@@ -0,0 +1,88 @@
+package listcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const redisTimeout = 3 * time.Second
+
+// redisStore shares the listings between instances, prefix isolates the
+// keys of different sites using the same redis
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis connects to the redis and returns the cache backed by it
+func NewRedis(addr, password string, db int, prefix string) (Cache, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db})
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return newPersistent(&redisStore{client: client, prefix: prefix}), nil
+}
+
+func (r *redisStore) get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Warnf("failed to get listing cache %s from redis: %+v", key, err)
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+func (r *redisStore) set(key string, data []byte, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := r.client.Set(ctx, r.prefix+key, data, ttl).Err(); err != nil {
+		log.Warnf("failed to set listing cache %s to redis: %+v", key, err)
+	}
+}
+
+func (r *redisStore) del(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := r.client.Del(ctx, r.prefix+key).Err(); err != nil {
+		log.Warnf("failed to delete listing cache %s from redis: %+v", key, err)
+	}
+}
+
+func (r *redisStore) delTree(key string) {
+	r.del(key)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*redisTimeout)
+	defer cancel()
+	iter := r.client.Scan(ctx, 0, utils.EscapeGlob(r.prefix+subKeyPrefix(key))+"*", 1000).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 1000 {
+			r.client.Unlink(ctx, keys...)
+			keys = keys[:0]
+		}
+	}
+	if len(keys) > 0 {
+		r.client.Unlink(ctx, keys...)
+	}
+	if err := iter.Err(); err != nil {
+		log.Warnf("failed to delete listing cache below %s from redis: %+v", key, err)
+	}
+}
+
+func (r *redisStore) close() error {
+	return r.client.Close()
+}
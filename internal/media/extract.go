@@ -0,0 +1,186 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/rwcarlsen/goexif/exif"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// headSize is the bytes read of the images, the exif and the headers
+	// are at the start of the files
+	headSize = 1 << 20
+	// probeTimeout is the max time of ffprobe
+	probeTimeout = 30 * time.Second
+)
+
+var httpClient = &http.Client{}
+
+var (
+	ffprobeOnce sync.Once
+	ffprobe     bool
+)
+
+// hasFFprobe reports whether ffprobe is installed to read the videos
+func hasFFprobe() bool {
+	ffprobeOnce.Do(func() {
+		_, err := exec.LookPath("ffprobe")
+		ffprobe = err == nil
+	})
+	return ffprobe
+}
+
+// extract reads the info of the file, the errors are the failures to read
+// the file, the info is empty if the content can't be parsed
+func extract(ctx context.Context, path string, obj model.Obj) (*model.MediaInfo, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if link.Data != nil {
+		defer link.Data.Close()
+	}
+	if utils.GetFileType(obj.GetName()) == conf.VIDEO {
+		return probe(link)
+	}
+	data, err := readHead(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return parseImage(data), nil
+}
+
+// readHead reads the first headSize bytes of the file of the link
+func readHead(ctx context.Context, link *model.Link) ([]byte, error) {
+	var rc io.ReadCloser
+	switch {
+	case link.Data != nil:
+		rc = io.NopCloser(link.Data)
+	case link.FilePath != nil:
+		f, err := os.Open(*link.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		rc = f
+	default:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for h, val := range link.Header {
+			req.Header[h] = val
+		}
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", headSize-1))
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("unexpected status: %s", res.Status)
+		}
+		rc = res.Body
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, headSize))
+}
+
+func parseImage(data []byte) *model.MediaInfo {
+	info := &model.MediaInfo{}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		info.Width, info.Height = cfg.Width, cfg.Height
+	}
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return info
+	}
+	if t, err := x.DateTime(); err == nil {
+		info.TakenAt = &t
+	}
+	// the orientations from 5 to 8 rotate the image by 90 degrees
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if o, err := tag.Int(0); err == nil && o >= 5 && o <= 8 {
+			info.Width, info.Height = info.Height, info.Width
+		}
+	}
+	return info
+}
+
+type probeResult struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+	Format struct {
+		Duration string            `json:"duration"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// probe reads the info of the video with ffprobe, the streams of the links
+// can't be probed since ffprobe seeks
+func probe(link *model.Link) (*model.MediaInfo, error) {
+	info := &model.MediaInfo{}
+	input := link.URL
+	kwArgs := ffmpeg.KwArgs{}
+	if link.FilePath != nil {
+		input = *link.FilePath
+	} else if link.Data != nil {
+		return info, nil
+	} else if len(link.Header) > 0 {
+		var headers strings.Builder
+		for k, vals := range link.Header {
+			for _, v := range vals {
+				headers.WriteString(k + ": " + v + "\r\n")
+			}
+		}
+		kwArgs["headers"] = headers.String()
+	}
+	out, err := ffmpeg.ProbeWithTimeout(input, probeTimeout, kwArgs)
+	if err != nil {
+		return nil, err
+	}
+	return parseProbe(out), nil
+}
+
+func parseProbe(out string) *model.MediaInfo {
+	info := &model.MediaInfo{}
+	var res probeResult
+	if err := utils.Json.UnmarshalFromString(out, &res); err != nil {
+		return info
+	}
+	for _, s := range res.Streams {
+		if s.CodecType == "video" {
+			info.Width, info.Height = s.Width, s.Height
+			break
+		}
+	}
+	if d, err := strconv.ParseFloat(res.Format.Duration, 64); err == nil {
+		info.Duration = d
+	}
+	if t, err := time.Parse(time.RFC3339Nano, res.Format.Tags["creation_time"]); err == nil && t.Year() > 1970 {
+		info.TakenAt = &t
+	}
+	return info
+}
@@ -0,0 +1,175 @@
+// Package media reads the capture date, the dimensions and the duration of
+// the images and the videos, the infos are cached in the database by the
+// path, the size and the modified time of the files.
+package media
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// workers reading the infos of the listed files in the background
+	workers = 2
+	// queue is the max files waiting for the workers, the others are read
+	// when they're listed again
+	queue = 256
+)
+
+var (
+	infoG   singleflight.Group[*model.MediaInfo]
+	pending generic_sync.MapOf[string, struct{}]
+	jobs    chan job
+	once    sync.Once
+)
+
+type job struct {
+	path string
+	obj  model.Obj
+}
+
+// Enabled reports whether the infos are read
+func Enabled() bool {
+	return setting.GetBool(conf.MediaInfoExtract)
+}
+
+// Supported reports whether the info of the file can be read, the videos
+// need ffprobe
+func Supported(name string) bool {
+	t := utils.GetFileType(name)
+	return (t == conf.IMAGE && utils.Ext(name) != "svg") || (t == conf.VIDEO && hasFFprobe())
+}
+
+func hash(path string, obj model.Obj) string {
+	return utils.GetMD5Encode(fmt.Sprintf("%s-%d-%d", path, obj.GetSize(), obj.ModTime().Unix()))
+}
+
+// Get returns the info of the file at path, reading it if it's not cached
+func Get(ctx context.Context, path string) (*model.MediaInfo, error) {
+	obj, err := fs.Get(ctx, path, &fs.GetArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if obj.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	if !Supported(obj.GetName()) {
+		return nil, errors.Errorf("media info of %s is not supported", obj.GetName())
+	}
+	return get(ctx, path, obj)
+}
+
+func get(ctx context.Context, path string, obj model.Obj) (*model.MediaInfo, error) {
+	h := hash(path, obj)
+	info, err, _ := infoG.Do(h, func() (*model.MediaInfo, error) {
+		if infos, err := db.GetMediaInfos([]string{h}); err == nil && len(infos) > 0 {
+			return &infos[0], nil
+		}
+		info, err := extract(ctx, path, obj)
+		if err != nil {
+			return nil, err
+		}
+		info.Hash = h
+		if err := db.SaveMediaInfo(info); err != nil {
+			log.Warnf("failed to cache media info of %s: %+v", path, err)
+		}
+		return info, nil
+	})
+	return info, err
+}
+
+// Lookup returns the cached infos of the files in the dir by the name, the
+// missing ones are read in the background for the next listing
+func Lookup(dir string, objs []model.Obj) map[string]*model.MediaInfo {
+	hashes := make(map[string]model.Obj)
+	for _, obj := range objs {
+		if !obj.IsDir() && Supported(obj.GetName()) {
+			hashes[hash(stdpath.Join(dir, obj.GetName()), obj)] = obj
+		}
+	}
+	res := make(map[string]*model.MediaInfo, len(hashes))
+	if len(hashes) == 0 {
+		return res
+	}
+	keys := make([]string, 0, len(hashes))
+	for h := range hashes {
+		keys = append(keys, h)
+	}
+	infos, err := db.GetMediaInfos(keys)
+	if err != nil {
+		log.Errorf("failed get media infos of %s: %+v", dir, err)
+		return res
+	}
+	for i := range infos {
+		if obj, ok := hashes[infos[i].Hash]; ok {
+			res[obj.GetName()] = &infos[i]
+			delete(hashes, infos[i].Hash)
+		}
+	}
+	for _, obj := range hashes {
+		enqueue(stdpath.Join(dir, obj.GetName()), obj)
+	}
+	return res
+}
+
+func enqueue(path string, obj model.Obj) {
+	once.Do(func() {
+		jobs = make(chan job, queue)
+		for i := 0; i < workers; i++ {
+			go work()
+		}
+	})
+	if _, ok := pending.LoadOrStore(path, struct{}{}); ok {
+		return
+	}
+	select {
+	case jobs <- job{path: path, obj: obj}:
+	default:
+		pending.Delete(path)
+	}
+}
+
+func work() {
+	for j := range jobs {
+		if _, err := get(context.Background(), j.path, j.obj); err != nil {
+			log.Warnf("failed read media info of %s: %+v", j.path, err)
+		}
+		pending.Delete(j.path)
+	}
+}
+
+// takenAt is the capture date of the file, or the modified time if it's
+// unknown
+func takenAt(obj model.Obj, infos map[string]*model.MediaInfo) time.Time {
+	if info, ok := infos[obj.GetName()]; ok && info.TakenAt != nil {
+		return *info.TakenAt
+	}
+	return obj.ModTime()
+}
+
+// SortByTakenAt sorts the objs by the capture date of the infos, the ones
+// without it are sorted by the modified time
+func SortByTakenAt(objs []model.Obj, infos map[string]*model.MediaInfo, orderDirection string) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		if orderDirection == "desc" {
+			return takenAt(objs[i], infos).After(takenAt(objs[j], infos))
+		}
+		return takenAt(objs[i], infos).Before(takenAt(objs[j], infos))
+	})
+}
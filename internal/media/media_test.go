@@ -0,0 +1,58 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestParseImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 30, 20))); err != nil {
+		t.Fatal(err)
+	}
+	info := parseImage(buf.Bytes())
+	if info.Width != 30 || info.Height != 20 || info.TakenAt != nil {
+		t.Errorf("expect the dimensions without the capture date, got %+v", info)
+	}
+	if info := parseImage([]byte("not an image")); *info != (model.MediaInfo{}) {
+		t.Errorf("expect an empty info, got %+v", info)
+	}
+}
+
+func TestParseProbe(t *testing.T) {
+	info := parseProbe(`{
+		"streams": [{"codec_type": "audio"}, {"codec_type": "video", "width": 1920, "height": 1080}],
+		"format": {"duration": "12.5", "tags": {"creation_time": "2023-05-01T10:00:00.000000Z"}}
+	}`)
+	if info.Width != 1920 || info.Height != 1080 || info.Duration != 12.5 {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if info.TakenAt == nil || !info.TakenAt.Equal(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected creation time: %v", info.TakenAt)
+	}
+}
+
+func TestSortByTakenAt(t *testing.T) {
+	now := time.Now()
+	taken := now.Add(-time.Hour)
+	objs := []model.Obj{
+		&model.Object{Name: "a.jpg", Modified: now.Add(-time.Minute)},
+		&model.Object{Name: "b.jpg", Modified: now},
+		&model.Object{Name: "c.jpg", Modified: now.Add(-2 * time.Hour)},
+	}
+	// b.jpg is uploaded recently but taken an hour ago
+	infos := map[string]*model.MediaInfo{"b.jpg": {TakenAt: &taken}}
+	SortByTakenAt(objs, infos, "asc")
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetName())
+	}
+	if got := names[0] + names[1] + names[2]; got != "c.jpgb.jpga.jpg" {
+		t.Errorf("unexpected order: %v", names)
+	}
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "alist"
+
+// Registry holds the metrics of alist and the go runtime, it's served by
+// the metrics endpoint
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RequestDuration is the latency of the requests by the route template,
+	// so that the paths of the files don't make new series
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "The latency of the http requests by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	// ActiveTransfers is the number of the running proxied downloads and
+	// uploads
+	ActiveTransfers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_transfers",
+		Help:      "The number of the running transfers by direction.",
+	}, []string{"direction"})
+	// ProxiedBytes is the bytes sent to the clients by the proxy
+	ProxiedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "proxied_bytes_total",
+		Help:      "The bytes sent to the clients by the proxy.",
+	})
+	// CacheRequests counts the lookups of the caches by the result, the
+	// hit ratio is hit / (hit + miss)
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "The lookups of the listing and link caches by result.",
+	}, []string{"cache", "result"})
+	// DriverErrors counts the failed calls to the drivers
+	DriverErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_errors_total",
+		Help:      "The failed calls to the drivers by driver and method.",
+	}, []string{"driver", "method"})
+)
+
+var taskQueueDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "task_queue_depth"),
+	"The number of the undone tasks by type.",
+	[]string{"type"}, nil,
+)
+
+var taskQueues generic_sync.MapOf[string, func() int]
+
+// SetTaskQueue sets the function returning the number of the undone tasks of
+// the type, it's read when the metrics are scraped
+func SetTaskQueue(typ string, depth func() int) {
+	taskQueues.Store(typ, depth)
+}
+
+type taskQueueCollector struct{}
+
+func (taskQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- taskQueueDesc
+}
+
+func (taskQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	taskQueues.Range(func(typ string, depth func() int) bool {
+		ch <- prometheus.MustNewConstMetric(taskQueueDesc, prometheus.GaugeValue, float64(depth()), typ)
+		return true
+	})
+}
+
+// CacheResult counts a lookup of the cache
+func CacheResult(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestDuration,
+		ActiveTransfers,
+		ProxiedBytes,
+		CacheRequests,
+		DriverErrors,
+		taskQueueCollector{},
+	)
+}
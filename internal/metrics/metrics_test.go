@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTaskQueue(t *testing.T) {
+	depth := 2
+	SetTaskQueue("copy", func() int { return depth })
+	expected := `
+# HELP alist_task_queue_depth The number of the undone tasks by type.
+# TYPE alist_task_queue_depth gauge
+alist_task_queue_depth{type="copy"} 2
+`
+	if err := testutil.CollectAndCompare(taskQueueCollector{}, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+	depth = 0
+	if v := testutil.ToFloat64(taskQueueCollector{}); v != 0 {
+		t.Fatalf("expected the depth read on scraping, got %v", v)
+	}
+}
+
+func TestCacheResult(t *testing.T) {
+	CacheResult("list", true)
+	CacheResult("list", true)
+	CacheResult("list", false)
+	if v := testutil.ToFloat64(CacheRequests.WithLabelValues("list", "hit")); v != 2 {
+		t.Fatalf("expected 2 hits, got %v", v)
+	}
+	if v := testutil.ToFloat64(CacheRequests.WithLabelValues("list", "miss")); v != 1 {
+		t.Fatalf("expected 1 miss, got %v", v)
+	}
+}
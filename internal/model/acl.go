@@ -0,0 +1,25 @@
+package model
+
+// Permissions of acl rules
+const (
+	PermRead = 1 << iota
+	PermWrite
+	PermRename // rename, and move out of the path
+	PermDelete
+	PermShare
+	PermProxy // download through the proxy of alist
+)
+
+// ACLRule allows or denies the permissions on the path and everything
+// under it. It applies to the user of UserID, or the members of GroupID,
+// or everyone if both are zero. The rule of the deepest path decides a
+// permission, then user rules take precedence over group rules over the
+// rules for everyone, and deny wins over allow at the same level.
+type ACLRule struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Path    string `json:"path" gorm:"index" binding:"required"`
+	UserID  uint   `json:"user_id"`
+	GroupID uint   `json:"group_id"`
+	Allow   int32  `json:"allow"`
+	Deny    int32  `json:"deny"`
+}
@@ -24,6 +24,10 @@ type Link struct {
 	FilePath   *string                                            // local file, return the filepath
 	Expiration *time.Duration                                     // url expiration time
 	Handle     func(w http.ResponseWriter, r *http.Request) error // custom handler
+	// Concurrency and PartSize override the settings of parallel proxied
+	// downloads, Concurrency 1 disables it for the link
+	Concurrency int
+	PartSize    int
 }
 
 type OtherArgs struct {
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// AuditLog records who did what, Target is the path of the file or the
+// mount path of the storage etc. concerned
+type AuditLog struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Time     time.Time `json:"time" gorm:"index"`
+	UserID   uint      `json:"user_id" gorm:"index"`
+	Username string    `json:"username"`
+	IP       string    `json:"ip"`
+	Action   string    `json:"action" gorm:"index"`
+	Target   string    `json:"target"`
+	Detail   string    `json:"detail" gorm:"type:text"`
+	Success  bool      `json:"success"`
+}
+
+// AuditFilter filters the audit logs, zero fields are not filtered
+type AuditFilter struct {
+	Username string `json:"username" form:"username"`
+	Action   string `json:"action" form:"action"`
+	// Target is the prefix of the target
+	Target string    `json:"target" form:"target"`
+	Since  time.Time `json:"since" form:"since"`
+	Until  time.Time `json:"until" form:"until"`
+}
@@ -0,0 +1,13 @@
+package model
+
+type Group struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"unique" binding:"required"`
+	Description string `json:"description"`
+}
+
+// UserGroup is the membership of a user in a group
+type UserGroup struct {
+	UserID  uint `json:"user_id" gorm:"primaryKey"`
+	GroupID uint `json:"group_id" gorm:"primaryKey"`
+}
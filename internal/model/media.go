@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// MediaInfo is the metadata read from the content of an image or a video,
+// the zero fields are unknown
+type MediaInfo struct {
+	// Hash is the md5 of the path, the size and the modified time of the
+	// file, so that the info is read again when the file changes
+	Hash string `json:"-" gorm:"primaryKey;size:32"`
+	// TakenAt is the capture date of the exif of the images or the creation
+	// time of the videos
+	TakenAt *time.Time `json:"taken_at,omitempty"`
+	Width   int        `json:"width,omitempty"`
+	Height  int        `json:"height,omitempty"`
+	// Duration of the videos in seconds
+	Duration float64 `json:"duration,omitempty"`
+}
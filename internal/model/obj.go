@@ -1,6 +1,11 @@
 package model
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"hash"
+	"hash/crc64"
 	"io"
 	"regexp"
 	"sort"
@@ -50,6 +55,32 @@ type SetPath interface {
 	SetPath(path string)
 }
 
+// the types of the checksums reported by storages, the values are in
+// lower case hex
+const (
+	HashMD5    = "md5"
+	HashSHA1   = "sha1"
+	HashSHA256 = "sha256"
+	// HashCRC64 is the crc64 of the ECMA polynomial, as used by aliyun oss
+	HashCRC64 = "crc64"
+)
+
+// HashNews creates the hashes of the hash types
+var HashNews = map[string]func() hash.Hash{
+	HashMD5:    md5.New,
+	HashSHA1:   sha1.New,
+	HashSHA256: sha256.New,
+	HashCRC64: func() hash.Hash {
+		return crc64.New(crc64.MakeTable(crc64.ECMA))
+	},
+}
+
+// Hashes is implemented by the objs whose checksums are known, the keys
+// are the hash types
+type Hashes interface {
+	GetHashes() map[string]string
+}
+
 func SortFiles(objs []Obj, orderBy, orderDirection string) {
 	if orderBy == "" {
 		return
@@ -126,6 +157,16 @@ func GetThumb(obj Obj) (thumb string, ok bool) {
 	return thumb, false
 }
 
+func GetHashes(obj Obj) map[string]string {
+	if obj, ok := obj.(Hashes); ok {
+		return obj.GetHashes()
+	}
+	if unwrap, ok := obj.(ObjUnwrap); ok {
+		return GetHashes(unwrap.Unwrap())
+	}
+	return nil
+}
+
 func GetUrl(obj Obj) (url string, ok bool) {
 	if obj, ok := obj.(URL); ok {
 		return obj.URL(), true
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashNews(t *testing.T) {
+	// the check values of the catalogue of parametrised crc algorithms
+	h := HashNews[HashCRC64]()
+	_, _ = h.Write([]byte("123456789"))
+	if got := hex.EncodeToString(h.Sum(nil)); got != "995dc9bbdf1939fa" {
+		t.Errorf("unexpected crc64: %s", got)
+	}
+}
+
+func TestGetHashes(t *testing.T) {
+	obj := &Object{Name: "a", Hashes: map[string]string{HashMD5: "d41d8cd98f00b204e9800998ecf8427e"}}
+	if GetHashes(WrapObjName(obj))[HashMD5] == "" {
+		t.Errorf("expect the hashes of the wrapped obj")
+	}
+	if GetHashes(&ObjThumb{}) != nil {
+		t.Errorf("expect no hashes")
+	}
+}
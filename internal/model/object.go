@@ -29,6 +29,8 @@ type Object struct {
 	Size     int64
 	Modified time.Time
 	IsFolder bool
+	// Hashes are the checksums reported by the storage, such as md5
+	Hashes map[string]string
 }
 
 func (o *Object) GetName() string {
@@ -47,6 +49,10 @@ func (o *Object) IsDir() bool {
 	return o.IsFolder
 }
 
+func (o *Object) GetHashes() map[string]string {
+	return o.Hashes
+}
+
 func (o *Object) GetID() string {
 	return o.ID
 }
@@ -0,0 +1,8 @@
+package model
+
+// QuotaUsage is the bytes uploaded by the user to the storage
+type QuotaUsage struct {
+	UserID    uint  `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	StorageID uint  `json:"storage_id" gorm:"primaryKey;autoIncrement:false"`
+	Used      int64 `json:"used"`
+}
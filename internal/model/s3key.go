@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// S3Key is an access key of the s3 server, requests signed with it act as
+// the user it belongs to
+type S3Key struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"index"`
+	AccessKeyID string    `json:"access_key_id" gorm:"unique"`
+	SecretKey   string    `json:"secret_key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -9,6 +9,7 @@ const (
 	ARIA2
 	INDEX
 	SSO
+	S3
 )
 
 const (
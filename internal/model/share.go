@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Share is a tokenized link to a file or folder that can be accessed
+// without login, as the user who created it
+type Share struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Token  string `json:"token" gorm:"unique"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	Path   string `json:"path"`
+	// Password is the bcrypt hash of the password, empty if there's none
+	Password    string     `json:"-"`
+	HasPassword bool       `json:"has_password" gorm:"-"`
+	ExpireAt    *time.Time `json:"expire_at"`
+	// MaxDownloads is unlimited if it's zero
+	MaxDownloads int       `json:"max_downloads"`
+	Downloads    int       `json:"downloads"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (s *Share) AfterFind(tx *gorm.DB) error {
+	s.HasPassword = s.Password != ""
+	return nil
+}
+
+func (s Share) Expired() bool {
+	return s.ExpireAt != nil && time.Now().After(*s.ExpireAt)
+}
+
+// SetPassword stores the hash of password, an empty one removes the password
+func (s *Share) SetPassword(password string) error {
+	s.HasPassword = password != ""
+	if password == "" {
+		s.Password = ""
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	s.Password = string(hash)
+	return nil
+}
+
+// ValidatePassword reports whether password opens the share, the hashes are
+// compared in constant time
+func (s Share) ValidatePassword(password string) bool {
+	if s.Password == "" {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(s.Password), []byte(password)) == nil
+}
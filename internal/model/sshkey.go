@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// SSHKey is a public key of the user to login the sftp server
+type SSHKey struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"index"`
+	Title       string    `json:"title"`
+	Fingerprint string    `json:"fingerprint" gorm:"unique"`
+	KeyStr      string    `json:"key_str" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -14,8 +14,23 @@ type Storage struct {
 	Modified        time.Time `json:"modified"`
 	Disabled        bool      `json:"disabled"` // if disabled
 	EnableSign      bool      `json:"enable_sign"`
+	// KeepVersions is the number of previous versions kept when a file is
+	// overwritten, for the storages that don't keep versions themselves
+	KeepVersions int `json:"keep_versions"`
+	// Trash moves the objects removed through alist to the recycle bin of
+	// the storage instead of deleting them, they're deleted after TrashDays,
+	// 0 keeps them forever
+	Trash     bool `json:"trash"`
+	TrashDays int  `json:"trash_days"`
 	Sort
 	Proxy
+	Limit
+}
+
+// StorageSpace is the capacity of a storage in bytes
+type StorageSpace struct {
+	Total int64 `json:"total"`
+	Free  int64 `json:"free"`
 }
 
 type Sort struct {
@@ -30,6 +45,37 @@ type Proxy struct {
 	DownProxyUrl string `json:"down_proxy_url"`
 }
 
+// Limit is the bandwidth limits of a user or a storage, zero means unlimited
+type Limit struct {
+	// DownloadLimit and UploadLimit are in bytes per second
+	DownloadLimit int64 `json:"download_limit"`
+	UploadLimit   int64 `json:"upload_limit"`
+	MaxTransfers  int   `json:"max_transfers"`
+	// Quota is the max bytes that can be uploaded, by the user in total
+	// or by each user to the storage, 0 means unlimited
+	Quota int64 `json:"quota"`
+}
+
+// the health of storages checked periodically
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthOffline  = "offline"
+)
+
+// StorageHealth is the result of the last health checks of a storage, the
+// storage is degraded once a check fails and offline after several
+// consecutive failures
+type StorageHealth struct {
+	MountPath string    `json:"mount_path"`
+	Health    string    `json:"health"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"last_error"`
+	LastCheck time.Time `json:"last_check"`
+	// Latency of the last check in milliseconds
+	Latency int64 `json:"latency"`
+}
+
 func (s *Storage) GetStorage() *Storage {
 	return s
 }
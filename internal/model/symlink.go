@@ -0,0 +1,10 @@
+package model
+
+// Symlink is a virtual entry at Path that shows the object at Target, the
+// paths under Path are resolved to the paths under Target
+type Symlink struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Path   string `json:"path" gorm:"unique" binding:"required"`
+	Target string `json:"target" binding:"required"`
+	Remark string `json:"remark"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	// SyncOneWay mirrors the source to the destination
+	SyncOneWay = "one_way"
+	// SyncTwoWay copies the missing and the newer files to each other
+	SyncTwoWay = "two_way"
+)
+
+// SyncJob syncs the files between two paths, which can be in different
+// storages, on the schedule of the cron expression
+type SyncJob struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name"`
+	Src  string `json:"src" binding:"required"`
+	Dst  string `json:"dst" binding:"required"`
+	// Cron is the schedule like "0 3 * * *" or "@every 6h", empty means
+	// the job only runs manually
+	Cron string `json:"cron"`
+	Mode string `json:"mode"`
+	// Delete removes the files of the destination that are not in the
+	// source, only for one way sync
+	Delete bool `json:"delete"`
+	// Include and Exclude are the comma separated glob patterns matched
+	// against the names and the relative paths, empty Include means all
+	Include  string `json:"include"`
+	Exclude  string `json:"exclude"`
+	Disabled bool   `json:"disabled"`
+
+	LastRun    time.Time `json:"last_run"`
+	LastResult string    `json:"last_result" gorm:"type:text"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// TaskItem is an unfinished task persisted so it can be resumed after restart
+type TaskItem struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Type string `json:"type" gorm:"index"`
+	Name string `json:"name"`
+	// Data is the json encoded arguments to rerun the task
+	Data string `json:"data"`
+	// Node is the instance of the cluster running the task
+	Node      string    `json:"node" gorm:"index;not null;default:''"`
+	CreatedAt time.Time `json:"created_at"`
+}
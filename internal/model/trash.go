@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// TrashItem is an object removed to the recycle bin of a storage
+type TrashItem struct {
+	// ID is the folder of the removal in the recycle bin, an ID may have
+	// several items removed from the same folder at the same time
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Path is the original path of the item
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	IsDir     bool      `json:"is_dir"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
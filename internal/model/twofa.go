@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// AppPassword is a password for the clients that can't do 2FA, such as
+// WebDAV and FTP clients, the main password is refused by them once 2FA is
+// enabled
+type AppPassword struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Title     string    `json:"title"`
+	Hash      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebAuthnCredential is a security key or passkey registered by the user
+type WebAuthnCredential struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	Title  string `json:"title"`
+	// CredentialID is the base64url of the id of the credential
+	CredentialID string `json:"credential_id" gorm:"unique"`
+	// Credential is the json of webauthn.Credential
+	Credential string    `json:"-" gorm:"type:text"`
+	LastUsed   time.Time `json:"last_used"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/utils"
+)
+
+// UploadRule routes the uploads it matches to DstPath and runs the actions
+// after the uploads, the enabled rule of the smallest priority matching an
+// upload is applied. The empty conditions match any upload.
+type UploadRule struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority" gorm:"index"`
+	Disabled bool   `json:"disabled"`
+	// Extensions are the comma separated extensions of the files, such as
+	// "jpg,png"
+	Extensions string `json:"extensions"`
+	// MinSize and MaxSize are the sizes in bytes, 0 means no limit
+	MinSize int64 `json:"min_size"`
+	MaxSize int64 `json:"max_size"`
+	// SrcPath is the dir the files are uploaded to, including its sub dirs
+	SrcPath string `json:"src_path"`
+	// Uploaders are the comma separated usernames of the uploaders
+	Uploaders string `json:"uploaders"`
+	// DstPath is the dir the files are stored to instead, it's kept if
+	// empty. {user}, {ext}, {year}, {month} and {day} are replaced with the
+	// uploader, the extension and the date of the upload.
+	DstPath string `json:"dst_path"`
+
+	// Thumbnail generates the thumbnail of the file after the upload
+	Thumbnail bool `json:"thumbnail"`
+	// CopyTo is the dir the file is copied to after the upload, such as the
+	// mount of an ipfs storage to pin the file
+	CopyTo string `json:"copy_to"`
+	// WebhookID is the webhook the upload is posted to, whatever events it
+	// accepts
+	WebhookID uint `json:"webhook_id"`
+}
+
+// Match reports whether the upload of the file of name and size to dir by
+// username matches the rule
+func (r UploadRule) Match(dir, name string, size int64, username string) bool {
+	if r.Disabled {
+		return false
+	}
+	if r.Extensions != "" && !inList(r.Extensions, strings.ToLower(utils.Ext(name))) {
+		return false
+	}
+	if (r.MinSize > 0 && size < r.MinSize) || (r.MaxSize > 0 && size > r.MaxSize) {
+		return false
+	}
+	if r.SrcPath != "" && !utils.IsSubPath(r.SrcPath, dir) {
+		return false
+	}
+	if r.Uploaders != "" && !inList(r.Uploaders, username) {
+		return false
+	}
+	return true
+}
+
+// Target returns the dir the upload of the file of name to dir by username
+// at t is stored to
+func (r UploadRule) Target(dir, name, username string, t time.Time) string {
+	if r.DstPath == "" {
+		return dir
+	}
+	target := strings.NewReplacer(
+		"{user}", username,
+		"{ext}", strings.ToLower(utils.Ext(name)),
+		"{year}", t.Format("2006"),
+		"{month}", t.Format("01"),
+		"{day}", t.Format("02"),
+	).Replace(r.DstPath)
+	return utils.FixAndCleanPath(stdpath.Clean(target))
+}
+
+func inList(list, v string) bool {
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); s != "" && strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
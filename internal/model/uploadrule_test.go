@@ -0,0 +1,18 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUploadRuleTarget(t *testing.T) {
+	r := UploadRule{DstPath: "/archive/{user}/{year}-{month}/"}
+	at := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	if got := r.Target("/in", "a.mp4", "bob", at); got != "/archive/bob/2023-07" {
+		t.Errorf("unexpected target: %s", got)
+	}
+	r = UploadRule{Uploaders: "bob, alice", MinSize: 10, MaxSize: 20}
+	if !r.Match("/any", "a", 15, "alice") || r.Match("/any", "a", 15, "eve") || r.Match("/any", "a", 25, "bob") {
+		t.Errorf("expect the uploaders and the sizes are matched")
+	}
+}
@@ -33,7 +33,11 @@ type User struct {
 	//  10: can add qbittorrent tasks
 	Permission int32  `json:"permission"`
 	OtpSecret  string `json:"-"`
-	SsoID      string `json:"sso_id"`
+	// RecoveryCodes is the sha256 of the unused recovery codes of 2FA,
+	// separated by commas
+	RecoveryCodes string `json:"-" gorm:"type:text"`
+	SsoID         string `json:"sso_id"`
+	Limit
 }
 
 func (u User) IsGuest() bool {
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// FileVersion is a previous version of a file
+type FileVersion struct {
+	ID       string    `json:"id"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "strings"
+
+// Webhook is an endpoint that the events are posted to, the body is signed
+// with the secret by hmac-sha256
+type Webhook struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Name   string `json:"name"`
+	URL    string `json:"url" binding:"required"`
+	Secret string `json:"secret"`
+	// Events is the comma separated types of events to post, empty means all
+	Events   string `json:"events"`
+	Disabled bool   `json:"disabled"`
+}
+
+// Accept reports whether the events of typ should be posted to the webhook
+func (w Webhook) Accept(typ string) bool {
+	if w.Disabled {
+		return false
+	}
+	if strings.TrimSpace(w.Events) == "" {
+		return true
+	}
+	for _, e := range strings.Split(w.Events, ",") {
+		if strings.TrimSpace(e) == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package op
+
+import (
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// the rules and memberships are few and checked on every fs operation, so
+// all of them are kept in memory and reloaded after any change
+type aclCache struct {
+	rules  []model.ACLRule
+	groups map[uint][]uint // user id -> group ids
+}
+
+var (
+	aclMu     sync.RWMutex
+	aclLoaded *aclCache
+	// aclGen is increased by every clear, so that a load that raced with a
+	// change doesn't keep the stale rules
+	aclGen uint64
+)
+
+func clearACLCache() {
+	clearCache(cacheACL, "")
+}
+
+func dropACLCache() {
+	aclMu.Lock()
+	aclLoaded = nil
+	aclGen++
+	aclMu.Unlock()
+}
+
+func loadACLCache() (*aclCache, error) {
+	aclMu.RLock()
+	c, gen := aclLoaded, aclGen
+	aclMu.RUnlock()
+	if c != nil {
+		return c, nil
+	}
+	rules, err := db.GetAllACLRules()
+	if err != nil {
+		return nil, err
+	}
+	ugs, err := db.GetUserGroups()
+	if err != nil {
+		return nil, err
+	}
+	c = &aclCache{rules: rules, groups: make(map[uint][]uint)}
+	for _, ug := range ugs {
+		c.groups[ug.UserID] = append(c.groups[ug.UserID], ug.GroupID)
+	}
+	aclMu.Lock()
+	if gen == aclGen {
+		aclLoaded = c
+	}
+	aclMu.Unlock()
+	return c, nil
+}
+
+// subjectRank returns how specific the subject of the rule is for the
+// user, or -1 if the rule doesn't apply to the user
+func (c *aclCache) subjectRank(r *model.ACLRule, user *model.User) int {
+	switch {
+	case r.UserID != 0:
+		if user != nil && r.UserID == user.ID {
+			return 2
+		}
+	case r.GroupID != 0:
+		if user != nil && utils.SliceContains(c.groups[user.ID], r.GroupID) {
+			return 1
+		}
+	default:
+		return 0
+	}
+	return -1
+}
+
+// CheckPermission evaluates the acl rules of the perm on the path for the
+// user, which is nil for anonymous requests like signed downloads. decided
+// is false if no rule covers it, then the callers fall back to the
+// permissions of the user.
+func CheckPermission(user *model.User, path string, perm int32) (allowed bool, decided bool) {
+	if user != nil && user.IsAdmin() {
+		return true, true
+	}
+	c, err := loadACLCache()
+	if err != nil {
+		log.Errorf("failed load acl rules: %+v", err)
+		return false, true
+	}
+	path = utils.FixAndCleanPath(path)
+	bestDepth, bestRank := -1, -1
+	for i := range c.rules {
+		r := &c.rules[i]
+		if (r.Allow|r.Deny)&perm == 0 || !utils.IsSubPath(r.Path, path) {
+			continue
+		}
+		rank := c.subjectRank(r, user)
+		if rank < 0 {
+			continue
+		}
+		// the rule paths are ancestors of path, so the longer the deeper
+		depth := len(r.Path)
+		if depth < bestDepth || (depth == bestDepth && rank < bestRank) {
+			continue
+		}
+		deny := r.Deny&perm != 0
+		if depth == bestDepth && rank == bestRank {
+			// deny wins at the same level
+			allowed = allowed && !deny
+			continue
+		}
+		bestDepth, bestRank = depth, rank
+		allowed, decided = !deny, true
+	}
+	return allowed, decided
+}
+
+// DeniedBelow reports whether a rule under path, not on path itself,
+// denies the user any of perms. The operations on a whole dir check it, as
+// they would bypass the rules of the objs in the dir.
+func DeniedBelow(user *model.User, path string, perms int32) bool {
+	if user != nil && user.IsAdmin() {
+		return false
+	}
+	c, err := loadACLCache()
+	if err != nil {
+		log.Errorf("failed load acl rules: %+v", err)
+		return true
+	}
+	path = utils.FixAndCleanPath(path)
+	for i := range c.rules {
+		r := &c.rules[i]
+		if r.Deny&perms == 0 || r.Path == path || !utils.IsSubPath(path, r.Path) {
+			continue
+		}
+		if c.subjectRank(r, user) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func GetACLRuleById(id uint) (*model.ACLRule, error) {
+	return db.GetACLRuleById(id)
+}
+
+func GetACLRules(pageIndex, pageSize int) ([]model.ACLRule, int64, error) {
+	return db.GetACLRules(pageIndex, pageSize)
+}
+
+func CreateACLRule(r *model.ACLRule) error {
+	r.Path = utils.FixAndCleanPath(r.Path)
+	defer clearACLCache()
+	return db.CreateACLRule(r)
+}
+
+func UpdateACLRule(r *model.ACLRule) error {
+	r.Path = utils.FixAndCleanPath(r.Path)
+	if _, err := db.GetACLRuleById(r.ID); err != nil {
+		return err
+	}
+	defer clearACLCache()
+	return db.UpdateACLRule(r)
+}
+
+func DeleteACLRuleById(id uint) error {
+	defer clearACLCache()
+	return db.DeleteACLRuleById(id)
+}
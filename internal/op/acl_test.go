@@ -0,0 +1,77 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestCheckPermission(t *testing.T) {
+	alice := &model.User{Username: "acl_alice"}
+	bob := &model.User{Username: "acl_bob"}
+	for _, u := range []*model.User{alice, bob} {
+		if err := db.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	team := &model.Group{Name: "acl_team"}
+	if err := op.CreateGroup(team); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.AddUserToGroup(alice.ID, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	rules := []model.ACLRule{
+		{Path: "/acl", Deny: model.PermWrite | model.PermProxy},
+		{Path: "/acl/team", GroupID: team.ID, Allow: model.PermWrite},
+		{Path: "/acl/team/private", Deny: model.PermRead},
+		{Path: "/acl/team/private", UserID: alice.ID, Allow: model.PermRead},
+		{Path: "/acl/team/locked", GroupID: team.ID, Allow: model.PermDelete, Deny: model.PermDelete},
+	}
+	for i := range rules {
+		if err := op.CreateACLRule(&rules[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		user    *model.User
+		path    string
+		perm    int32
+		allowed bool
+		decided bool
+	}{
+		{alice, "/other", model.PermWrite, false, false},
+		{alice, "/aclx", model.PermWrite, false, false},
+		{alice, "/acl/a.txt", model.PermWrite, false, true},
+		{nil, "/acl/a.txt", model.PermProxy, false, true},
+		{alice, "/acl/team/a.txt", model.PermWrite, true, true},
+		{bob, "/acl/team/a.txt", model.PermWrite, false, true},
+		{alice, "/acl/team/private/a.txt", model.PermRead, true, true},
+		{bob, "/acl/team/private/a.txt", model.PermRead, false, true},
+		{alice, "/acl/team/locked", model.PermDelete, false, true},
+		{&model.User{Role: model.ADMIN}, "/acl/team/private", model.PermRead, true, true},
+	}
+	for _, tt := range tests {
+		allowed, decided := op.CheckPermission(tt.user, tt.path, tt.perm)
+		if allowed != tt.allowed || decided != tt.decided {
+			t.Errorf("%v %s %d: expect %v %v, got %v %v", tt.user, tt.path, tt.perm, tt.allowed, tt.decided, allowed, decided)
+		}
+	}
+	// the dirs with denied objs in them can't be moved or removed as a whole
+	if !op.DeniedBelow(bob, "/acl/team", model.PermRead) || !op.DeniedBelow(alice, "/", model.PermDelete) || op.DeniedBelow(bob, "/", model.PermDelete) {
+		t.Errorf("expect the rules of the user under the dir are found")
+	}
+	if op.DeniedBelow(bob, "/acl/team/private", model.PermRead) || op.DeniedBelow(bob, "/acl/team", model.PermShare) ||
+		op.DeniedBelow(&model.User{Role: model.ADMIN}, "/acl", model.PermRead) {
+		t.Errorf("expect only the rules strictly under the dir of the perms count")
+	}
+	// the cache is reloaded after changes
+	if err := op.RemoveUserFromGroup(alice.ID, team.ID); err != nil {
+		t.Fatal(err)
+	}
+	if allowed, _ := op.CheckPermission(alice, "/acl/team/a.txt", model.PermWrite); allowed {
+		t.Errorf("expect write denied after leaving the group")
+	}
+}
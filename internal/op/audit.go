@@ -0,0 +1,76 @@
+package op
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	auditLogs = make(chan model.AuditLog, 1024)
+	auditOnce sync.Once
+)
+
+// RecordAudit saves the audit log in background, so that the requests are
+// not slowed down by the database. The logs are dropped if the database
+// can't keep up.
+func RecordAudit(l model.AuditLog) {
+	auditOnce.Do(func() {
+		go writeAuditLogs()
+	})
+	if l.Time.IsZero() {
+		l.Time = time.Now()
+	}
+	select {
+	case auditLogs <- l:
+	default:
+		log.Warnf("audit log dropped: %+v", l)
+	}
+}
+
+// writeAuditLogs saves the logs in batches
+func writeAuditLogs() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	var batch []model.AuditLog
+	for {
+		select {
+		case l := <-auditLogs:
+			batch = append(batch, l)
+			if len(batch) < 100 {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := db.CreateAuditLogs(batch); err != nil {
+			log.Errorf("failed save %d audit logs: %+v", len(batch), err)
+		}
+		batch = nil
+	}
+}
+
+func GetAuditLogs(f model.AuditFilter, pageIndex, pageSize int) ([]model.AuditLog, int64, error) {
+	return db.GetAuditLogs(f, pageIndex, pageSize)
+}
+
+func IterAuditLogs(f model.AuditFilter, fn func(logs []model.AuditLog) error) error {
+	return db.IterAuditLogs(f, fn)
+}
+
+// CleanAuditLogs deletes the logs older than the retention
+func CleanAuditLogs(retention time.Duration) {
+	n, err := db.DeleteAuditLogsBefore(time.Now().Add(-retention))
+	if err != nil {
+		log.Errorf("failed clean audit logs: %+v", err)
+		return
+	}
+	if n > 0 {
+		log.Infof("%d audit logs older than %s are cleaned", n, retention)
+	}
+}
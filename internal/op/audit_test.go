@@ -0,0 +1,46 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestAuditLogs(t *testing.T) {
+	now := time.Now()
+	logs := []model.AuditLog{
+		{Time: now.Add(-48 * time.Hour), Username: "admin", Action: "login"},
+		{Time: now.Add(-time.Hour), Username: "admin", Action: "file.remove", Target: "/a_b/c"},
+		{Time: now, Username: "guest", Action: "file.remove", Target: "/a%b/c"},
+	}
+	if err := db.CreateAuditLogs(logs); err != nil {
+		t.Fatalf("failed create audit logs: %+v", err)
+	}
+	got, total, err := op.GetAuditLogs(model.AuditFilter{Action: "file.remove"}, 1, 10)
+	if err != nil {
+		t.Fatalf("failed get audit logs: %+v", err)
+	}
+	if total != 2 || got[0].Username != "guest" {
+		t.Errorf("expect the 2 removes with the latest first, got %d: %+v", total, got)
+	}
+	_, total, _ = op.GetAuditLogs(model.AuditFilter{Target: "/a_"}, 1, 10)
+	if total != 1 {
+		t.Errorf("expect the wildcards in the target are escaped, got %d", total)
+	}
+	_, total, _ = op.GetAuditLogs(model.AuditFilter{Since: now.Add(-2 * time.Hour)}, 1, 10)
+	if total != 2 {
+		t.Errorf("expect 2 logs since 2 hours ago, got %d", total)
+	}
+	op.CleanAuditLogs(24 * time.Hour)
+	var exported int
+	err = op.IterAuditLogs(model.AuditFilter{}, func(logs []model.AuditLog) error {
+		exported += len(logs)
+		return nil
+	})
+	if err != nil || exported != 2 {
+		t.Errorf("expect 2 logs left after cleaning, got %d: %+v", exported, err)
+	}
+}
@@ -0,0 +1,49 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/driver"
+)
+
+// GetCapabilities returns the capabilities of the storage, derived from
+// the interfaces the driver implements and its config
+func GetCapabilities(storage driver.Driver) driver.Capabilities {
+	caps := driver.Capabilities{
+		List:      true,
+		RangeRead: true,
+	}
+	switch storage.(type) {
+	case driver.Mkdir, driver.MkdirResult:
+		caps.MakeDir = true
+	}
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		caps.Move = true
+	}
+	switch storage.(type) {
+	case driver.Rename, driver.RenameResult:
+		caps.Rename = true
+	}
+	switch storage.(type) {
+	case driver.Copy, driver.CopyResult:
+		caps.Copy = true
+	}
+	if _, ok := storage.(driver.Remove); ok {
+		caps.Remove = true
+	}
+	switch storage.(type) {
+	case driver.Put, driver.PutResult:
+		caps.Upload = !storage.Config().NoUpload
+	}
+	if _, ok := storage.(driver.GetSpacer); ok {
+		caps.Space = true
+	}
+	if _, ok := storage.(driver.Versioner); ok || storage.GetStorage().KeepVersions > 0 {
+		caps.Versions = true
+	}
+	caps.Trash = UsesTrash(storage) && caps.Move && caps.MakeDir
+	caps.DirectLink = !storage.Config().MustProxy() && !storage.GetStorage().WebProxy
+	if c, ok := storage.(driver.Capabler); ok {
+		c.Capabilities(&caps)
+	}
+	return caps
+}
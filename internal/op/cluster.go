@@ -0,0 +1,97 @@
+package op
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	topicCache    = "cache"
+	topicStorages = "storages"
+	topicSyncJob  = "sync_job"
+)
+
+// syncJobChange reschedules the sync job on the other instances
+type syncJobChange struct {
+	Type string        `json:"type"`
+	Job  model.SyncJob `json:"job"`
+}
+
+// the kinds of the caches kept in the memory, which are cleared on the other
+// instances of the cluster after any change
+const (
+	cacheUser       = "user"
+	cacheMeta       = "meta"
+	cacheSetting    = "setting"
+	cacheACL        = "acl"
+	cacheWebhook    = "webhook"
+	cacheSymlink    = "symlink"
+	cacheClientAuth = "client_auth"
+	cacheUploadRule = "upload_rule"
+)
+
+var cacheDroppers = map[string]func(key string){
+	cacheUser: func(key string) {
+		userCache.Del(key)
+		adminUser, guestUser = nil, nil
+	},
+	cacheMeta: func(key string) { metaCache.Del(key) },
+	cacheSetting: func(string) {
+		// the hooks of the settings apply them to the memory
+		if err := ReloadSettings(); err != nil {
+			log.Errorf("failed reload settings changed by the cluster: %+v", err)
+		}
+	},
+	cacheACL:        func(string) { dropACLCache() },
+	cacheWebhook:    func(string) { dropWebhookCache() },
+	cacheSymlink:    func(string) { dropSymlinkCache() },
+	cacheClientAuth: func(key string) { clientAuthCache.Del(key) },
+	cacheUploadRule: func(string) { dropUploadRuleCache() },
+}
+
+func init() {
+	cluster.Subscribe(topicCache, func(data string) {
+		kind, key, _ := strings.Cut(data, ":")
+		if drop, ok := cacheDroppers[kind]; ok {
+			drop(key)
+		}
+	})
+	cluster.Subscribe(topicSyncJob, func(data string) {
+		var c syncJobChange
+		if err := utils.Json.UnmarshalFromString(data, &c); err != nil {
+			log.Errorf("failed decode change of sync job: %+v", err)
+			return
+		}
+		runSyncJobHooks(c.Type, c.Job)
+	})
+	cluster.Subscribe(topicStorages, func(string) {
+		res, err := ReloadStorages(context.Background())
+		if err != nil {
+			log.Errorf("failed reload storages changed by the cluster: %+v", err)
+		}
+		if res != nil {
+			log.Infof("storages changed by the cluster reloaded, added: %v, updated: %v, removed: %v",
+				res.Added, res.Updated, res.Removed)
+		}
+	})
+}
+
+// clearCache clears the cache of kind here and on the other instances
+func clearCache(kind, key string) {
+	cacheDroppers[kind](key)
+	publishCacheClear(kind, key)
+}
+
+func publishCacheClear(kind, key string) {
+	cluster.Publish(topicCache, kind+":"+key)
+}
+
+// storagesChanged tells the other instances to reload the storages
+func storagesChanged() {
+	cluster.Publish(topicStorages, "")
+}
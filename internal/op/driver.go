@@ -19,10 +19,31 @@ func RegisterDriver(driver New) {
 	// log.Infof("register driver: [%s]", config.Name)
 	tempDriver := driver()
 	tempConfig := tempDriver.Config()
-	registerDriverItems(tempConfig, tempDriver.GetAddition())
+	registerDriverItems(tempConfig, tempDriver.GetAddition(), isVersioner(tempDriver))
 	driverNewMap[tempConfig.Name] = driver
 }
 
+// RegisterExternalDriver registers the driver of config whose additional
+// items are given rather than read from the addition, such as the drivers
+// served by the plugins
+func RegisterExternalDriver(config driver.Config, additional []driver.Item, new New) error {
+	if _, ok := driverNewMap[config.Name]; ok {
+		return errors.Errorf("driver %s is registered already", config.Name)
+	}
+	driverInfoMap[config.Name] = driver.Info{
+		Common:     getMainItems(config, false),
+		Additional: additional,
+		Config:     config,
+	}
+	driverNewMap[config.Name] = new
+	return nil
+}
+
+func isVersioner(d driver.Driver) bool {
+	_, ok := d.(driver.Versioner)
+	return ok
+}
+
 func GetDriverNew(name string) (New, error) {
 	n, ok := driverNewMap[name]
 	if !ok {
@@ -43,13 +64,15 @@ func GetDriverInfoMap() map[string]driver.Info {
 	return driverInfoMap
 }
 
-func registerDriverItems(config driver.Config, addition driver.Additional) {
+// registerDriverItems registers the items of the storage form, versioner
+// means the driver keeps the versions of files itself
+func registerDriverItems(config driver.Config, addition driver.Additional, versioner bool) {
 	// log.Debugf("addition of %s: %+v", config.Name, addition)
 	tAddition := reflect.TypeOf(addition)
 	for tAddition.Kind() == reflect.Pointer {
 		tAddition = tAddition.Elem()
 	}
-	mainItems := getMainItems(config)
+	mainItems := getMainItems(config, versioner)
 	additionalItems := getAdditionalItems(tAddition, config.DefaultRoot)
 	driverInfoMap[config.Name] = driver.Info{
 		Common:     mainItems,
@@ -58,7 +81,7 @@ func registerDriverItems(config driver.Config, addition driver.Additional) {
 	}
 }
 
-func getMainItems(config driver.Config) []driver.Item {
+func getMainItems(config driver.Config, versioner bool) []driver.Item {
 	items := []driver.Item{{
 		Name:     "mount_path",
 		Type:     conf.TypeString,
@@ -128,6 +151,35 @@ func getMainItems(config driver.Config) []driver.Item {
 		Default:  "false",
 		Required: true,
 	})
+	if !versioner {
+		items = append(items, driver.Item{
+			Name:    "keep_versions",
+			Type:    conf.TypeNumber,
+			Default: "0",
+			Help:    "previous versions kept in the .versions folder when a file is overwritten, 0 disables it",
+		})
+	}
+	items = append(items, []driver.Item{{
+		Name:    "download_limit",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "bytes per second of proxied downloads, 0 means unlimited",
+	}, {
+		Name:    "upload_limit",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "bytes per second of uploads, 0 means unlimited",
+	}, {
+		Name:    "max_transfers",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "max concurrent proxied downloads and uploads, 0 means unlimited",
+	}, {
+		Name:    "quota",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "max bytes each user can upload, 0 means unlimited",
+	}}...)
 	return items
 }
 func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
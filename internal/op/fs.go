@@ -9,7 +9,10 @@ import (
 	"github.com/Xhofe/go-cache"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/listcache"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/singleflight"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -19,9 +22,31 @@ import (
 
 // In order to facilitate adding some other things before and after file op
 
-var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
+var listCache listcache.Cache = listcache.NewMemory()
 var listG singleflight.Group[[]model.Obj]
 
+// SetListCache replaces the backend of the listing cache, the listings
+// cached by the old one are dropped
+func SetListCache(c listcache.Cache) {
+	old := listCache
+	listCache = c
+	_ = old.Close()
+}
+
+// CloseListCache flushes and closes the backend of the listing cache
+func CloseListCache() error {
+	return listCache.Close()
+}
+
+func setCache(storage driver.Driver, key string, objs []model.Obj) {
+	expiration := storage.GetStorage().CacheExpiration
+	if expiration <= 0 {
+		listCache.Del(key)
+		return
+	}
+	listCache.Set(key, objs, time.Minute*time.Duration(expiration))
+}
+
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
 	key := Key(storage, path)
 	objs, ok := listCache.Get(key)
@@ -32,7 +57,7 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		setCache(storage, key, objs)
 	}
 }
 
@@ -46,7 +71,7 @@ func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 				break
 			}
 		}
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		setCache(storage, key, objs)
 	}
 }
 
@@ -59,6 +84,7 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 		for i, obj := range objs {
 			if obj.GetName() == newObj.GetName() {
 				objs[i] = newObj
+				setCache(storage, key, objs)
 				return
 			}
 		}
@@ -76,11 +102,15 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 			debounce(func() {
 				log.Debug("addCacheObj: start sort")
 				model.SortFiles(objs, storage.GetStorage().OrderBy, storage.GetStorage().OrderDirection)
+				// the backend may keep a copy of the objs
+				if _, ok := listCache.Get(key); ok {
+					setCache(storage, key, objs)
+				}
 				addSortDebounceMap.Delete(key)
 			})
 		}
 
-		listCache.Set(key, objs, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+		setCache(storage, key, objs)
 	}
 }
 
@@ -88,6 +118,12 @@ func ClearCache(storage driver.Driver, path string) {
 	listCache.Del(Key(storage, path))
 }
 
+// ClearTreeCache clears the listings of the dir and all dirs below it, the
+// paths below a moved, renamed or removed dir are no longer valid
+func ClearTreeCache(storage driver.Driver, path string) {
+	listCache.DelTree(Key(storage, path))
+}
+
 func Key(storage driver.Driver, path string) string {
 	return stdpath.Join(storage.GetStorage().MountPath, utils.FixAndCleanPath(path))
 }
@@ -101,7 +137,9 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !utils.IsBool(refresh...) {
-		if files, ok := listCache.Get(key); ok {
+		files, ok := listCache.Get(key)
+		metrics.CacheResult("list", ok)
+		if ok {
 			log.Debugf("use cache when list %s", path)
 			return files, nil
 		}
@@ -115,8 +153,11 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
+		ctx, end := traceDriver(ctx, storage, "list", path)
 		files, err := storage.List(ctx, dir, args)
+		end(err)
 		if err != nil {
+			countDriverError(storage, "list", err)
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
 		// set path
@@ -143,7 +184,7 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !storage.Config().NoCache {
 			if len(files) > 0 {
 				log.Debugf("set cache: %s => %+v", key, files)
-				listCache.Set(key, files, cache.WithEx[[]model.Obj](time.Minute*time.Duration(storage.GetStorage().CacheExpiration)))
+				setCache(storage, key, files)
 			} else {
 				log.Debugf("del cache: %s", key)
 				listCache.Del(key)
@@ -161,7 +202,9 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 
 	// get the obj directly without list so that we can reduce the io
 	if g, ok := storage.(driver.Getter); ok {
+		ctx, end := traceDriver(ctx, storage, "get", path)
 		obj, err := g.Get(ctx, path)
+		end(err)
 		if err == nil {
 			return model.WrapObjName(obj), nil
 		}
@@ -244,12 +287,17 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, nil, errors.WithStack(errs.NotFile)
 	}
 	key := Key(storage, path) + ":" + args.IP
-	if link, ok := linkCache.Get(key); ok {
+	link, ok := linkCache.Get(key)
+	metrics.CacheResult("link", ok)
+	if ok {
 		return link, file, nil
 	}
 	fn := func() (*model.Link, error) {
+		ctx, end := traceDriver(ctx, storage, "link", path)
 		link, err := storage.Link(ctx, file, args)
+		end(err)
 		if err != nil {
+			countDriverError(storage, "link", err)
 			return nil, errors.Wrapf(err, "failed get link")
 		}
 		if link.Expiration != nil {
@@ -257,7 +305,7 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		}
 		return link, nil
 	}
-	link, err, _ := linkG.Do(key, fn)
+	link, err, _ = linkG.Do(key, fn)
 	return link, file, err
 }
 
@@ -278,6 +326,17 @@ func Other(ctx context.Context, storage driver.Driver, args model.FsOtherArgs) (
 	}
 }
 
+// GetSpace get the capacity of the storage
+func GetSpace(ctx context.Context, storage driver.Driver) (*model.StorageSpace, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	if s, ok := storage.(driver.GetSpacer); ok {
+		return s.GetSpace(ctx)
+	}
+	return nil, errs.NotImplement
+}
+
 var mkdirG singleflight.Group[interface{}]
 
 func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache ...bool) error {
@@ -302,10 +361,11 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 					return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
 				}
 
+				dctx, end := traceDriver(ctx, storage, "make_dir", path)
 				switch s := storage.(type) {
 				case driver.MkdirResult:
 					var newObj model.Obj
-					newObj, err = s.MakeDir(ctx, parentDir, dirName)
+					newObj, err = s.MakeDir(dctx, parentDir, dirName)
 					if err == nil {
 						if newObj != nil {
 							addCacheObj(storage, parentPath, model.WrapObjName(newObj))
@@ -314,13 +374,19 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 						}
 					}
 				case driver.Mkdir:
-					err = s.MakeDir(ctx, parentDir, dirName)
+					err = s.MakeDir(dctx, parentDir, dirName)
 					if err == nil && !utils.IsBool(lazyCache...) {
 						ClearCache(storage, parentPath)
 					}
 				default:
+					end(errs.NotImplement)
 					return nil, errs.NotImplement
 				}
+				end(err)
+				countDriverError(storage, "make_dir", err)
+				if err == nil {
+					handleObjsChange(storage, parentPath)
+				}
 				return nil, errors.WithStack(err)
 			}
 			return nil, errors.WithMessage(err, "failed to check if dir exists")
@@ -352,10 +418,11 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	}
 	srcDirPath := stdpath.Dir(srcPath)
 
+	dctx, end := traceDriver(ctx, storage, "move", srcPath)
 	switch s := storage.(type) {
 	case driver.MoveResult:
 		var newObj model.Obj
-		newObj, err = s.Move(ctx, srcObj, dstDir)
+		newObj, err = s.Move(dctx, srcObj, dstDir)
 		if err == nil {
 			delCacheObj(storage, srcDirPath, srcRawObj)
 			if newObj != nil {
@@ -365,7 +432,7 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			}
 		}
 	case driver.Move:
-		err = s.Move(ctx, srcObj, dstDir)
+		err = s.Move(dctx, srcObj, dstDir)
 		if err == nil {
 			delCacheObj(storage, srcDirPath, srcRawObj)
 			if !utils.IsBool(lazyCache...) {
@@ -373,8 +440,18 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			}
 		}
 	default:
+		end(errs.NotImplement)
 		return errs.NotImplement
 	}
+	end(err)
+	countDriverError(storage, "move", err)
+	if err == nil {
+		if srcObj.IsDir() {
+			ClearTreeCache(storage, srcPath)
+			ClearTreeCache(storage, stdpath.Join(dstDirPath, srcObj.GetName()))
+		}
+		handleObjsChange(storage, srcDirPath, dstDirPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -390,10 +467,11 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	srcObj := model.UnwrapObj(srcRawObj)
 	srcDirPath := stdpath.Dir(srcPath)
 
+	dctx, end := traceDriver(ctx, storage, "rename", srcPath)
 	switch s := storage.(type) {
 	case driver.RenameResult:
 		var newObj model.Obj
-		newObj, err = s.Rename(ctx, srcObj, dstName)
+		newObj, err = s.Rename(dctx, srcObj, dstName)
 		if err == nil {
 			if newObj != nil {
 				updateCacheObj(storage, srcDirPath, srcRawObj, model.WrapObjName(newObj))
@@ -402,13 +480,23 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 			}
 		}
 	case driver.Rename:
-		err = s.Rename(ctx, srcObj, dstName)
+		err = s.Rename(dctx, srcObj, dstName)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, srcDirPath)
 		}
 	default:
+		end(errs.NotImplement)
 		return errs.NotImplement
 	}
+	end(err)
+	countDriverError(storage, "rename", err)
+	if err == nil {
+		if srcObj.IsDir() {
+			ClearTreeCache(storage, srcPath)
+			ClearTreeCache(storage, stdpath.Join(srcDirPath, dstName))
+		}
+		handleObjsChange(storage, srcDirPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -428,10 +516,11 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
 
+	dctx, end := traceDriver(ctx, storage, "copy", srcPath)
 	switch s := storage.(type) {
 	case driver.CopyResult:
 		var newObj model.Obj
-		newObj, err = s.Copy(ctx, srcObj, dstDir)
+		newObj, err = s.Copy(dctx, srcObj, dstDir)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -440,13 +529,22 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			}
 		}
 	case driver.Copy:
-		err = s.Copy(ctx, srcObj, dstDir)
+		err = s.Copy(dctx, srcObj, dstDir)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
 	default:
+		end(errs.NotImplement)
 		return errs.NotImplement
 	}
+	end(err)
+	countDriverError(storage, "copy", err)
+	if err == nil {
+		if srcObj.IsDir() {
+			ClearTreeCache(storage, stdpath.Join(dstDirPath, srcObj.GetName()))
+		}
+		handleObjsChange(storage, dstDirPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -466,15 +564,25 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	}
 	dirPath := stdpath.Dir(path)
 
+	dctx, end := traceDriver(ctx, storage, "remove", path)
 	switch s := storage.(type) {
 	case driver.Remove:
-		err = s.Remove(ctx, model.UnwrapObj(rawObj))
+		err = s.Remove(dctx, model.UnwrapObj(rawObj))
 		if err == nil {
 			delCacheObj(storage, dirPath, rawObj)
+			if rawObj.IsDir() {
+				ClearTreeCache(storage, path)
+			}
 		}
 	default:
+		end(errs.NotImplement)
 		return errs.NotImplement
 	}
+	end(err)
+	countDriverError(storage, "remove", err)
+	if err == nil {
+		handleObjsChange(storage, dirPath)
+	}
 	return errors.WithStack(err)
 }
 
@@ -482,6 +590,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	transfers := metrics.ActiveTransfers.WithLabelValues("upload")
+	transfers.Inc()
+	defer transfers.Dec()
 	defer func() {
 		if f, ok := file.GetReadCloser().(*os.File); ok {
 			err := os.RemoveAll(f.Name())
@@ -502,6 +613,11 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
 	if err == nil {
+		if fi.GetSize() > 0 {
+			if err := saveVersion(ctx, storage, dstPath, fi); err != nil {
+				return errors.WithMessagef(err, "failed keep the previous version")
+			}
+		}
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
 			if err != nil {
@@ -531,10 +647,11 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 		up = func(p int) {}
 	}
 
+	dctx, end := traceDriver(ctx, storage, "put", dstPath)
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
-		newObj, err = s.Put(ctx, parentDir, file, up)
+		newObj, err = s.Put(dctx, parentDir, file, up)
 		if err == nil {
 			if newObj != nil {
 				addCacheObj(storage, dstDirPath, model.WrapObjName(newObj))
@@ -543,13 +660,19 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 			}
 		}
 	case driver.Put:
-		err = s.Put(ctx, parentDir, file, up)
+		err = s.Put(dctx, parentDir, file, up)
 		if err == nil && !utils.IsBool(lazyCache...) {
 			ClearCache(storage, dstDirPath)
 		}
 	default:
+		end(errs.NotImplement)
 		return errs.NotImplement
 	}
+	end(err)
+	countDriverError(storage, "put", err)
+	if err == nil {
+		handleObjsChange(storage, dstDirPath)
+	}
 	log.Debugf("put file [%s] done", file.GetName())
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
@@ -568,3 +691,16 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file *mo
 	}
 	return errors.WithStack(err)
 }
+
+// traceDriver starts the span of the call to the method of the driver on
+// path, the returned func ends it with the error of the call
+func traceDriver(ctx context.Context, storage driver.Driver, method, path string) (context.Context, func(err error)) {
+	return tracing.StartDriver(ctx, storage.GetStorage().Driver, storage.GetStorage().MountPath, method, path)
+}
+
+// countDriverError counts the error of the driver for the metrics
+func countDriverError(storage driver.Driver, method string, err error) {
+	if err != nil {
+		metrics.DriverErrors.WithLabelValues(storage.GetStorage().Driver, method).Inc()
+	}
+}
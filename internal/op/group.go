@@ -0,0 +1,88 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func CreateGroup(g *model.Group) error {
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	return db.UpdateGroup(g)
+}
+
+// DeleteGroupById deletes the group with its memberships and acl rules
+func DeleteGroupById(id uint) error {
+	defer clearACLCache()
+	if err := db.DeleteACLRulesByGroupID(id); err != nil {
+		return err
+	}
+	return db.DeleteGroupById(id)
+}
+
+func GetGroupUsers(groupID uint) ([]model.User, error) {
+	return db.GetUsersByGroupID(groupID)
+}
+
+func AddUserToGroup(userID, groupID uint) error {
+	if _, err := db.GetUserById(userID); err != nil {
+		return err
+	}
+	if _, err := db.GetGroupById(groupID); err != nil {
+		return err
+	}
+	defer clearACLCache()
+	return db.AddUserToGroup(userID, groupID)
+}
+
+// SyncUserGroupsByName makes the user a member of exactly the existing
+// groups with the names, such as the groups claimed by an identity provider,
+// the names of no groups are ignored
+func SyncUserGroupsByName(userID uint, names []string) error {
+	defer clearACLCache()
+	want := make(map[uint]struct{})
+	for _, name := range names {
+		g, err := db.GetGroupByName(name)
+		if err != nil {
+			continue
+		}
+		want[g.ID] = struct{}{}
+	}
+	has, err := db.GetGroupIDsByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range has {
+		if _, ok := want[id]; ok {
+			delete(want, id)
+			continue
+		}
+		if err := db.RemoveUserFromGroup(userID, id); err != nil {
+			return err
+		}
+	}
+	for id := range want {
+		if err := db.AddUserToGroup(userID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RemoveUserFromGroup(userID, groupID uint) error {
+	defer clearACLCache()
+	return db.RemoveUserFromGroup(userID, groupID)
+}
@@ -0,0 +1,35 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestSyncUserGroupsByName(t *testing.T) {
+	user := &model.User{Username: "sync_groups"}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sync_a", "sync_b"} {
+		if err := op.CreateGroup(&model.Group{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := op.SyncUserGroupsByName(user.ID, []string{"sync_a", "sync_b", "sync_none"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := db.GetGroupIDsByUserID(user.ID); len(ids) != 2 {
+		t.Errorf("expect the user in 2 groups, got %v", ids)
+	}
+	// the groups left out of the claim are revoked
+	if err := op.SyncUserGroupsByName(user.ID, []string{"sync_b"}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := db.GetGroupByName("sync_b")
+	if ids, _ := db.GetGroupIDsByUserID(user.ID); len(ids) != 1 || ids[0] != b.ID {
+		t.Errorf("expect the user only in sync_b, got %v", ids)
+	}
+}
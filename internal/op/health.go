@@ -0,0 +1,120 @@
+package op
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// healthCheckTimeout is the max time of a health check
+const healthCheckTimeout = 30 * time.Second
+
+var healthMap generic_sync.MapOf[string, model.StorageHealth]
+
+// GetStoragesHealth returns the health of the checked storages
+func GetStoragesHealth() []model.StorageHealth {
+	hs := healthMap.Values()
+	sort.Slice(hs, func(i, j int) bool {
+		return hs[i].MountPath < hs[j].MountPath
+	})
+	return hs
+}
+
+// IsStorageOffline reports whether the requests should not be routed to the
+// storage
+func IsStorageOffline(storage driver.Driver) bool {
+	h, ok := healthMap.Load(storage.GetStorage().MountPath)
+	return ok && h.Health == model.HealthOffline
+}
+
+// resetHealth forgets the health of the storage, it's called when the
+// storage is loaded again or removed
+func resetHealth(mountPath string) {
+	healthMap.Delete(mountPath)
+}
+
+// CheckStoragesHealth checks the storages that haven't been checked in the
+// interval in parallel, a storage is offline after the failures in a row
+func CheckStoragesHealth(ctx context.Context, interval time.Duration, failures int) {
+	var wg sync.WaitGroup
+	for _, storage := range GetAllStorages() {
+		if h, ok := healthMap.Load(storage.GetStorage().MountPath); ok && time.Since(h.LastCheck) < interval {
+			continue
+		}
+		wg.Add(1)
+		go func(storage driver.Driver) {
+			defer wg.Done()
+			CheckStorageHealth(ctx, storage, failures)
+		}(storage)
+	}
+	wg.Wait()
+}
+
+// CheckStorageHealth checks the storage and publishes the events when it
+// goes offline or comes back online
+func CheckStorageHealth(ctx context.Context, storage driver.Driver, failures int) model.StorageHealth {
+	start := time.Now()
+	err := probeStorage(ctx, storage)
+	mountPath := storage.GetStorage().MountPath
+	prev, _ := healthMap.Load(mountPath)
+	h := model.StorageHealth{
+		MountPath: mountPath,
+		Health:    model.HealthOK,
+		LastCheck: time.Now(),
+		Latency:   time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		h.Failures = prev.Failures + 1
+		h.LastError = err.Error()
+		h.Health = model.HealthDegraded
+		if h.Failures >= failures {
+			h.Health = model.HealthOffline
+		}
+	}
+	// the storage may be removed or reloaded during the check
+	if s, err := GetStorageByMountPath(mountPath); err != nil || s != storage {
+		return h
+	}
+	healthMap.Store(mountPath, h)
+	data := map[string]any{
+		"mount_path": mountPath,
+		"driver":     storage.GetStorage().Driver,
+	}
+	switch {
+	case h.Health == model.HealthOffline && prev.Health != model.HealthOffline:
+		log.Warnf("storage %s is offline after %d failed health checks: %s", mountPath, h.Failures, h.LastError)
+		data["status"] = h.LastError
+		event.Publish(event.StorageOffline, data)
+	case h.Health == model.HealthOK && prev.Health == model.HealthOffline:
+		log.Infof("storage %s is back online", mountPath)
+		event.Publish(event.StorageOnline, data)
+	}
+	return h
+}
+
+// probeStorage checks the token with the storage if it can, or lists the
+// root without the cache
+func probeStorage(ctx context.Context, storage driver.Driver) error {
+	if status := storage.GetStorage().Status; status != WORK {
+		return errors.Errorf("failed init: %s", status)
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if hc, ok := storage.(driver.HealthChecker); ok {
+		return hc.CheckHealth(ctx)
+	}
+	root, err := GetUnwrap(ctx, storage, "/")
+	if err != nil {
+		return err
+	}
+	_, err = storage.List(ctx, root, model.ListArgs{ReqPath: storage.GetStorage().MountPath})
+	return err
+}
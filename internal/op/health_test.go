@@ -0,0 +1,69 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestStorageHealth(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	if err := os.Mkdir(root, 0777); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/health",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	event.Subscribe(func(e event.Event) {
+		if e.Data["mount_path"] == "/health" {
+			events = append(events, e.Type)
+		}
+	})
+
+	if h := op.CheckStorageHealth(context.Background(), storage, 2); h.Health != model.HealthOK {
+		t.Fatalf("expect the storage is ok, got %+v", h)
+	}
+	if err := os.Remove(root); err != nil {
+		t.Fatal(err)
+	}
+	if h := op.CheckStorageHealth(context.Background(), storage, 2); h.Health != model.HealthDegraded {
+		t.Errorf("expect the storage is degraded after a failure, got %+v", h)
+	}
+	if _, _, err := op.GetStorageAndActualPath("/health/a"); err != nil {
+		t.Errorf("expect a degraded storage is still used, got %+v", err)
+	}
+	if h := op.CheckStorageHealth(context.Background(), storage, 2); h.Health != model.HealthOffline {
+		t.Errorf("expect the storage is offline after 2 failures, got %+v", h)
+	}
+	if _, _, err := op.GetStorageAndActualPath("/health/a"); !errors.Is(err, errs.StorageOffline) {
+		t.Errorf("expect an offline storage is not used, got %+v", err)
+	}
+
+	if err := os.Mkdir(root, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if h := op.CheckStorageHealth(context.Background(), storage, 2); h.Health != model.HealthOK {
+		t.Errorf("expect the storage is back online, got %+v", h)
+	}
+	if len(events) != 2 || events[0] != event.StorageOffline || events[1] != event.StorageOnline {
+		t.Errorf("expect the offline and the online events, got %v", events)
+	}
+}
@@ -2,11 +2,14 @@ package op
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -29,6 +32,26 @@ func HandleObjsUpdateHook(parent string, objs []model.Obj) {
 	}
 }
 
+// ObjsChangeHook is called after objs in parent of the storage are changed by write operations,
+// the parent is the actual path in the storage
+type ObjsChangeHook = func(storage driver.Driver, parent string)
+
+var (
+	objsChangeHooks = make([]ObjsChangeHook, 0)
+)
+
+func RegisterObjsChangeHook(hook ObjsChangeHook) {
+	objsChangeHooks = append(objsChangeHooks, hook)
+}
+
+func handleObjsChange(storage driver.Driver, parents ...string) {
+	for _, hook := range objsChangeHooks {
+		for _, parent := range parents {
+			go hook(storage, parent)
+		}
+	}
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
@@ -78,6 +101,14 @@ var settingItemHooks = map[string]SettingItemHook{
 		log.Debugf("filename char mapping: %+v", conf.FilenameCharMap)
 		return nil
 	},
+	conf.SlowOperationThreshold: func(item *model.SettingItem) error {
+		ms, err := strconv.Atoi(item.Value)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		tracing.SetSlowThreshold(time.Duration(ms) * time.Millisecond)
+		return nil
+	},
 }
 
 func RegisterSettingItemHook(key string, hook SettingItemHook) {
@@ -67,7 +67,7 @@ func DeleteMetaById(id uint) error {
 	if err != nil {
 		return err
 	}
-	metaCache.Del(old.Path)
+	clearCache(cacheMeta, old.Path)
 	return db.DeleteMetaById(id)
 }
 
@@ -77,13 +77,13 @@ func UpdateMeta(u *model.Meta) error {
 	if err != nil {
 		return err
 	}
-	metaCache.Del(old.Path)
+	clearCache(cacheMeta, old.Path)
 	return db.UpdateMeta(u)
 }
 
 func CreateMeta(u *model.Meta) error {
 	u.Path = utils.FixAndCleanPath(u.Path)
-	metaCache.Del(u.Path)
+	clearCache(cacheMeta, u.Path)
 	return db.CreateMeta(u)
 }
 
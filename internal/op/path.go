@@ -4,15 +4,17 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 // GetStorageAndActualPath Get the corresponding storage and actual path
-// for path: remove the mount path prefix and join the actual root folder if exists
+// for path: remove the mount path prefix and join the actual root folder if exists.
+// The symlinks in the path are resolved to their targets first
 func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath string, err error) {
-	rawPath = utils.FixAndCleanPath(rawPath)
+	rawPath = ResolveSymlink(rawPath)
 	storage = GetBalancedStorage(rawPath)
 	if storage == nil {
 		if rawPath == "/" {
@@ -22,6 +24,12 @@ func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath
 		err = errors.Errorf("can't find storage with rawPath: %s", rawPath)
 		return
 	}
+	if IsStorageOffline(storage) {
+		h, _ := healthMap.Load(storage.GetStorage().MountPath)
+		err = errors.WithMessagef(errs.StorageOffline, "%s failed the health checks (%s)", storage.GetStorage().MountPath, h.LastError)
+		storage = nil
+		return
+	}
 	log.Debugln("use storage: ", storage.GetStorage().MountPath)
 	mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
 	actualPath = utils.FixAndCleanPath(strings.TrimPrefix(rawPath, mountPath))
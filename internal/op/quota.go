@@ -0,0 +1,85 @@
+package op
+
+import (
+	"context"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckQuota checks the user can upload size more bytes to the storage, by
+// both the quota of the user and the quota of each user on the storage.
+// The size is unknown if it's negative.
+func CheckQuota(user *model.User, storage *model.Storage, size int64) error {
+	if user == nil || (user.Quota <= 0 && storage.Quota <= 0) {
+		return nil
+	}
+	if size < 0 {
+		size = 0
+	}
+	usages, err := db.GetQuotaUsagesByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	var total, used int64
+	for _, u := range usages {
+		total += u.Used
+		if u.StorageID == storage.ID {
+			used = u.Used
+		}
+	}
+	if user.Quota > 0 && total+size > user.Quota {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	if storage.Quota > 0 && used+size > storage.Quota {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	return nil
+}
+
+// RecordUpload adds the uploaded bytes to the usage of the user
+func RecordUpload(user *model.User, storage *model.Storage, size int64) error {
+	if user == nil || size <= 0 {
+		return nil
+	}
+	return db.AddQuotaUsage(user.ID, storage.ID, size)
+}
+
+// PutWithQuota puts the file written by alist for the user, such as the
+// files of the offline downloads of the user, the file is checked against
+// and counted in the quotas of the user like the uploads
+func PutWithQuota(ctx context.Context, user *model.User, storage driver.Driver, dstDirPath string, file *model.FileStream, up driver.UpdateProgress) error {
+	if err := CheckQuota(user, storage.GetStorage(), file.GetSize()); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := Put(ctx, storage, dstDirPath, file, up, true); err != nil {
+		return err
+	}
+	if err := RecordUpload(user, storage.GetStorage(), file.GetSize()); err != nil {
+		log.Errorf("failed record upload of %s: %+v", file.GetName(), err)
+	}
+	return nil
+}
+
+// GetTaskUser gets the user who created the task, nil for the tasks of no
+// user, so that the writes of the task count against the quotas of the user
+func GetTaskUser(creator uint) (*model.User, error) {
+	if creator == 0 {
+		return nil, nil
+	}
+	return db.GetUserById(creator)
+}
+
+func GetQuotaUsagesByUserID(userID uint) ([]model.QuotaUsage, error) {
+	return db.GetQuotaUsagesByUserID(userID)
+}
+
+// ResetQuotaUsage clears the usage of the user on all storages
+func ResetQuotaUsage(userID uint) error {
+	return db.DeleteQuotaUsagesByUserID(userID)
+}
@@ -0,0 +1,78 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+func TestQuota(t *testing.T) {
+	user := &model.User{ID: 100, Limit: model.Limit{Quota: 100}}
+	s1 := &model.Storage{ID: 101}
+	s2 := &model.Storage{ID: 102, Limit: model.Limit{Quota: 30}}
+	if err := op.CheckQuota(user, s1, 60); err != nil {
+		t.Fatalf("expect 60 bytes can be uploaded, got %+v", err)
+	}
+	for _, s := range []*model.Storage{s1, s1, s2} {
+		if err := op.RecordUpload(user, s, 20); err != nil {
+			t.Fatalf("failed record upload: %+v", err)
+		}
+	}
+	if err := op.CheckQuota(user, s2, 20); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expect the quota of the storage is exceeded, got %+v", err)
+	}
+	if err := op.CheckQuota(user, s1, 50); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expect the quota of the user is exceeded, got %+v", err)
+	}
+	usages, err := op.GetQuotaUsagesByUserID(user.ID)
+	if err != nil || len(usages) != 2 {
+		t.Fatalf("expect the usages of 2 storages, got %+v: %+v", usages, err)
+	}
+	if err := op.ResetQuotaUsage(user.ID); err != nil {
+		t.Fatalf("failed reset quota usage: %+v", err)
+	}
+	if err := op.CheckQuota(user, s1, 100); err != nil {
+		t.Errorf("expect the quota is reset, got %+v", err)
+	}
+}
+
+func TestPutWithQuota(t *testing.T) {
+	storage, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/quota",
+		Limit:     model.Limit{Quota: 5},
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, t.TempDir()),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	d, err := op.GetStorageByMountPath("/quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 200}
+	put := func(name, content string) error {
+		return op.PutWithQuota(context.Background(), user, d, "/", &model.FileStream{
+			Obj:        &model.Object{Name: name, Size: int64(len(content)), Modified: time.Now()},
+			ReadCloser: io.NopCloser(strings.NewReader(content)),
+		}, nil)
+	}
+	if err := put("a.txt", "abc"); err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	if err := put("b.txt", "abc"); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expect the quota of the storage is exceeded, got %+v", err)
+	}
+	usages, err := op.GetQuotaUsagesByUserID(user.ID)
+	if err != nil || len(usages) != 1 || usages[0].StorageID != storage || usages[0].Used != 3 {
+		t.Errorf("expect 3 bytes used on the storage, got %+v: %+v", usages, err)
+	}
+}
@@ -0,0 +1,48 @@
+package op
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// CreateS3Key generates a new access key of the s3 server for the user
+func CreateS3Key(userID uint) (*model.S3Key, error) {
+	if _, err := db.GetUserById(userID); err != nil {
+		return nil, err
+	}
+	k := &model.S3Key{
+		UserID:      userID,
+		AccessKeyID: strings.ToUpper(random.Secret(20)),
+		SecretKey:   random.Secret(40),
+	}
+	if err := db.CreateS3Key(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func GetS3KeysByUserID(userID uint) ([]model.S3Key, error) {
+	return db.GetS3KeysByUserID(userID)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return db.DeleteS3KeyById(id)
+}
+
+// GetUserByS3Key returns the user that the access key belongs to,
+// together with the secret key to verify the request
+func GetUserByS3Key(accessKeyID string) (*model.User, string, error) {
+	k, err := db.GetS3KeyByAccessKeyID(accessKeyID)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := db.GetUserById(k.UserID)
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "the user of the s3 key not exists")
+	}
+	return user, k.SecretKey, nil
+}
@@ -31,6 +31,13 @@ func settingCacheUpdate() {
 	settingGroupCache.Clear()
 }
 
+// settingsChanged clears the cached settings here and has the other
+// instances reload them, so that the hooks apply them there too
+func settingsChanged() {
+	settingCacheUpdate()
+	publishCacheClear(cacheSetting, "")
+}
+
 func GetPublicSettingsMap() map[string]string {
 	items, _ := GetPublicSettingItems()
 	pSettings := make(map[string]string)
@@ -167,7 +174,7 @@ func SaveSettingItems(items []model.SettingItem) error {
 		}
 	}
 	if len(errs) < len(items)-len(noHookItems)+1 {
-		settingCacheUpdate()
+		settingsChanged()
 	}
 	return utils.MergeErrors(errs...)
 }
@@ -181,7 +188,7 @@ func SaveSettingItem(item *model.SettingItem) (err error) {
 	if err = db.SaveSettingItem(item); err != nil {
 		return err
 	}
-	settingCacheUpdate()
+	settingsChanged()
 	return nil
 }
 
@@ -193,6 +200,23 @@ func DeleteSettingItemByKey(key string) error {
 	if !old.IsDeprecated() {
 		return errors.Errorf("setting [%s] is not deprecated", key)
 	}
-	settingCacheUpdate()
+	settingsChanged()
 	return db.DeleteSettingItemByKey(key)
 }
+
+// ReloadSettings drops the cached settings and runs the hooks of the settings
+// in the database again, for the settings changed outside the api
+func ReloadSettings() error {
+	items, err := db.GetSettingItems()
+	if err != nil {
+		return errors.WithMessage(err, "failed get settings")
+	}
+	settingCacheUpdate()
+	var errs []error
+	for i := range items {
+		if _, err := HandleSettingItemHook(&items[i]); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed apply setting [%s]", items[i].Key))
+		}
+	}
+	return utils.MergeErrors(errs...)
+}
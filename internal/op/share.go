@@ -0,0 +1,62 @@
+package op
+
+import (
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+func CreateShare(s *model.Share) error {
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.Token = random.Secret(16)
+	s.Downloads = 0
+	return db.CreateShare(s)
+}
+
+// GetValidShare returns the share of the token with the user who created
+// it, after checking it's not expired
+func GetValidShare(token string) (*model.Share, *model.User, error) {
+	s, err := db.GetShareByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Expired() {
+		return nil, nil, errors.WithStack(errs.ShareExpired)
+	}
+	user, err := GetUserById(s.UserID)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "the user of the share not exists")
+	}
+	if user.Disabled {
+		return nil, nil, errors.WithStack(errs.PermissionDenied)
+	}
+	return s, user, nil
+}
+
+func GetShareById(id uint) (*model.Share, error) {
+	return db.GetShareById(id)
+}
+
+func GetShares(userID uint, pageIndex, pageSize int) ([]model.Share, int64, error) {
+	return db.GetShares(userID, pageIndex, pageSize)
+}
+
+// CountShareDownload counts a download of the share, failing if the limit
+// is reached
+func CountShareDownload(s *model.Share) error {
+	ok, err := db.IncreaseShareDownloads(s.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithStack(errs.ShareLimitReached)
+	}
+	return nil
+}
+
+func DeleteShareById(id uint) error {
+	return db.DeleteShareById(id)
+}
@@ -0,0 +1,68 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestShare(t *testing.T) {
+	user := &model.User{Username: "share_user"}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	share := &model.Share{UserID: user.ID, Path: "share/a/", MaxDownloads: 2}
+	if err := op.CreateShare(share); err != nil {
+		t.Fatal(err)
+	}
+	if share.Path != "/share/a" || share.Token == "" {
+		t.Errorf("unexpected share: %+v", share)
+	}
+	s, u, err := op.GetValidShare(share.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != user.ID {
+		t.Errorf("expect user %d, got %d", user.ID, u.ID)
+	}
+	for i := 0; i < 2; i++ {
+		if err := op.CountShareDownload(s); err != nil {
+			t.Fatalf("download %d: %+v", i, err)
+		}
+	}
+	if err := op.CountShareDownload(s); !errors.Is(errors.Cause(err), errs.ShareLimitReached) {
+		t.Errorf("expect limit reached, got %+v", err)
+	}
+
+	protected := &model.Share{UserID: user.ID, Path: "/share/c"}
+	if err := protected.SetPassword("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.CreateShare(protected); err != nil {
+		t.Fatal(err)
+	}
+	s, _, err = op.GetValidShare(protected.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.HasPassword || s.Password == "secret" {
+		t.Errorf("expect the hash of the password is stored, got %q", s.Password)
+	}
+	if !s.ValidatePassword("secret") || s.ValidatePassword("wrong") || s.ValidatePassword("") {
+		t.Errorf("unexpected result of validating the password")
+	}
+
+	expireAt := time.Now().Add(-time.Minute)
+	expired := &model.Share{UserID: user.ID, Path: "/share/b", ExpireAt: &expireAt}
+	if err := op.CreateShare(expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := op.GetValidShare(expired.Token); !errors.Is(errors.Cause(err), errs.ShareExpired) {
+		t.Errorf("expect expired, got %+v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package op
+
+import (
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// CreateSSHKey adds the public key in authorized_keys format for the user
+func CreateSSHKey(userID uint, title, keyStr string) (*model.SSHKey, error) {
+	if _, err := db.GetUserById(userID); err != nil {
+		return nil, err
+	}
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(keyStr))
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid public key")
+	}
+	if title == "" {
+		title = comment
+	}
+	k := &model.SSHKey{
+		UserID:      userID,
+		Title:       title,
+		Fingerprint: ssh.FingerprintSHA256(pub),
+		KeyStr:      strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+	}
+	if err := db.CreateSSHKey(k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func GetSSHKeysByUserID(userID uint) ([]model.SSHKey, error) {
+	return db.GetSSHKeysByUserID(userID)
+}
+
+func DeleteSSHKeyById(id uint) error {
+	return db.DeleteSSHKeyById(id)
+}
+
+// GetUserBySSHKey returns the user that the public key belongs to
+func GetUserBySSHKey(pub ssh.PublicKey) (*model.User, error) {
+	k, err := db.GetSSHKeyByFingerprint(ssh.FingerprintSHA256(pub))
+	if err != nil {
+		return nil, err
+	}
+	user, err := db.GetUserById(k.UserID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "the user of the ssh key not exists")
+	}
+	return user, nil
+}
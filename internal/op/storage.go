@@ -2,12 +2,14 @@ package op
 
 import (
 	"context"
+	"reflect"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/event"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/pkg/generic_sync"
 	"github.com/alist-org/alist/v3/pkg/utils"
@@ -56,6 +58,7 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	if err != nil {
 		return storage.ID, errors.WithMessage(err, "failed create storage in database")
 	}
+	defer storagesChanged()
 	// already has an id
 	err = initStorage(ctx, storage, storageDriver)
 	go callStorageHooks("add", storageDriver)
@@ -94,9 +97,15 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 		err = storageDriver.Init(ctx)
 	}
 	storagesMap.Store(driverStorage.MountPath, storageDriver)
+	resetHealth(driverStorage.MountPath)
 	if err != nil {
 		driverStorage.SetStatus(err.Error())
 		err = errors.Wrap(err, "failed init storage")
+		event.Publish(event.StorageOffline, map[string]any{
+			"mount_path": driverStorage.MountPath,
+			"driver":     driverStorage.Driver,
+			"status":     driverStorage.Status,
+		})
 	} else {
 		driverStorage.SetStatus(WORK)
 	}
@@ -117,6 +126,7 @@ func EnableStorage(ctx context.Context, id uint) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in db")
 	}
+	defer storagesChanged()
 	err = LoadStorage(ctx, *storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed load storage")
@@ -147,7 +157,10 @@ func DisableStorage(ctx context.Context, id uint) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in db")
 	}
+	defer storagesChanged()
 	storagesMap.Delete(storage.MountPath)
+	resetHealth(storage.MountPath)
+	listCache.DelTree(storage.MountPath)
 	go callStorageHooks("del", storageDriver)
 	return nil
 }
@@ -169,6 +182,9 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
+	defer storagesChanged()
+	// the listings may change with the new settings of the storage
+	listCache.DelTree(oldStorage.MountPath)
 	if storage.Disabled {
 		return nil
 	}
@@ -176,6 +192,7 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if oldStorage.MountPath != storage.MountPath {
 		// mount path renamed, need to drop the storage
 		storagesMap.Delete(oldStorage.MountPath)
+		resetHealth(oldStorage.MountPath)
 	}
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage driver")
@@ -196,6 +213,7 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	defer storagesChanged()
 	if !storage.Disabled {
 		storageDriver, err := GetStorageByMountPath(storage.MountPath)
 		if err != nil {
@@ -207,12 +225,17 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 		}
 		// delete the storage in the memory
 		storagesMap.Delete(storage.MountPath)
+		resetHealth(storage.MountPath)
 		go callStorageHooks("del", storageDriver)
 	}
+	listCache.DelTree(storage.MountPath)
 	// delete the storage in the database
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteQuotaUsagesByStorageID(id); err != nil {
+		return errors.WithMessage(err, "failed delete quota usages of storage")
+	}
 	return nil
 }
 
@@ -315,6 +338,20 @@ func GetBalancedStorage(path string) driver.Driver {
 	case 1:
 		return storages[0]
 	default:
+		// skip the offline storages unless all of them are offline
+		online := make([]driver.Driver, 0, storageNum)
+		for _, s := range storages {
+			if !IsStorageOffline(s) {
+				online = append(online, s)
+			}
+		}
+		if len(online) == 1 {
+			return online[0]
+		}
+		if len(online) > 0 {
+			storages = online
+			storageNum = len(online)
+		}
 		virtualPath := utils.GetActualMountPath(storages[0].GetStorage().MountPath)
 		i, _ := balanceMap.LoadOrStore(virtualPath, 0)
 		i = (i + 1) % storageNum
@@ -322,3 +359,82 @@ func GetBalancedStorage(path string) driver.Driver {
 		return storages[i]
 	}
 }
+
+// ReloadResult is the mount paths of the storages changed by ReloadStorages
+type ReloadResult struct {
+	Added     []string `json:"added"`
+	Updated   []string `json:"updated"`
+	Removed   []string `json:"removed"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// sameStorage reports whether the storage needs no reinitializing, the status
+// and the modified time are not the configuration
+func sameStorage(a, b model.Storage) bool {
+	a.Status, b.Status = "", ""
+	a.Modified, b.Modified = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+// ReloadStorages reads the storages in the database again, and loads the new
+// storages, reinitializes the changed ones and drops the disabled or deleted
+// ones. The untouched storages are kept with the transfers on them.
+func ReloadStorages(ctx context.Context) (*ReloadResult, error) {
+	storages, err := db.GetEnabledStorages()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get enabled storages")
+	}
+	loaded := make(map[uint]driver.Driver)
+	for _, d := range GetAllStorages() {
+		loaded[d.GetStorage().ID] = d
+	}
+	res := &ReloadResult{}
+	var errs []error
+	for _, storage := range storages {
+		storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
+		d, ok := loaded[storage.ID]
+		delete(loaded, storage.ID)
+		if ok && sameStorage(*d.GetStorage(), storage) {
+			res.Unchanged++
+			continue
+		}
+		if ok {
+			if err := dropStorage(ctx, d); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			res.Updated = append(res.Updated, storage.MountPath)
+		} else {
+			res.Added = append(res.Added, storage.MountPath)
+		}
+		if err := LoadStorage(ctx, storage); err != nil {
+			errs = append(errs, errors.WithMessagef(err, "failed load storage [%s]", storage.MountPath))
+		}
+	}
+	// the rest are disabled or deleted
+	for _, d := range loaded {
+		if err := dropStorage(ctx, d); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		go callStorageHooks("del", d)
+		res.Removed = append(res.Removed, d.GetStorage().MountPath)
+	}
+	return res, utils.MergeErrors(errs...)
+}
+
+// dropStorage drops the storage in the driver and removes it from the memory
+func dropStorage(ctx context.Context, d driver.Driver) error {
+	mountPath := d.GetStorage().MountPath
+	if err := d.Drop(ctx); err != nil {
+		return errors.Wrapf(err, "failed drop storage [%s]", mountPath)
+	}
+	// only the loaded one is removed, the mount path may be taken by
+	// another storage already
+	if s, ok := storagesMap.Load(mountPath); ok && s == d {
+		storagesMap.Delete(mountPath)
+		resetHealth(mountPath)
+	}
+	listCache.DelTree(mountPath)
+	return nil
+}
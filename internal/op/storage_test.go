@@ -2,6 +2,7 @@ package op_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/alist-org/alist/v3/internal/conf"
@@ -89,3 +90,47 @@ func setupStorages(t *testing.T) {
 		}
 	}
 }
+
+func TestReloadStorages(t *testing.T) {
+	addition := fmt.Sprintf(`{"root_folder_path":%q}`, t.TempDir())
+	for _, mountPath := range []string{"/reload/a", "/reload/b", "/reload/d"} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{Driver: "Local", MountPath: mountPath, Addition: addition})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+	}
+	a, _ := op.GetStorageByMountPath("/reload/a")
+	b, _ := op.GetStorageByMountPath("/reload/b")
+	// change the database behind the back of op
+	storage, _ := db.GetStorageByMountPath("/reload/b")
+	storage.Remark = "changed"
+	if err := db.UpdateStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateStorage(&model.Storage{Driver: "Local", MountPath: "/reload/c", Addition: addition}); err != nil {
+		t.Fatal(err)
+	}
+	storage, _ = db.GetStorageByMountPath("/reload/d")
+	storage.Disabled = true
+	if err := db.UpdateStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := op.ReloadStorages(context.Background())
+	if err != nil {
+		t.Fatalf("failed reload storages: %+v", err)
+	}
+	if !utils.SliceEqual(res.Added, []string{"/reload/c"}) || !utils.SliceEqual(res.Updated, []string{"/reload/b"}) ||
+		!utils.SliceEqual(res.Removed, []string{"/reload/d"}) {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if s, _ := op.GetStorageByMountPath("/reload/a"); s != a {
+		t.Errorf("the unchanged storage should be kept")
+	}
+	if s, _ := op.GetStorageByMountPath("/reload/b"); s == b || s.GetStorage().Remark != "changed" {
+		t.Errorf("the changed storage should be reinitialized")
+	}
+	if !op.HasStorage("/reload/c") || op.HasStorage("/reload/d") {
+		t.Errorf("the new storage should be loaded and the disabled one dropped")
+	}
+}
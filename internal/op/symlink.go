@@ -0,0 +1,228 @@
+package op
+
+import (
+	stdpath "path"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxSymlinkDepth is the max symlinks followed to resolve a path, so that
+// the symlinks pointing to each other don't loop forever
+const maxSymlinkDepth = 8
+
+// the symlinks are looked up on every path, so keep them in memory and
+// reload them after any change
+var (
+	symlinkMu     sync.RWMutex
+	symlinkLoaded []model.Symlink
+	symlinkGen    uint64
+)
+
+func clearSymlinkCache() {
+	clearCache(cacheSymlink, "")
+}
+
+func dropSymlinkCache() {
+	symlinkMu.Lock()
+	symlinkLoaded = nil
+	symlinkGen++
+	symlinkMu.Unlock()
+}
+
+func getAllSymlinks() ([]model.Symlink, error) {
+	symlinkMu.RLock()
+	symlinks, gen := symlinkLoaded, symlinkGen
+	symlinkMu.RUnlock()
+	if symlinks != nil {
+		return symlinks, nil
+	}
+	symlinks, err := db.GetAllSymlinks()
+	if err != nil {
+		return nil, err
+	}
+	if symlinks == nil {
+		symlinks = []model.Symlink{}
+	}
+	symlinkMu.Lock()
+	if gen == symlinkGen {
+		symlinkLoaded = symlinks
+	}
+	symlinkMu.Unlock()
+	return symlinks, nil
+}
+
+// ResolveSymlink replaces the symlink that is the path or a parent of it
+// with the target, until no symlink matches
+func ResolveSymlink(path string) string {
+	path = utils.FixAndCleanPath(path)
+	symlinks, err := getAllSymlinks()
+	if err != nil {
+		log.Errorf("failed get symlinks: %+v", err)
+		return path
+	}
+	path, _ = resolveSymlinkIn(symlinks, path)
+	return path
+}
+
+// resolveSymlinkIn resolves the path with the symlinks, it returns false if
+// a symlink still matches after maxSymlinkDepth symlinks are followed
+func resolveSymlinkIn(symlinks []model.Symlink, path string) (string, bool) {
+	for i := 0; i <= maxSymlinkDepth; i++ {
+		var matched *model.Symlink
+		for j := range symlinks {
+			s := &symlinks[j]
+			if utils.IsSubPath(s.Path, path) && (matched == nil || len(s.Path) > len(matched.Path)) {
+				matched = s
+			}
+		}
+		if matched == nil {
+			return path, true
+		}
+		if i == maxSymlinkDepth {
+			break
+		}
+		path = utils.FixAndCleanPath(stdpath.Join(matched.Target, strings.TrimPrefix(path, matched.Path)))
+	}
+	return path, false
+}
+
+// CheckSymlinkLoop returns an error if the symlink s, created or updated,
+// makes a loop with the existing symlinks. The loop is either a target that
+// can't be resolved, or symlinks whose targets lead to the folders
+// containing them, which makes the tree below them repeat forever.
+func CheckSymlinkLoop(s *model.Symlink) error {
+	existing, err := getAllSymlinks()
+	if err != nil {
+		return err
+	}
+	link := model.Symlink{ID: s.ID, Path: utils.FixAndCleanPath(s.Path), Target: utils.FixAndCleanPath(s.Target)}
+	symlinks := []model.Symlink{link}
+	for _, e := range existing {
+		if (s.ID == 0 || e.ID != s.ID) && e.Path != link.Path {
+			symlinks = append(symlinks, e)
+		}
+	}
+	targets := make([]string, len(symlinks))
+	for i, l := range symlinks {
+		target, ok := resolveSymlinkIn(symlinks, l.Target)
+		if !ok {
+			return errors.Errorf("the target of %s can't be resolved, the symlinks lead to each other", l.Path)
+		}
+		targets[i] = target
+	}
+	// walking symlink i enters symlink j if j is below the target of i, a
+	// cycle of entering is a loop. The new symlink may change the targets
+	// of the others, so all of them are searched.
+	const (
+		unvisited = iota
+		walking
+		walked
+	)
+	state := make([]int, len(symlinks))
+	var loops func(i int) bool
+	loops = func(i int) bool {
+		state[i] = walking
+		for j := range symlinks {
+			if !utils.IsSubPath(targets[i], symlinks[j].Path) {
+				continue
+			}
+			if state[j] == walking || state[j] == unvisited && loops(j) {
+				return true
+			}
+		}
+		state[i] = walked
+		return false
+	}
+	for i := range symlinks {
+		if state[i] == unvisited && loops(i) {
+			return errors.Errorf("the target %s makes the symlinks lead back to themselves", link.Target)
+		}
+	}
+	return nil
+}
+
+// WalkedDirs is the set of the resolved paths of the folders walked, the
+// recursive walkers check it so that a folder reached through symlinks is
+// walked once only and the symlinks leading back to it don't loop forever
+type WalkedDirs map[string]struct{}
+
+// Visit reports whether the folder of path isn't walked yet, and marks it
+// as walked
+func (w WalkedDirs) Visit(path string) bool {
+	resolved := ResolveSymlink(path)
+	if _, ok := w[resolved]; ok {
+		return false
+	}
+	w[resolved] = struct{}{}
+	return true
+}
+
+// IsSymlink reports whether the path is a symlink itself, the symlinks are
+// managed by the admin only, so they can't be moved or removed as the objects
+func IsSymlink(path string) bool {
+	path = utils.FixAndCleanPath(path)
+	symlinks, err := getAllSymlinks()
+	if err != nil {
+		log.Errorf("failed get symlinks: %+v", err)
+		return false
+	}
+	for _, s := range symlinks {
+		if s.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSymlinksIn returns the symlinks right in the dir
+func GetSymlinksIn(dir string) []model.Symlink {
+	dir = utils.FixAndCleanPath(dir)
+	symlinks, err := getAllSymlinks()
+	if err != nil {
+		log.Errorf("failed get symlinks: %+v", err)
+		return nil
+	}
+	var res []model.Symlink
+	for _, s := range symlinks {
+		if stdpath.Dir(s.Path) == dir {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func GetSymlinkById(id uint) (*model.Symlink, error) {
+	return db.GetSymlinkById(id)
+}
+
+func GetSymlinks(pageIndex, pageSize int) ([]model.Symlink, int64, error) {
+	return db.GetSymlinks(pageIndex, pageSize)
+}
+
+func CreateSymlink(s *model.Symlink) error {
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.Target = utils.FixAndCleanPath(s.Target)
+	defer clearSymlinkCache()
+	return db.CreateSymlink(s)
+}
+
+func UpdateSymlink(s *model.Symlink) error {
+	if _, err := db.GetSymlinkById(s.ID); err != nil {
+		return err
+	}
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.Target = utils.FixAndCleanPath(s.Target)
+	defer clearSymlinkCache()
+	return db.UpdateSymlink(s)
+}
+
+func DeleteSymlinkById(id uint) error {
+	defer clearSymlinkCache()
+	return db.DeleteSymlinkById(id)
+}
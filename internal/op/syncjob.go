@@ -0,0 +1,87 @@
+package op
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// SyncJobHook is called after a sync job is created, updated or deleted,
+// typ is "add", "update" or "del"
+type SyncJobHook func(typ string, job model.SyncJob)
+
+var syncJobHooks []SyncJobHook
+
+func RegisterSyncJobHook(hook SyncJobHook) {
+	syncJobHooks = append(syncJobHooks, hook)
+}
+
+func callSyncJobHooks(typ string, job model.SyncJob) {
+	runSyncJobHooks(typ, job)
+	data, err := utils.Json.MarshalToString(syncJobChange{Type: typ, Job: job})
+	if err != nil {
+		log.Errorf("failed encode change of sync job %s: %+v", job.Name, err)
+		return
+	}
+	cluster.Publish(topicSyncJob, data)
+}
+
+func runSyncJobHooks(typ string, job model.SyncJob) {
+	for _, hook := range syncJobHooks {
+		hook(typ, job)
+	}
+}
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	return db.GetSyncJobById(id)
+}
+
+func GetSyncJobs(pageIndex, pageSize int) ([]model.SyncJob, int64, error) {
+	return db.GetSyncJobs(pageIndex, pageSize)
+}
+
+func GetEnabledSyncJobs() ([]model.SyncJob, error) {
+	return db.GetEnabledSyncJobs()
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	if err := db.CreateSyncJob(j); err != nil {
+		return err
+	}
+	callSyncJobHooks("add", *j)
+	return nil
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	old, err := db.GetSyncJobById(j.ID)
+	if err != nil {
+		return err
+	}
+	// the result is only updated by the runs
+	j.LastRun, j.LastResult = old.LastRun, old.LastResult
+	if err := db.UpdateSyncJob(j); err != nil {
+		return err
+	}
+	callSyncJobHooks("update", *j)
+	return nil
+}
+
+func UpdateSyncJobResult(id uint, lastRun time.Time, result string) error {
+	return db.UpdateSyncJobResult(id, lastRun, result)
+}
+
+func DeleteSyncJobById(id uint) error {
+	j, err := db.GetSyncJobById(id)
+	if err != nil {
+		return err
+	}
+	if err := db.DeleteSyncJobById(id); err != nil {
+		return err
+	}
+	callSyncJobHooks("del", *j)
+	return nil
+}
@@ -0,0 +1,263 @@
+package op
+
+import (
+	"context"
+	"encoding/base64"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrashDir is the recycle bin in the root of the storages with Trash.
+// The objects removed from a folder at the same time are moved to
+// TrashDir/<id>, the id is the time of the removal and the encoded path of
+// the folder, so that the recycle bin works with any driver that can move.
+const TrashDir = ".trash"
+
+// UsesTrash reports whether the objects removed from the storage are moved
+// to TrashDir
+func UsesTrash(storage driver.Driver) bool {
+	return storage.GetStorage().Trash
+}
+
+// driverTrash gets the recycle bin of the driver, it's used unless the
+// storage moves the removed objects to TrashDir
+func driverTrash(storage driver.Driver) (driver.Trasher, bool) {
+	if UsesTrash(storage) {
+		return nil, false
+	}
+	t, ok := storage.(driver.Trasher)
+	return t, ok
+}
+
+// InTrash reports whether the path is TrashDir or inside it
+func InTrash(path string) bool {
+	path = utils.FixAndCleanPath(path)
+	trash := "/" + TrashDir
+	return path == trash || strings.HasPrefix(path, trash+"/")
+}
+
+func trashID(deletedAt time.Time, dir string) string {
+	return deletedAt.UTC().Format(versionTimeFormat) + "_" + base64.RawURLEncoding.EncodeToString([]byte(dir))
+}
+
+func parseTrashID(id string) (time.Time, string, error) {
+	t, dir, ok := strings.Cut(id, "_")
+	if !ok {
+		return time.Time{}, "", errors.Errorf("invalid trash id: %s", id)
+	}
+	deletedAt, err := time.Parse(versionTimeFormat, t)
+	if err != nil {
+		return time.Time{}, "", errors.Wrapf(err, "invalid trash id: %s", id)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(dir)
+	if err != nil {
+		return time.Time{}, "", errors.Wrapf(err, "invalid trash id: %s", id)
+	}
+	return deletedAt, utils.FixAndCleanPath(string(data)), nil
+}
+
+func checkTrashName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return errors.Errorf("invalid name: %s", name)
+	}
+	return nil
+}
+
+// MoveToTrash moves the object to TrashDir instead of removing it
+func MoveToTrash(ctx context.Context, storage driver.Driver, path string) error {
+	path = utils.FixAndCleanPath(path)
+	if path == "/" {
+		return errors.New("can't remove the root folder")
+	}
+	caps := GetCapabilities(storage)
+	if !caps.Move || !caps.MakeDir {
+		return errors.New("the recycle bin needs the storage to support move and make dir")
+	}
+	if _, err := Get(ctx, storage, path); err != nil {
+		if errs.IsObjectNotFound(err) {
+			log.Debugf("%s have been removed", path)
+			return nil
+		}
+		return errors.WithMessage(err, "failed to get object")
+	}
+	id := trashID(time.Now(), stdpath.Dir(path))
+	// the names of the folders are limited to 255 bytes by most storages
+	if len(id) > 255 {
+		return errors.Errorf("the path of %s is too long to be kept in the recycle bin", path)
+	}
+	dir := stdpath.Join("/", TrashDir, id)
+	if err := MakeDir(ctx, storage, dir); err != nil {
+		return errors.WithMessage(err, "failed make trash dir")
+	}
+	if err := Move(ctx, storage, path, dir); err != nil {
+		return errors.WithMessage(err, "failed move to trash")
+	}
+	return nil
+}
+
+// ListTrash lists the items in the recycle bin of the storage, the latest
+// removed first
+func ListTrash(ctx context.Context, storage driver.Driver) ([]model.TrashItem, error) {
+	if t, ok := driverTrash(storage); ok {
+		return t.ListTrash(ctx)
+	}
+	if !UsesTrash(storage) {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	dirs, err := List(ctx, storage, "/"+TrashDir, model.ListArgs{}, true)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return []model.TrashItem{}, nil
+		}
+		return nil, err
+	}
+	items := make([]model.TrashItem, 0, len(dirs))
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		deletedAt, dir, err := parseTrashID(d.GetName())
+		if err != nil {
+			log.Warnf("skip %s in the recycle bin of %s: %+v", d.GetName(), storage.GetStorage().MountPath, err)
+			continue
+		}
+		objs, err := List(ctx, storage, stdpath.Join("/", TrashDir, d.GetName()), model.ListArgs{}, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range objs {
+			items = append(items, model.TrashItem{
+				ID:        d.GetName(),
+				Name:      obj.GetName(),
+				Path:      stdpath.Join(dir, obj.GetName()),
+				Size:      obj.GetSize(),
+				IsDir:     obj.IsDir(),
+				DeletedAt: deletedAt,
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// GetTrashItem gets the item in the recycle bin by the id and the name
+func GetTrashItem(ctx context.Context, storage driver.Driver, id, name string) (*model.TrashItem, error) {
+	if err := checkTrashName(id); err != nil {
+		return nil, err
+	}
+	if err := checkTrashName(name); err != nil {
+		return nil, err
+	}
+	if t, ok := driverTrash(storage); ok {
+		return t.GetTrashItem(ctx, id, name)
+	}
+	if !UsesTrash(storage) {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	deletedAt, dir, err := parseTrashID(id)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := GetUnwrap(ctx, storage, stdpath.Join("/", TrashDir, id, name))
+	if err != nil {
+		return nil, err
+	}
+	return &model.TrashItem{
+		ID:        id,
+		Name:      name,
+		Path:      stdpath.Join(dir, name),
+		Size:      obj.GetSize(),
+		IsDir:     obj.IsDir(),
+		DeletedAt: deletedAt,
+	}, nil
+}
+
+// RestoreTrash moves the item back to its original path, the folders of the
+// path are made again if they have been removed
+func RestoreTrash(ctx context.Context, storage driver.Driver, id, name string) error {
+	item, err := GetTrashItem(ctx, storage, id, name)
+	if err != nil {
+		return err
+	}
+	if t, ok := driverTrash(storage); ok {
+		if err := t.RestoreTrash(ctx, id, name); err != nil {
+			return err
+		}
+		ClearCache(storage, stdpath.Dir(item.Path))
+		return nil
+	}
+	if _, err := GetUnwrap(ctx, storage, item.Path); err == nil {
+		return errors.Errorf("%s already exists", item.Path)
+	}
+	dir := stdpath.Dir(item.Path)
+	if err := MakeDir(ctx, storage, dir); err != nil {
+		return errors.WithMessage(err, "failed make dir")
+	}
+	if err := Move(ctx, storage, stdpath.Join("/", TrashDir, id, name), dir); err != nil {
+		return errors.WithMessage(err, "failed move out of trash")
+	}
+	removeEmptyTrashDir(ctx, storage, id)
+	return nil
+}
+
+// DeleteTrash deletes the item in the recycle bin permanently
+func DeleteTrash(ctx context.Context, storage driver.Driver, id, name string) error {
+	if _, err := GetTrashItem(ctx, storage, id, name); err != nil {
+		return err
+	}
+	if t, ok := driverTrash(storage); ok {
+		return t.DeleteTrash(ctx, id, name)
+	}
+	if err := Remove(ctx, storage, stdpath.Join("/", TrashDir, id, name)); err != nil {
+		return err
+	}
+	removeEmptyTrashDir(ctx, storage, id)
+	return nil
+}
+
+func removeEmptyTrashDir(ctx context.Context, storage driver.Driver, id string) {
+	dir := stdpath.Join("/", TrashDir, id)
+	objs, err := List(ctx, storage, dir, model.ListArgs{}, true)
+	if err != nil || len(objs) > 0 {
+		return
+	}
+	if err := Remove(ctx, storage, dir); err != nil {
+		log.Warnf("failed remove empty trash dir %s of %s: %+v", id, storage.GetStorage().MountPath, err)
+	}
+}
+
+// PurgeTrash deletes the items removed more than TrashDays ago from
+// the recycle bins of the storages
+func PurgeTrash(ctx context.Context) {
+	for _, storage := range GetAllStorages() {
+		days := storage.GetStorage().TrashDays
+		if !UsesTrash(storage) || days <= 0 || storage.GetStorage().Status != WORK {
+			continue
+		}
+		items, err := ListTrash(ctx, storage)
+		if err != nil {
+			log.Errorf("failed list recycle bin of %s: %+v", storage.GetStorage().MountPath, err)
+			continue
+		}
+		deadline := time.Now().AddDate(0, 0, -days)
+		for _, item := range items {
+			if item.DeletedAt.After(deadline) {
+				continue
+			}
+			if err := DeleteTrash(ctx, storage, item.ID, item.Name); err != nil {
+				log.Errorf("failed purge %s from recycle bin of %s: %+v", item.Path, storage.GetStorage().MountPath, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package op
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	"github.com/pquerna/otp/totp"
+	log "github.com/sirupsen/logrus"
+)
+
+const recoveryCodeCount = 10
+
+// clientAuth is what the passwords of the clients that can't do 2FA are
+// checked against, cached since WebDAV clients login on every request
+type clientAuth struct {
+	appPasswords map[string]struct{}
+	webAuthn     bool
+}
+
+var clientAuthCache = cache.NewMemCache(cache.WithShards[*clientAuth](2))
+
+func getClientAuth(userID uint) (*clientAuth, error) {
+	key := strconv.FormatUint(uint64(userID), 10)
+	if a, ok := clientAuthCache.Get(key); ok {
+		return a, nil
+	}
+	passwords, err := db.GetAppPasswordsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := db.GetWebAuthnCredentialsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	a := &clientAuth{appPasswords: make(map[string]struct{}, len(passwords)), webAuthn: len(credentials) > 0}
+	for _, p := range passwords {
+		a.appPasswords[p.Hash] = struct{}{}
+	}
+	clientAuthCache.Set(key, a, cache.WithEx[*clientAuth](time.Minute*10))
+	return a, nil
+}
+
+func clearClientAuth(userID uint) {
+	clearCache(cacheClientAuth, strconv.FormatUint(uint64(userID), 10))
+}
+
+// HasWebAuthn reports whether the user has registered any webauthn
+// credential, it's assumed so if it can't be told
+func HasWebAuthn(u *model.User) bool {
+	a, err := getClientAuth(u.ID)
+	if err != nil {
+		log.Errorf("failed get webauthn credentials of %s: %+v", u.Username, err)
+		return true
+	}
+	return a.webAuthn
+}
+
+// Has2FA reports whether the user has enabled TOTP or webauthn
+func Has2FA(u *model.User) bool {
+	return u.OtpSecret != "" || HasWebAuthn(u)
+}
+
+// Validate2FACode validates the TOTP code, or a recovery code which can be
+// used only once
+func Validate2FACode(u *model.User, code string) bool {
+	if code == "" {
+		return false
+	}
+	if u.OtpSecret != "" && totp.Validate(code, u.OtpSecret) {
+		return true
+	}
+	return useRecoveryCode(u, code)
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func useRecoveryCode(u *model.User, code string) bool {
+	if u.RecoveryCodes == "" {
+		return false
+	}
+	hash := utils.GetSHA256Encode(normalizeRecoveryCode(code))
+	hashes := strings.Split(u.RecoveryCodes, ",")
+	for i, h := range hashes {
+		if h != hash {
+			continue
+		}
+		u.RecoveryCodes = strings.Join(append(hashes[:i:i], hashes[i+1:]...), ",")
+		if err := UpdateUser(u); err != nil {
+			log.Errorf("failed use recovery code of %s: %+v", u.Username, err)
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// SetRecoveryCodes sets new recovery codes for the user without saving it,
+// the codes are only shown here
+func SetRecoveryCodes(u *model.User) []string {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code := random.Secret(10)
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = utils.GetSHA256Encode(code)
+	}
+	u.RecoveryCodes = strings.Join(hashes, ",")
+	return codes
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of the user
+func GenerateRecoveryCodes(u *model.User) ([]string, error) {
+	codes := SetRecoveryCodes(u)
+	if err := UpdateUser(u); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ValidateClientPassword validates the password of WebDAV, FTP and SFTP
+// clients, which can't do 2FA. App passwords are always accepted, while the
+// main password is refused once 2FA is enabled.
+func ValidateClientPassword(u *model.User, password string) error {
+	if password == "" {
+		return errors.WithStack(errs.EmptyPassword)
+	}
+	a, err := getClientAuth(u.ID)
+	if err != nil {
+		return err
+	}
+	if _, ok := a.appPasswords[utils.GetSHA256Encode(password)]; ok {
+		return nil
+	}
+	if u.OtpSecret != "" || a.webAuthn {
+		return errors.WithStack(errs.TwoFARequired)
+	}
+	return u.ValidatePassword(password)
+}
+
+// CreateAppPassword generates a new app password for the user, the password
+// is only returned here
+func CreateAppPassword(userID uint, title string) (*model.AppPassword, string, error) {
+	if _, err := db.GetUserById(userID); err != nil {
+		return nil, "", err
+	}
+	password := random.Secret(24)
+	p := &model.AppPassword{
+		UserID: userID,
+		Title:  title,
+		Hash:   utils.GetSHA256Encode(password),
+	}
+	if err := db.CreateAppPassword(p); err != nil {
+		return nil, "", err
+	}
+	clearClientAuth(userID)
+	return p, password, nil
+}
+
+func GetAppPasswordsByUserID(userID uint) ([]model.AppPassword, error) {
+	return db.GetAppPasswordsByUserID(userID)
+}
+
+func DeleteAppPassword(userID, id uint) error {
+	defer clearClientAuth(userID)
+	return db.DeleteAppPassword(userID, id)
+}
+
+func CreateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	defer clearClientAuth(c.UserID)
+	return db.CreateWebAuthnCredential(c)
+}
+
+func UpdateWebAuthnCredential(c *model.WebAuthnCredential) error {
+	return db.UpdateWebAuthnCredential(c)
+}
+
+func GetWebAuthnCredentialsByUserID(userID uint) ([]model.WebAuthnCredential, error) {
+	return db.GetWebAuthnCredentialsByUserID(userID)
+}
+
+func DeleteWebAuthnCredential(userID, id uint) error {
+	defer clearClientAuth(userID)
+	return db.DeleteWebAuthnCredential(userID, id)
+}
@@ -0,0 +1,65 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestClientPassword(t *testing.T) {
+	user := &model.User{Username: "twofa_user", Password: "main"}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.ValidateClientPassword(user, "main"); err != nil {
+		t.Errorf("expect main password to be accepted without 2FA, got %+v", err)
+	}
+	p, password, err := op.CreateAppPassword(user.ID, "dav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.OtpSecret = "secret"
+	if err := op.ValidateClientPassword(user, "main"); !errors.Is(errors.Cause(err), errs.TwoFARequired) {
+		t.Errorf("expect main password to be refused with 2FA, got %+v", err)
+	}
+	if err := op.ValidateClientPassword(user, password); err != nil {
+		t.Errorf("expect app password to be accepted, got %+v", err)
+	}
+	if err := op.DeleteAppPassword(user.ID, p.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.ValidateClientPassword(user, password); err == nil {
+		t.Errorf("expect deleted app password to be refused")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	user := &model.User{Username: "recovery_user", Password: "main"}
+	if err := db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	codes, err := op.GenerateRecoveryCodes(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("expect 10 codes, got %d", len(codes))
+	}
+	if !op.Validate2FACode(user, codes[3]) {
+		t.Errorf("expect recovery code to be accepted")
+	}
+	if op.Validate2FACode(user, codes[3]) {
+		t.Errorf("expect recovery code to be used only once")
+	}
+	u, err := db.GetUserById(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !op.Validate2FACode(u, codes[4]) {
+		t.Errorf("expect unused recovery code to be accepted after reload")
+	}
+}
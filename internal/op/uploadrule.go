@@ -0,0 +1,91 @@
+package op
+
+import (
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// the enabled upload rules are matched on every upload, so keep them in
+// memory and reload them after any change
+var (
+	uploadRuleMu     sync.RWMutex
+	uploadRuleLoaded []model.UploadRule
+	uploadRuleGen    uint64
+)
+
+func clearUploadRuleCache() {
+	clearCache(cacheUploadRule, "")
+}
+
+func dropUploadRuleCache() {
+	uploadRuleMu.Lock()
+	uploadRuleLoaded = nil
+	uploadRuleGen++
+	uploadRuleMu.Unlock()
+}
+
+func getEnabledUploadRules() ([]model.UploadRule, error) {
+	uploadRuleMu.RLock()
+	rules, gen := uploadRuleLoaded, uploadRuleGen
+	uploadRuleMu.RUnlock()
+	if rules != nil {
+		return rules, nil
+	}
+	rules, err := db.GetEnabledUploadRules()
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []model.UploadRule{}
+	}
+	uploadRuleMu.Lock()
+	if gen == uploadRuleGen {
+		uploadRuleLoaded = rules
+	}
+	uploadRuleMu.Unlock()
+	return rules, nil
+}
+
+// MatchUploadRule returns the rule applied to the upload, which is nil if
+// no rule matches
+func MatchUploadRule(dir, name string, size int64, username string) (*model.UploadRule, error) {
+	rules, err := getEnabledUploadRules()
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Match(dir, name, size, username) {
+			r := rules[i]
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func GetUploadRuleById(id uint) (*model.UploadRule, error) {
+	return db.GetUploadRuleById(id)
+}
+
+func GetUploadRules(pageIndex, pageSize int) ([]model.UploadRule, int64, error) {
+	return db.GetUploadRules(pageIndex, pageSize)
+}
+
+func CreateUploadRule(r *model.UploadRule) error {
+	defer clearUploadRuleCache()
+	return db.CreateUploadRule(r)
+}
+
+func UpdateUploadRule(r *model.UploadRule) error {
+	if _, err := db.GetUploadRuleById(r.ID); err != nil {
+		return err
+	}
+	defer clearUploadRuleCache()
+	return db.UpdateUploadRule(r)
+}
+
+func DeleteUploadRuleById(id uint) error {
+	defer clearUploadRuleCache()
+	return db.DeleteUploadRuleById(id)
+}
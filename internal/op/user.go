@@ -1,6 +1,7 @@
 package op
 
 import (
+	"context"
 	"time"
 
 	"github.com/Xhofe/go-cache"
@@ -60,6 +61,15 @@ func GetUserByName(username string) (*model.User, error) {
 	return user, err
 }
 
+// UserIDFromCtx returns the id of the user of the request in ctx, 0 if ctx
+// has no user such as the tasks of alist itself
+func UserIDFromCtx(ctx context.Context) uint {
+	if user, ok := ctx.Value("user").(*model.User); ok && user != nil {
+		return user.ID
+	}
+	return 0
+}
+
 func GetUserById(id uint) (*model.User, error) {
 	return db.GetUserById(id)
 }
@@ -81,7 +91,33 @@ func DeleteUserById(id uint) error {
 	if old.IsAdmin() || old.IsGuest() {
 		return errs.DeleteAdminOrGuest
 	}
-	userCache.Del(old.Username)
+	clearCache(cacheUser, old.Username)
+	if err := db.DeleteS3KeysByUserID(id); err != nil {
+		return err
+	}
+	if err := db.DeleteSSHKeysByUserID(id); err != nil {
+		return err
+	}
+	if err := db.DeleteQuotaUsagesByUserID(id); err != nil {
+		return err
+	}
+	defer clearClientAuth(id)
+	if err := db.DeleteAppPasswordsByUserID(id); err != nil {
+		return err
+	}
+	if err := db.DeleteWebAuthnCredentialsByUserID(id); err != nil {
+		return err
+	}
+	defer clearACLCache()
+	if err := db.DeleteACLRulesByUserID(id); err != nil {
+		return err
+	}
+	if err := db.DeleteUserGroupsByUserID(id); err != nil {
+		return err
+	}
+	if err := db.DeleteSharesByUserID(id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -96,13 +132,20 @@ func UpdateUser(u *model.User) error {
 	if u.IsGuest() {
 		guestUser = nil
 	}
-	userCache.Del(old.Username)
+	clearCache(cacheUser, old.Username)
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
 	return db.UpdateUser(u)
 }
 
+// Cancel2FAByUser disables TOTP and removes the webauthn credentials and
+// recovery codes of the user
 func Cancel2FAByUser(u *model.User) error {
+	defer clearClientAuth(u.ID)
+	if err := db.DeleteWebAuthnCredentialsByUserID(u.ID); err != nil {
+		return err
+	}
 	u.OtpSecret = ""
+	u.RecoveryCodes = ""
 	return UpdateUser(u)
 }
 
@@ -0,0 +1,210 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// VersionsDir is the folder that keeps the previous versions of the files
+// beside it, for the storages that don't keep versions themselves. The
+// versions of a file are in VersionsDir/<name>, named by the time they are
+// replaced, so that they sort by time.
+const VersionsDir = ".versions"
+
+const versionTimeFormat = "20060102T150405.000Z"
+
+// KeepsVersions reports whether the versions of the storage are kept in
+// VersionsDir
+func KeepsVersions(storage driver.Driver) bool {
+	return storage.GetStorage().KeepVersions > 0 && !isVersioner(storage)
+}
+
+func versionsDirOf(path string) string {
+	return stdpath.Join(stdpath.Dir(path), VersionsDir, stdpath.Base(path))
+}
+
+func getVersionFile(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	file, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	return file, nil
+}
+
+// ListVersions lists the previous versions of the file, the latest first
+func ListVersions(ctx context.Context, storage driver.Driver, path string) ([]model.FileVersion, error) {
+	path = utils.FixAndCleanPath(path)
+	file, err := getVersionFile(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := storage.(driver.Versioner); ok {
+		versions, err := v.ListVersions(ctx, file)
+		return versions, errors.WithMessage(err, "failed list versions")
+	}
+	if !KeepsVersions(storage) {
+		return nil, errors.WithStack(errs.NotSupport)
+	}
+	objs, err := List(ctx, storage, versionsDirOf(path), model.ListArgs{})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return []model.FileVersion{}, nil
+		}
+		return nil, err
+	}
+	versions := make([]model.FileVersion, 0, len(objs))
+	for _, obj := range objs {
+		if obj.IsDir() {
+			continue
+		}
+		versions = append(versions, model.FileVersion{ID: obj.GetName(), Size: obj.GetSize(), Modified: obj.ModTime()})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+func checkVersionID(version string) error {
+	if version == "" || version == "." || version == ".." || strings.Contains(version, "/") {
+		return errors.Errorf("invalid version: %s", version)
+	}
+	return nil
+}
+
+// LinkVersion gets the link of the version of the file, the returned obj
+// has the name of the file and the size of the version
+func LinkVersion(ctx context.Context, storage driver.Driver, path, version string, args model.LinkArgs) (*model.Link, model.Obj, error) {
+	path = utils.FixAndCleanPath(path)
+	versions, err := ListVersions(ctx, storage, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var found *model.FileVersion
+	for i := range versions {
+		if versions[i].ID == version {
+			found = &versions[i]
+			break
+		}
+	}
+	if found == nil {
+		return nil, nil, errors.Errorf("version %s not found", version)
+	}
+	obj := &model.Object{Name: stdpath.Base(path), Size: found.Size, Modified: found.Modified}
+	if v, ok := storage.(driver.Versioner); ok {
+		file, err := getVersionFile(ctx, storage, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		link, err := v.LinkVersion(ctx, file, version, args)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed link version")
+		}
+		return link, obj, nil
+	}
+	link, _, err := Link(ctx, storage, stdpath.Join(versionsDirOf(path), version), args)
+	return link, obj, err
+}
+
+// RestoreVersion makes the version the current content of the file, the
+// current content is kept as a version first if the versions are in
+// VersionsDir
+func RestoreVersion(ctx context.Context, storage driver.Driver, path, version string) error {
+	path = utils.FixAndCleanPath(path)
+	if err := checkVersionID(version); err != nil {
+		return err
+	}
+	file, err := getVersionFile(ctx, storage, path)
+	if err != nil {
+		return err
+	}
+	dir := stdpath.Dir(path)
+	if v, ok := storage.(driver.Versioner); ok {
+		err := v.RestoreVersion(ctx, file, version)
+		if err != nil {
+			return errors.WithMessage(err, "failed restore version")
+		}
+		ClearCache(storage, dir)
+		handleObjsChange(storage, dir)
+		return nil
+	}
+	if !KeepsVersions(storage) {
+		return errors.WithStack(errs.NotSupport)
+	}
+	versionPath := stdpath.Join(versionsDirOf(path), version)
+	if _, err := GetUnwrap(ctx, storage, versionPath); err != nil {
+		return errors.WithMessagef(err, "failed get version %s", version)
+	}
+	// the version is copied out first, since keeping the current content
+	// may prune it
+	restored := stdpath.Join(dir, version)
+	if _, err := GetUnwrap(ctx, storage, restored); err == nil {
+		return errors.Errorf("%s already exists", restored)
+	}
+	if err := Copy(ctx, storage, versionPath, dir); err != nil {
+		return errors.WithMessage(err, "failed copy version")
+	}
+	if err := saveVersion(ctx, storage, path, file); err != nil {
+		_ = Remove(ctx, storage, restored)
+		return err
+	}
+	if err := Remove(ctx, storage, path); err != nil {
+		_ = Remove(ctx, storage, restored)
+		return errors.WithMessage(err, "failed remove current version")
+	}
+	return Rename(ctx, storage, restored, stdpath.Base(path))
+}
+
+// saveVersion copies the file to VersionsDir before it's overwritten, and
+// removes the versions more than the storage keeps
+func saveVersion(ctx context.Context, storage driver.Driver, path string, file model.Obj) error {
+	if !KeepsVersions(storage) || file.IsDir() {
+		return nil
+	}
+	caps := GetCapabilities(storage)
+	if !caps.Copy || !caps.Rename {
+		return errors.New("keeping versions needs the storage to support copy and rename")
+	}
+	dir := versionsDirOf(path)
+	if err := MakeDir(ctx, storage, dir); err != nil {
+		return errors.WithMessage(err, "failed make versions dir")
+	}
+	if err := Copy(ctx, storage, path, dir); err != nil {
+		return errors.WithMessage(err, "failed copy version")
+	}
+	version := time.Now().UTC().Format(versionTimeFormat)
+	if err := Rename(ctx, storage, stdpath.Join(dir, stdpath.Base(path)), version); err != nil {
+		return errors.WithMessage(err, "failed rename version")
+	}
+	objs, err := List(ctx, storage, dir, model.ListArgs{}, true)
+	if err != nil {
+		log.Warnf("failed list versions of %s: %+v", path, err)
+		return nil
+	}
+	names := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		if !obj.IsDir() {
+			names = append(names, obj.GetName())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for i := storage.GetStorage().KeepVersions; i < len(names); i++ {
+		if err := Remove(ctx, storage, stdpath.Join(dir, names[i])); err != nil {
+			log.Warnf("failed remove old version %s of %s: %+v", names[i], path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package op
+
+import (
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// the enabled webhooks are looked up on every event, so keep them in
+// memory and reload them after any change
+var (
+	webhookMu     sync.RWMutex
+	webhookLoaded []model.Webhook
+	webhookGen    uint64
+)
+
+func clearWebhookCache() {
+	clearCache(cacheWebhook, "")
+}
+
+func dropWebhookCache() {
+	webhookMu.Lock()
+	webhookLoaded = nil
+	webhookGen++
+	webhookMu.Unlock()
+}
+
+// GetEnabledWebhooks returns the cached enabled webhooks
+func GetEnabledWebhooks() ([]model.Webhook, error) {
+	webhookMu.RLock()
+	webhooks, gen := webhookLoaded, webhookGen
+	webhookMu.RUnlock()
+	if webhooks != nil {
+		return webhooks, nil
+	}
+	webhooks, err := db.GetEnabledWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	if webhooks == nil {
+		webhooks = []model.Webhook{}
+	}
+	webhookMu.Lock()
+	if gen == webhookGen {
+		webhookLoaded = webhooks
+	}
+	webhookMu.Unlock()
+	return webhooks, nil
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	return db.GetWebhookById(id)
+}
+
+func GetWebhooks(pageIndex, pageSize int) ([]model.Webhook, int64, error) {
+	return db.GetWebhooks(pageIndex, pageSize)
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	defer clearWebhookCache()
+	return db.CreateWebhook(w)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	if _, err := db.GetWebhookById(w.ID); err != nil {
+		return err
+	}
+	defer clearWebhookCache()
+	return db.UpdateWebhook(w)
+}
+
+func DeleteWebhookById(id uint) error {
+	defer clearWebhookCache()
+	return db.DeleteWebhookById(id)
+}
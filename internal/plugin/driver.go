@@ -0,0 +1,181 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Driver is the driver of a storage served by the plugin, the storage is an
+// instance in the plugin identified by a random id
+type Driver struct {
+	model.Storage
+	plugin   *Plugin
+	config   driver.Config
+	addition json.RawMessage
+	instance string
+}
+
+func (d *Driver) Config() driver.Config {
+	return d.config
+}
+
+func (d *Driver) GetAddition() driver.Additional {
+	return &d.addition
+}
+
+func (d *Driver) Init(ctx context.Context) error {
+	if d.instance == "" {
+		d.instance = uuid.NewString()
+	}
+	addition := string(d.addition)
+	if addition == "" {
+		addition = "{}"
+	}
+	return toErr(d.plugin.client.Init(ctx, d.instance, addition))
+}
+
+func (d *Driver) Drop(ctx context.Context) error {
+	if d.instance == "" {
+		return nil
+	}
+	select {
+	case <-d.plugin.exited:
+		return nil
+	default:
+	}
+	return toErr(d.plugin.client.Drop(ctx, d.instance))
+}
+
+func (d *Driver) GetRoot(ctx context.Context) (model.Obj, error) {
+	return &model.Object{Path: "/", Name: "root", IsFolder: true}, nil
+}
+
+func (d *Driver) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	objs, err := d.plugin.client.List(ctx, d.instance, fromObj(dir))
+	if err != nil {
+		return nil, toErr(err)
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, o := range objs {
+		res = append(res, toObj(o))
+	}
+	return res, nil
+}
+
+func (d *Driver) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	link, err := d.plugin.client.Link(ctx, d.instance, fromObj(file), plugin.LinkArgs{IP: args.IP, Header: args.Header})
+	if err != nil {
+		return nil, toErr(err)
+	}
+	res := &model.Link{URL: link.URL, Header: link.Header}
+	if link.Expiration > 0 {
+		res.Expiration = &link.Expiration
+	}
+	return res, nil
+}
+
+func (d *Driver) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	return toErr(d.plugin.client.MakeDir(ctx, d.instance, fromObj(parentDir), dirName))
+}
+
+func (d *Driver) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return toErr(d.plugin.client.Move(ctx, d.instance, fromObj(srcObj), fromObj(dstDir)))
+}
+
+func (d *Driver) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return toErr(d.plugin.client.Rename(ctx, d.instance, fromObj(srcObj), newName))
+}
+
+func (d *Driver) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return toErr(d.plugin.client.Copy(ctx, d.instance, fromObj(srcObj), fromObj(dstDir)))
+}
+
+func (d *Driver) Remove(ctx context.Context, obj model.Obj) error {
+	return toErr(d.plugin.client.Remove(ctx, d.instance, fromObj(obj)))
+}
+
+func (d *Driver) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	file := plugin.FileStream{Reader: stream, Name: stream.GetName(), Size: stream.GetSize(), Mimetype: stream.GetMimetype()}
+	err := d.plugin.client.Put(ctx, d.instance, fromObj(dstDir), file, func(n int64) {
+		if stream.GetSize() > 0 {
+			up(int(n * 100 / stream.GetSize()))
+		}
+	})
+	return toErr(err)
+}
+
+// Capabilities drops the capabilities of the methods the plugin doesn't
+// implement
+func (d *Driver) Capabilities(caps *driver.Capabilities) {
+	caps.MakeDir = d.plugin.implements(plugin.MethodMakeDir)
+	caps.Move = d.plugin.implements(plugin.MethodMove)
+	caps.Rename = d.plugin.implements(plugin.MethodRename)
+	caps.Copy = d.plugin.implements(plugin.MethodCopy)
+	caps.Remove = d.plugin.implements(plugin.MethodRemove)
+	caps.Upload = caps.Upload && d.plugin.implements(plugin.MethodPut)
+	caps.Trash = caps.Trash && caps.Move && caps.MakeDir
+}
+
+func fromObj(obj model.Obj) plugin.Obj {
+	o := plugin.Obj{
+		ID:       obj.GetID(),
+		Path:     obj.GetPath(),
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		IsFolder: obj.IsDir(),
+	}
+	if h, ok := model.UnwrapObj(obj).(*model.Object); ok {
+		o.Hashes = h.Hashes
+	}
+	return o
+}
+
+func toObj(o plugin.Obj) model.Obj {
+	return &model.Object{
+		ID:       o.ID,
+		Path:     o.Path,
+		Name:     o.Name,
+		Size:     o.Size,
+		Modified: o.Modified,
+		IsFolder: o.IsFolder,
+		Hashes:   o.Hashes,
+	}
+}
+
+// toErr maps the status of the plugin to the errors of alist
+func toErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch s.Code() {
+	case codes.Unimplemented:
+		return errors.WithStack(errs.NotImplement)
+	case codes.NotFound:
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	return errors.New(s.Message())
+}
+
+var _ driver.Driver = (*Driver)(nil)
+var _ driver.GetRooter = (*Driver)(nil)
+var _ driver.Mkdir = (*Driver)(nil)
+var _ driver.Move = (*Driver)(nil)
+var _ driver.Rename = (*Driver)(nil)
+var _ driver.Copy = (*Driver)(nil)
+var _ driver.Remove = (*Driver)(nil)
+var _ driver.Put = (*Driver)(nil)
+var _ driver.Capabler = (*Driver)(nil)
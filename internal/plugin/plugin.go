@@ -0,0 +1,200 @@
+// Package plugin runs the driver plugins in the plugins dir and registers
+// their drivers, see pkg/plugin for the protocol.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/driver"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/plugin"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	handshakeTimeout = 10 * time.Second
+	callTimeout      = 10 * time.Second
+)
+
+// Plugin is a running plugin
+type Plugin struct {
+	Path string
+	Info plugin.Info
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	client *plugin.Client
+	exited chan struct{}
+}
+
+var (
+	mu      sync.Mutex
+	plugins []*Plugin
+)
+
+// Load starts the plugins in dir and registers their drivers, the plugins
+// failed to start are skipped
+func Load(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed read plugins dir %s: %+v", dir, err)
+		}
+		return
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || !executable(e.Name(), info.Mode()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		p, err := start(path)
+		if err != nil {
+			log.Errorf("failed start plugin %s: %+v", path, err)
+			continue
+		}
+		if err := register(p); err != nil {
+			log.Errorf("failed register driver of plugin %s: %+v", path, err)
+			p.stop()
+			continue
+		}
+		log.Infof("load plugin %s of driver %s", path, p.Info.Config.Name)
+		mu.Lock()
+		plugins = append(plugins, p)
+		mu.Unlock()
+	}
+}
+
+func executable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(name), ".exe")
+	}
+	return mode&0111 != 0
+}
+
+// start runs the plugin and connects to it with the address of its
+// handshake line
+func start(path string) (*Plugin, error) {
+	secret := random.Secret(32)
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(), plugin.MagicEnv+"="+plugin.ProtocolVersion, plugin.SecretEnv+"="+secret)
+	cmd.Stderr = utils.Log.WriterLevel(log.WarnLevel)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &Plugin{Path: path, cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	go func() {
+		err := cmd.Wait()
+		close(p.exited)
+		log.Warnf("plugin %s exited: %v", path, err)
+	}()
+	lines := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		if scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		// the other outputs are logged
+		for scanner.Scan() {
+			log.Infof("[plugin %s] %s", filepath.Base(path), scanner.Text())
+		}
+	}()
+	var line string
+	select {
+	case line = <-lines:
+	case <-p.exited:
+		return nil, errors.New("exited before the handshake")
+	case <-time.After(handshakeTimeout):
+		p.stop()
+		return nil, errors.New("timeout waiting for the handshake")
+	}
+	network, address, err := plugin.ParseHandshake(line)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+	if p.client, err = plugin.Dial(network, address, secret); err != nil {
+		p.stop()
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	info, err := p.client.Info(ctx)
+	if err != nil {
+		p.stop()
+		return nil, errors.WithMessage(err, "failed get info")
+	}
+	p.Info = *info
+	return p, nil
+}
+
+func register(p *Plugin) error {
+	c := p.Info.Config
+	if c.Name == "" {
+		return errors.New("the driver has no name")
+	}
+	items := make([]driver.Item, 0, len(p.Info.Additional))
+	for _, item := range p.Info.Additional {
+		items = append(items, driver.Item(item))
+	}
+	config := driver.Config{
+		Name:        c.Name,
+		LocalSort:   c.LocalSort,
+		OnlyProxy:   c.OnlyProxy,
+		NoCache:     c.NoCache,
+		NoUpload:    c.NoUpload || !p.implements(plugin.MethodPut),
+		DefaultRoot: c.DefaultRoot,
+		Alert:       c.Alert,
+	}
+	return op.RegisterExternalDriver(config, items, func() driver.Driver {
+		return &Driver{plugin: p, config: config}
+	})
+}
+
+func (p *Plugin) implements(method string) bool {
+	return utils.SliceContains(p.Info.Methods, method)
+}
+
+// stop closes the stdin of the plugin to make it exit, and kills it if it
+// doesn't in time
+func (p *Plugin) stop() {
+	if p.client != nil {
+		_ = p.client.Close()
+	}
+	_ = p.stdin.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(3 * time.Second):
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+// Close stops all the plugins
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, p := range plugins {
+		p.stop()
+	}
+	plugins = nil
+}
@@ -0,0 +1,83 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+var cleaning atomic.Bool
+
+// cacheDir keeps the pdf and the rendered pages of each document in the
+// folder of its cache key
+func cacheDir() string {
+	return filepath.Join(flags.DataDir, "previews")
+}
+
+func touch(dir string) {
+	now := time.Now()
+	_ = os.Chtimes(dir, now, now)
+}
+
+type cachedDoc struct {
+	dir    string
+	size   int64
+	usedAt time.Time
+}
+
+// clean drops the least recently previewed documents until the cache fits
+// in the size of the setting, the latest one is kept as it's being viewed
+func clean() {
+	if !cleaning.CompareAndSwap(false, true) {
+		return
+	}
+	defer cleaning.Store(false)
+	limit := int64(setting.GetInt(conf.PreviewCacheSize, 1024)) * 1024 * 1024
+	entries, err := os.ReadDir(cacheDir())
+	if err != nil {
+		return
+	}
+	var docs []cachedDoc
+	var total int64
+	for _, e := range entries {
+		name := filepath.Join(cacheDir(), e.Name())
+		// the files of the flat layout before the folders of the keys
+		if !e.IsDir() {
+			_ = os.Remove(name)
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		d := cachedDoc{dir: name, usedAt: info.ModTime()}
+		files, _ := os.ReadDir(d.dir)
+		for _, f := range files {
+			if fi, err := f.Info(); err == nil {
+				d.size += fi.Size()
+			}
+		}
+		total += d.size
+		docs = append(docs, d)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].usedAt.Before(docs[j].usedAt)
+	})
+	for i, d := range docs {
+		if total <= limit || i == len(docs)-1 {
+			break
+		}
+		if err := os.RemoveAll(d.dir); err != nil {
+			log.Warnf("failed remove cached previews %s: %+v", d.dir, err)
+			continue
+		}
+		total -= d.size
+	}
+}
@@ -0,0 +1,143 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+// the office converters
+const (
+	OnlyOffice = "onlyoffice"
+	Collabora  = "collabora"
+)
+
+// convert converts the office file to the pdf of dst with the configured
+// converter
+func convert(ctx context.Context, path string, obj model.Obj, key, sourceURL, dst string) error {
+	u := strings.TrimSuffix(setting.GetStr(conf.PreviewConverterUrl), "/")
+	if u == "" {
+		return errors.New("the url of the office converter is not configured")
+	}
+	switch setting.GetStr(conf.PreviewOfficeConverter) {
+	case OnlyOffice:
+		return convertOnlyOffice(ctx, u, obj, key, sourceURL, dst)
+	case Collabora:
+		return convertCollabora(ctx, u, path, obj, dst)
+	}
+	return errors.New("the office converter is not configured")
+}
+
+type onlyOfficeResp struct {
+	EndConvert bool   `json:"endConvert"`
+	FileUrl    string `json:"fileUrl"`
+	Error      int    `json:"error"`
+}
+
+// convertOnlyOffice asks the conversion api of onlyoffice to convert the
+// file, which downloads the file from sourceURL
+func convertOnlyOffice(ctx context.Context, u string, obj model.Obj, key, sourceURL, dst string) error {
+	if sourceURL == "" {
+		return errors.New("onlyoffice needs the url to download the file")
+	}
+	payload := map[string]any{
+		"async":      false,
+		"filetype":   utils.Ext(obj.GetName()),
+		"key":        key,
+		"outputtype": "pdf",
+		"title":      obj.GetName(),
+		"url":        sourceURL,
+	}
+	if secret := setting.GetStr(conf.PreviewConverterSecret); secret != "" {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(payload)).SignedString([]byte(secret))
+		if err != nil {
+			return errors.Wrap(err, "failed sign the request of onlyoffice")
+		}
+		payload["token"] = token
+	}
+	body, err := utils.Json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u+"/ConvertService.ashx", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	res, err := common.HttpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed request onlyoffice")
+	}
+	defer res.Body.Close()
+	var resp onlyOfficeResp
+	if err := utils.Json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		return errors.Wrapf(err, "failed decode the response of onlyoffice: %s", res.Status)
+	}
+	if resp.Error != 0 {
+		return errors.Errorf("onlyoffice failed to convert with error %d", resp.Error)
+	}
+	if !resp.EndConvert || resp.FileUrl == "" {
+		return errors.New("onlyoffice didn't finish converting")
+	}
+	rc, err := common.OpenLink(ctx, &model.Link{URL: resp.FileUrl})
+	if err != nil {
+		return errors.WithMessage(err, "failed download the converted pdf")
+	}
+	defer rc.Close()
+	return writeFile(dst, rc)
+}
+
+// convertCollabora uploads the file to the convert-to api of collabora
+func convertCollabora(ctx context.Context, u, path string, obj model.Obj, dst string) error {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	src, err := common.OpenLink(ctx, link)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		part, err := mw.CreateFormFile("data", obj.GetName())
+		if err == nil {
+			_, err = io.Copy(part, io.LimitReader(src, maxSourceSize()))
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u+"/cool/convert-to/pdf", pr)
+	if err != nil {
+		_ = pr.Close()
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	res, err := common.HttpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed request collabora")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("collabora failed to convert: %s %s", res.Status, msg)
+	}
+	return writeFile(dst, res.Body)
+}
@@ -0,0 +1,175 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// Resolution of the rendered pages in dpi
+const Resolution = 110
+
+var (
+	pdfG  singleflight.Group[*pdfFile]
+	pageG singleflight.Group[[]byte]
+)
+
+// pdfFile is the cached pdf of a pdf or office file
+type pdfFile struct {
+	path  string
+	pages int
+	key   string
+}
+
+// cacheKey changes whenever the source file changes
+func cacheKey(path string, obj model.Obj) string {
+	return utils.GetMD5Encode(fmt.Sprintf("%s-%d-%d", path, obj.GetSize(), obj.ModTime().Unix()))
+}
+
+func getPDF(ctx context.Context, path string, obj model.Obj, typ, sourceURL string) (*pdfFile, error) {
+	key := cacheKey(path, obj)
+	f, err, _ := pdfG.Do(key, func() (*pdfFile, error) {
+		dir := filepath.Join(cacheDir(), key)
+		name := filepath.Join(dir, "doc.pdf")
+		if _, err := os.Stat(name); err != nil {
+			if obj.GetSize() > maxSourceSize() {
+				return nil, errors.Errorf("%s is too large to preview", obj.GetName())
+			}
+			if err := os.MkdirAll(dir, 0777); err != nil {
+				return nil, err
+			}
+			if typ == TypeOffice {
+				err = convert(ctx, path, obj, key, sourceURL, name)
+			} else {
+				err = download(ctx, path, name)
+			}
+			if err != nil {
+				return nil, err
+			}
+			go clean()
+		}
+		touch(dir)
+		pages, err := countPages(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return &pdfFile{path: name, pages: pages, key: key}, nil
+	})
+	return f, err
+}
+
+// PDF returns the local path of the pdf of the pdf or office file
+func PDF(ctx context.Context, path, sourceURL string) (string, error) {
+	obj, typ, err := getFile(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if typ != TypePDF && typ != TypeOffice {
+		return "", errors.Errorf("%s has no pages", obj.GetName())
+	}
+	f, err := getPDF(ctx, path, obj, typ, sourceURL)
+	if err != nil {
+		return "", err
+	}
+	return f.path, nil
+}
+
+// Page renders the page of the pdf or office file to png, the page starts
+// from 1. The etag changes whenever the source file changes.
+func Page(ctx context.Context, path string, page int, sourceURL string) ([]byte, string, error) {
+	obj, typ, err := getFile(ctx, path)
+	if err != nil {
+		return nil, "", err
+	}
+	if typ != TypePDF && typ != TypeOffice {
+		return nil, "", errors.Errorf("%s has no pages", obj.GetName())
+	}
+	f, err := getPDF(ctx, path, obj, typ, sourceURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if page < 1 || page > f.pages {
+		return nil, "", errors.Errorf("page %d is out of range [1, %d]", page, f.pages)
+	}
+	dir := filepath.Dir(f.path)
+	prefix := filepath.Join(dir, strconv.Itoa(page))
+	data, err, _ := pageG.Do(prefix, func() ([]byte, error) {
+		if data, err := os.ReadFile(prefix + ".png"); err == nil {
+			touch(dir)
+			return data, nil
+		}
+		n := strconv.Itoa(page)
+		cmd := exec.CommandContext(ctx, "pdftoppm", "-f", n, "-l", n, "-png",
+			"-r", strconv.Itoa(Resolution), "-singlefile", f.path, prefix)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, errors.Wrapf(err, "failed render page with pdftoppm: %s", stderr.String())
+		}
+		touch(dir)
+		go clean()
+		return os.ReadFile(prefix + ".png")
+	})
+	return data, fmt.Sprintf("%s-%d", f.key, page), err
+}
+
+// countPages reads the number of the pages with pdfinfo
+func countPages(ctx context.Context, name string) (int, error) {
+	out, err := exec.CommandContext(ctx, "pdfinfo", name).Output()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed read pdf with pdfinfo, is poppler installed?")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "Pages:"); ok {
+			return strconv.Atoi(strings.TrimSpace(v))
+		}
+	}
+	return 0, errors.New("failed get the number of the pages")
+}
+
+func download(ctx context.Context, path, dst string) error {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	rc, err := common.OpenLink(ctx, link)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeFile(dst, io.LimitReader(rc, maxSourceSize()))
+}
+
+// writeFile writes to a temp file first, so that a failed write is not taken
+// as cached
+func writeFile(dst string, r io.Reader) error {
+	tmp := dst + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,161 @@
+// Package preview renders the previews of documents on the server, so that
+// they work even if the storage doesn't allow direct links.
+//
+// Text and markdown are rendered to sanitized html. PDFs are rendered page
+// by page to images with pdftoppm of poppler, and office files are converted
+// to PDFs by onlyoffice or collabora first.
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/pkg/errors"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// the types of previews
+const (
+	TypeText     = "text"
+	TypeMarkdown = "markdown"
+	TypePDF      = "pdf"
+	TypeOffice   = "office"
+)
+
+type Preview struct {
+	Type string `json:"type"`
+	// Content is the sanitized html of text and markdown
+	Content string `json:"content,omitempty"`
+	// Truncated is true if only the head of the text is previewed
+	Truncated bool `json:"truncated,omitempty"`
+	// Pages is the number of the pages of pdf and office files, which are
+	// rendered by Page
+	Pages int `json:"pages,omitempty"`
+}
+
+var (
+	md        = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	sanitizer = bluemonday.UGCPolicy().AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code", "pre", "span")
+)
+
+// TypeOf returns the type of the preview of the file, or empty if it can't
+// be previewed
+func TypeOf(name string) string {
+	ext := utils.Ext(name)
+	switch {
+	case ext == "md" || ext == "markdown":
+		return TypeMarkdown
+	case ext == "pdf":
+		return TypePDF
+	case isOffice(ext):
+		if setting.GetStr(conf.PreviewOfficeConverter) == "" {
+			return ""
+		}
+		return TypeOffice
+	case utils.GetFileType(name) == conf.TEXT:
+		return TypeText
+	}
+	return ""
+}
+
+func isOffice(ext string) bool {
+	for _, t := range strings.Split(setting.GetStr(conf.PreviewOfficeTypes), ",") {
+		if strings.TrimSpace(t) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func maxSourceSize() int64 {
+	return int64(setting.GetInt(conf.PreviewMaxSourceSize, 20)) * 1024 * 1024
+}
+
+func getFile(ctx context.Context, path string) (model.Obj, string, error) {
+	obj, err := fs.Get(ctx, path, &fs.GetArgs{})
+	if err != nil {
+		return nil, "", err
+	}
+	if obj.IsDir() {
+		return nil, "", errors.WithStack(errs.NotFile)
+	}
+	typ := TypeOf(obj.GetName())
+	if typ == "" {
+		return nil, "", errors.Errorf("preview of %s is not supported", obj.GetName())
+	}
+	return obj, typ, nil
+}
+
+// Get previews the file at path, sourceURL is where the office converter
+// downloads the file from
+func Get(ctx context.Context, path, sourceURL string) (*Preview, error) {
+	obj, typ, err := getFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if typ == TypePDF || typ == TypeOffice {
+		pdf, err := getPDF(ctx, path, obj, typ, sourceURL)
+		if err != nil {
+			return nil, err
+		}
+		return &Preview{Type: typ, Pages: pdf.pages}, nil
+	}
+	data, truncated, err := readHead(ctx, path, maxSourceSize())
+	if err != nil {
+		return nil, err
+	}
+	content, err := Render(typ, obj.GetName(), data)
+	if err != nil {
+		return nil, err
+	}
+	return &Preview{Type: typ, Content: content, Truncated: truncated}, nil
+}
+
+// readHead reads at most n bytes of the file
+func readHead(ctx context.Context, path string, n int64) ([]byte, bool, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return nil, false, err
+	}
+	rc, err := common.OpenLink(ctx, link)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, n+1))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed read file")
+	}
+	if int64(len(data)) > n {
+		return data[:n], true, nil
+	}
+	return data, false, nil
+}
+
+// Render renders the text or markdown to sanitized html, code is escaped in
+// a pre block with the language class for highlighting
+func Render(typ, name string, data []byte) (string, error) {
+	if typ == TypeMarkdown {
+		var buf bytes.Buffer
+		if err := md.Convert(data, &buf); err != nil {
+			return "", errors.Wrap(err, "failed render markdown")
+		}
+		return sanitizer.Sanitize(buf.String()), nil
+	}
+	text := strings.ToValidUTF8(string(data), "�")
+	return fmt.Sprintf(`<pre><code class="language-%s">%s</code></pre>`,
+		html.EscapeString(utils.Ext(name)), html.EscapeString(text)), nil
+}
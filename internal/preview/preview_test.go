@@ -0,0 +1,30 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	out, err := Render(TypeMarkdown, "a.md", []byte("# Title\n\n<script>alert(1)</script>\n\n[x](javascript:alert(1))\n\n```go\nfmt.Println()\n```\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "<h1") || !strings.Contains(out, `class="language-go"`) {
+		t.Errorf("expect the markdown is rendered, got %s", out)
+	}
+	if strings.Contains(out, "<script") || strings.Contains(out, "javascript:") {
+		t.Errorf("expect the html is sanitized, got %s", out)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	out, err := Render(TypeText, "a.go", []byte(`if a < b { s := "</code>" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<pre><code class="language-go">if a &lt; b { s := &#34;&lt;/code&gt;&#34; }</code></pre>`
+	if out != want {
+		t.Errorf("expect %s, got %s", want, out)
+	}
+}
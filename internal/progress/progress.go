@@ -0,0 +1,98 @@
+// Package progress pushes the changes of the tasks and the uploads to the
+// subscribed clients, each user receives the changes of the own ones only.
+package progress
+
+import (
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+)
+
+// Event is the latest state of a task or a direct upload
+type Event struct {
+	// Type is the type of the task api, such as copy and upload, or
+	// direct_upload for the uploads not as a task
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+	Error    string `json:"error,omitempty"`
+	// Bytes and Total are the received bytes and the size of direct uploads
+	Bytes int64 `json:"bytes,omitempty"`
+	Total int64 `json:"total,omitempty"`
+
+	creator uint
+}
+
+// Done reports whether it's the last event of the task
+func (e Event) Done() bool {
+	return e.State == task.SUCCEEDED || e.State == task.CANCELED || e.State == task.ERRORED
+}
+
+// buffer is the events kept for a slow client, the events are dropped if
+// it's full, and the client catches up with the later ones
+const buffer = 64
+
+type subscriber struct {
+	user *model.User
+	ch   chan Event
+}
+
+func (s *subscriber) match(e Event) bool {
+	return s.user.IsAdmin() || (e.creator != 0 && e.creator == s.user.ID)
+}
+
+var (
+	mu     sync.RWMutex
+	subs   = make(map[*subscriber]struct{})
+	latest = make(map[string]Event)
+)
+
+// Subscribe receives the events of the tasks of the user, or of all tasks
+// for the admin. The latest events of the undone tasks are sent first, and
+// cancel must be called when the client leaves.
+func Subscribe(user *model.User) (<-chan Event, func()) {
+	s := &subscriber{user: user, ch: make(chan Event, buffer)}
+	mu.Lock()
+	for _, e := range latest {
+		if s.match(e) {
+			select {
+			case s.ch <- e:
+			default:
+			}
+		}
+	}
+	subs[s] = struct{}{}
+	mu.Unlock()
+	return s.ch, func() {
+		mu.Lock()
+		delete(subs, s)
+		mu.Unlock()
+	}
+}
+
+// Publish sends the event of the task created by the user of creator, it
+// doesn't block on slow clients
+func Publish(creator uint, e Event) {
+	e.creator = creator
+	key := e.Type + ":" + e.ID
+	mu.Lock()
+	defer mu.Unlock()
+	if e.Done() {
+		delete(latest, key)
+	} else {
+		latest[key] = e
+	}
+	for s := range subs {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+		}
+	}
+}
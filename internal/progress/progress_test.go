@@ -0,0 +1,40 @@
+package progress
+
+import (
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/task"
+)
+
+func TestSubscribe(t *testing.T) {
+	admin := &model.User{ID: 1, Role: model.ADMIN}
+	user := &model.User{ID: 2, Role: model.GENERAL}
+	other := &model.User{ID: 3, Role: model.GENERAL}
+	Publish(2, Event{Type: "copy", ID: "1", State: task.RUNNING})
+	adminEvents, cancelAdmin := Subscribe(admin)
+	defer cancelAdmin()
+	userEvents, cancelUser := Subscribe(user)
+	defer cancelUser()
+	otherEvents, cancelOther := Subscribe(other)
+	defer cancelOther()
+	if len(adminEvents) != 1 || len(userEvents) != 1 {
+		t.Fatalf("expect the undone task sent on subscribing, got %d and %d", len(adminEvents), len(userEvents))
+	}
+	<-adminEvents
+	<-userEvents
+	Publish(2, Event{Type: "copy", ID: "1", State: task.SUCCEEDED})
+	Publish(0, Event{Type: "sync", ID: "1", State: task.SUCCEEDED})
+	if len(adminEvents) != 2 {
+		t.Errorf("expect the admin receives all events, got %d", len(adminEvents))
+	}
+	if e := <-userEvents; e.Type != "copy" || !e.Done() || len(userEvents) != 0 {
+		t.Errorf("expect the user receives the own task only, got %+v", e)
+	}
+	if len(otherEvents) != 0 {
+		t.Errorf("expect the other user receives nothing, got %d", len(otherEvents))
+	}
+	if len(latest) != 0 {
+		t.Errorf("expect the done tasks removed, got %d", len(latest))
+	}
+}
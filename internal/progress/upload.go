@@ -0,0 +1,68 @@
+package progress
+
+import (
+	"io"
+	"time"
+
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/google/uuid"
+)
+
+// interval is the min interval between the progress events of an upload
+const interval = 500 * time.Millisecond
+
+// Upload reports the received bytes of an upload that is not a task
+type Upload struct {
+	io.ReadCloser
+	creator uint
+	event   Event
+	bytes   int64
+	last    time.Time
+}
+
+// TrackUpload wraps the body of the upload of the user, Done must be called
+// after the upload ends
+func TrackUpload(creator uint, name string, total int64, body io.ReadCloser) *Upload {
+	u := &Upload{
+		ReadCloser: body,
+		creator:    creator,
+		event: Event{
+			Type:  "direct_upload",
+			ID:    uuid.NewString(),
+			Name:  name,
+			State: task.RUNNING,
+			Total: total,
+		},
+		last: time.Now(),
+	}
+	Publish(creator, u.event)
+	return u
+}
+
+func (u *Upload) Read(p []byte) (int, error) {
+	n, err := u.ReadCloser.Read(p)
+	u.bytes += int64(n)
+	if time.Since(u.last) >= interval {
+		u.last = time.Now()
+		u.publish()
+	}
+	return n, err
+}
+
+func (u *Upload) publish() {
+	u.event.Bytes = u.bytes
+	if u.event.Total > 0 {
+		u.event.Progress = int(u.event.Bytes * 100 / u.event.Total)
+	}
+	Publish(u.creator, u.event)
+}
+
+// Done publishes the result of the upload
+func (u *Upload) Done(err error) {
+	u.event.State = task.SUCCEEDED
+	if err != nil {
+		u.event.State = task.ERRORED
+		u.event.Error = err.Error()
+	}
+	u.publish()
+}
@@ -44,8 +44,9 @@ func AddURL(ctx context.Context, url string, dstDirPath string) error {
 		return errors.Wrapf(err, "failed to add url %s", url)
 	}
 	DownTaskManager.Submit(task.WithCancelCtx(&task.Task[string]{
-		ID:   id,
-		Name: fmt.Sprintf("download %s to [%s](%s)", url, storage.GetStorage().MountPath, dstDirActualPath),
+		ID:      id,
+		Creator: op.UserIDFromCtx(ctx),
+		Name:    fmt.Sprintf("download %s to [%s](%s)", url, storage.GetStorage().MountPath, dstDirActualPath),
 		Func: func(tsk *task.Task[string]) error {
 			m := &Monitor{
 				tsk:        tsk,
@@ -60,7 +60,11 @@ outer:
 		select {
 		case <-m.tsk.Ctx.Done():
 			// delete qbittorrent task and downloaded files when the task exits with error
-			return qbclient.Delete(m.tsk.ID, true)
+			err = qbclient.Delete(m.tsk.ID, true)
+			if e := os.RemoveAll(m.tempDir); e != nil {
+				log.Errorf("failed to remove qbittorrent temp dir: %+v", e)
+			}
+			return err
 		case <-time.After(time.Second * 2):
 			completed, err = m.update()
 			if completed {
@@ -71,7 +75,6 @@ outer:
 	if err != nil {
 		return err
 	}
-	m.tsk.SetStatus("qbittorrent download completed, transferring")
 	<-m.finish
 	m.tsk.SetStatus("completed")
 	return nil
@@ -109,6 +112,10 @@ func (m *Monitor) complete() error {
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	user, err := op.GetTaskUser(m.tsk.Creator)
+	if err != nil {
+		return errors.WithMessage(err, "failed get the user of the task")
+	}
 	// get files
 	files, err := qbclient.GetFiles(m.tsk.ID)
 	if err != nil {
@@ -122,7 +129,11 @@ func (m *Monitor) complete() error {
 	// 	return err
 	// }
 	// upload files
-	var wg sync.WaitGroup
+	var (
+		wg          sync.WaitGroup
+		transferred atomic.Int32
+	)
+	m.tsk.SetStatus(fmt.Sprintf("transferring 0/%d files", len(files)))
 	wg.Add(len(files))
 	go func() {
 		wg.Wait()
@@ -147,11 +158,15 @@ func (m *Monitor) complete() error {
 		dstPath := filepath.Join(dstBaseDir, file.Name)
 		dstDir := filepath.Dir(dstPath)
 		fileName := filepath.Base(dstPath)
+		size := file.Size
 		TransferTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
-			Name: fmt.Sprintf("transfer %s to [%s](%s)", tempPath, storage.GetStorage().MountPath, dstPath),
+			Name:    fmt.Sprintf("transfer %s to [%s](%s)", tempPath, storage.GetStorage().MountPath, dstPath),
+			Creator: m.tsk.Creator,
 			Func: func(tsk *task.Task[uint64]) error {
-				defer wg.Done()
-				size := file.Size
+				defer func() {
+					m.tsk.SetStatus(fmt.Sprintf("transferring %d/%d files", transferred.Add(1), len(files)))
+					wg.Done()
+				}()
 				mimetype := utils.GetMimeType(tempPath)
 				f, err := os.Open(tempPath)
 				if err != nil {
@@ -167,7 +182,7 @@ func (m *Monitor) complete() error {
 					ReadCloser: struct{ io.ReadSeekCloser }{f},
 					Mimetype:   mimetype,
 				}
-				return op.Put(tsk.Ctx, storage, dstDir, stream, tsk.SetProgress)
+				return op.PutWithQuota(tsk.Ctx, user, storage, dstDir, stream, tsk.SetProgress)
 			},
 		}))
 	}
@@ -5,10 +5,12 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/driver"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
@@ -233,6 +235,50 @@ func Update(parent string, objs []model.Obj) {
 	}
 }
 
+// objsChangeDelay is how long the changed parents are collected, so that a
+// bulk move or delete lists each parent once
+const objsChangeDelay = 3 * time.Second
+
+type changedParent struct {
+	storage driver.Driver
+	parent  string
+}
+
+var (
+	changedMu      sync.Mutex
+	changedParents map[string]changedParent
+)
+
+// onObjsChange collects parent after objs in it are changed, to be listed
+// again later
+func onObjsChange(storage driver.Driver, parent string) {
+	if instance == nil || !instance.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) {
+		return
+	}
+	changedMu.Lock()
+	defer changedMu.Unlock()
+	if changedParents == nil {
+		changedParents = make(map[string]changedParent)
+		time.AfterFunc(objsChangeDelay, refreshChangedParents)
+	}
+	changedParents[op.Key(storage, parent)] = changedParent{storage: storage, parent: parent}
+}
+
+// refreshChangedParents lists the changed parents bypassing the cache, the
+// objsUpdate hook of the list updates the index then
+func refreshChangedParents() {
+	changedMu.Lock()
+	parents := changedParents
+	changedParents = nil
+	changedMu.Unlock()
+	for _, p := range parents {
+		if _, err := op.List(context.Background(), p.storage, p.parent, model.ListArgs{}, true); err != nil {
+			log.Errorf("update search index error while list %s: %+v", op.Key(p.storage, p.parent), err)
+		}
+	}
+}
+
 func init() {
 	op.RegisterObjsUpdateHook(Update)
+	op.RegisterObjsChangeHook(onObjsChange)
 }
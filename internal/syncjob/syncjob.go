@@ -0,0 +1,147 @@
+// Package syncjob runs the sync jobs on their cron schedules.
+//
+// A run is a task of fs.SyncTaskManager, and a job is not run again while
+// its last run is still going on.
+package syncjob
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/task"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+	mu      sync.Mutex
+	c       = cron.New(cron.WithParser(parser))
+	entries = make(map[uint]cron.EntryID)
+	running = make(map[uint]bool)
+)
+
+// ParseCron checks the cron expression of a job
+func ParseCron(spec string) error {
+	if spec == "" {
+		return nil
+	}
+	_, err := parser.Parse(spec)
+	return errors.Wrapf(err, "invalid cron expression %s", spec)
+}
+
+// Init schedules the enabled jobs and reschedules them on changes, it
+// should be called after storages are loaded
+func Init() {
+	op.RegisterSyncJobHook(func(typ string, job model.SyncJob) {
+		unschedule(job.ID)
+		if typ != "del" {
+			schedule(job)
+		}
+	})
+	jobs, err := op.GetEnabledSyncJobs()
+	if err != nil {
+		log.Errorf("failed get sync jobs: %+v", err)
+	}
+	for _, job := range jobs {
+		schedule(job)
+	}
+	c.Start()
+}
+
+// Stop stops scheduling new runs
+func Stop() {
+	c.Stop()
+}
+
+func schedule(job model.SyncJob) {
+	if job.Disabled || job.Cron == "" {
+		return
+	}
+	id := job.ID
+	entry, err := c.AddFunc(job.Cron, func() {
+		// the scheduled runs are left to the leader of the cluster
+		if !cluster.IsLeader() {
+			return
+		}
+		// the job may be changed since scheduled
+		j, err := op.GetSyncJobById(id)
+		if err != nil {
+			log.Errorf("failed get sync job %d: %+v", id, err)
+			return
+		}
+		if err := Run(*j); err != nil {
+			log.Warnf("skip scheduled sync job %s: %+v", j.Name, err)
+		}
+	})
+	if err != nil {
+		log.Errorf("failed schedule sync job %s: %+v", job.Name, err)
+		return
+	}
+	mu.Lock()
+	entries[id] = entry
+	mu.Unlock()
+}
+
+func unschedule(id uint) {
+	mu.Lock()
+	defer mu.Unlock()
+	if entry, ok := entries[id]; ok {
+		c.Remove(entry)
+		delete(entries, id)
+	}
+}
+
+// Run submits a run of the job as a task, it fails if the job is running
+func Run(job model.SyncJob) error {
+	mu.Lock()
+	if running[job.ID] {
+		mu.Unlock()
+		return errors.Errorf("sync job %s is running", job.Name)
+	}
+	running[job.ID] = true
+	mu.Unlock()
+	fs.SyncTaskManager.Submit(task.WithCancelCtx(&task.Task[uint64]{
+		Name: fmt.Sprintf("sync %s to %s", job.Src, job.Dst),
+		Func: func(t *task.Task[uint64]) error {
+			report, err := fs.Sync(t.Ctx, &job, false, t.SetStatus)
+			result := ""
+			if report != nil {
+				result = report.String()
+				t.SetStatus(result)
+			}
+			if err == nil && report.Failed > 0 {
+				err = errors.Errorf("%d actions failed", report.Failed)
+			}
+			if err != nil {
+				if result != "" {
+					result += ": "
+				}
+				result += err.Error()
+			}
+			if e := op.UpdateSyncJobResult(job.ID, time.Now(), result); e != nil {
+				log.Errorf("failed save the result of sync job %s: %+v", job.Name, e)
+			}
+			return err
+		},
+		Finally: func(t *task.Task[uint64]) {
+			mu.Lock()
+			delete(running, job.ID)
+			mu.Unlock()
+		},
+	}))
+	return nil
+}
+
+// DryRun reports what a run of the job would do without touching files
+func DryRun(ctx context.Context, job model.SyncJob) (*fs.SyncReport, error) {
+	return fs.Sync(ctx, &job, true, nil)
+}
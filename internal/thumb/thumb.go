@@ -0,0 +1,238 @@
+package thumb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	stdpath "path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/disintegration/imaging"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+	_ "golang.org/x/image/webp"
+)
+
+// Width of generated thumbnails, the same as the local driver
+const Width = 144
+
+// snapshotTimeout bounds a ffmpeg run, which may hang on a slow or broken
+// remote video
+const snapshotTimeout = time.Minute
+
+var thumbG singleflight.Group[*Thumb]
+
+var httpClient = &http.Client{}
+
+type Thumb struct {
+	Data []byte
+	// ETag changes whenever the source file changes
+	ETag string
+}
+
+// Supported reports whether a thumbnail can be generated for the file
+func Supported(name string) bool {
+	if utils.Ext(name) == "svg" {
+		return false
+	}
+	t := utils.GetFileType(name)
+	return t == conf.IMAGE || t == conf.VIDEO
+}
+
+// Get returns the thumbnail of the file at path, generating it if it's not cached
+func Get(ctx context.Context, path string) (*Thumb, error) {
+	obj, err := fs.Get(ctx, path, &fs.GetArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if obj.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	if !Supported(obj.GetName()) {
+		return nil, fmt.Errorf("thumbnail of %s is not supported", obj.GetName())
+	}
+	key := utils.GetMD5Encode(fmt.Sprintf("%s-%d-%d-%d", path, obj.GetSize(), obj.ModTime().Unix(), Width))
+	t, err, _ := thumbG.Do(key, func() (*Thumb, error) {
+		if data, err := readCache(ctx, key); err == nil {
+			return &Thumb{Data: data, ETag: key}, nil
+		}
+		data, err := generate(ctx, path, obj)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeCache(ctx, key, data); err != nil {
+			log.Warnf("failed to cache thumbnail of %s: %+v", path, err)
+		}
+		return &Thumb{Data: data, ETag: key}, nil
+	})
+	return t, err
+}
+
+func generate(ctx context.Context, path string, obj model.Obj) ([]byte, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if link.Data != nil {
+		defer link.Data.Close()
+	}
+	var src image.Image
+	if utils.GetFileType(obj.GetName()) == conf.VIDEO {
+		src, err = snapshot(ctx, link, obj.GetName())
+	} else {
+		var rc io.ReadCloser
+		rc, err = open(ctx, link)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		maxSize := int64(setting.GetInt(conf.ThumbnailMaxSourceSize, 50)) * 1024 * 1024
+		src, err = imaging.Decode(io.LimitReader(rc, maxSize), imaging.AutoOrientation(true))
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to decode source")
+	}
+	var buf bytes.Buffer
+	err = imaging.Encode(&buf, imaging.Resize(src, Width, 0, imaging.Lanczos), imaging.JPEG)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// snapshot grabs a frame of the video with ffmpeg, which is killed when
+// ctx is done or after snapshotTimeout
+func snapshot(ctx context.Context, link *model.Link, name string) (image.Image, error) {
+	input := link.URL
+	kwArgs := ffmpeg.KwArgs{}
+	if link.FilePath != nil {
+		input = *link.FilePath
+	} else if link.Data != nil {
+		// ffmpeg can't read from a stream that can't seek, so write it to a temp file
+		tmp := filepath.Join(conf.Conf.TempDir, uuid.NewString()+"-"+name)
+		f, err := os.Create(tmp)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp)
+		maxSize := int64(setting.GetInt(conf.ThumbnailMaxSourceSize, 50)) * 1024 * 1024
+		_, err = io.Copy(f, io.LimitReader(link.Data, maxSize))
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+		input = tmp
+	} else if len(link.Header) > 0 {
+		var headers strings.Builder
+		for k, vals := range link.Header {
+			for _, v := range vals {
+				headers.WriteString(k + ": " + v + "\r\n")
+			}
+		}
+		kwArgs["headers"] = headers.String()
+	}
+	var buf bytes.Buffer
+	compiled := ffmpeg.Input(input, kwArgs).Filter("select", ffmpeg.Args{"gte(n,10)"}).
+		Output("pipe:", ffmpeg.KwArgs{"vframes": 1, "format": "image2", "vcodec": "mjpeg"}).
+		WithOutput(&buf, io.Discard).
+		Compile()
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, compiled.Path, compiled.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = compiled.Stdout, compiled.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return imaging.Decode(&buf)
+}
+
+func open(ctx context.Context, link *model.Link) (io.ReadCloser, error) {
+	if link.Data != nil {
+		return link.Data, nil
+	}
+	if link.FilePath != nil {
+		return os.Open(*link.FilePath)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return res.Body, nil
+}
+
+func cacheName(key string) string {
+	return stdpath.Join(key[:2], key+".jpg")
+}
+
+// readCache reads the cached thumbnail from the storage of the cache path
+// setting, or the data dir if it's empty
+func readCache(ctx context.Context, key string) ([]byte, error) {
+	cachePath := setting.GetStr(conf.ThumbnailCachePath)
+	if cachePath == "" {
+		return os.ReadFile(filepath.Join(flags.DataDir, "thumbnails", cacheName(key)))
+	}
+	name := stdpath.Join(cachePath, cacheName(key))
+	// check first so that cache misses are not logged as errors
+	if _, err := fs.Get(ctx, name, &fs.GetArgs{NoLog: true}); err != nil {
+		return nil, err
+	}
+	link, _, err := fs.Link(ctx, name, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	rc, err := open(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func writeCache(ctx context.Context, key string, data []byte) error {
+	cachePath := setting.GetStr(conf.ThumbnailCachePath)
+	if cachePath == "" {
+		name := filepath.Join(flags.DataDir, "thumbnails", cacheName(key))
+		if err := os.MkdirAll(filepath.Dir(name), 0777); err != nil {
+			return err
+		}
+		return os.WriteFile(name, data, 0644)
+	}
+	name := stdpath.Join(cachePath, cacheName(key))
+	return fs.PutDirectly(fs.SkipUploadRules(ctx), stdpath.Dir(name), &model.FileStream{
+		Obj: &model.Object{
+			Name:     stdpath.Base(name),
+			Size:     int64(len(data)),
+			Modified: time.Now(),
+		},
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		Mimetype:   "image/jpeg",
+	}, true)
+}
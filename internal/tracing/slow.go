@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxSlowOperations is the number of the latest slow operations kept
+const maxSlowOperations = 200
+
+// SlowOperation is a call to a driver slower than the threshold
+type SlowOperation struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Driver    string    `json:"driver"`
+	MountPath string    `json:"mount_path"`
+	Path      string    `json:"path"`
+	// Duration is in milliseconds
+	Duration int64  `json:"duration"`
+	Error    string `json:"error,omitempty"`
+	// TraceID finds the trace of the operation in the collector
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+var (
+	slowThreshold atomic.Int64
+	slowMu        sync.Mutex
+	// slowOps is a ring of the latest slow operations, next is where the
+	// next one is put
+	slowOps  []SlowOperation
+	slowNext int
+)
+
+func init() {
+	slowThreshold.Store(int64(3 * time.Second))
+}
+
+// SetSlowThreshold sets the duration the calls slower than are reported, 0
+// disables reporting
+func SetSlowThreshold(d time.Duration) {
+	slowThreshold.Store(int64(d))
+}
+
+// StartDriver starts the span of the call to the method of the driver of
+// the storage mounted at mountPath, path is the one in the storage. The
+// returned func ends the span and reports the call if it's slow.
+func StartDriver(ctx context.Context, driver, mountPath, method, path string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := Start(ctx, "driver."+method,
+		attribute.String("alist.driver", driver),
+		attribute.String("alist.mount_path", mountPath),
+		Path(path),
+	)
+	return ctx, func(err error) {
+		d := time.Since(start)
+		if threshold := time.Duration(slowThreshold.Load()); threshold > 0 && d >= threshold {
+			op := SlowOperation{
+				Time:      start,
+				Method:    method,
+				Driver:    driver,
+				MountPath: mountPath,
+				Path:      path,
+				Duration:  d.Milliseconds(),
+			}
+			if err != nil {
+				op.Error = err.Error()
+			}
+			if sc := trace.SpanContextFromContext(ctx); sc.IsSampled() {
+				op.TraceID = sc.TraceID().String()
+			}
+			addSlowOperation(op)
+		}
+		End(span, err)
+	}
+}
+
+func addSlowOperation(op SlowOperation) {
+	slowMu.Lock()
+	defer slowMu.Unlock()
+	if len(slowOps) < maxSlowOperations {
+		slowOps = append(slowOps, op)
+		return
+	}
+	slowOps[slowNext] = op
+	slowNext = (slowNext + 1) % maxSlowOperations
+}
+
+// SlowOperations returns the latest slow operations, the latest first
+func SlowOperations() []SlowOperation {
+	slowMu.Lock()
+	defer slowMu.Unlock()
+	res := make([]SlowOperation, 0, len(slowOps))
+	for i := len(slowOps) - 1; i >= 0; i-- {
+		res = append(res, slowOps[(slowNext+i)%len(slowOps)])
+	}
+	return res
+}
+
+func ClearSlowOperations() {
+	slowMu.Lock()
+	defer slowMu.Unlock()
+	slowOps, slowNext = nil, 0
+}
@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSlowOperations(t *testing.T) {
+	defer ClearSlowOperations()
+	SetSlowThreshold(time.Nanosecond)
+	defer SetSlowThreshold(3 * time.Second)
+	for i := 0; i < maxSlowOperations+5; i++ {
+		_, end := StartDriver(context.Background(), "Local", "/local", "list", fmt.Sprintf("/%d", i))
+		time.Sleep(time.Microsecond)
+		end(nil)
+	}
+	_, end := StartDriver(context.Background(), "S3", "/s3", "link", "/a")
+	end(errors.New("timeout"))
+	ops := SlowOperations()
+	if len(ops) != maxSlowOperations {
+		t.Fatalf("expect the latest %d operations are kept, got %d", maxSlowOperations, len(ops))
+	}
+	if ops[0].Driver != "S3" || ops[0].Error != "timeout" || ops[1].Path != fmt.Sprintf("/%d", maxSlowOperations+4) {
+		t.Errorf("expect the latest first, got %+v, %+v", ops[0], ops[1])
+	}
+	if last := ops[len(ops)-1].Path; last != "/6" {
+		t.Errorf("expect the oldest ones are dropped, got %s", last)
+	}
+
+	SetSlowThreshold(time.Hour)
+	_, end = StartDriver(context.Background(), "S3", "/s3", "list", "/")
+	end(nil)
+	if len(SlowOperations()) != maxSlowOperations || SlowOperations()[0].Method != "link" {
+		t.Errorf("expect the fast operations aren't reported")
+	}
+}
@@ -0,0 +1,101 @@
+// Package tracing makes the opentelemetry spans of the requests, the fs
+// operations, the calls to the drivers and the proxied streams, and reports
+// the calls to the drivers slower than the threshold, so that the storage
+// making the requests slow can be found.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanKey is the key of the span of the request in the gin context, whose
+// values of other keys than strings are not looked up in the request
+const SpanKey = "span"
+
+var (
+	tracer   = otel.Tracer("github.com/alist-org/alist/v3")
+	provider *sdktrace.TracerProvider
+	enabled  bool
+)
+
+// Init starts exporting the spans to the collector of c
+func Init(c conf.Tracing) error {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Endpoint)}
+	if c.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", c.ServiceName),
+			attribute.String("service.version", conf.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	enabled = true
+	return nil
+}
+
+// Enabled reports whether the spans are exported
+func Enabled() bool {
+	return enabled
+}
+
+// Shutdown exports the spans left and stops exporting
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Extract returns ctx with the parent span propagated by the headers of a
+// request
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// Start starts the span of name as the child of the span in ctx, or of the
+// span of the request in the gin context
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !enabled {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if s, ok := ctx.Value(SpanKey).(trace.Span); ok {
+			ctx = trace.ContextWithSpan(ctx, s)
+		}
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends the span with the error of the operation
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Path is the attribute of the path the operation is on
+func Path(path string) attribute.KeyValue {
+	return attribute.String("alist.path", path)
+}
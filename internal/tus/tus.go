@@ -0,0 +1,306 @@
+// Package tus keeps the state of resumable uploads of the tus protocol,
+// see https://tus.io/protocols/resumable-upload
+package tus
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/pkg/generic_sync"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const Version = "1.0.0"
+
+// Expiration is how long an upload is kept since the last received chunk
+var Expiration = 24 * time.Hour
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+	ErrLocked         = errors.New("upload is in use")
+)
+
+var uploads generic_sync.MapOf[string, *Upload]
+
+// Upload is a resumable upload, the received data is stored in the data
+// dir until the whole file arrives, with the fields saved next to it so
+// that the upload is resumed after a restart
+type Upload struct {
+	ID     string `json:"id"`
+	UserID uint   `json:"user_id"`
+	// Path is the path of the file in the virtual filesystem
+	Path     string `json:"path"`
+	Mimetype string `json:"mimetype"`
+	AsTask   bool   `json:"as_task"`
+	Length   int64  `json:"length"`
+	Metadata string `json:"metadata"`
+
+	// mu is held while receiving a chunk
+	mu sync.Mutex
+	// stateMu guards offset and expires, so that they can be queried
+	// while a chunk is being received
+	stateMu sync.Mutex
+	offset  int64
+	expires time.Time
+	// finished means the file has been handed over to the storage
+	finished bool
+}
+
+// dir is in the data dir, as the temp dir is cleared on startup
+func dir() string {
+	return filepath.Join(flags.DataDir, "tus")
+}
+
+func dataPath(id string) string {
+	return filepath.Join(dir(), id)
+}
+
+func infoPath(id string) string {
+	return dataPath(id) + ".json"
+}
+
+// Init loads the uploads saved by the last run and removes the expired ones
+// and the files of no upload, then removes the expired uploads hourly
+func Init() {
+	load()
+	go cleanup()
+}
+
+func load() {
+	entries, err := os.ReadDir(dir())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("failed to read tus uploads: %+v", err)
+		}
+		return
+	}
+	now := time.Now()
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			if !utils.Exists(infoPath(e.Name())) {
+				log.Debugf("remove file of no tus upload %s", e.Name())
+				_ = os.Remove(dataPath(e.Name()))
+			}
+			continue
+		}
+		u, err := loadUpload(id)
+		if err != nil || u.expires.Before(now) {
+			log.Debugf("remove expired or broken tus upload %s: %v", id, err)
+			Remove(id)
+			continue
+		}
+		uploads.Store(id, u)
+	}
+}
+
+// loadUpload reads the fields of the upload, the offset and the time of the
+// last chunk are the ones of the data file
+func loadUpload(id string) (*Upload, error) {
+	data, err := os.ReadFile(infoPath(id))
+	if err != nil {
+		return nil, err
+	}
+	u := &Upload{}
+	if err = utils.Json.Unmarshal(data, u); err != nil {
+		return nil, err
+	}
+	if u.ID != id {
+		return nil, errors.New("the id doesn't match")
+	}
+	info, err := os.Stat(dataPath(id))
+	if err != nil {
+		return nil, err
+	}
+	u.offset = info.Size()
+	if u.offset > u.Length {
+		return nil, errors.New("the data is longer than the upload")
+	}
+	u.expires = info.ModTime().Add(Expiration)
+	return u, nil
+}
+
+// Create allocates the id and the data file of the upload
+func Create(u *Upload) error {
+	u.ID = uuid.NewString()
+	if err := os.MkdirAll(dir(), 0777); err != nil {
+		return err
+	}
+	f, err := os.Create(dataPath(u.ID))
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	data, err := utils.Json.Marshal(u)
+	if err == nil {
+		err = os.WriteFile(infoPath(u.ID), data, 0666)
+	}
+	if err != nil {
+		_ = os.Remove(dataPath(u.ID))
+		return err
+	}
+	u.expires = time.Now().Add(Expiration)
+	uploads.Store(u.ID, u)
+	return nil
+}
+
+func Get(id string) (*Upload, error) {
+	u, ok := uploads.Load(id)
+	if !ok || u.Expires().Before(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// Remove terminates the upload and deletes the received data
+func Remove(id string) {
+	uploads.Delete(id)
+	for _, p := range []string{dataPath(id), infoPath(id)} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Warnf("failed to remove tus upload %s: %+v", id, err)
+		}
+	}
+}
+
+func (u *Upload) Offset() int64 {
+	u.stateMu.Lock()
+	defer u.stateMu.Unlock()
+	return u.offset
+}
+
+func (u *Upload) Expires() time.Time {
+	u.stateMu.Lock()
+	defer u.stateMu.Unlock()
+	return u.expires
+}
+
+// Lock locks the upload for a chunk, so that the chunks of an upload
+// arrive one by one
+func (u *Upload) Lock() error {
+	if !u.mu.TryLock() {
+		return ErrLocked
+	}
+	return nil
+}
+
+func (u *Upload) Unlock() {
+	u.mu.Unlock()
+}
+
+// Write appends the chunk starting at offset, the upload must be locked.
+// Bytes beyond the length are not read.
+func (u *Upload) Write(offset int64, r io.Reader) (int64, error) {
+	if offset != u.offset {
+		return 0, ErrOffsetMismatch
+	}
+	f, err := os.OpenFile(dataPath(u.ID), os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	// keep what has been written even if the connection breaks, that's
+	// where the client resumes from
+	n, err := io.Copy(f, io.LimitReader(r, u.Length-u.offset))
+	u.stateMu.Lock()
+	u.offset += n
+	u.expires = time.Now().Add(Expiration)
+	u.stateMu.Unlock()
+	return n, err
+}
+
+// Complete reports whether all bytes are received, the upload must be locked
+func (u *Upload) Complete() bool {
+	return u.offset == u.Length
+}
+
+// Finished reports whether the file has been handed over, the upload must
+// be locked
+func (u *Upload) Finished() bool {
+	return u.finished
+}
+
+// Finish marks the file handed over to the storage, the upload must be locked
+func (u *Upload) Finish() {
+	u.finished = true
+}
+
+// Open opens the received file, if remove is set the upload is removed
+// once the file is closed. The file is wrapped so that op.Put does not
+// take it as a temp file and remove it.
+func (u *Upload) Open(remove bool) (io.ReadCloser, error) {
+	f, err := os.Open(dataPath(u.ID))
+	if err != nil {
+		return nil, err
+	}
+	return &file{File: f, id: u.ID, remove: remove}, nil
+}
+
+type file struct {
+	*os.File
+	id     string
+	remove bool
+	once   sync.Once
+}
+
+func (f *file) Close() error {
+	err := f.File.Close()
+	if f.remove {
+		f.once.Do(func() {
+			Remove(f.id)
+		})
+	}
+	return err
+}
+
+// ParseMetadata parses the Upload-Metadata header, which is a comma
+// separated list of keys and base64 encoded values
+func ParseMetadata(s string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		meta[key] = string(v)
+	}
+	return meta
+}
+
+// cleanup removes the expired uploads periodically
+func cleanup() {
+	for range time.Tick(time.Hour) {
+		removeExpired(time.Now())
+	}
+}
+
+// removeExpired removes the uploads expired before now. The uploads
+// receiving a chunk are skipped, as are the finished ones whose file is
+// read by a task, which removes them after.
+func removeExpired(now time.Time) {
+	uploads.Range(func(id string, u *Upload) bool {
+		if !u.Expires().Before(now) || !u.mu.TryLock() {
+			return true
+		}
+		defer u.mu.Unlock()
+		if u.finished && u.AsTask {
+			return true
+		}
+		log.Debugf("remove expired tus upload %s", id)
+		Remove(id)
+		return true
+	})
+}
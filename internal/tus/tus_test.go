@@ -0,0 +1,121 @@
+package tus
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+)
+
+func TestUpload(t *testing.T) {
+	flags.DataDir = t.TempDir()
+	u := &Upload{Length: 10}
+	if err := Create(u); err != nil {
+		t.Fatalf("failed to create upload: %+v", err)
+	}
+	if err := u.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Lock(); !errors.Is(err, ErrLocked) {
+		t.Errorf("expect locked, got %v", err)
+	}
+	if _, err := u.Write(0, bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Write(3, bytes.NewReader([]byte("lo"))); !errors.Is(err, ErrOffsetMismatch) {
+		t.Errorf("expect offset mismatch, got %v", err)
+	}
+	// bytes beyond the length are ignored
+	if _, err := u.Write(5, bytes.NewReader([]byte(" world!"))); err != nil {
+		t.Fatal(err)
+	}
+	if !u.Complete() || u.Offset() != 10 {
+		t.Fatalf("expect complete, offset is %d", u.Offset())
+	}
+	u.Unlock()
+
+	rc, err := u.Open(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	if string(data) != "hello worl" {
+		t.Errorf("unexpected data: %q", data)
+	}
+	_ = rc.Close()
+	if _, err = Get(u.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expect removed after close, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	flags.DataDir = t.TempDir()
+	u := &Upload{UserID: 1, Path: "/a.txt", Length: 10}
+	if err := Create(u); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Write(0, bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatal(err)
+	}
+	expired := &Upload{Length: 10}
+	if err := Create(expired); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-Expiration - time.Minute)
+	if err := os.Chtimes(dataPath(expired.ID), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dataPath("orphan"), []byte("x"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart
+	uploads.Range(func(id string, _ *Upload) bool {
+		uploads.Delete(id)
+		return true
+	})
+	load()
+	got, err := Get(u.ID)
+	if err != nil {
+		t.Fatalf("expect the upload is resumed: %+v", err)
+	}
+	if got.Offset() != 5 || got.Path != "/a.txt" || got.UserID != 1 || got.Length != 10 {
+		t.Errorf("unexpected upload: %+v", got)
+	}
+	if _, err := Get(expired.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expect the expired upload is removed, got %v", err)
+	}
+	for _, p := range []string{dataPath(expired.ID), infoPath(expired.ID), dataPath("orphan")} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("expect %s is removed, got %v", p, err)
+		}
+	}
+
+	// the upload receiving a chunk is kept
+	if err := got.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	removeExpired(time.Now().Add(2 * Expiration))
+	if _, ok := uploads.Load(u.ID); !ok {
+		t.Errorf("expect the locked upload is kept")
+	}
+	got.Unlock()
+	removeExpired(time.Now().Add(2 * Expiration))
+	if _, ok := uploads.Load(u.ID); ok {
+		t.Errorf("expect the expired upload is removed")
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	meta := ParseMetadata("filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==,is_confidential,filetype dGV4dC9wbGFpbg==")
+	if meta["filename"] != "world_domination_plan.pdf" || meta["filetype"] != "text/plain" {
+		t.Errorf("unexpected metadata: %v", meta)
+	}
+	if _, ok := meta["is_confidential"]; !ok {
+		t.Errorf("expect key without value")
+	}
+}
@@ -0,0 +1,115 @@
+// Package uploadrule runs the actions of the upload rules after the uploads
+// they are applied to, the uploads are routed by the rules in the fs
+// package.
+package uploadrule
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/thumb"
+	"github.com/alist-org/alist/v3/internal/webhook"
+	log "github.com/sirupsen/logrus"
+)
+
+const workers = 2
+
+var (
+	queue    = make(chan event.Event, 256)
+	initOnce sync.Once
+)
+
+// Init subscribes the uploads and starts the workers running the actions
+func Init() {
+	initOnce.Do(func() {
+		event.Subscribe(handle)
+		for i := 0; i < workers; i++ {
+			go work()
+		}
+	})
+}
+
+func handle(e event.Event) {
+	if e.Type != event.FileUpload {
+		return
+	}
+	if _, ok := e.Data["rule"]; !ok {
+		return
+	}
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("[upload rule] queue is full, drop actions of %v", e.Data["path"])
+	}
+}
+
+func work() {
+	for e := range queue {
+		run(e)
+	}
+}
+
+func run(e event.Event) {
+	id, _ := e.Data["rule"].(uint)
+	path, _ := e.Data["path"].(string)
+	rule, err := op.GetUploadRuleById(id)
+	if err != nil {
+		log.Errorf("[upload rule] failed get rule %d of %s: %+v", id, path, err)
+		return
+	}
+	ctx, err := userCtx(e)
+	if err != nil {
+		log.Errorf("[upload rule] failed get uploader of %s: %+v", path, err)
+		return
+	}
+	for _, err := range runActions(ctx, rule, e, path) {
+		log.Errorf("[upload rule] failed run action of rule %s on %s: %+v", rule.Name, path, err)
+	}
+}
+
+// runActions runs the actions of the rule on the file at path, the errors
+// of the actions are returned
+func runActions(ctx context.Context, rule *model.UploadRule, e event.Event, path string) []error {
+	var errs []error
+	if rule.Thumbnail && thumb.Supported(path) {
+		if _, err := thumb.Get(ctx, path); err != nil {
+			errs = append(errs, fmt.Errorf("thumbnail: %w", err))
+		}
+	}
+	if rule.CopyTo != "" {
+		if _, err := fs.Copy(ctx, path, rule.CopyTo); err != nil {
+			errs = append(errs, fmt.Errorf("copy to %s: %w", rule.CopyTo, err))
+		}
+	}
+	if rule.WebhookID != 0 {
+		w, err := op.GetWebhookById(rule.WebhookID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		} else if !w.Accept(e.Type) {
+			// the webhooks accepting the uploads have got it already
+			webhook.Deliver(*w, e)
+		}
+	}
+	return errs
+}
+
+// userCtx returns the context of the uploader, or of the admin if the
+// upload has no uploader
+func userCtx(e event.Event) (context.Context, error) {
+	var user *model.User
+	var err error
+	if name, ok := e.Data["user"].(string); ok && name != "" {
+		user, err = op.GetUserByName(name)
+	} else {
+		user, err = op.GetAdmin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(context.Background(), "user", user), nil
+}
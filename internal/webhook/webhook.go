@@ -0,0 +1,142 @@
+// Package webhook posts the events to the webhooks configured by admin.
+//
+// The body is the json of the event, and if the webhook has a secret, the
+// X-Alist-Signature header is "sha256=" followed by the hex of the
+// hmac-sha256 of the body with the secret, so that the receiver can verify
+// the request is from alist. A failed delivery is retried with backoff.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	workers = 2
+	// maxAttempts is the max times to post an event to a webhook
+	maxAttempts = 4
+)
+
+var (
+	// retryBackoff is the wait before the first retry, doubled after each
+	retryBackoff = 5 * time.Second
+	client       = &http.Client{Timeout: 15 * time.Second}
+	queue        = make(chan delivery, 256)
+	initOnce     sync.Once
+)
+
+type delivery struct {
+	webhook model.Webhook
+	event   event.Event
+	id      string
+	attempt int
+}
+
+// Init subscribes the events and starts the workers that post them
+func Init() {
+	initOnce.Do(func() {
+		event.Subscribe(handle)
+		for i := 0; i < workers; i++ {
+			go work()
+		}
+	})
+}
+
+func handle(e event.Event) {
+	webhooks, err := op.GetEnabledWebhooks()
+	if err != nil {
+		log.Errorf("[webhook] failed get webhooks: %+v", err)
+		return
+	}
+	for _, w := range webhooks {
+		if w.Accept(e.Type) {
+			enqueue(delivery{webhook: w, event: e, id: uuid.NewString(), attempt: 1})
+		}
+	}
+}
+
+// Deliver posts the event to the webhook in the background with the
+// retries, whatever events the webhook accepts
+func Deliver(w model.Webhook, e event.Event) {
+	enqueue(delivery{webhook: w, event: e, id: uuid.NewString(), attempt: 1})
+}
+
+func enqueue(d delivery) {
+	select {
+	case queue <- d:
+	default:
+		log.Warnf("[webhook] queue is full, drop event %s to %s", d.event.Type, d.webhook.URL)
+	}
+}
+
+func work() {
+	for d := range queue {
+		err := Send(context.Background(), d.webhook, d.event, d.id)
+		if err == nil {
+			continue
+		}
+		if d.attempt >= maxAttempts {
+			log.Errorf("[webhook] failed to post event %s to %s after %d attempts: %+v", d.event.Type, d.webhook.URL, d.attempt, err)
+			continue
+		}
+		backoff := retryBackoff << (d.attempt - 1)
+		log.Warnf("[webhook] failed to post event %s to %s, retry after %s: %+v", d.event.Type, d.webhook.URL, backoff, err)
+		d.attempt++
+		next := d
+		time.AfterFunc(backoff, func() { enqueue(next) })
+	}
+}
+
+// Sign returns the signature of the body with the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts the event to the webhook once, id identifies the delivery
+// and is the same in the retries
+func Send(ctx context.Context, w model.Webhook, e event.Event, id string) error {
+	body, err := utils.Json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "alist/"+conf.Version)
+	req.Header.Set("X-Alist-Event", e.Type)
+	req.Header.Set("X-Alist-Delivery", id)
+	if w.Secret != "" {
+		req.Header.Set("X-Alist-Signature", Sign(w.Secret, body))
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", res.Status, msg)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
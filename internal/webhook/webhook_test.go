@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/event"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestSend(t *testing.T) {
+	var body []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer srv.Close()
+	w := model.Webhook{URL: srv.URL, Secret: "secret"}
+	e := event.Event{Type: event.FileUpload, Time: time.Now(), Data: map[string]any{"path": "/a.txt"}}
+	if err := Send(context.Background(), w, e, "id"); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("X-Alist-Event") != event.FileUpload || header.Get("X-Alist-Delivery") != "id" {
+		t.Errorf("unexpected headers: %v", header)
+	}
+	if got, expect := header.Get("X-Alist-Signature"), Sign("secret", body); got != expect {
+		t.Errorf("expect signature %s, got %s", expect, got)
+	}
+}
+
+func TestSend_Status(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	e := event.Event{Type: event.FileDelete, Time: time.Now()}
+	if err := Send(context.Background(), model.Webhook{URL: srv.URL}, e, "id"); err == nil {
+		t.Error("expect error of status 500")
+	}
+}
+
+func TestWebhook_Accept(t *testing.T) {
+	tests := []struct {
+		webhook model.Webhook
+		typ     string
+		expect  bool
+	}{
+		{model.Webhook{}, event.TaskFailed, true},
+		{model.Webhook{Events: "file.upload, file.delete"}, event.FileDelete, true},
+		{model.Webhook{Events: "file.upload"}, event.FileDelete, false},
+		{model.Webhook{Disabled: true}, event.FileUpload, false},
+	}
+	for _, tt := range tests {
+		if got := tt.webhook.Accept(tt.typ); got != tt.expect {
+			t.Errorf("%+v accept %s: expect %v, got %v", tt.webhook, tt.typ, tt.expect, got)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package gowebdav
+
+import (
+	"net/http"
+)
+
+// BearerAuth structure holds our token
+type BearerAuth struct {
+	token string
+}
+
+// Type identifies the BearerAuthenticator
+func (b *BearerAuth) Type() string {
+	return "BearerAuth"
+}
+
+// User holds the BearerAuth username
+func (b *BearerAuth) User() string {
+	return ""
+}
+
+// Pass holds the BearerAuth token
+func (b *BearerAuth) Pass() string {
+	return b.token
+}
+
+// Authorize the current request
+func (b *BearerAuth) Authorize(req *http.Request, method string, path string) {
+	req.Header.Set("Authorization", "Bearer "+b.token)
+}
@@ -63,6 +63,11 @@ func NewClient(uri, user, pw string) *Client {
 	return &Client{FixSlash(uri), make(http.Header), nil, &http.Client{}, sync.Mutex{}, &NoAuth{user, pw}}
 }
 
+// NewTokenClient creates a new instance of client authorized by a bearer token
+func NewTokenClient(uri, token string) *Client {
+	return &Client{FixSlash(uri), make(http.Header), nil, &http.Client{}, sync.Mutex{}, &BearerAuth{token}}
+}
+
 // SetHeader lets us set arbitrary headers for a given client
 func (c *Client) SetHeader(key, value string) {
 	c.headers.Add(key, value)
@@ -369,6 +374,12 @@ func (c *Client) Link(path string) (string, http.Header, error) {
 	return r.URL.String(), r.Header, nil
 }
 
+// Do sends a request with an arbitrary method to the given path,
+// the caller is responsible for closing the response body
+func (c *Client) Do(method, path string, body io.Reader, intercept func(rq *http.Request)) (*http.Response, error) {
+	return c.req(method, path, body, intercept)
+}
+
 // ReadStream reads the stream for a given path
 func (c *Client) ReadStream(path string, callback func(rq *http.Request)) (io.ReadCloser, http.Header, error) {
 	rs, err := c.req("GET", path, nil, callback)
@@ -0,0 +1,162 @@
+package plugin
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// chunkSize is the size of the chunks of the file put
+const chunkSize = 1024 * 1024
+
+// Client calls the driver of the plugin on the address of the handshake
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// secretCreds sends the secret in the metadata of the calls
+type secretCreds string
+
+func (s secretCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{secretKey: string(s)}, nil
+}
+
+// RequireTransportSecurity is false as the plugin listens on a local address
+func (s secretCreds) RequireTransportSecurity() bool {
+	return false
+}
+
+// Dial connects to the plugin with the secret it's started with, the
+// connection is made lazily
+func Dial(network, address, secret string) (*Client, error) {
+	target := address
+	if network == "unix" {
+		target = "unix://" + address
+	}
+	conn, err := grpc.Dial(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(secretCreds(secret)),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{})))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) invoke(ctx context.Context, method string, req *request) (*response, error) {
+	resp := new(response)
+	if err := c.conn.Invoke(ctx, fullMethod(method), req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	resp, err := c.invoke(ctx, "Info", &request{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Info == nil {
+		resp.Info = &Info{}
+	}
+	return resp.Info, nil
+}
+
+// Init initializes the storage of instance, which is dropped first if it's
+// initialized already
+func (c *Client) Init(ctx context.Context, instance, addition string) error {
+	_, err := c.invoke(ctx, "Init", &request{Instance: instance, Addition: addition})
+	return err
+}
+
+func (c *Client) Drop(ctx context.Context, instance string) error {
+	_, err := c.invoke(ctx, "Drop", &request{Instance: instance})
+	return err
+}
+
+func (c *Client) List(ctx context.Context, instance string, dir Obj) ([]Obj, error) {
+	resp, err := c.invoke(ctx, "List", &request{Instance: instance, Obj: &dir})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Objs, nil
+}
+
+func (c *Client) Link(ctx context.Context, instance string, file Obj, args LinkArgs) (*Link, error) {
+	resp, err := c.invoke(ctx, "Link", &request{Instance: instance, Obj: &file, Args: &args})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Link == nil {
+		resp.Link = &Link{}
+	}
+	return resp.Link, nil
+}
+
+func (c *Client) MakeDir(ctx context.Context, instance string, parent Obj, name string) error {
+	_, err := c.invoke(ctx, "MakeDir", &request{Instance: instance, Obj: &parent, Name: name})
+	return err
+}
+
+func (c *Client) Move(ctx context.Context, instance string, src, dstDir Obj) error {
+	_, err := c.invoke(ctx, "Move", &request{Instance: instance, Obj: &src, Dst: &dstDir})
+	return err
+}
+
+func (c *Client) Rename(ctx context.Context, instance string, src Obj, name string) error {
+	_, err := c.invoke(ctx, "Rename", &request{Instance: instance, Obj: &src, Name: name})
+	return err
+}
+
+func (c *Client) Copy(ctx context.Context, instance string, src, dstDir Obj) error {
+	_, err := c.invoke(ctx, "Copy", &request{Instance: instance, Obj: &src, Dst: &dstDir})
+	return err
+}
+
+func (c *Client) Remove(ctx context.Context, instance string, obj Obj) error {
+	_, err := c.invoke(ctx, "Remove", &request{Instance: instance, Obj: &obj})
+	return err
+}
+
+// Put streams the file to dir in chunks, written is called with the bytes
+// sent after each chunk
+func (c *Client) Put(ctx context.Context, instance string, dir Obj, file FileStream, written func(n int64)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], fullMethod("Put"))
+	if err != nil {
+		return err
+	}
+	err = stream.SendMsg(&request{Instance: instance, Obj: &dir, Name: file.Name, Size: file.Size, Mimetype: file.Mimetype})
+	buf := make([]byte, chunkSize)
+	var n int64
+	for err == nil {
+		var m int
+		m, err = io.ReadFull(file, buf)
+		if m > 0 {
+			if sendErr := stream.SendMsg(&request{Data: buf[:m]}); sendErr != nil {
+				err = sendErr
+				break
+			}
+			n += int64(m)
+			if written != nil {
+				written(n)
+			}
+		}
+	}
+	// io.EOF of SendMsg means the plugin ended the stream, the error is
+	// returned by RecvMsg
+	if err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	return stream.RecvMsg(&response{})
+}
@@ -0,0 +1,131 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// memDriver keeps the files in the memory, it can't make dirs
+type memDriver struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (d *memDriver) Init(ctx context.Context, addition string) error {
+	d.files = map[string][]byte{}
+	return nil
+}
+
+func (d *memDriver) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *memDriver) List(ctx context.Context, dir Obj) ([]Obj, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var objs []Obj
+	for p, data := range d.files {
+		objs = append(objs, Obj{Path: p, Name: path.Base(p), Size: int64(len(data))})
+	}
+	return objs, nil
+}
+
+func (d *memDriver) Link(ctx context.Context, file Obj, args LinkArgs) (*Link, error) {
+	if _, ok := d.files[file.Path]; !ok {
+		return nil, ErrNotFound
+	}
+	return &Link{URL: "http://example.com" + file.Path}, nil
+}
+
+func (d *memDriver) Put(ctx context.Context, dir Obj, file FileStream) error {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.files[path.Join(dir.Path, file.Name)] = data
+	return nil
+}
+
+func TestPlugin(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = ServeListener(lis, "secret", Info{Config: Config{Name: "Memory"}}, func() Driver { return &memDriver{} })
+	}()
+	network, address, err := ParseHandshake(Handshake("tcp", lis.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	bad, err := Dial(network, address, "wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if _, err := bad.Info(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expect the call with a wrong secret is rejected, got %v", err)
+	}
+	c, err := Dial(network, address, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		t.Fatalf("failed get info: %+v", err)
+	}
+	if info.Config.Name != "Memory" || strings.Join(info.Methods, ",") != MethodPut {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if _, err := c.List(ctx, "a", Obj{Path: "/"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expect the storage isn't initialized, got %v", err)
+	}
+	if err := c.Init(ctx, "a", "{}"); err != nil {
+		t.Fatalf("failed init: %+v", err)
+	}
+
+	data := bytes.Repeat([]byte("x"), chunkSize+10)
+	var written int64
+	file := FileStream{Reader: bytes.NewReader(data), Name: "f.txt", Size: int64(len(data))}
+	if err := c.Put(ctx, "a", Obj{Path: "/"}, file, func(n int64) { written = n }); err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	if written != int64(len(data)) {
+		t.Errorf("expect all the bytes are written, got %d", written)
+	}
+	objs, err := c.List(ctx, "a", Obj{Path: "/"})
+	if err != nil || len(objs) != 1 || objs[0].Size != int64(len(data)) {
+		t.Errorf("expect the file put is listed, got %+v: %v", objs, err)
+	}
+	if link, err := c.Link(ctx, "a", objs[0], LinkArgs{}); err != nil || link.URL != "http://example.com/f.txt" {
+		t.Errorf("unexpected link %+v: %v", link, err)
+	}
+	if _, err := c.Link(ctx, "a", Obj{Path: "/none"}, LinkArgs{}); status.Code(err) != codes.NotFound {
+		t.Errorf("expect not found, got %v", err)
+	}
+	if err := c.MakeDir(ctx, "a", Obj{Path: "/"}, "d"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expect make dir isn't implemented, got %v", err)
+	}
+}
+
+func TestParseHandshake(t *testing.T) {
+	for _, line := range []string{"hello", "alist-plugin|0|tcp|127.0.0.1:1"} {
+		if _, _, err := ParseHandshake(line); err == nil {
+			t.Errorf("expect %q is rejected", line)
+		}
+	}
+}
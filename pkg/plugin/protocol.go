@@ -0,0 +1,217 @@
+// Package plugin is the protocol of the storage drivers shipped as separate
+// binaries, and the kit to write them.
+//
+// A plugin is an executable in the plugins dir of alist. alist starts it
+// with the ALIST_PLUGIN environment of the protocol version and the
+// ALIST_PLUGIN_SECRET environment of a random secret, the plugin listens
+// on a local address and prints the handshake line
+//
+//	alist-plugin|<version>|<network>|<address>
+//
+// to the stdout, then alist calls the driver through gRPC on the address
+// with the secret in the metadata of the calls, the calls without it are
+// rejected, so that other local users can't call the driver.
+// The plugin should exit once its stdin is closed, which Serve does.
+//
+// A plugin only needs to implement Driver and pass it to Serve:
+//
+//	func main() {
+//		plugin.Serve(plugin.Info{Config: plugin.Config{Name: "Foo"}}, func() plugin.Driver {
+//			return &Foo{}
+//		})
+//	}
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+const (
+	// ProtocolVersion changes whenever the protocol breaks
+	ProtocolVersion = "2"
+	// MagicEnv is set to the protocol version when alist starts the plugin
+	MagicEnv = "ALIST_PLUGIN"
+	// SecretEnv is set to the secret of the calls when alist starts the
+	// plugin, which is made for each launch
+	SecretEnv = "ALIST_PLUGIN_SECRET"
+
+	handshakePrefix = "alist-plugin"
+	secretKey       = "alist-plugin-secret"
+	serviceName     = "alist.plugin.Driver"
+)
+
+// the names of the optional methods implemented by the driver
+const (
+	MethodMakeDir = "make_dir"
+	MethodMove    = "move"
+	MethodRename  = "rename"
+	MethodCopy    = "copy"
+	MethodRemove  = "remove"
+	MethodPut     = "put"
+)
+
+// Item is an item of the storage form, as the field tags of the additions
+// of the builtin drivers
+type Item struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Default  string `json:"default"`
+	Options  string `json:"options"`
+	Required bool   `json:"required"`
+	Help     string `json:"help"`
+}
+
+// Config is the config of the driver, the name must be unique among the
+// drivers
+type Config struct {
+	Name        string `json:"name"`
+	LocalSort   bool   `json:"local_sort"`
+	OnlyProxy   bool   `json:"only_proxy"`
+	NoCache     bool   `json:"no_cache"`
+	NoUpload    bool   `json:"no_upload"`
+	DefaultRoot string `json:"default_root"`
+	Alert       string `json:"alert"`
+}
+
+// Info describes the driver of the plugin
+type Info struct {
+	Config     Config `json:"config"`
+	Additional []Item `json:"additional"`
+	// Methods are the optional methods implemented, which are filled by
+	// Serve
+	Methods []string `json:"methods"`
+}
+
+// Obj is a file or a folder, the root is the folder of the path /. Either
+// ID or Path identifies the object, as the driver sets them in List.
+type Obj struct {
+	ID       string            `json:"id"`
+	Path     string            `json:"path"`
+	Name     string            `json:"name"`
+	Size     int64             `json:"size"`
+	Modified time.Time         `json:"modified"`
+	IsFolder bool              `json:"is_folder"`
+	Hashes   map[string]string `json:"hashes,omitempty"`
+}
+
+// Link is where the file is downloaded from, with the headers needed
+type Link struct {
+	URL        string        `json:"url"`
+	Header     http.Header   `json:"header,omitempty"`
+	Expiration time.Duration `json:"expiration,omitempty"`
+}
+
+// LinkArgs are the ip and the headers of the request of the link
+type LinkArgs struct {
+	IP     string      `json:"ip"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+// request is the message of all the calls, the instance is the storage the
+// call is made to
+type request struct {
+	Instance string    `json:"instance,omitempty"`
+	Addition string    `json:"addition,omitempty"`
+	Obj      *Obj      `json:"obj,omitempty"`
+	Dst      *Obj      `json:"dst,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	Args     *LinkArgs `json:"args,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Mimetype string    `json:"mimetype,omitempty"`
+	// Data is a chunk of the file put
+	Data []byte `json:"data,omitempty"`
+}
+
+type response struct {
+	Info *Info `json:"info,omitempty"`
+	Objs []Obj `json:"objs,omitempty"`
+	Link *Link `json:"link,omitempty"`
+}
+
+// codec encodes the messages in json, so that the protocol needs no
+// generated code
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return "json"
+}
+
+var _ encoding.Codec = codec{}
+
+// handler is implemented by the server of the service
+type handler interface {
+	call(ctx context.Context, method string, req *request) (*response, error)
+	put(stream grpc.ServerStream) error
+}
+
+func unary(method string) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(request)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return srv.(handler).call(ctx, method, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(method)}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return srv.(handler).call(ctx, method, req.(*request))
+			})
+		},
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*handler)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("Info"), unary("Init"), unary("Drop"), unary("List"), unary("Link"),
+		unary("MakeDir"), unary("Move"), unary("Rename"), unary("Copy"), unary("Remove"),
+	},
+	Streams: []grpc.StreamDesc{{
+		StreamName: "Put",
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			return srv.(handler).put(stream)
+		},
+		ClientStreams: true,
+	}},
+}
+
+func fullMethod(method string) string {
+	return "/" + serviceName + "/" + method
+}
+
+// Handshake returns the handshake line of the address
+func Handshake(network, address string) string {
+	return strings.Join([]string{handshakePrefix, ProtocolVersion, network, address}, "|")
+}
+
+// ParseHandshake returns the network and the address of the handshake line
+func ParseHandshake(line string) (string, string, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 4 || parts[0] != handshakePrefix {
+		return "", "", fmt.Errorf("invalid handshake: %q", line)
+	}
+	if parts[1] != ProtocolVersion {
+		return "", "", fmt.Errorf("the protocol version %s of the plugin isn't supported, expect %s", parts[1], ProtocolVersion)
+	}
+	return parts[2], parts[3], nil
+}
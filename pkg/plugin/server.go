@@ -0,0 +1,305 @@
+package plugin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotFound is returned by the driver if the object is not found
+var ErrNotFound = errors.New("object not found")
+
+// Driver is the storage driver of the plugin, a new one is made for each
+// storage using the driver
+type Driver interface {
+	// Init initializes the storage with the json of its additional items
+	Init(ctx context.Context, addition string) error
+	Drop(ctx context.Context) error
+	List(ctx context.Context, dir Obj) ([]Obj, error)
+	Link(ctx context.Context, file Obj, args LinkArgs) (*Link, error)
+}
+
+type MakeDirer interface {
+	MakeDir(ctx context.Context, parent Obj, name string) error
+}
+
+type Mover interface {
+	Move(ctx context.Context, src, dstDir Obj) error
+}
+
+type Renamer interface {
+	Rename(ctx context.Context, src Obj, name string) error
+}
+
+type Copier interface {
+	Copy(ctx context.Context, src, dstDir Obj) error
+}
+
+type Remover interface {
+	Remove(ctx context.Context, obj Obj) error
+}
+
+type Putter interface {
+	Put(ctx context.Context, dir Obj, file FileStream) error
+}
+
+// FileStream is the content of the file put
+type FileStream struct {
+	io.Reader
+	Name     string
+	Size     int64
+	Mimetype string
+}
+
+// Serve serves the driver made by newDriver as the plugin, it exits once
+// alist closes the stdin
+func Serve(info Info, newDriver func() Driver) {
+	if os.Getenv(MagicEnv) != ProtocolVersion {
+		fmt.Fprintln(os.Stderr, "this is a plugin of alist, put it in the plugins dir of alist instead of running it")
+		os.Exit(1)
+	}
+	secret := os.Getenv(SecretEnv)
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "the secret of the calls isn't set")
+		os.Exit(1)
+	}
+	// the processes started by the driver don't need it
+	_ = os.Unsetenv(SecretEnv)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed listen: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(Handshake("tcp", lis.Addr().String()))
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		os.Exit(0)
+	}()
+	if err := ServeListener(lis, secret, info, newDriver); err != nil {
+		fmt.Fprintf(os.Stderr, "failed serve: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ServeListener serves the driver on lis to the calls with the secret, it's
+// used by Serve and the tests
+func ServeListener(lis net.Listener, secret string, info Info, newDriver func() Driver) error {
+	if secret == "" {
+		return errors.New("the secret is empty")
+	}
+	info.Methods = methodsOf(newDriver())
+	s := grpc.NewServer(grpc.ForceServerCodec(codec{}),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := checkSecret(ctx, secret); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkSecret(ss.Context(), secret); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}))
+	s.RegisterService(&serviceDesc, &server{info: info, newDriver: newDriver, drivers: make(map[string]Driver)})
+	return s.Serve(lis)
+}
+
+func checkSecret(ctx context.Context, secret string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(secretKey) {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(secret)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid secret")
+}
+
+func methodsOf(d Driver) []string {
+	var methods []string
+	if _, ok := d.(MakeDirer); ok {
+		methods = append(methods, MethodMakeDir)
+	}
+	if _, ok := d.(Mover); ok {
+		methods = append(methods, MethodMove)
+	}
+	if _, ok := d.(Renamer); ok {
+		methods = append(methods, MethodRename)
+	}
+	if _, ok := d.(Copier); ok {
+		methods = append(methods, MethodCopy)
+	}
+	if _, ok := d.(Remover); ok {
+		methods = append(methods, MethodRemove)
+	}
+	if _, ok := d.(Putter); ok {
+		methods = append(methods, MethodPut)
+	}
+	return methods
+}
+
+type server struct {
+	info      Info
+	newDriver func() Driver
+
+	mu      sync.Mutex
+	drivers map[string]Driver
+}
+
+func (s *server) driver(instance string) (Driver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.drivers[instance]
+	if !ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "storage %s isn't initialized", instance)
+	}
+	return d, nil
+}
+
+func (s *server) init(ctx context.Context, req *request) error {
+	s.mu.Lock()
+	old := s.drivers[req.Instance]
+	delete(s.drivers, req.Instance)
+	s.mu.Unlock()
+	if old != nil {
+		_ = old.Drop(ctx)
+	}
+	d := s.newDriver()
+	if err := d.Init(ctx, req.Addition); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.drivers[req.Instance] = d
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *server) drop(ctx context.Context, instance string) error {
+	s.mu.Lock()
+	d := s.drivers[instance]
+	delete(s.drivers, instance)
+	s.mu.Unlock()
+	if d == nil {
+		return nil
+	}
+	return d.Drop(ctx)
+}
+
+func (s *server) call(ctx context.Context, method string, req *request) (*response, error) {
+	switch method {
+	case "Info":
+		return &response{Info: &s.info}, nil
+	case "Init":
+		return &response{}, toStatus(s.init(ctx, req))
+	case "Drop":
+		return &response{}, toStatus(s.drop(ctx, req.Instance))
+	}
+	d, err := s.driver(req.Instance)
+	if err != nil {
+		return nil, err
+	}
+	if req.Obj == nil {
+		return nil, status.Error(codes.InvalidArgument, "no object")
+	}
+	unimplemented := status.Errorf(codes.Unimplemented, "%s isn't implemented", method)
+	switch method {
+	case "List":
+		objs, err := d.List(ctx, *req.Obj)
+		return &response{Objs: objs}, toStatus(err)
+	case "Link":
+		var args LinkArgs
+		if req.Args != nil {
+			args = *req.Args
+		}
+		link, err := d.Link(ctx, *req.Obj, args)
+		return &response{Link: link}, toStatus(err)
+	case "MakeDir":
+		if m, ok := d.(MakeDirer); ok {
+			return &response{}, toStatus(m.MakeDir(ctx, *req.Obj, req.Name))
+		}
+	case "Rename":
+		if r, ok := d.(Renamer); ok {
+			return &response{}, toStatus(r.Rename(ctx, *req.Obj, req.Name))
+		}
+	case "Remove":
+		if r, ok := d.(Remover); ok {
+			return &response{}, toStatus(r.Remove(ctx, *req.Obj))
+		}
+	case "Move", "Copy":
+		if req.Dst == nil {
+			return nil, status.Error(codes.InvalidArgument, "no destination")
+		}
+		if m, ok := d.(Mover); ok && method == "Move" {
+			return &response{}, toStatus(m.Move(ctx, *req.Obj, *req.Dst))
+		}
+		if c, ok := d.(Copier); ok && method == "Copy" {
+			return &response{}, toStatus(c.Copy(ctx, *req.Obj, *req.Dst))
+		}
+	}
+	return nil, unimplemented
+}
+
+// put receives the header of the file, then the chunks of it until the
+// client closes the stream
+func (s *server) put(stream grpc.ServerStream) error {
+	var req request
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	d, err := s.driver(req.Instance)
+	if err != nil {
+		return err
+	}
+	p, ok := d.(Putter)
+	if !ok {
+		return status.Error(codes.Unimplemented, "Put isn't implemented")
+	}
+	if req.Obj == nil {
+		return status.Error(codes.InvalidArgument, "no object")
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			var chunk request
+			if err := stream.RecvMsg(&chunk); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				_ = pw.CloseWithError(err)
+				return
+			}
+			if _, err := pw.Write(chunk.Data); err != nil {
+				return
+			}
+		}
+	}()
+	err = p.Put(stream.Context(), *req.Obj, FileStream{Reader: pr, Name: req.Name, Size: req.Size, Mimetype: req.Mimetype})
+	_ = pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil {
+		return toStatus(err)
+	}
+	return stream.SendMsg(&response{})
+}
+
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
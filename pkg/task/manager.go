@@ -12,6 +12,21 @@ type Manager[K comparable] struct {
 	workerC  chan struct{}
 	updateID func(*K)
 	tasks    generic_sync.MapOf[K, *Task[K]]
+	// onErrored is called after a task of the manager ends with an error
+	onErrored Callback[K]
+	// onChange is called when a task of the manager changes
+	onChange Callback[K]
+}
+
+// OnChange sets the callback called when the state, the status or the
+// progress of a task changes, it's called synchronously so it must not block
+func (tm *Manager[K]) OnChange(callback Callback[K]) {
+	tm.onChange = callback
+}
+
+// OnErrored sets the callback called after a task ends with an error
+func (tm *Manager[K]) OnErrored(callback Callback[K]) {
+	tm.onErrored = callback
 }
 
 func (tm *Manager[K]) Submit(task *Task[K]) K {
@@ -19,7 +34,9 @@ func (tm *Manager[K]) Submit(task *Task[K]) K {
 		tm.updateID(&tm.curID)
 		task.ID = tm.curID
 	}
+	task.onChange = tm.onChange
 	tm.tasks.Store(task.ID, task)
+	task.notify()
 	tm.do(task)
 	return task.ID
 }
@@ -32,8 +49,16 @@ func (tm *Manager[K]) do(task *Task[K]) {
 			log.Debugf("task [%s] starting", task.Name)
 			task.run()
 			log.Debugf("task [%s] ended", task.Name)
+			if task.state == ERRORED && tm.onErrored != nil {
+				tm.onErrored(task)
+			}
 		case <-task.Ctx.Done():
 			log.Debugf("task [%s] canceled", task.Name)
+			task.state = CANCELED
+			task.notify()
+			if task.Finally != nil {
+				task.Finally(task)
+			}
 			return
 		}
 		// return worker
@@ -3,7 +3,9 @@ package task
 
 import (
 	"context"
+	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -22,8 +24,11 @@ type Func[K comparable] func(task *Task[K]) error
 type Callback[K comparable] func(task *Task[K])
 
 type Task[K comparable] struct {
-	ID       K
-	Name     string
+	ID   K
+	Name string
+	// Creator is the id of the user created the task, 0 if it's created by
+	// alist itself
+	Creator  uint
 	state    string // pending, running, finished, canceling, canceled, errored
 	status   string
 	progress int
@@ -32,17 +37,38 @@ type Task[K comparable] struct {
 
 	Func     Func[K]
 	callback Callback[K]
+	// onChange is called when the state, the status or the progress changes
+	onChange Callback[K]
+	// Finally is called after the task ends, whatever the state is
+	Finally Callback[K]
+
+	// MaxRetry is the max times to rerun Func when it returns an error,
+	// waiting RetryBackoff before the first retry and doubling it after each
+	MaxRetry     int
+	RetryBackoff time.Duration
 
 	Ctx    context.Context
 	cancel context.CancelFunc
 }
 
 func (t *Task[K]) SetStatus(status string) {
-	t.status = status
+	if t.status != status {
+		t.status = status
+		t.notify()
+	}
 }
 
 func (t *Task[K]) SetProgress(percentage int) {
-	t.progress = percentage
+	if t.progress != percentage {
+		t.progress = percentage
+		t.notify()
+	}
+}
+
+func (t *Task[K]) notify() {
+	if t.onChange != nil {
+		t.onChange(t)
+	}
 }
 
 func (t Task[K]) GetProgress() int {
@@ -70,16 +96,44 @@ func getCurrentGoroutineStack() string {
 	return string(buf[:n])
 }
 
-func (t *Task[K]) run() {
-	t.state = RUNNING
+// maxRetryBackoff caps the wait between retries
+const maxRetryBackoff = time.Minute * 10
+
+func (t *Task[K]) call() (err error) {
 	defer func() {
-		if err := recover(); err != nil {
-			log.Errorf("error [%s] while run task [%s],stack trace:\n%s", err, t.Name, getCurrentGoroutineStack())
-			t.Error = errors.Errorf("panic: %+v", err)
-			t.state = ERRORED
+		if e := recover(); e != nil {
+			log.Errorf("error [%s] while run task [%s],stack trace:\n%s", e, t.Name, getCurrentGoroutineStack())
+			err = errors.Errorf("panic: %+v", e)
 		}
 	}()
-	t.Error = t.Func(t)
+	return t.Func(t)
+}
+
+func (t *Task[K]) run() {
+	t.state = RUNNING
+	t.notify()
+	if t.Finally != nil {
+		defer t.Finally(t)
+	}
+	backoff := t.RetryBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		t.Error = t.call()
+		if t.Error == nil || t.Ctx.Err() != nil || attempt >= t.MaxRetry {
+			break
+		}
+		log.Warnf("error [%+v] while run task [%s], retry %d/%d after %s", t.Error, t.Name, attempt+1, t.MaxRetry, backoff)
+		t.SetStatus(fmt.Sprintf("retry %d/%d after %s: %s", attempt+1, t.MaxRetry, backoff, t.Error))
+		select {
+		case <-time.After(backoff):
+		case <-t.Ctx.Done():
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
 	if t.Error != nil {
 		log.Errorf("error [%+v] while run task [%s]", t.Error, t.Name)
 	}
@@ -89,11 +143,12 @@ func (t *Task[K]) run() {
 		t.state = ERRORED
 	} else {
 		t.state = SUCCEEDED
-		t.SetProgress(100)
+		t.progress = 100
 		if t.callback != nil {
 			t.callback(t)
 		}
 	}
+	t.notify()
 }
 
 func (t *Task[K]) retry() {
@@ -113,6 +168,7 @@ func (t *Task[K]) Cancel() {
 	}
 	// maybe can't cancel
 	t.state = CANCELING
+	t.notify()
 }
 
 func WithCancelCtx[K comparable](task *Task[K]) *Task[K] {
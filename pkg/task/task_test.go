@@ -94,3 +94,105 @@ func TestTask_Retry(t *testing.T) {
 		t.Errorf("task error: %+v, but expected nil", task.Error)
 	}
 }
+
+func TestTask_MaxRetry(t *testing.T) {
+	tm := NewTaskManager(3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	var num, finally int32
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name:         "test",
+		MaxRetry:     2,
+		RetryBackoff: time.Millisecond,
+		Func: func(task *Task[uint64]) error {
+			if atomic.AddInt32(&num, 1) < 3 {
+				return errors.New("test error")
+			}
+			return nil
+		},
+		Finally: func(task *Task[uint64]) {
+			atomic.AddInt32(&finally, 1)
+		},
+	}))
+	task, ok := tm.Get(id)
+	if !ok {
+		t.Fatal("task not found")
+	}
+	time.Sleep(time.Millisecond * 100)
+	if task.state != SUCCEEDED {
+		t.Errorf("task state: %s, but expected succeeded, error: %+v", task.state, task.Error)
+	}
+	if n := atomic.LoadInt32(&num); n != 3 {
+		t.Errorf("task run %d times, but expected 3", n)
+	}
+	if f := atomic.LoadInt32(&finally); f != 1 {
+		t.Errorf("finally called %d times, but expected 1", f)
+	}
+}
+
+func TestTask_OnErrored(t *testing.T) {
+	tm := NewTaskManager(3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	errored := make(chan uint64, 2)
+	tm.OnErrored(func(task *Task[uint64]) {
+		errored <- task.ID
+	})
+	tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "succeed",
+		Func: func(task *Task[uint64]) error {
+			return nil
+		},
+	}))
+	id := tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "fail",
+		Func: func(task *Task[uint64]) error {
+			return errors.New("error")
+		},
+	}))
+	select {
+	case got := <-errored:
+		if got != id {
+			t.Errorf("expect errored task %d, got %d", id, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("errored callback not called")
+	}
+	time.Sleep(time.Millisecond * 100)
+	if len(errored) != 0 {
+		t.Error("errored callback called for a succeeded task")
+	}
+}
+
+func TestTask_OnChange(t *testing.T) {
+	tm := NewTaskManager(3, func(id *uint64) {
+		atomic.AddUint64(id, 1)
+	})
+	changes := make(chan string, 16)
+	tm.OnChange(func(task *Task[uint64]) {
+		changes <- task.GetState() + ":" + task.GetStatus()
+	})
+	tm.Submit(WithCancelCtx(&Task[uint64]{
+		Name: "test",
+		Func: func(task *Task[uint64]) error {
+			task.SetStatus("working")
+			task.SetStatus("working")
+			return nil
+		},
+	}))
+	expect := []string{PENDING + ":", RUNNING + ":", RUNNING + ":working", SUCCEEDED + ":working"}
+	for _, e := range expect {
+		select {
+		case got := <-changes:
+			if got != e {
+				t.Errorf("expect change %s, got %s", e, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("change %s not notified", e)
+		}
+	}
+	time.Sleep(time.Millisecond * 100)
+	if len(changes) != 0 {
+		t.Errorf("unexpected changes: %d", len(changes))
+	}
+}
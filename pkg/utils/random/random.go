@@ -1,6 +1,8 @@
 package random
 
 import (
+	crand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"time"
 
@@ -19,6 +21,21 @@ func String(n int) string {
 	return string(b)
 }
 
+// Secret is like String but reads from crypto/rand, for the strings that
+// must not be guessed
+func Secret(n int) string {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(letterBytes)))
+	for i := range b {
+		idx, err := crand.Int(crand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = letterBytes[idx.Int64()]
+	}
+	return string(b)
+}
+
 func Token() string {
 	return "alist-" + uuid.NewString() + String(64)
 }
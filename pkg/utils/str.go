@@ -12,3 +12,16 @@ func MappingName(name string) string {
 	}
 	return name
 }
+
+// EscapeGlob escapes the special characters of the glob patterns of redis
+func EscapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\', '^', '-':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/gin-gonic/gin"
+)
+
+// the actions recorded in the audit log
+const (
+	AuditLogin          = "login"
+	AuditStorageCreate  = "storage.create"
+	AuditStorageUpdate  = "storage.update"
+	AuditStorageDelete  = "storage.delete"
+	AuditStorageEnable  = "storage.enable"
+	AuditStorageDisable = "storage.disable"
+	AuditUserCreate     = "user.create"
+	AuditUserUpdate     = "user.update"
+	AuditUserDelete     = "user.delete"
+	AuditSettingSave    = "setting.save"
+	AuditReload         = "reload"
+	AuditConfigExport   = "config.export"
+	AuditConfigImport   = "config.import"
+	AuditShareCreate    = "share.create"
+	AuditShareDelete    = "share.delete"
+	AuditFileMkdir      = "file.mkdir"
+	AuditFileRename     = "file.rename"
+	AuditFileMove       = "file.move"
+	AuditFileCopy       = "file.copy"
+	AuditFileRemove     = "file.remove"
+	AuditFileUpload     = "file.upload"
+	AuditFileDownload   = "file.download"
+)
+
+// Audit records the action of the user of the request, err is the result
+// of the action
+func Audit(c *gin.Context, action, target string, err error, detail ...string) {
+	var user *model.User
+	if u, ok := c.Get("user"); ok {
+		user, _ = u.(*model.User)
+	}
+	AuditAs(c, user, "", action, target, err, detail...)
+}
+
+// AuditAs records the action of the user, the username is used if the
+// user is unknown, such as a failed login
+func AuditAs(c *gin.Context, user *model.User, username, action, target string, err error, detail ...string) {
+	if !setting.GetBool(conf.AuditEnabled) {
+		return
+	}
+	if action == AuditFileDownload && !setting.GetBool(conf.AuditDownloads) {
+		return
+	}
+	l := model.AuditLog{
+		Username: username,
+		IP:       c.ClientIP(),
+		Action:   action,
+		Target:   target,
+		Success:  err == nil,
+	}
+	if user != nil {
+		l.UserID, l.Username = user.ID, user.Username
+	}
+	if len(detail) > 0 {
+		l.Detail = detail[0]
+	}
+	if err != nil {
+		if l.Detail != "" {
+			l.Detail += ": "
+		}
+		l.Detail += err.Error()
+	}
+	op.RecordAudit(l)
+}
@@ -13,7 +13,7 @@ import (
 )
 
 func IsStorageSignEnabled(rawPath string) bool {
-	storage := op.GetBalancedStorage(rawPath)
+	storage := op.GetBalancedStorage(op.ResolveSymlink(rawPath))
 	return storage != nil && storage.GetStorage().EnableSign
 }
 
@@ -72,3 +72,13 @@ func ShouldProxy(storage driver.Driver, filename string) bool {
 	}
 	return false
 }
+
+// HasPermission reports whether the acl rules give the user the perm on
+// the path, or returns fallback, which is usually decided by the
+// permissions of the user, if no rule covers it
+func HasPermission(user *model.User, path string, perm int32, fallback bool) bool {
+	if allowed, decided := op.CheckPermission(user, path, perm); decided {
+		return allowed
+	}
+	return fallback
+}
@@ -0,0 +1,33 @@
+package common
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/limit"
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+type limitWriter struct {
+	http.ResponseWriter
+	ctx      context.Context
+	transfer *limit.Transfer
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	return w.transfer.Write(w.ctx, w.ResponseWriter, p)
+}
+
+// LimitProxy starts a proxied download of the user (nil if unknown) from
+// the storage, the returned writer is throttled by the download limits and
+// the transfer must be done after the download ends
+func LimitProxy(ctx context.Context, w http.ResponseWriter, user *model.User, storage *model.Storage) (http.ResponseWriter, *limit.Transfer, error) {
+	transfer, err := limit.Start(ctx, user, storage, limit.Download, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !transfer.Limited() {
+		return w, transfer, nil
+	}
+	return &limitWriter{ResponseWriter: w, ctx: ctx, transfer: transfer}, transfer, nil
+}
@@ -0,0 +1,194 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/http_range"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// partRetry is the times to try a part before the download fails
+const partRetry = 3
+
+// parallelOptions returns the concurrency and the part size in bytes of
+// the parallel download of the link
+func parallelOptions(link *model.Link) (int, int64) {
+	concurrency := link.Concurrency
+	if concurrency == 0 {
+		concurrency = setting.GetInt(conf.ProxyConcurrency, 1)
+	}
+	partSize := int64(link.PartSize)
+	if partSize == 0 {
+		partSize = int64(setting.GetInt(conf.ProxyPartSize, 8)) * 1024 * 1024
+	}
+	return concurrency, partSize
+}
+
+type partResult struct {
+	data []byte
+	err  error
+}
+
+// proxyParallel downloads the requested range of the link with parallel
+// range requests and writes the parts in order. It returns false without
+// writing anything if the download can't be parallel, such as the size is
+// unknown, several ranges are requested or the upstream ignores ranges.
+func proxyParallel(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) (bool, error) {
+	concurrency, partSize := parallelOptions(link)
+	if concurrency <= 1 || partSize <= 0 || file == nil || file.GetSize() <= 0 || r.Method != http.MethodGet {
+		return false, nil
+	}
+	size := file.GetSize()
+	ranges, err := http_range.ParseRange(r.Header.Get("Range"), size)
+	if err != nil || len(ranges) > 1 {
+		return false, nil
+	}
+	rg := http_range.Range{Start: 0, Length: size}
+	if len(ranges) == 1 {
+		rg = ranges[0]
+	}
+	if rg.Length <= partSize {
+		return false, nil
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// the first part checks that the upstream supports ranges
+	res, err := requestPart(ctx, link, rg.Start, partSize)
+	if err != nil {
+		return false, nil
+	}
+	if res.StatusCode != http.StatusPartialContent || rangeTotal(res.Header.Get("Content-Range")) != size {
+		_ = res.Body.Close()
+		return false, nil
+	}
+	first := make([]byte, partSize)
+	_, err = io.ReadFull(res.Body, first)
+	_ = res.Body.Close()
+	if err != nil {
+		return false, nil
+	}
+
+	for _, h := range []string{"Content-Type", "Last-Modified", "ETag", "Content-Disposition", "Cache-Control"} {
+		if v := res.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Length", strconv.FormatInt(rg.Length, 10))
+	if len(ranges) == 1 {
+		w.Header().Set("Content-Range", rg.ContentRange(size))
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	if _, err = w.Write(first); err != nil {
+		return true, err
+	}
+
+	n := (rg.Length + partSize - 1) / partSize
+	results := make([]chan partResult, n)
+	for i := range results {
+		results[i] = make(chan partResult, 1)
+	}
+	// a part holds the semaphore until it's written, so that at most
+	// concurrency parts are in memory
+	sem := make(chan struct{}, concurrency)
+	go func() {
+		for i := int64(1); i < n; i++ {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			start := rg.Start + i*partSize
+			length := partSize
+			if end := rg.Start + rg.Length; start+length > end {
+				length = end - start
+			}
+			go func(ch chan partResult, start, length int64) {
+				data, err := readPart(ctx, link, start, length)
+				ch <- partResult{data: data, err: err}
+			}(results[i], start, length)
+		}
+	}()
+	for i := int64(1); i < n; i++ {
+		var res partResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+		if res.err != nil {
+			return true, res.err
+		}
+		if _, err = w.Write(res.data); err != nil {
+			return true, err
+		}
+		<-sem
+	}
+	return true, nil
+}
+
+func requestPart(ctx context.Context, link *model.Link, start, length int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, start+length-1))
+	return HttpClient.Do(req)
+}
+
+// readPart reads the part, and retries it if it fails
+func readPart(ctx context.Context, link *model.Link, start, length int64) ([]byte, error) {
+	var err error
+	for i := 0; i < partRetry; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var res *http.Response
+		res, err = requestPart(ctx, link, start, length)
+		if err != nil {
+			continue
+		}
+		if res.StatusCode != http.StatusPartialContent {
+			_ = res.Body.Close()
+			err = fmt.Errorf("unexpected status of upstream: %s", res.Status)
+			continue
+		}
+		data := make([]byte, length)
+		_, err = io.ReadFull(res.Body, data)
+		_ = res.Body.Close()
+		if err == nil {
+			return data, nil
+		}
+		log.Debugf("failed read part %d-%d, retrying: %+v", start, start+length-1, err)
+	}
+	return nil, errors.WithMessagef(err, "failed read part %d-%d", start, start+length-1)
+}
+
+// rangeTotal returns the total size in the Content-Range header, or -1 if
+// it's unknown
+func rangeTotal(contentRange string) int64 {
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return -1
+	}
+	total, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestProxyParallel(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789abcdefghij", 5) + "xyz")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "a.bin", time.Now(), bytes.NewReader(content))
+	}))
+	defer upstream.Close()
+	link := &model.Link{URL: upstream.URL, Concurrency: 3, PartSize: 7}
+	file := &model.Object{Name: "a.bin", Size: int64(len(content))}
+
+	for _, tc := range []struct {
+		rangeHeader string
+		status      int
+		want        []byte
+	}{
+		{"", http.StatusOK, content},
+		{"bytes=5-", http.StatusPartialContent, content[5:]},
+		{"bytes=13-60", http.StatusPartialContent, content[13:61]},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.rangeHeader != "" {
+			r.Header.Set("Range", tc.rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		ok, err := proxyParallel(w, r, link, file)
+		if !ok || err != nil {
+			t.Fatalf("expect the download of %q is parallel: %v", tc.rangeHeader, err)
+		}
+		if w.Code != tc.status || !bytes.Equal(w.Body.Bytes(), tc.want) {
+			t.Errorf("unexpected response of %q: %d %q", tc.rangeHeader, w.Code, w.Body.Bytes())
+		}
+	}
+
+	noRange := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer noRange.Close()
+	w := httptest.NewRecorder()
+	ok, _ := proxyParallel(w, httptest.NewRequest(http.MethodGet, "/", nil), &model.Link{URL: noRange.URL, Concurrency: 3, PartSize: 7}, file)
+	if ok || w.Body.Len() > 0 {
+		t.Errorf("expect it falls back if the upstream ignores ranges")
+	}
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,10 +11,13 @@ import (
 	"strings"
 
 	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/tracing"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var HttpClient = &http.Client{
@@ -27,6 +31,31 @@ var HttpClient = &http.Client{
 }
 
 func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
+	transfers := metrics.ActiveTransfers.WithLabelValues("download")
+	transfers.Inc()
+	defer transfers.Dec()
+	ctx, span := tracing.Start(r.Context(), "proxy", tracing.Path(file.GetPath()), attribute.Int64("alist.size", file.GetSize()))
+	cw := &countWriter{ResponseWriter: w}
+	err := proxy(cw, r.WithContext(ctx), link, file)
+	span.SetAttributes(attribute.Int64("alist.sent_bytes", cw.n))
+	tracing.End(span, err)
+	return err
+}
+
+// countWriter counts the proxied bytes for the metrics and the span
+type countWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	metrics.ProxiedBytes.Add(float64(n))
+	w.n += int64(n)
+	return n, err
+}
+
+func proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.Obj) error {
 	// read data with native
 	var err error
 	if link.Data != nil {
@@ -75,6 +104,9 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 	} else if link.Handle != nil {
 		return link.Handle(w, r)
 	} else {
+		if ok, err := proxyParallel(w, r, link, file); ok {
+			return err
+		}
 		req, err := http.NewRequest(r.Method, link.URL, nil)
 		if err != nil {
 			return err
@@ -117,3 +149,127 @@ func Proxy(w http.ResponseWriter, r *http.Request, link *model.Link, file model.
 		return nil
 	}
 }
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// OpenRange opens length bytes of the linked file from start
+func OpenRange(ctx context.Context, link *model.Link, start, length int64) (io.ReadCloser, error) {
+	if link.Data != nil {
+		if _, err := io.CopyN(io.Discard, link.Data, start); err != nil {
+			_ = link.Data.Close()
+			return nil, err
+		}
+		return readCloser{io.LimitReader(link.Data, length), link.Data}, nil
+	}
+	if link.FilePath != nil && *link.FilePath != "" {
+		f, err := os.Open(*link.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = f.Seek(start, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return readCloser{io.LimitReader(f, length), f}, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, start+length-1))
+	res, err := HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// the upstream ignores the range
+		if _, err = io.CopyN(io.Discard, res.Body, start); err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+	default:
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unexpected status of upstream: %s", res.Status)
+	}
+	return readCloser{io.LimitReader(res.Body, length), res.Body}, nil
+}
+
+// OpenLink opens the data of the link for the server to read, such as
+// packing files into an archive
+func OpenLink(ctx context.Context, link *model.Link) (io.ReadCloser, error) {
+	if link.Data != nil {
+		return link.Data, nil
+	}
+	if link.FilePath != nil && *link.FilePath != "" {
+		return os.Open(*link.FilePath)
+	}
+	if link.Handle != nil {
+		return openHandle(ctx, link)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for h, val := range link.Header {
+		req.Header[h] = val
+	}
+	res, err := HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return res.Body, nil
+}
+
+// pipeResponse is a http.ResponseWriter that pipes the body to a reader
+type pipeResponse struct {
+	header http.Header
+	pw     *io.PipeWriter
+	status int
+}
+
+func (p *pipeResponse) Header() http.Header {
+	return p.header
+}
+
+func (p *pipeResponse) WriteHeader(status int) {
+	if p.status == 0 {
+		p.status = status
+	}
+}
+
+func (p *pipeResponse) Write(b []byte) (int, error) {
+	p.WriteHeader(http.StatusOK)
+	if p.status != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status: %d", p.status)
+	}
+	return p.pw.Write(b)
+}
+
+func openHandle(ctx context.Context, link *model.Link) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	w := &pipeResponse{header: http.Header{}, pw: pw}
+	go func() {
+		err := link.Handle(w, req)
+		if err == nil && w.status != 0 && w.status != http.StatusOK {
+			err = fmt.Errorf("unexpected status: %d", w.status)
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestOpenLinkHandle(t *testing.T) {
+	link := &model.Link{Handle: func(w http.ResponseWriter, r *http.Request) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	}}
+	rc, err := OpenLink(context.Background(), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil || string(data) != "hello" {
+		t.Errorf("unexpected data %q: %v", data, err)
+	}
+
+	link.Handle = func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNotFound)
+		return nil
+	}
+	rc, err = OpenLink(context.Background(), link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.ReadAll(rc); err == nil {
+		t.Errorf("expect error of the status")
+	}
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/ftp"
+	"github.com/pkg/errors"
+)
+
+// NewFTP creates the ftp server of the ftp config
+func NewFTP() (*ftp.Server, error) {
+	min, max, err := ftp.ParsePortRange(conf.Conf.FTP.PassivePortRange)
+	if err != nil {
+		return nil, err
+	}
+	s := &ftp.Server{
+		Addr:           fmt.Sprintf("%s:%d", conf.Conf.Address, conf.Conf.FTP.Port),
+		ImplicitTLS:    conf.Conf.FTP.ImplicitTLS,
+		MinPassivePort: min,
+		MaxPassivePort: max,
+		PublicHost:     conf.Conf.FTP.PublicHost,
+		Auth:           ftpAuth,
+	}
+	if conf.Conf.FTP.TLS || conf.Conf.FTP.ImplicitTLS {
+		cert, err := tls.LoadX509KeyPair(conf.Conf.Scheme.CertFile, conf.Conf.Scheme.KeyFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load cert of ftp server")
+		}
+		s.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	return s, nil
+}
+
+// ftpAuth logins the user, anonymous logins use the guest
+func ftpAuth(username, password string) (*model.User, error) {
+	var user *model.User
+	var err error
+	if username == "anonymous" || username == "ftp" {
+		user, err = op.GetGuest()
+	} else {
+		user, err = op.GetUserByName(username)
+		if err == nil {
+			err = op.ValidateClientPassword(user, password)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errs.PermissionDenied
+	}
+	return user, nil
+}
@@ -0,0 +1,756 @@
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/limit"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	log "github.com/sirupsen/logrus"
+)
+
+type command struct {
+	// open commands can be used before login
+	open bool
+	fn   func(c *conn, arg string)
+}
+
+var commands = map[string]command{
+	"USER": {true, (*conn).cmdUser},
+	"PASS": {true, (*conn).cmdPass},
+	"AUTH": {true, (*conn).cmdAuth},
+	"PBSZ": {true, (*conn).cmdPbsz},
+	"PROT": {true, (*conn).cmdProt},
+	"FEAT": {true, (*conn).cmdFeat},
+	"SYST": {true, (*conn).cmdSyst},
+	"OPTS": {true, (*conn).cmdOpts},
+	"NOOP": {true, (*conn).cmdNoop},
+	"HELP": {true, (*conn).cmdHelp},
+	"QUIT": {true, (*conn).cmdQuit},
+	"TYPE": {false, (*conn).cmdType},
+	"MODE": {false, (*conn).cmdMode},
+	"STRU": {false, (*conn).cmdStru},
+	"ALLO": {false, (*conn).cmdAllo},
+	"STAT": {false, (*conn).cmdStat},
+	"PWD":  {false, (*conn).cmdPwd},
+	"XPWD": {false, (*conn).cmdPwd},
+	"CWD":  {false, (*conn).cmdCwd},
+	"XCWD": {false, (*conn).cmdCwd},
+	"CDUP": {false, (*conn).cmdCdup},
+	"XCUP": {false, (*conn).cmdCdup},
+	"PASV": {false, (*conn).cmdPasv},
+	"EPSV": {false, (*conn).cmdEpsv},
+	"PORT": {false, (*conn).cmdPort},
+	"EPRT": {false, (*conn).cmdEprt},
+	"LIST": {false, (*conn).cmdList},
+	"NLST": {false, (*conn).cmdNlst},
+	"MLSD": {false, (*conn).cmdMlsd},
+	"MLST": {false, (*conn).cmdMlst},
+	"RETR": {false, (*conn).cmdRetr},
+	"STOR": {false, (*conn).cmdStor},
+	"DELE": {false, (*conn).cmdDele},
+	"RMD":  {false, (*conn).cmdRmd},
+	"XRMD": {false, (*conn).cmdRmd},
+	"MKD":  {false, (*conn).cmdMkd},
+	"XMKD": {false, (*conn).cmdMkd},
+	"RNFR": {false, (*conn).cmdRnfr},
+	"RNTO": {false, (*conn).cmdRnto},
+	"SIZE": {false, (*conn).cmdSize},
+	"MDTM": {false, (*conn).cmdMdtm},
+	"REST": {false, (*conn).cmdRest},
+	"ABOR": {false, (*conn).cmdAbor},
+}
+
+func (c *conn) cmdUser(arg string) {
+	c.username = arg
+	c.user = nil
+	c.reply(331, "Password required for "+arg)
+}
+
+func (c *conn) cmdPass(arg string) {
+	if c.username == "" {
+		c.reply(503, "Login with USER first")
+		return
+	}
+	user, err := c.srv.Auth(c.username, arg)
+	if err != nil {
+		log.Debugf("[ftp] failed login of %s from %s: %+v", c.username, c.ctrl.RemoteAddr(), err)
+		c.reply(530, "Login incorrect")
+		return
+	}
+	c.user = user
+	c.ctx = context.WithValue(context.Background(), "user", user)
+	c.cwd = "/"
+	c.reply(230, "Login successful")
+}
+
+func (c *conn) cmdAuth(arg string) {
+	if c.srv.TLSConfig == nil {
+		c.reply(502, "TLS is not enabled")
+		return
+	}
+	if c.tls {
+		c.reply(503, "Already using TLS")
+		return
+	}
+	switch strings.ToUpper(arg) {
+	case "TLS", "TLS-C", "SSL":
+	default:
+		c.reply(504, "Unsupported security mechanism")
+		return
+	}
+	c.reply(234, "AUTH TLS successful")
+	tc := tls.Server(c.ctrl, c.srv.TLSConfig)
+	_ = tc.SetDeadline(time.Now().Add(dataTimeout))
+	if err := tc.Handshake(); err != nil {
+		log.Debugf("[ftp] tls handshake with %s failed: %+v", c.ctrl.RemoteAddr(), err)
+		_ = c.ctrl.Close()
+		return
+	}
+	_ = tc.SetDeadline(time.Time{})
+	c.ctrl = tc
+	c.r = bufio.NewReader(tc)
+	c.w = bufio.NewWriter(tc)
+	c.tls = true
+	// a new session starts after the handshake
+	c.username = ""
+	c.user = nil
+}
+
+func (c *conn) cmdPbsz(arg string) {
+	if !c.tls {
+		c.reply(503, "Use AUTH TLS first")
+		return
+	}
+	c.reply(200, "PBSZ=0")
+}
+
+func (c *conn) cmdProt(arg string) {
+	if !c.tls {
+		c.reply(503, "Use AUTH TLS first")
+		return
+	}
+	switch strings.ToUpper(arg) {
+	case "P":
+		c.protP = true
+	case "C":
+		c.protP = false
+	default:
+		c.reply(504, "Unsupported protection level")
+		return
+	}
+	c.reply(200, "Protection level set to "+strings.ToUpper(arg))
+}
+
+func (c *conn) cmdFeat(arg string) {
+	feats := []string{"UTF8", "PASV", "EPSV", "EPRT", "SIZE", "MDTM", "REST STREAM", "MLST type*;size*;modify*;"}
+	if c.srv.TLSConfig != nil {
+		feats = append(feats, "AUTH TLS", "PBSZ", "PROT")
+	}
+	c.replyLines(211, "Features:", feats, "End")
+}
+
+func (c *conn) cmdSyst(arg string) {
+	c.reply(215, "UNIX Type: L8")
+}
+
+func (c *conn) cmdOpts(arg string) {
+	if strings.EqualFold(strings.TrimSpace(arg), "UTF8 ON") {
+		c.reply(200, "UTF8 mode enabled")
+		return
+	}
+	c.reply(501, "Unsupported option")
+}
+
+func (c *conn) cmdNoop(arg string) {
+	c.reply(200, "OK")
+}
+
+func (c *conn) cmdHelp(arg string) {
+	c.reply(214, "See RFC 959 for the commands")
+}
+
+func (c *conn) cmdQuit(arg string) {
+	c.reply(221, "Goodbye")
+}
+
+func (c *conn) cmdType(arg string) {
+	// all transfers are binary, the ascii type is accepted for old clients
+	switch strings.ToUpper(strings.TrimSpace(arg)) {
+	case "A", "A N", "I", "L 8":
+		c.reply(200, "Type set to "+arg)
+	default:
+		c.reply(504, "Unsupported type")
+	}
+}
+
+func (c *conn) cmdMode(arg string) {
+	if strings.EqualFold(arg, "S") {
+		c.reply(200, "Mode set to S")
+		return
+	}
+	c.reply(504, "Only stream mode is supported")
+}
+
+func (c *conn) cmdStru(arg string) {
+	if strings.EqualFold(arg, "F") {
+		c.reply(200, "Structure set to F")
+		return
+	}
+	c.reply(504, "Only file structure is supported")
+}
+
+func (c *conn) cmdAllo(arg string) {
+	c.reply(202, "No storage allocation necessary")
+}
+
+func (c *conn) cmdStat(arg string) {
+	if arg != "" {
+		c.reply(504, "STAT with arguments is not supported")
+		return
+	}
+	c.replyLines(211, "alist ftp server status:", []string{
+		"Logged in as " + c.user.Username,
+		"TLS: " + strconv.FormatBool(c.tls),
+	}, "End of status")
+}
+
+func (c *conn) cmdPwd(arg string) {
+	c.reply(257, quote(c.cwd)+" is the current directory")
+}
+
+func (c *conn) cmdCwd(arg string) {
+	reqPath, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	if !obj.IsDir() {
+		c.replyErr(errs.NotFolder)
+		return
+	}
+	c.cwd = c.visible(arg)
+	c.reply(250, "Directory changed to "+c.cwd)
+}
+
+func (c *conn) cmdCdup(arg string) {
+	c.cmdCwd("..")
+}
+
+func (c *conn) publicIP() net.IP {
+	if c.srv.PublicHost != "" {
+		if ip := net.ParseIP(c.srv.PublicHost); ip != nil {
+			return ip
+		}
+		if ips, err := net.LookupIP(c.srv.PublicHost); err == nil && len(ips) > 0 {
+			return ips[0]
+		}
+	}
+	if addr, ok := c.ctrl.LocalAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
+func (c *conn) listenPassive() (int, bool) {
+	c.closeData()
+	l, err := c.srv.listenPassive()
+	if err != nil {
+		log.Warnf("[ftp] %+v", err)
+		c.reply(425, "Can't open passive connection")
+		return 0, false
+	}
+	c.pasv = l
+	return l.Addr().(*net.TCPAddr).Port, true
+}
+
+func (c *conn) cmdPasv(arg string) {
+	ip := c.publicIP().To4()
+	if ip == nil {
+		c.reply(425, "PASV requires ipv4, use EPSV")
+		return
+	}
+	port, ok := c.listenPassive()
+	if !ok {
+		return
+	}
+	c.reply(227, fmt.Sprintf("Entering Passive Mode (%d,%d,%d,%d,%d,%d)", ip[0], ip[1], ip[2], ip[3], port>>8, port&0xff))
+}
+
+func (c *conn) cmdEpsv(arg string) {
+	if strings.EqualFold(arg, "ALL") {
+		c.reply(200, "EPSV ALL accepted")
+		return
+	}
+	port, ok := c.listenPassive()
+	if !ok {
+		return
+	}
+	c.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+}
+
+func (c *conn) setPort(addr string, err error) {
+	if err != nil {
+		c.reply(501, err.Error())
+		return
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	remote, ok := c.ctrl.RemoteAddr().(*net.TCPAddr)
+	// connecting to other hosts is refused to prevent the bounce attack
+	if !ok || !remote.IP.Equal(net.ParseIP(host)) {
+		c.reply(504, "Only the address of the control connection is allowed")
+		return
+	}
+	c.closeData()
+	c.port = addr
+	c.reply(200, "Command okay")
+}
+
+func (c *conn) cmdPort(arg string) {
+	c.setPort(parsePort(arg))
+}
+
+func (c *conn) cmdEprt(arg string) {
+	c.setPort(parseEprt(arg))
+}
+
+// parsePort parses the address of PORT like "h1,h2,h3,h4,p1,p2"
+func parsePort(arg string) (string, error) {
+	parts := strings.Split(arg, ",")
+	if len(parts) != 6 {
+		return "", fmt.Errorf("invalid address: %s", arg)
+	}
+	var nums [6]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 0 || n > 255 {
+			return "", fmt.Errorf("invalid address: %s", arg)
+		}
+		nums[i] = n
+	}
+	ip := fmt.Sprintf("%d.%d.%d.%d", nums[0], nums[1], nums[2], nums[3])
+	return net.JoinHostPort(ip, strconv.Itoa(nums[4]<<8|nums[5])), nil
+}
+
+// parseEprt parses the address of EPRT like "|1|132.235.1.2|6275|"
+func parseEprt(arg string) (string, error) {
+	if len(arg) < 2 {
+		return "", fmt.Errorf("invalid address: %s", arg)
+	}
+	parts := strings.Split(arg[1:], arg[:1])
+	if len(parts) != 4 || (parts[0] != "1" && parts[0] != "2") {
+		return "", fmt.Errorf("invalid address: %s", arg)
+	}
+	ip := net.ParseIP(parts[1])
+	port, err := strconv.Atoi(parts[2])
+	if ip == nil || err != nil || port <= 0 || port > 65535 {
+		return "", fmt.Errorf("invalid address: %s", arg)
+	}
+	return net.JoinHostPort(ip.String(), parts[2]), nil
+}
+
+// transfer replies 150, runs fn with the data connection and replies the result
+func (c *conn) transfer(fn func(dc net.Conn) error) {
+	c.reply(150, "Opening data connection")
+	dc, err := c.openData()
+	if err != nil {
+		c.reply(425, "Can't open data connection: "+err.Error())
+		return
+	}
+	err = fn(dc)
+	if cerr := dc.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		log.Debugf("[ftp] transfer of %s failed: %+v", c.ctrl.RemoteAddr(), err)
+		c.reply(426, "Transfer aborted: "+err.Error())
+		return
+	}
+	c.reply(226, "Transfer complete")
+}
+
+// listTarget lists the dir of arg, or returns the file itself
+func (c *conn) listTarget(arg string) ([]model.Obj, error) {
+	// ignore the flags of ls sent by some clients
+	var target []string
+	for _, f := range strings.Fields(arg) {
+		if !strings.HasPrefix(f, "-") {
+			target = append(target, f)
+		}
+	}
+	reqPath, meta, err := c.resolve(strings.Join(target, " "))
+	if err != nil {
+		return nil, err
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if !obj.IsDir() {
+		return []model.Obj{obj}, nil
+	}
+	return c.list(reqPath, meta)
+}
+
+func (c *conn) cmdList(arg string) {
+	objs, err := c.listTarget(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.transfer(func(dc net.Conn) error {
+		return writeList(dc, objs, time.Now(), formatList)
+	})
+}
+
+func (c *conn) cmdNlst(arg string) {
+	objs, err := c.listTarget(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.transfer(func(dc net.Conn) error {
+		return writeList(dc, objs, time.Now(), func(obj model.Obj, _ time.Time) string {
+			return obj.GetName()
+		})
+	})
+}
+
+func (c *conn) cmdMlsd(arg string) {
+	reqPath, meta, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	objs, err := c.list(reqPath, meta)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.transfer(func(dc net.Conn) error {
+		return writeList(dc, objs, time.Now(), func(obj model.Obj, _ time.Time) string {
+			return formatFacts(obj) + " " + obj.GetName()
+		})
+	})
+}
+
+func (c *conn) cmdMlst(arg string) {
+	reqPath, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.replyLines(250, "Listing "+c.visible(arg), []string{formatFacts(obj) + " " + c.visible(arg)}, "End")
+}
+
+// getFile returns the file of arg, replying the error if it's not a file
+func (c *conn) getFile(arg string) (string, model.Obj, bool) {
+	reqPath, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return "", nil, false
+	}
+	obj, err := fs.Get(c.ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		c.replyErr(err)
+		return "", nil, false
+	}
+	if obj.IsDir() {
+		c.replyErr(errs.NotFile)
+		return "", nil, false
+	}
+	return reqPath, obj, true
+}
+
+func (c *conn) cmdRetr(arg string) {
+	reqPath, obj, ok := c.getFile(arg)
+	if !ok {
+		return
+	}
+	offset := c.rest
+	if offset > obj.GetSize() {
+		c.reply(554, "Invalid restart position")
+		return
+	}
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	transfer, err := limit.Start(c.ctx, c.user, storage.GetStorage(), limit.Download, false)
+	if err != nil {
+		c.reply(450, err.Error())
+		return
+	}
+	defer transfer.Done()
+	var rc io.ReadCloser = io.NopCloser(strings.NewReader(""))
+	if offset < obj.GetSize() {
+		link, _, err := fs.Link(c.ctx, reqPath, model.LinkArgs{IP: remoteIP(c.ctrl)})
+		if err != nil {
+			c.replyErr(err)
+			return
+		}
+		if link.Handle != nil {
+			c.reply(550, "Downloading from this storage is not supported")
+			return
+		}
+		rc, err = common.OpenRange(c.ctx, link, offset, obj.GetSize()-offset)
+		if err != nil {
+			c.replyErr(err)
+			return
+		}
+		rc = transfer.ReadCloser(c.ctx, rc)
+	}
+	defer rc.Close()
+	c.transfer(func(dc net.Conn) error {
+		_, err := io.Copy(dc, rc)
+		return err
+	})
+}
+
+func (c *conn) cmdStor(arg string) {
+	reqPath, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	dir, name := stdpath.Split(reqPath)
+	_, meta, err := c.resolve(stdpath.Dir(c.visible(arg)))
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	if !c.canWrite(meta, reqPath) {
+		c.replyErr(errs.PermissionDenied)
+		return
+	}
+	if c.rest != 0 {
+		c.reply(554, "Resuming uploads is not supported")
+		return
+	}
+	// spool to a temp file, as the storages need the size before uploading
+	tmp, err := os.CreateTemp(conf.Conf.TempDir, "ftp-*")
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	// op.Put removes it too, but it may fail before reaching there
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	c.reply(150, "Opening data connection")
+	dc, err := c.openData()
+	if err != nil {
+		c.reply(425, "Can't open data connection: "+err.Error())
+		return
+	}
+	size, err := io.Copy(tmp, dc)
+	_ = dc.Close()
+	if err != nil {
+		c.reply(426, "Transfer aborted: "+err.Error())
+		return
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		c.replyErr(err)
+		return
+	}
+	err = fs.PutDirectly(c.ctx, dir, &model.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		ReadCloser: tmp,
+		Mimetype:   utils.GetMimeType(name),
+	})
+	if err != nil {
+		c.reply(451, "Failed to upload: "+err.Error())
+		return
+	}
+	c.reply(226, "Transfer complete")
+}
+
+func (c *conn) cmdDele(arg string) {
+	reqPath, _, ok := c.getFile(arg)
+	if !ok {
+		return
+	}
+	if !common.HasPermission(c.user, reqPath, model.PermDelete, c.user.CanRemove()) {
+		c.replyErr(errs.PermissionDenied)
+		return
+	}
+	if err := fs.Remove(c.ctx, reqPath); err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.reply(250, "File removed")
+}
+
+func (c *conn) cmdRmd(arg string) {
+	reqPath, meta, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	if !common.HasPermission(c.user, reqPath, model.PermDelete, c.user.CanRemove()) {
+		c.replyErr(errs.PermissionDenied)
+		return
+	}
+	// RMD only removes empty dirs, and listing fails if it's not a dir
+	objs, err := c.list(reqPath, meta)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	if len(objs) > 0 {
+		c.reply(550, "Directory not empty")
+		return
+	}
+	if err = fs.Remove(c.ctx, reqPath); err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.reply(250, "Directory removed")
+}
+
+func (c *conn) cmdMkd(arg string) {
+	reqPath, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	_, meta, err := c.resolve(stdpath.Dir(c.visible(arg)))
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	if !c.canWrite(meta, reqPath) {
+		c.replyErr(errs.PermissionDenied)
+		return
+	}
+	if err = fs.MakeDir(c.ctx, reqPath); err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.reply(257, quote(c.visible(arg))+" created")
+}
+
+func (c *conn) cmdRnfr(arg string) {
+	reqPath, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	if _, err = fs.Get(c.ctx, reqPath, &fs.GetArgs{}); err != nil {
+		c.replyErr(err)
+		return
+	}
+	c.rnfr = reqPath
+	c.reply(350, "Ready for RNTO")
+}
+
+func (c *conn) cmdRnto(arg string) {
+	if c.rnfr == "" {
+		c.reply(503, "Use RNFR first")
+		return
+	}
+	dst, _, err := c.resolve(arg)
+	if err != nil {
+		c.replyErr(err)
+		return
+	}
+	src := c.rnfr
+	srcDir, srcName := stdpath.Split(src)
+	dstDir, dstName := stdpath.Split(dst)
+	if srcDir == dstDir && srcName == dstName {
+		c.reply(250, "Renamed")
+		return
+	}
+	if srcDir != dstDir {
+		if !common.HasPermission(c.user, src, model.PermRename, c.user.CanMove()) {
+			c.replyErr(errs.PermissionDenied)
+			return
+		}
+		if err = fs.Move(c.ctx, src, dstDir); err != nil {
+			c.replyErr(err)
+			return
+		}
+		src = stdpath.Join(dstDir, srcName)
+	}
+	if srcName != dstName {
+		if !common.HasPermission(c.user, src, model.PermRename, c.user.CanRename()) {
+			c.replyErr(errs.PermissionDenied)
+			return
+		}
+		if err = fs.Rename(c.ctx, src, dstName); err != nil {
+			c.replyErr(err)
+			return
+		}
+	}
+	c.reply(250, "Renamed")
+}
+
+func (c *conn) cmdSize(arg string) {
+	_, obj, ok := c.getFile(arg)
+	if !ok {
+		return
+	}
+	c.reply(213, strconv.FormatInt(obj.GetSize(), 10))
+}
+
+func (c *conn) cmdMdtm(arg string) {
+	_, obj, ok := c.getFile(arg)
+	if !ok {
+		return
+	}
+	c.reply(213, obj.ModTime().UTC().Format(factTimeFormat))
+}
+
+func (c *conn) cmdRest(arg string) {
+	offset, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || offset < 0 {
+		c.reply(501, "Invalid restart position")
+		return
+	}
+	c.rest = offset
+	c.reply(350, "Restarting at "+arg)
+}
+
+func (c *conn) cmdAbor(arg string) {
+	// transfers run in the command loop, so there is nothing to abort
+	c.reply(226, "No transfer to abort")
+}
+
+func quote(p string) string {
+	return `"` + strings.ReplaceAll(p, `"`, `""`) + `"`
+}
+
+func remoteIP(c net.Conn) string {
+	host, _, _ := net.SplitHostPort(c.RemoteAddr().String())
+	return host
+}
@@ -0,0 +1,63 @@
+package ftp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		parse func(string) (string, error)
+		arg   string
+		want  string
+	}{
+		{parsePort, "192,168,1,2,19,137", "192.168.1.2:5001"},
+		{parsePort, "192,168,1,2,19", ""},
+		{parsePort, "192,168,1,256,19,137", ""},
+		{parseEprt, "|1|132.235.1.2|6275|", "132.235.1.2:6275"},
+		{parseEprt, "|2|1080::8:800:200C:417A|5282|", "[1080::8:800:200c:417a]:5282"},
+		{parseEprt, "|3|132.235.1.2|6275|", ""},
+		{parseEprt, "|1|132.235.1.2|0|", ""},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse(tt.arg)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: expect error, got %s", tt.arg, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: expect %s, got %s, %v", tt.arg, tt.want, got, err)
+		}
+	}
+}
+
+func TestParsePortRange(t *testing.T) {
+	min, max, err := ParsePortRange("30000-30100")
+	if err != nil || min != 30000 || max != 30100 {
+		t.Errorf("got %d-%d, %v", min, max, err)
+	}
+	for _, s := range []string{"30000", "30100-30000", "0-10", "1-70000", "a-b"} {
+		if _, _, err := ParsePortRange(s); err == nil {
+			t.Errorf("%s: expect error", s)
+		}
+	}
+}
+
+func TestFormatList(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	file := &model.Object{Name: "a b.txt", Size: 1024, Modified: time.Date(2023, 3, 4, 5, 6, 7, 0, time.UTC)}
+	dir := &model.Object{Name: "dir", IsFolder: true, Modified: time.Date(2021, 12, 25, 0, 0, 0, 0, time.UTC)}
+	if got, want := formatList(file, now), "-rw-r--r-- 1 alist alist         1024 Mar  4 05:06 a b.txt"; got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+	if got, want := formatList(dir, now), "drwxr-xr-x 1 alist alist            0 Dec 25  2021 dir"; got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+	if got, want := formatFacts(file), "type=file;size=1024;modify=20230304050607;"; got != want {
+		t.Errorf("expect %q, got %q", want, got)
+	}
+}
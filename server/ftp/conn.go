@@ -0,0 +1,225 @@
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	idleTimeout = 5 * time.Minute
+	dataTimeout = 30 * time.Second
+)
+
+type conn struct {
+	srv  *Server
+	ctrl net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+
+	username string
+	user     *model.User
+	ctx      context.Context
+	// cwd is the working directory seen by the user
+	cwd string
+	// rnfr is the path of the last RNFR command
+	rnfr string
+	// rest is the offset of the last REST command
+	rest int64
+	// pasv is the listener of passive mode, port is the address of active mode
+	pasv net.Listener
+	port string
+	// protP reports whether the data connections are protected by tls
+	protP bool
+	tls   bool
+}
+
+func newConn(srv *Server, c net.Conn) *conn {
+	_, isTLS := c.(*tls.Conn)
+	return &conn{
+		srv:  srv,
+		ctrl: c,
+		r:    bufio.NewReader(c),
+		w:    bufio.NewWriter(c),
+		cwd:  "/",
+		tls:  isTLS,
+		// implicit tls protects the data connections too
+		protP: isTLS,
+	}
+}
+
+func (c *conn) serve() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("[ftp] panic of %s: %v", c.ctrl.RemoteAddr(), r)
+		}
+		c.closeData()
+		_ = c.ctrl.Close()
+	}()
+	c.reply(220, "Welcome to alist ftp server")
+	for {
+		_ = c.ctrl.SetReadDeadline(time.Now().Add(idleTimeout))
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		name, arg, _ := strings.Cut(line, " ")
+		name = strings.ToUpper(name)
+		if name == "PASS" {
+			log.Debugf("[ftp] %s: PASS ***", c.ctrl.RemoteAddr())
+		} else {
+			log.Debugf("[ftp] %s: %s", c.ctrl.RemoteAddr(), line)
+		}
+		cmd, ok := commands[name]
+		if !ok {
+			c.reply(502, "Command not implemented")
+			continue
+		}
+		if !cmd.open && c.user == nil {
+			c.reply(530, "Please login with USER and PASS")
+			continue
+		}
+		cmd.fn(c, arg)
+		// RNTO and the transfers must follow RNFR and REST directly
+		if name != "RNFR" {
+			c.rnfr = ""
+		}
+		if name != "REST" {
+			c.rest = 0
+		}
+		if name == "QUIT" {
+			return
+		}
+	}
+}
+
+func (c *conn) reply(code int, msg string) {
+	_, _ = fmt.Fprintf(c.w, "%d %s\r\n", code, msg)
+	_ = c.w.Flush()
+}
+
+// replyLines sends a multi-line reply
+func (c *conn) replyLines(code int, first string, lines []string, last string) {
+	_, _ = fmt.Fprintf(c.w, "%d-%s\r\n", code, first)
+	for _, l := range lines {
+		_, _ = fmt.Fprintf(c.w, " %s\r\n", l)
+	}
+	c.reply(code, last)
+}
+
+// replyErr replies the error of a filesystem operation
+func (c *conn) replyErr(err error) {
+	switch {
+	case errs.IsObjectNotFound(err):
+		c.reply(550, "No such file or directory")
+	case errors.Is(errors.Cause(err), errs.PermissionDenied):
+		c.reply(550, "Permission denied")
+	default:
+		c.reply(550, err.Error())
+	}
+}
+
+// visible returns the clean path of arg seen by the user
+func (c *conn) visible(arg string) string {
+	if !strings.HasPrefix(arg, "/") {
+		arg = stdpath.Join(c.cwd, arg)
+	}
+	return stdpath.Clean("/" + arg)
+}
+
+// resolve returns the path of arg in the virtual filesystem and the nearest
+// meta of it, after checking the user can access it
+func (c *conn) resolve(arg string) (string, *model.Meta, error) {
+	reqPath, err := c.user.JoinPath(c.visible(arg))
+	if err != nil {
+		return "", nil, err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return "", nil, err
+	}
+	if !common.CanAccess(c.user, meta, reqPath, "") {
+		return "", nil, errs.PermissionDenied
+	}
+	return reqPath, meta, nil
+}
+
+// list lists the dir with the meta that fs.List needs for the hide rules
+func (c *conn) list(reqPath string, meta *model.Meta) ([]model.Obj, error) {
+	return fs.List(context.WithValue(c.ctx, "meta", meta), reqPath, &fs.ListArgs{})
+}
+
+// canWrite reports whether the user can upload or make dirs at reqPath,
+// meta is the nearest meta of the dir
+func (c *conn) canWrite(meta *model.Meta, reqPath string) bool {
+	dir := stdpath.Dir(reqPath)
+	return common.HasPermission(c.user, reqPath, model.PermWrite, c.user.CanWrite() || common.CanWrite(meta, dir))
+}
+
+func (c *conn) closeData() {
+	if c.pasv != nil {
+		_ = c.pasv.Close()
+		c.pasv = nil
+	}
+	c.port = ""
+}
+
+// openData opens the data connection set by PASV, EPSV, PORT or EPRT
+func (c *conn) openData() (net.Conn, error) {
+	defer c.closeData()
+	var dc net.Conn
+	var err error
+	switch {
+	case c.pasv != nil:
+		if l, ok := c.pasv.(*net.TCPListener); ok {
+			_ = l.SetDeadline(time.Now().Add(dataTimeout))
+		}
+		dc, err = c.pasv.Accept()
+		if err != nil {
+			return nil, err
+		}
+		// refuse connections of others to prevent stealing the data
+		if !sameIP(dc.RemoteAddr(), c.ctrl.RemoteAddr()) {
+			_ = dc.Close()
+			return nil, fmt.Errorf("data connection from %s is refused", dc.RemoteAddr())
+		}
+	case c.port != "":
+		dc, err = net.DialTimeout("tcp", c.port, dataTimeout)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("use PASV or PORT first")
+	}
+	if c.protP {
+		tc := tls.Server(dc, c.srv.TLSConfig)
+		_ = tc.SetDeadline(time.Now().Add(dataTimeout))
+		if err = tc.Handshake(); err != nil {
+			_ = dc.Close()
+			return nil, err
+		}
+		_ = tc.SetDeadline(time.Time{})
+		dc = tc
+	}
+	return dc, nil
+}
+
+func sameIP(a, b net.Addr) bool {
+	ta, ok1 := a.(*net.TCPAddr)
+	tb, ok2 := b.(*net.TCPAddr)
+	return ok1 && ok2 && ta.IP.Equal(tb.IP)
+}
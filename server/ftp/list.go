@@ -0,0 +1,46 @@
+package ftp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+const factTimeFormat = "20060102150405"
+
+func writeList(w io.Writer, objs []model.Obj, now time.Time, format func(model.Obj, time.Time) string) error {
+	bw := bufio.NewWriter(w)
+	for _, obj := range objs {
+		if _, err := bw.WriteString(format(obj, now) + "\r\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// formatList formats the obj like the output of "ls -l", which most clients
+// are able to parse
+func formatList(obj model.Obj, now time.Time) string {
+	mode := "-rw-r--r--"
+	if obj.IsDir() {
+		mode = "drwxr-xr-x"
+	}
+	mod := obj.ModTime()
+	stamp := mod.Format("Jan _2 15:04")
+	if mod.Year() != now.Year() {
+		stamp = mod.Format("Jan _2  2006")
+	}
+	return fmt.Sprintf("%s 1 alist alist %12d %s %s", mode, obj.GetSize(), stamp, obj.GetName())
+}
+
+// formatFacts formats the facts of MLSD and MLST of RFC 3659
+func formatFacts(obj model.Obj) string {
+	typ := "file"
+	if obj.IsDir() {
+		typ = "dir"
+	}
+	return fmt.Sprintf("type=%s;size=%d;modify=%s;", typ, obj.GetSize(), obj.ModTime().UTC().Format(factTimeFormat))
+}
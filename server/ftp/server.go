@@ -0,0 +1,134 @@
+// Package ftp provides a ftp server of the virtual filesystem, it speaks
+// RFC 959 with the extensions of RFC 2389, RFC 2428, RFC 3659 and FTPS of
+// RFC 4217.
+package ftp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+var ErrServerClosed = errors.New("ftp: Server closed")
+
+type Server struct {
+	Addr string
+	// TLSConfig enables AUTH TLS if not nil
+	TLSConfig *tls.Config
+	// ImplicitTLS requires tls from the start of the connection
+	ImplicitTLS bool
+	// MinPassivePort and MaxPassivePort limit the ports listened in passive
+	// mode, random ports are used if they are zero
+	MinPassivePort int
+	MaxPassivePort int
+	// PublicHost is the ip told to clients in passive mode
+	PublicHost string
+	// Auth returns the user of the login
+	Auth func(username, password string) (*model.User, error)
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*conn]struct{}
+	closed   bool
+}
+
+// ParsePortRange parses a port range like "30000-30100"
+func ParsePortRange(s string) (int, int, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	min, err1 := strconv.Atoi(strings.TrimSpace(from))
+	max, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil || min <= 0 || max > 65535 || min > max {
+		return 0, 0, fmt.Errorf("invalid port range: %s", s)
+	}
+	return min, max, nil
+}
+
+func (s *Server) ListenAndServe() error {
+	if s.ImplicitTLS && s.TLSConfig == nil {
+		return errors.New("ftp: implicit tls requires a certificate")
+	}
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	if s.ImplicitTLS {
+		l = tls.NewListener(l, s.TLSConfig)
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.conns = make(map[*conn]struct{})
+	s.mu.Unlock()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		cc := newConn(s, c)
+		s.mu.Lock()
+		s.conns[cc] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			cc.serve()
+			s.mu.Lock()
+			delete(s.conns, cc)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops listening and closes all connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for c := range s.conns {
+		_ = c.ctrl.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+// listenPassive listens on a port for the data connection of passive mode
+func (s *Server) listenPassive() (net.Listener, error) {
+	if s.MinPassivePort == 0 {
+		return net.Listen("tcp", ":0")
+	}
+	n := s.MaxPassivePort - s.MinPassivePort + 1
+	start := rand.Intn(n)
+	var err error
+	for i := 0; i < n; i++ {
+		port := s.MinPassivePort + (start+i)%n
+		var l net.Listener
+		if l, err = net.Listen("tcp", fmt.Sprintf(":%d", port)); err == nil {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("no available passive port: %w", err)
+}
@@ -0,0 +1,67 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListAppPasswords(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	passwords, err := op.GetAppPasswordsByUserID(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, passwords)
+}
+
+type CreateAppPasswordReq struct {
+	Title string `json:"title" binding:"required"`
+	// Code is the 2FA code, or a recovery code, of the users with 2FA
+	Code string `json:"code"`
+}
+
+// CreateAppPassword generates an app password of the current user for
+// WebDAV, FTP and SFTP clients, the password is only shown once.
+// App passwords skip 2FA, so the users with 2FA confirm it with a code.
+func CreateAppPassword(c *gin.Context) {
+	var req CreateAppPasswordReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "Guest user can not create app password", 403)
+		return
+	}
+	if op.Has2FA(user) && !op.Validate2FACode(user, req.Code) {
+		common.ErrorStrResp(c, "Invalid 2FA code", 400)
+		return
+	}
+	p, password, err := op.CreateAppPassword(user.ID, req.Title)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"app_password": p, "password": password})
+}
+
+func DeleteAppPassword(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := op.DeleteAppPassword(user.ID, uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,100 @@
+package handles
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditFilter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := op.GetAuditLogs(req.AuditFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+// ExportAuditLogs streams the filtered audit logs as csv or json, which is
+// decided by the format in the query
+func ExportAuditLogs(c *gin.Context) {
+	var req model.AuditFilter
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		common.ErrorStrResp(c, "unsupported format: "+format, 400)
+		return
+	}
+	name := fmt.Sprintf("audit-%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	var err error
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(200)
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write([]string{"time", "username", "ip", "action", "target", "success", "detail"})
+		err = op.IterAuditLogs(req, func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				if err := w.Write([]string{l.Time.Format(time.RFC3339), l.Username, l.IP, l.Action,
+					l.Target, strconv.FormatBool(l.Success), l.Detail}); err != nil {
+					return err
+				}
+			}
+			w.Flush()
+			return w.Error()
+		})
+		w.Flush()
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Status(200)
+		_, _ = c.Writer.WriteString("[")
+		first := true
+		err = op.IterAuditLogs(req, func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				data, err := utils.Json.Marshal(l)
+				if err != nil {
+					return err
+				}
+				if !first {
+					_, _ = c.Writer.WriteString(",")
+				}
+				first = false
+				if _, err := c.Writer.Write(data); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		_, _ = c.Writer.WriteString("]")
+	}
+	// the response has started, an error can only break the export
+	if err != nil {
+		log.Errorf("failed export audit logs: %+v", err)
+		c.Abort()
+	}
+}
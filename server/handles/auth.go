@@ -3,10 +3,11 @@ package handles
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"image/png"
 	"time"
 
-	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/cluster"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/server/common"
@@ -14,7 +15,9 @@ import (
 	"github.com/pquerna/otp/totp"
 )
 
-var loginCache = cache.NewMemCache[int]()
+// loginCache counts the failed logins by the ip, shared by the instances of
+// the cluster
+var loginCache = cluster.NewStore[int]("login")
 var (
 	defaultDuration = time.Minute * 5
 	defaultTimes    = 5
@@ -44,20 +47,26 @@ func Login(c *gin.Context) {
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		common.AuditAs(c, nil, req.Username, common.AuditLogin, "", err, "password")
+		loginCache.Set(ip, count+1, 0)
 		return
 	}
 	// validate password
 	if err := user.ValidatePassword(req.Password); err != nil {
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		common.AuditAs(c, user, "", common.AuditLogin, "", err, "password")
+		loginCache.Set(ip, count+1, 0)
 		return
 	}
-	// check 2FA
-	if user.OtpSecret != "" {
-		if !totp.Validate(req.OtpCode, user.OtpSecret) {
+	// check 2FA, a recovery code can be used in place of the TOTP code
+	if op.Has2FA(user) {
+		if !op.Validate2FACode(user, req.OtpCode) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
-			loginCache.Set(ip, count+1)
+			// the code is asked for by the first attempt without it
+			if req.OtpCode != "" {
+				common.AuditAs(c, user, "", common.AuditLogin, "", errors.New("invalid 2FA code"), "password")
+			}
+			loginCache.Set(ip, count+1, 0)
 			return
 		}
 	}
@@ -68,12 +77,18 @@ func Login(c *gin.Context) {
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	common.AuditAs(c, user, "", common.AuditLogin, "", nil, "password")
 	loginCache.Del(ip)
 }
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp      bool `json:"otp"`
+	WebAuthn bool `json:"webauthn"`
+	// QuotaUsed is the bytes uploaded on all storages, the usage of each
+	// of them is in QuotaUsages
+	QuotaUsed   int64              `json:"quota_used"`
+	QuotaUsages []model.QuotaUsage `json:"quota_usages"`
 }
 
 // CurrentUser get current user by token
@@ -87,6 +102,18 @@ func CurrentUser(c *gin.Context) {
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
+	if !user.IsGuest() {
+		userResp.WebAuthn = op.HasWebAuthn(user)
+		usages, err := op.GetQuotaUsagesByUserID(user.ID)
+		if err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		for _, u := range usages {
+			userResp.QuotaUsed += u.Used
+		}
+		userResp.QuotaUsages = usages
+	}
 	common.SuccessResp(c, userResp)
 }
 
@@ -159,9 +186,43 @@ func Verify2FA(c *gin.Context) {
 		return
 	}
 	user.OtpSecret = req.Secret
+	// keep the recovery codes made on registering webauthn
+	var codes []string
+	if user.RecoveryCodes == "" {
+		codes = op.SetRecoveryCodes(user)
+	}
 	if err := op.UpdateUser(user); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
-		common.SuccessResp(c)
+		common.SuccessResp(c, gin.H{"recovery_codes": codes})
+	}
+}
+
+type RecoveryCodesReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of the current user,
+// either the TOTP code or an unused recovery code is required
+func GenerateRecoveryCodes(c *gin.Context) {
+	var req RecoveryCodesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !op.Has2FA(user) {
+		common.ErrorStrResp(c, "2FA is not enabled", 400)
+		return
+	}
+	if !op.Validate2FACode(user, req.Code) {
+		common.ErrorStrResp(c, "Invalid 2FA code", 400)
+		return
+	}
+	codes, err := op.GenerateRecoveryCodes(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
+	common.SuccessResp(c, gin.H{"recovery_codes": codes})
 }
@@ -0,0 +1,50 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/bundle"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ExportConfigReq struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ExportConfig exports the storages, users, metas and settings as a bundle,
+// the secrets in it are encrypted with the password
+func ExportConfig(c *gin.Context) {
+	var req ExportConfigReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	b, err := bundle.Export(req.Password)
+	common.Audit(c, common.AuditConfigExport, "", err)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, b)
+}
+
+type ImportConfigReq struct {
+	Password string         `json:"password" binding:"required"`
+	Bundle   *bundle.Bundle `json:"bundle" binding:"required"`
+}
+
+// ImportConfig imports the bundle exported by ExportConfig, the items that
+// failed to import are returned in the errors of the result
+func ImportConfig(c *gin.Context) {
+	var req ImportConfigReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	res, err := bundle.Import(c, req.Bundle, req.Password)
+	common.Audit(c, common.AuditConfigImport, "", err)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, res)
+}
@@ -26,7 +26,12 @@ func Down(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if common.ShouldProxy(storage, filename) {
+	proxy := common.ShouldProxy(storage, filename)
+	// redirect instead if the acl rules deny proxying through alist
+	if proxy && !storage.Config().MustProxy() && !common.HasPermission(nil, rawPath, model.PermProxy, true) {
+		proxy = false
+	}
+	if proxy {
 		Proxy(c)
 		return
 	} else {
@@ -59,7 +64,18 @@ func Down(c *gin.Context) {
 			}
 		}
 		c.Redirect(302, link.URL)
+		auditDownload(c, rawPath, nil)
+	}
+}
+
+// auditDownload records the download, the requests of the ranges not from
+// the start are skipped, since players request a file many times
+func auditDownload(c *gin.Context, rawPath string, err error) {
+	r := c.GetHeader("Range")
+	if r != "" && !strings.HasPrefix(r, "bytes=0-") {
+		return
 	}
+	common.Audit(c, common.AuditFileDownload, rawPath, err)
 }
 
 func Proxy(c *gin.Context) {
@@ -70,7 +86,7 @@ func Proxy(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	if canProxy(storage, filename) {
+	if canProxy(storage, filename) && common.HasPermission(nil, rawPath, model.PermProxy, true) {
 		downProxyUrl := storage.GetStorage().DownProxyUrl
 		if downProxyUrl != "" {
 			_, ok := c.GetQuery("d")
@@ -83,6 +99,14 @@ func Proxy(c *gin.Context) {
 				return
 			}
 		}
+		// the user is known only for the downloads of shares
+		user, _ := c.Value("user").(*model.User)
+		w, transfer, err := common.LimitProxy(c, c.Writer, user, storage.GetStorage())
+		if err != nil {
+			common.ErrorResp(c, err, 429)
+			return
+		}
+		defer transfer.Done()
 		link, file, err := fs.Link(c, rawPath, model.LinkArgs{
 			Header: c.Request.Header,
 			Type:   c.Query("type"),
@@ -100,7 +124,8 @@ func Proxy(c *gin.Context) {
 				return
 			}
 		}
-		err = common.Proxy(c.Writer, c.Request, link, file)
+		err = common.Proxy(w, c.Request, link, file)
+		auditDownload(c, rawPath, err)
 		if err != nil {
 			common.ErrorResp(c, err, 500, true)
 			return
@@ -0,0 +1,305 @@
+package handles
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// skippedName is the entry that lists the files left out of an archive
+const skippedName = ".alist-skipped.txt"
+
+type FsArchiveReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Format is zip or tar
+	Format string `json:"format" form:"format"`
+}
+
+type FsArchiveResp struct {
+	URL string `json:"url"`
+}
+
+func archiveSignData(uid uint, path string) string {
+	return fmt.Sprintf("archive:%d:%s", uid, path)
+}
+
+// FsArchive checks the user can read the folder and returns a signed url
+// that streams it as an archive, so that browsers can download it directly
+func FsArchive(c *gin.Context) {
+	var req FsArchiveReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "zip"
+	}
+	if req.Format != "zip" && req.Format != "tar" {
+		common.ErrorStrResp(c, "format must be zip or tar", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !obj.IsDir() {
+		common.ErrorResp(c, errs.NotFolder, 400)
+		return
+	}
+	query := url.Values{}
+	query.Set("format", req.Format)
+	query.Set("uid", strconv.Itoa(int(user.ID)))
+	query.Set("sign", sign.Sign(archiveSignData(user.ID, reqPath)))
+	common.SuccessResp(c, FsArchiveResp{
+		URL: fmt.Sprintf("%s/ar%s?%s", common.GetApiUrl(c.Request), utils.EncodePath(reqPath, true), query.Encode()),
+	})
+}
+
+// archiveWriter writes the entries of an archive
+type archiveWriter interface {
+	Dir(name string, obj model.Obj) error
+	File(name string, obj model.Obj, r io.Reader) error
+	Close() error
+}
+
+type zipWriter struct {
+	w *zip.Writer
+}
+
+func (z zipWriter) Dir(name string, obj model.Obj) error {
+	_, err := z.w.CreateHeader(&zip.FileHeader{Name: name + "/", Modified: obj.ModTime()})
+	return err
+}
+
+// File stores the file without compression, the crc and size are written
+// after the data so that nothing needs to be buffered
+func (z zipWriter) File(name string, obj model.Obj, r io.Reader) error {
+	w, err := z.w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: obj.ModTime()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (z zipWriter) Close() error {
+	return z.w.Close()
+}
+
+type tarWriter struct {
+	w *tar.Writer
+}
+
+func (t tarWriter) Dir(name string, obj model.Obj) error {
+	return t.w.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name + "/", Mode: 0755, ModTime: obj.ModTime()})
+}
+
+// File writes the file with the size reported by the storage, since tar
+// needs it before the data
+func (t tarWriter) File(name string, obj model.Obj, r io.Reader) error {
+	err := t.w.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: obj.GetSize(), ModTime: obj.ModTime()})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(t.w, r, obj.GetSize())
+	return err
+}
+
+func (t tarWriter) Close() error {
+	return t.w.Close()
+}
+
+type archiver struct {
+	ctx     context.Context
+	user    *model.User
+	meta    *model.Meta
+	w       archiveWriter
+	skipped []string
+	walked  op.WalkedDirs
+}
+
+// ArchiveDown streams the folder of the signed url as a zip or tar
+func ArchiveDown(c *gin.Context) {
+	rawPath := utils.FixAndCleanPath(c.Param("path"))
+	uid, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = sign.Verify(archiveSignData(uint(uid), rawPath), c.Query("sign")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(uint(uid))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "the user is disabled", 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(rawPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	ctx := context.WithValue(c, "user", user)
+	var storage *model.Storage
+	if s, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{}); err == nil {
+		storage = s.GetStorage()
+	}
+	w, transfer, err := common.LimitProxy(ctx, c.Writer, user, storage)
+	if err != nil {
+		common.ErrorResp(c, err, 429)
+		return
+	}
+	defer transfer.Done()
+
+	name := stdpath.Base(rawPath)
+	if rawPath == "/" {
+		name = "root"
+	}
+	a := &archiver{ctx: ctx, user: user, meta: meta, walked: op.WalkedDirs{}}
+	if c.Query("format") == "tar" {
+		name += ".tar"
+		c.Header("Content-Type", "application/x-tar")
+		a.w = tarWriter{w: tar.NewWriter(w)}
+	} else {
+		name += ".zip"
+		c.Header("Content-Type", "application/zip")
+		a.w = zipWriter{w: zip.NewWriter(w)}
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, name, url.PathEscape(name)))
+	c.Status(200)
+	// the response has started, an error can only break the archive
+	if err = a.walk(rawPath, ""); err == nil {
+		err = a.writeSkipped()
+	}
+	if err == nil {
+		err = a.w.Close()
+	}
+	common.AuditAs(c, user, "", common.AuditFileDownload, rawPath, err, "archive")
+	if err != nil {
+		log.Errorf("failed to archive %s: %+v", rawPath, err)
+		c.Abort()
+	}
+}
+
+// walk lists the dir lazily and writes the objs below it with the prefix
+func (a *archiver) walk(dir, prefix string) error {
+	// the folder reached again through symlinks is left empty
+	if !a.walked.Visit(dir) {
+		return nil
+	}
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return err
+	}
+	// the password of the root is checked when signing, other protected
+	// folders below it are skipped
+	rootMeta := meta != nil && a.meta != nil && meta.ID == a.meta.ID
+	if !rootMeta && !common.CanAccess(a.user, meta, dir, "") {
+		a.skip(prefix + "/")
+		return nil
+	}
+	objs, err := fs.List(context.WithValue(a.ctx, "meta", meta), dir, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		if utils.IsCanceled(a.ctx) {
+			return err
+		}
+		a.skip(prefix + "/")
+		return nil
+	}
+	for _, obj := range objs {
+		if utils.IsCanceled(a.ctx) {
+			return a.ctx.Err()
+		}
+		objPath := stdpath.Join(dir, obj.GetName())
+		name := strings.TrimPrefix(stdpath.Join(prefix, obj.GetName()), "/")
+		if obj.IsDir() {
+			if err = a.w.Dir(name, obj); err != nil {
+				return err
+			}
+			if err = a.walk(objPath, name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = a.file(objPath, name, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// file writes the file, it's skipped if it can't be proxied or opened
+func (a *archiver) file(objPath, name string, obj model.Obj) error {
+	if !common.HasPermission(a.user, objPath, model.PermProxy, true) {
+		a.skip(name)
+		return nil
+	}
+	link, _, err := fs.Link(a.ctx, objPath, model.LinkArgs{})
+	if err != nil {
+		log.Debugf("failed to link %s for archive: %+v", objPath, err)
+		a.skip(name)
+		return nil
+	}
+	rc, err := common.OpenLink(a.ctx, link)
+	if err != nil {
+		log.Debugf("failed to open %s for archive: %+v", objPath, err)
+		a.skip(name)
+		return nil
+	}
+	defer rc.Close()
+	return a.w.File(name, obj, rc)
+}
+
+func (a *archiver) skip(name string) {
+	a.skipped = append(a.skipped, name)
+}
+
+func (a *archiver) writeSkipped() error {
+	if len(a.skipped) == 0 {
+		return nil
+	}
+	data := strings.Join(a.skipped, "\n") + "\n"
+	obj := &model.Object{Name: skippedName, Size: int64(len(data)), Modified: time.Now()}
+	return a.w.File(skippedName, obj, strings.NewReader(data))
+}
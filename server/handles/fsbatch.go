@@ -0,0 +1,178 @@
+package handles
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchPaths is the max paths of a batch
+const maxBatchPaths = 10000
+
+type BatchReq struct {
+	Op     string   `json:"op" binding:"required"`
+	Paths  []string `json:"paths"`
+	DstDir string   `json:"dst_dir"`
+}
+
+type BatchResp struct {
+	TaskInfo
+	Op       string           `json:"op"`
+	Progress fs.BatchProgress `json:"progress"`
+}
+
+func toBatchResp(b *fs.Batch) BatchResp {
+	return BatchResp{
+		TaskInfo: getTaskInfo(b.Task(), uint64K2Str),
+		Op:       b.Op,
+		Progress: b.Progress(),
+	}
+}
+
+// FsBatch submits a batch task to copy, move or remove the paths
+func FsBatch(c *gin.Context) {
+	var req BatchReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) == 0 {
+		common.ErrorStrResp(c, "Empty paths", 400)
+		return
+	}
+	if len(req.Paths) > maxBatchPaths {
+		common.ErrorStrResp(c, "Too many paths, the max is "+strconv.Itoa(maxBatchPaths), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	var dstDir string
+	var err error
+	switch req.Op {
+	case fs.BatchCopy, fs.BatchMove:
+		dstDir, err = user.JoinPath(req.DstDir)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		fallback := user.CanCopy()
+		if req.Op == fs.BatchMove {
+			fallback = user.CanMove()
+		}
+		if !common.HasPermission(user, dstDir, model.PermWrite, fallback) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+	case fs.BatchRemove:
+	default:
+		common.ErrorStrResp(c, "Unknown op: "+req.Op, 400)
+		return
+	}
+	paths := make([]string, 0, len(req.Paths))
+	for _, p := range req.Paths {
+		reqPath, err := user.JoinPath(p)
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		var ok bool
+		switch req.Op {
+		case fs.BatchCopy:
+			ok = common.HasPermission(user, reqPath, model.PermRead, true)
+		case fs.BatchMove:
+			ok = common.HasPermission(user, reqPath, model.PermRename, user.CanMove())
+		case fs.BatchRemove:
+			ok = common.HasPermission(user, reqPath, model.PermDelete, user.CanRemove())
+		}
+		if !ok {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		paths = append(paths, reqPath)
+	}
+	b, err := fs.SubmitBatch(c, req.Op, paths, dstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	// the items are done in background, so only the submission is recorded
+	action := map[string]string{
+		fs.BatchCopy:   common.AuditFileCopy,
+		fs.BatchMove:   common.AuditFileMove,
+		fs.BatchRemove: common.AuditFileRemove,
+	}[req.Op]
+	detail := "batch " + strconv.FormatUint(b.ID, 10)
+	if dstDir != "" {
+		detail += " to " + dstDir
+	}
+	for _, p := range paths {
+		common.Audit(c, action, p, nil, detail)
+	}
+	common.SuccessResp(c, toBatchResp(b))
+}
+
+// getBatch returns the batch of the id in query, only admin can access the
+// batches of others
+func getBatch(c *gin.Context) (*fs.Batch, bool) {
+	id, err := strconv.ParseUint(c.Query("id"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	b, ok := fs.GetBatch(id)
+	user := c.MustGet("user").(*model.User)
+	if !ok || (!user.IsAdmin() && b.UserID != user.ID) {
+		common.ErrorStrResp(c, "batch not found", 404)
+		return nil, false
+	}
+	return b, true
+}
+
+func FsBatchGet(c *gin.Context) {
+	b, ok := getBatch(c)
+	if !ok {
+		return
+	}
+	common.SuccessResp(c, toBatchResp(b))
+}
+
+// FsBatchStream streams the progress of the batch as server-sent events
+// every second until the batch is done
+func FsBatchStream(c *gin.Context) {
+	b, ok := getBatch(c)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	c.Header("Cache-Control", "no-cache")
+	c.Stream(func(w io.Writer) bool {
+		c.SSEvent("progress", toBatchResp(b))
+		if b.Task().Done() {
+			return false
+		}
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+func FsBatchCancel(c *gin.Context) {
+	b, ok := getBatch(c)
+	if !ok {
+		return
+	}
+	if err := fs.BatchTaskManager.Cancel(b.ID); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
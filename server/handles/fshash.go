@@ -0,0 +1,162 @@
+package handles
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/limit"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/singleflight"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// computed hashes are cached by the path, size and modified time, so that
+// the same file is not downloaded again until it changes
+var (
+	hashCache = cache.NewMemCache(cache.WithShards[map[string]string](16))
+	hashG     singleflight.Group[map[string]string]
+)
+
+type FsHashReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+	// Types are the hash types wanted, all types if empty
+	Types []string `json:"types" form:"types"`
+}
+
+type FsHashResp struct {
+	Hashes map[string]string `json:"hashes"`
+	// Computed are the types not reported by the storage, which are computed
+	// by downloading the file
+	Computed []string `json:"computed"`
+}
+
+// FsHash returns the hashes of the file, the missing ones are computed by
+// streaming the file through the server
+func FsHash(c *gin.Context) {
+	var req FsHashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Types) == 0 {
+		for typ := range model.HashNews {
+			req.Types = append(req.Types, typ)
+		}
+		sort.Strings(req.Types)
+	}
+	for _, typ := range req.Types {
+		if _, ok := model.HashNews[typ]; !ok {
+			common.ErrorStrResp(c, "unknown hash type: "+typ, 400)
+			return
+		}
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorResp(c, errs.NotFile, 400)
+		return
+	}
+	resp := FsHashResp{Hashes: map[string]string{}, Computed: []string{}}
+	reported := model.GetHashes(obj)
+	var missing []string
+	for _, typ := range req.Types {
+		if v := reported[typ]; v != "" {
+			resp.Hashes[typ] = strings.ToLower(v)
+		} else {
+			missing = append(missing, typ)
+		}
+	}
+	if len(missing) > 0 {
+		computed, err := computeHashes(c, user, reqPath, obj)
+		if err != nil {
+			if errors.Is(err, errs.TooManyTransfers) {
+				common.ErrorResp(c, err, 429)
+				return
+			}
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		for _, typ := range missing {
+			resp.Hashes[typ] = computed[typ]
+		}
+		resp.Computed = missing
+	}
+	common.SuccessResp(c, resp)
+}
+
+// computeHashes computes all types of hashes at once, since reading the
+// file costs much more than hashing it
+func computeHashes(c *gin.Context, user *model.User, reqPath string, obj model.Obj) (map[string]string, error) {
+	key := utils.GetMD5Encode(fmt.Sprintf("%s-%d-%d", reqPath, obj.GetSize(), obj.ModTime().Unix()))
+	if hashes, ok := hashCache.Get(key); ok {
+		return hashes, nil
+	}
+	hashes, err, _ := hashG.Do(key, func() (map[string]string, error) {
+		storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+		if err != nil {
+			return nil, err
+		}
+		transfer, err := limit.Start(c, user, storage.GetStorage(), limit.Download, false)
+		if err != nil {
+			return nil, err
+		}
+		defer transfer.Done()
+		link, _, err := fs.Link(c, reqPath, model.LinkArgs{})
+		if err != nil {
+			return nil, err
+		}
+		rc, err := common.OpenLink(c, link)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		hs := make(map[string]hash.Hash, len(model.HashNews))
+		ws := make([]io.Writer, 0, len(model.HashNews))
+		for typ, n := range model.HashNews {
+			hs[typ] = n()
+			ws = append(ws, hs[typ])
+		}
+		if _, err = io.Copy(io.MultiWriter(ws...), transfer.Reader(c, rc)); err != nil {
+			return nil, err
+		}
+		hashes := make(map[string]string, len(hs))
+		for typ, h := range hs {
+			hashes[typ] = hex.EncodeToString(h.Sum(nil))
+		}
+		hashCache.Set(key, hashes, cache.WithEx[map[string]string](24*time.Hour))
+		return hashes, nil
+	})
+	return hashes, err
+}
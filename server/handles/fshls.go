@@ -0,0 +1,138 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/hls"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsHlsReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+type FsHlsResp struct {
+	// URL returns the m3u8 of the video
+	URL      string  `json:"url"`
+	Duration float64 `json:"duration"`
+	Segments int     `json:"segments"`
+}
+
+func hlsSignData(uid uint, path string) string {
+	return fmt.Sprintf("hls:%d:%s", uid, path)
+}
+
+// hlsURL signs the url of the playlist, and of the segment i if it's not
+// negative
+func hlsURL(c *gin.Context, user *model.User, reqPath string, i int) string {
+	query := url.Values{}
+	query.Set("uid", strconv.Itoa(int(user.ID)))
+	query.Set("sign", sign.Sign(hlsSignData(user.ID, reqPath)))
+	if i >= 0 {
+		query.Set("segment", strconv.Itoa(i))
+	}
+	return fmt.Sprintf("%s/hls%s?%s", common.GetApiUrl(c.Request), utils.EncodePath(reqPath, true), query.Encode())
+}
+
+func FsHls(c *gin.Context) {
+	var req FsHlsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if !hls.Enabled() {
+		common.ErrorStrResp(c, "hls transcoding is disabled", 403)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	v, err := hls.Open(c, reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, FsHlsResp{
+		URL:      hlsURL(c, user, reqPath, -1),
+		Duration: v.Duration,
+		Segments: v.Segments(),
+	})
+}
+
+// HlsDown serves the playlist of the signed url, or the segment of it with
+// the segment query
+func HlsDown(c *gin.Context) {
+	rawPath := utils.FixAndCleanPath(c.Param("path"))
+	uid, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = sign.Verify(hlsSignData(uint(uid), rawPath), c.Query("sign")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if !hls.Enabled() {
+		common.ErrorStrResp(c, "hls transcoding is disabled", 403)
+		return
+	}
+	user, err := op.GetUserById(uint(uid))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "the user is disabled", 403)
+		return
+	}
+	ctx := context.WithValue(c, "user", user)
+	v, err := hls.Open(ctx, rawPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if s := c.Query("segment"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		name, err := hls.Segment(ctx, user.ID, v, i)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		c.Header("Content-Type", "video/mp2t")
+		c.Header("Cache-Control", "private, max-age=604800")
+		c.File(name)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(200, "application/vnd.apple.mpegurl", []byte(v.Playlist(func(i int) string {
+		return hlsURL(c, user, rawPath, i)
+	})))
+}
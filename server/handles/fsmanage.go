@@ -35,20 +35,20 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !user.CanWrite() {
-		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
-		if err != nil {
-			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
-				common.ErrorResp(c, err, 500, true)
-				return
-			}
-		}
-		if !common.CanWrite(meta, reqPath) {
-			common.ErrorResp(c, errs.PermissionDenied, 403)
+	meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
 			return
 		}
 	}
-	if err := fs.MakeDir(c, reqPath); err != nil {
+	if !common.HasPermission(user, reqPath, model.PermWrite, user.CanWrite() || common.CanWrite(meta, reqPath)) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	err = fs.MakeDir(c, reqPath)
+	common.Audit(c, common.AuditFileMkdir, reqPath, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -72,10 +72,6 @@ func FsMove(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -86,8 +82,17 @@ func FsMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, srcDir, model.PermRename, user.CanMove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(user, dstDir, model.PermWrite, user.CanMove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	for i, name := range req.Names {
 		err := fs.Move(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		common.Audit(c, common.AuditFileMove, stdpath.Join(srcDir, name), err, "to "+dstDir)
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -109,10 +114,6 @@ func FsRecursiveMove(c *gin.Context) {
 	}
 
 	user := c.MustGet("user").(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -123,6 +124,14 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, srcDir, model.PermRename, user.CanMove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.HasPermission(user, dstDir, model.PermWrite, user.CanMove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -173,6 +182,7 @@ func FsRecursiveMove(c *gin.Context) {
 
 			// move
 			err := fs.Move(c, movingFileName, dstDir, movingFiles.IsEmpty())
+			common.Audit(c, common.AuditFileMove, movingFileName, err, "to "+dstDir)
 			if err != nil {
 				common.ErrorResp(c, err, 500)
 				return
@@ -195,10 +205,6 @@ func FsCopy(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanCopy() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -209,9 +215,14 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, dstDir, model.PermWrite, user.CanCopy()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	var addedTask []string
 	for i, name := range req.Names {
 		ok, err := fs.Copy(c, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		common.Audit(c, common.AuditFileCopy, stdpath.Join(srcDir, name), err, "to "+dstDir)
 		if ok {
 			addedTask = append(addedTask, name)
 		}
@@ -239,16 +250,18 @@ func FsRename(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if err := fs.Rename(c, reqPath, req.Name); err != nil {
+	if !common.HasPermission(user, reqPath, model.PermRename, user.CanRename()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	err = fs.Rename(c, reqPath, req.Name)
+	common.Audit(c, common.AuditFileRename, reqPath, err, "to "+req.Name)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
@@ -268,16 +281,16 @@ func FsRegexRename(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 
 	reqPath, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, reqPath, model.PermRename, user.CanRename()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -305,7 +318,9 @@ func FsRegexRename(c *gin.Context) {
 		if srcRegexp.MatchString(file.GetName()) {
 			filePath := fmt.Sprintf("%s/%s", reqPath, file.GetName())
 			newFileName := srcRegexp.ReplaceAllString(file.GetName(), req.NewNameRegex)
-			if err := fs.Rename(c, filePath, newFileName); err != nil {
+			err := fs.Rename(c, filePath, newFileName)
+			common.Audit(c, common.AuditFileRename, filePath, err, "to "+newFileName)
+			if err != nil {
 				common.ErrorResp(c, err, 500)
 				return
 			}
@@ -332,17 +347,18 @@ func FsRemove(c *gin.Context) {
 		return
 	}
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqDir, err := user.JoinPath(req.Dir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, reqDir, model.PermDelete, user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	for _, name := range req.Names {
 		err := fs.Remove(c, stdpath.Join(reqDir, name))
+		common.Audit(c, common.AuditFileRemove, stdpath.Join(reqDir, name), err)
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -364,15 +380,15 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 	}
 
 	user := c.MustGet("user").(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, srcDir, model.PermDelete, user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -423,6 +439,7 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 		if len(subFiles) == 0 {
 			// remove empty directory
 			err = fs.Remove(c, removingFilePath)
+			common.Audit(c, common.AuditFileRemove, removingFilePath, err)
 			removedFiles[removingFilePath] = true
 			if err != nil {
 				common.ErrorResp(c, err, 500)
@@ -0,0 +1,182 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/preview"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsPreviewReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+type FsPreviewResp struct {
+	*preview.Preview
+	// URL returns the pdf of pdf and office files, and the page of it with
+	// the page query starting from 1
+	URL string `json:"url,omitempty"`
+}
+
+const (
+	// the source url is fetched by the office converter right away
+	previewSourceExpire = 10 * time.Minute
+	// the page url is kept by the viewer while the file is open
+	previewPageExpire = 2 * time.Hour
+)
+
+func previewSignData(uid uint, path string) string {
+	return fmt.Sprintf("preview:%d:%s", uid, path)
+}
+
+// previewURL signs the url of the preview, the source query makes it
+// return the file itself for the office converter
+func previewURL(c *gin.Context, user *model.User, reqPath string, source bool) string {
+	query := url.Values{}
+	query.Set("uid", strconv.Itoa(int(user.ID)))
+	if source {
+		query.Set("sign", sign.WithDuration(previewSignData(user.ID, reqPath), previewSourceExpire))
+		query.Set("source", "true")
+	} else {
+		query.Set("sign", sign.WithDuration(previewSignData(user.ID, reqPath), previewPageExpire))
+	}
+	return fmt.Sprintf("%s/pv%s?%s", common.GetApiUrl(c.Request), utils.EncodePath(reqPath, true), query.Encode())
+}
+
+func FsPreview(c *gin.Context) {
+	var req FsPreviewReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	p, err := preview.Get(c, reqPath, previewURL(c, user, reqPath, true))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	resp := FsPreviewResp{Preview: p}
+	if p.Pages > 0 {
+		resp.URL = previewURL(c, user, reqPath, false)
+	}
+	common.SuccessResp(c, resp)
+}
+
+// PreviewDown serves the pdf or the rendered page of the signed url, or the
+// file itself to the office converter
+func PreviewDown(c *gin.Context) {
+	rawPath := utils.FixAndCleanPath(c.Param("path"))
+	uid, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = sign.Verify(previewSignData(uint(uid), rawPath), c.Query("sign")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(uint(uid))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "the user is disabled", 403)
+		return
+	}
+	// the base path of the user may be changed after the url is signed
+	if !utils.IsSubPath(user.BasePath, rawPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	ctx := context.WithValue(c, "user", user)
+	if c.Query("source") == "true" {
+		previewSource(c, ctx, user, rawPath)
+		return
+	}
+	sourceURL := previewURL(c, user, rawPath, true)
+	if p := c.Query("page"); p != "" {
+		page, err := strconv.Atoi(p)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		data, etag, err := preview.Page(ctx, rawPath, page, sourceURL)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		etag = `"` + etag + `"`
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "private, max-age=604800")
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(304)
+			return
+		}
+		c.Data(200, "image/png", data)
+		return
+	}
+	pdf, err := preview.PDF(ctx, rawPath, sourceURL)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Header("Content-Type", "application/pdf")
+	c.File(pdf)
+}
+
+// previewSource proxies the file itself like the temporary link does
+func previewSource(c *gin.Context, ctx context.Context, user *model.User, rawPath string) {
+	storage, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !canProxy(storage, stdpath.Base(rawPath)) || !common.HasPermission(user, rawPath, model.PermProxy, true) {
+		common.ErrorStrResp(c, "proxy not allowed", 403)
+		return
+	}
+	w, transfer, err := common.LimitProxy(ctx, c.Writer, user, storage.GetStorage())
+	if err != nil {
+		common.ErrorResp(c, err, 429)
+		return
+	}
+	defer transfer.Done()
+	link, obj, err := fs.Link(ctx, rawPath, model.LinkArgs{Header: c.Request.Header})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err = common.Proxy(w, c.Request, link, obj); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/conf"
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/media"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/setting"
@@ -17,6 +18,7 @@ import (
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type ListReq struct {
@@ -24,6 +26,10 @@ type ListReq struct {
 	Path     string `json:"path" form:"path"`
 	Password string `json:"password" form:"password"`
 	Refresh  bool   `json:"refresh"`
+	// OrderBy sorts the objs before the pagination, taken_at sorts by the
+	// capture date of the media infos
+	OrderBy        string `json:"order_by" form:"order_by"`
+	OrderDirection string `json:"order_direction" form:"order_direction"`
 }
 
 type DirReq struct {
@@ -40,6 +46,10 @@ type ObjResp struct {
 	Sign     string    `json:"sign"`
 	Thumb    string    `json:"thumb"`
 	Type     int       `json:"type"`
+	// Hashes are the checksums reported by the storage
+	Hashes map[string]string `json:"hashes,omitempty"`
+	// Media is the info of the images and the videos if it has been read
+	Media *model.MediaInfo `json:"media,omitempty"`
 }
 
 type FsListResp struct {
@@ -75,7 +85,8 @@ func FsList(c *gin.Context) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
-	if !user.CanWrite() && !common.CanWrite(meta, reqPath) && req.Refresh {
+	write := common.HasPermission(user, reqPath, model.PermWrite, user.CanWrite() || common.CanWrite(meta, reqPath))
+	if !write && req.Refresh {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
@@ -84,17 +95,33 @@ func FsList(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	var infos map[string]*model.MediaInfo
+	if media.Enabled() {
+		infos = media.Lookup(reqPath, objs)
+	}
+	if req.OrderBy == "taken_at" {
+		// the listed objs may be cached, sort a copy
+		objs = append([]model.Obj(nil), objs...)
+		media.SortByTakenAt(objs, infos, req.OrderDirection)
+	} else if req.OrderBy != "" {
+		objs = append([]model.Obj(nil), objs...)
+		model.SortFiles(objs, req.OrderBy, req.OrderDirection)
+	}
 	total, objs := pagination(objs, &req.PageReq)
 	provider := "unknown"
 	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	if err == nil {
 		provider = storage.GetStorage().Driver
 	}
+	content := toObjsResp(objs, reqPath, isEncrypt(meta, reqPath))
+	for i := range content {
+		content[i].Media = infos[content[i].Name]
+	}
 	common.SuccessResp(c, FsListResp{
-		Content:  toObjsResp(objs, reqPath, isEncrypt(meta, reqPath)),
+		Content:  content,
 		Total:    int64(total),
 		Readme:   getReadme(meta, reqPath),
-		Write:    user.CanWrite() || common.CanWrite(meta, reqPath),
+		Write:    write,
 		Provider: provider,
 	})
 }
@@ -196,7 +223,10 @@ func pagination(objs []model.Obj, req *model.PageReq) (int, []model.Obj) {
 func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
 	var resp []ObjResp
 	for _, obj := range objs {
-		thumb, _ := model.GetThumb(obj)
+		thumb, ok := model.GetThumb(obj)
+		if !ok || thumb == "" {
+			thumb = thumbURL(obj, parent, encrypt)
+		}
 		resp = append(resp, ObjResp{
 			Name:     obj.GetName(),
 			Size:     obj.GetSize(),
@@ -205,6 +235,7 @@ func toObjsResp(objs []model.Obj, parent string, encrypt bool) []ObjResp {
 			Sign:     common.Sign(obj, parent, encrypt),
 			Thumb:    thumb,
 			Type:     utils.GetObjType(obj.GetName(), obj.IsDir()),
+			Hashes:   model.GetHashes(obj),
 		})
 	}
 	return resp
@@ -302,7 +333,16 @@ func FsGet(c *gin.Context) {
 		related = filterRelated(sameLevelFiles, obj)
 	}
 	parentMeta, _ := op.GetNearestMeta(parentPath)
-	thumb, _ := model.GetThumb(obj)
+	thumb, ok := model.GetThumb(obj)
+	if !ok || thumb == "" {
+		thumb = thumbURL(obj, parentPath, isEncrypt(meta, reqPath))
+	}
+	var info *model.MediaInfo
+	if media.Enabled() && !obj.IsDir() && media.Supported(obj.GetName()) {
+		if info, err = media.Get(c, reqPath); err != nil {
+			log.Warnf("failed read media info of %s: %+v", reqPath, err)
+		}
+	}
 	common.SuccessResp(c, FsGetResp{
 		ObjResp: ObjResp{
 			Name:     obj.GetName(),
@@ -312,6 +352,8 @@ func FsGet(c *gin.Context) {
 			Sign:     common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
 			Type:     utils.GetFileType(obj.GetName()),
 			Thumb:    thumb,
+			Hashes:   model.GetHashes(obj),
+			Media:    info,
 		},
 		RawURL:   rawURL,
 		Readme:   getReadme(meta, reqPath),
@@ -371,3 +413,40 @@ func FsOther(c *gin.Context) {
 	}
 	common.SuccessResp(c, res)
 }
+
+type FsCapabilitiesReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+func FsCapabilities(c *gin.Context) {
+	var req FsCapabilitiesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	var err error
+	req.Path, err = user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(req.Path)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, req.Path, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	caps, err := fs.GetCapabilities(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, caps)
+}
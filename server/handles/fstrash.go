@@ -0,0 +1,135 @@
+package handles
+
+import (
+	stdpath "path"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsTrashListReq struct {
+	Path string `json:"path" form:"path"`
+}
+
+// relPath is the path seen by the user, false if it's out of the base path
+func relPath(user *model.User, path string) (string, bool) {
+	if !utils.IsSubPath(user.BasePath, path) {
+		return "", false
+	}
+	return utils.FixAndCleanPath(strings.TrimPrefix(path, utils.FixAndCleanPath(user.BasePath))), true
+}
+
+// FsTrashList lists the items removed to the recycle bin of the storage of
+// the path, the items out of the base path of the user are skipped
+func FsTrashList(c *gin.Context) {
+	var req FsTrashListReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	items, err := fs.ListTrash(c, reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	res := make([]model.TrashItem, 0, len(items))
+	for _, item := range items {
+		if p, ok := relPath(user, item.Path); ok {
+			item.Path = p
+			res = append(res, item)
+		}
+	}
+	common.SuccessResp(c, res)
+}
+
+type FsTrashReq struct {
+	Path string `json:"path"`
+	ID   string `json:"id" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+// trashItem gets the item of the request, it's not found if the original
+// path is out of the base path of the user
+func trashItem(c *gin.Context, req FsTrashReq) (*model.TrashItem, string, bool) {
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return nil, "", false
+	}
+	item, err := fs.GetTrashItem(c, reqPath, req.ID, req.Name)
+	if err == nil && !utils.IsSubPath(user.BasePath, item.Path) {
+		err = errors.WithStack(errs.ObjectNotFound)
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil, "", false
+	}
+	return item, reqPath, true
+}
+
+func FsTrashRestore(c *gin.Context) {
+	var req FsTrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	item, reqPath, ok := trashItem(c, req)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	meta, err := op.GetNearestMeta(stdpath.Dir(item.Path))
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.HasPermission(user, item.Path, model.PermWrite, user.CanWrite() || common.CanWrite(meta, item.Path)) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	err = fs.RestoreTrash(c, reqPath, req.ID, req.Name)
+	common.Audit(c, common.AuditFileUpload, item.Path, err, "restore from recycle bin")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func FsTrashDelete(c *gin.Context) {
+	var req FsTrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	item, reqPath, ok := trashItem(c, req)
+	if !ok {
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !common.HasPermission(user, item.Path, model.PermDelete, user.CanRemove()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	err := fs.DeleteTrash(c, reqPath, req.ID, req.Name)
+	common.Audit(c, common.AuditFileRemove, item.Path, err, "delete from recycle bin")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
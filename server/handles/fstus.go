@@ -0,0 +1,240 @@
+package handles
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/tus"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// The handlers implement the core protocol and the creation, expiration and
+// termination extensions of tus. They answer with bare status codes instead
+// of the json body of other apis, because tus clients rely on them.
+
+const tusOffsetContentType = "application/offset+octet-stream"
+
+// TusHeaders are the response headers that browsers must be able to read
+var TusHeaders = []string{"Location", "Upload-Offset", "Upload-Length", "Upload-Expires",
+	"Upload-Metadata", "Tus-Resumable", "Tus-Version", "Tus-Extension"}
+
+func tusVersion(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tus.Version)
+	if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != tus.Version {
+		c.Header("Tus-Version", tus.Version)
+		c.String(http.StatusPreconditionFailed, "unsupported tus version")
+		return false
+	}
+	return true
+}
+
+func TusOptions(c *gin.Context) {
+	tusVersion(c)
+	c.Header("Tus-Version", tus.Version)
+	c.Header("Tus-Extension", "creation,expiration,termination")
+	c.Status(http.StatusNoContent)
+}
+
+// TusCreate creates an upload of the file at File-Path, the permission is
+// checked by middlewares.FsUp
+func TusCreate(c *gin.Context) {
+	if !tusVersion(c) {
+		return
+	}
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.String(http.StatusBadRequest, "invalid Upload-Length")
+		return
+	}
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	path, err = user.JoinPath(path)
+	if err != nil {
+		c.String(http.StatusForbidden, err.Error())
+		return
+	}
+	storage, err := fs.GetStorage(path, &fs.GetStoragesArgs{})
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if storage.Config().NoUpload {
+		c.String(http.StatusMethodNotAllowed, "Current storage doesn't support upload")
+		return
+	}
+	if err = op.CheckQuota(user, storage.GetStorage(), length); err != nil {
+		c.String(http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	mimetype := tus.ParseMetadata(c.GetHeader("Upload-Metadata"))["filetype"]
+	if mimetype == "" {
+		mimetype = utils.GetMimeType(path)
+	}
+	u := &tus.Upload{
+		UserID:   user.ID,
+		Path:     path,
+		Mimetype: mimetype,
+		AsTask:   c.GetHeader("As-Task") == "true",
+		Length:   length,
+		Metadata: c.GetHeader("Upload-Metadata"),
+	}
+	if err = tus.Create(u); err != nil {
+		log.Errorf("failed to create tus upload: %+v", err)
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Location", stdpath.Join(c.Request.URL.Path, u.ID))
+	c.Header("Upload-Expires", u.Expires().UTC().Format(http.TimeFormat))
+	if length == 0 {
+		_ = u.Lock()
+		defer u.Unlock()
+		if err = tusFinish(c, u); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	c.Status(http.StatusCreated)
+}
+
+// getTusUpload gets the upload of id of the current user
+func getTusUpload(c *gin.Context) (*tus.Upload, bool) {
+	if !tusVersion(c) {
+		return nil, false
+	}
+	user := c.MustGet("user").(*model.User)
+	u, err := tus.Get(c.Param("id"))
+	if err != nil || u.UserID != user.ID {
+		c.String(http.StatusNotFound, tus.ErrNotFound.Error())
+		return nil, false
+	}
+	return u, true
+}
+
+func TusHead(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset(), 10))
+	c.Header("Upload-Length", strconv.FormatInt(u.Length, 10))
+	c.Header("Upload-Expires", u.Expires().UTC().Format(http.TimeFormat))
+	if u.Metadata != "" {
+		c.Header("Upload-Metadata", u.Metadata)
+	}
+	c.Status(http.StatusOK)
+}
+
+func TusPatch(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if c.ContentType() != tusOffsetContentType {
+		c.String(http.StatusUnsupportedMediaType, "Content-Type must be "+tusOffsetContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.String(http.StatusBadRequest, "invalid Upload-Offset")
+		return
+	}
+	if err = u.Lock(); err != nil {
+		c.String(http.StatusLocked, err.Error())
+		return
+	}
+	defer u.Unlock()
+	_, err = u.Write(offset, c.Request.Body)
+	c.Header("Upload-Offset", strconv.FormatInt(u.Offset(), 10))
+	c.Header("Upload-Expires", u.Expires().UTC().Format(http.TimeFormat))
+	if errors.Is(err, tus.ErrOffsetMismatch) {
+		c.String(http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		log.Debugf("failed to receive chunk of tus upload %s: %+v", u.ID, err)
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if u.Complete() && !u.Finished() {
+		// a failed handover is kept, so that the client can retry with an
+		// empty chunk at the end
+		if err = tusFinish(c, u); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func TusDelete(c *gin.Context) {
+	u, ok := getTusUpload(c)
+	if !ok {
+		return
+	}
+	if err := u.Lock(); err != nil {
+		c.String(http.StatusLocked, err.Error())
+		return
+	}
+	defer u.Unlock()
+	if !u.Finished() {
+		tus.Remove(u.ID)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// tusFinish hands over the received file to the storage, the upload must
+// be locked
+func tusFinish(c *gin.Context, u *tus.Upload) (err error) {
+	defer func() {
+		common.Audit(c, common.AuditFileUpload, u.Path, err, "tus")
+	}()
+	// the file of a task is stored with the task to survive restarts, the
+	// upload is removed once the file is stored
+	rc, err := u.Open(u.AsTask)
+	if err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(u.Path)
+	stream := &model.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     u.Length,
+			Modified: time.Now(),
+		},
+		ReadCloser:   rc,
+		Mimetype:     u.Mimetype,
+		WebPutAsTask: u.AsTask,
+	}
+	if u.AsTask {
+		err = fs.PutAsTask(c, dir, stream)
+		if err != nil {
+			_ = rc.Close()
+			return err
+		}
+		u.Finish()
+		return nil
+	}
+	err = fs.PutDirectly(c, dir, stream, true)
+	_ = rc.Close()
+	if err != nil {
+		return err
+	}
+	u.Finish()
+	tus.Remove(u.ID)
+	return nil
+}
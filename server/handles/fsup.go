@@ -8,6 +8,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/progress"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 )
@@ -44,10 +45,16 @@ func FsStream(c *gin.Context) {
 		WebPutAsTask: asTask,
 	}
 	if asTask {
-		err = fs.PutAsTask(dir, stream)
+		err = fs.PutAsTask(c, dir, stream)
 	} else {
+		// the form uploads are received before they're put, so only the
+		// streams report the progress
+		up := progress.TrackUpload(user.ID, path, size, stream.ReadCloser)
+		stream.ReadCloser = up
 		err = fs.PutDirectly(c, dir, stream, true)
+		up.Done(err)
 	}
+	common.Audit(c, common.AuditFileUpload, path, err)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -100,10 +107,11 @@ func FsForm(c *gin.Context) {
 		WebPutAsTask: false,
 	}
 	if asTask {
-		err = fs.PutAsTask(dir, stream)
+		err = fs.PutAsTask(c, dir, stream)
 	} else {
 		err = fs.PutDirectly(c, dir, stream, true)
 	}
+	common.Audit(c, common.AuditFileUpload, path, err)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
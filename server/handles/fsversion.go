@@ -0,0 +1,175 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsVersionsReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+type FsVersionResp struct {
+	ID       string    `json:"id"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// URL downloads the version without the token
+	URL string `json:"url"`
+}
+
+func versionSignData(uid uint, path, version string) string {
+	return fmt.Sprintf("version:%d:%s:%s", uid, path, version)
+}
+
+// FsVersions lists the previous versions of the file, each with a signed url
+// to download it
+func FsVersions(c *gin.Context) {
+	var req FsVersionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	versions, err := fs.ListVersions(c, reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	base := fmt.Sprintf("%s/vd%s", common.GetApiUrl(c.Request), utils.EncodePath(reqPath, true))
+	resp := make([]FsVersionResp, 0, len(versions))
+	for _, v := range versions {
+		query := url.Values{}
+		query.Set("version", v.ID)
+		query.Set("uid", strconv.Itoa(int(user.ID)))
+		query.Set("sign", sign.Sign(versionSignData(user.ID, reqPath, v.ID)))
+		resp = append(resp, FsVersionResp{
+			ID:       v.ID,
+			Size:     v.Size,
+			Modified: v.Modified,
+			URL:      base + "?" + query.Encode(),
+		})
+	}
+	common.SuccessResp(c, resp)
+}
+
+// VersionDown proxies the version of the signed url, the versions are
+// always proxied since the link of a version may need the token of the
+// storage
+func VersionDown(c *gin.Context) {
+	rawPath := utils.FixAndCleanPath(c.Param("path"))
+	version := c.Query("version")
+	uid, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err = sign.Verify(versionSignData(uint(uid), rawPath, version), c.Query("sign")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(uint(uid))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "the user is disabled", 403)
+		return
+	}
+	if !common.HasPermission(user, rawPath, model.PermProxy, true) {
+		common.ErrorStrResp(c, "proxy not allowed", 403)
+		return
+	}
+	ctx := context.WithValue(c, "user", user)
+	var storage *model.Storage
+	if s, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{}); err == nil {
+		storage = s.GetStorage()
+	}
+	w, transfer, err := common.LimitProxy(ctx, c.Writer, user, storage)
+	if err != nil {
+		common.ErrorResp(c, err, 429)
+		return
+	}
+	defer transfer.Done()
+	link, obj, err := fs.LinkVersion(ctx, rawPath, version, model.LinkArgs{
+		Header: c.Request.Header,
+		Type:   c.Query("type"),
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	err = common.Proxy(w, c.Request, link, obj)
+	common.AuditAs(c, user, "", common.AuditFileDownload, rawPath, err, "version "+version)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+}
+
+type FsRestoreVersionReq struct {
+	Path    string `json:"path"`
+	Version string `json:"version" binding:"required"`
+}
+
+func FsRestoreVersion(c *gin.Context) {
+	var req FsRestoreVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.HasPermission(user, reqPath, model.PermWrite, user.CanWrite() || common.CanWrite(meta, reqPath)) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	err = fs.RestoreVersion(c, reqPath, req.Version)
+	common.Audit(c, common.AuditFileUpload, reqPath, err, "restore version "+req.Version)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,32 @@
+package handles
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var metricsHandler = promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+
+// Metrics serves the prometheus metrics if they are enabled, the token is
+// required if it's set
+func Metrics(c *gin.Context) {
+	if !setting.GetBool(conf.MetricsEnabled) {
+		common.ErrorStrResp(c, "metrics are disabled", 404)
+		return
+	}
+	if token := setting.GetStr(conf.MetricsToken); token != "" {
+		got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			common.ErrorStrResp(c, "invalid metrics token", 401)
+			return
+		}
+	}
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,28 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Reload applies the settings and the storages changed in the database
+// without restarting, only the changed storages are reinitialized
+func Reload(c *gin.Context) {
+	var errs []error
+	if err := op.ReloadSettings(); err != nil {
+		errs = append(errs, err)
+	}
+	res, err := op.ReloadStorages(c)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	err = utils.MergeErrors(errs...)
+	common.Audit(c, common.AuditReload, "", err)
+	if err != nil {
+		common.ErrorWithDataResp(c, err, 500, res, true)
+		return
+	}
+	common.SuccessResp(c, res)
+}
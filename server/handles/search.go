@@ -57,7 +57,12 @@ func Search(c *gin.Context) {
 		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
 			continue
 		}
-		if !common.CanAccess(user, meta, path.Join(node.Parent, node.Name), req.Password) {
+		nodePath := path.Join(node.Parent, node.Name)
+		if !common.CanAccess(user, meta, nodePath, req.Password) {
+			continue
+		}
+		// the same as the listings, the objs denied by the acl rules are hidden
+		if allowed, decided := op.CheckPermission(user, nodePath, model.PermRead); decided && !allowed {
 			continue
 		}
 		filteredNodes = append(filteredNodes, node)
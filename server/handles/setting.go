@@ -51,7 +51,13 @@ func SaveSettings(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.SaveSettingItems(req); err != nil {
+	keys := make([]string, len(req))
+	for i, item := range req {
+		keys[i] = item.Key
+	}
+	err := op.SaveSettingItems(req)
+	common.Audit(c, common.AuditSettingSave, "", err, strings.Join(keys, ","))
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
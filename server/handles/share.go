@@ -0,0 +1,323 @@
+package handles
+
+import (
+	stdpath "path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type CreateShareReq struct {
+	Path         string     `json:"path" binding:"required"`
+	Password     string     `json:"password"`
+	ExpireAt     *time.Time `json:"expire_at"`
+	MaxDownloads int        `json:"max_downloads"`
+}
+
+func CreateShare(c *gin.Context) {
+	var req CreateShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.MaxDownloads < 0 {
+		common.ErrorStrResp(c, "max downloads can't be negative", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CanAccess(user, meta, reqPath, "") ||
+		!common.HasPermission(user, reqPath, model.PermShare, !user.IsGuest()) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if _, err := fs.Get(c, reqPath, &fs.GetArgs{}); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	share := &model.Share{
+		UserID:       user.ID,
+		Path:         reqPath,
+		ExpireAt:     req.ExpireAt,
+		MaxDownloads: req.MaxDownloads,
+	}
+	if err := share.SetPassword(req.Password); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if err := op.CreateShare(share); err != nil {
+		common.Audit(c, common.AuditShareCreate, reqPath, err)
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.Audit(c, common.AuditShareCreate, reqPath, nil, share.Token)
+	common.SuccessResp(c, share)
+}
+
+// ListShares lists the shares of the current user, or of all users for admin
+func ListShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	var userID uint
+	if !user.IsAdmin() {
+		userID = user.ID
+	}
+	shares, total, err := op.GetShares(userID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: shares,
+		Total:   total,
+	})
+}
+
+func DeleteShare(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	share, err := op.GetShareById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !user.IsAdmin() && share.UserID != user.ID {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err := op.DeleteShareById(share.ID); err != nil {
+		common.Audit(c, common.AuditShareDelete, share.Path, err, share.Token)
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.Audit(c, common.AuditShareDelete, share.Path, nil, share.Token)
+	common.SuccessResp(c)
+}
+
+// shareDownloadExpire is how long the download links of ShareGet are valid
+const shareDownloadExpire = 12 * time.Hour
+
+// shareDownloads are the downloads counted, by the share and the id of the
+// download, so that the requests of a download after the first, such as
+// the ranges of players and resuming, aren't counted again
+var (
+	shareDownloads   = cluster.NewStore[bool]("share_download")
+	shareDownloadsMu sync.Mutex
+)
+
+// shareSignData is signed for the download links of ShareGet, with the id
+// of the download counted once, and so that the password of the share is
+// not put in the links
+func shareSignData(token, subPath, download string) string {
+	return "share:" + token + ":" + download + ":" + utils.FixAndCleanPath(subPath)
+}
+
+// countShareDownload counts the download of id, unless it's counted already
+func countShareDownload(share *model.Share, download string) error {
+	key := strconv.Itoa(int(share.ID)) + ":" + download
+	shareDownloadsMu.Lock()
+	defer shareDownloadsMu.Unlock()
+	if _, ok := shareDownloads.Get(key); ok {
+		return nil
+	}
+	if err := op.CountShareDownload(share); err != nil {
+		return err
+	}
+	shareDownloads.Set(key, true, shareDownloadExpire)
+	return nil
+}
+
+// openShare returns the share of the token and the path of subPath in it,
+// the request acts as the user who created the share from then on
+func openShare(c *gin.Context, token, subPath string) (*model.Share, string, bool) {
+	share, user, err := op.GetValidShare(token)
+	if err != nil {
+		code := 500
+		if errors.Is(errors.Cause(err), errs.ShareExpired) {
+			code = 410
+		} else if errors.Is(errors.Cause(err), errs.PermissionDenied) {
+			code = 403
+		}
+		common.ErrorResp(c, err, code)
+		return nil, "", false
+	}
+	rawPath, err := utils.JoinBasePath(share.Path, subPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return nil, "", false
+	}
+	meta, err := op.GetNearestMeta(rawPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return nil, "", false
+	}
+	// the password of the meta is never asked in shares, so the paths in
+	// the share protected by a password are denied
+	if !common.CanAccess(user, meta, rawPath, "") {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return nil, "", false
+	}
+	c.Set("user", user)
+	c.Set("meta", meta)
+	return share, rawPath, true
+}
+
+type ShareGetReq struct {
+	Token    string `json:"token" form:"token" binding:"required"`
+	Password string `json:"password" form:"password"`
+	Path     string `json:"path" form:"path"`
+}
+
+type ShareObjResp struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	IsDir    bool      `json:"is_dir"`
+	Modified time.Time `json:"modified"`
+	Type     int       `json:"type"`
+	// Download and Sign are the dl and the sign queries of the download
+	// link of the file
+	Download string `json:"download,omitempty"`
+	Sign     string `json:"sign,omitempty"`
+}
+
+type ShareGetResp struct {
+	ShareObjResp
+	ExpireAt     *time.Time     `json:"expire_at"`
+	MaxDownloads int            `json:"max_downloads"`
+	Downloads    int            `json:"downloads"`
+	Content      []ShareObjResp `json:"content"`
+}
+
+// ShareGet returns the shared object at the path of the share, with the
+// objects in it if it's a folder
+func ShareGet(c *gin.Context) {
+	var req ShareGetReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	share, rawPath, ok := openShare(c, req.Token, req.Path)
+	if !ok {
+		return
+	}
+	if !share.ValidatePassword(req.Password) {
+		common.ErrorResp(c, errs.WrongSharePassword, 403)
+		return
+	}
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	subPath := utils.FixAndCleanPath(req.Path)
+	toResp := func(obj model.Obj, p string) ShareObjResp {
+		resp := ShareObjResp{
+			Name:     obj.GetName(),
+			Size:     obj.GetSize(),
+			IsDir:    obj.IsDir(),
+			Modified: obj.ModTime(),
+			Type:     utils.GetObjType(obj.GetName(), obj.IsDir()),
+		}
+		if !obj.IsDir() {
+			resp.Download = random.String(16)
+			resp.Sign = sign.WithDuration(shareSignData(share.Token, p, resp.Download), shareDownloadExpire)
+		}
+		return resp
+	}
+	resp := ShareGetResp{
+		ShareObjResp: toResp(obj, subPath),
+		ExpireAt:     share.ExpireAt,
+		MaxDownloads: share.MaxDownloads,
+		Downloads:    share.Downloads,
+	}
+	// the name of the shared object, instead of the name in the storage
+	resp.Name = stdpath.Base(rawPath)
+	if obj.IsDir() {
+		objs, err := fs.List(c, rawPath, &fs.ListArgs{})
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		user := c.MustGet("user").(*model.User)
+		resp.Content = make([]ShareObjResp, 0, len(objs))
+		for _, o := range objs {
+			p := stdpath.Join(rawPath, o.GetName())
+			meta, err := op.GetNearestMeta(p)
+			if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+			// leave out the objs openShare denies
+			if !common.CanAccess(user, meta, p, "") {
+				continue
+			}
+			resp.Content = append(resp.Content, toResp(o, stdpath.Join(subPath, o.GetName())))
+		}
+	}
+	common.SuccessResp(c, resp)
+}
+
+// ShareDown downloads the file at the path of the share, in the same way as
+// Down so that the proxy settings of the storage are respected
+func ShareDown(c *gin.Context) {
+	token := c.Param("token")
+	subPath := c.Param("path")
+	share, rawPath, ok := openShare(c, token, subPath)
+	if !ok {
+		return
+	}
+	// the links are signed by ShareGet, so that the password is never put
+	// in them and each download is counted once
+	download := c.Query("dl")
+	if err := sign.Verify(shareSignData(token, subPath, download), strings.TrimSuffix(c.Query("sign"), "/")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	obj, err := fs.Get(c, rawPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorResp(c, errs.NotFile, 400)
+		return
+	}
+	if err := countShareDownload(share, download); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	c.Set("path", rawPath)
+	Down(c)
+}
@@ -0,0 +1,18 @@
+package handles
+
+import (
+	"github.com/alist-org/alist/v3/internal/tracing"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSlowOperations returns the latest calls to the storages slower than
+// the threshold of the setting, the latest first
+func ListSlowOperations(c *gin.Context) {
+	common.SuccessResp(c, tracing.SlowOperations())
+}
+
+func ClearSlowOperations(c *gin.Context) {
+	tracing.ClearSlowOperations()
+	common.SuccessResp(c)
+}
@@ -27,6 +27,10 @@ func SSOLoginRedirect(c *gin.Context) {
 			common.ErrorStrResp(c, "no method provided", 400)
 			return
 		}
+		if isGenericSSO(platform) {
+			genericSSORedirect(c, platform, method)
+			return
+		}
 		redirect_uri = common.GetApiUrl(c.Request) + "/api/auth/sso_callback" + "?method=" + method
 		urlValues.Add("response_type", "code")
 		urlValues.Add("redirect_uri", redirect_uri)
@@ -66,6 +70,14 @@ func SSOLoginCallback(c *gin.Context) {
 		clientId := setting.GetStr(conf.SSOClientId)
 		platform := setting.GetStr(conf.SSOLoginplatform)
 		clientSecret := setting.GetStr(conf.SSOClientSecret)
+		if isGenericSSO(platform) {
+			if !enabled {
+				common.ErrorStrResp(c, "Single sign-on is not enabled", 403)
+				return
+			}
+			genericSSOCallback(c, platform, argument)
+			return
+		}
 		var url1, url2, additionalbody, scope, authstring, idstring string
 		switch platform {
 		case "Github":
@@ -163,6 +175,7 @@ func SSOLoginCallback(c *gin.Context) {
 				if err != nil {
 					common.ErrorResp(c, err, 400)
 				}
+				common.AuditAs(c, user, "", common.AuditLogin, "", nil, "sso")
 				html := fmt.Sprintf(`<!DOCTYPE html>
 				<head></head>
 				<body>
@@ -0,0 +1,253 @@
+package handles
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Xhofe/go-cache"
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/db"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+)
+
+// the platforms of which the endpoints are configured instead of built in
+const (
+	ssoOIDC   = "OIDC"
+	ssoOAuth2 = "OAuth2"
+)
+
+func isGenericSSO(platform string) bool {
+	return platform == ssoOIDC || platform == ssoOAuth2
+}
+
+type ssoEndpoints struct {
+	AuthURL     string `json:"authorization_endpoint"`
+	TokenURL    string `json:"token_endpoint"`
+	UserInfoURL string `json:"userinfo_endpoint"`
+}
+
+var ssoDiscoveryCache = cache.NewMemCache[*ssoEndpoints]()
+
+// ssoStates keeps the states sent to the provider against csrf, the value
+// is the method of the login
+var ssoStates = cluster.NewStore[string]("sso_state")
+
+// getSSOEndpoints discovers the endpoints of OIDC from the issuer, or reads
+// the ones of OAuth2 from the settings
+func getSSOEndpoints(platform string) (*ssoEndpoints, error) {
+	if platform == ssoOAuth2 {
+		e := &ssoEndpoints{
+			AuthURL:     setting.GetStr(conf.SSOAuthURL),
+			TokenURL:    setting.GetStr(conf.SSOTokenURL),
+			UserInfoURL: setting.GetStr(conf.SSOUserInfoURL),
+		}
+		if e.AuthURL == "" || e.TokenURL == "" || e.UserInfoURL == "" {
+			return nil, errors.New("the endpoints of OAuth2 are not configured")
+		}
+		return e, nil
+	}
+	discoveryURL := strings.TrimSuffix(setting.GetStr(conf.SSOIssuer), "/")
+	if discoveryURL == "" {
+		return nil, errors.New("the issuer of OIDC is not configured")
+	}
+	if !strings.Contains(discoveryURL, "/.well-known/") {
+		discoveryURL += "/.well-known/openid-configuration"
+	}
+	if e, ok := ssoDiscoveryCache.Get(discoveryURL); ok {
+		return e, nil
+	}
+	var e ssoEndpoints
+	res, err := ssoClient.R().SetResult(&e).Get(discoveryURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed discover OIDC")
+	}
+	if res.StatusCode() != 200 || e.AuthURL == "" || e.TokenURL == "" || e.UserInfoURL == "" {
+		return nil, errors.Errorf("failed discover OIDC: %s", res.String())
+	}
+	ssoDiscoveryCache.Set(discoveryURL, &e, cache.WithEx[*ssoEndpoints](time.Hour))
+	return &e, nil
+}
+
+func ssoRedirectURI(c *gin.Context, method string) string {
+	return common.GetApiUrl(c.Request) + "/api/auth/sso_callback" + "?method=" + method
+}
+
+func genericSSORedirect(c *gin.Context, platform, method string) {
+	e, err := getSSOEndpoints(platform)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	state := random.Secret(32)
+	ssoStates.Set(state, method, time.Minute*10)
+	urlValues := url.Values{}
+	urlValues.Add("response_type", "code")
+	urlValues.Add("redirect_uri", ssoRedirectURI(c, method))
+	urlValues.Add("client_id", setting.GetStr(conf.SSOClientId))
+	urlValues.Add("scope", setting.GetStr(conf.SSOScopes))
+	urlValues.Add("state", state)
+	sep := "?"
+	if strings.Contains(e.AuthURL, "?") {
+		sep = "&"
+	}
+	c.Redirect(302, e.AuthURL+sep+urlValues.Encode())
+}
+
+// ssoClaim gets the claim from the userinfo, nested claims are separated
+// by dots
+func ssoClaim(userInfo []byte, claim string) jsoniter.Any {
+	var path []interface{}
+	for _, p := range strings.Split(claim, ".") {
+		path = append(path, p)
+	}
+	return utils.Json.Get(userInfo, path...)
+}
+
+func ssoClaimStrings(userInfo []byte, claim string) []string {
+	v := ssoClaim(userInfo, claim)
+	switch v.ValueType() {
+	case jsoniter.ArrayValue:
+		s := make([]string, 0, v.Size())
+		for i := 0; i < v.Size(); i++ {
+			s = append(s, v.Get(i).ToString())
+		}
+		return s
+	case jsoniter.StringValue:
+		return strings.Split(v.ToString(), ",")
+	}
+	return nil
+}
+
+func genericSSOCallback(c *gin.Context, platform, method string) {
+	state := c.Query("state")
+	if m, ok := ssoStates.Get(state); !ok || m != method {
+		common.ErrorStrResp(c, "invalid state", 400)
+		return
+	}
+	ssoStates.Del(state)
+	code := c.Query("code")
+	if code == "" {
+		common.ErrorStrResp(c, "No code provided", 400)
+		return
+	}
+	e, err := getSSOEndpoints(platform)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	res, err := ssoClient.R().SetHeader("Accept", "application/json").
+		SetFormData(map[string]string{
+			"grant_type":    "authorization_code",
+			"code":          code,
+			"redirect_uri":  ssoRedirectURI(c, method),
+			"client_id":     setting.GetStr(conf.SSOClientId),
+			"client_secret": setting.GetStr(conf.SSOClientSecret),
+		}).Post(e.TokenURL)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	accessToken := utils.Json.Get(res.Body(), "access_token").ToString()
+	if accessToken == "" {
+		common.ErrorStrResp(c, "failed get access token: "+res.String(), 400)
+		return
+	}
+	res, err = ssoClient.R().SetHeader("Authorization", "Bearer "+accessToken).
+		SetHeader("Accept", "application/json").Get(e.UserInfoURL)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	userInfo := res.Body()
+	ssoID := ssoClaim(userInfo, setting.GetStr(conf.SSOIDClaim, "sub")).ToString()
+	if ssoID == "" {
+		common.ErrorStrResp(c, "failed get the id of the user: "+res.String(), 400)
+		return
+	}
+	if method == "get_sso_id" {
+		ssoPostMessage(c, gin.H{"sso_id": ssoID})
+		return
+	}
+	if method != "sso_get_token" {
+		common.ErrorStrResp(c, "invalid method", 400)
+		return
+	}
+	user, err := db.GetUserBySSOID(ssoID)
+	if err != nil {
+		if !setting.GetBool(conf.SSOAutoRegister) {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		username := ssoClaim(userInfo, setting.GetStr(conf.SSOUsernameClaim, "preferred_username")).ToString()
+		user, err = ssoRegister(ssoID, username)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "the user is disabled", 403)
+		return
+	}
+	if claim := setting.GetStr(conf.SSOGroupsClaim); claim != "" {
+		if err := op.SyncUserGroupsByName(user.ID, ssoClaimStrings(userInfo, claim)); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	token, err := common.GenerateToken(user.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.AuditAs(c, user, "", common.AuditLogin, "", nil, "sso")
+	ssoPostMessage(c, gin.H{"token": token})
+}
+
+// ssoRegister creates the user bound to the sso id, the password is random
+// so that the user can only login with sso until it's changed
+func ssoRegister(ssoID, username string) (*model.User, error) {
+	if username == "" {
+		username = ssoID
+	}
+	if _, err := op.GetUserByName(username); err == nil {
+		return nil, errors.Errorf("user %s already exists, bind it to sso in the profile instead", username)
+	}
+	user := &model.User{
+		Username:   username,
+		Password:   random.Secret(32),
+		BasePath:   setting.GetStr(conf.SSODefaultDir, "/"),
+		Role:       model.GENERAL,
+		Permission: int32(setting.GetInt(conf.SSODefaultPermission, 0)),
+		SsoID:      ssoID,
+	}
+	if err := op.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ssoPostMessage passes the data to the window that opened the login
+func ssoPostMessage(c *gin.Context, data gin.H) {
+	msg, _ := utils.Json.MarshalToString(data)
+	html := fmt.Sprintf(`<!DOCTYPE html>
+				<head></head>
+				<body>
+				<script>
+				window.opener.postMessage(%s, "*")
+				window.close()
+				</script>
+				</body>`, msg)
+	c.Data(200, "text/html; charset=utf-8", []byte(html))
+}
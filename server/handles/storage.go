@@ -8,6 +8,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/db"
 	"github.com/alist-org/alist/v3/internal/model"
 	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
 	"github.com/alist-org/alist/v3/server/common"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -38,7 +39,9 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if id, err := op.CreateStorage(c, req); err != nil {
+	id, err := op.CreateStorage(c, req)
+	common.Audit(c, common.AuditStorageCreate, req.MountPath, err, req.Driver)
+	if err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
 		}, true)
@@ -55,7 +58,9 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	if err := op.UpdateStorage(c, req); err != nil {
+	err := op.UpdateStorage(c, req)
+	common.Audit(c, common.AuditStorageUpdate, req.MountPath, err, req.Driver)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -69,10 +74,13 @@ func DeleteStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	target := storageTarget(uint(id))
 	if err := op.DeleteStorageById(c, uint(id)); err != nil {
+		common.Audit(c, common.AuditStorageDelete, target, err)
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	common.Audit(c, common.AuditStorageDelete, target, nil)
 	common.SuccessResp(c)
 }
 
@@ -83,10 +91,13 @@ func DisableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	target := storageTarget(uint(id))
 	if err := op.DisableStorage(c, uint(id)); err != nil {
+		common.Audit(c, common.AuditStorageDisable, target, err)
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	common.Audit(c, common.AuditStorageDisable, target, nil)
 	common.SuccessResp(c)
 }
 
@@ -97,13 +108,25 @@ func EnableStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	target := storageTarget(uint(id))
 	if err := op.EnableStorage(c, uint(id)); err != nil {
+		common.Audit(c, common.AuditStorageEnable, target, err)
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	common.Audit(c, common.AuditStorageEnable, target, nil)
 	common.SuccessResp(c)
 }
 
+// storageTarget is the mount path of the storage for the audit log
+func storageTarget(id uint) string {
+	storage, err := db.GetStorageById(id)
+	if err != nil {
+		return "storage " + strconv.FormatUint(uint64(id), 10)
+	}
+	return storage.MountPath
+}
+
 func GetStorage(c *gin.Context) {
 	idStr := c.Query("id")
 	id, err := strconv.Atoi(idStr)
@@ -150,3 +173,29 @@ func LoadAllStorages(c *gin.Context) {
 	}(storages)
 	common.SuccessResp(c)
 }
+
+func ListStoragesHealth(c *gin.Context) {
+	common.SuccessResp(c, op.GetStoragesHealth())
+}
+
+// CheckStorageHealth checks the storage now instead of waiting for the
+// interval, such as after fixing the token of an offline storage
+func CheckStorageHealth(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, err := db.GetStorageById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	storageDriver, err := op.GetStorageByMountPath(storage.MountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, op.CheckStorageHealth(c, storageDriver, setting.GetInt(conf.StorageHealthFailures, 3)))
+}
@@ -0,0 +1,107 @@
+package handles
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListSymlinks(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	symlinks, total, err := op.GetSymlinks(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: symlinks,
+		Total:   total,
+	})
+}
+
+func GetSymlink(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	s, err := op.GetSymlinkById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, s)
+}
+
+func checkSymlink(s *model.Symlink) error {
+	path, target := utils.FixAndCleanPath(s.Path), utils.FixAndCleanPath(s.Target)
+	if path == "/" {
+		return fmt.Errorf("the root folder can't be a symlink")
+	}
+	if utils.IsSubPath(path, target) {
+		return fmt.Errorf("the target can't be the symlink or inside it")
+	}
+	if utils.IsSubPath(target, path) {
+		return fmt.Errorf("the target can't be a parent of the symlink")
+	}
+	return op.CheckSymlinkLoop(s)
+}
+
+func CreateSymlink(c *gin.Context) {
+	var req model.Symlink
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := checkSymlink(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.CreateSymlink(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func UpdateSymlink(c *gin.Context) {
+	var req model.Symlink
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := checkSymlink(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateSymlink(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteSymlink(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteSymlinkById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,135 @@
+package handles
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/syncjob"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListSyncJobs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	jobs, total, err := op.GetSyncJobs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: jobs,
+		Total:   total,
+	})
+}
+
+func getSyncJob(c *gin.Context) (*model.SyncJob, bool) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	j, err := op.GetSyncJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return nil, false
+	}
+	return j, true
+}
+
+func GetSyncJob(c *gin.Context) {
+	if j, ok := getSyncJob(c); ok {
+		common.SuccessResp(c, j)
+	}
+}
+
+func checkSyncJob(j *model.SyncJob) error {
+	j.Src = utils.FixAndCleanPath(j.Src)
+	j.Dst = utils.FixAndCleanPath(j.Dst)
+	if j.Mode == "" {
+		j.Mode = model.SyncOneWay
+	}
+	if j.Mode != model.SyncOneWay && j.Mode != model.SyncTwoWay {
+		return fmt.Errorf("unknown sync mode: %s", j.Mode)
+	}
+	return syncjob.ParseCron(j.Cron)
+}
+
+func CreateSyncJob(c *gin.Context) {
+	var req model.SyncJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := checkSyncJob(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.CreateSyncJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func UpdateSyncJob(c *gin.Context) {
+	var req model.SyncJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := checkSyncJob(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateSyncJob(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteSyncJob(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteSyncJobById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RunSyncJob runs the job now as a task, or reports what it would do if
+// dry_run is true in the query
+func RunSyncJob(c *gin.Context) {
+	j, ok := getSyncJob(c)
+	if !ok {
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		report, err := syncjob.DryRun(c, *j)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		common.SuccessResp(c, report)
+		return
+	}
+	if err := syncjob.Run(*j); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/alist-org/alist/v3/internal/aria2"
 	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/metrics"
 	"github.com/alist-org/alist/v3/internal/qbittorrent"
 	"github.com/alist-org/alist/v3/pkg/task"
 	"github.com/alist-org/alist/v3/server/common"
@@ -59,7 +60,11 @@ func str2StrK(str string) (string, error) {
 	return str, nil
 }
 
-func taskRoute[K comparable](g *gin.RouterGroup, manager *task.Manager[K], k2Str K2Str[K], str2K Str2K[K]) {
+func taskRoute[K comparable](g *gin.RouterGroup, name string, manager *task.Manager[K], k2Str K2Str[K], str2K Str2K[K]) {
+	metrics.SetTaskQueue(name, func() int {
+		return len(manager.ListUndone())
+	})
+	g = g.Group("/" + name)
 	g.GET("/undone", func(c *gin.Context) {
 		common.SuccessResp(c, getTaskInfos(manager.ListUndone(), k2Str))
 	})
@@ -116,10 +121,12 @@ func taskRoute[K comparable](g *gin.RouterGroup, manager *task.Manager[K], k2Str
 }
 
 func SetupTaskRoute(g *gin.RouterGroup) {
-	taskRoute(g.Group("/aria2_down"), aria2.DownTaskManager, strK2Str, str2StrK)
-	taskRoute(g.Group("/aria2_transfer"), aria2.TransferTaskManager, uint64K2Str, str2Uint64K)
-	taskRoute(g.Group("/upload"), fs.UploadTaskManager, uint64K2Str, str2Uint64K)
-	taskRoute(g.Group("/copy"), fs.CopyTaskManager, uint64K2Str, str2Uint64K)
-	taskRoute(g.Group("/qbit_down"), qbittorrent.DownTaskManager, strK2Str, str2StrK)
-	taskRoute(g.Group("/qbit_transfer"), qbittorrent.TransferTaskManager, uint64K2Str, str2Uint64K)
+	taskRoute(g, "aria2_down", aria2.DownTaskManager, strK2Str, str2StrK)
+	taskRoute(g, "aria2_transfer", aria2.TransferTaskManager, uint64K2Str, str2Uint64K)
+	taskRoute(g, "upload", fs.UploadTaskManager, uint64K2Str, str2Uint64K)
+	taskRoute(g, "copy", fs.CopyTaskManager, uint64K2Str, str2Uint64K)
+	taskRoute(g, "batch", fs.BatchTaskManager, uint64K2Str, str2Uint64K)
+	taskRoute(g, "sync", fs.SyncTaskManager, uint64K2Str, str2Uint64K)
+	taskRoute(g, "qbit_down", qbittorrent.DownTaskManager, strK2Str, str2StrK)
+	taskRoute(g, "qbit_transfer", qbittorrent.TransferTaskManager, uint64K2Str, str2Uint64K)
 }
@@ -0,0 +1,46 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/progress"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// keepAlive is the interval of the comments sent to keep the idle stream
+// open through the proxies
+const keepAlive = 30 * time.Second
+
+// TaskEvents streams the changes of the tasks and the uploads of the user as
+// server-sent events, the admin receives the changes of all tasks
+func TaskEvents(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "login please", 401)
+		return
+	}
+	events, cancel := progress.Subscribe(user)
+	defer cancel()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	c.Writer.Flush()
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e := <-events:
+			c.SSEvent("task", e)
+		case <-ticker.C:
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,179 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/sign"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsTempLinkReq struct {
+	Path     string `json:"path"`
+	Password string `json:"password"`
+	// Expire is the seconds the link is valid, 1 hour by default
+	Expire int64 `json:"expire"`
+	// BindIP makes the link work from the ip of the request only
+	BindIP bool `json:"bind_ip"`
+}
+
+type FsTempLinkResp struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// tempLinkSignData is signed for a temporary link, ip is empty if the link
+// isn't bound to an ip
+func tempLinkSignData(uid uint, path, ip string) string {
+	return fmt.Sprintf("temp:%d:%s:%s", uid, path, ip)
+}
+
+// FsTempLink creates a temporary link to download the file as the user
+// through the proxy, the link can be handed out without the token
+func FsTempLink(c *gin.Context) {
+	var req FsTempLinkReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	maxHours := setting.GetInt(conf.TempLinkMaxExpiration, 24)
+	if maxHours <= 0 {
+		common.ErrorStrResp(c, "temporary links are disabled", 403)
+		return
+	}
+	if req.Expire == 0 {
+		req.Expire = int64(time.Hour / time.Second)
+	}
+	if req.Expire < 0 || req.Expire > int64(maxHours)*3600 {
+		common.ErrorStrResp(c, fmt.Sprintf("the expiration must be between 1 second and %d hours", maxHours), 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	c.Set("meta", meta)
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	obj, err := fs.Get(c, reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorResp(c, errs.NotFile, 400)
+		return
+	}
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !canProxy(storage, obj.GetName()) || !common.HasPermission(user, reqPath, model.PermProxy, true) {
+		common.ErrorStrResp(c, "temporary links are only for the proxied files", 403)
+		return
+	}
+	ip := ""
+	if req.BindIP {
+		ip = c.ClientIP()
+	}
+	d := time.Duration(req.Expire) * time.Second
+	query := url.Values{}
+	query.Set("uid", strconv.Itoa(int(user.ID)))
+	if req.BindIP {
+		query.Set("ip", "1")
+	}
+	query.Set("sign", sign.WithDuration(tempLinkSignData(user.ID, reqPath, ip), d))
+	common.SuccessResp(c, FsTempLinkResp{
+		URL:       fmt.Sprintf("%s/tl%s?%s", common.GetApiUrl(c.Request), utils.EncodePath(reqPath, true), query.Encode()),
+		ExpiresAt: time.Now().Add(d),
+	})
+}
+
+// TempLinkDown proxies the file of the temporary link as the user created
+// it, so that the permissions of the user still apply
+func TempLinkDown(c *gin.Context) {
+	rawPath := utils.FixAndCleanPath(c.Param("path"))
+	uid, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	ip := ""
+	if c.Query("ip") != "" {
+		ip = c.ClientIP()
+	}
+	if err = sign.Verify(tempLinkSignData(uint(uid), rawPath, ip), c.Query("sign")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(uint(uid))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "the user is disabled", 403)
+		return
+	}
+	// the base path of the user may be changed after the link is created
+	if !utils.IsSubPath(user.BasePath, rawPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	storage, err := fs.GetStorage(rawPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !canProxy(storage, stdpath.Base(rawPath)) || !common.HasPermission(user, rawPath, model.PermProxy, true) {
+		common.ErrorStrResp(c, "proxy not allowed", 403)
+		return
+	}
+	ctx := context.WithValue(c, "user", user)
+	w, transfer, err := common.LimitProxy(ctx, c.Writer, user, storage.GetStorage())
+	if err != nil {
+		common.ErrorResp(c, err, 429)
+		return
+	}
+	defer transfer.Done()
+	link, obj, err := fs.Link(ctx, rawPath, model.LinkArgs{
+		Header: c.Request.Header,
+		Type:   c.Query("type"),
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	err = common.Proxy(w, c.Request, link, obj)
+	if r := c.GetHeader("Range"); r == "" || strings.HasPrefix(r, "bytes=0-") {
+		common.AuditAs(c, user, "", common.AuditFileDownload, rawPath, err, "temporary link")
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+}
@@ -0,0 +1,50 @@
+package handles
+
+import (
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/internal/thumb"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func Thumb(c *gin.Context) {
+	if !setting.GetBool(conf.ThumbnailGenerate) {
+		common.ErrorStrResp(c, "thumbnail generation is disabled", 403)
+		return
+	}
+	rawPath := c.MustGet("path").(string)
+	t, err := thumb.Get(c, rawPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	etag := `"` + t.ETag + `"`
+	c.Header("ETag", etag)
+	// the files may be behind passwords or acl rules, so shared caches
+	// must not keep the thumbnails
+	c.Header("Cache-Control", "private, max-age=604800")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(304)
+		return
+	}
+	c.Data(200, "image/jpeg", t.Data)
+}
+
+// thumbURL returns the url of the generated thumbnail of obj,
+// or empty if thumbnail generation is disabled or not supported
+func thumbURL(obj model.Obj, parent string, encrypt bool) string {
+	if obj.IsDir() || !setting.GetBool(conf.ThumbnailGenerate) || !thumb.Supported(obj.GetName()) {
+		return ""
+	}
+	path := stdpath.Join(parent, obj.GetName())
+	u := common.GetApiUrl(nil) + utils.EncodePath(stdpath.Join("/t", path), true)
+	if s := common.Sign(obj, parent, encrypt); s != "" {
+		u += "?sign=" + s
+	}
+	return u
+}
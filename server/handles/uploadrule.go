@@ -0,0 +1,114 @@
+package handles
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListUploadRules(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	rules, total, err := op.GetUploadRules(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: rules,
+		Total:   total,
+	})
+}
+
+func GetUploadRule(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	r, err := op.GetUploadRuleById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, r)
+}
+
+func checkUploadRule(r *model.UploadRule) error {
+	if r.MaxSize > 0 && r.MinSize > r.MaxSize {
+		return fmt.Errorf("the min size %d is larger than the max size %d", r.MinSize, r.MaxSize)
+	}
+	if r.SrcPath != "" {
+		r.SrcPath = utils.FixAndCleanPath(r.SrcPath)
+	}
+	if r.DstPath != "" {
+		r.DstPath = utils.FixAndCleanPath(r.DstPath)
+	}
+	if r.CopyTo != "" {
+		r.CopyTo = utils.FixAndCleanPath(r.CopyTo)
+	}
+	if r.WebhookID != 0 {
+		if _, err := op.GetWebhookById(r.WebhookID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func CreateUploadRule(c *gin.Context) {
+	var req model.UploadRule
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := checkUploadRule(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.CreateUploadRule(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func UpdateUploadRule(c *gin.Context) {
+	var req model.UploadRule
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := checkUploadRule(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateUploadRule(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func DeleteUploadRule(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteUploadRuleById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
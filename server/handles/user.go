@@ -39,7 +39,9 @@ func CreateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin or guest user can not be created", 400, true)
 		return
 	}
-	if err := op.CreateUser(&req); err != nil {
+	err := op.CreateUser(&req)
+	common.Audit(c, common.AuditUserCreate, req.Username, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
 		common.SuccessResp(c)
@@ -67,11 +69,14 @@ func UpdateUser(c *gin.Context) {
 	if req.OtpSecret == "" {
 		req.OtpSecret = user.OtpSecret
 	}
+	req.RecoveryCodes = user.RecoveryCodes
 	if req.Disabled && req.IsAdmin() {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
 	}
-	if err := op.UpdateUser(&req); err != nil {
+	err = op.UpdateUser(&req)
+	common.Audit(c, common.AuditUserUpdate, user.Username, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -85,10 +90,16 @@ func DeleteUser(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	target := "user " + idStr
+	if user, err := op.GetUserById(uint(id)); err == nil {
+		target = user.Username
+	}
 	if err := op.DeleteUserById(uint(id)); err != nil {
+		common.Audit(c, common.AuditUserDelete, target, err)
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	common.Audit(c, common.AuditUserDelete, target, nil)
 	common.SuccessResp(c)
 }
 
@@ -120,3 +131,19 @@ func Cancel2FAById(c *gin.Context) {
 	}
 	common.SuccessResp(c)
 }
+
+// ResetQuotaUsage clears the uploaded bytes counted against the quota of
+// the user
+func ResetQuotaUsage(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.ResetQuotaUsage(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
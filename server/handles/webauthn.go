@@ -0,0 +1,331 @@
+package handles
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/cluster"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/pkg/errors"
+)
+
+// webAuthnSessions keeps the challenges between the begin and the finish of
+// registrations and logins
+var webAuthnSessions = cluster.NewStore[*webauthn.SessionData]("webauthn")
+
+const webAuthnTimeout = time.Minute * 5
+
+// webAuthnUser adapts the user to webauthn.User
+type webAuthnUser struct {
+	user        *model.User
+	credentials []model.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(strconv.FormatUint(uint64(u.user.ID), 10))
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		var credential webauthn.Credential
+		if err := utils.Json.UnmarshalFromString(c.Credential, &credential); err != nil {
+			continue
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials
+}
+
+// find returns the stored credential with the id
+func (u *webAuthnUser) find(id []byte) *model.WebAuthnCredential {
+	credentialID := base64.RawURLEncoding.EncodeToString(id)
+	for i := range u.credentials {
+		if u.credentials[i].CredentialID == credentialID {
+			return &u.credentials[i]
+		}
+	}
+	return nil
+}
+
+func getWebAuthnUser(user *model.User) (*webAuthnUser, error) {
+	credentials, err := op.GetWebAuthnCredentialsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// newWebAuthn makes the relying party of the site, the id of which is the
+// host of the site url
+func newWebAuthn(c *gin.Context) (*webauthn.WebAuthn, error) {
+	u, err := url.Parse(common.GetApiUrl(c.Request))
+	if err != nil {
+		return nil, err
+	}
+	return webauthn.New(&webauthn.Config{
+		RPID:          u.Hostname(),
+		RPDisplayName: "Alist",
+		RPOrigins:     []string{fmt.Sprintf("%s://%s", u.Scheme, u.Host)},
+	})
+}
+
+func webAuthnSessionKey(kind string, userID uint) string {
+	return fmt.Sprintf("%s-%d", kind, userID)
+}
+
+func BeginWebAuthnRegistration(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "Guest user can not register webauthn", 403)
+		return
+	}
+	w, err := newWebAuthn(c)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(wu.credentials))
+	for _, credential := range wu.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+	creation, session, err := w.BeginRegistration(wu,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	webAuthnSessions.Set(webAuthnSessionKey("register", user.ID), session, webAuthnTimeout)
+	common.SuccessResp(c, creation)
+}
+
+// FinishWebAuthnRegistration saves the credential created by the
+// authenticator, the title of which is in the query. Recovery codes are
+// returned if the user has none.
+func FinishWebAuthnRegistration(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	key := webAuthnSessionKey("register", user.ID)
+	session, ok := webAuthnSessions.Get(key)
+	if !ok {
+		common.ErrorStrResp(c, "webauthn registration is expired", 400)
+		return
+	}
+	webAuthnSessions.Del(key)
+	w, err := newWebAuthn(c)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	credential, err := w.FinishRegistration(wu, *session, c.Request)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	data, err := utils.Json.MarshalToString(credential)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	title := c.Query("title")
+	if title == "" {
+		title = "Security key " + time.Now().Format("2006-01-02")
+	}
+	wc := &model.WebAuthnCredential{
+		UserID:       user.ID,
+		Title:        title,
+		CredentialID: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Credential:   data,
+	}
+	if err := op.CreateWebAuthnCredential(wc); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	var codes []string
+	if user.RecoveryCodes == "" {
+		codes, err = op.GenerateRecoveryCodes(user)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c, gin.H{"credential": wc, "recovery_codes": codes})
+}
+
+func ListWebAuthnCredentials(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	credentials, err := op.GetWebAuthnCredentialsByUserID(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, credentials)
+}
+
+func DeleteWebAuthnCredential(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if err := op.DeleteWebAuthnCredential(user.ID, uint(id)); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type WebAuthnLoginReq struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password"`
+}
+
+// BeginWebAuthnLogin validates the password and challenges the
+// authenticators of the user, webauthn is the second factor here
+func BeginWebAuthnLogin(c *gin.Context) {
+	ip := c.ClientIP()
+	count, ok := loginCache.Get(ip)
+	if ok && count >= defaultTimes {
+		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
+		loginCache.Expire(ip, defaultDuration)
+		return
+	}
+	var req WebAuthnLoginReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user, err := op.GetUserByName(req.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		loginCache.Set(ip, count+1, 0)
+		return
+	}
+	if err := user.ValidatePassword(req.Password); err != nil {
+		common.ErrorResp(c, err, 400)
+		common.AuditAs(c, user, "", common.AuditLogin, "", err, "webauthn")
+		loginCache.Set(ip, count+1, 0)
+		return
+	}
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if len(wu.credentials) == 0 {
+		common.ErrorStrResp(c, "no webauthn credential is registered", 400)
+		return
+	}
+	w, err := newWebAuthn(c)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	assertion, session, err := w.BeginLogin(wu)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	webAuthnSessions.Set(webAuthnSessionKey("login", user.ID), session, webAuthnTimeout)
+	common.SuccessResp(c, assertion)
+}
+
+// FinishWebAuthnLogin verifies the assertion of the user in the query and
+// generates the token
+func FinishWebAuthnLogin(c *gin.Context) {
+	ip := c.ClientIP()
+	count, ok := loginCache.Get(ip)
+	if ok && count >= defaultTimes {
+		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
+		loginCache.Expire(ip, defaultDuration)
+		return
+	}
+	user, err := op.GetUserByName(c.Query("username"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		loginCache.Set(ip, count+1, 0)
+		return
+	}
+	key := webAuthnSessionKey("login", user.ID)
+	session, ok := webAuthnSessions.Get(key)
+	if !ok {
+		common.ErrorStrResp(c, "webauthn login is expired", 400)
+		return
+	}
+	webAuthnSessions.Del(key)
+	w, err := newWebAuthn(c)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	wu, err := getWebAuthnUser(user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	credential, err := w.FinishLogin(wu, *session, c.Request)
+	if err != nil {
+		common.ErrorResp(c, err, 402)
+		common.AuditAs(c, user, "", common.AuditLogin, "", err, "webauthn")
+		loginCache.Set(ip, count+1, 0)
+		return
+	}
+	// the sign count going back means the authenticator may be cloned, the
+	// saved count is kept so that the clone keeps failing
+	if credential.Authenticator.CloneWarning {
+		err = errors.New("the sign count of the authenticator went back, it may be cloned")
+		common.ErrorResp(c, err, 402)
+		common.AuditAs(c, user, "", common.AuditLogin, "", err, "webauthn")
+		loginCache.Set(ip, count+1, 0)
+		return
+	}
+	// save the sign count to detect cloned authenticators
+	if wc := wu.find(credential.ID); wc != nil {
+		if data, err := utils.Json.MarshalToString(credential); err == nil {
+			wc.Credential = data
+			wc.LastUsed = time.Now()
+			_ = op.UpdateWebAuthnCredential(wc)
+		}
+	}
+	token, err := common.GenerateToken(user.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 400, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"token": token})
+	common.AuditAs(c, user, "", common.AuditLogin, "", nil, "webauthn")
+	loginCache.Del(ip)
+}
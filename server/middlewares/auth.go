@@ -76,3 +76,12 @@ func AuthAdmin(c *gin.Context) {
 		c.Next()
 	}
 }
+
+// TokenQuery takes the token from the query if the header is empty, for the
+// clients that can't set the headers, such as EventSource of the browsers
+func TokenQuery(c *gin.Context) {
+	if token := c.Query("token"); token != "" && c.GetHeader("Authorization") == "" {
+		c.Request.Header.Set("Authorization", token)
+	}
+	c.Next()
+}
@@ -35,7 +35,8 @@ func FsUp(c *gin.Context) {
 			return
 		}
 	}
-	if !(common.CanAccess(user, meta, path, password) && (user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path)))) {
+	canWrite := common.HasPermission(user, path, model.PermWrite, user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path)))
+	if !(common.CanAccess(user, meta, path, password) && canWrite) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		c.Abort()
 		return
@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics observes the latency of the request by the route template
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.RequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"github.com/alist-org/alist/v3/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Tracing starts the span of the request by the route template, whose parent
+// is propagated by the headers of the request
+func Tracing(c *gin.Context) {
+	if !tracing.Enabled() {
+		c.Next()
+		return
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+	ctx, span := tracing.Start(ctx, c.Request.Method+" "+route,
+		attribute.String("http.method", c.Request.Method),
+		attribute.String("http.route", route),
+	)
+	defer span.End()
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(tracing.SpanKey, span)
+	c.Next()
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, "")
+	}
+}
@@ -21,9 +21,12 @@ func Init(e *gin.Engine) {
 	}
 	Cors(e)
 	g := e.Group(conf.URL.Path)
+	g.Use(middlewares.Metrics)
 	g.Any("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
+	g.GET("/metrics", handles.Metrics)
+	g.Use(middlewares.Tracing)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
 	g.Use(middlewares.StoragesLoaded)
 	if conf.Conf.MaxConnections > 0 {
@@ -35,6 +38,14 @@ func Init(e *gin.Engine) {
 	g.GET("/i/:link_name", handles.Plist)
 	g.GET("/d/*path", middlewares.Down, handles.Down)
 	g.GET("/p/*path", middlewares.Down, handles.Proxy)
+	g.GET("/t/*path", middlewares.Down, handles.Thumb)
+	g.GET("/sd/:token/*path", handles.ShareDown)
+	g.GET("/ar/*path", handles.ArchiveDown)
+	g.GET("/vd/*path", handles.VersionDown)
+	g.GET("/tl/*path", handles.TempLinkDown)
+	g.HEAD("/tl/*path", handles.TempLinkDown)
+	g.GET("/pv/*path", handles.PreviewDown)
+	g.GET("/hls/*path", handles.HlsDown)
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth)
@@ -44,6 +55,17 @@ func Init(e *gin.Engine) {
 	auth.POST("/me/update", handles.UpdateCurrent)
 	auth.POST("/auth/2fa/generate", handles.Generate2FA)
 	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/auth/2fa/recovery_codes", handles.GenerateRecoveryCodes)
+	auth.GET("/auth/webauthn/credentials", handles.ListWebAuthnCredentials)
+	auth.POST("/auth/webauthn/register/begin", handles.BeginWebAuthnRegistration)
+	auth.POST("/auth/webauthn/register/finish", handles.FinishWebAuthnRegistration)
+	auth.POST("/auth/webauthn/credential/delete", handles.DeleteWebAuthnCredential)
+	api.POST("/auth/webauthn/login/begin", handles.BeginWebAuthnLogin)
+	api.POST("/auth/webauthn/login/finish", handles.FinishWebAuthnLogin)
+	api.GET("/task/events", middlewares.TokenQuery, middlewares.Auth, handles.TaskEvents)
+	auth.GET("/me/app_passwords", handles.ListAppPasswords)
+	auth.POST("/me/app_password/create", handles.CreateAppPassword)
+	auth.POST("/me/app_password/delete", handles.DeleteAppPassword)
 
 	// github auth
 	api.GET("/auth/sso", handles.SSOLoginRedirect)
@@ -52,6 +74,12 @@ func Init(e *gin.Engine) {
 	// no need auth
 	public := api.Group("/public")
 	public.Any("/settings", handles.PublicSettings)
+	public.POST("/share/get", handles.ShareGet)
+
+	share := auth.Group("/share")
+	share.POST("/create", handles.CreateShare)
+	share.GET("/list", handles.ListShares)
+	share.POST("/delete", handles.DeleteShare)
 
 	_fs(auth.Group("/fs"))
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
@@ -77,7 +105,65 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/create", handles.CreateUser)
 	user.POST("/update", handles.UpdateUser)
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
+	user.POST("/reset_quota", handles.ResetQuotaUsage)
 	user.POST("/delete", handles.DeleteUser)
+	user.GET("/s3_keys", handles.ListS3Keys)
+	user.POST("/s3_key/create", handles.CreateS3Key)
+	user.POST("/s3_key/delete", handles.DeleteS3Key)
+	user.GET("/ssh_keys", handles.ListSSHKeys)
+	user.POST("/ssh_key/create", handles.CreateSSHKey)
+	user.POST("/ssh_key/delete", handles.DeleteSSHKey)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+	group.GET("/users", handles.ListGroupUsers)
+	group.POST("/add_user", handles.AddGroupUser)
+	group.POST("/remove_user", handles.RemoveGroupUser)
+
+	acl := g.Group("/acl")
+	acl.GET("/list", handles.ListACLRules)
+	acl.GET("/get", handles.GetACLRule)
+	acl.POST("/create", handles.CreateACLRule)
+	acl.POST("/update", handles.UpdateACLRule)
+	acl.POST("/delete", handles.DeleteACLRule)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/get", handles.GetWebhook)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.POST("/test", handles.TestWebhook)
+
+	slowOperation := g.Group("/slow_operation")
+	slowOperation.GET("/list", handles.ListSlowOperations)
+	slowOperation.POST("/clear", handles.ClearSlowOperations)
+
+	uploadRule := g.Group("/upload_rule")
+	uploadRule.GET("/list", handles.ListUploadRules)
+	uploadRule.GET("/get", handles.GetUploadRule)
+	uploadRule.POST("/create", handles.CreateUploadRule)
+	uploadRule.POST("/update", handles.UpdateUploadRule)
+	uploadRule.POST("/delete", handles.DeleteUploadRule)
+
+	symlink := g.Group("/symlink")
+	symlink.GET("/list", handles.ListSymlinks)
+	symlink.GET("/get", handles.GetSymlink)
+	symlink.POST("/create", handles.CreateSymlink)
+	symlink.POST("/update", handles.UpdateSymlink)
+	symlink.POST("/delete", handles.DeleteSymlink)
+
+	syncJob := g.Group("/sync_job")
+	syncJob.GET("/list", handles.ListSyncJobs)
+	syncJob.GET("/get", handles.GetSyncJob)
+	syncJob.POST("/create", handles.CreateSyncJob)
+	syncJob.POST("/update", handles.UpdateSyncJob)
+	syncJob.POST("/delete", handles.DeleteSyncJob)
+	syncJob.POST("/run", handles.RunSyncJob)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -88,6 +174,8 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/health", handles.ListStoragesHealth)
+	storage.POST("/check_health", handles.CheckStorageHealth)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -102,6 +190,15 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/reset_token", handles.ResetToken)
 	setting.POST("/set_aria2", handles.SetAria2)
 	setting.POST("/set_qbit", handles.SetQbittorrent)
+	g.POST("/reload", handles.Reload)
+
+	config := g.Group("/config")
+	config.POST("/export", handles.ExportConfig)
+	config.POST("/import", handles.ImportConfig)
+
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
 
 	task := g.Group("/task")
 	handles.SetupTaskRoute(task)
@@ -123,6 +220,7 @@ func _fs(g *gin.RouterGroup) {
 	g.Any("/search", middlewares.SearchIndex, handles.Search)
 	g.Any("/get", handles.FsGet)
 	g.Any("/other", handles.FsOther)
+	g.Any("/capabilities", handles.FsCapabilities)
 	g.Any("/dirs", handles.FsDirs)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
@@ -132,8 +230,27 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.POST("/batch", handles.FsBatch)
+	g.GET("/batch/get", handles.FsBatchGet)
+	g.GET("/batch/stream", handles.FsBatchStream)
+	g.POST("/batch/cancel", handles.FsBatchCancel)
+	g.POST("/archive", handles.FsArchive)
+	g.POST("/versions", handles.FsVersions)
+	g.POST("/version/restore", handles.FsRestoreVersion)
+	g.POST("/trash/list", handles.FsTrashList)
+	g.POST("/trash/restore", handles.FsTrashRestore)
+	g.POST("/trash/delete", handles.FsTrashDelete)
+	g.POST("/preview", handles.FsPreview)
+	g.POST("/hls", handles.FsHls)
+	g.POST("/hash", handles.FsHash)
+	g.POST("/temp_link", handles.FsTempLink)
 	g.PUT("/put", middlewares.FsUp, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, handles.FsForm)
+	g.OPTIONS("/tus", handles.TusOptions)
+	g.POST("/tus", middlewares.FsUp, handles.TusCreate)
+	g.HEAD("/tus/:id", handles.TusHead)
+	g.PATCH("/tus/:id", handles.TusPatch)
+	g.DELETE("/tus/:id", handles.TusDelete)
 	g.POST("/link", middlewares.AuthAdmin, handles.Link)
 	g.POST("/add_aria2", handles.AddAria2)
 	g.POST("/add_qbit", handles.AddQbittorrent)
@@ -144,5 +261,6 @@ func Cors(r *gin.Engine) {
 	config.AllowAllOrigins = true
 	config.AllowHeaders = []string{"*"}
 	config.AllowMethods = []string{"*"}
+	config.ExposeHeaders = handles.TusHeaders
 	r.Use(cors.New(config))
 }
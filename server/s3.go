@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/middlewares"
+	"github.com/alist-org/alist/v3/server/s3"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+var s3Handler = &s3.Handler{
+	Lookup: op.GetUserByS3Key,
+	Logger: func(request *http.Request, err error) {
+		log.Debugf("[s3] %s %s %+v", request.Method, request.URL.Path, err)
+	},
+}
+
+// InitS3 serves the s3 api on its own engine, as s3 clients expect the
+// buckets at the root of the endpoint
+func InitS3(e *gin.Engine) {
+	Cors(e)
+	e.Use(middlewares.StoragesLoaded)
+	e.Any("/*path", func(c *gin.Context) {
+		s3Handler.ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -0,0 +1,252 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+// Requests are authenticated with AWS Signature Version 4, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+
+const (
+	signV4Algorithm          = "AWS4-HMAC-SHA256"
+	unsignedPayload          = "UNSIGNED-PAYLOAD"
+	streamingPayload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	emptySHA256              = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	iso8601Format            = "20060102T150405Z"
+	yyyymmdd                 = "20060102"
+	maxClockSkew             = 15 * time.Minute
+	maxPresignExpires        = 7 * 24 * time.Hour
+)
+
+// KeyLookup returns the user and the secret key of an access key
+type KeyLookup func(accessKeyID string) (*model.User, string, error)
+
+// signature is the verified signature of a request, it's needed to verify
+// the chunks of a streaming upload
+type signature struct {
+	user        *model.User
+	signingKey  []byte
+	amzDate     string
+	scope       string
+	seed        string
+	payloadHash string
+}
+
+type credential struct {
+	accessKeyID string
+	date        string
+	region      string
+	service     string
+}
+
+func (c credential) scope() string {
+	return strings.Join([]string{c.date, c.region, c.service, "aws4_request"}, "/")
+}
+
+func parseCredential(s string) (credential, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || parts[0] == "" {
+		return credential{}, ErrAuthorizationHeaderMalformed
+	}
+	return credential{
+		accessKeyID: parts[0],
+		date:        parts[1],
+		region:      parts[2],
+		service:     parts[3],
+	}, nil
+}
+
+// authenticate verifies the signature of the request, which is either in
+// the Authorization header or in the query of a presigned url.
+func authenticate(r *http.Request, now time.Time, lookup KeyLookup) (*signature, error) {
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != "" {
+		return authenticatePresigned(r, query, now, lookup)
+	}
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return nil, ErrAccessDenied
+	}
+	if !strings.HasPrefix(auth, signV4Algorithm+" ") {
+		// signature version 2 is deprecated
+		return nil, ErrSignatureDoesNotMatch
+	}
+	fields := make(map[string]string)
+	for _, f := range strings.Split(strings.TrimPrefix(auth, signV4Algorithm+" "), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(f), "=")
+		if !ok {
+			return nil, ErrAuthorizationHeaderMalformed
+		}
+		fields[k] = v
+	}
+	cred, err := parseCredential(fields["Credential"])
+	if err != nil {
+		return nil, err
+	}
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if fields["SignedHeaders"] == "" || fields["Signature"] == "" {
+		return nil, ErrAuthorizationHeaderMalformed
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate == "" {
+		amzDate = r.Header.Get("Date")
+	}
+	t, err := time.Parse(iso8601Format, amzDate)
+	if err != nil {
+		return nil, ErrAccessDenied
+	}
+	if t.Sub(now) > maxClockSkew || now.Sub(t) > maxClockSkew {
+		return nil, ErrRequestTimeTooSkewed
+	}
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = emptySHA256
+	}
+	return verify(r, cred, signedHeaders, payloadHash, amzDate, fields["Signature"], query, lookup)
+}
+
+func authenticatePresigned(r *http.Request, query url.Values, now time.Time, lookup KeyLookup) (*signature, error) {
+	if query.Get("X-Amz-Algorithm") != signV4Algorithm {
+		return nil, ErrSignatureDoesNotMatch
+	}
+	cred, err := parseCredential(query.Get("X-Amz-Credential"))
+	if err != nil {
+		return nil, err
+	}
+	amzDate := query.Get("X-Amz-Date")
+	t, err := time.Parse(iso8601Format, amzDate)
+	if err != nil {
+		return nil, ErrAccessDenied
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires < 0 || time.Duration(expires)*time.Second > maxPresignExpires {
+		return nil, ErrInvalidArgument
+	}
+	if t.Sub(now) > maxClockSkew {
+		return nil, ErrRequestTimeTooSkewed
+	}
+	if now.After(t.Add(time.Duration(expires) * time.Second)) {
+		return nil, ErrExpiredPresignRequest
+	}
+	signedHeaders := strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+	sig := query.Get("X-Amz-Signature")
+	query.Del("X-Amz-Signature")
+	return verify(r, cred, signedHeaders, unsignedPayload, amzDate, sig, query, lookup)
+}
+
+func verify(r *http.Request, cred credential, signedHeaders []string, payloadHash, amzDate, sig string, query url.Values, lookup KeyLookup) (*signature, error) {
+	user, secret, err := lookup(cred.accessKeyID)
+	if err != nil {
+		return nil, ErrInvalidAccessKeyID
+	}
+	canonical := strings.Join([]string{
+		r.Method,
+		uriEncode(r.URL.Path, false),
+		canonicalQuery(query),
+		canonicalHeaders(r, signedHeaders),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+	key := signingKey(secret, cred)
+	expected := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign(amzDate, cred.scope(), sha256Hex([]byte(canonical))))))
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return nil, ErrSignatureDoesNotMatch
+	}
+	return &signature{
+		user:        user,
+		signingKey:  key,
+		amzDate:     amzDate,
+		scope:       cred.scope(),
+		seed:        sig,
+		payloadHash: payloadHash,
+	}, nil
+}
+
+func stringToSign(amzDate, scope, hash string) string {
+	return strings.Join([]string{signV4Algorithm, amzDate, scope, hash}, "\n")
+}
+
+func signingKey(secret string, cred credential) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(cred.date))
+	key = hmacSHA256(key, []byte(cred.region))
+	key = hmacSHA256(key, []byte(cred.service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		vals := append([]string(nil), query[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func canonicalHeaders(r *http.Request, signedHeaders []string) string {
+	var b strings.Builder
+	for _, h := range signedHeaders {
+		var vals []string
+		switch h {
+		case "host":
+			vals = []string{r.Host}
+		case "content-length":
+			vals = []string{strconv.FormatInt(r.ContentLength, 10)}
+		default:
+			vals = r.Header.Values(h)
+		}
+		for i, v := range vals {
+			vals[i] = strings.Join(strings.Fields(v), " ")
+		}
+		b.WriteString(h + ":" + strings.Join(vals, ",") + "\n")
+	}
+	return b.String()
+}
+
+// uriEncode encodes s as required by the canonical request, every byte
+// except the unreserved characters is percent-encoded.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexChars = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexChars[c>>4])
+		b.WriteByte(hexChars[c&15])
+	}
+	return b.String()
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
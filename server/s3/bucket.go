@@ -0,0 +1,237 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+)
+
+const maxKeys = 1000
+
+func (h *Handler) listBuckets(req *request) error {
+	res := listAllMyBucketsResult{
+		Xmlns: xmlns,
+		Owner: owner{ID: strconv.Itoa(int(req.user.ID)), DisplayName: req.user.Username},
+	}
+	for _, b := range getBuckets() {
+		root, err := req.user.JoinPath(b.Path)
+		if err != nil {
+			continue
+		}
+		obj, err := fs.Get(req.ctx, root, &fs.GetArgs{NoLog: true})
+		if err != nil || !obj.IsDir() {
+			continue
+		}
+		res.Buckets = append(res.Buckets, bucketInfo{Name: b.Name, CreationDate: obj.ModTime().UTC()})
+	}
+	writeXML(req.w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) headBucket(req *request) error {
+	obj, err := fs.Get(req.ctx, req.root, &fs.GetArgs{NoLog: true})
+	if err != nil || !obj.IsDir() {
+		return ErrNoSuchBucket
+	}
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// entry is an object or a common prefix of a listing
+type entry struct {
+	key    string
+	obj    model.Obj
+	prefix bool
+}
+
+// listObjects implements both ListObjects and ListObjectsV2
+func (h *Handler) listObjects(req *request) error {
+	q := req.r.URL.Query()
+	v2 := q.Get("list-type") == "2"
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	limit := maxKeys
+	if s := q.Get("max-keys"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return ErrInvalidArgument
+		}
+		if n < limit {
+			limit = n
+		}
+	}
+	marker := q.Get("marker")
+	if v2 {
+		marker = q.Get("start-after")
+		if token := q.Get("continuation-token"); token != "" {
+			data, err := base64.StdEncoding.DecodeString(token)
+			if err != nil {
+				return ErrInvalidArgument
+			}
+			marker = string(data)
+		}
+	}
+	entries, err := h.collect(req, prefix, delimiter)
+	if err != nil {
+		return err
+	}
+	encode := func(s string) string { return s }
+	if q.Get("encoding-type") == "url" {
+		encode = func(s string) string { return uriEncode(s, false) }
+	}
+
+	res := listBucketResult{
+		Xmlns:        xmlns,
+		Name:         req.bucket,
+		Prefix:       encode(prefix),
+		Delimiter:    encode(delimiter),
+		MaxKeys:      limit,
+		EncodingType: q.Get("encoding-type"),
+	}
+	var last string
+	count := 0
+	for _, e := range entries {
+		if e.key <= marker {
+			continue
+		}
+		if count >= limit {
+			res.IsTruncated = true
+			break
+		}
+		count++
+		last = e.key
+		if e.prefix {
+			res.CommonPrefixes = append(res.CommonPrefixes, commonPrefix{Prefix: encode(e.key)})
+			continue
+		}
+		o := object{
+			Key:          encode(e.key),
+			LastModified: e.obj.ModTime().UTC(),
+			ETag:         etag(e.obj),
+			Size:         e.obj.GetSize(),
+			StorageClass: "STANDARD",
+		}
+		if !v2 || q.Get("fetch-owner") == "true" {
+			o.Owner = &owner{ID: strconv.Itoa(int(req.user.ID)), DisplayName: req.user.Username}
+		}
+		res.Contents = append(res.Contents, o)
+	}
+	if v2 {
+		res.KeyCount = &count
+		res.StartAfter = encode(q.Get("start-after"))
+		res.ContinuationToken = q.Get("continuation-token")
+		if res.IsTruncated {
+			res.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(last))
+		}
+	} else {
+		m := encode(marker)
+		res.Marker = &m
+		if res.IsTruncated {
+			res.NextMarker = encode(last)
+		}
+	}
+	writeXML(req.w, http.StatusOK, res)
+	return nil
+}
+
+// collect returns the sorted entries of the bucket matching the prefix.
+// Folders are listed one level at a time for the "/" delimiter, other
+// delimiters need the whole tree under the prefix.
+func (h *Handler) collect(req *request, prefix, delimiter string) ([]entry, error) {
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	var entries []entry
+	var err error
+	if delimiter == "/" {
+		err = h.walk(req, dir, prefix, false, &entries, nil)
+	} else {
+		err = h.walk(req, dir, prefix, true, &entries, op.WalkedDirs{})
+		if delimiter != "" {
+			entries = groupByDelimiter(entries, prefix, delimiter)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	return entries, nil
+}
+
+// walk lists the folder of dir, and the folders below it if recursive, in
+// which case walked keeps the folders reached again through symlinks out
+func (h *Handler) walk(req *request, dir, prefix string, recursive bool, entries *[]entry, walked op.WalkedDirs) error {
+	p, err := objectPath(req.root, dir)
+	if err != nil {
+		return err
+	}
+	if recursive && !walked.Visit(p) {
+		return nil
+	}
+	meta, _ := op.GetNearestMeta(p)
+	objs, err := fs.List(context.WithValue(req.ctx, "meta", meta), p, &fs.ListArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, obj := range objs {
+		key := dir + obj.GetName()
+		if !obj.IsDir() {
+			if strings.HasPrefix(key, prefix) {
+				*entries = append(*entries, entry{key: key, obj: obj})
+			}
+			continue
+		}
+		key += "/"
+		if !recursive {
+			if strings.HasPrefix(key, prefix) {
+				*entries = append(*entries, entry{key: key, prefix: true})
+			}
+			continue
+		}
+		if strings.HasPrefix(key, prefix) || strings.HasPrefix(prefix, key) {
+			if err := h.walk(req, key, prefix, true, entries, walked); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func groupByDelimiter(entries []entry, prefix, delimiter string) []entry {
+	res := entries[:0]
+	seen := make(map[string]struct{})
+	for _, e := range entries {
+		i := strings.Index(e.key[len(prefix):], delimiter)
+		if i < 0 {
+			res = append(res, e)
+			continue
+		}
+		p := e.key[:len(prefix)+i+len(delimiter)]
+		if _, ok := seen[p]; !ok {
+			seen[p] = struct{}{}
+			res = append(res, entry{key: p, prefix: true})
+		}
+	}
+	return res
+}
+
+// etag of objects that were not uploaded through the s3 server, it doesn't
+// look like a md5 so that clients won't verify the content with it
+func etag(obj model.Obj) string {
+	return fmt.Sprintf(`"%x-%x"`, obj.ModTime().UnixNano(), obj.GetSize())
+}
+
+func lastModified(obj model.Obj) string {
+	return obj.ModTime().UTC().Format(http.TimeFormat)
+}
@@ -0,0 +1,155 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxChunkSize limits the memory used by a chunk of a streaming upload
+const maxChunkSize = 16 * 1024 * 1024
+
+// payloadReader returns the body of the request, decoding the aws-chunked
+// encoding and verifying the payload hash or chunk signatures.
+func payloadReader(body io.Reader, sig *signature) io.Reader {
+	switch sig.payloadHash {
+	case unsignedPayload:
+		return body
+	case streamingPayload:
+		return &chunkedReader{r: bufio.NewReader(body), sig: sig, prev: sig.seed, signed: true}
+	case streamingUnsignedTrailer:
+		return &chunkedReader{r: bufio.NewReader(body), sig: sig}
+	default:
+		return &hashReader{r: body, h: sha256.New(), expected: sig.payloadHash}
+	}
+}
+
+// hashReader verifies the sha256 of the payload when it reaches EOF
+type hashReader struct {
+	r        io.Reader
+	h        hash.Hash
+	expected string
+}
+
+func (r *hashReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.h.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(r.h.Sum(nil)) != r.expected {
+		return n, ErrContentSHA256Mismatch
+	}
+	return n, err
+}
+
+// chunkedReader decodes the aws-chunked content encoding, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
+type chunkedReader struct {
+	r      *bufio.Reader
+	sig    *signature
+	signed bool
+	// prev is the signature of the previous chunk
+	prev string
+	buf  []byte
+	left []byte
+	done bool
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for len(c.left) == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.left)
+	c.left = c.left[n:]
+	return n, nil
+}
+
+// next reads the next chunk into buf
+func (c *chunkedReader) next() error {
+	line, err := c.readLine()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	sizeStr, ext, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return ErrInvalidArgument
+	}
+	if int64(cap(c.buf)) < size {
+		c.buf = make([]byte, size)
+	}
+	c.buf = c.buf[:size]
+	if _, err = io.ReadFull(c.r, c.buf); err != nil {
+		return err
+	}
+	if c.signed {
+		chunkSig := strings.TrimPrefix(ext, "chunk-signature=")
+		if !c.verify(chunkSig) {
+			return ErrSignatureDoesNotMatch
+		}
+		c.prev = chunkSig
+	}
+	if size == 0 {
+		c.done = true
+		return c.skipTrailer()
+	}
+	// every chunk ends with a crlf
+	if line, err = c.readLine(); err != nil || line != "" {
+		return ErrInvalidArgument
+	}
+	c.left = c.buf
+	return nil
+}
+
+func (c *chunkedReader) verify(chunkSig string) bool {
+	toSign := strings.Join([]string{
+		signV4Algorithm + "-PAYLOAD",
+		c.sig.amzDate,
+		c.sig.scope,
+		c.prev,
+		emptySHA256,
+		sha256Hex(c.buf),
+	}, "\n")
+	expected := hex.EncodeToString(hmacSHA256(c.sig.signingKey, []byte(toSign)))
+	return hmac.Equal([]byte(expected), []byte(chunkSig))
+}
+
+// skipTrailer skips the trailing headers after the last chunk, which are
+// checksums we don't verify
+func (c *chunkedReader) skipTrailer() error {
+	for {
+		line, err := c.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil || line == "" {
+			return err
+		}
+	}
+}
+
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err == io.EOF && len(line) == 0 {
+		return "", io.EOF
+	}
+	if err != nil {
+		if err == io.EOF || err == bufio.ErrBufferFull {
+			return "", ErrInvalidArgument
+		}
+		return "", err
+	}
+	return string(bytes.TrimRight(line, "\r\n")), nil
+}
@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// APIError is an error with the code and status defined by the s3 api, see
+// https://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+type APIError struct {
+	Code    string
+	Message string
+	Status  int
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+var (
+	ErrAccessDenied                 = &APIError{"AccessDenied", "Access Denied.", http.StatusForbidden}
+	ErrAuthorizationHeaderMalformed = &APIError{"AuthorizationHeaderMalformed", "The authorization header is malformed.", http.StatusBadRequest}
+	ErrBadDigest                    = &APIError{"BadDigest", "The Content-MD5 you specified did not match what we received.", http.StatusBadRequest}
+	ErrBucketAlreadyOwnedByYou      = &APIError{"BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.", http.StatusConflict}
+	ErrContentSHA256Mismatch        = &APIError{"XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.", http.StatusBadRequest}
+	ErrExpiredPresignRequest        = &APIError{"AccessDenied", "Request has expired.", http.StatusForbidden}
+	ErrInternalError                = &APIError{"InternalError", "We encountered an internal error, please try again.", http.StatusInternalServerError}
+	ErrInvalidAccessKeyID           = &APIError{"InvalidAccessKeyId", "The access key Id you provided does not exist in our records.", http.StatusForbidden}
+	ErrInvalidArgument              = &APIError{"InvalidArgument", "Invalid Argument.", http.StatusBadRequest}
+	ErrInvalidPart                  = &APIError{"InvalidPart", "One or more of the specified parts could not be found.", http.StatusBadRequest}
+	ErrInvalidPartOrder             = &APIError{"InvalidPartOrder", "The list of parts was not in ascending order.", http.StatusBadRequest}
+	ErrInvalidRange                 = &APIError{"InvalidRange", "The requested range is not satisfiable.", http.StatusRequestedRangeNotSatisfiable}
+	ErrMalformedXML                 = &APIError{"MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest}
+	ErrMethodNotAllowed             = &APIError{"MethodNotAllowed", "The specified method is not allowed against this resource.", http.StatusMethodNotAllowed}
+	ErrMissingContentLength         = &APIError{"MissingContentLength", "You must provide the Content-Length HTTP header.", http.StatusLengthRequired}
+	ErrNoSuchBucket                 = &APIError{"NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound}
+	ErrNoSuchKey                    = &APIError{"NoSuchKey", "The specified key does not exist.", http.StatusNotFound}
+	ErrNoSuchUpload                 = &APIError{"NoSuchUpload", "The specified multipart upload does not exist.", http.StatusNotFound}
+	ErrNotImplemented               = &APIError{"NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented}
+	ErrRequestTimeTooSkewed         = &APIError{"RequestTimeTooSkewed", "The difference between the request time and the server's time is too large.", http.StatusForbidden}
+	ErrSignatureDoesNotMatch        = &APIError{"SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden}
+	ErrSlowDown                     = &APIError{"SlowDown", "Please reduce your request rate.", http.StatusServiceUnavailable}
+)
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource"`
+	RequestID string   `xml:"RequestId"`
+}
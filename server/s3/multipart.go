@@ -0,0 +1,309 @@
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/google/uuid"
+)
+
+// The parts of multipart uploads are kept in the temp dir until the upload
+// is completed, then they are uploaded to alist as a single stream.
+
+const maxPartNumber = 10000
+
+type multipartUpload struct {
+	UserID    uint        `json:"user_id"`
+	Bucket    string      `json:"bucket"`
+	Key       string      `json:"key"`
+	Header    http.Header `json:"header"`
+	Initiated time.Time   `json:"initiated"`
+}
+
+func uploadDir(id string) string {
+	return filepath.Join(conf.Conf.TempDir, "s3", id)
+}
+
+func partPath(id string, n int) string {
+	return filepath.Join(uploadDir(id), strconv.Itoa(n))
+}
+
+func (h *Handler) createMultipartUpload(req *request) error {
+	p, err := objectPath(req.root, req.key)
+	if err != nil {
+		return err
+	}
+	if !common.HasPermission(req.user, p, model.PermWrite, req.user.CanWrite()) {
+		return ErrAccessDenied
+	}
+	id := uuid.NewString()
+	if err := os.MkdirAll(uploadDir(id), 0777); err != nil {
+		return err
+	}
+	info := multipartUpload{
+		UserID:    req.user.ID,
+		Bucket:    req.bucket,
+		Key:       req.key,
+		Header:    http.Header{},
+		Initiated: time.Now().UTC(),
+	}
+	// only keep the headers needed when putting the object
+	for _, k := range []string{"Content-Type", "X-Amz-Meta-Mtime"} {
+		if v := req.r.Header.Get(k); v != "" {
+			info.Header.Set(k, v)
+		}
+	}
+	data, err := utils.Json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(filepath.Join(uploadDir(id), "info.json"), data, 0644); err != nil {
+		return err
+	}
+	writeXML(req.w, http.StatusOK, initiateMultipartUploadResult{
+		Xmlns:    xmlns,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		UploadID: id,
+	})
+	return nil
+}
+
+// getUpload returns the multipart upload of the request, which must be
+// created by the same user for the same object
+func getUpload(req *request) (string, *multipartUpload, error) {
+	id := req.r.URL.Query().Get("uploadId")
+	if _, err := uuid.Parse(id); err != nil {
+		return "", nil, ErrNoSuchUpload
+	}
+	data, err := os.ReadFile(filepath.Join(uploadDir(id), "info.json"))
+	if err != nil {
+		return "", nil, ErrNoSuchUpload
+	}
+	var info multipartUpload
+	if err = utils.Json.Unmarshal(data, &info); err != nil {
+		return "", nil, err
+	}
+	if info.UserID != req.user.ID || info.Bucket != req.bucket || info.Key != req.key {
+		return "", nil, ErrNoSuchUpload
+	}
+	return id, &info, nil
+}
+
+func (h *Handler) uploadPart(req *request) error {
+	if req.r.Header.Get("X-Amz-Copy-Source") != "" {
+		return ErrNotImplemented
+	}
+	id, _, err := getUpload(req)
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(req.r.URL.Query().Get("partNumber"))
+	if err != nil || n < 1 || n > maxPartNumber {
+		return ErrInvalidArgument
+	}
+	size, err := contentLength(req.r)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(uploadDir(id), "part-")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(f, hash), payloadReader(req.r.Body, req.sig))
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return ErrInvalidArgument
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	// the md5 is kept in a sidecar so that parts can be uploaded concurrently
+	if err = os.WriteFile(partPath(id, n)+".md5", []byte(sum), 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, partPath(id, n)); err != nil {
+		return err
+	}
+	req.w.Header().Set("ETag", `"`+sum+`"`)
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func readPart(id string, n int) (part, error) {
+	stat, err := os.Stat(partPath(id, n))
+	if err != nil {
+		return part{}, ErrInvalidPart
+	}
+	sum, err := os.ReadFile(partPath(id, n) + ".md5")
+	if err != nil {
+		return part{}, ErrInvalidPart
+	}
+	return part{
+		PartNumber:   n,
+		LastModified: stat.ModTime().UTC(),
+		ETag:         `"` + string(sum) + `"`,
+		Size:         stat.Size(),
+	}, nil
+}
+
+func (h *Handler) completeMultipartUpload(req *request) error {
+	id, info, err := getUpload(req)
+	if err != nil {
+		return err
+	}
+	p, err := objectPath(req.root, req.key)
+	if err != nil {
+		return err
+	}
+	var complete completeMultipartUpload
+	if err = xml.NewDecoder(payloadReader(req.r.Body, req.sig)).Decode(&complete); err != nil || len(complete.Parts) == 0 {
+		return ErrMalformedXML
+	}
+	var size int64
+	sums := md5.New()
+	for i, cp := range complete.Parts {
+		if i > 0 && cp.PartNumber <= complete.Parts[i-1].PartNumber {
+			return ErrInvalidPartOrder
+		}
+		pt, err := readPart(id, cp.PartNumber)
+		if err != nil {
+			return err
+		}
+		if strings.Trim(cp.ETag, `"`) != strings.Trim(pt.ETag, `"`) {
+			return ErrInvalidPart
+		}
+		sum, _ := hex.DecodeString(strings.Trim(pt.ETag, `"`))
+		sums.Write(sum)
+		size += pt.Size
+	}
+	r := &partsReader{id: id, parts: complete.Parts}
+	defer r.Close()
+	if _, err = put(req.ctx, p, size, r, info.Header); err != nil {
+		return err
+	}
+	_ = os.RemoveAll(uploadDir(id))
+	writeXML(req.w, http.StatusOK, completeMultipartUploadResult{
+		Xmlns:    xmlns,
+		Location: req.r.URL.Path,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		ETag:     fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sums.Sum(nil)), len(complete.Parts)),
+	})
+	return nil
+}
+
+// partsReader reads the parts one after another, opening at most one file
+type partsReader struct {
+	id    string
+	parts []completePart
+	f     *os.File
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.f == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(partPath(r.id, r.parts[0].PartNumber))
+			if err != nil {
+				return 0, err
+			}
+			r.f, r.parts = f, r.parts[1:]
+		}
+		n, err := r.f.Read(p)
+		if err == io.EOF {
+			_ = r.f.Close()
+			r.f = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.f != nil {
+		return r.f.Close()
+	}
+	return nil
+}
+
+func (h *Handler) abortMultipartUpload(req *request) error {
+	id, _, err := getUpload(req)
+	if err != nil {
+		return err
+	}
+	if err = os.RemoveAll(uploadDir(id)); err != nil {
+		return err
+	}
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *Handler) listParts(req *request) error {
+	id, _, err := getUpload(req)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(uploadDir(id))
+	if err != nil {
+		return err
+	}
+	res := listPartsResult{Xmlns: xmlns, Bucket: req.bucket, Key: req.key, UploadID: id}
+	for _, e := range entries {
+		n, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if pt, err := readPart(id, n); err == nil {
+			res.Parts = append(res.Parts, pt)
+		}
+	}
+	sort.Slice(res.Parts, func(i, j int) bool {
+		return res.Parts[i].PartNumber < res.Parts[j].PartNumber
+	})
+	writeXML(req.w, http.StatusOK, res)
+	return nil
+}
+
+func (h *Handler) listMultipartUploads(req *request) error {
+	res := listMultipartUploadsResult{Xmlns: xmlns, Bucket: req.bucket}
+	entries, _ := os.ReadDir(filepath.Join(conf.Conf.TempDir, "s3"))
+	prefix := req.r.URL.Query().Get("prefix")
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(uploadDir(e.Name()), "info.json"))
+		if err != nil {
+			continue
+		}
+		var info multipartUpload
+		if utils.Json.Unmarshal(data, &info) != nil || info.UserID != req.user.ID ||
+			info.Bucket != req.bucket || !strings.HasPrefix(info.Key, prefix) {
+			continue
+		}
+		res.Uploads = append(res.Uploads, upload{Key: info.Key, UploadID: e.Name(), Initiated: info.Initiated})
+	}
+	writeXML(req.w, http.StatusOK, res)
+	return nil
+}
@@ -0,0 +1,332 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/limit"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+)
+
+const emptyMD5 = "d41d8cd98f00b204e9800998ecf8427e"
+
+func (h *Handler) getObject(req *request, head bool) error {
+	p, err := objectPath(req.root, req.key)
+	if err != nil {
+		return err
+	}
+	obj, err := fs.Get(req.ctx, p, &fs.GetArgs{NoLog: true})
+	if err != nil || obj.IsDir() {
+		return ErrNoSuchKey
+	}
+	header := req.w.Header()
+	header.Set("ETag", etag(obj))
+	header.Set("Last-Modified", lastModified(obj))
+	header.Set("Content-Type", utils.GetMimeType(obj.GetName()))
+	header.Set("Accept-Ranges", "bytes")
+	if head {
+		header.Set("Content-Length", strconv.FormatInt(obj.GetSize(), 10))
+		req.w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	start, length, partial, err := parseRange(req.r.Header.Get("Range"), obj.GetSize())
+	if err != nil {
+		return err
+	}
+	storage, err := fs.GetStorage(p, &fs.GetStoragesArgs{})
+	if err != nil {
+		return err
+	}
+	transfer, err := limit.Start(req.ctx, req.user, storage.GetStorage(), limit.Download, false)
+	if err != nil {
+		return err
+	}
+	defer transfer.Done()
+	link, _, err := fs.Link(req.ctx, p, model.LinkArgs{IP: utils.ClientIP(req.r), Header: req.r.Header})
+	if err != nil {
+		return err
+	}
+	if link.Handle != nil {
+		return link.Handle(req.w, req.r)
+	}
+	rc, err := common.OpenRange(req.ctx, link, start, length)
+	if err != nil {
+		return err
+	}
+	rc = transfer.ReadCloser(req.ctx, rc)
+	defer rc.Close()
+	header.Set("Content-Length", strconv.FormatInt(length, 10))
+	if partial {
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, obj.GetSize()))
+		req.w.WriteHeader(http.StatusPartialContent)
+	} else {
+		req.w.WriteHeader(http.StatusOK)
+	}
+	_, err = io.Copy(req.w, rc)
+	if err != nil {
+		// the status has been written, so only log it
+		utils.Log.Debugf("[s3] failed to write %s: %+v", p, err)
+	}
+	return nil
+}
+
+// parseRange parses a single range of the Range header, multiple ranges
+// are not supported by s3 either.
+func parseRange(s string, size int64) (start, length int64, partial bool, err error) {
+	if !strings.HasPrefix(s, "bytes=") || strings.Contains(s, ",") {
+		return 0, size, false, nil
+	}
+	from, to, ok := strings.Cut(strings.TrimPrefix(s, "bytes="), "-")
+	if !ok {
+		return 0, size, false, nil
+	}
+	end := size - 1
+	if from == "" {
+		n, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return 0, size, false, nil
+		}
+		if n > size {
+			n = size
+		}
+		start = size - n
+	} else {
+		start, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return 0, size, false, nil
+		}
+		if to != "" {
+			if end, err = strconv.ParseInt(to, 10, 64); err != nil {
+				return 0, size, false, nil
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+	}
+	if start >= size || start > end {
+		return 0, 0, false, ErrInvalidRange
+	}
+	return start, end - start + 1, true, nil
+}
+
+func (h *Handler) putObject(req *request) error {
+	p, err := objectPath(req.root, req.key)
+	if err != nil {
+		return err
+	}
+	if !common.HasPermission(req.user, p, model.PermWrite, req.user.CanWrite()) {
+		return ErrAccessDenied
+	}
+	size, err := contentLength(req.r)
+	if err != nil {
+		return err
+	}
+	// clients create folders with an empty object whose key ends with "/"
+	if strings.HasSuffix(req.key, "/") && size == 0 {
+		if err := fs.MakeDir(req.ctx, p); err != nil {
+			return err
+		}
+		req.w.Header().Set("ETag", `"`+emptyMD5+`"`)
+		req.w.WriteHeader(http.StatusOK)
+		return nil
+	}
+	sum, err := put(req.ctx, p, size, payloadReader(req.r.Body, req.sig), req.r.Header)
+	if err != nil {
+		return err
+	}
+	if md5Header := req.r.Header.Get("Content-MD5"); md5Header != "" {
+		expected, err := base64.StdEncoding.DecodeString(md5Header)
+		if err != nil || hex.EncodeToString(expected) != sum {
+			_ = fs.Remove(req.ctx, p)
+			return ErrBadDigest
+		}
+	}
+	req.w.Header().Set("ETag", `"`+sum+`"`)
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// put uploads the content to alist and returns its md5
+func put(ctx context.Context, p string, size int64, r io.Reader, header http.Header) (string, error) {
+	h := md5.New()
+	mimetype := header.Get("Content-Type")
+	if mimetype == "" || mimetype == "application/octet-stream" {
+		mimetype = utils.GetMimeType(p)
+	}
+	err := fs.PutDirectly(ctx, stdpath.Dir(p), &model.FileStream{
+		Obj: &model.Object{
+			Name:     stdpath.Base(p),
+			Size:     size,
+			Modified: modTime(header),
+		},
+		ReadCloser: io.NopCloser(io.TeeReader(r, h)),
+		Mimetype:   mimetype,
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func contentLength(r *http.Request) (int64, error) {
+	if s := r.Header.Get("X-Amz-Decoded-Content-Length"); s != "" {
+		size, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || size < 0 {
+			return 0, ErrInvalidArgument
+		}
+		return size, nil
+	}
+	if r.ContentLength < 0 {
+		return 0, ErrMissingContentLength
+	}
+	return r.ContentLength, nil
+}
+
+// modTime returns the modification time kept in the metadata by clients
+// like rclone, or now if there's none
+func modTime(header http.Header) time.Time {
+	if s := header.Get("X-Amz-Meta-Mtime"); s != "" {
+		if sec, err := strconv.ParseFloat(s, 64); err == nil {
+			return time.Unix(0, int64(sec*float64(time.Second)))
+		}
+	}
+	return time.Now()
+}
+
+func (h *Handler) copyObject(req *request) error {
+	dst, err := objectPath(req.root, req.key)
+	if err != nil {
+		return err
+	}
+	if !common.HasPermission(req.user, dst, model.PermWrite, req.user.CanWrite()) {
+		return ErrAccessDenied
+	}
+	source, err := url.PathUnescape(req.r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		return ErrInvalidArgument
+	}
+	source, _, _ = strings.Cut(source, "?")
+	srcBucket, srcKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	srcRoot, err := bucketRoot(req.user, srcBucket)
+	if err != nil {
+		return err
+	}
+	src, err := objectPath(srcRoot, srcKey)
+	if err != nil {
+		return err
+	}
+	obj, err := fs.Get(req.ctx, src, &fs.GetArgs{NoLog: true})
+	if err != nil || obj.IsDir() {
+		return ErrNoSuchKey
+	}
+	sum := ""
+	if src == dst {
+		// copying to itself only replaces the metadata, which we don't keep
+		sum = strings.Trim(etag(obj), `"`)
+	} else {
+		link, _, err := fs.Link(req.ctx, src, model.LinkArgs{})
+		if err != nil {
+			return err
+		}
+		rc, err := common.OpenRange(req.ctx, link, 0, obj.GetSize())
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		header := req.r.Header.Clone()
+		if header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+			header.Set("X-Amz-Meta-Mtime", strconv.FormatInt(obj.ModTime().Unix(), 10))
+		}
+		if sum, err = put(req.ctx, dst, obj.GetSize(), rc, header); err != nil {
+			return err
+		}
+	}
+	writeXML(req.w, http.StatusOK, copyObjectResult{
+		Xmlns:        xmlns,
+		LastModified: time.Now().UTC(),
+		ETag:         `"` + sum + `"`,
+	})
+	return nil
+}
+
+func (h *Handler) deleteObject(req *request) error {
+	if err := h.remove(req, req.key); err != nil {
+		return err
+	}
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// remove removes the object of the key, deleting a key that doesn't exist
+// is not an error in s3. Folders are only removed by their "/" key when
+// they are empty, as there are no folders in s3.
+func (h *Handler) remove(req *request, key string) error {
+	p, err := objectPath(req.root, key)
+	if err != nil {
+		return err
+	}
+	if !common.HasPermission(req.user, p, model.PermDelete, req.user.CanRemove()) {
+		return ErrAccessDenied
+	}
+	if p == req.root {
+		return nil
+	}
+	obj, err := fs.Get(req.ctx, p, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if obj.IsDir() {
+		if !strings.HasSuffix(key, "/") {
+			return nil
+		}
+		meta, _ := op.GetNearestMeta(p)
+		objs, err := fs.List(context.WithValue(req.ctx, "meta", meta), p, &fs.ListArgs{NoLog: true})
+		if err != nil || len(objs) > 0 {
+			return err
+		}
+	}
+	return fs.Remove(req.ctx, p)
+}
+
+func (h *Handler) deleteObjects(req *request) error {
+	var del deleteRequest
+	if err := xml.NewDecoder(payloadReader(req.r.Body, req.sig)).Decode(&del); err != nil {
+		return ErrMalformedXML
+	}
+	res := deleteResult{Xmlns: xmlns}
+	for _, o := range del.Objects {
+		if err := h.remove(req, o.Key); err != nil {
+			code, msg := ErrInternalError.Code, err.Error()
+			if apiErr, ok := err.(*APIError); ok {
+				code, msg = apiErr.Code, apiErr.Message
+			}
+			res.Errors = append(res.Errors, deleteError{Key: o.Key, Code: code, Message: msg})
+			continue
+		}
+		if !del.Quiet {
+			res.Deleted = append(res.Deleted, deletedObject{Key: o.Key})
+		}
+	}
+	writeXML(req.w, http.StatusOK, res)
+	return nil
+}
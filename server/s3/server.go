@@ -0,0 +1,223 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/setting"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// Handler serves the configured buckets with the s3 api, only path-style
+// requests are supported.
+type Handler struct {
+	// Lookup returns the user and the secret key of an access key.
+	Lookup KeyLookup
+	// Logger is an optional error logger. If non-nil, it will be called
+	// for all failed requests.
+	Logger func(*http.Request, error)
+}
+
+// request is a request that has been authenticated and routed to a bucket
+type request struct {
+	w    http.ResponseWriter
+	r    *http.Request
+	ctx  context.Context
+	user *model.User
+	sig  *signature
+	// bucket is the name of the bucket and root is its path in alist
+	bucket string
+	root   string
+	key    string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reqID := strings.ToUpper(random.String(16))
+	w.Header().Set("x-amz-request-id", reqID)
+	err := h.serve(w, r)
+	if err == nil {
+		return
+	}
+	if h.Logger != nil {
+		h.Logger(r, err)
+	}
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+	case errs.IsObjectNotFound(err):
+		apiErr = ErrNoSuchKey
+	case errors.Is(err, errs.PermissionDenied):
+		apiErr = ErrAccessDenied
+	case errors.Is(err, errs.TooManyTransfers):
+		apiErr = ErrSlowDown
+	case errors.Is(err, errs.QuotaExceeded):
+		apiErr = &APIError{Code: ErrAccessDenied.Code, Message: err.Error(), Status: ErrAccessDenied.Status}
+	default:
+		apiErr = &APIError{Code: ErrInternalError.Code, Message: err.Error(), Status: ErrInternalError.Status}
+	}
+	if r.Method == http.MethodHead {
+		w.WriteHeader(apiErr.Status)
+		return
+	}
+	writeXML(w, apiErr.Status, errorResponse{
+		Code:      apiErr.Code,
+		Message:   apiErr.Message,
+		Resource:  r.URL.Path,
+		RequestID: reqID,
+	})
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request) error {
+	sig, err := authenticate(r, time.Now(), h.Lookup)
+	if err != nil {
+		return err
+	}
+	if sig.user.Disabled {
+		return ErrAccessDenied
+	}
+	req := &request{
+		w:    w,
+		r:    r,
+		ctx:  context.WithValue(r.Context(), "user", sig.user),
+		user: sig.user,
+		sig:  sig,
+	}
+	req.bucket, req.key, _ = strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if req.bucket == "" {
+		if r.Method != http.MethodGet {
+			return ErrMethodNotAllowed
+		}
+		return h.listBuckets(req)
+	}
+	if req.root, err = bucketRoot(req.user, req.bucket); err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	if req.key == "" {
+		return h.serveBucket(req, query)
+	}
+	return h.serveObject(req, query)
+}
+
+func (h *Handler) serveBucket(req *request, query map[string][]string) error {
+	has := func(k string) bool {
+		_, ok := query[k]
+		return ok
+	}
+	switch req.r.Method {
+	case http.MethodGet:
+		switch {
+		case has("location"):
+			writeXML(req.w, http.StatusOK, locationConstraint{Xmlns: xmlns})
+			return nil
+		case has("versioning"):
+			writeXML(req.w, http.StatusOK, versioningConfiguration{Xmlns: xmlns})
+			return nil
+		case has("uploads"):
+			return h.listMultipartUploads(req)
+		}
+		return h.listObjects(req)
+	case http.MethodHead:
+		return h.headBucket(req)
+	case http.MethodPut:
+		// buckets are configured in the settings, so it always exists here
+		return ErrBucketAlreadyOwnedByYou
+	case http.MethodPost:
+		if has("delete") {
+			return h.deleteObjects(req)
+		}
+	}
+	return ErrNotImplemented
+}
+
+func (h *Handler) serveObject(req *request, query map[string][]string) error {
+	has := func(k string) bool {
+		_, ok := query[k]
+		return ok
+	}
+	switch req.r.Method {
+	case http.MethodGet:
+		if has("uploadId") {
+			return h.listParts(req)
+		}
+		return h.getObject(req, false)
+	case http.MethodHead:
+		return h.getObject(req, true)
+	case http.MethodPut:
+		if has("uploadId") {
+			return h.uploadPart(req)
+		}
+		if req.r.Header.Get("X-Amz-Copy-Source") != "" {
+			return h.copyObject(req)
+		}
+		return h.putObject(req)
+	case http.MethodPost:
+		if has("uploads") {
+			return h.createMultipartUpload(req)
+		}
+		if has("uploadId") {
+			return h.completeMultipartUpload(req)
+		}
+	case http.MethodDelete:
+		if has("uploadId") {
+			return h.abortMultipartUpload(req)
+		}
+		return h.deleteObject(req)
+	}
+	return ErrNotImplemented
+}
+
+func getBuckets() []Bucket {
+	var buckets []Bucket
+	if err := utils.Json.UnmarshalFromString(setting.GetStr(conf.S3Buckets, "[]"), &buckets); err != nil {
+		utils.Log.Warnf("invalid s3 buckets setting: %+v", err)
+		return nil
+	}
+	return buckets
+}
+
+// bucketRoot returns the path of the bucket in alist for the user
+func bucketRoot(user *model.User, name string) (string, error) {
+	for _, b := range getBuckets() {
+		if b.Name != name {
+			continue
+		}
+		root, err := user.JoinPath(b.Path)
+		if err != nil {
+			return "", ErrAccessDenied
+		}
+		return root, nil
+	}
+	return "", ErrNoSuchBucket
+}
+
+// objectPath returns the path in alist of the object key, keys can't
+// escape the bucket with ".."
+func objectPath(root, key string) (string, error) {
+	p := stdpath.Join(root, key)
+	if !utils.IsSubPath(root, p) {
+		return "", ErrInvalidArgument
+	}
+	return p, nil
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/alist-org/alist/v3/cmd/flags"
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// NewSFTP creates the sftp server of the sftp config, the host key is kept
+// in the data dir
+func NewSFTP() (*sftp.Server, error) {
+	hostKey, err := sftp.LoadHostKey(filepath.Join(flags.DataDir, "ssh_host_ed25519_key"))
+	if err != nil {
+		return nil, err
+	}
+	return &sftp.Server{
+		Addr:          fmt.Sprintf("%s:%d", conf.Conf.Address, conf.Conf.SFTP.Port),
+		HostKey:       hostKey,
+		PasswordAuth:  sftpPasswordAuth,
+		PublicKeyAuth: sftpPublicKeyAuth,
+	}, nil
+}
+
+func sftpPasswordAuth(username, password string) (*model.User, error) {
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if err = op.ValidateClientPassword(user, password); err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errs.PermissionDenied
+	}
+	return user, nil
+}
+
+func sftpPublicKeyAuth(username string, key ssh.PublicKey) (*model.User, error) {
+	user, err := op.GetUserBySSHKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if user.Username != username {
+		return nil, fmt.Errorf("the key doesn't belong to %s", username)
+	}
+	if user.Disabled {
+		return nil, errs.PermissionDenied
+	}
+	return user, nil
+}
@@ -0,0 +1,159 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/conf"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/limit"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/pkg/utils"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+)
+
+// the reads of a client come concurrently and slightly out of order, so the
+// recent bytes are kept to serve them without reopening the link
+const (
+	readWindow = 4 * 1024 * 1024
+	readKeep   = 4 * 1024 * 1024
+)
+
+// reader reads the linked file as a sequential stream, it reopens the link
+// at the offset only when the reads jump out of the window
+type reader struct {
+	ctx  context.Context
+	link *model.Link
+	size int64
+	// transfer throttles the reads, it's done when the reader is closed
+	transfer *limit.Transfer
+
+	mu     sync.Mutex
+	rc     io.ReadCloser
+	opened bool
+	// buf holds the bytes of [start, start+len(buf)) read from rc
+	buf   []byte
+	start int64
+}
+
+func (r *reader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	end := off + int64(len(p))
+	if end > r.size {
+		end = r.size
+	}
+	pos := r.start + int64(len(r.buf))
+	if r.rc == nil || off < r.start || off > pos+readWindow {
+		if err := r.reopen(off); err != nil {
+			return 0, err
+		}
+		pos = off
+	}
+	if end > pos {
+		chunk := make([]byte, end-pos)
+		n, err := io.ReadFull(r.rc, chunk)
+		r.buf = append(r.buf, chunk[:n]...)
+		if err != nil {
+			_ = r.rc.Close()
+			r.rc = nil
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf[off-r.start:end-r.start])
+	if over := len(r.buf) - readKeep; over > 0 {
+		r.buf = append(r.buf[:0], r.buf[over:]...)
+		r.start += int64(over)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *reader) reopen(off int64) error {
+	// a stream can't be opened again
+	if r.opened && r.link.Data != nil {
+		return errors.New("random access of this file is not supported")
+	}
+	if r.rc != nil {
+		_ = r.rc.Close()
+		r.rc = nil
+	}
+	rc, err := common.OpenRange(r.ctx, r.link, off, r.size-off)
+	if err != nil {
+		return err
+	}
+	if r.transfer != nil {
+		rc = r.transfer.ReadCloser(r.ctx, rc)
+	}
+	r.rc = rc
+	r.opened = true
+	r.buf = r.buf[:0]
+	r.start = off
+	return nil
+}
+
+func (r *reader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.transfer != nil {
+		defer r.transfer.Done()
+	}
+	if r.rc != nil {
+		return r.rc.Close()
+	}
+	if !r.opened && r.link.Data != nil {
+		return r.link.Data.Close()
+	}
+	return nil
+}
+
+// writer spools the writes to a temp file and uploads it when closed, as
+// the storages need the size before uploading
+type writer struct {
+	ctx     context.Context
+	reqPath string
+	*os.File
+}
+
+func newWriter(ctx context.Context, reqPath string) (*writer, error) {
+	f, err := os.CreateTemp(conf.Conf.TempDir, "sftp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &writer{ctx: ctx, reqPath: reqPath, File: f}, nil
+}
+
+func (w *writer) Close() error {
+	// op.Put removes it too, but it may fail before reaching there
+	defer func() {
+		_ = w.File.Close()
+		_ = os.Remove(w.File.Name())
+	}()
+	stat, err := w.File.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = w.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(w.reqPath)
+	return fs.PutDirectly(w.ctx, dir, &model.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     stat.Size(),
+			Modified: time.Now(),
+		},
+		ReadCloser: w.File,
+		Mimetype:   utils.GetMimeType(name),
+	})
+}
@@ -0,0 +1,58 @@
+package sftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alist-org/alist/v3/internal/model"
+)
+
+func TestLoadHostKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host_key")
+	k1, err := LoadHostKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := LoadHostKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k1.PublicKey().Marshal(), k2.PublicKey().Marshal()) {
+		t.Errorf("host key changed after reloading")
+	}
+}
+
+func TestReaderReadAt(t *testing.T) {
+	data := make([]byte, 3*readWindow)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := &reader{ctx: context.Background(), link: &model.Link{FilePath: &path}, size: int64(len(data))}
+	defer r.Close()
+	// in order, out of order within the window, backwards and beyond the window
+	for _, off := range []int64{0, 32768, 98304, 65536, 10, 2*readWindow + 5, readWindow, int64(len(data)) - 100} {
+		p := make([]byte, 32768)
+		n, err := r.ReadAt(p, off)
+		want := data[off:]
+		if len(want) > len(p) {
+			want = want[:len(p)]
+		}
+		if n != len(want) || !bytes.Equal(p[:n], want) {
+			t.Fatalf("read at %d: got %d bytes, want %d", off, n, len(want))
+		}
+		if n < len(p) && err != io.EOF {
+			t.Fatalf("read at %d: expect EOF, got %v", off, err)
+		}
+	}
+	if _, err := r.ReadAt(make([]byte, 1), int64(len(data))); err != io.EOF {
+		t.Errorf("expect EOF at the end, got %v", err)
+	}
+}
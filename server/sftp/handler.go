@@ -0,0 +1,289 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"os"
+	stdpath "path"
+
+	"github.com/alist-org/alist/v3/internal/errs"
+	"github.com/alist-org/alist/v3/internal/fs"
+	"github.com/alist-org/alist/v3/internal/limit"
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
+	"github.com/alist-org/alist/v3/server/common"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+)
+
+// handler serves the requests of a sftp session as the user, the paths of
+// requests are seen by the user and joined with the base path of the user
+type handler struct {
+	ctx  context.Context
+	user *model.User
+}
+
+// resolve returns the path of p in the virtual filesystem and the nearest
+// meta of it, after checking the user can access it
+func (h *handler) resolve(p string) (string, *model.Meta, error) {
+	reqPath, err := h.user.JoinPath(p)
+	if err != nil {
+		return "", nil, err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return "", nil, err
+	}
+	if !common.CanAccess(h.user, meta, reqPath, "") {
+		return "", nil, errs.PermissionDenied
+	}
+	return reqPath, meta, nil
+}
+
+// list lists the dir with the meta that fs.List needs for the hide rules
+func (h *handler) list(reqPath string, meta *model.Meta) ([]model.Obj, error) {
+	return fs.List(context.WithValue(h.ctx, "meta", meta), reqPath, &fs.ListArgs{})
+}
+
+// canWrite reports whether the user can upload or make dirs in the dir of p
+func (h *handler) canWrite(p string) (bool, error) {
+	dir, meta, err := h.resolve(stdpath.Dir(p))
+	if err != nil {
+		return false, err
+	}
+	fallback := h.user.CanWrite() || common.CanWrite(meta, dir)
+	return common.HasPermission(h.user, stdpath.Join(dir, stdpath.Base(p)), model.PermWrite, fallback), nil
+}
+
+func (h *handler) get(p string) (string, model.Obj, error) {
+	reqPath, _, err := h.resolve(p)
+	if err != nil {
+		return "", nil, err
+	}
+	obj, err := fs.Get(h.ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		return "", nil, err
+	}
+	return reqPath, obj, nil
+}
+
+func (h *handler) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	reqPath, obj, err := h.get(r.Filepath)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if obj.IsDir() {
+		return nil, toStatus(errs.NotFile)
+	}
+	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	transfer, err := limit.Start(h.ctx, h.user, storage.GetStorage(), limit.Download, false)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	link, _, err := fs.Link(h.ctx, reqPath, model.LinkArgs{})
+	if err != nil {
+		transfer.Done()
+		return nil, toStatus(err)
+	}
+	if link.Handle != nil {
+		transfer.Done()
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+	return &reader{ctx: h.ctx, link: link, size: obj.GetSize(), transfer: transfer}, nil
+}
+
+func (h *handler) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	if r.Pflags().Append {
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+	ok, err := h.canWrite(r.Filepath)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !ok {
+		return nil, os.ErrPermission
+	}
+	reqPath, _, err := h.resolve(r.Filepath)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return newWriter(h.ctx, reqPath)
+}
+
+func (h *handler) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		// the attributes are decided by the storages
+		return nil
+	case "Rename", "PosixRename":
+		return toStatus(h.rename(r.Filepath, r.Target))
+	case "Rmdir":
+		return toStatus(h.rmdir(r.Filepath))
+	case "Remove":
+		return toStatus(h.remove(r.Filepath))
+	case "Mkdir":
+		return toStatus(h.mkdir(r.Filepath))
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (h *handler) rename(src, dst string) error {
+	srcPath, _, err := h.get(src)
+	if err != nil {
+		return err
+	}
+	dstPath, _, err := h.resolve(dst)
+	if err != nil {
+		return err
+	}
+	srcDir, srcName := stdpath.Split(srcPath)
+	dstDir, dstName := stdpath.Split(dstPath)
+	if srcDir != dstDir {
+		if !common.HasPermission(h.user, srcPath, model.PermRename, h.user.CanMove()) {
+			return errs.PermissionDenied
+		}
+		if err = fs.Move(h.ctx, srcPath, dstDir); err != nil {
+			return err
+		}
+		srcPath = stdpath.Join(dstDir, srcName)
+	}
+	if srcName != dstName {
+		if !common.HasPermission(h.user, srcPath, model.PermRename, h.user.CanRename()) {
+			return errs.PermissionDenied
+		}
+		return fs.Rename(h.ctx, srcPath, dstName)
+	}
+	return nil
+}
+
+func (h *handler) rmdir(p string) error {
+	reqPath, meta, err := h.resolve(p)
+	if err != nil {
+		return err
+	}
+	if !common.HasPermission(h.user, reqPath, model.PermDelete, h.user.CanRemove()) {
+		return errs.PermissionDenied
+	}
+	// like rmdir(2), only empty dirs can be removed
+	objs, err := h.list(reqPath, meta)
+	if err != nil {
+		return err
+	}
+	if len(objs) > 0 {
+		return errors.New("directory not empty")
+	}
+	return fs.Remove(h.ctx, reqPath)
+}
+
+func (h *handler) remove(p string) error {
+	reqPath, obj, err := h.get(p)
+	if err != nil {
+		return err
+	}
+	if !common.HasPermission(h.user, reqPath, model.PermDelete, h.user.CanRemove()) {
+		return errs.PermissionDenied
+	}
+	if obj.IsDir() {
+		return errs.NotFile
+	}
+	return fs.Remove(h.ctx, reqPath)
+}
+
+func (h *handler) mkdir(p string) error {
+	ok, err := h.canWrite(p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.PermissionDenied
+	}
+	reqPath, _, err := h.resolve(p)
+	if err != nil {
+		return err
+	}
+	return fs.MakeDir(h.ctx, reqPath)
+}
+
+func (h *handler) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		reqPath, meta, err := h.resolve(r.Filepath)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		objs, err := h.list(reqPath, meta)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		infos := make(listerAt, len(objs))
+		for i, obj := range objs {
+			infos[i] = fileInfo{obj}
+		}
+		return infos, nil
+	case "Stat":
+		_, obj, err := h.get(r.Filepath)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		// the name of the root is empty in the virtual filesystem
+		return listerAt{fileInfo{&model.Object{
+			Name:     stdpath.Base(r.Filepath),
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+			IsFolder: obj.IsDir(),
+		}}}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// toStatus converts the errors to the ones that sftp knows the status of
+func toStatus(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errs.IsObjectNotFound(err):
+		return os.ErrNotExist
+	case errors.Is(errors.Cause(err), errs.PermissionDenied):
+		return os.ErrPermission
+	}
+	return err
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+type fileInfo struct {
+	model.Obj
+}
+
+func (f fileInfo) Name() string {
+	return f.GetName()
+}
+
+func (f fileInfo) Size() int64 {
+	return f.GetSize()
+}
+
+func (f fileInfo) Mode() os.FileMode {
+	if f.IsDir() {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
+
+func (f fileInfo) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,49 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// LoadHostKey loads the host key at path, generating an ed25519 key if it
+// doesn't exist, so that clients see the same host across restarts
+func LoadHostKey(path string) (ssh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data, err = generateHostKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse host key %s", path)
+	}
+	return signer, nil
+}
+
+func generateHostKey(path string) ([]byte, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(path, data, 0600); err != nil {
+		return nil, errors.WithMessage(err, "failed to save host key")
+	}
+	return data, nil
+}
@@ -0,0 +1,169 @@
+// Package sftp provides a sftp server of the virtual filesystem. Only the
+// sftp subsystem is served, the legacy scp protocol with exec is not, but
+// scp of OpenSSH 9 and later uses sftp by default.
+package sftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrServerClosed = errors.New("sftp: Server closed")
+
+const handshakeTimeout = 30 * time.Second
+
+type Server struct {
+	Addr    string
+	HostKey ssh.Signer
+	// PasswordAuth and PublicKeyAuth return the user of the login, the
+	// method is disabled if it's nil
+	PasswordAuth  func(username, password string) (*model.User, error)
+	PublicKeyAuth func(username string, key ssh.PublicKey) (*model.User, error)
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+func (s *Server) ListenAndServe() error {
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.conns = make(map[net.Conn]struct{})
+	s.mu.Unlock()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			s.serveConn(c)
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops listening and closes all connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	if s.listener != nil {
+		return s.listener.Close()
+	}
+	return nil
+}
+
+func (s *Server) serveConn(c net.Conn) {
+	defer c.Close()
+	// the config is created per connection to remember the user of the login
+	var user *model.User
+	config := &ssh.ServerConfig{ServerVersion: "SSH-2.0-alist"}
+	if s.PasswordAuth != nil {
+		config.PasswordCallback = func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			u, err := s.PasswordAuth(meta.User(), string(password))
+			if err != nil {
+				log.Debugf("[sftp] failed login of %s from %s: %+v", meta.User(), meta.RemoteAddr(), err)
+				return nil, fmt.Errorf("login incorrect")
+			}
+			user = u
+			return &ssh.Permissions{}, nil
+		}
+	}
+	if s.PublicKeyAuth != nil {
+		config.PublicKeyCallback = func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			u, err := s.PublicKeyAuth(meta.User(), key)
+			if err != nil {
+				return nil, fmt.Errorf("unknown public key")
+			}
+			user = u
+			return &ssh.Permissions{}, nil
+		}
+	}
+	config.AddHostKey(s.HostKey)
+	_ = c.SetDeadline(time.Now().Add(handshakeTimeout))
+	sc, chans, reqs, err := ssh.NewServerConn(c, config)
+	if err != nil {
+		log.Debugf("[sftp] handshake with %s failed: %+v", c.RemoteAddr(), err)
+		return
+	}
+	_ = c.SetDeadline(time.Time{})
+	defer sc.Close()
+	go ssh.DiscardRequests(reqs)
+	ctx := context.WithValue(context.Background(), "user", user)
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			log.Debugf("[sftp] failed to accept channel: %+v", err)
+			continue
+		}
+		go serveChannel(ctx, user, ch, chReqs)
+	}
+}
+
+func serveChannel(ctx context.Context, user *model.User, ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		// the payload of subsystem is the length prefixed name
+		if req.Type != "subsystem" || len(req.Payload) < 4 || string(req.Payload[4:]) != "sftp" {
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+			continue
+		}
+		_ = req.Reply(true, nil)
+		h := &handler{ctx: ctx, user: user}
+		server := sftp.NewRequestServer(ch, sftp.Handlers{
+			FileGet:  h,
+			FilePut:  h,
+			FileCmd:  h,
+			FileList: h,
+		})
+		if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+			log.Debugf("[sftp] session of %s ended: %+v", user.Username, err)
+		}
+		_ = server.Close()
+		return
+	}
+}
@@ -0,0 +1,83 @@
+package sftp
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestServerSession(t *testing.T) {
+	hostKey, err := LoadHostKey(filepath.Join(t.TempDir(), "host_key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	s := &Server{
+		Addr:    addr,
+		HostKey: hostKey,
+		PasswordAuth: func(username, password string) (*model.User, error) {
+			if username != "alice" || password != "secret" {
+				return nil, errors.New("login incorrect")
+			}
+			return &model.User{Username: username}, nil
+		},
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.ListenAndServe() }()
+	defer func() {
+		_ = s.Close()
+		if err := <-done; err != ErrServerClosed {
+			t.Errorf("expect ErrServerClosed, got %v", err)
+		}
+	}()
+
+	dial := func(password string) (*ssh.Client, error) {
+		var err error
+		for i := 0; i < 50; i++ {
+			var c *ssh.Client
+			c, err = ssh.Dial("tcp", addr, &ssh.ClientConfig{
+				User:            "alice",
+				Auth:            []ssh.AuthMethod{ssh.Password(password)},
+				HostKeyCallback: ssh.FixedHostKey(hostKey.PublicKey()),
+			})
+			if err == nil {
+				return c, nil
+			}
+			var opErr *net.OpError
+			if !errors.As(err, &opErr) {
+				return nil, err
+			}
+			// the server may not be listening yet
+			time.Sleep(10 * time.Millisecond)
+		}
+		return nil, err
+	}
+	if _, err := dial("wrong"); err == nil {
+		t.Fatal("expect login with wrong password to fail")
+	}
+	conn, err := dial("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	wd, err := client.Getwd()
+	if err != nil || wd != "/" {
+		t.Errorf("expect working dir /, got %q, %v", wd, err)
+	}
+}
@@ -67,7 +67,8 @@ func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 // Allowed values for depth are 0, 1 or infiniteDepth. For each visited node,
 // walkFS calls walkFn. If a visited file system node is a directory and
 // walkFn returns path.SkipDir, walkFS will skip traversal of this node.
-func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn func(reqPath string, info model.Obj, err error) error) error {
+// The folders reached again through symlinks are visited but not traversed.
+func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn func(reqPath string, info model.Obj, err error) error, walked op.WalkedDirs) error {
 	// This implementation is based on Walk's code in the standard path/path package.
 	err := walkFn(name, info, nil)
 	if err != nil {
@@ -76,7 +77,7 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 		}
 		return err
 	}
-	if !info.IsDir() || depth == 0 {
+	if !info.IsDir() || depth == 0 || !walked.Visit(name) {
 		return nil
 	}
 	if depth == 1 {
@@ -102,7 +103,7 @@ func walkFS(ctx context.Context, depth int, name string, info model.Obj, walkFn
 				return err
 			}
 		} else {
-			err = walkFS(ctx, depth, filename, fileInfo, walkFn)
+			err = walkFS(ctx, depth, filename, fileInfo, walkFn, walked)
 			if err != nil {
 				if !fileInfo.IsDir() || err != filepath.SkipDir {
 					return err
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
@@ -111,12 +113,26 @@ type LockDetails struct {
 	ZeroDepth bool
 }
 
+// ActiveLock is a lock reported by a LockLister.
+type ActiveLock struct {
+	Token string
+	LockDetails
+}
+
+// LockLister is an optional interface for a LockSystem that can report the
+// locks covering a resource, which is required by the lockdiscovery
+// property.
+type LockLister interface {
+	// Locks returns the locks whose root is name, and the infinite depth
+	// locks of its ancestors.
+	Locks(now time.Time, name string) []ActiveLock
+}
+
 // NewMemLS returns a new in-memory LockSystem.
 func NewMemLS() LockSystem {
 	return &memLS{
 		byName:  make(map[string]*memLSNode),
 		byToken: make(map[string]*memLSNode),
-		gen:     uint64(time.Now().Unix()),
 	}
 }
 
@@ -124,15 +140,15 @@ type memLS struct {
 	mu      sync.Mutex
 	byName  map[string]*memLSNode
 	byToken map[string]*memLSNode
-	gen     uint64
 	// byExpiry only contains those nodes whose LockDetails have a finite
 	// Duration and are yet to expire.
 	byExpiry byExpiry
 }
 
+// nextToken returns a lock token in the opaquelocktoken URI scheme, see
+// http://www.webdav.org/specs/rfc4918.html#opaquelocktoken.lock.token.uri.scheme
 func (m *memLS) nextToken() string {
-	m.gen++
-	return strconv.FormatUint(m.gen, 10)
+	return "opaquelocktoken:" + uuid.NewString()
 }
 
 func (m *memLS) collectExpiredNodes(now time.Time) {
@@ -190,8 +206,12 @@ func (m *memLS) Confirm(now time.Time, name0, name1 string, conditions ...Condit
 //
 // n may be a parent of the named resource, if n is an infinite depth lock.
 func (m *memLS) lookup(name string, conditions ...Condition) (n *memLSNode) {
-	// TODO: support Condition.Not and Condition.ETag.
+	// Condition.Not and Condition.ETag are evaluated by the Handler, which
+	// only passes the tokens that must be held.
 	for _, c := range conditions {
+		if c.Not || c.Token == "" {
+			continue
+		}
 		n = m.byToken[c.Token]
 		if n == nil || n.held {
 			continue
@@ -288,6 +308,22 @@ func (m *memLS) Unlock(now time.Time, token string) error {
 	return nil
 }
 
+func (m *memLS) Locks(now time.Time, name string) []ActiveLock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collectExpiredNodes(now)
+
+	var locks []ActiveLock
+	walkToRoot(slashClean(name), func(name0 string, first bool) bool {
+		n := m.byName[name0]
+		if n != nil && n.token != "" && (first || !n.details.ZeroDepth) {
+			locks = append(locks, ActiveLock{Token: n.token, LockDetails: n.details})
+		}
+		return true
+	})
+	return locks
+}
+
 func (m *memLS) canCreate(name string, zeroDepth bool) bool {
 	return walkToRoot(name, func(name0 string, first bool) bool {
 		n := m.byName[name0]
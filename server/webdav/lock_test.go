@@ -204,6 +204,47 @@ func TestMemLSLookup(t *testing.T) {
 	}
 }
 
+func TestMemLSLocks(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemLS().(*memLS)
+	tokens := map[string]string{}
+	for _, name := range []string{"/a", "/b", "/b/c"} {
+		token, err := m.Create(now, LockDetails{
+			Root:      name,
+			Duration:  infiniteTimeout,
+			ZeroDepth: name == "/b",
+		})
+		if err != nil {
+			t.Fatalf("creating lock for %q: %v", name, err)
+		}
+		tokens[name] = token
+	}
+
+	testCases := []struct {
+		name string
+		want []string
+	}{
+		{"/", nil},
+		{"/a", []string{"/a"}},
+		{"/a/x", []string{"/a"}},
+		{"/b", []string{"/b"}},
+		{"/b/x", nil},
+		{"/b/c/d", []string{"/b/c"}},
+	}
+	for _, tc := range testCases {
+		var got []string
+		for _, l := range m.Locks(now, tc.name) {
+			if l.Token != tokens[l.Root] {
+				t.Errorf("name=%q: lock of %q has token %q, want %q", tc.name, l.Root, l.Token, tokens[l.Root])
+			}
+			got = append(got, l.Root)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("name=%q: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
 func TestMemLSConfirm(t *testing.T) {
 	now := time.Unix(0, 0)
 	m := NewMemLS().(*memLS)
@@ -14,6 +14,8 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alist-org/alist/v3/internal/model"
 )
@@ -151,9 +153,10 @@ var liveProps = map[xml.Name]struct {
 		dir: false,
 	},
 
-	// TODO: The lockdiscovery property requires LockSystem to list the
-	// active locks on a resource.
-	{Space: "DAV:", Local: "lockdiscovery"}: {},
+	{Space: "DAV:", Local: "lockdiscovery"}: {
+		findFn: findLockDiscovery,
+		dir:    true,
+	},
 	{Space: "DAV:", Local: "supportedlock"}: {
 		findFn: findSupportedLock,
 		dir:    true,
@@ -166,7 +169,7 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -197,7 +200,7 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
-			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			innerXML, err := prop.findFn(ctx, ls, name, fi)
 			if err != nil {
 				return nil, err
 			}
@@ -215,7 +218,7 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
+func propnames(ctx context.Context, ls LockSystem, name string, fi model.Obj) ([]xml.Name, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -256,8 +259,8 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, name, fi)
 	if err != nil {
 		return nil, err
 	}
@@ -271,7 +274,7 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, pnames)
 }
 
 // Patch patches the properties of resource name. The return values are
@@ -474,3 +477,17 @@ func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model
 		`<D:locktype><D:write/></D:locktype>` +
 		`</D:lockentry>`, nil
 }
+
+func findLockDiscovery(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	ll, ok := ls.(LockLister)
+	if !ok {
+		return "", nil
+	}
+	user := ctx.Value("user").(*model.User)
+	prefix, _ := ctx.Value("prefix").(string)
+	var b strings.Builder
+	for _, l := range ll.Locks(time.Now(), name) {
+		b.WriteString(activeLockXML(l.Token, l.LockDetails, lockRootHref(prefix, user, l.Root)))
+	}
+	return b.String(), nil
+}
@@ -6,8 +6,11 @@
 package webdav // import "golang.org/x/net/webdav"
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,6 +21,7 @@ import (
 	"github.com/alist-org/alist/v3/internal/errs"
 	"github.com/alist-org/alist/v3/internal/fs"
 	"github.com/alist-org/alist/v3/internal/model"
+	"github.com/alist-org/alist/v3/internal/op"
 	"github.com/alist-org/alist/v3/internal/sign"
 	"github.com/alist-org/alist/v3/pkg/utils"
 	"github.com/alist-org/alist/v3/server/common"
@@ -104,45 +108,23 @@ func (h *Handler) lock(now time.Time, root string) (token string, status int, er
 	return token, 0, nil
 }
 
+// confirmLocks confirms the locks of src and dst, which are joined with the
+// base path of the user.
 func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func(), status int, err error) {
 	hdr := r.Header.Get("If")
 	if hdr == "" {
 		// An empty If header means that the client hasn't previously created locks.
 		// Even if this client doesn't care about locks, we still need to check that
-		// the resources aren't locked by another client, so we create temporary
-		// locks that would conflict with another client's locks. These temporary
-		// locks are unlocked at the end of the HTTP request.
-		now, srcToken, dstToken := time.Now(), "", ""
-		if src != "" {
-			srcToken, status, err = h.lock(now, src)
-			if err != nil {
-				return nil, status, err
-			}
-		}
-		if dst != "" {
-			dstToken, status, err = h.lock(now, dst)
-			if err != nil {
-				if srcToken != "" {
-					h.LockSystem.Unlock(now, srcToken)
-				}
-				return nil, status, err
-			}
-		}
-
-		return func() {
-			if dstToken != "" {
-				h.LockSystem.Unlock(now, dstToken)
-			}
-			if srcToken != "" {
-				h.LockSystem.Unlock(now, srcToken)
-			}
-		}, 0, nil
+		// the resources aren't locked by another client.
+		return h.lockTemporarily(src, dst)
 	}
 
 	ih, ok := parseIfHeader(hdr)
 	if !ok {
 		return nil, http.StatusBadRequest, errInvalidIfHeader
 	}
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
 	// ih is a disjunction (OR) of ifLists, so any ifList will do.
 	for _, l := range ih.lists {
 		lsrc := l.resourceTag
@@ -160,8 +142,34 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 			if err != nil {
 				return nil, status, err
 			}
+			lsrc, err = user.JoinPath(lsrc)
+			if err != nil {
+				return nil, 403, err
+			}
+		}
+		// the lock system only knows about the tokens to hold, the other
+		// conditions of the list are evaluated here
+		var tokens []Condition
+		matched := true
+		for _, c := range l.conditions {
+			if c.ETag == "" && !c.Not {
+				tokens = append(tokens, c)
+				continue
+			}
+			if h.evalCondition(r, lsrc, c) == c.Not {
+				matched = false
+				break
+			}
 		}
-		release, err = h.LockSystem.Confirm(time.Now(), lsrc, dst, l.conditions...)
+		if !matched {
+			continue
+		}
+		if len(tokens) == 0 {
+			// e.g. "(Not <DAV:no-lock>)" or a plain ETag, which don't claim
+			// any lock, so the resources must not be locked by others.
+			return h.lockTemporarily(src, dst)
+		}
+		release, err = h.LockSystem.Confirm(time.Now(), lsrc, dst, tokens...)
 		if err == ErrConfirmationFailed {
 			continue
 		}
@@ -177,6 +185,69 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 	return nil, http.StatusPreconditionFailed, ErrLocked
 }
 
+// lockTemporarily creates temporary locks that would conflict with another
+// client's locks. These temporary locks are unlocked at the end of the HTTP
+// request.
+func (h *Handler) lockTemporarily(src, dst string) (release func(), status int, err error) {
+	now, srcToken, dstToken := time.Now(), "", ""
+	if src != "" {
+		srcToken, status, err = h.lock(now, src)
+		if err != nil {
+			return nil, status, err
+		}
+	}
+	if dst != "" {
+		dstToken, status, err = h.lock(now, dst)
+		if err != nil {
+			if srcToken != "" {
+				h.LockSystem.Unlock(now, srcToken)
+			}
+			return nil, status, err
+		}
+	}
+
+	return func() {
+		if dstToken != "" {
+			h.LockSystem.Unlock(now, dstToken)
+		}
+		if srcToken != "" {
+			h.LockSystem.Unlock(now, srcToken)
+		}
+	}, 0, nil
+}
+
+// evalCondition reports whether the condition matches the resource at name,
+// ignoring c.Not.
+func (h *Handler) evalCondition(r *http.Request, name string, c Condition) bool {
+	if c.ETag != "" {
+		fi, err := fs.Get(r.Context(), name, &fs.GetArgs{})
+		if err != nil {
+			return false
+		}
+		etag, err := findETag(r.Context(), h.LockSystem, name, fi)
+		return err == nil && etag == c.ETag
+	}
+	ll, ok := h.LockSystem.(LockLister)
+	if !ok {
+		return false
+	}
+	for _, l := range ll.Locks(time.Now(), name) {
+		if l.Token == c.Token {
+			return true
+		}
+	}
+	return false
+}
+
+// lockRootHref returns the url path of the lock root as seen by the user.
+func lockRootHref(prefix string, user *model.User, root string) string {
+	rel := "/"
+	if utils.IsSubPath(user.BasePath, root) {
+		rel = strings.TrimPrefix(root, user.BasePath)
+	}
+	return (&url.URL{Path: path.Join(prefix, rel)}).EscapedPath()
+}
+
 func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status int, err error) {
 	reqPath, status, err := h.stripPrefix(r.URL.Path)
 	if err != nil {
@@ -231,12 +302,25 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	// Let ServeContent determine the Content-Type header.
 	storage, _ := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	downProxyUrl := storage.GetStorage().DownProxyUrl
-	if storage.GetStorage().WebdavNative() || (storage.GetStorage().WebdavProxy() && downProxyUrl == "") {
+	proxy := storage.GetStorage().WebdavNative() || (storage.GetStorage().WebdavProxy() && downProxyUrl == "")
+	if proxy && !common.HasPermission(user, reqPath, model.PermProxy, true) {
+		// redirect instead if the acl rules deny proxying through alist
+		if storage.Config().MustProxy() {
+			return http.StatusForbidden, errs.PermissionDenied
+		}
+		proxy = false
+	}
+	if proxy {
+		lw, transfer, err := common.LimitProxy(ctx, w, user, storage.GetStorage())
+		if err != nil {
+			return http.StatusTooManyRequests, err
+		}
+		defer transfer.Done()
 		link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{Header: r.Header})
 		if err != nil {
 			return http.StatusInternalServerError, err
 		}
-		err = common.Proxy(w, r, link, fi)
+		err = common.Proxy(lw, r, link, fi)
 		if err != nil {
 			log.Errorf("webdav proxy error: %+v", err)
 			return http.StatusInternalServerError, err
@@ -263,18 +347,17 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	// TODO: return MultiStatus where appropriate.
 
 	// "godoc os RemoveAll" says that "If the path does not exist, RemoveAll
@@ -301,6 +384,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if reqPath == "" {
 		return http.StatusMethodNotAllowed, nil
 	}
+	ctx := r.Context()
+	user := ctx.Value("user").(*model.User)
+	reqPath, err = user.JoinPath(reqPath)
+	if err != nil {
+		return 403, err
+	}
 	release, status, err := h.confirmLocks(r, reqPath, "")
 	if err != nil {
 		return status, err
@@ -308,12 +397,6 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	defer release()
 	// TODO(rost): Support the If-Match, If-None-Match headers? See bradfitz'
 	// comments in http.checkEtag.
-	ctx := r.Context()
-	user := ctx.Value("user").(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
-	if err != nil {
-		return 403, err
-	}
 	obj := model.Object{
 		Name:     path.Base(reqPath),
 		Size:     r.ContentLength,
@@ -328,6 +411,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 		stream.Mimetype = utils.GetMimeType(reqPath)
 	}
 	err = fs.PutDirectly(ctx, path.Dir(reqPath), stream)
+	if errors.Is(err, errs.QuotaExceeded) {
+		return http.StatusInsufficientStorage, err
+	}
 
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if err != nil {
@@ -350,18 +436,17 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 
 	if r.ContentLength > 0 {
 		return http.StatusUnsupportedMediaType, nil
@@ -505,12 +590,12 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 			}
 		}
 		reqPath, status, err := h.stripPrefix(r.URL.Path)
-		reqPath, err = user.JoinPath(reqPath)
 		if err != nil {
-			return 403, err
+			return status, err
 		}
+		reqPath, err = user.JoinPath(reqPath)
 		if err != nil {
-			return status, err
+			return 403, err
 		}
 		ld = LockDetails{
 			Root:      reqPath,
@@ -531,17 +616,29 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 			}
 		}()
 
-		// ??? Why create resource here?
-		//// Create the resource if it didn't previously exist.
-		//if _, err := h.FileSystem.Stat(ctx, reqPath); err != nil {
-		//	f, err := h.FileSystem.OpenFile(ctx, reqPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-		//	if err != nil {
-		//		// TODO: detect missing intermediate dirs and return http.StatusConflict?
-		//		return http.StatusInternalServerError, err
-		//	}
-		//	f.Close()
-		//	created = true
-		//}
+		// Section 7.3 says that a LOCK on an unmapped URL creates an empty
+		// resource, Windows and Office clients lock a new file before PUT.
+		if _, err := fs.Get(ctx, reqPath, &fs.GetArgs{}); err != nil {
+			if !errs.IsObjectNotFound(err) {
+				return http.StatusInternalServerError, err
+			}
+			if !user.CanWebdavManage() {
+				return http.StatusForbidden, errs.PermissionDenied
+			}
+			err = fs.PutDirectly(ctx, path.Dir(reqPath), &model.FileStream{
+				Obj: &model.Object{
+					Name:     path.Base(reqPath),
+					Modified: now,
+				},
+				ReadCloser: io.NopCloser(bytes.NewReader(nil)),
+				Mimetype:   utils.GetMimeType(reqPath),
+			})
+			if err != nil {
+				// TODO: detect missing intermediate dirs and return http.StatusConflict?
+				return http.StatusInternalServerError, err
+			}
+			created = true
+		}
 
 		// http://www.webdav.org/specs/rfc4918.html#HEADER_Lock-Token says that the
 		// Lock-Token value is a Coded-URL. We add angle brackets.
@@ -555,7 +652,7 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 		// and Handler.ServeHTTP would otherwise write "Created".
 		w.WriteHeader(http.StatusCreated)
 	}
-	writeLockInfo(w, token, ld)
+	writeLockInfo(w, token, ld, lockRootHref(h.Prefix, user, ld.Root))
 	return 0, nil
 }
 
@@ -611,6 +708,8 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 	if err != nil {
 		return status, err
 	}
+	// used by the lockdiscovery property
+	ctx = context.WithValue(ctx, "prefix", h.Prefix)
 
 	mw := multistatusWriter{w: w}
 
@@ -620,7 +719,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -630,9 +729,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
@@ -644,7 +743,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		return mw.write(makePropstatResponse(href, pstats))
 	}
 
-	walkErr := walkFS(ctx, depth, reqPath, fi, walkFn)
+	walkErr := walkFS(ctx, depth, reqPath, fi, walkFn, op.WalkedDirs{})
 	closeErr := mw.close()
 	if walkErr != nil {
 		return http.StatusInternalServerError, walkErr
@@ -660,18 +759,17 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
 	ctx := r.Context()
 	user := ctx.Value("user").(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return 403, err
 	}
+	release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	if _, err := fs.Get(ctx, reqPath, &fs.GetArgs{}); err != nil {
 		if errs.IsObjectNotFound(err) {
 			return http.StatusNotFound, err
@@ -81,23 +81,34 @@ func (c *countingReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
-func writeLockInfo(w io.Writer, token string, ld LockDetails) (int, error) {
+func writeLockInfo(w io.Writer, token string, ld LockDetails, rootHref string) (int, error) {
+	return fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"+
+		"<D:prop xmlns:D=\"DAV:\"><D:lockdiscovery>%s</D:lockdiscovery></D:prop>",
+		activeLockXML(token, ld, rootHref),
+	)
+}
+
+// activeLockXML returns the activelock element of a lock, rootHref is the
+// url path of ld.Root as seen by the client.
+func activeLockXML(token string, ld LockDetails, rootHref string) string {
 	depth := "infinity"
 	if ld.ZeroDepth {
 		depth = "0"
 	}
-	timeout := ld.Duration / time.Second
-	return fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"+
-		"<D:prop xmlns:D=\"DAV:\"><D:lockdiscovery><D:activelock>\n"+
+	timeout := "Infinite"
+	if ld.Duration >= 0 {
+		timeout = fmt.Sprintf("Second-%d", ld.Duration/time.Second)
+	}
+	return fmt.Sprintf("<D:activelock>\n"+
 		"	<D:locktype><D:write/></D:locktype>\n"+
 		"	<D:lockscope><D:exclusive/></D:lockscope>\n"+
 		"	<D:depth>%s</D:depth>\n"+
 		"	<D:owner>%s</D:owner>\n"+
-		"	<D:timeout>Second-%d</D:timeout>\n"+
+		"	<D:timeout>%s</D:timeout>\n"+
 		"	<D:locktoken><D:href>%s</D:href></D:locktoken>\n"+
 		"	<D:lockroot><D:href>%s</D:href></D:lockroot>\n"+
-		"</D:activelock></D:lockdiscovery></D:prop>",
-		depth, ld.OwnerXML, timeout, escape(token), escape(ld.Root),
+		"</D:activelock>",
+		depth, ld.OwnerXML, timeout, escape(token), escape(rootHref),
 	)
 }
 